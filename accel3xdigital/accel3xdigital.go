@@ -1,6 +1,7 @@
 // Package accel3xdigital allows developers to read x,y,z and acceleratation.
-// Currently this library doesn't support any of the interrupts:  Front/Back Interrupt,
-// Up/Down/Left/Right Interrupt, Tap Detection Interrupt, GINT (real-time motion tracking), Shake on X-axis, Shake on Y-axis, and
+// The GINT interrupt (real-time motion tracking) can be used to read each sample as soon as it is available,
+// see StreamOnInterrupt. Currently this library doesn't support the other interrupts:  Front/Back Interrupt,
+// Up/Down/Left/Right Interrupt, Tap Detection Interrupt, Shake on X-axis, Shake on Y-axis, and
 // Shake on Z-axis.
 package accel3xdigital
 
@@ -5,11 +5,17 @@ import (
 	"time"
 
 	"github.com/goiot/devices/accel3xdigital"
-	"golang.org/x/exp/io/i2c"
+	"github.com/goiot/devices/i2cbus"
 )
 
 func main() {
-	accel, err := accel3xdigital.Open(&i2c.Devfs{Dev: "/dev/i2c-1"})
+	// the bus of the GPIO header, see i2cbus.Buses to list the others
+	bus, err := i2cbus.Open("primary")
+	if err != nil {
+		panic(err)
+	}
+
+	accel, err := accel3xdigital.Open(bus)
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,70 @@
+package accel3xdigital
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/goiot/devices/gpio"
+)
+
+// interruptTimeout is how long StreamOnInterrupt waits for a sample, it
+// is longer than the period of the slowest sample rate.
+const interruptTimeout = 2 * time.Second
+
+// StreamOnInterrupt enables the interrupt the sensor raises after every
+// measurement and updates the state each time the INT output, wired to
+// the given pin, signals new data. fn is called with the new state and
+// streaming stops once it returns false.
+// The INT output is active low and open drain, the pin needs a pull-up and
+// must watch for falling edges. The interrupt is disabled when streaming
+// stops, an error if it cannot be.
+func (a *Accel3xDigital) StreamOnInterrupt(pin gpio.Watcher, fn func(*State) bool) (err error) {
+	defer func() {
+		if derr := a.setInterrupts(0); derr != nil {
+			if err == nil {
+				err = fmt.Errorf("disabling the interrupts failed - %v", derr)
+			} else {
+				err = fmt.Errorf("%v, and disabling the interrupts failed - %v", err, derr)
+			}
+		}
+	}()
+	if err := a.setInterrupts(accelGint); err != nil {
+		return fmt.Errorf("enabling the interrupts failed - %v", err)
+	}
+
+	// reading the state clears a pending interrupt
+	if err := a.Update(); err != nil && err != ErrNotReady {
+		return fmt.Errorf("reading the first sample failed - %v", err)
+	}
+	for {
+		_, err := pin.Wait(interruptTimeout)
+		if err == gpio.ErrTimeout {
+			return fmt.Errorf("no data ready interrupt received, check the INT wiring")
+		}
+		if err != nil {
+			return err
+		}
+		if err := a.Update(); err == ErrNotReady {
+			// the sample was being updated, the next
+			// interrupt brings a fresh one
+			continue
+		} else if err != nil {
+			return fmt.Errorf("reading a sample failed - %v", err)
+		}
+		if !fn(a.State) {
+			return nil
+		}
+	}
+}
+
+// setInterrupts sets the interrupt setup register, which can only be
+// written in standby mode.
+func (a *Accel3xDigital) setInterrupts(v byte) error {
+	if err := a.ChangeMode(StandBy); err != nil {
+		return err
+	}
+	if err := a.Device.Write([]byte{accelIntsu, v}); err != nil {
+		return err
+	}
+	return a.ChangeMode(Active)
+}
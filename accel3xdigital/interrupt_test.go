@@ -0,0 +1,144 @@
+package accel3xdigital
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/gpio"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+type opener struct {
+	w *bytes.Buffer
+}
+
+func (o opener) Open(addr int, tenbit bool) (driver.Conn, error) {
+	return conn(o), nil
+}
+
+type conn struct {
+	w *bytes.Buffer
+}
+
+func (c conn) Tx(w, r []byte) error {
+	c.w.Write(w)
+	for i := range r {
+		r[i] = 0 // a valid sample at rest
+	}
+	return nil
+}
+
+func (conn) Close() error { return nil }
+
+// intPin signals n samples and then times out.
+type intPin struct {
+	n int
+}
+
+func (p *intPin) Read() (int, error) { return 1, nil }
+func (p *intPin) Write(int) error    { return nil }
+func (p *intPin) Close() error       { return nil }
+
+func (p *intPin) Wait(timeout time.Duration) (gpio.Event, error) {
+	if p.n == 0 {
+		return gpio.Event{}, gpio.ErrTimeout
+	}
+	p.n--
+	return gpio.Event{Edge: gpio.FallingEdge, Time: time.Now()}, nil
+}
+
+func TestStreamOnInterrupt(t *testing.T) {
+	w := &bytes.Buffer{}
+	accel, err := Open(opener{w})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Reset()
+
+	var samples int
+	err = accel.StreamOnInterrupt(&intPin{n: 5}, func(s *State) bool {
+		samples++
+		return samples < 3
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if samples != 3 {
+		t.Errorf("got %d samples, want 3", samples)
+	}
+	enable := []byte{accelMode, accelStandBy, accelIntsu, accelGint, accelMode, accelActive}
+	if !bytes.HasPrefix(w.Bytes(), enable) {
+		t.Errorf("got %x, want the GINT interrupt enabled first", w.Bytes())
+	}
+	disable := []byte{accelMode, accelStandBy, accelIntsu, 0, accelMode, accelActive}
+	if !bytes.HasSuffix(w.Bytes(), disable) {
+		t.Errorf("got %x, want the interrupts disabled last", w.Bytes())
+	}
+}
+
+func TestStreamOnInterruptTimeout(t *testing.T) {
+	accel, err := Open(opener{&bytes.Buffer{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = accel.StreamOnInterrupt(&intPin{}, func(s *State) bool { return true })
+	if err == nil {
+		t.Fatal("expected an error when no interrupt is received")
+	}
+}
+
+// failingOpener fails the transfers on which fail returns true.
+type failingOpener struct {
+	fail func(w, r []byte) bool
+}
+
+func (o failingOpener) Open(addr int, tenbit bool) (driver.Conn, error) {
+	return failingConn(o), nil
+}
+
+type failingConn failingOpener
+
+func (c failingConn) Tx(w, r []byte) error {
+	if c.fail(w, r) {
+		return errors.New("no ack")
+	}
+	for i := range r {
+		r[i] = 0
+	}
+	return nil
+}
+
+func (failingConn) Close() error { return nil }
+
+func TestStreamOnInterruptErrors(t *testing.T) {
+	// the reads of the samples fail after samples of them, never if -1
+	samples, failDisable := -1, false
+	accel, err := Open(failingOpener{func(w, r []byte) bool {
+		if len(w) == 0 && len(r) > 0 {
+			if samples == 0 {
+				return true
+			}
+			samples--
+		}
+		return failDisable && bytes.Equal(w, []byte{accelIntsu, 0})
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fn := func(s *State) bool { return true }
+
+	samples = 0
+	if err := accel.StreamOnInterrupt(&intPin{n: 5}, fn); err == nil {
+		t.Error("expected an error when the first sample cannot be read")
+	}
+	samples = 2
+	if err := accel.StreamOnInterrupt(&intPin{n: 5}, fn); err == nil || err.Error() != "reading a sample failed - no ack" {
+		t.Errorf("StreamOnInterrupt() = %v; want the error of the sample read", err)
+	}
+	samples, failDisable = -1, true
+	if err := accel.StreamOnInterrupt(&intPin{n: 1}, func(s *State) bool { return false }); err == nil {
+		t.Error("expected an error when the interrupts cannot be disabled")
+	}
+}
@@ -37,6 +37,8 @@ const (
 	accelSrst  = 0x04
 	accelSpcnt = 0x05
 	accelIntsu = 0x06
+	// automatic interrupt after every measurement
+	accelGint = 0x10
 
 	accelMode    = 0x07
 	accelStandBy = 0x00
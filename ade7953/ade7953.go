@@ -0,0 +1,173 @@
+// Package ade7953 implements a driver for the Analog Devices ADE7953
+// single phase energy metering frontend, found in smart plugs and relays
+// such as the Shelly ones. It measures the voltage and the currents of
+// two channels, one per load, with their active power and energy.
+//
+// The frontend measures the voltages of its inputs, the readings are
+// converted to volts, amperes, watts and watt-hours with the scales of
+// the voltage divider and of the shunts or current transformers of the
+// board, found by measuring a known load.
+package ade7953
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/goiot/devices/energy"
+	"golang.org/x/exp/io/i2c"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+// Addr is the I2C address of the frontend.
+const Addr = 0x38
+
+const (
+	regUnlock   = 0x0FE // 8 bits
+	regSetup    = 0x120 // 16 bits, must be written after the unlock
+	regPFA      = 0x10A // 16 bits, signed, per channel
+	regPeriod   = 0x10E // 16 bits
+	regAWatt    = 0x312 // 32 bits, signed, per channel
+	regIRMSA    = 0x31A // 32 bits, per channel
+	regVRMS     = 0x31C // 32 bits
+	regAEnergyA = 0x31E // 32 bits, signed, per channel, reset when read
+
+	unlock = 0xAD
+	setup  = 0x0030 // optimum settings of the datasheet
+
+	periodClock = 223750 // Hz
+)
+
+// Channel is a current channel.
+type Channel int
+
+const (
+	A Channel = iota
+	B
+)
+
+// Scale is the number of counts of the readings per unit, depending on
+// the board.
+type Scale struct {
+	Voltage float64 // per volt
+	Current float64 // per ampere
+	Power   float64 // per watt
+	Energy  float64 // per watt-hour
+}
+
+// ADE7953 represents an ADE7953. It implements energy.Meter with the
+// channel A. It can be used by multiple goroutines.
+type ADE7953 struct {
+	Device *i2c.Device
+	Scale  Scale
+
+	mu     sync.Mutex
+	energy [2]float64 // Wh of the channels
+}
+
+// Open opens the frontend, whose readings are converted with scale.
+func Open(o driver.Opener, scale Scale) (*ADE7953, error) {
+	if scale.Voltage == 0 || scale.Current == 0 || scale.Power == 0 || scale.Energy == 0 {
+		return nil, errors.New("the scales of the voltage, current, power and energy must be set")
+	}
+	dev, err := i2c.Open(o, Addr)
+	if err != nil {
+		return nil, err
+	}
+	a := &ADE7953{Device: dev, Scale: scale}
+	if err := a.write(regUnlock, []byte{unlock}); err != nil {
+		dev.Close()
+		return nil, fmt.Errorf("initializing the ADE7953 failed - %v", err)
+	}
+	if err := a.write(regSetup, []byte{setup >> 8, setup & 0xFF}); err != nil {
+		dev.Close()
+		return nil, fmt.Errorf("initializing the ADE7953 failed - %v", err)
+	}
+	return a, nil
+}
+
+// write writes b to the register reg, most significant byte first.
+func (a *ADE7953) write(reg uint16, b []byte) error {
+	return a.Device.Write(append([]byte{byte(reg >> 8), byte(reg)}, b...))
+}
+
+// read reads the n bytes of the register reg.
+func (a *ADE7953) read(reg uint16, n int) (uint32, error) {
+	if err := a.Device.Write([]byte{byte(reg >> 8), byte(reg)}); err != nil {
+		return 0, err
+	}
+	b := make([]byte, n)
+	if err := a.Device.Read(b); err != nil {
+		return 0, err
+	}
+	var v uint32
+	for _, c := range b {
+		v = v<<8 | uint32(c)
+	}
+	return v, nil
+}
+
+// Read implements energy.Meter, it reads the channel A.
+func (a *ADE7953) Read() (energy.Measurement, error) {
+	return a.ReadChannel(A)
+}
+
+// ReadChannel reads the voltage and the channel ch. The energy is
+// accumulated by the driver since Open or ResetEnergy: the registers of
+// the frontend overflow after a few hours at full power, read each
+// channel more often.
+func (a *ADE7953) ReadChannel(ch Channel) (energy.Measurement, error) {
+	if ch != A && ch != B {
+		return energy.Measurement{}, fmt.Errorf("invalid channel %d", ch)
+	}
+	off := uint16(ch)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var r [6]uint32
+	for i, reg := range []struct {
+		addr uint16
+		n    int
+	}{
+		{regVRMS, 4},
+		{regIRMSA + off, 4},
+		{regAWatt + off, 4},
+		{regAEnergyA + off, 4},
+		{regPFA + off, 2},
+		{regPeriod, 2},
+	} {
+		v, err := a.read(reg.addr, reg.n)
+		if err != nil {
+			return energy.Measurement{}, fmt.Errorf("reading the ADE7953 failed - %v", err)
+		}
+		r[i] = v
+	}
+	a.energy[ch] += float64(int32(r[3])) / a.Scale.Energy
+	return energy.Measurement{
+		Voltage:     float64(r[0]) / a.Scale.Voltage,
+		Current:     float64(r[1]) / a.Scale.Current,
+		Power:       float64(int32(r[2])) / a.Scale.Power,
+		Energy:      a.energy[ch],
+		Frequency:   periodClock / float64(r[5]+1),
+		PowerFactor: float64(int16(r[4])) / 0x8000,
+	}, nil
+}
+
+// ResetEnergy resets the energy of the channel ch.
+func (a *ADE7953) ResetEnergy(ch Channel) error {
+	if ch != A && ch != B {
+		return fmt.Errorf("invalid channel %d", ch)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	// reading the register resets it
+	if _, err := a.read(regAEnergyA+uint16(ch), 4); err != nil {
+		return err
+	}
+	a.energy[ch] = 0
+	return nil
+}
+
+// Close closes the device.
+func (a *ADE7953) Close() error {
+	return a.Device.Close()
+}
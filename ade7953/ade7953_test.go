@@ -0,0 +1,124 @@
+package ade7953
+
+import (
+	"math"
+	"testing"
+
+	"github.com/goiot/devices/energy"
+	"github.com/goiot/devices/i2csim"
+)
+
+var _ energy.Meter = (*ADE7953)(nil)
+
+// frontend is a fake ADE7953, its registers hold the values of their
+// last bytes written or set.
+type frontend struct {
+	regs     map[uint16][]byte
+	reg      uint16
+	unlocked bool
+}
+
+func (f *frontend) Tx(w, r []byte) error {
+	if len(w) >= 2 {
+		f.reg = uint16(w[0])<<8 | uint16(w[1])
+		if len(w) > 2 {
+			if f.reg == regSetup && !f.unlocked {
+				return nil
+			}
+			f.regs[f.reg] = append([]byte(nil), w[2:]...)
+			f.unlocked = f.reg == regUnlock && w[2] == unlock
+		}
+	}
+	if len(r) > 0 {
+		copy(r, f.regs[f.reg])
+		if f.reg == regAEnergyA || f.reg == regAEnergyA+1 {
+			f.regs[f.reg] = make([]byte, 4)
+		}
+	}
+	return nil
+}
+
+func (f *frontend) set(reg uint16, v int32, n int) {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(v >> uint(8*(n-1-i)))
+	}
+	f.regs[reg] = b
+}
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+
+var scale = Scale{Voltage: 26000, Current: 100000, Power: 130, Energy: 25}
+
+func newFrontend(t *testing.T) (*ADE7953, *frontend) {
+	f := &frontend{regs: map[uint16][]byte{}}
+	bus := i2csim.NewBus()
+	bus.Attach(Addr, f)
+	a, err := Open(bus, scale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a, f
+}
+
+func TestOpen(t *testing.T) {
+	_, f := newFrontend(t)
+	if got := f.regs[regSetup]; len(got) != 2 || got[0] != 0 || got[1] != 0x30 {
+		t.Errorf("setup register = % X; want 00 30", got)
+	}
+	if _, err := Open(i2csim.NewBus(), Scale{Voltage: 1}); err == nil {
+		t.Error("Open() succeeded without scales")
+	}
+}
+
+func TestReadChannel(t *testing.T) {
+	a, f := newFrontend(t)
+	f.set(regVRMS, 230*26000, 4)
+	f.set(regIRMSA, 150000, 4)
+	f.set(regIRMSA+1, 50000, 4)
+	f.set(regAWatt, 130*340, 4)
+	f.set(regAWatt+1, -130*100, 4)
+	f.set(regAEnergyA, 250, 4)
+	f.set(regAEnergyA+1, -50, 4)
+	f.set(regPFA, 0x7000, 2)
+	f.set(regPFA+1, -0x4000, 2)
+	f.set(regPeriod, 4474, 2)
+
+	m, err := a.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := energy.Measurement{Voltage: 230, Current: 1.5, Power: 340, Energy: 10, Frequency: 223750.0 / 4475, PowerFactor: 0.875}
+	if !equal(m, want) {
+		t.Errorf("Read() = %+v; want %+v", m, want)
+	}
+	m, err = a.ReadChannel(B)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = energy.Measurement{Voltage: 230, Current: 0.5, Power: -100, Energy: -2, Frequency: 223750.0 / 4475, PowerFactor: -0.5}
+	if !equal(m, want) {
+		t.Errorf("ReadChannel(B) = %+v; want %+v", m, want)
+	}
+
+	// the energy registers are reset when read, the driver accumulates
+	f.set(regAEnergyA, 125, 4)
+	if m, _ := a.Read(); !near(m.Energy, 15) {
+		t.Errorf("energy = %vWh; want 15Wh", m.Energy)
+	}
+	f.set(regAEnergyA, 125, 4)
+	if err := a.ResetEnergy(A); err != nil {
+		t.Fatal(err)
+	}
+	if m, _ := a.Read(); m.Energy != 0 {
+		t.Errorf("energy = %vWh after ResetEnergy; want 0", m.Energy)
+	}
+	if _, err := a.ReadChannel(2); err == nil {
+		t.Error("ReadChannel(2) succeeded")
+	}
+}
+
+func equal(a, b energy.Measurement) bool {
+	return near(a.Voltage, b.Voltage) && near(a.Current, b.Current) && near(a.Power, b.Power) &&
+		near(a.Energy, b.Energy) && near(a.Frequency, b.Frequency) && near(a.PowerFactor, b.PowerFactor)
+}
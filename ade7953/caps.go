@@ -0,0 +1,23 @@
+package ade7953
+
+import "github.com/goiot/devices/caps"
+
+// Caps are the capabilities of the frontend, the ranges of the readings
+// depend on the board.
+var Caps = caps.Capabilities{
+	Name:      "ADE7953",
+	Bus:       "i2c",
+	Addresses: []int{Addr},
+	Measurements: []caps.Measurement{
+		{Kind: caps.Voltage, Unit: "V"},
+		{Kind: caps.Current, Unit: "A"},
+		{Kind: caps.Power, Unit: "W"},
+		{Kind: caps.Energy, Unit: "Wh"},
+		{Kind: caps.Frequency, Unit: "Hz", Min: 45, Max: 66},
+	},
+}
+
+func init() { caps.Register(Caps) }
+
+// Capabilities implements caps.Describer.
+func (a *ADE7953) Capabilities() caps.Capabilities { return Caps }
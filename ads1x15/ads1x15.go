@@ -0,0 +1,129 @@
+// Package ads1x15 implements a driver for the Texas Instruments ADS1015
+// (12-bit) and ADS1115 (16-bit) 4 channel analog to digital converters.
+// The converters implement analog.ADC with their inputs used single-ended.
+package ads1x15
+
+import (
+	"errors"
+	"time"
+
+	"github.com/goiot/devices/analog"
+	"github.com/goiot/devices/clock"
+	"golang.org/x/exp/io/i2c"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+// Addr is the I2C address of the converter with ADDR connected to GND, it
+// is 0x49, 0x4A or 0x4B with ADDR connected to VDD, SDA or SCL.
+const Addr = 0x48
+
+// Chip is the model of a converter.
+type Chip int
+
+const (
+	ADS1015 Chip = iota
+	ADS1115
+)
+
+// FullScale is the range of the programmable gain amplifier, it is the
+// reference voltage to give to analog.Volts. The inputs must stay between
+// GND and VDD whatever the range is.
+type FullScale int
+
+const (
+	FS6V144 FullScale = iota
+	FS4V096
+	FS2V048
+	FS1V024
+	FS0V512
+	FS0V256
+)
+
+// Volts returns the full scale voltage.
+func (fs FullScale) Volts() float64 {
+	return [...]float64{6.144, 4.096, 2.048, 1.024, 0.512, 0.256}[fs]
+}
+
+const (
+	regConversion = 0x00
+	regConfig     = 0x01
+
+	cfgStart      = 0x8000 // start a conversion, set when idle
+	cfgMuxSingle  = 0x4000 // AINx against GND, x in bits 12-13
+	cfgSingleShot = 0x0100
+	cfgRateMax    = 0x00E0 // 3300SPS on the ADS1015, 860SPS on the ADS1115
+	cfgNoComp     = 0x0003
+)
+
+// ADC represents a converter.
+type ADC struct {
+	Device *i2c.Device
+	// Clock times the polling of the conversions, clock.Real if nil.
+	Clock clock.Clock
+
+	chip Chip
+	fs   FullScale
+}
+
+// Open opens the converter at addr.
+func Open(o driver.Opener, addr int, chip Chip, fs FullScale) (*ADC, error) {
+	dev, err := i2c.Open(o, addr)
+	if err != nil {
+		return nil, err
+	}
+	return &ADC{Device: dev, chip: chip, fs: fs}, nil
+}
+
+// Read runs a single-shot conversion of the channel ch. The result is
+// between 0 and 2^Resolution()-1 over the full scale range.
+func (a *ADC) Read(ch int) (int, error) {
+	if err := analog.CheckChannel(ch, 4); err != nil {
+		return 0, err
+	}
+	cfg := cfgStart | cfgMuxSingle | ch<<12 | int(a.fs)<<9 | cfgSingleShot | cfgRateMax | cfgNoComp
+	if err := a.Device.WriteReg(regConfig, []byte{byte(cfg >> 8), byte(cfg)}); err != nil {
+		return 0, err
+	}
+	b := make([]byte, 2)
+	for i := 0; ; i++ {
+		// a conversion takes 1.2ms at 860SPS
+		clock.Or(a.Clock).Sleep(500 * time.Microsecond)
+		if err := a.Device.ReadReg(regConfig, b); err != nil {
+			return 0, err
+		}
+		if b[0]&(cfgStart>>8) != 0 {
+			break
+		}
+		if i == 10 {
+			return 0, errors.New("conversion timed out")
+		}
+	}
+	if err := a.Device.ReadReg(regConversion, b); err != nil {
+		return 0, err
+	}
+	v := int(int16(uint16(b[0])<<8 | uint16(b[1])))
+	if a.chip == ADS1015 {
+		v >>= 4 // 12-bit result, left aligned
+	}
+	if v < 0 { // slightly below GND
+		v = 0
+	}
+	return v, nil
+}
+
+// Channels returns 4.
+func (a *ADC) Channels() int { return 4 }
+
+// Resolution returns the number of bits of single-ended results, the sign
+// bit of the converter is always 0.
+func (a *ADC) Resolution() int {
+	if a.chip == ADS1015 {
+		return 11
+	}
+	return 15
+}
+
+// Close closes the converter.
+func (a *ADC) Close() error {
+	return a.Device.Close()
+}
@@ -0,0 +1,100 @@
+package ads1x15
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/analog"
+	"github.com/goiot/devices/clock"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+// converter is a fake converter returning the conversion results of
+// the channels.
+type converter struct {
+	results [4]uint16
+	config  uint16
+	reg     byte
+	busy    bool // never finishes the conversions
+}
+
+func (c *converter) Open(addr int, tenbit bool) (driver.Conn, error) { return c, nil }
+func (c *converter) Close() error                                    { return nil }
+
+func (c *converter) Tx(w, r []byte) error {
+	if len(w) > 0 {
+		c.reg = w[0]
+	}
+	if len(w) == 3 {
+		c.config = uint16(w[1])<<8 | uint16(w[2])
+	}
+	if len(r) == 2 {
+		v := c.config | cfgStart // idle
+		if c.busy {
+			v &^= cfgStart
+		}
+		if c.reg == regConversion {
+			v = c.results[c.config>>12&0x03]
+		}
+		r[0], r[1] = byte(v>>8), byte(v)
+	}
+	return nil
+}
+
+func TestRead(t *testing.T) {
+	c := &converter{results: [4]uint16{0x7FF0, 0x4000, 0xFFF0, 0x1230}}
+	a, err := Open(c, Addr, ADS1015, FS4V096)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var _ analog.ADC = a
+
+	for ch, want := range []int{2047, 1024, 0, 0x123} {
+		v, err := a.Read(ch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != want {
+			t.Errorf("channel %d = %d, want %d", ch, v, want)
+		}
+	}
+	if mux, pga := c.config>>12&0x07, c.config>>9&0x07; mux != 0x07 || pga != uint16(FS4V096) {
+		t.Errorf("config = %#x, want AIN3 and 4.096V", c.config)
+	}
+
+	v, err := analog.Volts(a, 1, FS4V096.Volts())
+	if err != nil || v < 2.04 || v > 2.05 {
+		t.Errorf("Volts = %v, %v; want 2.048", v, err)
+	}
+	if _, err := a.Read(4); err == nil {
+		t.Error("expected an error on channel 4")
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	c := &converter{busy: true}
+	a, err := Open(c, Addr, ADS1115, FS4V096)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	clk.SetAutoSleep(true)
+	a.Clock = clk
+	if _, err := a.Read(0); err == nil {
+		t.Fatal("expected a timeout")
+	}
+	if d := clk.Now().Sub(start); d != 11*500*time.Microsecond {
+		t.Errorf("gave up after %v, want 5.5ms", d)
+	}
+}
+
+func TestCaps(t *testing.T) {
+	m := ADS1115.Caps(FS4V096).Measurements[0]
+	if m.Max != 4.096 || m.Resolution != 0.000125 || m.Channels != 4 {
+		t.Errorf("ADS1115 at 4.096V: %+v; want a 125µV resolution up to 4.096V on 4 channels", m)
+	}
+	if m := ADS1015.Caps(FS6V144).Measurements[0]; m.Resolution != 0.003 {
+		t.Errorf("ADS1015 resolution at 6.144V = %v; want 3mV", m.Resolution)
+	}
+}
@@ -0,0 +1,41 @@
+package ads1x15
+
+import "github.com/goiot/devices/caps"
+
+func init() {
+	caps.Register(ADS1015.Caps(FS6V144))
+	caps.Register(ADS1115.Caps(FS6V144))
+}
+
+func (c Chip) String() string {
+	if c == ADS1115 {
+		return "ADS1115"
+	}
+	return "ADS1015"
+}
+
+// Caps returns the capabilities of the converter with the range fs, the
+// inputs being single-ended.
+func (c Chip) Caps(fs FullScale) caps.Capabilities {
+	codes := 1 << 12
+	if c == ADS1115 {
+		codes = 1 << 16
+	}
+	return caps.Capabilities{
+		Name:      c.String(),
+		Bus:       "i2c",
+		Addresses: []int{Addr, Addr + 1, Addr + 2, Addr + 3},
+		Measurements: []caps.Measurement{{
+			Kind:       caps.Voltage,
+			Unit:       "V",
+			Min:        0,
+			Max:        fs.Volts(),
+			Resolution: 2 * fs.Volts() / float64(codes),
+			Channels:   4,
+		}},
+		PowerModes: []string{"power-down", "single-shot", "continuous"},
+	}
+}
+
+// Capabilities implements caps.Describer.
+func (a *ADC) Capabilities() caps.Capabilities { return a.chip.Caps(a.fs) }
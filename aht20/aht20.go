@@ -0,0 +1,167 @@
+// Package aht20 implements a driver for the Aosong AHT20 and AHT10
+// temperature and humidity sensors.
+package aht20
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"golang.org/x/exp/io/i2c"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+const (
+	addr = 0x38
+
+	statusBusy       = 0x80
+	statusCalibrated = 0x08
+)
+
+var (
+	initAHT20 = []byte{0xBE, 0x08, 0x00}
+	initAHT10 = []byte{0xE1, 0x08, 0x00}
+	trigger   = []byte{0xAC, 0x33, 0x00}
+	softReset = []byte{0xBA}
+)
+
+// Measurement is the result of a measurement.
+type Measurement struct {
+	Temperature float64 // Temperature in degrees Celsius.
+	Humidity    float64 // Humidity in percent of relative humidity.
+}
+
+// AHT20 represents an AHT20 or AHT10 sensor.
+type AHT20 struct {
+	Device *i2c.Device
+	// Clock times the waits of the measurements, clock.Real if nil.
+	Clock clock.Clock
+
+	aht10 bool
+}
+
+// Open opens an AHT20 sensor, and calibrates it if needed.
+func Open(o driver.Opener) (*AHT20, error) {
+	return open(o, false)
+}
+
+// OpenAHT10 opens an AHT10 sensor, which has another calibration command
+// and no CRC.
+func OpenAHT10(o driver.Opener) (*AHT20, error) {
+	return open(o, true)
+}
+
+func open(o driver.Opener, aht10 bool) (*AHT20, error) {
+	dev, err := i2c.Open(o, addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &AHT20{Device: dev, aht10: aht10}
+	if err := s.init(); err != nil {
+		dev.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *AHT20) status() (byte, error) {
+	b := make([]byte, 1)
+	if err := s.Device.Read(b); err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (s *AHT20) init() error {
+	st, err := s.status()
+	if err != nil {
+		return err
+	}
+	if st&statusCalibrated != 0 {
+		return nil
+	}
+	cmd := initAHT20
+	if s.aht10 {
+		cmd = initAHT10
+	}
+	if err := s.Device.Write(cmd); err != nil {
+		return fmt.Errorf("calibrating the sensor failed - %v", err)
+	}
+	clock.Or(s.Clock).Sleep(10 * time.Millisecond)
+	if st, err = s.status(); err != nil {
+		return err
+	}
+	if st&statusCalibrated == 0 {
+		return errors.New("the sensor is not calibrated")
+	}
+	return nil
+}
+
+// crc8 is the CRC of the measurements, polynomial x^8+x^5+x^4+1 from
+// 0xFF.
+func crc8(b []byte) byte {
+	crc := byte(0xFF)
+	for _, v := range b {
+		crc ^= v
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x31
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// Read runs a measurement, which takes 80ms.
+func (s *AHT20) Read() (Measurement, error) {
+	if err := s.Device.Write(trigger); err != nil {
+		return Measurement{}, err
+	}
+	n := 7 // status, 20 bits of humidity, 20 bits of temperature, CRC
+	if s.aht10 {
+		n = 6
+	}
+	b := make([]byte, n)
+	c := clock.Or(s.Clock)
+	c.Sleep(80 * time.Millisecond)
+	for i := 0; ; i++ {
+		if err := s.Device.Read(b); err != nil {
+			return Measurement{}, err
+		}
+		if b[0]&statusBusy == 0 {
+			break
+		}
+		if i == 10 {
+			return Measurement{}, errors.New("measurement timed out")
+		}
+		c.Sleep(10 * time.Millisecond)
+	}
+	if !s.aht10 {
+		if crc := crc8(b[:6]); crc != b[6] {
+			return Measurement{}, fmt.Errorf("invalid CRC %#02x, want %#02x", b[6], crc)
+		}
+	}
+	h := uint32(b[1])<<12 | uint32(b[2])<<4 | uint32(b[3])>>4
+	t := uint32(b[3]&0x0F)<<16 | uint32(b[4])<<8 | uint32(b[5])
+	return Measurement{
+		Temperature: float64(t)/(1<<20)*200 - 50,
+		Humidity:    float64(h) / (1 << 20) * 100,
+	}, nil
+}
+
+// Reset resets the sensor, it has to be opened again.
+func (s *AHT20) Reset() error {
+	if err := s.Device.Write(softReset); err != nil {
+		return err
+	}
+	clock.Or(s.Clock).Sleep(20 * time.Millisecond)
+	return nil
+}
+
+// Close closes the sensor.
+func (s *AHT20) Close() error {
+	return s.Device.Close()
+}
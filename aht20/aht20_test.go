@@ -0,0 +1,100 @@
+package aht20
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/i2csim"
+)
+
+// sensor is a fake AHT20, busy for the first reads after a trigger.
+type sensor struct {
+	status byte
+	data   []byte // humidity and temperature
+	busy   int
+	aht10  bool
+
+	corrupt bool // the CRC is wrong
+	stuck   bool // the sensor stays busy
+}
+
+func (s *sensor) Tx(w, r []byte) error {
+	switch {
+	case bytes.Equal(w, initAHT20) && !s.aht10, bytes.Equal(w, initAHT10) && s.aht10:
+		s.status |= statusCalibrated
+	case bytes.Equal(w, trigger):
+		s.busy = 2
+	}
+	if len(r) == 0 {
+		return nil
+	}
+	st := s.status
+	if s.busy > 0 || s.stuck {
+		st |= statusBusy
+		s.busy--
+	}
+	b := append([]byte{st}, s.data...)
+	b = append(b, crc8(b))
+	if s.corrupt {
+		b[len(b)-1]++
+	}
+	copy(r, b)
+	return nil
+}
+
+func newSensor(t *testing.T, s *sensor) *AHT20 {
+	bus := i2csim.NewBus()
+	bus.Attach(addr, s)
+	open := Open
+	if s.aht10 {
+		open = OpenAHT10
+	}
+	dev, err := open(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := clock.NewFake(time.Time{})
+	c.SetAutoSleep(true)
+	dev.Clock = c
+	return dev
+}
+
+func TestCRC(t *testing.T) {
+	if crc := crc8([]byte("123456789")); crc != 0xF7 {
+		t.Errorf("crc8 = %#02x; want 0xF7", crc)
+	}
+}
+
+func TestRead(t *testing.T) {
+	for _, aht10 := range []bool{false, true} {
+		// 50% and 25°C.
+		s := &sensor{data: []byte{0x80, 0x00, 0x06, 0x00, 0x00}, aht10: aht10}
+		dev := newSensor(t, s)
+		if s.status&statusCalibrated == 0 {
+			t.Fatal("the sensor is not calibrated by Open")
+		}
+		m, err := dev.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(m.Humidity-50) > 1e-9 || math.Abs(m.Temperature-25) > 1e-9 {
+			t.Errorf("Read = %+v; want 25°C and 50%%", m)
+		}
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	s := &sensor{data: make([]byte, 5)}
+	dev := newSensor(t, s)
+	s.corrupt = true
+	if _, err := dev.Read(); err == nil {
+		t.Error("expected an error on an invalid CRC")
+	}
+	s.corrupt, s.stuck = false, true
+	if _, err := dev.Read(); err == nil {
+		t.Error("expected an error while the sensor stays busy")
+	}
+}
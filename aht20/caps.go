@@ -0,0 +1,25 @@
+package aht20
+
+import "github.com/goiot/devices/caps"
+
+// Caps are the capabilities of the sensor.
+var Caps = caps.Capabilities{
+	Name:      "AHT20",
+	Bus:       "i2c",
+	Addresses: []int{addr},
+	Measurements: []caps.Measurement{
+		{Kind: caps.Temperature, Unit: "C", Min: -40, Max: 85, Resolution: 0.01},
+		{Kind: caps.Humidity, Unit: "%", Min: 0, Max: 100, Resolution: 0.024},
+	},
+}
+
+func init() { caps.Register(Caps) }
+
+// Capabilities implements caps.Describer.
+func (s *AHT20) Capabilities() caps.Capabilities {
+	c := Caps
+	if s.aht10 {
+		c.Name = "AHT10"
+	}
+	return c
+}
@@ -0,0 +1,228 @@
+// Package alerts raises alerts when sensor readings cross thresholds, e.g.
+// "sound the buzzer when CO2 is above 1500ppm", with hysteresis and
+// debouncing so noisy readings don't make alerts flap.
+package alerts
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/goiot/devices/gpio"
+)
+
+// Kind is the kind of condition of an alert.
+type Kind int
+
+const (
+	// Above is raised when the value is above the threshold.
+	Above Kind = iota
+	// Below is raised when the value is below the threshold.
+	Below
+	// RateAbove is raised when the value changes faster than the
+	// threshold, in units per second, in either direction.
+	RateAbove
+)
+
+// Event is passed to the actions when an alert is raised or cleared.
+type Event struct {
+	Alert  *Alert
+	Active bool // Active is true when the alert is raised, false when cleared.
+	Value  float64
+	Time   time.Time
+}
+
+func (e Event) String() string {
+	state := "cleared"
+	if e.Active {
+		state = "raised"
+	}
+	return fmt.Sprintf("%v %v at %v (value %v)", e.Alert.Name, state, e.Time.Format(time.RFC3339), e.Value)
+}
+
+// Action is run when an alert is raised and when it is cleared.
+type Action func(e Event) error
+
+// Alert is a condition on a stream of readings.
+type Alert struct {
+	Name      string
+	Kind      Kind
+	Threshold float64
+
+	// Hysteresis is how far back past the threshold the value needs to
+	// go for the alert to clear.
+	Hysteresis float64
+
+	// For is how long the condition needs to hold before the alert is
+	// raised, and how long it needs to be false before it is cleared.
+	For time.Duration
+
+	// Actions are run in order when the alert changes state.
+	Actions []Action
+
+	mu      sync.Mutex
+	active  bool
+	since   time.Time // when the condition started to disagree with active
+	pending bool
+	last    float64
+	lastT   time.Time
+}
+
+// Active reports whether the alert is raised.
+func (a *Alert) Active() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.active
+}
+
+// Observe evaluates the alert with a new reading taken at t, and runs
+// the actions if the alert is raised or cleared. All the actions are run
+// and the first error is returned.
+func (a *Alert) Observe(v float64, t time.Time) error {
+	a.mu.Lock()
+	changed := a.update(v, t)
+	active := a.active
+	a.mu.Unlock()
+	if !changed {
+		return nil
+	}
+	e := Event{Alert: a, Active: active, Value: v, Time: t}
+	var first error
+	for _, act := range a.Actions {
+		if err := act(e); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+func (a *Alert) update(v float64, t time.Time) bool {
+	x := v
+	if a.Kind == RateAbove {
+		prev, prevT := a.last, a.lastT
+		a.last, a.lastT = v, t
+		dt := t.Sub(prevT).Seconds()
+		if prevT.IsZero() || dt <= 0 {
+			return false
+		}
+		x = (v - prev) / dt
+		if x < 0 {
+			x = -x
+		}
+	}
+
+	var flip bool
+	switch {
+	case a.Kind == Below && !a.active:
+		flip = x < a.Threshold
+	case a.Kind == Below:
+		flip = x > a.Threshold+a.Hysteresis
+	case !a.active:
+		flip = x > a.Threshold
+	default:
+		flip = x < a.Threshold-a.Hysteresis
+	}
+	if !flip {
+		a.pending = false
+		return false
+	}
+	if !a.pending {
+		a.pending, a.since = true, t
+	}
+	if t.Sub(a.since) < a.For {
+		return false
+	}
+	a.active = !a.active
+	a.pending = false
+	return true
+}
+
+// Monitor dispatches the readings of named streams to their alerts.
+// It can be used by multiple goroutines.
+type Monitor struct {
+	mu     sync.Mutex
+	alerts map[string][]*Alert
+}
+
+// NewMonitor returns a monitor without alerts.
+func NewMonitor() *Monitor {
+	return &Monitor{alerts: make(map[string][]*Alert)}
+}
+
+// Add adds alerts on the stream, e.g. "co2" or "bedroom/temperature".
+func (m *Monitor) Add(stream string, alerts ...*Alert) {
+	m.mu.Lock()
+	m.alerts[stream] = append(m.alerts[stream], alerts...)
+	m.mu.Unlock()
+}
+
+// Observe evaluates the alerts of the stream with a new reading.
+func (m *Monitor) Observe(stream string, v float64, t time.Time) error {
+	m.mu.Lock()
+	alerts := m.alerts[stream]
+	m.mu.Unlock()
+	var first error
+	for _, a := range alerts {
+		if err := a.Observe(v, t); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Active returns the raised alerts.
+func (m *Monitor) Active() []*Alert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var active []*Alert
+	for _, alerts := range m.alerts {
+		for _, a := range alerts {
+			if a.Active() {
+				active = append(active, a)
+			}
+		}
+	}
+	return active
+}
+
+// Pin returns an action driving p high while the alert is raised, e.g.
+// to sound a buzzer or turn on a fan through a relay.
+func Pin(p gpio.Pin) Action {
+	return func(e Event) error {
+		v := 0
+		if e.Active {
+			v = 1
+		}
+		return p.Write(v)
+	}
+}
+
+// Switch returns an action calling on when the alert is raised and off
+// when it is cleared; either can be nil. It adapts driver methods such as
+// Inverse and Normal of a display.
+func Switch(on, off func() error) Action {
+	return func(e Event) error {
+		f := off
+		if e.Active {
+			f = on
+		}
+		if f == nil {
+			return nil
+		}
+		return f()
+	}
+}
+
+// Notify returns an action sending the events on c without blocking,
+// events are dropped if c is full.
+func Notify(c chan<- Event) Action {
+	return func(e Event) error {
+		select {
+		case c <- e:
+			return nil
+		default:
+			return errors.New("notification channel is full")
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package alerts
+
+import (
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2016, 3, 4, 10, 0, 0, 0, time.UTC)
+
+func at(s int) time.Time { return t0.Add(time.Duration(s) * time.Second) }
+
+func TestHysteresis(t *testing.T) {
+	var events []Event
+	a := &Alert{
+		Name:       "co2",
+		Kind:       Above,
+		Threshold:  1500,
+		Hysteresis: 100,
+		Actions:    []Action{func(e Event) error { events = append(events, e); return nil }},
+	}
+	for i, v := range []float64{1200, 1510, 1450, 1501, 1390, 1380} {
+		a.Observe(v, at(i))
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2: %v", len(events), events)
+	}
+	if !events[0].Active || events[0].Value != 1510 {
+		t.Errorf("first event = %v, want raised at 1510", events[0])
+	}
+	if events[1].Active || events[1].Value != 1390 {
+		t.Errorf("second event = %v, want cleared at 1390", events[1])
+	}
+}
+
+func TestDebounce(t *testing.T) {
+	a := &Alert{Name: "cold", Kind: Below, Threshold: 5, For: 2 * time.Second}
+	a.Observe(4, at(0))
+	a.Observe(6, at(1)) // back above, the timer restarts
+	a.Observe(4, at(2))
+	a.Observe(4, at(3))
+	if a.Active() {
+		t.Fatal("alert raised before the condition held for 2s")
+	}
+	a.Observe(3, at(4))
+	if !a.Active() {
+		t.Fatal("alert should be raised")
+	}
+}
+
+func TestRate(t *testing.T) {
+	a := &Alert{Name: "pressure drop", Kind: RateAbove, Threshold: 1}
+	a.Observe(1013, at(0))
+	a.Observe(1012.5, at(1))
+	if a.Active() {
+		t.Fatal("rate of 0.5/s should not raise the alert")
+	}
+	a.Observe(1010, at(2))
+	if !a.Active() {
+		t.Fatal("rate of 2.5/s should raise the alert")
+	}
+}
+
+func TestMonitor(t *testing.T) {
+	m := NewMonitor()
+	c := make(chan Event, 1)
+	m.Add("temperature", &Alert{Name: "hot", Threshold: 28, Actions: []Action{Notify(c)}})
+	m.Observe("humidity", 90, at(0))
+	m.Observe("temperature", 30, at(0))
+	if e := <-c; e.Alert.Name != "hot" || !e.Active {
+		t.Errorf("got %v, want hot raised", e)
+	}
+	if n := len(m.Active()); n != 1 {
+		t.Errorf("got %d active alerts, want 1", n)
+	}
+}
@@ -0,0 +1,51 @@
+// Package analog defines the interfaces implemented by analog to digital
+// and digital to analog converters, so that drivers of analog sensors can
+// read from any of them.
+package analog
+
+import "fmt"
+
+// ADC is an analog to digital converter with one or more input channels.
+type ADC interface {
+	// Read returns the raw conversion result of the channel ch,
+	// between 0 and 2^Resolution()-1.
+	Read(ch int) (int, error)
+
+	// Channels returns the number of input channels.
+	Channels() int
+
+	// Resolution returns the number of bits of a conversion result.
+	Resolution() int
+}
+
+// DAC is a digital to analog converter with one or more output channels.
+type DAC interface {
+	// Write sets the raw output value of the channel ch,
+	// between 0 and 2^Resolution()-1.
+	Write(ch int, v int) error
+
+	// Channels returns the number of output channels.
+	Channels() int
+
+	// Resolution returns the number of bits of an output value.
+	Resolution() int
+}
+
+// Volts reads the channel ch of a and converts the result to volts,
+// given the reference voltage of the converter.
+func Volts(a ADC, ch int, vref float64) (float64, error) {
+	v, err := a.Read(ch)
+	if err != nil {
+		return 0, err
+	}
+	return float64(v) * vref / float64(int(1)<<uint(a.Resolution())-1), nil
+}
+
+// CheckChannel returns an error if ch is not a valid channel of a
+// converter with n channels.
+func CheckChannel(ch, n int) error {
+	if ch < 0 || ch >= n {
+		return fmt.Errorf("channel %d is out of range, the converter has %d channels", ch, n)
+	}
+	return nil
+}
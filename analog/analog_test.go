@@ -0,0 +1,22 @@
+package analog
+
+import "testing"
+
+type adc int
+
+func (a adc) Read(ch int) (int, error) { return int(a), nil }
+func (adc) Channels() int              { return 1 }
+func (adc) Resolution() int            { return 10 }
+
+func TestVolts(t *testing.T) {
+	v, err := Volts(adc(1023), 0, 3.3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != 3.3 {
+		t.Errorf("got %v, want 3.3", v)
+	}
+	if v, _ := Volts(adc(0), 0, 3.3); v != 0 {
+		t.Errorf("got %v, want 0", v)
+	}
+}
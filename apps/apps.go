@@ -0,0 +1,113 @@
+// Package apps contains ready to use screens for small displays: a clock,
+// the system statistics and the weather measured by a sensor.
+package apps
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/display"
+	"github.com/goiot/devices/text"
+)
+
+// Screen is a screen of an app.
+type Screen interface {
+	// Draw draws the screen on dst, which is cleared to black.
+	Draw(dst draw.Image) error
+}
+
+// Run shows the screens on d, redrawn every second. With several screens,
+// it switches to the next screen every interval. It returns when ctx is done
+// or when a screen or the display fails.
+func Run(ctx context.Context, d display.Display, interval time.Duration, screens ...Screen) error {
+	return RunWithClock(ctx, nil, d, interval, screens...)
+}
+
+// RunWithClock is Run timed by c, clock.Real if nil.
+func RunWithClock(ctx context.Context, c clock.Clock, d display.Display, interval time.Duration, screens ...Screen) error {
+	if len(screens) == 0 {
+		return errors.New("no screen to show")
+	}
+	c = clock.Or(c)
+	t := c.NewTicker(time.Second)
+	defer t.Stop()
+	i, shown := 0, c.Now()
+	for {
+		draw.Draw(d, d.Bounds(), image.Black, image.Point{}, draw.Src)
+		if err := screens[i].Draw(d); err != nil {
+			return err
+		}
+		if err := d.Draw(); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-t.C():
+			if interval > 0 && now.Sub(shown) >= interval {
+				i, shown = (i+1)%len(screens), now
+			}
+		}
+	}
+}
+
+func fg(c color.Color) color.Color {
+	if c == nil {
+		return color.White
+	}
+	return c
+}
+
+// centered draws s at the given scale, horizontally centered in r at y.
+func centered(dst draw.Image, r image.Rectangle, y int, s string, c color.Color, scale int) {
+	w := text.Default.Size(s, scale).X
+	text.Default.Draw(dst, r.Min.X+(r.Dx()-w)/2, y, s, c, scale)
+}
+
+// lines draws one line of text per row of the font from the top of r,
+// stopping at the bottom of r.
+func lines(dst draw.Image, r image.Rectangle, c color.Color, ls ...string) {
+	y := r.Min.Y
+	for _, l := range ls {
+		if y+text.Default.Height > r.Max.Y {
+			return
+		}
+		text.Default.Draw(dst, r.Min.X, y, l, c, 1)
+		y += text.Default.Height
+	}
+}
+
+// line draws a line from x0, y0 to x1, y1 with Bresenham's algorithm.
+func line(dst draw.Image, x0, y0, x1, y1 int, c color.Color) {
+	dx, sx := x1-x0, 1
+	if dx < 0 {
+		dx, sx = -dx, -1
+	}
+	dy, sy := y1-y0, 1
+	if dy > 0 {
+		dy = -dy
+	} else {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		dst.Set(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
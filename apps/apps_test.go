@@ -0,0 +1,246 @@
+package apps
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/draw"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/bme280"
+	"github.com/goiot/devices/clock"
+)
+
+type screen struct {
+	*image.Gray
+	draws int
+	err   error
+}
+
+func (s *screen) Draw() error { s.draws++; return s.err }
+
+func newScreen(w, h int) *screen { return &screen{Gray: image.NewGray(image.Rect(0, 0, w, h))} }
+
+func lit(img *image.Gray, r image.Rectangle) int {
+	n := 0
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if img.GrayAt(x, y).Y != 0 {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+type counter struct{ n int }
+
+func (c *counter) Draw(dst draw.Image) error { c.n++; return nil }
+
+// signal sends its name on c when drawn.
+type signal struct {
+	c    chan string
+	name string
+}
+
+func (s signal) Draw(dst draw.Image) error { s.c <- s.name; return nil }
+
+func TestRun(t *testing.T) {
+	d := newScreen(8, 8)
+	a, b := &counter{}, &counter{}
+	ctx, cancel := context.WithTimeout(context.Background(), 2500*time.Millisecond)
+	defer cancel()
+	if err := Run(ctx, d, time.Second, a, b); err != context.DeadlineExceeded {
+		t.Fatalf("Run = %v; want %v", err, context.DeadlineExceeded)
+	}
+	if d.draws != 3 || a.n+b.n != 3 || a.n == 0 || b.n == 0 {
+		t.Errorf("%d display draws, screens drawn %d and %d times; want 3 draws of both screens", d.draws, a.n, b.n)
+	}
+
+	// timed by a fake clock, switching every 2s
+	c := clock.NewFake(time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC))
+	drawn := make(chan string, 10)
+	ctx, cancel = context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- RunWithClock(ctx, c, d, 2*time.Second, signal{drawn, "a"}, signal{drawn, "b"})
+	}()
+	var got []string
+	for i := 0; i < 4; i++ {
+		got = append(got, <-drawn)
+		c.Advance(time.Second)
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("RunWithClock = %v; want %v", err, context.Canceled)
+	}
+	if strings.Join(got, "") != "aabb" {
+		t.Errorf("screens drawn %q; want aabb", got)
+	}
+
+	d.err = errors.New("bus error")
+	if err := Run(context.Background(), d, 0, a); err != d.err {
+		t.Errorf("Run = %v; want %v", err, d.err)
+	}
+	if err := Run(context.Background(), d, 0); err == nil {
+		t.Error("Run without screen succeeded")
+	}
+}
+
+func TestClock(t *testing.T) {
+	now := time.Date(2024, 5, 17, 3, 0, 30, 0, time.UTC)
+	c := &Clock{Now: func() (time.Time, error) { return now, nil }, Location: time.UTC}
+
+	d := newScreen(128, 32)
+	if err := c.Draw(d); err != nil {
+		t.Fatal(err)
+	}
+	// "03:00" at scale 3 is 120x24 centered above the date line.
+	if n := lit(d.Gray, image.Rect(4, 0, 124, 24)); n == 0 {
+		t.Error("digital face: the time is not drawn")
+	}
+	if n := lit(d.Gray, image.Rect(0, 24, 128, 32)); n == 0 {
+		t.Error("digital face: the date is not drawn")
+	}
+
+	c.Face = Analog
+	d = newScreen(64, 64)
+	c.Draw(d)
+	// At 3:00 the hour hand points to the right and there is no hand on the
+	// left.
+	if n := lit(d.Gray, image.Rect(33, 31, 44, 33)); n < 8 {
+		t.Errorf("analog face: the hour hand lit %d pixels", n)
+	}
+	if n := lit(d.Gray, image.Rect(12, 28, 30, 36)); n != 0 {
+		t.Errorf("analog face: %d pixels lit left of the center", n)
+	}
+
+	c.Face, c.Seconds = Binary, true
+	d = newScreen(48, 32)
+	c.Draw(d)
+	// 03:00:30, the cells are 8x8: the unset bits of the tens of hours are
+	// outlined and the units of hours have their two low bits set.
+	for i := 0; i < 4; i++ {
+		if n := lit(d.Gray, image.Rect(2, 8*i+2, 6, 8*i+6)); n != 0 {
+			t.Errorf("binary face: tens of hours bit %d lit %d pixels inside the outline", 3-i, n)
+		}
+	}
+	if n := lit(d.Gray, image.Rect(8, 16, 16, 32)); n != 72 {
+		t.Errorf("binary face: the two low bits of 3 lit %d pixels; want 72", n)
+	}
+
+	c.Now = func() (time.Time, error) { return time.Time{}, errors.New("rtc error") }
+	if err := c.Draw(d); err == nil {
+		t.Error("Draw succeeded with a failing time source")
+	}
+}
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for name, data := range files {
+		p := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"proc/stat":                            "cpu  100 0 100 700 100 0 0 0 0 0\ncpu0 100 0 100 700 100 0 0 0 0 0\n",
+		"proc/meminfo":                         "MemTotal:        1024000 kB\nMemFree:          100000 kB\nMemAvailable:     512000 kB\n",
+		"proc/net/dev":                         "Inter-|   Receive\n face |bytes packets\n    lo: 10 1 0 0 0 0 0 0 10 1 0 0 0 0 0 0\n",
+		"sys/class/thermal/thermal_zone0/temp": "48312\n",
+	})
+	s := &Stats{Interface: "lo", proc: filepath.Join(root, "proc"), sys: filepath.Join(root, "sys")}
+
+	if got, want := s.cpuLoad(), "20%"; got != want {
+		t.Errorf("CPU load since boot = %q; want %q", got, want)
+	}
+	writeFiles(t, root, map[string]string{"proc/stat": "cpu  150 0 100 750 100 0 0 0 0 0\n"})
+	if got, want := s.cpuLoad(), "50%"; got != want {
+		t.Errorf("CPU load = %q; want %q", got, want)
+	}
+	if got, want := s.temperature(), "48.3C"; got != want {
+		t.Errorf("temperature = %q; want %q", got, want)
+	}
+	if got, want := s.memory(), "500/1000M"; got != want {
+		t.Errorf("memory = %q; want %q", got, want)
+	}
+
+	start := time.Now()
+	if got := s.traffic(start); got != "-" {
+		t.Errorf("traffic on the first draw = %q; want -", got)
+	}
+	s.readTime = start
+	writeFiles(t, root, map[string]string{
+		"proc/net/dev": "    lo: 4106 2 0 0 0 0 0 0 522 2 0 0 0 0 0 0\n",
+	})
+	if got, want := s.traffic(start.Add(2*time.Second)), "2.0k/256"; got != want {
+		t.Errorf("traffic = %q; want %q", got, want)
+	}
+
+	d := newScreen(128, 64)
+	if err := s.Draw(d); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if lit(d.Gray, image.Rect(0, 8*i, 128, 8*i+8)) == 0 {
+			t.Errorf("line %d is not drawn", i)
+		}
+	}
+
+	s = &Stats{Interface: "missing0", proc: filepath.Join(root, "missing")}
+	for name, got := range map[string]string{"ip": s.ip(), "cpu": s.cpuLoad(), "memory": s.memory()} {
+		if got != "-" {
+			t.Errorf("%s without statistics = %q; want -", name, got)
+		}
+	}
+}
+
+func TestWeather(t *testing.T) {
+	m := bme280.Measurement{Temperature: 21.5, Pressure: 1013, Humidity: 45}
+	c := clock.NewFake(time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC))
+	w := &Weather{
+		Read:  func() (bme280.Measurement, error) { return m, nil },
+		Clock: c,
+	}
+	d := newScreen(128, 32)
+	if err := w.Draw(d); err != nil {
+		t.Fatal(err)
+	}
+	// "21.5C" at scale 3 above "45% 1013hPa", without a trend yet.
+	if lit(d.Gray, image.Rect(0, 0, 128, 24)) == 0 || lit(d.Gray, image.Rect(0, 24, 88, 32)) == 0 {
+		t.Error("the measurement is not drawn")
+	}
+	arrow := image.Rect(89, 24, 96, 32)
+	if n := lit(d.Gray, arrow); n != 0 {
+		t.Errorf("trend drawn without history: %d pixels", n)
+	}
+
+	c.Advance(trendPeriod)
+	m.Pressure = 1010
+	d = newScreen(128, 32)
+	w.Draw(d)
+	// The pressure fell: the tip of the arrow is at the bottom.
+	if d.GrayAt(92, 30).Y == 0 || d.GrayAt(89, 27).Y == 0 || d.GrayAt(89, 28).Y != 0 {
+		t.Error("falling trend is not drawn")
+	}
+
+	w.Fahrenheit = true
+	if err := w.Draw(newScreen(128, 32)); err != nil {
+		t.Error(err)
+	}
+	w.Read = func() (bme280.Measurement, error) { return m, errors.New("i2c error") }
+	if err := w.Draw(d); err == nil || !strings.Contains(err.Error(), "i2c error") {
+		t.Errorf("Draw = %v; want the sensor error", err)
+	}
+}
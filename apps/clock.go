@@ -0,0 +1,152 @@
+package apps
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/text"
+)
+
+// Face is the face of a clock.
+type Face int
+
+// Faces of the clock.
+const (
+	Digital Face = iota // large digits above the date
+	Analog              // dial with hands
+	Binary              // binary coded decimal digits, one column each
+)
+
+// Clock shows the time. RTC drivers can be the time source, the system
+// clock, usually synchronized by NTP, is used otherwise.
+type Clock struct {
+	Face     Face
+	Seconds  bool                      // show the seconds
+	Now      func() (time.Time, error) // time source, Clock if nil
+	Clock    clock.Clock               // system clock, clock.Real if nil
+	Location *time.Location            // time zone, time.Local if nil
+	Color    color.Color               // white if nil
+}
+
+// Draw implements Screen.
+func (c *Clock) Draw(dst draw.Image) error {
+	now := clock.Or(c.Clock).Now()
+	if c.Now != nil {
+		var err error
+		if now, err = c.Now(); err != nil {
+			return fmt.Errorf("reading the time failed - %v", err)
+		}
+	}
+	if c.Location != nil {
+		now = now.In(c.Location)
+	} else {
+		now = now.Local()
+	}
+	switch c.Face {
+	case Analog:
+		c.analog(dst, now)
+	case Binary:
+		c.binary(dst, now)
+	default:
+		c.digital(dst, now)
+	}
+	return nil
+}
+
+func (c *Clock) digital(dst draw.Image, now time.Time) {
+	r := dst.Bounds()
+	layout := "15:04"
+	if c.Seconds {
+		layout = "15:04:05"
+	}
+	s := now.Format(layout)
+	h := r.Dy()
+	date := now.Format("Mon 02 Jan 2006")
+	if h >= 3*text.Default.Height {
+		h -= text.Default.Height
+	} else {
+		date = ""
+	}
+	scale := text.Default.Fit(s, r.Dx(), h)
+	if scale < 1 {
+		scale = 1
+	}
+	th := text.Default.Size(s, scale).Y
+	centered(dst, r, r.Min.Y+(h-th)/2, s, fg(c.Color), scale)
+	if date != "" {
+		centered(dst, r, r.Max.Y-text.Default.Height, date, fg(c.Color), 1)
+	}
+}
+
+func (c *Clock) analog(dst draw.Image, now time.Time) {
+	r := dst.Bounds()
+	cx, cy := r.Min.X+r.Dx()/2, r.Min.Y+r.Dy()/2
+	radius := r.Dx()
+	if r.Dy() < radius {
+		radius = r.Dy()
+	}
+	radius = radius/2 - 1
+	col := fg(c.Color)
+	// point returns the point at the fraction f of a turn and the fraction
+	// l of the radius.
+	point := func(f, l float64) (int, int) {
+		a := 2 * math.Pi * f
+		x := float64(radius) * l * math.Sin(a)
+		y := float64(radius) * l * math.Cos(a)
+		return cx + int(math.Round(x)), cy - int(math.Round(y))
+	}
+	for i := 0; i < 60; i++ {
+		x, y := point(float64(i)/60, 1)
+		dst.Set(x, y, col)
+		if i%5 == 0 {
+			x1, y1 := point(float64(i)/60, 0.85)
+			line(dst, x, y, x1, y1, col)
+		}
+	}
+	sec := float64(now.Second()) / 60
+	minute := (float64(now.Minute()) + sec) / 60
+	hour := (float64(now.Hour()%12) + minute) / 12
+	x, y := point(hour, 0.5)
+	line(dst, cx, cy, x, y, col)
+	x, y = point(minute, 0.8)
+	line(dst, cx, cy, x, y, col)
+	if c.Seconds {
+		x, y = point(sec, 0.9)
+		line(dst, cx, cy, x, y, col)
+	}
+}
+
+func (c *Clock) binary(dst draw.Image, now time.Time) {
+	r := dst.Bounds()
+	digits := []int{now.Hour() / 10, now.Hour() % 10, now.Minute() / 10, now.Minute() % 10}
+	if c.Seconds {
+		digits = append(digits, now.Second()/10, now.Second()%10)
+	}
+	cell := r.Dx() / len(digits)
+	if r.Dy()/4 < cell {
+		cell = r.Dy() / 4
+	}
+	x0 := r.Min.X + (r.Dx()-cell*len(digits))/2
+	y0 := r.Min.Y + (r.Dy()-cell*4)/2
+	col := image.NewUniform(fg(c.Color))
+	for i, d := range digits {
+		for bit := 0; bit < 4; bit++ {
+			x, y := x0+i*cell, y0+(3-bit)*cell
+			dot := image.Rect(x+1, y+1, x+cell-1, y+cell-1)
+			if d>>uint(bit)&1 != 0 {
+				draw.Draw(dst, dot, col, image.Point{}, draw.Src)
+				continue
+			}
+			// Unset bits are outlined.
+			line(dst, dot.Min.X, dot.Min.Y, dot.Max.X-1, dot.Min.Y, col)
+			line(dst, dot.Min.X, dot.Max.Y-1, dot.Max.X-1, dot.Max.Y-1, col)
+			line(dst, dot.Min.X, dot.Min.Y, dot.Min.X, dot.Max.Y-1, col)
+			line(dst, dot.Max.X-1, dot.Min.Y, dot.Max.X-1, dot.Max.Y-1, col)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+//go:build linux && !tinygo
+// +build linux,!tinygo
+
+package apps
+
+import "syscall"
+
+// diskUsage returns the used and total bytes of the file system mounted at
+// mnt.
+func diskUsage(mnt string) (used, size uint64, err error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(mnt, &st); err != nil {
+		return 0, 0, err
+	}
+	bs := uint64(st.Bsize)
+	return (st.Blocks - st.Bfree) * bs, st.Blocks * bs, nil
+}
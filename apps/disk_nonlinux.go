@@ -0,0 +1,10 @@
+//go:build !linux || tinygo
+// +build !linux tinygo
+
+package apps
+
+import "errors"
+
+func diskUsage(mnt string) (used, size uint64, err error) {
+	return 0, 0, errors.New("not implemented on this platform")
+}
@@ -0,0 +1,46 @@
+// The status example shows the classic PiOLED status screens: the IP
+// address and the system statistics, alternating with a clock and, if a
+// BME280 is on the bus, the weather. A splash screen is shown at start,
+// and a crash screen if it fails.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/goiot/devices/apps"
+	"github.com/goiot/devices/bme280"
+	"github.com/goiot/devices/boards/pioled"
+	"github.com/goiot/devices/i2cbus"
+)
+
+const version = "v1.0.0"
+
+func main() {
+	oled, err := pioled.OpenPiOLED()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer oled.Close()
+	defer apps.Recover(oled, version)
+	apps.Show(oled, &apps.Splash{Name: "status", Version: version})
+	time.Sleep(2 * time.Second)
+
+	screens := []apps.Screen{&apps.Stats{}, &apps.Clock{Seconds: true}}
+	if bus, err := i2cbus.Open("primary"); err == nil {
+		if s, err := bme280.Open(bus, bme280.Addr); err == nil {
+			defer s.Close()
+			screens = append(screens, &apps.Weather{Read: s.Read})
+		}
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	if err := apps.Run(ctx, oled, 5*time.Second, screens...); err != context.Canceled {
+		apps.Fatal(oled, version, err)
+	}
+	oled.Clear()
+}
@@ -0,0 +1,192 @@
+package apps
+
+import "errors"
+
+// The QR codes are encoded in byte mode with the low error correction
+// level, up to version 5, which fits 106 bytes in 37x37 modules.
+var (
+	// data and error correction codewords of the versions 1 to 5, level L,
+	// which have a single block.
+	qrData = [...]int{19, 34, 55, 80, 108}
+	qrECC  = [...]int{7, 10, 15, 20, 26}
+)
+
+var gfExp, gfLog [256]byte
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		gfExp[i] = byte(x)
+		gfLog[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11D
+		}
+	}
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[(int(gfLog[a])+int(gfLog[b]))%255]
+}
+
+// reedSolomon returns the n error correction codewords of data.
+func reedSolomon(data []byte, n int) []byte {
+	gen := []byte{1}
+	for i := 0; i < n; i++ {
+		next := make([]byte, len(gen)+1)
+		for j, c := range gen {
+			next[j] ^= c
+			next[j+1] ^= gfMul(c, gfExp[i])
+		}
+		gen = next
+	}
+	msg := make([]byte, len(data)+n)
+	copy(msg, data)
+	for i := range data {
+		if c := msg[i]; c != 0 {
+			for j := 1; j < len(gen); j++ {
+				msg[i+j] ^= gfMul(gen[j], c)
+			}
+		}
+	}
+	return msg[len(data):]
+}
+
+// qrFormat returns the 15 format bits of the level L with mask 0.
+func qrFormat() int {
+	data := 1 << 3 // level L, mask 0
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// encodeQR returns the modules of the QR code of b, true for the dark
+// ones, without the quiet zone.
+func encodeQR(b []byte) ([][]bool, error) {
+	v := 0
+	for v < len(qrData) && (qrData[v]*8-12)/8 < len(b) {
+		v++
+	}
+	if v == len(qrData) {
+		return nil, errors.New("too much data for a QR code")
+	}
+
+	// Mode, length, data, terminator and padding.
+	data := make([]byte, 0, qrData[v])
+	var acc, nbits uint
+	put := func(x, n uint) {
+		acc, nbits = acc<<n|x, nbits+n
+		for nbits >= 8 {
+			nbits -= 8
+			data = append(data, byte(acc>>nbits))
+		}
+	}
+	put(0x4, 4)
+	put(uint(len(b)), 8)
+	for _, c := range b {
+		put(uint(c), 8)
+	}
+	if len(data) < qrData[v] {
+		put(0, 4)
+	}
+	if nbits > 0 {
+		put(0, 8-nbits)
+	}
+	for pad := byte(0xEC); len(data) < qrData[v]; pad ^= 0xEC ^ 0x11 {
+		data = append(data, pad)
+	}
+	data = append(data, reedSolomon(data, qrECC[v])...)
+
+	size := 21 + 4*v
+	m := make([][]bool, size)
+	fn := make([][]bool, size) // function modules
+	for i := range m {
+		m[i], fn[i] = make([]bool, size), make([]bool, size)
+	}
+	set := func(x, y int, dark bool) { m[y][x], fn[y][x] = dark, true }
+	abs := func(a int) int {
+		if a < 0 {
+			return -a
+		}
+		return a
+	}
+	dist := func(dx, dy int) int {
+		if abs(dx) > abs(dy) {
+			return abs(dx)
+		}
+		return abs(dy)
+	}
+	for i := 0; i < size; i++ {
+		set(6, i, i%2 == 0)
+		set(i, 6, i%2 == 0)
+	}
+	for _, c := range [][2]int{{3, 3}, {size - 4, 3}, {3, size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := c[0]+dx, c[1]+dy
+				if x >= 0 && x < size && y >= 0 && y < size {
+					d := dist(dx, dy)
+					set(x, y, d != 2 && d != 4)
+				}
+			}
+		}
+	}
+	if v > 0 {
+		for dy := -2; dy <= 2; dy++ {
+			for dx := -2; dx <= 2; dx++ {
+				set(size-7+dx, size-7+dy, dist(dx, dy) != 1)
+			}
+		}
+	}
+
+	f := qrFormat()
+	bit := func(i int) bool { return f>>uint(i)&1 != 0 }
+	for i := 0; i <= 5; i++ {
+		set(8, i, bit(i))
+	}
+	set(8, 7, bit(6))
+	set(8, 8, bit(7))
+	set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		set(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		set(size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		set(8, size-15+i, bit(i))
+	}
+	set(8, size-8, true)
+
+	// Data in the zigzag order from the bottom right corner, with mask 0.
+	i := 0
+	for right := size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = size - 1 - vert
+				}
+				if fn[y][x] {
+					continue
+				}
+				if i < len(data)*8 {
+					m[y][x] = data[i>>3]>>uint(7-i&7)&1 != 0
+					i++
+				}
+				if (x+y)%2 == 0 {
+					m[y][x] = !m[y][x]
+				}
+			}
+		}
+	}
+	return m, nil
+}
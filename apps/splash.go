@@ -0,0 +1,165 @@
+package apps
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+
+	"github.com/goiot/devices/display"
+	"github.com/goiot/devices/text"
+)
+
+// Splash is a boot screen: a logo above the name and the version of the
+// application. Show it right after opening the display, while the rest of
+// the application starts.
+type Splash struct {
+	Logo    image.Image // centered above the text, optional
+	Name    string
+	Version string
+	Color   color.Color // color of the text, white if nil
+}
+
+// Draw implements Screen.
+func (s *Splash) Draw(dst draw.Image) error {
+	r := dst.Bounds()
+	c := fg(s.Color)
+	vh := 0 // height of the version
+	if s.Version != "" {
+		vh = text.Default.Height
+	}
+	if s.Logo == nil {
+		// The name as large as it fits.
+		nh := 0
+		scale := 1
+		if s.Name != "" {
+			if scale = text.Default.Fit(s.Name, r.Dx(), r.Dy()-vh); scale < 1 {
+				scale = 1
+			}
+			nh = scale * text.Default.Height
+		}
+		y := r.Min.Y + (r.Dy()-nh-vh)/2
+		centered(dst, r, y, s.Name, c, scale)
+		centered(dst, r, y+nh, s.Version, c, 1)
+		return nil
+	}
+	nh := 0
+	if s.Name != "" {
+		nh = text.Default.Height
+	}
+	lb := s.Logo.Bounds()
+	top := r.Min.Y + (r.Dy()-lb.Dy()-nh-vh)/2
+	if top < r.Min.Y {
+		top = r.Min.Y
+	}
+	at := image.Pt(r.Min.X+(r.Dx()-lb.Dx())/2, top)
+	draw.Draw(dst, image.Rectangle{at, at.Add(lb.Size())}.Intersect(r), s.Logo, lb.Min, draw.Over)
+	y := at.Y + lb.Dy()
+	if y+nh+vh > r.Max.Y {
+		y = r.Max.Y - nh - vh
+	}
+	centered(dst, r, y, s.Name, c, 1)
+	centered(dst, r, y+nh, s.Version, c, 1)
+	return nil
+}
+
+// Crash is the screen of an application which stopped: what happened,
+// and a QR code of it to be scanned by a phone in the field.
+type Crash struct {
+	Title   string // CRASH if empty
+	Version string // version of the application, added to the QR code
+	Reason  string
+	NoQR    bool        // leaves out the QR code
+	Color   color.Color // white if nil
+}
+
+// Draw implements Screen. The QR code is on the right of the display if
+// it is wide enough, the text wraps on the left.
+func (c *Crash) Draw(dst draw.Image) error {
+	r := dst.Bounds()
+	col := fg(c.Color)
+	title := c.Title
+	if title == "" {
+		title = "CRASH"
+	}
+	if !c.NoQR {
+		msg := c.Reason
+		if c.Version != "" {
+			msg = c.Version + ": " + msg
+		}
+		if q := qrImage(msg, r.Dy(), col); q != nil && q.Bounds().Dx() <= r.Dx()/2 {
+			b := q.Bounds()
+			at := image.Pt(r.Max.X-b.Dx(), r.Min.Y+(r.Dy()-b.Dy())/2)
+			draw.Draw(dst, image.Rectangle{at, at.Add(b.Size())}, q, b.Min, draw.Src)
+			r.Max.X = at.X - 2
+		}
+	}
+	ls := []string{title}
+	ls = append(ls, text.Default.Wrap(c.Reason, r.Dx(), 1)...)
+	if c.Version != "" {
+		ls = append(ls, c.Version)
+	}
+	lines(dst, r, col, ls...)
+	return nil
+}
+
+// qrImage returns the QR code of s, truncated to fit, as large as fits in
+// h pixels with a quiet zone of a module, nil if it does not fit.
+func qrImage(s string, h int, c color.Color) image.Image {
+	b := []byte(s)
+	if len(b) > 106 {
+		b = b[:106]
+	}
+	m, err := encodeQR(b)
+	if err != nil {
+		return nil
+	}
+	n := len(m) + 2
+	scale := h / n
+	if scale < 1 {
+		return nil
+	}
+	img := image.NewRGBA(image.Rect(0, 0, n*scale, n*scale))
+	// The dark modules are not lit, the quiet zone is.
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	for y, row := range m {
+		for x, dark := range row {
+			if dark {
+				px := image.Rect((x+1)*scale, (y+1)*scale, (x+2)*scale, (y+2)*scale)
+				draw.Draw(img, px, image.Black, image.Point{}, draw.Src)
+			}
+		}
+	}
+	return img
+}
+
+// Show draws s on d, which is cleared to black first.
+func Show(d display.Display, s Screen) error {
+	draw.Draw(d, d.Bounds(), image.Black, image.Point{}, draw.Src)
+	if err := s.Draw(d); err != nil {
+		return err
+	}
+	return d.Draw()
+}
+
+// Recover shows the crash screen of a panic on d and panics again, so
+// that the display keeps showing why the application died. It must be
+// deferred, typically at the top of main:
+//
+//	defer apps.Recover(oled, version)
+func Recover(d display.Display, version string) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	Show(d, &Crash{Version: version, Reason: fmt.Sprint(r)})
+	panic(r)
+}
+
+// Fatal shows the crash screen of err on d and exits with the status 1.
+func Fatal(d display.Display, version string, err error) {
+	fmt.Fprintln(os.Stderr, err)
+	Show(d, &Crash{Version: version, Reason: err.Error()})
+	os.Exit(1)
+}
@@ -0,0 +1,123 @@
+package apps
+
+import (
+	"bytes"
+	"image"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/displaytest"
+)
+
+func TestReedSolomon(t *testing.T) {
+	// HELLO WORLD in the version 1, level M, of the QR code tutorials.
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := reedSolomon(data, 10); !bytes.Equal(got, want) {
+		t.Errorf("reedSolomon = %v; want %v", got, want)
+	}
+	if got := qrFormat(); got != 0x77C4 {
+		t.Errorf("format bits = %015b; want 111011111000100", got)
+	}
+}
+
+func TestQR(t *testing.T) {
+	for _, tt := range []struct{ n, size int }{{17, 21}, {18, 25}, {53, 29}, {106, 37}} {
+		m, err := encodeQR(bytes.Repeat([]byte("a"), tt.n))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(m) != tt.size {
+			t.Errorf("%d bytes in %d modules; want %d", tt.n, len(m), tt.size)
+		}
+		// The finders, and the separators around them.
+		for _, c := range [][2]int{{0, 0}, {tt.size - 7, 0}, {0, tt.size - 7}} {
+			for i := 0; i < 7; i++ {
+				if !m[c[1]][c[0]+i] || !m[c[1]+i][c[0]] || !m[c[1]+3][c[0]+3] || m[c[1]+1][c[0]+1] {
+					t.Fatalf("no finder at %v in %d modules", c, tt.size)
+				}
+			}
+		}
+		if m[7][7] || !m[6][8] || m[6][9] {
+			t.Errorf("separator or timing pattern missing in %d modules", tt.size)
+		}
+	}
+	if _, err := encodeQR(make([]byte, 107)); err == nil {
+		t.Error("107 bytes encoded")
+	}
+}
+
+func TestSplash(t *testing.T) {
+	d := newScreen(128, 64)
+	// The name at scale 4, from y 12 to 44, above the version.
+	if err := Show(d, &Splash{Name: "Hive", Version: "v1.2.0"}); err != nil {
+		t.Fatal(err)
+	}
+	if d.draws != 1 || lit(d.Gray, image.Rect(0, 12, 128, 44)) == 0 || lit(d.Gray, image.Rect(0, 44, 128, 52)) == 0 {
+		t.Error("name or version not drawn")
+	}
+
+	logo := image.NewGray(image.Rect(0, 0, 16, 16))
+	for i := range logo.Pix {
+		logo.Pix[i] = 0xFF
+	}
+	d = newScreen(128, 64)
+	Show(d, &Splash{Logo: logo, Version: "v1"})
+	if got := lit(d.Gray, image.Rect(56, 20, 72, 36)); got != 256 {
+		t.Errorf("%d pixels of the logo lit at the center; want 256", got)
+	}
+}
+
+func TestCrash(t *testing.T) {
+	d := newScreen(128, 64)
+	Show(d, &Crash{Version: "v1", Reason: "bme280: read failed"})
+	// A QR code of 25 modules and the quiet zone, at scale 2.
+	q := image.Rect(128-54, 5, 128, 59)
+	if lit(d.Gray, q) == 0 || lit(d.Gray, q) == q.Dx()*q.Dy() {
+		t.Error("no QR code on the right")
+	}
+	if lit(d.Gray, image.Rect(0, 0, 70, 8)) == 0 {
+		t.Error("no title")
+	}
+
+	d = newScreen(128, 64)
+	Show(d, &Crash{Reason: "x", NoQR: true})
+	if lit(d.Gray, image.Rect(64, 0, 128, 64)) != 0 {
+		t.Error("QR code drawn with NoQR")
+	}
+}
+
+func TestRecover(t *testing.T) {
+	d := newScreen(128, 64)
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(r.(string), "boom") {
+			t.Errorf("recovered %v; want the panic again", r)
+		}
+		if d.draws != 1 {
+			t.Error("crash screen not shown")
+		}
+	}()
+	defer Recover(d, "v1")
+	panic("boom")
+}
+
+func TestScreensGolden(t *testing.T) {
+	now := time.Date(2024, 5, 17, 3, 0, 30, 0, time.UTC)
+	for _, tt := range []struct {
+		name string
+		s    Screen
+	}{
+		{"digital", &Clock{Now: func() (time.Time, error) { return now, nil }, Location: time.UTC, Seconds: true}},
+		{"analog", &Clock{Face: Analog, Now: func() (time.Time, error) { return now, nil }, Location: time.UTC}},
+		{"binary", &Clock{Face: Binary, Now: func() (time.Time, error) { return now, nil }, Location: time.UTC}},
+		{"splash", &Splash{Name: "Hive", Version: "v1.2.0"}},
+		{"crash", &Crash{Version: "v1.2.0", Reason: "bme280: reading failed"}},
+	} {
+		o, sim := displaytest.SSD1306(t, 128, 64)
+		if err := Show(o, tt.s); err != nil {
+			t.Fatal(err)
+		}
+		displaytest.Golden(t, tt.name, sim.Image())
+	}
+}
@@ -0,0 +1,225 @@
+package apps
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"image/draw"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goiot/devices/clock"
+)
+
+// Stats shows the IP address, the CPU load and temperature, the memory and
+// disk usage and the network traffic of the system, as many lines as fit on
+// the display. The statistics which cannot be read are shown as "-".
+type Stats struct {
+	Interface string      // network interface, the first one up with an IPv4 address if empty
+	Disk      string      // mount point of the disk, "/" if empty
+	Color     color.Color // white if nil
+	Clock     clock.Clock // times the traffic, clock.Real if nil
+
+	proc, sys string // roots of procfs and sysfs, changed by the tests
+
+	cpu      [2]uint64 // busy and total jiffies of the previous draw
+	net      [2]uint64 // received and sent bytes of the previous draw
+	readTime time.Time
+}
+
+// Draw implements Screen.
+func (s *Stats) Draw(dst draw.Image) error {
+	now := clock.Or(s.Clock).Now()
+	lines(dst, dst.Bounds(), fg(s.Color),
+		"IP "+s.ip(),
+		"CPU "+s.cpuLoad()+" "+s.temperature(),
+		"Mem "+s.memory(),
+		"Disk "+s.disk(),
+		"Net "+s.traffic(now),
+	)
+	s.readTime = now
+	return nil
+}
+
+func (s *Stats) path(root, def string, elem ...string) string {
+	if root == "" {
+		root = def
+	}
+	return filepath.Join(append([]string{root}, elem...)...)
+}
+
+func (s *Stats) iface() (*net.Interface, error) {
+	if s.Interface != "" {
+		return net.InterfaceByName(s.Interface)
+	}
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	for i := range ifaces {
+		if ifaces[i].Flags&net.FlagUp == 0 || ifaces[i].Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		if ipv4(&ifaces[i]) != nil {
+			return &ifaces[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no network interface is up")
+}
+
+func ipv4(i *net.Interface) net.IP {
+	addrs, err := i.Addrs()
+	if err != nil {
+		return nil
+	}
+	for _, a := range addrs {
+		if n, ok := a.(*net.IPNet); ok && n.IP.To4() != nil {
+			return n.IP
+		}
+	}
+	return nil
+}
+
+func (s *Stats) ip() string {
+	i, err := s.iface()
+	if err != nil {
+		return "-"
+	}
+	if ip := ipv4(i); ip != nil {
+		return ip.String()
+	}
+	return "-"
+}
+
+// cpuLoad returns the CPU load since the previous draw, or since the boot
+// on the first draw.
+func (s *Stats) cpuLoad() string {
+	b, err := ioutil.ReadFile(s.path(s.proc, "/proc", "stat"))
+	if err != nil {
+		return "-"
+	}
+	f := strings.Fields(strings.SplitN(string(b), "\n", 2)[0])
+	if len(f) < 5 || f[0] != "cpu" {
+		return "-"
+	}
+	var total, idle uint64
+	for i, v := range f[1:] {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return "-"
+		}
+		total += n
+		if i == 3 || i == 4 { // idle and iowait
+			idle += n
+		}
+	}
+	busy := total - idle
+	prev := s.cpu
+	s.cpu = [2]uint64{busy, total}
+	if total <= prev[1] || busy < prev[0] {
+		return "-"
+	}
+	return fmt.Sprintf("%d%%", 100*(busy-prev[0])/(total-prev[1]))
+}
+
+func (s *Stats) temperature() string {
+	b, err := ioutil.ReadFile(s.path(s.sys, "/sys", "class", "thermal", "thermal_zone0", "temp"))
+	if err != nil {
+		return ""
+	}
+	mc, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%.1fC", float64(mc)/1000)
+}
+
+func (s *Stats) memory() string {
+	f, err := os.Open(s.path(s.proc, "/proc", "meminfo"))
+	if err != nil {
+		return "-"
+	}
+	defer f.Close()
+	var total, avail uint64 // in kB
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "MemTotal:":
+			total, _ = strconv.ParseUint(fields[1], 10, 64)
+		case "MemAvailable:":
+			avail, _ = strconv.ParseUint(fields[1], 10, 64)
+		}
+	}
+	if total == 0 || avail > total {
+		return "-"
+	}
+	return fmt.Sprintf("%d/%dM", (total-avail)/1024, total/1024)
+}
+
+func (s *Stats) disk() string {
+	mnt := s.Disk
+	if mnt == "" {
+		mnt = "/"
+	}
+	used, size, err := diskUsage(mnt)
+	if err != nil || size == 0 {
+		return "-"
+	}
+	const gb = 1 << 30
+	return fmt.Sprintf("%.1f/%.0fG %d%%", float64(used)/gb, float64(size)/gb, 100*used/size)
+}
+
+// traffic returns the receive and send rates since the previous draw.
+func (s *Stats) traffic(now time.Time) string {
+	i, err := s.iface()
+	if err != nil {
+		return "-"
+	}
+	b, err := ioutil.ReadFile(s.path(s.proc, "/proc", "net", "dev"))
+	if err != nil {
+		return "-"
+	}
+	for _, l := range strings.Split(string(b), "\n") {
+		kv := strings.SplitN(l, ":", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) != i.Name {
+			continue
+		}
+		f := strings.Fields(kv[1])
+		if len(f) < 9 {
+			return "-"
+		}
+		rx, err1 := strconv.ParseUint(f[0], 10, 64)
+		tx, err2 := strconv.ParseUint(f[8], 10, 64)
+		if err1 != nil || err2 != nil {
+			return "-"
+		}
+		prev := s.net
+		s.net = [2]uint64{rx, tx}
+		dt := now.Sub(s.readTime).Seconds()
+		if s.readTime.IsZero() || dt <= 0 || rx < prev[0] || tx < prev[1] {
+			return "-"
+		}
+		return rate(float64(rx-prev[0])/dt) + "/" + rate(float64(tx-prev[1])/dt)
+	}
+	return "-"
+}
+
+// rate formats a rate in bytes per second.
+func rate(v float64) string {
+	switch {
+	case v >= 1<<20:
+		return fmt.Sprintf("%.1fM", v/(1<<20))
+	case v >= 1<<10:
+		return fmt.Sprintf("%.1fk", v/(1<<10))
+	}
+	return fmt.Sprintf("%.0f", v)
+}
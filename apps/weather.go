@@ -0,0 +1,88 @@
+package apps
+
+import (
+	"fmt"
+	"image/color"
+	"image/draw"
+	"time"
+
+	"github.com/goiot/devices/bme280"
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/text"
+	"github.com/goiot/devices/timeseries"
+)
+
+// Weather shows the temperature, the humidity and the pressure measured by a
+// sensor, with the pressure trend over the last three hours as a rising or
+// falling arrow.
+type Weather struct {
+	Read       func() (bme280.Measurement, error) // reads the sensors, such as BME280.Read
+	Fahrenheit bool                               // show the temperature in degrees Fahrenheit
+	Color      color.Color                        // white if nil
+	Clock      clock.Clock                        // times the pressure trend, clock.Real if nil
+
+	pressure *timeseries.Series
+}
+
+// trendPeriod is the period over which the pressure trend is computed,
+// and trendMin the change in hPa shown as a trend.
+const (
+	trendPeriod = 3 * time.Hour
+	trendMin    = 1.0
+)
+
+// Draw implements Screen.
+func (w *Weather) Draw(dst draw.Image) error {
+	m, err := w.Read()
+	if err != nil {
+		return fmt.Errorf("reading the weather failed - %v", err)
+	}
+	now := clock.Or(w.Clock).Now()
+	if w.pressure == nil {
+		w.pressure = timeseries.New(trendPeriod+time.Hour, 10*time.Minute)
+	}
+	w.pressure.Add(m.Pressure, now)
+
+	r := dst.Bounds()
+	col := fg(w.Color)
+	temp := fmt.Sprintf("%.1fC", m.Temperature)
+	if w.Fahrenheit {
+		temp = fmt.Sprintf("%.1fF", m.Temperature*9/5+32)
+	}
+	h := r.Dy() - text.Default.Height
+	scale := text.Default.Fit(temp, r.Dx(), h)
+	if scale < 1 {
+		scale = 1
+	}
+	th := text.Default.Size(temp, scale).Y
+	centered(dst, r, r.Min.Y+(h-th)/2, temp, col, scale)
+
+	y := r.Max.Y - text.Default.Height
+	x := text.Default.Draw(dst, r.Min.X, y, fmt.Sprintf("%.0f%% %.0fhPa", m.Humidity, m.Pressure), col, 1)
+	w.trend(dst, x+1, y, now, col)
+	return nil
+}
+
+// trend draws the pressure trend as an arrow in the 8x8 cell at x, y.
+func (w *Weather) trend(dst draw.Image, x, y int, now time.Time, col color.Color) {
+	past := w.pressure.Range(now.Add(-trendPeriod), now.Add(-trendPeriod+10*time.Minute))
+	if past.Count == 0 {
+		return
+	}
+	d := 1
+	switch p, _ := w.pressure.Last(); {
+	case p.Avg()-past.Avg() >= trendMin:
+	case past.Avg()-p.Avg() >= trendMin:
+		d = -1
+	default:
+		return
+	}
+	// Arrow pointing up for a rising pressure, down otherwise.
+	tip, base := y+1, y+6
+	if d < 0 {
+		tip, base = base, tip
+	}
+	line(dst, x+3, tip, x+3, base, col)
+	line(dst, x+3, tip, x, tip+3*d, col)
+	line(dst, x+3, tip, x+6, tip+3*d, col)
+}
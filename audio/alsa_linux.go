@@ -0,0 +1,143 @@
+//go:build linux && !tinygo
+// +build linux,!tinygo
+
+package audio
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// The structures below mirror the ALSA ABI defined in
+// include/uapi/sound/asound.h.
+
+type mask struct {
+	bits [8]uint32
+}
+
+type interval struct {
+	min, max uint32
+	flags    uint32 // openmin, openmax, integer and empty bits
+}
+
+type hwParams struct {
+	flags     uint32
+	masks     [3]mask
+	mres      [5]mask
+	intervals [12]interval
+	ires      [9]interval
+	rmask     uint32
+	cmask     uint32
+	info      uint32
+	msbits    uint32
+	rateNum   uint32
+	rateDen   uint32
+	fifoSize  uint // snd_pcm_uframes_t
+	reserved  [64]byte
+}
+
+type xferi struct {
+	result int // snd_pcm_sframes_t
+	buf    unsafe.Pointer
+	frames uint
+}
+
+const (
+	paramAccess     = 0
+	paramFormat     = 1
+	paramSubformat  = 2
+	paramChannels   = 10 // the intervals start at 8
+	paramRate       = 11
+	paramPeriodSize = 13
+	paramPeriods    = 15
+
+	accessRWInterleaved = 3
+	formatS16LE         = 2
+	formatS32LE         = 10
+	intervalInteger     = 1 << 2
+
+	// periodFrames and periods set the buffer of the device, of 85ms at
+	// 48kHz.
+	periodFrames = 1024
+	periods      = 4
+)
+
+const (
+	iocWrite = 1
+	iocRead  = 2
+)
+
+var (
+	hwParamsIoctl = ioc(iocRead|iocWrite, 0x11, unsafe.Sizeof(hwParams{}))
+	prepareIoctl  = ioc(0, 0x40, 0)
+	dropIoctl     = ioc(0, 0x43, 0)
+	drainIoctl    = ioc(0, 0x44, 0)
+	writeiIoctl   = ioc(iocWrite, 0x50, unsafe.Sizeof(xferi{}))
+	readiIoctl    = ioc(iocRead, 0x51, unsafe.Sizeof(xferi{}))
+)
+
+// ioc returns the request code of an ALSA PCM ioctl.
+func ioc(dir, nr, size uintptr) uintptr {
+	return dir<<30 | size<<16 | 'A'<<8 | nr
+}
+
+func ioctl(f *os.File, req uintptr, arg unsafe.Pointer) error {
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno syscall.Errno
+	if err := rc.Control(func(fd uintptr) {
+		for {
+			_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(arg))
+			if errno != syscall.EINTR {
+				return
+			}
+		}
+	}); err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// setParams sets the access, the sample format, the rate and the
+// channels of the device, it must be stopped.
+func setParams(f *os.File, format uint32, rate, channels int) error {
+	var hw hwParams
+	for i := range hw.masks {
+		for j := range hw.masks[i].bits {
+			hw.masks[i].bits[j] = ^uint32(0)
+		}
+	}
+	for i := range hw.intervals {
+		hw.intervals[i].max = ^uint32(0)
+	}
+	hw.rmask, hw.info = ^uint32(0), ^uint32(0)
+	set := func(param int, v uint32) {
+		hw.masks[param] = mask{}
+		hw.masks[param].bits[v/32] = 1 << (v % 32)
+	}
+	set(paramAccess, accessRWInterleaved)
+	set(paramFormat, format)
+	set(paramSubformat, 0)
+	for _, v := range []struct {
+		param int
+		v     uint32
+	}{
+		{paramChannels, uint32(channels)},
+		{paramRate, uint32(rate)},
+		{paramPeriodSize, periodFrames},
+		{paramPeriods, periods},
+	} {
+		hw.intervals[v.param-8] = interval{min: v.v, max: v.v, flags: intervalInteger}
+	}
+	if err := ioctl(f, hwParamsIoctl, unsafe.Pointer(&hw)); err != nil {
+		return fmt.Errorf("the device doesn't support %d channels at %dHz - %v", channels, rate, err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+// Package audio plays sounds for the alarms and the voice prompts of the
+// appliances: WAV files and synthesized tones on an ALSA playback device,
+// such as a MAX98357 I2S amplifier, or melodies on a buzzer driven by a
+// PWM output.
+//
+// The MAX98357 is an ALSA device with the max98357a overlay
+// (dtoverlay=max98357a in /boot/config.txt on a Raspberry Pi), opened with
+// e.g.
+//
+//	pcm, err := audio.OpenPCM("/dev/snd/pcmC0D0p")
+//
+// Capture reads the samples of an ALSA capture device, such as an SPH0645
+// I2S microphone, measured by Level and Spectrum for the VU-meters and
+// the spectrum analyzers of the displays.
+package audio
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Sound is a sound in 16-bit samples.
+type Sound struct {
+	Rate     int     // samples per second and channel
+	Channels int     // 1 for mono, 2 for stereo
+	Samples  []int16 // interleaved samples of the channels
+}
+
+// Duration returns the duration of s.
+func (s *Sound) Duration() time.Duration {
+	if s.Rate <= 0 || s.Channels <= 0 {
+		return 0
+	}
+	return time.Duration(len(s.Samples)/s.Channels) * time.Second / time.Duration(s.Rate)
+}
+
+// Note is a note of a melody.
+type Note struct {
+	Frequency float64 // Hz, 0 for a rest
+	Duration  time.Duration
+}
+
+// Player plays melodies, it is implemented by PCM and Beeper.
+type Player interface {
+	PlayNotes(ctx context.Context, notes ...Note) error
+}
+
+// ramp is the time the tones fade in and out, against the clicks.
+const ramp = 5 * time.Millisecond
+
+// Tone returns a mono sine tone of frequency hz lasting d, at rate samples
+// per second and volume from 0 to 1. The tone is silent if hz is 0.
+func Tone(hz float64, d time.Duration, rate int, volume float64) *Sound {
+	n := int(int64(d) * int64(rate) / int64(time.Second))
+	s := &Sound{Rate: rate, Channels: 1, Samples: make([]int16, n)}
+	if hz <= 0 {
+		return s
+	}
+	fade := int(int64(ramp) * int64(rate) / int64(time.Second))
+	if fade > n/2 {
+		fade = n / 2
+	}
+	for i := range s.Samples {
+		a := volume * math.MaxInt16
+		if i < fade {
+			a *= float64(i) / float64(fade)
+		} else if i >= n-fade {
+			a *= float64(n-1-i) / float64(fade)
+		}
+		s.Samples[i] = int16(a * math.Sin(2*math.Pi*hz*float64(i)/float64(rate)))
+	}
+	return s
+}
+
+// Melody returns the notes synthesized by Tone.
+func Melody(notes []Note, rate int, volume float64) *Sound {
+	s := &Sound{Rate: rate, Channels: 1}
+	for _, n := range notes {
+		s.Samples = append(s.Samples, Tone(n.Frequency, n.Duration, rate, volume).Samples...)
+	}
+	return s
+}
@@ -0,0 +1,145 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/clock"
+)
+
+var (
+	_ Player = (*PCM)(nil)
+	_ Player = (*Beeper)(nil)
+)
+
+// wav returns a WAV file with a format chunk of the format and the
+// samples, preceded by a LIST chunk.
+func wav(format uint16, channels, rate, bits int, data []byte) []byte {
+	var b bytes.Buffer
+	le := func(v interface{}) { binary.Write(&b, binary.LittleEndian, v) }
+	b.WriteString("RIFF")
+	le(uint32(0))
+	b.WriteString("WAVE")
+	b.WriteString("LIST")
+	le(uint32(3))
+	b.WriteString("abc\x00") // padded
+	b.WriteString("fmt ")
+	le(uint32(16))
+	le(format)
+	le(uint16(channels))
+	le(uint32(rate))
+	le(uint32(rate * channels * bits / 8))
+	le(uint16(channels * bits / 8))
+	le(uint16(bits))
+	b.WriteString("data")
+	le(uint32(len(data)))
+	b.Write(data)
+	return b.Bytes()
+}
+
+func TestDecodeWAV(t *testing.T) {
+	s, err := DecodeWAV(bytes.NewReader(wav(wavPCM, 2, 16000, 16, []byte{0x01, 0x00, 0xFF, 0xFF, 0x00, 0x80, 0xFF, 0x7F, 0x12})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Rate != 16000 || s.Channels != 2 || fmt.Sprint(s.Samples) != "[1 -1 -32768 32767]" {
+		t.Errorf("DecodeWAV() = %+v; want 2 frames at 16kHz", s)
+	}
+	if d := s.Duration(); d != 125*time.Microsecond {
+		t.Errorf("Duration() = %v; want 125µs", d)
+	}
+
+	s, err = DecodeWAV(bytes.NewReader(wav(wavPCM, 1, 8000, 8, []byte{0x80, 0x00, 0xFF})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(s.Samples) != "[0 -32768 32512]" {
+		t.Errorf("8-bit samples %v; want [0 -32768 32512]", s.Samples)
+	}
+
+	for _, b := range [][]byte{
+		wav(3, 1, 8000, 32, nil), // float
+		wav(wavPCM, 1, 8000, 24, nil),
+		wav(wavPCM, 0, 8000, 16, nil),
+		[]byte("RIFF\x00\x00\x00\x00AVI "),
+		wav(wavPCM, 1, 8000, 16, nil)[:40],
+	} {
+		if _, err := DecodeWAV(bytes.NewReader(b)); err == nil {
+			t.Errorf("DecodeWAV(% X) succeeded", b)
+		}
+	}
+}
+
+func TestTone(t *testing.T) {
+	s := Tone(1000, 50*time.Millisecond, 8000, 0.5)
+	if len(s.Samples) != 400 || s.Duration() != 50*time.Millisecond {
+		t.Fatalf("Tone() of %d samples, %v; want 400, 50ms", len(s.Samples), s.Duration())
+	}
+	// faded in and out, at half the full scale
+	max := int16(0)
+	for _, v := range s.Samples {
+		if v > max {
+			max = v
+		}
+	}
+	if s.Samples[0] != 0 || s.Samples[399] != 0 || max < 16000 || max > 16384 {
+		t.Errorf("Tone() samples from %d to %d, up to %d", s.Samples[0], s.Samples[399], max)
+	}
+	m := Melody([]Note{{440, 5 * time.Millisecond}, {0, 5 * time.Millisecond}}, 8000, 1)
+	if len(m.Samples) != 80 || m.Samples[60] != 0 {
+		t.Errorf("Melody() of %d samples; want 80 ending silent", len(m.Samples))
+	}
+}
+
+type output struct {
+	duties []float64
+	freqs  []float64
+}
+
+func (o *output) SetDuty(duty float64) error {
+	o.duties = append(o.duties, duty)
+	return nil
+}
+
+func TestBeeper(t *testing.T) {
+	c := clock.NewFake(time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC))
+	o := &output{}
+	b := &Beeper{Output: o, Clock: c, SetFrequency: func(hz float64) error {
+		o.freqs = append(o.freqs, hz)
+		return nil
+	}}
+	done := make(chan error)
+	go func() {
+		done <- b.PlayNotes(context.Background(), Note{2000, 100 * time.Millisecond}, Note{0, 50 * time.Millisecond}, Note{1000, 100 * time.Millisecond})
+	}()
+	for _, d := range []time.Duration{100, 50, 100} {
+		c.BlockUntil(1)
+		c.Advance(d * time.Millisecond)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(o.duties, o.freqs) != "[0.5 0 0.5 0] [2000 1000]" {
+		t.Errorf("duties %v at %v Hz; want [0.5 0 0.5 0] at [2000 1000] Hz", o.duties, o.freqs)
+	}
+
+	// canceled, the buzzer is silenced
+	o.duties = nil
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { done <- b.PlayNotes(ctx, Note{2000, time.Second}) }()
+	c.BlockUntil(1)
+	cancel()
+	if err := <-done; err != context.Canceled || fmt.Sprint(o.duties) != "[0.5 0]" {
+		t.Errorf("PlayNotes() = %v with duties %v; want %v with [0.5 0]", err, o.duties, context.Canceled)
+	}
+
+	b.SetFrequency = func(float64) error { return errors.New("out of range") }
+	if err := b.PlayNotes(context.Background(), Note{10000, time.Second}); err == nil {
+		t.Error("PlayNotes() succeeded out of the range of the PWM")
+	}
+}
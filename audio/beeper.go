@@ -0,0 +1,49 @@
+package audio
+
+import (
+	"context"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/pwm"
+)
+
+// Beeper plays melodies on a buzzer with a square wave, e.g. when there
+// is no audio device.
+type Beeper struct {
+	Output pwm.Output
+
+	// SetFrequency sets the frequency of Output to the pitch of the notes,
+	// e.g. the SetFrequency method of the PCA9685 driving a passive
+	// buzzer. Nil for an active buzzer, which beeps at its own pitch.
+	SetFrequency func(hz float64) error
+
+	// Clock times the notes, clock.Real if nil.
+	Clock clock.Clock
+}
+
+// PlayNotes implements Player, the buzzer is silent when it returns.
+func (b *Beeper) PlayNotes(ctx context.Context, notes ...Note) error {
+	c := clock.Or(b.Clock)
+	for _, n := range notes {
+		duty := 0.0
+		if n.Frequency > 0 {
+			duty = 0.5
+			if b.SetFrequency != nil {
+				if err := b.SetFrequency(n.Frequency); err != nil {
+					b.Output.SetDuty(0)
+					return err
+				}
+			}
+		}
+		if err := b.Output.SetDuty(duty); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			b.Output.SetDuty(0)
+			return ctx.Err()
+		case <-c.After(n.Duration):
+		}
+	}
+	return b.Output.SetDuty(0)
+}
@@ -0,0 +1,84 @@
+//go:build linux && !tinygo
+// +build linux,!tinygo
+
+package audio
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// Capture is an ALSA capture device, such as an SPH0645 I2S microphone,
+// read in 32-bit samples. It must be closed if no longer in use.
+type Capture struct {
+	f              *os.File
+	rate, channels int
+	buf            []int32
+}
+
+// OpenCapture opens the capture device dev, e.g. /dev/snd/pcmC1D0c, and
+// starts capturing rate samples per second of the channels.
+func OpenCapture(dev string, rate, channels int) (*Capture, error) {
+	if rate <= 0 || channels <= 0 {
+		return nil, fmt.Errorf("invalid format of %d channels at %dHz", channels, rate)
+	}
+	f, err := os.OpenFile(dev, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := setParams(f, formatS32LE, rate, channels); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := ioctl(f, prepareIoctl, nil); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("preparing the device failed - %v", err)
+	}
+	return &Capture{f: f, rate: rate, channels: channels}, nil
+}
+
+// Rate returns the samples per second and channel.
+func (c *Capture) Rate() int { return c.rate }
+
+// Channels returns the number of channels.
+func (c *Capture) Channels() int { return c.channels }
+
+// Read reads the interleaved samples of whole frames in s, from -1 to 1,
+// and returns their number. It waits for the first frame of s, an
+// overrun of the device drops the frames not read in time.
+func (c *Capture) Read(s []float64) (int, error) {
+	n := len(s) / c.channels
+	if n == 0 {
+		return 0, nil
+	}
+	if len(c.buf) < n*c.channels {
+		c.buf = make([]int32, n*c.channels)
+	}
+	for {
+		x := xferi{buf: unsafe.Pointer(&c.buf[0]), frames: uint(n)}
+		err := ioctl(c.f, readiIoctl, unsafe.Pointer(&x))
+		if err == syscall.EPIPE {
+			// an overrun, the device stopped
+			if err := ioctl(c.f, prepareIoctl, nil); err != nil {
+				return 0, fmt.Errorf("preparing the device failed - %v", err)
+			}
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("reading the samples failed - %v", err)
+		}
+		read := x.result * c.channels
+		for i, v := range c.buf[:read] {
+			s[i] = float64(v) / (1 << 31)
+		}
+		return read, nil
+	}
+}
+
+// Close stops the capture and closes the device.
+func (c *Capture) Close() error {
+	ioctl(c.f, dropIoctl, nil)
+	return c.f.Close()
+}
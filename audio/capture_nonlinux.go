@@ -0,0 +1,24 @@
+//go:build !linux || tinygo
+// +build !linux tinygo
+
+package audio
+
+// Capture is no-implementation so developers using cross compilation
+// can rely on local tools even though the real implementation isn't
+// available on their platform.
+type Capture struct{}
+
+// OpenCapture is not implemented on this platform.
+func OpenCapture(dev string, rate, channels int) (*Capture, error) { return nil, errNotImplemented }
+
+// Rate is not implemented on this platform.
+func (c *Capture) Rate() int { return 0 }
+
+// Channels is not implemented on this platform.
+func (c *Capture) Channels() int { return 0 }
+
+// Read is not implemented on this platform.
+func (c *Capture) Read(s []float64) (int, error) { return 0, errNotImplemented }
+
+// Close is not implemented on this platform.
+func (c *Capture) Close() error { return errNotImplemented }
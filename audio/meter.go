@@ -0,0 +1,128 @@
+package audio
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// Floor is the lowest level measured, in dBFS, the level of silence.
+const Floor = -120
+
+// dB returns the level in dBFS of the mean power p of the samples, 0 for a
+// full scale sine as in AES17.
+func dB(p float64) float64 {
+	l := 10 * math.Log10(2*p)
+	if l < Floor || math.IsNaN(l) {
+		return Floor
+	}
+	return l
+}
+
+// Level returns the RMS level of the samples, from -1 to 1, in dBFS: 0 for
+// a full scale sine. Their mean is removed, e.g. the DC offset of the
+// SPH0645 microphones.
+func Level(samples []float64) float64 {
+	if len(samples) == 0 {
+		return Floor
+	}
+	mean := 0.0
+	for _, v := range samples {
+		mean += v
+	}
+	mean /= float64(len(samples))
+	p := 0.0
+	for _, v := range samples {
+		p += (v - mean) * (v - mean)
+	}
+	return dB(p / float64(len(samples)))
+}
+
+// Spectrum measures the levels of the frequency bands of blocks of mono
+// samples, e.g. to draw the bars of a spectrum analyzer.
+type Spectrum struct {
+	Rate int // samples per second
+
+	// Bands is the number of bands, 8 if 0. They are spaced
+	// logarithmically from Low to the half of Rate.
+	Bands int
+
+	// Low is the low edge of the first band, 50Hz if 0.
+	Low float64
+}
+
+// Measure returns the levels of the bands in the samples in dBFS, a full
+// scale sine in a band measures 0. The samples are analyzed by an FFT of
+// the largest power of two of them, in a Hann window.
+func (s *Spectrum) Measure(samples []float64) []float64 {
+	bands, low := s.Bands, s.Low
+	if bands <= 0 {
+		bands = 8
+	}
+	if low <= 0 {
+		low = 50
+	}
+	levels := make([]float64, bands)
+	n := 1
+	for n*2 <= len(samples) {
+		n *= 2
+	}
+	if n < 2 || s.Rate <= 0 {
+		for i := range levels {
+			levels[i] = Floor
+		}
+		return levels
+	}
+	x := make([]complex128, n)
+	w2 := 0.0
+	for i := range x {
+		w := 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n))
+		x[i] = complex(samples[len(samples)-n+i]*w, 0)
+		w2 += w * w
+	}
+	fft(x)
+	power := make([]float64, bands)
+	high := float64(s.Rate) / 2
+	for k := 1; k < n/2; k++ {
+		f := float64(k) * float64(s.Rate) / float64(n)
+		if f < low {
+			continue
+		}
+		b := int(float64(bands) * math.Log(f/low) / math.Log(high/low))
+		if b >= bands {
+			b = bands - 1
+		}
+		a := cmplx.Abs(x[k])
+		// the bins of a side hold half the power of the window
+		power[b] += 2 * a * a / (float64(n) * w2)
+	}
+	for i, p := range power {
+		levels[i] = dB(p)
+	}
+	return levels
+}
+
+// fft transforms x in place, its length is a power of two.
+func fft(x []complex128) {
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	for size := 2; size <= n; size *= 2 {
+		w := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			wk := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				a, b := x[start+k], x[start+k+size/2]*wk
+				x[start+k], x[start+k+size/2] = a+b, a-b
+				wk *= w
+			}
+		}
+	}
+}
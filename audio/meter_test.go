@@ -0,0 +1,79 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+func sine(hz, amplitude, offset float64, rate, n int) []float64 {
+	s := make([]float64, n)
+	for i := range s {
+		s[i] = offset + amplitude*math.Sin(2*math.Pi*hz*float64(i)/float64(rate))
+	}
+	return s
+}
+
+func TestLevel(t *testing.T) {
+	square := make([]float64, 1000)
+	for i := range square {
+		square[i] = 1 - float64(i/50%2)*2
+	}
+	for _, tt := range []struct {
+		name    string
+		samples []float64
+		want    float64
+	}{
+		{"full scale sine", sine(1000, 1, 0, 48000, 4800), 0},
+		{"half scale sine with an offset", sine(1000, 0.5, 0.2, 48000, 4800), -6.0206},
+		{"full scale square", square, 3.0103},
+		{"silence", make([]float64, 100), Floor},
+		{"nothing", nil, Floor},
+	} {
+		if got := Level(tt.samples); math.Abs(got-tt.want) > 0.01 {
+			t.Errorf("Level(%s) = %.3f dBFS; want %.3f", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSpectrum(t *testing.T) {
+	s := &Spectrum{Rate: 48000}
+	// 800Hz is in the band 3, from 504 to 1101Hz
+	levels := s.Measure(sine(800, 1, 0.1, 48000, 1500))
+	if len(levels) != 8 {
+		t.Fatalf("%d bands; want 8", len(levels))
+	}
+	for i, l := range levels {
+		if i == 3 && math.Abs(l) > 0.1 {
+			t.Errorf("band %d at %.2f dBFS; want 0", i, l)
+		}
+		if i != 3 && l > -30 {
+			t.Errorf("band %d at %.2f dBFS; want less than -30", i, l)
+		}
+	}
+
+	s = &Spectrum{Rate: 8000, Bands: 4, Low: 100}
+	for _, l := range s.Measure(make([]float64, 256)) {
+		if l != Floor {
+			t.Errorf("silence measures %v dBFS; want %v", l, Floor)
+		}
+	}
+	if levels := s.Measure(nil); len(levels) != 4 || levels[0] != Floor {
+		t.Errorf("Measure(nil) = %v; want 4 bands at %v", levels, Floor)
+	}
+}
+
+func TestFFT(t *testing.T) {
+	x := []complex128{1, 2, 3, 4, 0, 0, 0, 0}
+	want := make([]complex128, len(x))
+	for k := range want {
+		for n, v := range x {
+			want[k] += v * complex(math.Cos(2*math.Pi*float64(k*n)/8), -math.Sin(2*math.Pi*float64(k*n)/8))
+		}
+	}
+	fft(x)
+	for k := range x {
+		if d := x[k] - want[k]; math.Hypot(real(d), imag(d)) > 1e-9 {
+			t.Errorf("bin %d = %v; want %v", k, x[k], want[k])
+		}
+	}
+}
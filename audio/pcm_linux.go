@@ -0,0 +1,103 @@
+//go:build linux && !tinygo
+// +build linux,!tinygo
+
+package audio
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// PCM is an ALSA playback device. It implements Player, and can be used
+// by multiple goroutines, which play in turn.
+type PCM struct {
+	// Volume is the volume of the notes played, from 0 to 1, 0.5 if 0.
+	Volume float64
+
+	f *os.File
+
+	mu             sync.Mutex
+	rate, channels int // of the sounds played, 0 until the first
+}
+
+// OpenPCM opens the playback device dev, e.g. /dev/snd/pcmC0D0p.
+func OpenPCM(dev string) (*PCM, error) {
+	f, err := os.OpenFile(dev, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &PCM{f: f}, nil
+}
+
+// setup sets the format of the device, it must be stopped.
+func (p *PCM) setup(rate, channels int) error {
+	if err := setParams(p.f, formatS16LE, rate, channels); err != nil {
+		return err
+	}
+	p.rate, p.channels = rate, channels
+	return nil
+}
+
+// Play plays s until it ends or ctx is done.
+func (p *PCM) Play(ctx context.Context, s *Sound) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if s.Rate != p.rate || s.Channels != p.channels {
+		if err := p.setup(s.Rate, s.Channels); err != nil {
+			return err
+		}
+	}
+	if err := ioctl(p.f, prepareIoctl, nil); err != nil {
+		return fmt.Errorf("preparing the device failed - %v", err)
+	}
+	for b := s.Samples; len(b) > 0; {
+		select {
+		case <-ctx.Done():
+			ioctl(p.f, dropIoctl, nil)
+			return ctx.Err()
+		default:
+		}
+		n := len(b) / s.Channels
+		if n > periodFrames {
+			n = periodFrames
+		}
+		x := xferi{buf: unsafe.Pointer(&b[0]), frames: uint(n)}
+		err := ioctl(p.f, writeiIoctl, unsafe.Pointer(&x))
+		if err == syscall.EPIPE {
+			// an underrun, the device stopped
+			if err := ioctl(p.f, prepareIoctl, nil); err != nil {
+				return fmt.Errorf("preparing the device failed - %v", err)
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("writing the samples failed - %v", err)
+		}
+		b = b[x.result*s.Channels:]
+	}
+	if err := ioctl(p.f, drainIoctl, nil); err != nil {
+		return fmt.Errorf("draining the device failed - %v", err)
+	}
+	return nil
+}
+
+// toneRate is the rate the notes are synthesized at.
+const toneRate = 48000
+
+// PlayNotes implements Player.
+func (p *PCM) PlayNotes(ctx context.Context, notes ...Note) error {
+	volume := p.Volume
+	if volume == 0 {
+		volume = 0.5
+	}
+	return p.Play(ctx, Melody(notes, toneRate, volume))
+}
+
+// Close closes the device.
+func (p *PCM) Close() error {
+	return p.f.Close()
+}
@@ -0,0 +1,30 @@
+//go:build !linux || tinygo
+// +build !linux tinygo
+
+package audio
+
+import (
+	"context"
+	"errors"
+)
+
+var errNotImplemented = errors.New("not implemented on this platform")
+
+// PCM is no-implementation so developers using cross compilation
+// can rely on local tools even though the real implementation isn't
+// available on their platform.
+type PCM struct {
+	Volume float64
+}
+
+// OpenPCM is not implemented on this platform.
+func OpenPCM(dev string) (*PCM, error) { return nil, errNotImplemented }
+
+// Play is not implemented on this platform.
+func (p *PCM) Play(ctx context.Context, s *Sound) error { return errNotImplemented }
+
+// PlayNotes is not implemented on this platform.
+func (p *PCM) PlayNotes(ctx context.Context, notes ...Note) error { return errNotImplemented }
+
+// Close is not implemented on this platform.
+func (p *PCM) Close() error { return errNotImplemented }
@@ -0,0 +1,85 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+const (
+	wavPCM        = 1
+	wavExtensible = 0xFFFE
+)
+
+// DecodeWAV decodes a WAV file of 8 or 16-bit PCM samples.
+func DecodeWAV(r io.Reader) (*Sound, error) {
+	var hdr [12]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, fmt.Errorf("reading the WAV header failed - %v", err)
+	}
+	if string(hdr[:4]) != "RIFF" || string(hdr[8:]) != "WAVE" {
+		return nil, errors.New("not a WAV file")
+	}
+	var s *Sound
+	bits := 0
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return nil, fmt.Errorf("reading the WAV chunks failed - %v", err)
+		}
+		size := int64(binary.LittleEndian.Uint32(chunk[4:]))
+		switch string(chunk[:4]) {
+		case "fmt ":
+			if size < 16 {
+				return nil, fmt.Errorf("format chunk of %d bytes", size)
+			}
+			b := make([]byte, size+size%2)
+			if _, err := io.ReadFull(r, b); err != nil {
+				return nil, fmt.Errorf("reading the WAV format failed - %v", err)
+			}
+			format := binary.LittleEndian.Uint16(b)
+			if format == wavExtensible && size >= 26 {
+				format = binary.LittleEndian.Uint16(b[24:]) // the sub-format GUID
+			}
+			s = &Sound{
+				Channels: int(binary.LittleEndian.Uint16(b[2:])),
+				Rate:     int(binary.LittleEndian.Uint32(b[4:])),
+			}
+			bits = int(binary.LittleEndian.Uint16(b[14:]))
+			if format != wavPCM || (bits != 8 && bits != 16) {
+				return nil, fmt.Errorf("unsupported WAV format %#x of %d-bit samples", format, bits)
+			}
+			if s.Channels < 1 || s.Rate < 1 {
+				return nil, fmt.Errorf("invalid WAV format of %d channels at %dHz", s.Channels, s.Rate)
+			}
+		case "data":
+			if s == nil {
+				return nil, errors.New("WAV data before the format")
+			}
+			b, err := ioutil.ReadAll(io.LimitReader(r, size))
+			if err != nil {
+				return nil, fmt.Errorf("reading the WAV data failed - %v", err)
+			}
+			if bits == 8 {
+				s.Samples = make([]int16, len(b))
+				for i, v := range b {
+					s.Samples[i] = (int16(v) - 128) << 8 // unsigned
+				}
+			} else {
+				s.Samples = make([]int16, len(b)/2)
+				for i := range s.Samples {
+					s.Samples[i] = int16(binary.LittleEndian.Uint16(b[2*i:]))
+				}
+			}
+			// the last frame may be truncated
+			s.Samples = s.Samples[:len(s.Samples)/s.Channels*s.Channels]
+			return s, nil
+		default:
+			if _, err := io.CopyN(ioutil.Discard, r, size+size%2); err != nil {
+				return nil, fmt.Errorf("reading the WAV chunks failed - %v", err)
+			}
+		}
+	}
+}
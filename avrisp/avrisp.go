@@ -0,0 +1,283 @@
+// Package avrisp flashes AVR microcontrollers (ATmega, ATtiny) over their
+// SPI serial programming interface, using a SPI bus and a GPIO pin wired to
+// the RESET pin of the target.
+//
+// This is the protocol spoken by ISP programmers such as the USBasp or
+// the ArduinoISP sketch, without the programmer: the host's own SPI bus is
+// connected to the MOSI, MISO and SCK pins of the target.
+package avrisp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/goiot/devices/gpio"
+	"golang.org/x/exp/io/spi"
+	"golang.org/x/exp/io/spi/driver"
+)
+
+// Speed is the default SPI clock. It must be lower than a fourth of the clock
+// of the target, which is 1MHz on a new ATmega328P.
+const Speed = 125000
+
+// Signature identifies the model of a target, e.g. 1E 95 0F for the
+// ATmega328P.
+type Signature [3]byte
+
+func (s Signature) String() string {
+	return fmt.Sprintf("%02X %02X %02X", s[0], s[1], s[2])
+}
+
+// Fuses are the configuration bytes of a target.
+type Fuses struct {
+	Low, High, Extended byte
+}
+
+// Programmer programs a target. It must be closed if no longer in use.
+type Programmer struct {
+	// Device is the underlying SPI bus. Most users don't have to access
+	// this field.
+	Device *spi.Device
+
+	reset gpio.Pin
+}
+
+// Open opens the SPI bus and keeps the target in reset until Close is
+// called. reset is an output pin connected to the RESET pin of the target.
+func Open(o driver.Opener, reset gpio.Pin) (*Programmer, error) {
+	dev, err := spi.Open(o)
+	if err != nil {
+		return nil, err
+	}
+	if err := dev.SetMode(spi.Mode0); err != nil {
+		dev.Close()
+		return nil, err
+	}
+	if err := dev.SetBitsPerWord(8); err != nil {
+		dev.Close()
+		return nil, err
+	}
+	if err := dev.SetMaxSpeed(Speed); err != nil {
+		dev.Close()
+		return nil, err
+	}
+	p := &Programmer{Device: dev, reset: reset}
+	if err := p.enable(); err != nil {
+		p.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *Programmer) cmd(a, b, c, d byte) ([]byte, error) {
+	r := make([]byte, 4)
+	if err := p.Device.Tx([]byte{a, b, c, d}, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// enable pulses the reset pin and enters programming mode. The target
+// is out of sync if the echo is missing, and the datasheets recommend to
+// pulse the reset again.
+func (p *Programmer) enable() error {
+	for i := 0; i < 4; i++ {
+		if err := p.reset.Write(1); err != nil {
+			return err
+		}
+		time.Sleep(time.Millisecond)
+		if err := p.reset.Write(0); err != nil {
+			return err
+		}
+		time.Sleep(20 * time.Millisecond)
+		r, err := p.cmd(cmdProgEnable, progEnableEcho, 0, 0)
+		if err != nil {
+			return err
+		}
+		if r[2] == progEnableEcho {
+			return nil
+		}
+	}
+	return errors.New("the target did not enter programming mode, check the wiring and the SPI speed")
+}
+
+// wait polls the target until the last write or erase is done.
+func (p *Programmer) wait() error {
+	for i := 0; i < 100; i++ {
+		r, err := p.cmd(cmdPoll, 0, 0, 0)
+		if err != nil {
+			return err
+		}
+		if r[3]&0x01 == 0 {
+			return nil
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return errors.New("the target is still busy")
+}
+
+// Signature reads the signature of the target.
+func (p *Programmer) Signature() (Signature, error) {
+	var s Signature
+	for i := range s {
+		r, err := p.cmd(cmdReadSignature, 0, byte(i), 0)
+		if err != nil {
+			return s, err
+		}
+		s[i] = r[3]
+	}
+	return s, nil
+}
+
+// Fuses reads the fuses of the target.
+func (p *Programmer) Fuses() (Fuses, error) {
+	var f Fuses
+	for _, fuse := range []struct {
+		op, sel byte
+		v       *byte
+	}{
+		{cmdReadFuseLow, 0, &f.Low},
+		{cmdReadFuseHigh, fuseHighExtSelect, &f.High},
+		{cmdReadFuseExt, fuseHighExtSelect, &f.Extended},
+	} {
+		r, err := p.cmd(fuse.op, fuse.sel, 0, 0)
+		if err != nil {
+			return f, err
+		}
+		*fuse.v = r[3]
+	}
+	return f, nil
+}
+
+// WriteFuses writes the fuses of the target. Wrong fuses can make the
+// target unreachable over SPI, e.g. by disabling the RESET pin or selecting
+// an external clock, double check them against the datasheet.
+func (p *Programmer) WriteFuses(f Fuses) error {
+	for _, fuse := range []struct{ op, v byte }{
+		{cmdWriteFuseLow, f.Low},
+		{cmdWriteFuseHigh, f.High},
+		{cmdWriteFuseExt, f.Extended},
+	} {
+		if _, err := p.cmd(cmdProgEnable, fuse.op, 0, fuse.v); err != nil {
+			return err
+		}
+		if err := p.wait(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Erase erases the flash and the EEPROM of the target.
+func (p *Programmer) Erase() error {
+	if _, err := p.cmd(cmdProgEnable, cmdChipErase, 0, 0); err != nil {
+		return err
+	}
+	return p.wait()
+}
+
+// loadExtAddr selects the 64K words segment of the flash on the targets
+// with more than 128KB.
+func (p *Programmer) loadExtAddr(word int) error {
+	if word < 0x10000 {
+		return nil
+	}
+	_, err := p.cmd(cmdLoadExtAddr, 0, byte(word>>16), 0)
+	return err
+}
+
+// ReadFlash reads len(b) bytes of flash starting at the byte address addr.
+func (p *Programmer) ReadFlash(addr int, b []byte) error {
+	for i := range b {
+		a := addr + i
+		word := a / 2
+		if a == addr || word&0xFFFF == 0 {
+			if err := p.loadExtAddr(word); err != nil {
+				return err
+			}
+		}
+		op := byte(cmdReadFlashLow)
+		if a%2 == 1 {
+			op = cmdReadFlashHigh
+		}
+		r, err := p.cmd(op, byte(word>>8), byte(word), 0)
+		if err != nil {
+			return err
+		}
+		b[i] = r[3]
+	}
+	return nil
+}
+
+// WriteFlash writes data to the flash starting at the byte address addr,
+// one page at a time. pageSize is the page size in bytes given by the
+// datasheet of the target, e.g. 128 for the ATmega328P or 64 for the
+// ATtiny85, and addr must be a multiple of it. The flash must have been
+// erased.
+func (p *Programmer) WriteFlash(addr int, data []byte, pageSize int) error {
+	if pageSize <= 0 || pageSize%2 != 0 || addr%pageSize != 0 {
+		return fmt.Errorf("address %#x is not aligned on a page of %d bytes", addr, pageSize)
+	}
+	for off := 0; off < len(data); off += pageSize {
+		page := data[off:]
+		if len(page) > pageSize {
+			page = page[:pageSize]
+		}
+		if bytes.Count(page, []byte{0xFF}) == len(page) {
+			continue // erased already
+		}
+		for i, v := range page {
+			op := byte(cmdLoadPageLow)
+			if i%2 == 1 {
+				op = cmdLoadPageHigh
+			}
+			if _, err := p.cmd(op, 0, byte(i/2), v); err != nil {
+				return err
+			}
+		}
+		word := (addr + off) / 2
+		if err := p.loadExtAddr(word); err != nil {
+			return err
+		}
+		if _, err := p.cmd(cmdWritePage, byte(word>>8), byte(word), 0); err != nil {
+			return err
+		}
+		if err := p.wait(); err != nil {
+			return fmt.Errorf("cannot write the page at %#x - %v", addr+off, err)
+		}
+	}
+	return nil
+}
+
+// Program erases the target, writes the image to the flash and verifies
+// it. The image starts at address 0, see ReadHex.
+func (p *Programmer) Program(image []byte, pageSize int) error {
+	if err := p.Erase(); err != nil {
+		return err
+	}
+	if err := p.WriteFlash(0, image, pageSize); err != nil {
+		return err
+	}
+	got := make([]byte, len(image))
+	if err := p.ReadFlash(0, got); err != nil {
+		return err
+	}
+	for i := range image {
+		if got[i] != image[i] {
+			return fmt.Errorf("verification failed at %#x: wrote %#x, read %#x", i, image[i], got[i])
+		}
+	}
+	return nil
+}
+
+// Close releases the reset pin, which starts the target, and closes the
+// SPI bus.
+func (p *Programmer) Close() error {
+	if err := p.reset.Write(1); err != nil {
+		p.Device.Close()
+		return err
+	}
+	return p.Device.Close()
+}
@@ -0,0 +1,129 @@
+package avrisp
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golang.org/x/exp/io/spi/driver"
+)
+
+// target is a fake ATtiny85 answering the serial programming
+// instructions.
+type target struct {
+	reset   *pin
+	enabled bool
+	flash   [8192]byte
+	page    [64]byte
+	writes  int
+}
+
+func (t *target) Open() (driver.Conn, error) { return t, nil }
+func (t *target) Configure(k, v int) error   { return nil }
+func (t *target) Close() error               { return nil }
+
+func (t *target) Tx(w, r []byte) error {
+	copy(r[1:], w[:3]) // the target echoes the previous byte
+	switch {
+	case t.reset.v != 0:
+		return nil
+	case w[0] == cmdProgEnable && w[1] == progEnableEcho:
+		t.enabled = true
+		r[2] = progEnableEcho
+	case !t.enabled:
+	case w[0] == cmdProgEnable && w[1] == cmdChipErase:
+		for i := range t.flash {
+			t.flash[i] = 0xFF
+		}
+	case w[0] == cmdReadSignature:
+		r[3] = []byte{0x1E, 0x93, 0x0B}[w[2]]
+	case w[0] == cmdLoadPageLow, w[0] == cmdLoadPageHigh:
+		t.page[int(w[2])%32*2+int(w[0]>>3&1)] = w[3]
+	case w[0] == cmdWritePage:
+		addr := (int(w[1])<<8 | int(w[2])) * 2 &^ 63
+		copy(t.flash[addr:], t.page[:])
+		t.writes++
+	case w[0] == cmdReadFlashLow, w[0] == cmdReadFlashHigh:
+		r[3] = t.flash[(int(w[1])<<8|int(w[2]))*2+int(w[0]>>3&1)]
+	}
+	return nil
+}
+
+type pin struct{ v int }
+
+func (p *pin) Read() (int, error) { return p.v, nil }
+func (p *pin) Write(v int) error  { p.v = v; return nil }
+func (p *pin) Close() error       { return nil }
+
+func TestProgram(t *testing.T) {
+	reset := &pin{}
+	avr := &target{reset: reset}
+	p, err := Open(avr, reset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := p.Signature()
+	if err != nil || sig != (Signature{0x1E, 0x93, 0x0B}) {
+		t.Errorf("Signature = %v, %v", sig, err)
+	}
+
+	image := make([]byte, 200)
+	for i := range image {
+		image[i] = byte(i)
+	}
+	for i := 64; i < 128; i++ {
+		image[i] = 0xFF // blank page, skipped
+	}
+	if err := p.Program(image, 64); err != nil {
+		t.Fatal(err)
+	}
+	if avr.writes != 3 {
+		t.Errorf("got %d page writes, want 3", avr.writes)
+	}
+	if !bytes.Equal(avr.flash[:len(image)], image) {
+		t.Errorf("flash = %x, want %x", avr.flash[:len(image)], image)
+	}
+
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if reset.v != 1 {
+		t.Error("the target should be out of reset after Close")
+	}
+}
+
+func TestReadHex(t *testing.T) {
+	const blink = `:100000000C9434000C943E000C943E000C943E0082
+:020000040000FA
+:04001000DEADBEEFB4
+:00000001FF
+`
+	image, err := ReadHex(strings.NewReader(blink))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(image) != 20 || image[0] != 0x0C || image[16] != 0xDE || image[19] != 0xEF {
+		t.Errorf("image = %x", image)
+	}
+
+	for _, bad := range []string{
+		":100000000C9434000C943E000C943E000C943E0083\n:00000001FF\n", // checksum
+		":04001000DEADBEEFB4\n", // no end of file record
+		"0C9434\n",
+	} {
+		if _, err := ReadHex(strings.NewReader(bad)); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func FuzzReadHex(f *testing.F) {
+	f.Add([]byte(":100000000C9434000C943E000C943E000C943E0082\n:020000040000FA\n:00000001FF\n"))
+	f.Add([]byte(":020000021000EC\n:0100000000FF\n:00000001FF\n"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		image, err := ReadHex(bytes.NewReader(data))
+		if err == nil && len(image) > maxImage {
+			t.Errorf("image of %d bytes, above the limit", len(image))
+		}
+	})
+}
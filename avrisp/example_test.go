@@ -0,0 +1,51 @@
+package avrisp_test
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/goiot/devices/avrisp"
+	"github.com/goiot/devices/gpio/gpiod"
+	"golang.org/x/exp/io/spi"
+)
+
+func Example() {
+	f, err := os.Open("blink.hex")
+	if err != nil {
+		panic(err)
+	}
+	image, err := avrisp.ReadHex(f)
+	f.Close()
+	if err != nil {
+		panic(err)
+	}
+
+	chip, err := gpiod.Open("/dev/gpiochip0")
+	if err != nil {
+		panic(err)
+	}
+	defer chip.Close()
+
+	// RESET of the ATmega328P on GPIO25, starting in reset
+	reset, err := chip.Output(25, 0, 0)
+	if err != nil {
+		panic(err)
+	}
+	defer reset.Close()
+
+	p, err := avrisp.Open(&spi.Devfs{Dev: "/dev/spidev0.0", Mode: spi.Mode0}, reset)
+	if err != nil {
+		panic(err)
+	}
+	defer p.Close()
+
+	sig, err := p.Signature()
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("signature:", sig)
+
+	if err := p.Program(image, 128); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,98 @@
+package avrisp
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Intel HEX record types.
+const (
+	hexData        = 0x00
+	hexEOF         = 0x01
+	hexExtSegment  = 0x02
+	hexStartSeg    = 0x03
+	hexExtLinear   = 0x04
+	hexStartLinear = 0x05
+)
+
+// maxImage bounds the images read by ReadHex, the largest AVR has 384KB
+// of flash.
+const maxImage = 1 << 20
+
+// ReadHex reads a firmware in the Intel HEX format produced by avr-gcc
+// and the Arduino IDE, and returns its image starting at address 0. The
+// gaps between the records are filled with 0xFF, the value of erased
+// flash.
+func ReadHex(r io.Reader) ([]byte, error) {
+	var (
+		image []byte
+		base  int
+		eof   bool
+	)
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+		if eof {
+			return nil, fmt.Errorf("line %d: record after the end of file record", n)
+		}
+		if line[0] != ':' {
+			return nil, fmt.Errorf("line %d: missing start code", n)
+		}
+		rec, err := hex.DecodeString(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		if len(rec) < 5 || len(rec) != 5+int(rec[0]) {
+			return nil, fmt.Errorf("line %d: invalid record length", n)
+		}
+		var sum byte
+		for _, b := range rec {
+			sum += b
+		}
+		if sum != 0 {
+			return nil, fmt.Errorf("line %d: checksum mismatch", n)
+		}
+		data := rec[4 : len(rec)-1]
+		switch rec[3] {
+		case hexData:
+			addr := base + (int(rec[1])<<8 | int(rec[2]))
+			end := addr + len(data)
+			if end > maxImage {
+				return nil, fmt.Errorf("line %d: address %#x is out of range", n, addr)
+			}
+			for len(image) < end {
+				image = append(image, 0xFF)
+			}
+			copy(image[addr:], data)
+		case hexEOF:
+			eof = true
+		case hexExtSegment, hexExtLinear:
+			if len(data) != 2 {
+				return nil, fmt.Errorf("line %d: invalid address record", n)
+			}
+			base = int(data[0])<<8 | int(data[1])
+			if rec[3] == hexExtSegment {
+				base <<= 4
+			} else {
+				base <<= 16
+			}
+		case hexStartSeg, hexStartLinear:
+			// The start address is meaningless for AVRs.
+		default:
+			return nil, fmt.Errorf("line %d: unknown record type %#x", n, rec[3])
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if !eof {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return image, nil
+}
@@ -0,0 +1,24 @@
+package avrisp
+
+// Serial programming instructions, each one is 4 bytes long.
+const (
+	cmdProgEnable  = 0xAC // 0xAC 0x53 0x00 0x00, echoes 0x53 in the 3rd byte
+	cmdChipErase   = 0x80 // 2nd byte of 0xAC 0x80 0x00 0x00
+	cmdPoll        = 0xF0 // 0xF0 0x00 0x00 busy
+	cmdLoadExtAddr = 0x4D // 0x4D 0x00 addr 0x00
+
+	cmdReadFlashLow   = 0x20 // 0x20 addrMSB addrLSB out
+	cmdReadFlashHigh  = 0x28
+	cmdLoadPageLow    = 0x40 // 0x40 0x00 addrLSB in
+	cmdLoadPageHigh   = 0x48
+	cmdWritePage      = 0x4C // 0x4C addrMSB addrLSB 0x00
+	cmdReadSignature  = 0x30 // 0x30 0x00 index out
+	cmdReadFuseLow    = 0x50 // 0x50 0x00 0x00 out
+	cmdReadFuseHigh   = 0x58 // 0x58 0x08 0x00 out
+	cmdReadFuseExt    = 0x50 // 0x50 0x08 0x00 out
+	cmdWriteFuseLow   = 0xA0 // 2nd byte of 0xAC 0xA0 0x00 in
+	cmdWriteFuseHigh  = 0xA8
+	cmdWriteFuseExt   = 0xA4
+	progEnableEcho    = 0x53
+	fuseHighExtSelect = 0x08
+)
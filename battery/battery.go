@@ -0,0 +1,122 @@
+// Package battery monitors the battery of a device through its fuel gauge
+// and runs the power saving actions when it is low, e.g. dim the display
+// and sample the sensors less often.
+package battery
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/goiot/devices/alerts"
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/events"
+)
+
+// Gauge is a fuel gauge, it is implemented by the drivers of max17043 and
+// lc709203.
+type Gauge interface {
+	// Voltage returns the voltage of the battery in volts.
+	Voltage() (float64, error)
+
+	// Charge returns the state of charge of the battery in percent.
+	Charge() (float64, error)
+}
+
+// Low returns an alert raised when the charge is below percent for a
+// minute, and cleared once it is 5% above, e.g. when the battery charges.
+// The actions run when it is raised and when it is cleared.
+func Low(percent float64, actions ...alerts.Action) *alerts.Alert {
+	return &alerts.Alert{
+		Name:       fmt.Sprintf("battery below %v%%", percent),
+		Kind:       alerts.Below,
+		Threshold:  percent,
+		Hysteresis: 5,
+		For:        time.Minute,
+		Actions:    actions,
+	}
+}
+
+// Monitor polls a gauge.
+type Monitor struct {
+	Gauge Gauge
+
+	// Alerts are evaluated with the charge, e.g. a Low alert at 20%
+	// which dims the display and one at 5% which shuts down.
+	Alerts []*alerts.Alert
+
+	// Bus receives the samples <Source>/charge and <Source>/voltage and
+	// the status <Source> if not nil, Source is battery if empty.
+	Bus    *events.Bus
+	Source string
+
+	// ErrorLog logs the errors of the gauge and of the actions of Run,
+	// the standard logger is used if nil.
+	ErrorLog *log.Logger
+
+	// Clock dates the readings and times Run, clock.Real if nil.
+	Clock clock.Clock
+
+	failing bool
+}
+
+func (m *Monitor) source() string {
+	if m.Source == "" {
+		return "battery"
+	}
+	return m.Source
+}
+
+// Poll reads the gauge, publishes the readings and evaluates the alerts.
+func (m *Monitor) Poll() error {
+	now := clock.Or(m.Clock).Now()
+	charge, err := m.Gauge.Charge()
+	var volts float64
+	if err == nil {
+		volts, err = m.Gauge.Voltage()
+	}
+	if m.Bus != nil && (err != nil) != m.failing {
+		m.Bus.Publish(events.Status{Source: m.source(), Err: err, Time: now})
+	}
+	m.failing = err != nil
+	if err != nil {
+		return fmt.Errorf("reading the battery gauge failed - %v", err)
+	}
+	if m.Bus != nil {
+		m.Bus.Publish(events.Sample{Source: m.source() + "/charge", Value: charge, Unit: "%", Time: now})
+		m.Bus.Publish(events.Sample{Source: m.source() + "/voltage", Value: volts, Unit: "V", Time: now})
+	}
+	var first error
+	for _, a := range m.Alerts {
+		if err := a.Observe(charge, now); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Run polls the gauge every interval until ctx is done, logging the
+// errors.
+func (m *Monitor) Run(ctx context.Context, interval time.Duration) error {
+	t := clock.Or(m.Clock).NewTicker(interval)
+	defer t.Stop()
+	for {
+		if err := m.Poll(); err != nil {
+			m.logf("battery: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C():
+		}
+	}
+}
+
+func (m *Monitor) logf(format string, args ...interface{}) {
+	if m.ErrorLog != nil {
+		m.ErrorLog.Printf(format, args...)
+		return
+	}
+	log.Printf(format, args...)
+}
@@ -0,0 +1,78 @@
+package battery
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/alerts"
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/events"
+	"github.com/goiot/devices/lc709203"
+	"github.com/goiot/devices/max17043"
+)
+
+var (
+	_ Gauge = (*max17043.MAX17043)(nil)
+	_ Gauge = (*lc709203.LC709203)(nil)
+)
+
+type gauge struct {
+	charge, volts float64
+	err           error
+}
+
+func (g *gauge) Voltage() (float64, error) { return g.volts, g.err }
+func (g *gauge) Charge() (float64, error)  { return g.charge, g.err }
+
+func TestMonitor(t *testing.T) {
+	g := &gauge{charge: 50, volts: 3.8}
+	c := clock.NewFake(time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC))
+	dimmed := false
+	bus := events.New()
+	sub := bus.Subscribe("#", 16)
+	m := &Monitor{
+		Gauge:  g,
+		Alerts: []*alerts.Alert{Low(20, alerts.Switch(func() error { dimmed = true; return nil }, func() error { dimmed = false; return nil }))},
+		Bus:    bus,
+		Clock:  c,
+	}
+	if err := m.Poll(); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []events.Sample{{Source: "battery/charge", Value: 50, Unit: "%"}, {Source: "battery/voltage", Value: 3.8, Unit: "V"}} {
+		s := (<-sub.C).(events.Sample)
+		if s.Source != want.Source || s.Value != want.Value || s.Unit != want.Unit || !s.Time.Equal(c.Now()) {
+			t.Errorf("sample = %+v; want %+v", s, want)
+		}
+	}
+
+	g.charge = 15
+	m.Poll()
+	c.Advance(61 * time.Second)
+	m.Poll()
+	if !dimmed {
+		t.Error("the display is not dimmed after a minute below 20%")
+	}
+	g.charge = 22 // within the hysteresis
+	c.Advance(time.Hour)
+	m.Poll()
+	if !dimmed {
+		t.Error("the display is restored at 22%")
+	}
+
+	g.err = errors.New("gauge unplugged")
+	for len(sub.C) > 0 {
+		<-sub.C
+	}
+	if err := m.Poll(); err == nil {
+		t.Error("expected an error from a failing gauge")
+	}
+	if s, ok := (<-sub.C).(events.Status); !ok || s.Source != "battery" || s.Err != g.err {
+		t.Errorf("status = %+v; want the error of the gauge", s)
+	}
+	m.Poll()
+	if len(sub.C) != 0 {
+		t.Error("the status is published again while it did not change")
+	}
+}
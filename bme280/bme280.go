@@ -0,0 +1,159 @@
+// Package bme280 implements a driver for the Bosch BME280 temperature,
+// pressure and humidity sensor.
+package bme280
+
+import (
+	"fmt"
+
+	"golang.org/x/exp/io/i2c"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+// I2C addresses of the sensor, selected by the SDO pin.
+const (
+	Addr    = 0x76
+	AltAddr = 0x77
+)
+
+const (
+	regCalib1  = 0x88 // 26 bytes: T1-T3, P1-P9, H1
+	regChipID  = 0xD0
+	regCalib2  = 0xE1 // 7 bytes: H2-H6
+	regCtrlHum = 0xF2
+	regCtrlMea = 0xF4
+	regConfig  = 0xF5
+	regData    = 0xF7 // 8 bytes: pressure, temperature, humidity
+
+	chipID = 0x60
+
+	ctrlHumX1    = 0x01 // humidity oversampling x1
+	ctrlMeaX1    = 0x27 // temperature and pressure oversampling x1, normal mode
+	configSb1000 = 0xA0 // standby 1000ms between measurements, no filter
+)
+
+// Measurement is the result of a measurement.
+type Measurement struct {
+	Temperature float64 // Temperature in degrees Celsius.
+	Pressure    float64 // Pressure in hPa.
+	Humidity    float64 // Humidity in percent of relative humidity.
+}
+
+// BME280 represents a BME280 sensor.
+type BME280 struct {
+	Device *i2c.Device
+
+	t1                             uint16
+	t2, t3                         int16
+	p1                             uint16
+	p2, p3, p4, p5, p6, p7, p8, p9 int16
+	h1, h3                         uint8
+	h2, h4, h5                     int16
+	h6                             int8
+}
+
+// Open opens the sensor at addr and starts measuring every second.
+func Open(o driver.Opener, addr int) (*BME280, error) {
+	dev, err := i2c.Open(o, addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &BME280{Device: dev}
+	if err := s.init(); err != nil {
+		dev.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func u16(b []byte) uint16 { return uint16(b[0]) | uint16(b[1])<<8 }
+func s16(b []byte) int16  { return int16(u16(b)) }
+
+func (s *BME280) init() error {
+	id := make([]byte, 1)
+	if err := s.Device.ReadReg(regChipID, id); err != nil {
+		return err
+	}
+	if id[0] != chipID {
+		return fmt.Errorf("unexpected chip ID %#x, expected %#x", id[0], chipID)
+	}
+
+	c := make([]byte, 26)
+	if err := s.Device.ReadReg(regCalib1, c); err != nil {
+		return err
+	}
+	s.t1, s.t2, s.t3 = u16(c[0:]), s16(c[2:]), s16(c[4:])
+	s.p1, s.p2, s.p3 = u16(c[6:]), s16(c[8:]), s16(c[10:])
+	s.p4, s.p5, s.p6 = s16(c[12:]), s16(c[14:]), s16(c[16:])
+	s.p7, s.p8, s.p9 = s16(c[18:]), s16(c[20:]), s16(c[22:])
+	s.h1 = c[25]
+
+	c = c[:7]
+	if err := s.Device.ReadReg(regCalib2, c); err != nil {
+		return err
+	}
+	s.h2 = s16(c[0:])
+	s.h3 = c[2]
+	s.h4 = int16(int8(c[3]))<<4 | int16(c[4]&0x0F)
+	s.h5 = int16(int8(c[5]))<<4 | int16(c[4]>>4)
+	s.h6 = int8(c[6])
+
+	// ctrl_hum is applied on the next write of ctrl_meas.
+	for _, w := range [][]byte{
+		{regCtrlHum, ctrlHumX1},
+		{regConfig, configSb1000},
+		{regCtrlMea, ctrlMeaX1},
+	} {
+		if err := s.Device.Write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Read returns the last measurement.
+func (s *BME280) Read() (Measurement, error) {
+	b := make([]byte, 8)
+	if err := s.Device.ReadReg(regData, b); err != nil {
+		return Measurement{}, err
+	}
+	adcP := float64(int32(b[0])<<12 | int32(b[1])<<4 | int32(b[2])>>4)
+	adcT := float64(int32(b[3])<<12 | int32(b[4])<<4 | int32(b[5])>>4)
+	adcH := float64(int32(b[6])<<8 | int32(b[7]))
+
+	// Floating point compensation formulas of the datasheet, section 8.1.
+	v1 := (adcT/16384 - float64(s.t1)/1024) * float64(s.t2)
+	v2 := (adcT/131072 - float64(s.t1)/8192) * (adcT/131072 - float64(s.t1)/8192) * float64(s.t3)
+	tFine := v1 + v2
+	m := Measurement{Temperature: tFine / 5120}
+
+	v1 = tFine/2 - 64000
+	v2 = v1 * v1 * float64(s.p6) / 32768
+	v2 += v1 * float64(s.p5) * 2
+	v2 = v2/4 + float64(s.p4)*65536
+	v1 = (float64(s.p3)*v1*v1/524288 + float64(s.p2)*v1) / 524288
+	v1 = (1 + v1/32768) * float64(s.p1)
+	if v1 != 0 {
+		p := 1048576 - adcP
+		p = (p - v2/4096) * 6250 / v1
+		v1 = float64(s.p9) * p * p / 2147483648
+		v2 = p * float64(s.p8) / 32768
+		m.Pressure = (p + (v1+v2+float64(s.p7))/16) / 100
+	}
+
+	h := tFine - 76800
+	h = (adcH - (float64(s.h4)*64 + float64(s.h5)/16384*h)) *
+		(float64(s.h2) / 65536 * (1 + float64(s.h6)/67108864*h*(1+float64(s.h3)/67108864*h)))
+	h *= 1 - float64(s.h1)*h/524288
+	if h < 0 {
+		h = 0
+	} else if h > 100 {
+		h = 100
+	}
+	m.Humidity = h
+	return m, nil
+}
+
+// Close closes the sensor.
+func (s *BME280) Close() error {
+	return s.Device.Close()
+}
@@ -0,0 +1,80 @@
+package bme280
+
+import (
+	"math"
+	"testing"
+
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+// sensor is a fake BME280 with a map of 256 registers.
+type sensor struct {
+	regs [256]byte
+	reg  byte
+}
+
+func (s *sensor) Open(addr int, tenbit bool) (driver.Conn, error) { return s, nil }
+func (s *sensor) Close() error                                    { return nil }
+
+func (s *sensor) Tx(w, r []byte) error {
+	if len(w) > 0 {
+		s.reg = w[0]
+		copy(s.regs[s.reg:], w[1:])
+	}
+	copy(r, s.regs[s.reg:])
+	return nil
+}
+
+func put16(b []byte, v int) { b[0], b[1] = byte(v), byte(v>>8) }
+
+func TestRead(t *testing.T) {
+	s := &sensor{}
+	s.regs[regChipID] = chipID
+	// calibration of the datasheet example
+	c := s.regs[regCalib1:]
+	for i, v := range []int{27504, 26435, -1000, 36477, -10685, 3024, 2855, 140, -7, 15500, -14600, 6000} {
+		put16(c[2*i:], v)
+	}
+	c[25] = 75
+	copy(s.regs[regCalib2:], []byte{0x6A, 0x01, 0, 0x13, 0x29, 0x03, 30}) // H2=362 H4=313 H5=50 H6=30
+
+	dev, err := Open(s, Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.regs[regCtrlMea] != ctrlMeaX1 || s.regs[regCtrlHum] != ctrlHumX1 {
+		t.Error("the measurements are not started")
+	}
+
+	// adc_P=415148 adc_T=519888 adc_H=30000
+	copy(s.regs[regData:], []byte{0x65, 0x5A, 0xC0, 0x7E, 0xED, 0x00, 0x75, 0x30})
+	m, err := dev.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		name      string
+		got, want float64
+	}{
+		{"temperature", m.Temperature, 25.08},
+		{"pressure", m.Pressure, 1006.53},
+		{"humidity", m.Humidity, 55.00},
+	} {
+		if math.Abs(c.got-c.want) > 0.01 {
+			t.Errorf("%v = %.3f, want %.2f", c.name, c.got, c.want)
+		}
+	}
+
+	cond, err := dev.Conditions()
+	if err != nil || cond.Temperature != m.Temperature || cond.Humidity != m.Humidity || cond.Pressure != m.Pressure {
+		t.Errorf("Conditions = %+v, %v; want %+v", cond, err, m)
+	}
+}
+
+func TestChipID(t *testing.T) {
+	s := &sensor{}
+	s.regs[regChipID] = 0x58 // BMP280
+	if _, err := Open(s, Addr); err == nil {
+		t.Error("expected an error on a BMP280")
+	}
+}
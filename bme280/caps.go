@@ -0,0 +1,21 @@
+package bme280
+
+import "github.com/goiot/devices/caps"
+
+// Caps are the capabilities of the sensor.
+var Caps = caps.Capabilities{
+	Name:      "BME280",
+	Bus:       "i2c",
+	Addresses: []int{Addr, AltAddr},
+	Measurements: []caps.Measurement{
+		{Kind: caps.Temperature, Unit: "C", Min: -40, Max: 85, Resolution: 0.01},
+		{Kind: caps.Pressure, Unit: "hPa", Min: 300, Max: 1100, Resolution: 0.0018},
+		{Kind: caps.Humidity, Unit: "%", Min: 0, Max: 100, Resolution: 0.008},
+	},
+	PowerModes: []string{"sleep", "forced", "normal"},
+}
+
+func init() { caps.Register(Caps) }
+
+// Capabilities implements caps.Describer.
+func (s *BME280) Capabilities() caps.Capabilities { return Caps }
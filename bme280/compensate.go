@@ -0,0 +1,12 @@
+package bme280
+
+import "github.com/goiot/devices/compensate"
+
+// Conditions implements compensate.Source.
+func (s *BME280) Conditions() (compensate.Conditions, error) {
+	m, err := s.Read()
+	if err != nil {
+		return compensate.Unknown(), err
+	}
+	return compensate.Conditions{Temperature: m.Temperature, Humidity: m.Humidity, Pressure: m.Pressure}, nil
+}
@@ -0,0 +1,252 @@
+// Package motorhat drives the Adafruit DC & Stepper Motor HAT and Bonnet
+// for Raspberry Pi, whose PCA9685 PWM controller drives two TB6612
+// H-bridges: four DC motors, two stepper motors, or one stepper and two
+// DC motors. The motors implement the interfaces of the motor package.
+package motorhat
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/pca9685"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+// Addr is the I2C address of the HAT with no address jumper soldered.
+// The jumpers give addresses up to 0x7F, to stack HATs.
+const Addr = 0x60
+
+// Frequency is the PWM frequency of the H-bridges, above the audible
+// range of most motors.
+const Frequency = 1600
+
+// bridge lists the PCA9685 channels of an H-bridge output.
+type bridge struct{ pwm, in1, in2 int }
+
+// bridges are the outputs M1 to M4.
+var bridges = [4]bridge{{8, 10, 9}, {13, 11, 12}, {2, 4, 3}, {7, 5, 6}}
+
+// HAT represents a motor HAT. It must be closed if no longer in use.
+type HAT struct {
+	PWM *pca9685.PCA9685
+	// Clock times the steps of the steppers, clock.Real if nil.
+	Clock clock.Clock
+}
+
+// Open opens the HAT at addr, with all its motors released.
+func Open(o driver.Opener, addr int) (*HAT, error) {
+	p, err := pca9685.Open(o, addr)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.SetFrequency(Frequency); err != nil {
+		p.Close()
+		return nil, err
+	}
+	return &HAT{PWM: p}, nil
+}
+
+// Close releases the motors and closes the PWM controller.
+func (h *HAT) Close() error {
+	return h.PWM.Close()
+}
+
+// on sets the channel fully on or off.
+func (h *HAT) on(ch int, on bool) error {
+	if on {
+		return h.PWM.SetDuty(ch, 1)
+	}
+	return h.PWM.SetDuty(ch, 0)
+}
+
+// drive sets the current of the bridge b to the fraction current of the
+// maximum, negative backwards. No current lets the motor coast.
+func (h *HAT) drive(b bridge, current float64) error {
+	if err := h.on(b.in1, current > 0); err != nil {
+		return err
+	}
+	if err := h.on(b.in2, current < 0); err != nil {
+		return err
+	}
+	return h.PWM.SetDuty(b.pwm, math.Abs(current))
+}
+
+// Motor returns the DC motor connected to the output Mn, 1 to 4.
+func (h *HAT) Motor(n int) (*DC, error) {
+	if n < 1 || n > 4 {
+		return nil, fmt.Errorf("M%d is not an output of the HAT", n)
+	}
+	return &DC{h: h, b: bridges[n-1]}, nil
+}
+
+// DC is a DC motor. It implements motor.Motor.
+type DC struct {
+	h *HAT
+	b bridge
+}
+
+// SetSpeed sets the speed of the motor, from -1 to 1.
+func (m *DC) SetSpeed(speed float64) error {
+	if speed < -1 || speed > 1 {
+		return fmt.Errorf("invalid speed %v, must be -1 to 1", speed)
+	}
+	return m.h.drive(m.b, speed)
+}
+
+// Brake shorts the motor, which stops quicker than when coasting.
+func (m *DC) Brake() error {
+	if err := m.h.on(m.b.in1, true); err != nil {
+		return err
+	}
+	if err := m.h.on(m.b.in2, true); err != nil {
+		return err
+	}
+	return m.h.on(m.b.pwm, true)
+}
+
+// Style is the way the coils of a stepper are driven.
+type Style int
+
+const (
+	// Single powers one coil at a time.
+	Single Style = iota
+	// Double powers both coils, for more torque.
+	Double
+	// Interleave alternates Single and Double, turning by half steps.
+	Interleave
+	// Microstep powers the coils with sine and cosine currents, turning
+	// by Microsteps steps per full step, the smoothest and quietest.
+	Microstep
+)
+
+// phases is the number of phases of a full step, the resolution of the
+// position of the steppers.
+const phases = 64
+
+// Stepper returns the stepper motor connected to the outputs M1 and M2,
+// for n = 1, or M3 and M4 for n = 2. It turns by stepsPerRev full steps
+// per revolution, 200 for the 1.8° steppers, at 30 rpm.
+func (h *HAT) Stepper(n int, stepsPerRev int) (*Stepper, error) {
+	if n < 1 || n > 2 {
+		return nil, fmt.Errorf("there is no stepper %d on the HAT, only 1 and 2", n)
+	}
+	if stepsPerRev < 1 {
+		return nil, fmt.Errorf("invalid number of steps per revolution %d", stepsPerRev)
+	}
+	s := &Stepper{h: h, a: bridges[2*n-2], b: bridges[2*n-1], stepsPerRev: stepsPerRev, Microsteps: 16}
+	s.SetRPM(30)
+	return s, nil
+}
+
+// Stepper is a bipolar stepper motor. It implements motor.Stepper.
+type Stepper struct {
+	Style Style
+	// Microsteps is the number of steps of the Microstep style per full
+	// step: 2, 4, 8, 16, 32 or 64.
+	Microsteps int
+
+	h           *HAT
+	a, b        bridge
+	stepsPerRev int
+	delay       time.Duration // per full step
+	phase       int           // electrical angle, 90° per full step
+}
+
+// SetRPM sets the speed of the steps in revolutions per minute.
+func (s *Stepper) SetRPM(rpm float64) error {
+	if rpm <= 0 {
+		return fmt.Errorf("invalid speed %v rpm", rpm)
+	}
+	s.delay = time.Duration(float64(time.Minute) / (rpm * float64(s.stepsPerRev)))
+	return nil
+}
+
+// Step turns the motor by n full steps, through the half steps or the
+// microsteps of its style.
+func (s *Stepper) Step(n int) error {
+	inc := phases
+	switch s.Style {
+	case Single:
+		s.phase = align(s.phase, 0)
+	case Double:
+		s.phase = align(s.phase, phases/2)
+	case Interleave:
+		s.phase = align(s.phase, 0)
+		inc = phases / 2
+	case Microstep:
+		if s.Microsteps < 1 || phases%s.Microsteps != 0 {
+			return fmt.Errorf("invalid number of microsteps %d", s.Microsteps)
+		}
+		inc = phases / s.Microsteps
+	default:
+		return fmt.Errorf("invalid style %d", s.Style)
+	}
+	if n < 0 {
+		n, inc = -n, -inc
+	}
+	c := clock.Or(s.h.Clock)
+	delay := s.delay * time.Duration(abs(inc)) / phases
+	for i := 0; i < n*phases/abs(inc); i++ {
+		s.phase += inc
+		if err := s.energize(); err != nil {
+			return err
+		}
+		c.Sleep(delay)
+	}
+	return nil
+}
+
+// energize drives the coils for the current phase.
+func (s *Stepper) energize() error {
+	theta := float64(s.phase) * math.Pi / 2 / phases
+	a, b := math.Cos(theta), math.Sin(theta)
+	if s.Style != Microstep {
+		a, b = full(a), full(b)
+	}
+	if err := s.h.drive(s.a, a); err != nil {
+		return err
+	}
+	return s.h.drive(s.b, b)
+}
+
+// Release turns the coils off.
+func (s *Stepper) Release() error {
+	if err := s.h.drive(s.a, 0); err != nil {
+		return err
+	}
+	return s.h.drive(s.b, 0)
+}
+
+// align returns the phase of the full step position, offset by offset,
+// nearest to phase.
+func align(phase, offset int) int {
+	p := phase - offset
+	q := p / phases
+	if r := p - q*phases; r >= phases/2 {
+		q++
+	} else if r < -phases/2 {
+		q--
+	}
+	return q*phases + offset
+}
+
+// full returns the full current of the direction of v, 0 if the coil is
+// off.
+func full(v float64) float64 {
+	switch {
+	case v > 1e-9:
+		return 1
+	case v < -1e-9:
+		return -1
+	}
+	return 0
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
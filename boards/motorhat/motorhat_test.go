@@ -0,0 +1,133 @@
+package motorhat
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/i2csim"
+	"github.com/goiot/devices/motor"
+)
+
+var (
+	_ motor.Motor   = (*DC)(nil)
+	_ motor.Stepper = (*Stepper)(nil)
+)
+
+func newHAT(t *testing.T) (*HAT, *clock.Fake, func(ch int) float64) {
+	regs := i2csim.NewRegisters()
+	bus := i2csim.NewBus()
+	bus.Attach(Addr, regs)
+	h, err := Open(bus, Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := clock.NewFake(time.Time{})
+	c.SetAutoSleep(true)
+	h.Clock = c
+	duty := func(ch int) float64 {
+		b := regs.Get(byte(0x06+4*ch), 4)
+		switch {
+		case b[1]&0x10 != 0:
+			return 1
+		case b[3]&0x10 != 0:
+			return 0
+		}
+		return float64(int(b[3])<<8|int(b[2])) / 4096
+	}
+	return h, c, duty
+}
+
+func TestDC(t *testing.T) {
+	h, _, duty := newHAT(t)
+	m, err := h.Motor(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		speed         float64
+		pwm, in1, in2 float64
+	}{
+		{0.5, 0.5, 1, 0},
+		{-1, 1, 0, 1},
+		{0, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		if err := m.SetSpeed(tt.speed); err != nil {
+			t.Fatal(err)
+		}
+		if pwm, in1, in2 := duty(8), duty(10), duty(9); pwm != tt.pwm || in1 != tt.in1 || in2 != tt.in2 {
+			t.Errorf("speed %v: PWM %v, IN1 %v, IN2 %v; want %v, %v, %v", tt.speed, pwm, in1, in2, tt.pwm, tt.in1, tt.in2)
+		}
+	}
+	if err := m.Brake(); err != nil || duty(10) != 1 || duty(9) != 1 {
+		t.Errorf("Brake = %v, IN1 %v, IN2 %v; want both high", err, duty(10), duty(9))
+	}
+	if err := m.SetSpeed(1.5); err == nil {
+		t.Error("expected an error at speed 1.5")
+	}
+	if _, err := h.Motor(5); err == nil {
+		t.Error("expected an error with M5")
+	}
+}
+
+func TestStepper(t *testing.T) {
+	h, c, duty := newHAT(t)
+	s, err := h.Stepper(1, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// coil A on M1, channels 8, 10 and 9, coil B on M2, channels 13, 11
+	// and 12
+	coils := func() (float64, float64) {
+		return duty(8) * (duty(10) - duty(9)), duty(13) * (duty(11) - duty(12))
+	}
+	for _, tt := range []struct {
+		style Style
+		steps int
+		a, b  float64
+	}{
+		{Single, 1, 0, 1},
+		{Single, 1, -1, 0},
+		{Single, -2, 1, 0},
+		{Double, 1, -1, 1},
+		{Double, -1, 1, 1},
+		{Interleave, 1, -1, 0},
+	} {
+		s.Style = tt.style
+		if err := s.Step(tt.steps); err != nil {
+			t.Fatal(err)
+		}
+		if a, b := coils(); a != tt.a || b != tt.b {
+			t.Errorf("after %d steps of style %d, coils at %v, %v; want %v, %v", tt.steps, tt.style, a, b, tt.a, tt.b)
+		}
+	}
+
+	// 30 rpm of 200 steps, 10ms per step
+	start := c.Now()
+	s.Style = Microstep
+	if err := s.Step(3); err != nil {
+		t.Fatal(err)
+	}
+	if d := c.Now().Sub(start); d != 30*time.Millisecond {
+		t.Errorf("3 microstepped steps took %v; want 30ms", d)
+	}
+	s.phase = 16 // 22.5°
+	if err := s.energize(); err != nil {
+		t.Fatal(err)
+	}
+	if a, b := coils(); math.Abs(a-0.924) > 0.001 || math.Abs(b-0.383) > 0.001 {
+		t.Errorf("coils at %v, %v at a quarter step; want cos and sin of 22.5°", a, b)
+	}
+
+	if err := s.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if a, b := coils(); a != 0 || b != 0 {
+		t.Errorf("coils at %v, %v once released", a, b)
+	}
+	if _, err := h.Stepper(3, 200); err == nil {
+		t.Error("expected an error with stepper 3")
+	}
+}
@@ -0,0 +1,184 @@
+// Package pioled opens the Adafruit PiOLED (128x32) and the Adafruit
+// 128x64 OLED Bonnet, with its buttons and joystick, on a Raspberry Pi.
+package pioled
+
+import (
+	"sync"
+	"time"
+
+	"github.com/goiot/devices/gpio"
+	"github.com/goiot/devices/gpio/gpiod"
+	"github.com/goiot/devices/i2cbus"
+	"github.com/goiot/devices/monochromeoled"
+	"golang.org/x/exp/io/i2c"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+const addr = 0x3C
+
+// initPiOLED is the initialization of the 128x32 panel of the PiOLED,
+// the multiplex ratio and the COM pins configuration differ from the
+// 128x64 panels.
+var initPiOLED = []byte{
+	0x00, // commands follow
+	0xAE,
+	0xD5, 0x80,
+	0xA8, 32 - 1, // multiplex ratio
+	0xD3, 0x00,
+	0x40,
+	0x8D, 0x14,
+	0x20, 0x00,
+	0xA1,
+	0xC8,
+	0xDA, 0x02, // sequential COM pins
+	0x81, 0x8F,
+	0xD9, 0xF1,
+	0xDB, 0x40,
+	0xA4, 0xA6,
+	0x2E,
+	0xAF,
+}
+
+// OpenPiOLED opens the PiOLED on the I2C bus of the GPIO header.
+func OpenPiOLED() (*monochromeoled.OLED, error) {
+	bus, err := i2cbus.Open("primary")
+	if err != nil {
+		return nil, err
+	}
+	return openPiOLED(bus)
+}
+
+func openPiOLED(o driver.Opener) (*monochromeoled.OLED, error) {
+	dev, err := i2c.Open(o, addr)
+	if err != nil {
+		return nil, err
+	}
+	if err := dev.Write(initPiOLED); err != nil {
+		dev.Close()
+		return nil, err
+	}
+	return monochromeoled.OpenWithI2c(dev, 32)
+}
+
+// Button identifies the buttons of the Bonnet.
+type Button int
+
+const (
+	A Button = iota
+	B
+	Up
+	Down
+	Left
+	Right
+	Center // the joystick is pushed in
+)
+
+func (b Button) String() string {
+	return [...]string{"A", "B", "Up", "Down", "Left", "Right", "Center"}[b]
+}
+
+// pins are the GPIOs of the buttons, they connect to ground when pressed.
+var pins = map[Button]int{A: 5, B: 6, Up: 17, Down: 22, Left: 27, Right: 23, Center: 4}
+
+// Event is a button being pressed or released.
+type Event struct {
+	Button  Button
+	Pressed bool
+	Time    time.Time
+}
+
+// Bonnet represents the OLED Bonnet. It must be closed if no longer in use.
+type Bonnet struct {
+	Display *monochromeoled.OLED
+
+	chip     *gpiod.Chip
+	watchers []gpio.Watcher
+	events   chan Event
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// OpenBonnet opens the display of the Bonnet on the I2C bus of the GPIO
+// header and watches its buttons on /dev/gpiochip0.
+func OpenBonnet() (*Bonnet, error) {
+	bus, err := i2cbus.Open("primary")
+	if err != nil {
+		return nil, err
+	}
+	chip, err := gpiod.Open("/dev/gpiochip0")
+	if err != nil {
+		return nil, err
+	}
+	watchers := make(map[Button]gpio.Watcher)
+	fail := func(err error) (*Bonnet, error) {
+		for _, w := range watchers {
+			w.Close()
+		}
+		chip.Close()
+		return nil, err
+	}
+	for b, pin := range pins {
+		w, err := chip.Watch(pin, gpio.BothEdges, gpiod.PullUp)
+		if err != nil {
+			return fail(err)
+		}
+		watchers[b] = w
+	}
+	d, err := monochromeoled.Open(bus)
+	if err != nil {
+		return fail(err)
+	}
+	bonnet := newBonnet(d, watchers)
+	bonnet.chip = chip
+	return bonnet, nil
+}
+
+func newBonnet(d *monochromeoled.OLED, watchers map[Button]gpio.Watcher) *Bonnet {
+	b := &Bonnet{
+		Display: d,
+		events:  make(chan Event, 16),
+		done:    make(chan struct{}),
+	}
+	for button, w := range watchers {
+		b.watchers = append(b.watchers, w)
+		b.wg.Add(1)
+		go b.watch(button, w)
+	}
+	return b
+}
+
+func (b *Bonnet) watch(button Button, w gpio.Watcher) {
+	defer b.wg.Done()
+	for {
+		e, err := w.Wait(-1)
+		if err != nil {
+			return // closed
+		}
+		select {
+		case b.events <- Event{Button: button, Pressed: e.Edge == gpio.FallingEdge, Time: e.Time}:
+		case <-b.done:
+			return
+		}
+	}
+}
+
+// Events returns the presses and releases of the buttons. The channel is
+// closed by Close. The buttons are not debounced, a press can be followed
+// by a few quick releases and presses.
+func (b *Bonnet) Events() <-chan Event {
+	return b.events
+}
+
+// Close stops watching the buttons and closes the display.
+func (b *Bonnet) Close() error {
+	close(b.done)
+	for _, w := range b.watchers {
+		w.Close()
+	}
+	b.wg.Wait()
+	close(b.events)
+	if b.chip != nil {
+		b.chip.Close()
+	}
+	return b.Display.Close()
+}
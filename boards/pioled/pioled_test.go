@@ -0,0 +1,75 @@
+package pioled
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/gpio"
+	"github.com/goiot/devices/monochromeoled"
+	"github.com/goiot/devices/monochromeoled/oledsim"
+)
+
+func TestPiOLED(t *testing.T) {
+	sim := oledsim.New(128, 32)
+	d, err := openPiOLED(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Height() != 32 {
+		t.Errorf("Height = %d, want 32", d.Height())
+	}
+	if err := d.SetPixel(127, 31, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	if !sim.On() {
+		t.Error("the display should be on")
+	}
+	if v := sim.Image().GrayAt(127, 31).Y; v == 0 {
+		t.Error("the bottom right pixel should be lit")
+	}
+}
+
+// button is a fake watcher sending the queued edges.
+type button struct {
+	edges chan gpio.Edge
+}
+
+func (b *button) Read() (int, error) { return 0, nil }
+func (b *button) Write(v int) error  { return errors.New("input") }
+func (b *button) Close() error       { close(b.edges); return nil }
+
+func (b *button) Wait(timeout time.Duration) (gpio.Event, error) {
+	e, ok := <-b.edges
+	if !ok {
+		return gpio.Event{}, errors.New("closed")
+	}
+	return gpio.Event{Edge: e, Time: time.Now()}, nil
+}
+
+func TestEvents(t *testing.T) {
+	d, err := monochromeoled.Open(oledsim.New(128, 64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &button{edges: make(chan gpio.Edge, 2)}
+	b := newBonnet(d, map[Button]gpio.Watcher{A: a})
+
+	a.edges <- gpio.FallingEdge
+	a.edges <- gpio.RisingEdge
+	for _, pressed := range []bool{true, false} {
+		e := <-b.Events()
+		if e.Button != A || e.Pressed != pressed {
+			t.Errorf("got %+v, want A pressed=%v", e, pressed)
+		}
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-b.Events(); ok {
+		t.Error("the channel should be closed")
+	}
+}
@@ -0,0 +1,121 @@
+// Package camera grabs frames from a V4L2 video capture device, such as a
+// USB webcam, on Linux. A Camera is a mirror.Source: frames are shown on
+// a display by the scaling and dithering stages of the mirror package,
+// optionally through a Motion stage outlining the moving areas.
+package camera
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"sync"
+)
+
+// Format is a pixel format, a V4L2 fourcc code.
+type Format uint32
+
+// Formats supported by the package, the usual formats of the webcams.
+const (
+	YUYV  Format = 'Y' | 'U'<<8 | 'Y'<<16 | 'V'<<24 // YUV 4:2:2, packed
+	MJPEG Format = 'M' | 'J'<<8 | 'P'<<16 | 'G'<<24 // motion JPEG
+)
+
+func (f Format) String() string {
+	return string([]byte{byte(f), byte(f >> 8), byte(f >> 16), byte(f >> 24)})
+}
+
+// Config is the requested format of the frames, the device picks the
+// closest size it supports.
+type Config struct {
+	Width, Height int
+	Format        Format // YUYV if zero
+}
+
+// decodeYUYV converts a YUYV frame to img, which is allocated if nil or
+// of a different size.
+func decodeYUYV(img *image.YCbCr, b []byte, w, h, stride int) (*image.YCbCr, error) {
+	if len(b) < stride*(h-1)+2*w || w%2 != 0 {
+		return nil, fmt.Errorf("invalid YUYV frame of %d bytes for %dx%d pixels", len(b), w, h)
+	}
+	if img == nil || img.Rect.Dx() != w || img.Rect.Dy() != h {
+		img = image.NewYCbCr(image.Rect(0, 0, w, h), image.YCbCrSubsampleRatio422)
+	}
+	for y := 0; y < h; y++ {
+		row := b[y*stride:]
+		yi, ci := y*img.YStride, y*img.CStride
+		for x := 0; x < w; x += 2 {
+			img.Y[yi+x] = row[2*x]
+			img.Cb[ci+x/2] = row[2*x+1]
+			img.Y[yi+x+1] = row[2*x+2]
+			img.Cr[ci+x/2] = row[2*x+3]
+		}
+	}
+	return img, nil
+}
+
+// decodeMJPEG decodes a motion JPEG frame. The webcams often leave out
+// the Huffman tables, which are then the default tables of the JPEG
+// standard.
+func decodeMJPEG(b []byte) (image.Image, error) {
+	if !hasDHT(b) {
+		b = insertDHT(b)
+	}
+	return jpeg.Decode(bytes.NewReader(b))
+}
+
+// hasDHT reports whether the JPEG stream defines Huffman tables before
+// its first scan.
+func hasDHT(b []byte) bool {
+	for i := 2; i+4 <= len(b); {
+		if b[i] != 0xFF {
+			return false
+		}
+		switch b[i+1] {
+		case 0xC4:
+			return true
+		case 0xDA:
+			return false
+		}
+		i += 2 + (int(b[i+2])<<8 | int(b[i+3]))
+	}
+	return false
+}
+
+// insertDHT inserts the default Huffman tables after the start of image
+// marker.
+func insertDHT(b []byte) []byte {
+	if len(b) < 2 {
+		return b
+	}
+	out := make([]byte, 0, len(b)+len(defaultDHT()))
+	out = append(out, b[:2]...)
+	out = append(out, defaultDHT()...)
+	return append(out, b[2:]...)
+}
+
+var (
+	dhtOnce sync.Once
+	dht     []byte
+)
+
+// defaultDHT returns the DHT segments of the default tables, extracted
+// from a JPEG encoded by image/jpeg which always uses them.
+func defaultDHT() []byte {
+	dhtOnce.Do(func() {
+		var buf bytes.Buffer
+		jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 8, 8)), nil)
+		b := buf.Bytes()
+		for i := 2; i+4 <= len(b) && b[i+1] != 0xDA; {
+			n := 2 + (int(b[i+2])<<8 | int(b[i+3]))
+			if b[i+1] == 0xC4 {
+				dht = append(dht, b[i:i+n]...)
+			}
+			i += n
+		}
+	})
+	return dht
+}
+
+var errClosed = errors.New("the camera is closed")
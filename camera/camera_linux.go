@@ -0,0 +1,177 @@
+//go:build linux && !tinygo
+// +build linux,!tinygo
+
+package camera
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// buffers is the number of frames queued to the driver.
+const buffers = 4
+
+// Camera is an open V4L2 capture device. It must be closed if no longer
+// in use.
+type Camera struct {
+	f      *os.File
+	w, h   int
+	stride int
+	format Format
+	bufs   [][]byte
+
+	mu     sync.Mutex
+	closed bool
+	yuv    *image.YCbCr
+}
+
+// Open opens the capture device dev, e.g. /dev/video0, and starts
+// streaming frames in the configured format.
+func Open(dev string, c Config) (*Camera, error) {
+	if c.Format == 0 {
+		c.Format = YUYV
+	}
+	if c.Format != YUYV && c.Format != MJPEG {
+		return nil, fmt.Errorf("unsupported format %v", c.Format)
+	}
+	f, err := os.OpenFile(dev, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	cam := &Camera{f: f}
+	if err := cam.init(c); err != nil {
+		cam.release()
+		return nil, err
+	}
+	return cam, nil
+}
+
+func (c *Camera) init(cfg Config) error {
+	var cap capability
+	if err := ioctl(c.f, queryCapIoctl, unsafe.Pointer(&cap)); err != nil {
+		return fmt.Errorf("%s is not a V4L2 device - %v", c.f.Name(), err)
+	}
+	caps := cap.capabilities
+	if caps&capDeviceCaps != 0 {
+		caps = cap.deviceCaps
+	}
+	if caps&capVideoCapture == 0 || caps&capStreaming == 0 {
+		return fmt.Errorf("%s can't stream video captures", c.f.Name())
+	}
+
+	fmtReq := format{typ: bufTypeVideoCapture}
+	fmtReq.fmt.pix = pixFormat{width: uint32(cfg.Width), height: uint32(cfg.Height), pixelFormat: uint32(cfg.Format), field: fieldNone}
+	if err := ioctl(c.f, setFmtIoctl, unsafe.Pointer(&fmtReq)); err != nil {
+		return fmt.Errorf("setting the format failed - %v", err)
+	}
+	pix := fmtReq.fmt.pix
+	if Format(pix.pixelFormat) != cfg.Format {
+		return fmt.Errorf("the device doesn't support the format %v", cfg.Format)
+	}
+	c.w, c.h, c.stride, c.format = int(pix.width), int(pix.height), int(pix.bytesPerLine), cfg.Format
+	if c.stride == 0 {
+		c.stride = 2 * c.w
+	}
+
+	req := requestBuffers{count: buffers, typ: bufTypeVideoCapture, memory: memoryMmap}
+	if err := ioctl(c.f, reqBufsIoctl, unsafe.Pointer(&req)); err != nil {
+		return fmt.Errorf("requesting the buffers failed - %v", err)
+	}
+	for i := uint32(0); i < req.count; i++ {
+		buf := buffer{index: i, typ: bufTypeVideoCapture, memory: memoryMmap}
+		if err := ioctl(c.f, queryBufIoctl, unsafe.Pointer(&buf)); err != nil {
+			return fmt.Errorf("querying the buffer %d failed - %v", i, err)
+		}
+		b, err := syscall.Mmap(int(c.f.Fd()), int64(uint32(buf.m)), int(buf.length), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+		if err != nil {
+			return fmt.Errorf("mapping the buffer %d failed - %v", i, err)
+		}
+		c.bufs = append(c.bufs, b)
+		if err := ioctl(c.f, qBufIoctl, unsafe.Pointer(&buf)); err != nil {
+			return fmt.Errorf("queuing the buffer %d failed - %v", i, err)
+		}
+	}
+	typ := uint32(bufTypeVideoCapture)
+	if err := ioctl(c.f, streamOnIoctl, unsafe.Pointer(&typ)); err != nil {
+		return fmt.Errorf("starting the stream failed - %v", err)
+	}
+	return nil
+}
+
+// Size returns the size of the frames chosen by the device.
+func (c *Camera) Size() (w, h int) { return c.w, c.h }
+
+// Capture implements mirror.Source, it waits for the next frame. The
+// YUYV frames are returned in an image reused by the next call.
+func (c *Camera) Capture() (image.Image, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, errClosed
+	}
+	buf := buffer{typ: bufTypeVideoCapture, memory: memoryMmap}
+	if err := c.dequeue(&buf); err != nil {
+		return nil, err
+	}
+	defer ioctl(c.f, qBufIoctl, unsafe.Pointer(&buf))
+
+	data := c.bufs[buf.index][:buf.bytesUsed]
+	if c.format == MJPEG {
+		return decodeMJPEG(data)
+	}
+	img, err := decodeYUYV(c.yuv, data, c.w, c.h, c.stride)
+	if err != nil {
+		return nil, err
+	}
+	c.yuv = img
+	return img, nil
+}
+
+// dequeue waits for a filled buffer, the file is non-blocking and polled
+// by the runtime.
+func (c *Camera) dequeue(buf *buffer) error {
+	rc, err := c.f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno syscall.Errno
+	if err := rc.Read(func(fd uintptr) bool {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, dqBufIoctl, uintptr(unsafe.Pointer(buf)))
+		return errno != syscall.EAGAIN && errno != syscall.EINTR
+	}); err != nil {
+		return err
+	}
+	if errno != 0 {
+		return fmt.Errorf("capturing a frame failed - %v", errno)
+	}
+	if int(buf.index) >= len(c.bufs) {
+		return errors.New("the driver returned an unknown buffer")
+	}
+	return nil
+}
+
+// Close stops the stream and closes the device.
+func (c *Camera) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	typ := uint32(bufTypeVideoCapture)
+	ioctl(c.f, streamOffIoctl, unsafe.Pointer(&typ))
+	return c.release()
+}
+
+func (c *Camera) release() error {
+	for _, b := range c.bufs {
+		syscall.Munmap(b)
+	}
+	c.bufs = nil
+	return c.f.Close()
+}
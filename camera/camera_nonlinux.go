@@ -0,0 +1,28 @@
+//go:build !linux || tinygo
+// +build !linux tinygo
+
+package camera
+
+import (
+	"errors"
+	"image"
+)
+
+var errNotImplemented = errors.New("not implemented on this platform")
+
+// Camera is no-implementation so developers using cross compilation
+// can rely on local tools even though the real implementation isn't
+// available on their platform.
+type Camera struct{}
+
+// Open is not implemented on this platform.
+func Open(dev string, c Config) (*Camera, error) { return nil, errNotImplemented }
+
+// Size is not implemented on this platform.
+func (c *Camera) Size() (w, h int) { return 0, 0 }
+
+// Capture is not implemented on this platform.
+func (c *Camera) Capture() (image.Image, error) { return nil, errNotImplemented }
+
+// Close is not implemented on this platform.
+func (c *Camera) Close() error { return errNotImplemented }
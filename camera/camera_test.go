@@ -0,0 +1,110 @@
+package camera
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+func TestDecodeYUYV(t *testing.T) {
+	// 2x2 pixels, with 4 bytes of padding per row
+	b := []byte{
+		10, 100, 20, 200, 0, 0, 0, 0,
+		30, 110, 40, 210, 0, 0, 0, 0,
+	}
+	img, err := decodeYUYV(nil, b, 2, 2, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := img.YCbCrAt(1, 1); got != (color.YCbCr{Y: 40, Cb: 110, Cr: 210}) {
+		t.Errorf("pixel (1, 1) = %v, want {40 110 210}", got)
+	}
+	if again, _ := decodeYUYV(img, b, 2, 2, 8); again != img {
+		t.Error("the image of the same size is not reused")
+	}
+	if _, err := decodeYUYV(nil, b[:10], 2, 2, 8); err == nil {
+		t.Error("expected an error for a truncated frame")
+	}
+}
+
+func TestDecodeMJPEG(t *testing.T) {
+	src := image.NewGray(image.Rect(0, 0, 16, 16))
+	for i := range src.Pix {
+		src.Pix[i] = byte(i)
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, src, nil); err != nil {
+		t.Fatal(err)
+	}
+	full := buf.Bytes()
+	if !hasDHT(full) {
+		t.Fatal("the tables of image/jpeg are not found")
+	}
+
+	// strip the tables as the webcams do
+	var stripped []byte
+	stripped = append(stripped, full[:2]...)
+	for i := 2; ; {
+		n := 2 + (int(full[i+2])<<8 | int(full[i+3]))
+		if full[i+1] == 0xDA {
+			stripped = append(stripped, full[i:]...)
+			break
+		}
+		if full[i+1] != 0xC4 {
+			stripped = append(stripped, full[i:i+n]...)
+		}
+		i += n
+	}
+	if hasDHT(stripped) {
+		t.Fatal("the tables are not stripped")
+	}
+	img, err := decodeMJPEG(stripped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := jpeg.Decode(bytes.NewReader(full))
+	if img.At(5, 7) != want.At(5, 7) {
+		t.Errorf("pixel (5, 7) = %v, want %v", img.At(5, 7), want.At(5, 7))
+	}
+}
+
+// frames is a source returning its images in turn.
+type frames []image.Image
+
+func (f *frames) Capture() (image.Image, error) {
+	img := (*f)[0]
+	*f = (*f)[1:]
+	return img, nil
+}
+
+func TestMotion(t *testing.T) {
+	still := image.NewGray(image.Rect(0, 0, 64, 64))
+	moved := image.NewGray(still.Rect)
+	for y := 20; y < 28; y++ {
+		for x := 40; x < 48; x++ {
+			moved.SetGray(x, y, color.Gray{Y: 0xFF})
+		}
+	}
+	src := frames{still, still, moved}
+	m := &Motion{Source: &src}
+	for i := 0; i < 2; i++ {
+		if _, err := m.Capture(); err != nil {
+			t.Fatal(err)
+		}
+		if !m.Area().Empty() {
+			t.Errorf("frame %d: motion detected in %v", i, m.Area())
+		}
+	}
+	img, err := m.Capture()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := m.Area(); a != image.Rect(40, 20, 48, 28) {
+		t.Errorf("Area = %v, want (40,20)-(48,28)", a)
+	}
+	if r, _, _, _ := img.At(40, 24).RGBA(); r != 0xFFFF {
+		t.Error("the area is not outlined")
+	}
+}
@@ -0,0 +1,50 @@
+// Preview shows the video of a webcam on the display of a Pimoroni Enviro
+// board, with the moving areas outlined.
+package main
+
+import (
+	"context"
+
+	"github.com/goiot/devices/camera"
+	"github.com/goiot/devices/gpio/gpiod"
+	"github.com/goiot/devices/mirror"
+	"github.com/goiot/devices/st7735"
+	"golang.org/x/exp/io/spi"
+)
+
+func main() {
+	cam, err := camera.Open("/dev/video0", camera.Config{Width: 320, Height: 240})
+	if err != nil {
+		panic(err)
+	}
+	defer cam.Close()
+
+	chip, err := gpiod.Open("/dev/gpiochip0")
+	if err != nil {
+		panic(err)
+	}
+	defer chip.Close()
+	dc, err := chip.Output(9, 0, 0)
+	if err != nil {
+		panic(err)
+	}
+	backlight, err := chip.Output(12, 1, 0)
+	if err != nil {
+		panic(err)
+	}
+	defer backlight.Write(0)
+	display, err := st7735.Open(&spi.Devfs{Dev: "/dev/spidev0.1", Mode: spi.Mode0, MaxSpeed: st7735.Speed}, dc, st7735.Enviro)
+	if err != nil {
+		panic(err)
+	}
+	defer display.Close()
+
+	m := &mirror.Mirror{
+		Source:  &camera.Motion{Source: cam},
+		Display: display,
+		FPS:     15,
+	}
+	if err := m.Run(context.Background()); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,100 @@
+package camera
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/goiot/devices/mirror"
+)
+
+// grid is the spacing in pixels of the samples compared by Motion.
+const grid = 4
+
+// Motion is a stage detecting the motion between the frames of its
+// source. It implements mirror.Source, returning the frames with the
+// moving area outlined.
+type Motion struct {
+	Source mirror.Source
+
+	// Threshold is the luminance difference of a changed pixel, 32 if
+	// zero.
+	Threshold uint8
+
+	// MinChanged is the fraction of the sampled pixels which must change
+	// to detect a motion, 0.01 if zero.
+	MinChanged float64
+
+	// Color is the color of the outline, red if nil.
+	Color color.Color
+
+	prev  []uint8
+	area  image.Rectangle
+	frame *image.RGBA
+}
+
+// Capture implements mirror.Source.
+func (m *Motion) Capture() (image.Image, error) {
+	img, err := m.Source.Capture()
+	if err != nil {
+		return nil, err
+	}
+	b := img.Bounds()
+	if m.frame == nil || m.frame.Bounds() != b {
+		m.frame = image.NewRGBA(b)
+		m.prev = nil
+	}
+	draw.Draw(m.frame, b, img, b.Min, draw.Src)
+
+	threshold := int(m.Threshold)
+	if threshold == 0 {
+		threshold = 32
+	}
+	minChanged := m.MinChanged
+	if minChanged == 0 {
+		minChanged = 0.01
+	}
+
+	var luma []uint8
+	var area image.Rectangle
+	changed := 0
+	for y := b.Min.Y; y < b.Max.Y; y += grid {
+		for x := b.Min.X; x < b.Max.X; x += grid {
+			v := color.GrayModel.Convert(m.frame.RGBAAt(x, y)).(color.Gray).Y
+			if m.prev != nil {
+				if d := int(v) - int(m.prev[len(luma)]); d > threshold || d < -threshold {
+					area = area.Union(image.Rect(x, y, x+grid, y+grid))
+					changed++
+				}
+			}
+			luma = append(luma, v)
+		}
+	}
+	m.prev = luma
+	m.area = image.Rectangle{}
+	if float64(changed) >= minChanged*float64(len(luma)) && changed > 0 {
+		m.area = area.Intersect(b)
+		m.outline()
+	}
+	return m.frame, nil
+}
+
+// Area returns the moving area of the last frame, empty if no motion was
+// detected.
+func (m *Motion) Area() image.Rectangle { return m.area }
+
+func (m *Motion) outline() {
+	c := m.Color
+	if c == nil {
+		c = color.RGBA{R: 0xFF, A: 0xFF}
+	}
+	r := m.area
+	for x := r.Min.X; x < r.Max.X; x++ {
+		m.frame.Set(x, r.Min.Y, c)
+		m.frame.Set(x, r.Max.Y-1, c)
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		m.frame.Set(r.Min.X, y, c)
+		m.frame.Set(r.Max.X-1, y, c)
+	}
+}
@@ -0,0 +1,125 @@
+//go:build linux && !tinygo
+// +build linux,!tinygo
+
+package camera
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// The structures below mirror the V4L2 ABI defined in
+// include/uapi/linux/videodev2.h.
+
+type capability struct {
+	driver       [16]byte
+	card         [32]byte
+	busInfo      [32]byte
+	version      uint32
+	capabilities uint32
+	deviceCaps   uint32
+	reserved     [3]uint32
+}
+
+type pixFormat struct {
+	width        uint32
+	height       uint32
+	pixelFormat  uint32
+	field        uint32
+	bytesPerLine uint32
+	sizeImage    uint32
+	colorspace   uint32
+	priv         uint32
+	flags        uint32
+	ycbcrEnc     uint32
+	quantization uint32
+	xferFunc     uint32
+}
+
+type format struct {
+	typ uint32
+	fmt struct {
+		_   [0]uintptr // the union holds pointers, it is aligned on them
+		pix pixFormat
+		_   [200 - unsafe.Sizeof(pixFormat{})]byte
+	}
+}
+
+type requestBuffers struct {
+	count        uint32
+	typ          uint32
+	memory       uint32
+	capabilities uint32
+	flags        uint8
+	reserved     [3]uint8
+}
+
+type buffer struct {
+	index     uint32
+	typ       uint32
+	bytesUsed uint32
+	flags     uint32
+	field     uint32
+	timestamp syscall.Timeval
+	timecode  [16]byte
+	sequence  uint32
+	memory    uint32
+	m         uintptr // offset of the mmap buffers in the low 32 bits
+	length    uint32
+	reserved2 uint32
+	requestFD uint32
+}
+
+const (
+	capVideoCapture = 0x00000001
+	capStreaming    = 0x04000000
+	capDeviceCaps   = 0x80000000
+
+	bufTypeVideoCapture = 1
+	memoryMmap          = 1
+	fieldNone           = 1
+)
+
+const (
+	iocWrite = 1
+	iocRead  = 2
+)
+
+var (
+	queryCapIoctl  = ioc(iocRead, 0, unsafe.Sizeof(capability{}))
+	setFmtIoctl    = ioc(iocRead|iocWrite, 5, unsafe.Sizeof(format{}))
+	reqBufsIoctl   = ioc(iocRead|iocWrite, 8, unsafe.Sizeof(requestBuffers{}))
+	queryBufIoctl  = ioc(iocRead|iocWrite, 9, unsafe.Sizeof(buffer{}))
+	qBufIoctl      = ioc(iocRead|iocWrite, 15, unsafe.Sizeof(buffer{}))
+	dqBufIoctl     = ioc(iocRead|iocWrite, 17, unsafe.Sizeof(buffer{}))
+	streamOnIoctl  = ioc(iocWrite, 18, 4)
+	streamOffIoctl = ioc(iocWrite, 19, 4)
+)
+
+// ioc returns the request code of a V4L2 ioctl.
+func ioc(dir, nr, size uintptr) uintptr {
+	return dir<<30 | size<<16 | 'V'<<8 | nr
+}
+
+func ioctl(f *os.File, req uintptr, arg unsafe.Pointer) error {
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno syscall.Errno
+	if err := rc.Control(func(fd uintptr) {
+		for {
+			_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(arg))
+			if errno != syscall.EINTR {
+				return
+			}
+		}
+	}); err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
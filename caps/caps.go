@@ -0,0 +1,145 @@
+// Package caps describes the capabilities of the devices in a machine
+// readable form: what they measure and with which range and resolution,
+// what they output and the power modes of the hardware. Generic tools,
+// such as command line interfaces, HTTP APIs or configuration validators,
+// use it to handle any device without driver specific code.
+package caps
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Kind is the kind of a measurement or of an output.
+type Kind string
+
+// Kinds of measurements, with their units.
+const (
+	Temperature   Kind = "temperature"    // degrees Celsius
+	Pressure      Kind = "pressure"       // hPa
+	Humidity      Kind = "humidity"       // percent of relative humidity
+	Illuminance   Kind = "illuminance"    // lux
+	Proximity     Kind = "proximity"      // counts, higher when closer
+	PM1           Kind = "pm1"            // µg/m³ of particles below 1µm
+	PM25          Kind = "pm2.5"          // µg/m³ of particles below 2.5µm
+	PM10          Kind = "pm10"           // µg/m³ of particles below 10µm
+	Voltage       Kind = "voltage"        // volts
+	Acceleration  Kind = "acceleration"   // g
+	AngularRate   Kind = "angular_rate"   // degrees per second
+	MagneticField Kind = "magnetic_field" // gauss
+	Current       Kind = "current"        // amperes
+	Power         Kind = "power"          // watts
+	Heading       Kind = "heading"        // degrees from the magnetic north
+	VerticalSpeed Kind = "vertical_speed" // meters per second
+	Altitude      Kind = "altitude"       // meters above the sea level
+	Distance      Kind = "distance"       // meters to the nearest object
+	Charge        Kind = "charge"         // percent of the state of charge of a battery
+	Energy        Kind = "energy"         // watt-hours
+	Frequency     Kind = "frequency"      // Hz
+)
+
+// Kinds of outputs.
+const (
+	Pixels Kind = "pixels" // graphic display
+	LEDs   Kind = "leds"   // individually addressable LEDs
+	Text   Kind = "text"   // character display
+)
+
+// Measurement describes a quantity measured by a device.
+type Measurement struct {
+	Kind       Kind    `json:"kind"`
+	Unit       string  `json:"unit"`
+	Min        float64 `json:"min"`
+	Max        float64 `json:"max"`
+	Resolution float64 `json:"resolution,omitempty"` // smallest change measured
+	Channels   int     `json:"channels,omitempty"`   // number of channels or axes, 1 if zero
+}
+
+// Output describes an output of a device.
+type Output struct {
+	Kind Kind `json:"kind"`
+	// Width and Height are the size in pixels, characters or LEDs; zero
+	// when it is chosen by the user, e.g. the length of an LED strip.
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	// Color is the color model: "monochrome", "gray4" (16 levels),
+	// "rgb565" or "rgb888".
+	Color string `json:"color,omitempty"`
+}
+
+// Capabilities describes a device.
+type Capabilities struct {
+	Name         string        `json:"name"`                // component, e.g. BME280
+	Bus          string        `json:"bus"`                 // i2c, spi, uart or virtual
+	Addresses    []int         `json:"addresses,omitempty"` // I2C addresses
+	Measurements []Measurement `json:"measurements,omitempty"`
+	Outputs      []Output      `json:"outputs,omitempty"`
+	PowerModes   []string      `json:"power_modes,omitempty"`
+}
+
+// Measurement returns the description of the measurement of kind k, and
+// false if the device does not measure it.
+func (c Capabilities) Measurement(k Kind) (Measurement, bool) {
+	for _, m := range c.Measurements {
+		if m.Kind == k {
+			return m, true
+		}
+	}
+	return Measurement{}, false
+}
+
+// Check returns an error if the device does not measure k or if v is out
+// of its range, e.g. to validate an alert threshold in a configuration.
+func (c Capabilities) Check(k Kind, v float64) error {
+	m, ok := c.Measurement(k)
+	if !ok {
+		return fmt.Errorf("%v does not measure %v", c.Name, k)
+	}
+	if v < m.Min || v > m.Max {
+		return fmt.Errorf("%v %v%v is out of the %v-%v%v range of %v", k, v, m.Unit, m.Min, m.Max, m.Unit, c.Name)
+	}
+	return nil
+}
+
+// Describer is implemented by the drivers reporting the capabilities of an
+// open device, which can depend on its configuration.
+type Describer interface {
+	Capabilities() Capabilities
+}
+
+var (
+	mu       sync.RWMutex
+	registry = make(map[string]Capabilities)
+)
+
+// Register registers the capabilities of a component. The drivers register
+// the components they support when they are imported.
+func Register(c Capabilities) {
+	mu.Lock()
+	defer mu.Unlock()
+	registry[strings.ToLower(c.Name)] = c
+}
+
+// Lookup returns the capabilities of the component registered with name,
+// ignoring the case, and false if no driver imported supports it.
+func Lookup(name string) (Capabilities, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	c, ok := registry[strings.ToLower(name)]
+	return c, ok
+}
+
+// All returns the capabilities of the registered components, sorted by
+// name.
+func All() []Capabilities {
+	mu.RLock()
+	defer mu.RUnlock()
+	all := make([]Capabilities, 0, len(registry))
+	for _, c := range registry {
+		all = append(all, c)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
@@ -0,0 +1,59 @@
+package caps
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var sensor = Capabilities{
+	Name: "TEST1",
+	Bus:  "i2c",
+	Measurements: []Measurement{
+		{Kind: Temperature, Unit: "C", Min: -40, Max: 85, Resolution: 0.1},
+	},
+}
+
+func TestRegistry(t *testing.T) {
+	Register(sensor)
+	Register(Capabilities{Name: "A0", Bus: "spi"})
+	c, ok := Lookup("test1")
+	if !ok || c.Name != "TEST1" {
+		t.Fatalf("Lookup = %v, %v; want TEST1", c, ok)
+	}
+	if _, ok := Lookup("missing"); ok {
+		t.Error("Lookup of an unregistered component succeeded")
+	}
+	// The drivers imported by the examples are registered too.
+	all := All()
+	if len(all) < 2 || all[0].Name != "A0" || all[len(all)-1].Name != "TEST1" {
+		t.Errorf("All = %v; want A0 first and TEST1 last", all)
+	}
+	if !sort.SliceIsSorted(all, func(i, j int) bool { return all[i].Name < all[j].Name }) {
+		t.Errorf("All is not sorted by name")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	if err := sensor.Check(Temperature, 25); err != nil {
+		t.Error(err)
+	}
+	if err := sensor.Check(Temperature, 90); err == nil || !strings.Contains(err.Error(), "-40-85C") {
+		t.Errorf("Check out of range = %v", err)
+	}
+	if err := sensor.Check(Humidity, 50); err == nil {
+		t.Error("Check of a kind not measured succeeded")
+	}
+}
+
+func TestJSON(t *testing.T) {
+	b, err := json.Marshal(sensor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"name":"TEST1","bus":"i2c","measurements":[{"kind":"temperature","unit":"C","min":-40,"max":85,"resolution":0.1}]}`
+	if string(b) != want {
+		t.Errorf("JSON = %s; want %s", b, want)
+	}
+}
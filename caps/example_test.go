@@ -0,0 +1,28 @@
+package caps_test
+
+import (
+	"fmt"
+
+	"github.com/goiot/devices/bme280"
+	"github.com/goiot/devices/caps"
+)
+
+func Example() {
+	// A configuration sets an alert on the temperature measured by a
+	// sensor given by name.
+	c, ok := caps.Lookup("bme280")
+	if !ok {
+		panic("no driver for the sensor")
+	}
+	fmt.Println(c.Check(caps.Temperature, 35))
+	fmt.Println(c.Check(caps.Temperature, 120))
+	fmt.Println(c.Check(caps.Illuminance, 100))
+
+	// The drivers also describe the open devices.
+	var _ caps.Describer = (*bme280.BME280)(nil)
+
+	// Output:
+	// <nil>
+	// temperature 120C is out of the -40-85C range of BME280
+	// BME280 does not measure illuminance
+}
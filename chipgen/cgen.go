@@ -0,0 +1,103 @@
+package chipgen
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+)
+
+// C returns the C header of c, for the firmwares of the microcontrollers:
+// the macros of its address, registers, fields and ID, the values written
+// when it is opened, and the inline functions of the conversions. The
+// macros and the functions are prefixed with the name of the chip.
+func C(c *Chip) []byte {
+	g := &gen{}
+	p := snake(c.Name)
+	lp := strings.ToLower(p)
+	g.p("/* Code generated by chipgen from the %s descriptor. DO NOT EDIT. */\n\n", c.Name)
+	g.p("#ifndef %s_H\n#define %s_H\n\n", p, p)
+	g.p("#include <stdint.h>\n")
+	if c.converts() {
+		g.p("#include <math.h>\n")
+	}
+	g.p("\n/* Default I2C address of the %s. */\n", c.Name)
+	g.p("#define %s_ADDR %#x\n\n", p, c.Address)
+
+	order := "little"
+	if c.BigEndian {
+		order = "big"
+	}
+	g.p("/* Registers of the %s, in %s endian when wider than a byte. */\n", c.Name, order)
+	for _, r := range c.Registers {
+		g.p("#define %s_REG_%s %s /* %s_t%s */\n", p, snake(r.Name), hex(uint32(r.Addr), 8), regType(r), sentenceDoc(r.Doc))
+	}
+	for _, r := range c.Registers {
+		if len(r.Fields) == 0 {
+			continue
+		}
+		g.p("\n/* Fields of the %s register, and their values. */\n", r.Name)
+		for _, f := range r.Fields {
+			n := p + "_" + snake(r.Name) + "_" + snake(f.Name)
+			g.p("#define %s_MASK %s\n", n, hex(f.Mask(), r.Width))
+			g.p("#define %s_SHIFT %d\n", n, f.Shift)
+			for _, v := range f.Values {
+				g.p("#define %s_%s %d\n", n, snake(v.Name), v.Value)
+			}
+		}
+	}
+	if id := c.ID; id != nil {
+		g.p("\n/* The %s register masked by the mask identifies the %s. */\n", id.Register.Name, c.Name)
+		g.p("#define %s_ID_MASK %s\n", p, hex(id.Mask, id.Register.Width))
+		g.p("#define %s_ID_VALUE %s\n", p, hex(id.Value, id.Register.Width))
+	}
+	if len(c.Init) > 0 {
+		g.p("\n/* Values written to the registers when the %s is opened, in order. */\n", c.Name)
+		for _, w := range c.Init {
+			g.p("#define %s_INIT_%s %s\n", p, snake(w.Register.Name), hex(w.Value, w.Register.Width))
+		}
+	}
+
+	for _, r := range c.Registers {
+		for _, cv := range r.Conversions {
+			fn := lp + "_" + strings.ToLower(snake(r.Name)) + "_" + strings.ToLower(snake(cv.Name))
+			t := regType(r) + "_t"
+			if cv.read != nil {
+				g.p("\n/* %s converts a value of the %s register%s. */\n", fn, r.Name, unitDoc(cv.Unit))
+				g.p("static inline double %s(%s raw) {\n\treturn %s;\n}\n", fn, t, cv.read.code(1, "(double)raw"))
+			}
+			if cv.write != nil {
+				to := lp + "_" + strings.ToLower(snake(r.Name)) + "_from_" + strings.ToLower(snake(cv.Name))
+				g.p("\n/* %s converts a value%s to the %s register. */\n", to, unitDoc(cv.Unit), r.Name)
+				g.p("static inline %s %s(double v) {\n\treturn (%s)lround(%s);\n}\n", t, to, t, cv.write.code(1, "v"))
+			}
+		}
+	}
+	g.p("\n#endif /* %s_H */\n", p)
+	return g.buf.Bytes()
+}
+
+func (c *Chip) converts() bool {
+	for _, r := range c.Registers {
+		if len(r.Conversions) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// snake returns the name in upper snake case, e.g. DEVICE_ID for DeviceID.
+func snake(name string) string {
+	var b bytes.Buffer
+	r := []rune(name)
+	for i, c := range r {
+		if i > 0 && unicode.IsUpper(c) {
+			prev := r[i-1]
+			next := i+1 < len(r) && unicode.IsLower(r[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || unicode.IsUpper(prev) && next {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(c))
+	}
+	return b.String()
+}
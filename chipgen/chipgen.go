@@ -0,0 +1,433 @@
+// Package chipgen generates the boilerplate of the drivers of the simple
+// I2C chips from a descriptor of their registers: the constants of the
+// registers and of their fields, their typed accessors and the conversions
+// of their values to physical units. It emits Go drivers using the
+// golang.org/x/exp/io/i2c devices, and C headers for the firmwares of the
+// microcontrollers talking to the same chips.
+//
+// A descriptor is a YAML document:
+//
+//	chip: TMP117
+//	package: tmp117
+//	doc: Texas Instruments TMP117 digital temperature sensor.
+//	address: 0x48
+//	byteorder: big # of the registers wider than a byte, big or little
+//	width: 16      # of the registers in bits, 8, 16, 24 or 32, 8 if omitted
+//	id:            # checked when opening the chip
+//	  register: DeviceID
+//	  value: 0x0117
+//	  mask: 0x0FFF
+//	init:          # written when opening the chip, in order
+//	  - register: Config
+//	    value: 0x0220
+//	registers:
+//	  - name: Temp
+//	    addr: 0x00
+//	    access: r  # r, w or rw, rw if omitted
+//	    signed: true
+//	    doc: the temperature of the last conversion
+//	    conversions:
+//	      - name: Celsius
+//	        unit: °C
+//	        read: raw * 0.0078125 # of the raw value
+//	        write: v / 0.0078125  # of the value in the unit, if writable
+//	  - name: Config
+//	    addr: 0x01
+//	    fields:
+//	      - name: Mode
+//	        bits: 11:10 # or a single bit, e.g. 4
+//	        values:
+//	          Continuous: 0
+//	          Shutdown: 1
+//	          OneShot: 3
+//	  - name: DeviceID
+//	    addr: 0x0F
+//	    access: r
+//
+// The formulas of the conversions are arithmetic expressions of raw or v,
+// with the functions abs, exp, log, pow and sqrt. The chipgen command
+// generates the code of a descriptor, e.g. with
+//
+//	//go:generate go run github.com/goiot/devices/chipgen/cmd/chipgen -o regs.go tmp117.yaml
+//
+// Only a subset of YAML is supported: block mappings and sequences, plain
+// and quoted scalars, and comments.
+package chipgen
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Chip is a chip described by a descriptor.
+type Chip struct {
+	Name      string // e.g. TMP117
+	Package   string // of the Go driver
+	Doc       string
+	Address   int  // default I2C address
+	BigEndian bool // byte order of the registers wider than a byte
+
+	// ID is the register identifying the chip, nil if it has none.
+	ID *ID
+
+	// Init are the registers written when the chip is opened.
+	Init []Write
+
+	Registers []*Register
+}
+
+// ID is a register identifying a chip: its value masked by Mask is Value.
+type ID struct {
+	Register    *Register
+	Value, Mask uint32
+}
+
+// Write is a value written to a register.
+type Write struct {
+	Register *Register
+	Value    uint32
+}
+
+// Register is a register of a chip.
+type Register struct {
+	Name        string
+	Addr        byte
+	Width       int // in bits: 8, 16, 24 or 32
+	Signed      bool
+	Read, Write bool
+	Doc         string
+	Fields      []*Field
+	Conversions []*Conversion
+}
+
+// Field is a field of the bits of a register.
+type Field struct {
+	Name   string
+	Shift  int // of its lowest bit
+	Bits   int
+	Doc    string
+	Values []Value
+}
+
+// Mask returns the mask of the field in its register.
+func (f *Field) Mask() uint32 { return (1<<uint(f.Bits) - 1) << uint(f.Shift) }
+
+// Value is a named value of a field.
+type Value struct {
+	Name  string
+	Value uint32
+}
+
+// Conversion converts the value of a register to a physical unit, and
+// back if Write is set.
+type Conversion struct {
+	Name  string // e.g. Celsius
+	Unit  string // e.g. °C
+	Read  string // formula of raw
+	Write string // formula of v, empty if the unit is only read
+
+	read, write *formula
+}
+
+var (
+	identifier = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+	pkgName    = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+)
+
+// Parse parses the descriptor b of a chip.
+func Parse(b []byte) (*Chip, error) {
+	root, err := parseYAML(b)
+	if err != nil {
+		return nil, err
+	}
+	d := &decoder{}
+	c := d.chip(root)
+	if d.err != nil {
+		return nil, d.err
+	}
+	return c, nil
+}
+
+// decoder decodes the nodes of a descriptor, keeping the first error.
+type decoder struct {
+	err error
+}
+
+func (d *decoder) fail(n *node, format string, args ...interface{}) {
+	if d.err == nil {
+		d.err = fmt.Errorf("line %d: %s", n.line, fmt.Sprintf(format, args...))
+	}
+}
+
+// mapping checks that n is a mapping of the keys known.
+func (d *decoder) mapping(n *node, what string, known ...string) bool {
+	if !n.isMap() {
+		d.fail(n, "expected the mapping of %s", what)
+		return false
+	}
+	for _, k := range n.keys {
+		ok := false
+		for _, want := range known {
+			ok = ok || k == want
+		}
+		if !ok {
+			d.fail(n.m[k], "unknown key %q of %s", k, what)
+		}
+	}
+	return d.err == nil
+}
+
+// str returns the scalar of the key of the mapping n, def if it is missing.
+func (d *decoder) str(n *node, key, def string) string {
+	v, ok := n.m[key]
+	if !ok {
+		return def
+	}
+	if v.isMap() || v.isSeq() {
+		d.fail(v, "expected a value for %q", key)
+	}
+	return v.scalar
+}
+
+// required returns the scalar of the key of the mapping n.
+func (d *decoder) required(n *node, key string) string {
+	if _, ok := n.m[key]; !ok {
+		d.fail(n, "missing %q", key)
+	}
+	return d.str(n, key, "")
+}
+
+// uint returns the unsigned integer of the scalar of the key, in decimal,
+// hexadecimal (0x), octal (0o) or binary (0b).
+func (d *decoder) uint(n *node, key string, def uint64, bits int) uint64 {
+	s := d.str(n, key, "")
+	if s == "" {
+		return def
+	}
+	v, err := strconv.ParseUint(strings.Replace(s, "_", "", -1), 0, bits)
+	if err != nil {
+		d.fail(n.m[key], "invalid %s %q, expected an unsigned integer of %d bits", key, s, bits)
+	}
+	return v
+}
+
+func (d *decoder) bool(n *node, key string) bool {
+	switch s := d.str(n, key, "false"); s {
+	case "true":
+		return true
+	case "false":
+		return false
+	default:
+		d.fail(n.m[key], "invalid %s %q, expected true or false", key, s)
+		return false
+	}
+}
+
+func (d *decoder) seq(n *node, key string) []*node {
+	v, ok := n.m[key]
+	if !ok {
+		return nil
+	}
+	if !v.isSeq() {
+		d.fail(v, "expected a sequence for %q", key)
+	}
+	return v.items
+}
+
+func (d *decoder) chip(n *node) *Chip {
+	if !d.mapping(n, "the chip", "chip", "package", "doc", "address", "byteorder", "width", "id", "init", "registers") {
+		return nil
+	}
+	c := &Chip{
+		Name:    d.required(n, "chip"),
+		Package: d.required(n, "package"),
+		Doc:     d.str(n, "doc", ""),
+		Address: int(d.uint(n, "address", 0, 7)),
+	}
+	if !identifier.MatchString(c.Name) {
+		d.fail(n, "invalid chip %q, expected a Go identifier starting with a capital", c.Name)
+	}
+	if !pkgName.MatchString(c.Package) {
+		d.fail(n, "invalid package %q", c.Package)
+	}
+	switch s := d.str(n, "byteorder", "big"); s {
+	case "big":
+		c.BigEndian = true
+	case "little":
+	default:
+		d.fail(n.m["byteorder"], "invalid byteorder %q, expected big or little", s)
+	}
+	width := int(d.uint(n, "width", 8, 8))
+
+	names := map[string]bool{}
+	for _, r := range d.seq(n, "registers") {
+		reg := d.register(r, width)
+		if reg == nil {
+			return nil
+		}
+		if names[reg.Name] {
+			d.fail(r, "duplicate register %s", reg.Name)
+		}
+		names[reg.Name] = true
+		c.Registers = append(c.Registers, reg)
+	}
+	if len(c.Registers) == 0 {
+		d.fail(n, "missing registers")
+	}
+
+	if id, ok := n.m["id"]; ok && d.mapping(id, "the id", "register", "value", "mask") {
+		r := c.register(d.required(id, "register"))
+		if r == nil || !r.Read {
+			d.fail(id, "the id register %q is not a readable register", d.str(id, "register", ""))
+			return nil
+		}
+		c.ID = &ID{
+			Register: r,
+			Value:    uint32(d.uint(id, "value", 0, r.Width)),
+			Mask:     uint32(d.uint(id, "mask", 1<<uint(r.Width)-1, r.Width)),
+		}
+		if _, ok := id.m["value"]; !ok {
+			d.fail(id, "missing \"value\"")
+		}
+	}
+	for _, w := range d.seq(n, "init") {
+		if !d.mapping(w, "the init", "register", "value") {
+			return nil
+		}
+		r := c.register(d.required(w, "register"))
+		if r == nil || !r.Write {
+			d.fail(w, "the init register %q is not a writable register", d.str(w, "register", ""))
+			return nil
+		}
+		if _, ok := w.m["value"]; !ok {
+			d.fail(w, "missing \"value\"")
+		}
+		c.Init = append(c.Init, Write{Register: r, Value: uint32(d.uint(w, "value", 0, r.Width))})
+	}
+	return c
+}
+
+// register returns the register named name, nil if there is none.
+func (c *Chip) register(name string) *Register {
+	for _, r := range c.Registers {
+		if r.Name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+func (d *decoder) register(n *node, width int) *Register {
+	if !d.mapping(n, "the register", "name", "addr", "width", "signed", "access", "doc", "fields", "conversions") {
+		return nil
+	}
+	r := &Register{
+		Name:   d.required(n, "name"),
+		Addr:   byte(d.uint(n, "addr", 0, 8)),
+		Width:  int(d.uint(n, "width", uint64(width), 8)),
+		Signed: d.bool(n, "signed"),
+		Doc:    d.str(n, "doc", ""),
+	}
+	d.required(n, "addr")
+	if !identifier.MatchString(r.Name) {
+		d.fail(n, "invalid register name %q, expected a Go identifier starting with a capital", r.Name)
+	}
+	switch r.Width {
+	case 8, 16, 24, 32:
+	default:
+		d.fail(n, "invalid width of %d bits of the register %s, expected 8, 16, 24 or 32", r.Width, r.Name)
+	}
+	switch s := d.str(n, "access", "rw"); s {
+	case "r":
+		r.Read = true
+	case "w":
+		r.Write = true
+	case "rw":
+		r.Read, r.Write = true, true
+	default:
+		d.fail(n.m["access"], "invalid access %q, expected r, w or rw", s)
+	}
+
+	for _, f := range d.seq(n, "fields") {
+		if r.Signed {
+			d.fail(f, "the signed register %s has fields", r.Name)
+			return nil
+		}
+		if fld := d.field(f, r); fld != nil {
+			r.Fields = append(r.Fields, fld)
+		}
+	}
+	for _, cn := range d.seq(n, "conversions") {
+		if !d.mapping(cn, "the conversion", "name", "unit", "read", "write") {
+			return nil
+		}
+		c := &Conversion{
+			Name:  d.required(cn, "name"),
+			Unit:  d.str(cn, "unit", ""),
+			Read:  d.str(cn, "read", ""),
+			Write: d.str(cn, "write", ""),
+		}
+		if !identifier.MatchString(c.Name) {
+			d.fail(cn, "invalid conversion name %q", c.Name)
+		}
+		var err error
+		switch {
+		case c.Read == "" && c.Write == "":
+			d.fail(cn, "the conversion %s has no formula", c.Name)
+		case c.Read != "" && !r.Read:
+			d.fail(cn, "the conversion %s reads the write-only register %s", c.Name, r.Name)
+		case c.Write != "" && !r.Write:
+			d.fail(cn, "the conversion %s writes the read-only register %s", c.Name, r.Name)
+		}
+		if c.Read != "" {
+			if c.read, err = parseFormula(c.Read, "raw"); err != nil {
+				d.fail(cn.m["read"], "%v", err)
+			}
+		}
+		if c.Write != "" {
+			if c.write, err = parseFormula(c.Write, "v"); err != nil {
+				d.fail(cn.m["write"], "%v", err)
+			}
+		}
+		r.Conversions = append(r.Conversions, c)
+	}
+	return r
+}
+
+func (d *decoder) field(n *node, r *Register) *Field {
+	if !d.mapping(n, "the field", "name", "bits", "doc", "values") {
+		return nil
+	}
+	f := &Field{Name: d.required(n, "name"), Doc: d.str(n, "doc", "")}
+	if !identifier.MatchString(f.Name) {
+		d.fail(n, "invalid field name %q", f.Name)
+	}
+	bits := d.required(n, "bits")
+	hi, lo := bits, bits
+	if i := strings.Index(bits, ":"); i >= 0 {
+		hi, lo = bits[:i], bits[i+1:]
+	}
+	h, err1 := strconv.Atoi(strings.TrimSpace(hi))
+	l, err2 := strconv.Atoi(strings.TrimSpace(lo))
+	if err1 != nil || err2 != nil || l < 0 || h < l || h >= r.Width {
+		d.fail(n, "invalid bits %q of the field %s, expected high:low within %d bits", bits, f.Name, r.Width)
+		return nil
+	}
+	f.Shift, f.Bits = l, h-l+1
+	if v, ok := n.m["values"]; ok {
+		if !v.isMap() {
+			d.fail(v, "expected the mapping of the values of the field %s", f.Name)
+			return nil
+		}
+		for _, k := range v.keys {
+			if !identifier.MatchString(k) {
+				d.fail(v.m[k], "invalid value name %q", k)
+			}
+			x := d.uint(v, k, 0, f.Bits)
+			f.Values = append(f.Values, Value{Name: k, Value: uint32(x)})
+		}
+	}
+	return f
+}
@@ -0,0 +1,182 @@
+package chipgen
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "write the golden files of the chipgen package")
+
+func TestTMP117(t *testing.T) {
+	b, err := ioutil.ReadFile("../tmp117/tmp117.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := Parse(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := c.register("Config"); r == nil || len(r.Fields) != 11 || r.Fields[4].Mask() != 0x0C00 || len(r.Fields[4].Values) != 3 {
+		t.Errorf("Config register = %+v", r)
+	}
+
+	src, err := Go(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	regs, err := ioutil.ReadFile("../tmp117/regs.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, regs) {
+		t.Error("tmp117/regs.go is out of date, run go generate ./tmp117")
+	}
+
+	h := C(c)
+	if *update {
+		if err := ioutil.WriteFile("testdata/tmp117.h", h, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := ioutil.ReadFile("testdata/tmp117.h")
+	if err != nil {
+		t.Fatalf("%v, run the tests with -update to write the golden file", err)
+	}
+	if !bytes.Equal(h, want) {
+		t.Errorf("C header:\n%s\nwant testdata/tmp117.h, run the tests with -update if the change is expected", h)
+	}
+}
+
+func TestWidths(t *testing.T) {
+	c, err := Parse([]byte(`
+chip: ABC
+package: abc
+byteorder: little
+registers:
+  - {name: X}
+`))
+	if err == nil {
+		t.Fatalf("Parse() of a flow mapping = %+v", c)
+	}
+
+	c, err = Parse([]byte(`
+chip: ABC
+package: abc
+byteorder: little
+registers:
+  - name: Ctrl
+    addr: 0x10
+    fields:
+      - name: On
+        bits: 0
+  - name: Pressure # a 24-bit signed register
+    addr: 0x20
+    width: 24
+    signed: true
+    access: r
+    conversions:
+      - name: Pascal
+        read: sqrt(abs(raw)) * 2 - 1/4
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := Go(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"func (d *ABC) ReadPressure() (int32, error)",
+		"return int32(v<<8) >> 8, err",
+		"return math.Sqrt(math.Abs(float64(raw)))*2.0 - 1.0/4.0, nil",
+		"for i := n - 1; i >= 0; i-- {",
+		"func (d *ABC) WriteCtrlOn(v uint8) error {",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("the Go code does not contain %q:\n%s", want, src)
+		}
+	}
+	if strings.Contains(string(src), `"fmt"`) {
+		t.Errorf("the Go code imports fmt without using it:\n%s", src)
+	}
+	if h := string(C(c)); !strings.Contains(h, "return sqrt(fabs((double)raw)) * 2.0 - 1.0 / 4.0;") {
+		t.Errorf("the C header does not convert the pressure:\n%s", h)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	const head = "chip: ABC\npackage: abc\n"
+	for _, tt := range []struct {
+		desc, err string
+	}{
+		{"package: abc\nregisters:\n  - name: A\n    addr: 0\n", `line 1: missing "chip"`},
+		{head, "missing registers"},
+		{head + "registers:\n  - name: A\n    addr: 0\n    adr: 1\n", `line 6: unknown key "adr" of the register`},
+		{head + "registers:\n  - name: A\n    addr: 0x100\n", "line 5: invalid addr"},
+		{head + "registers:\n  - name: a\n    addr: 0\n", `invalid register name "a"`},
+		{head + "registers:\n  - name: A\n    addr: 0\n  - name: A\n    addr: 1\n", "duplicate register A"},
+		{head + "registers:\n  - name: A\n    addr: 0\n    width: 12\n", "invalid width of 12 bits"},
+		{head + "registers:\n  - name: A\n    addr: 0\n    fields:\n      - name: F\n        bits: 8:1\n", `invalid bits "8:1"`},
+		{head + "registers:\n  - name: A\n    addr: 0\n    fields:\n      - name: F\n        bits: 1:0\n        values:\n          X: 4\n", "invalid X"},
+		{head + "registers:\n  - name: A\n    addr: 0\n    access: r\n    conversions:\n      - name: U\n        write: v * 2\n", "writes the read-only register A"},
+		{head + "registers:\n  - name: A\n    addr: 0\n    conversions:\n      - name: U\n        read: raw << 2\n", "invalid operator <<"},
+		{head + "registers:\n  - name: A\n    addr: 0\n    conversions:\n      - name: U\n        read: v * 2\n", "unknown variable v"},
+		{head + "registers:\n  - name: A\n    addr: 0\n    conversions:\n      - name: U\n        read: sin(raw)\n", "unknown function sin"},
+		{head + "id:\n  register: B\n  value: 1\nregisters:\n  - name: A\n    addr: 0\n", `the id register "B" is not a readable register`},
+		{head + "init:\n  - register: A\n    value: 1\nregisters:\n  - name: A\n    addr: 0\n    access: r\n", `the init register "A" is not a writable register`},
+		{head + "registers:\n  - name: A\n   addr: 0\n", "line 5: unexpected indentation"},
+		{head + "registers:\n\t- name: A\n", "tabs are not allowed"},
+	} {
+		if _, err := Parse([]byte(tt.desc)); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("Parse(%q) = %v; want %q", tt.desc, err, tt.err)
+		}
+	}
+}
+
+func TestYAML(t *testing.T) {
+	n, err := parseYAML([]byte(`
+# comment
+a: 1 # comment
+b: "x # y"
+c: 'it''s'
+d:
+- 1
+- - 2
+  - 3
+e:
+  f:
+    g: true
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(n.keys, ","); got != "a,b,c,d,e" {
+		t.Errorf("keys = %s; want a,b,c,d,e", got)
+	}
+	if n.m["a"].scalar != "1" || n.m["b"].scalar != "x # y" || n.m["c"].scalar != "it's" {
+		t.Errorf("scalars = %q, %q, %q", n.m["a"].scalar, n.m["b"].scalar, n.m["c"].scalar)
+	}
+	if d := n.m["d"]; len(d.items) != 2 || d.items[0].scalar != "1" || len(d.items[1].items) != 2 || d.items[1].items[1].scalar != "3" {
+		t.Errorf("sequence d = %+v", d)
+	}
+	if g := n.m["e"].m["f"].m["g"]; g == nil || g.scalar != "true" {
+		t.Errorf("e.f.g = %+v", g)
+	}
+}
+
+func TestSnake(t *testing.T) {
+	for name, want := range map[string]string{
+		"DeviceID":   "DEVICE_ID",
+		"TMP117":     "TMP117",
+		"EEPROMBusy": "EEPROM_BUSY",
+		"Avg8":       "AVG8",
+		"OneShot":    "ONE_SHOT",
+	} {
+		if got := snake(name); got != want {
+			t.Errorf("snake(%s) = %s; want %s", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// Chipgen generates the Go driver or the C header of a chip descriptor.
+//
+// usage: chipgen [-lang go|c] [-o file] descriptor.yaml
+//
+// The code is written to the standard output without -o.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/goiot/devices/chipgen"
+)
+
+func main() {
+	lang := flag.String("lang", "go", "language of the code generated, go or c")
+	out := flag.String("o", "", "file written, the standard output if empty")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: chipgen [-lang go|c] [-o file] descriptor.yaml")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	log.SetFlags(0)
+	log.SetPrefix("chipgen: ")
+
+	b, err := ioutil.ReadFile(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	c, err := chipgen.Parse(b)
+	if err != nil {
+		log.Fatalf("%s: %v", flag.Arg(0), err)
+	}
+	var src []byte
+	switch *lang {
+	case "go":
+		if src, err = chipgen.Go(c); err != nil {
+			log.Fatal(err)
+		}
+	case "c":
+		src = chipgen.C(c)
+	default:
+		log.Fatalf("unknown language %q, expected go or c", *lang)
+	}
+	if *out == "" {
+		os.Stdout.Write(src)
+		return
+	}
+	if err := ioutil.WriteFile(*out, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,149 @@
+package chipgen
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// funcs are the functions of the formulas, with their Go and C names.
+var funcs = map[string][2]string{
+	"abs":  {"math.Abs", "fabs"},
+	"exp":  {"math.Exp", "exp"},
+	"log":  {"math.Log", "log"},
+	"pow":  {"math.Pow", "pow"},
+	"sqrt": {"math.Sqrt", "sqrt"},
+}
+
+// formula is a conversion formula: an arithmetic expression of a variable,
+// numbers and funcs.
+type formula struct {
+	src  string
+	expr ast.Expr
+}
+
+// parseFormula parses the formula s of the variable v.
+func parseFormula(s, v string) (*formula, error) {
+	e, err := parser.ParseExpr(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid formula %q - %v", s, err)
+	}
+	if err := check(e, v); err != nil {
+		return nil, fmt.Errorf("invalid formula %q - %v", s, err)
+	}
+	return &formula{src: s, expr: e}, nil
+}
+
+// check checks that e only uses v, numbers, arithmetic and funcs.
+func check(e ast.Expr, v string) error {
+	switch e := e.(type) {
+	case *ast.ParenExpr:
+		return check(e.X, v)
+	case *ast.BasicLit:
+		if e.Kind != token.INT && e.Kind != token.FLOAT {
+			return fmt.Errorf("invalid literal %s", e.Value)
+		}
+	case *ast.Ident:
+		if e.Name != v {
+			return fmt.Errorf("unknown variable %s, expected %s", e.Name, v)
+		}
+	case *ast.UnaryExpr:
+		if e.Op != token.SUB && e.Op != token.ADD {
+			return fmt.Errorf("invalid operator %s", e.Op)
+		}
+		return check(e.X, v)
+	case *ast.BinaryExpr:
+		switch e.Op {
+		case token.ADD, token.SUB, token.MUL, token.QUO:
+		default:
+			return fmt.Errorf("invalid operator %s", e.Op)
+		}
+		if err := check(e.X, v); err != nil {
+			return err
+		}
+		return check(e.Y, v)
+	case *ast.CallExpr:
+		f, ok := e.Fun.(*ast.Ident)
+		if !ok {
+			return errors.New("invalid call")
+		}
+		if _, ok := funcs[f.Name]; !ok {
+			return fmt.Errorf("unknown function %s", f.Name)
+		}
+		want := 1
+		if f.Name == "pow" {
+			want = 2
+		}
+		if len(e.Args) != want {
+			return fmt.Errorf("%s takes %d arguments", f.Name, want)
+		}
+		for _, a := range e.Args {
+			if err := check(a, v); err != nil {
+				return err
+			}
+		}
+	default:
+		return errors.New("invalid expression")
+	}
+	return nil
+}
+
+func (f *formula) usesMath() bool {
+	math := false
+	ast.Inspect(f.expr, func(n ast.Node) bool {
+		if _, ok := n.(*ast.CallExpr); ok {
+			math = true
+		}
+		return true
+	})
+	return math
+}
+
+// code returns the formula in Go, lang 0, or C, lang 1, with its variable
+// replaced by x. The integers are written as floating point numbers so
+// that their divisions are not truncated.
+func (f *formula) code(lang int, x string) string {
+	var b strings.Builder
+	printExpr(&b, f.expr, lang, x)
+	return b.String()
+}
+
+func printExpr(b *strings.Builder, e ast.Expr, lang int, x string) {
+	switch e := e.(type) {
+	case *ast.ParenExpr:
+		b.WriteString("(")
+		printExpr(b, e.X, lang, x)
+		b.WriteString(")")
+	case *ast.BasicLit:
+		if e.Kind == token.INT {
+			if v, err := strconv.ParseInt(e.Value, 0, 64); err == nil {
+				fmt.Fprintf(b, "%d.0", v)
+				return
+			}
+		}
+		b.WriteString(e.Value)
+	case *ast.Ident:
+		b.WriteString(x)
+	case *ast.UnaryExpr:
+		b.WriteString(e.Op.String())
+		printExpr(b, e.X, lang, x)
+	case *ast.BinaryExpr:
+		printExpr(b, e.X, lang, x)
+		fmt.Fprintf(b, " %s ", e.Op)
+		printExpr(b, e.Y, lang, x)
+	case *ast.CallExpr:
+		b.WriteString(funcs[e.Fun.(*ast.Ident).Name][lang])
+		b.WriteString("(")
+		for i, a := range e.Args {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			printExpr(b, a, lang, x)
+		}
+		b.WriteString(")")
+	}
+}
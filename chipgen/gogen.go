@@ -0,0 +1,279 @@
+package chipgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+)
+
+// Go returns the Go source of the driver of c: the constants of its
+// address, registers and fields, the type named after the chip and its
+// Open and Close, and the methods reading and writing the registers, their
+// fields and their conversions. The methods of the driver not described
+// are written in other files of the package.
+func Go(c *Chip) ([]byte, error) {
+	g := &gen{}
+	g.p("// Code generated by chipgen from the %s descriptor. DO NOT EDIT.\n\n", c.Name)
+	g.p("package %s\n\n", c.Package)
+	g.p("import (\n")
+	if c.ID != nil || c.usesFmt() {
+		g.p("%q\n", "fmt")
+	}
+	if c.usesMath() {
+		g.p("%q\n", "math")
+	}
+	g.p("\n%q\n%q\n)\n\n", "golang.org/x/exp/io/i2c", "golang.org/x/exp/io/i2c/driver")
+
+	g.p("// Addr is the default I2C address of the %s.\n", c.Name)
+	g.p("const Addr = %#x\n\n", c.Address)
+	g.p("// Registers of the %s.\nconst (\n", c.Name)
+	for _, r := range c.Registers {
+		g.p("Reg%s = %s%s\n", r.Name, hex(uint32(r.Addr), 8), lineDoc(r.Doc))
+	}
+	g.p(")\n\n")
+	for _, r := range c.Registers {
+		if len(r.Fields) == 0 {
+			continue
+		}
+		g.p("// Fields of the %s register, and their values.\nconst (\n", r.Name)
+		for i, f := range r.Fields {
+			if i > 0 {
+				g.p("\n")
+			}
+			n := r.Name + f.Name
+			g.p("%sMask = %s%s\n", n, hex(f.Mask(), r.Width), lineDoc(f.Doc))
+			g.p("%sShift = %d\n", n, f.Shift)
+			for _, v := range f.Values {
+				g.p("%s%s = %d\n", n, v.Name, v.Value)
+			}
+		}
+		g.p(")\n\n")
+	}
+
+	name := c.Name
+	doc := c.Doc
+	if doc == "" {
+		doc = "a " + c.Name + "."
+	}
+	g.doc("%s represents %s", name, doc)
+	g.p("type %s struct {\nDevice *i2c.Device\n}\n\n", name)
+
+	open := "Open opens the " + name + " at the address addr"
+	if c.ID != nil {
+		open += ", checks its " + c.ID.Register.Name + " register"
+	}
+	if len(c.Init) > 0 {
+		open += " and initializes its registers"
+	}
+	g.doc("%s.", open)
+	g.p("func Open(o driver.Opener, addr int) (*%s, error) {\n", name)
+	g.p("dev, err := i2c.Open(o, addr)\nif err != nil {\nreturn nil, err\n}\n")
+	g.p("d := &%s{Device: dev}\n", name)
+	if id := c.ID; id != nil {
+		g.p("id, err := d.Read%s()\n", id.Register.Name)
+		g.p("if err != nil {\ndev.Close()\nreturn nil, err\n}\n")
+		g.p("if id&%s != %s {\ndev.Close()\n", hex(id.Mask, id.Register.Width), hex(id.Value, id.Register.Width))
+		g.p("return nil, fmt.Errorf(\"unexpected %s %%#x, expected %%#x\", id&%s, %s)\n}\n",
+			id.Register.Name, hex(id.Mask, id.Register.Width), hex(id.Value, id.Register.Width))
+	}
+	for _, w := range c.Init {
+		g.p("if err := d.Write%s(%s); err != nil {\ndev.Close()\n", w.Register.Name, hex(w.Value, w.Register.Width))
+		g.p("return nil, fmt.Errorf(\"initializing the %s register failed - %%v\", err)\n}\n", w.Register.Name)
+	}
+	g.p("return d, nil\n}\n\n")
+	g.doc("Close closes the %s.", name)
+	g.p("func (d *%s) Close() error {\nreturn d.Device.Close()\n}\n\n", name)
+
+	for _, r := range c.Registers {
+		g.register(c, r)
+	}
+
+	n := 1 // bytes of the widest register
+	for _, r := range c.Registers {
+		if r.Width/8 > n {
+			n = r.Width / 8
+		}
+	}
+	g.doc("readReg reads the register reg of n bytes.")
+	g.p("func (d *%s) readReg(reg byte, n int) (uint32, error) {\n", name)
+	g.p("b := make([]byte, n)\nif err := d.Device.ReadReg(reg, b); err != nil {\nreturn 0, err\n}\n")
+	g.p("var v uint32\n")
+	if n == 1 {
+		g.p("v = uint32(b[0])\n")
+	} else if c.BigEndian {
+		g.p("for _, c := range b {\nv = v<<8 | uint32(c)\n}\n")
+	} else {
+		g.p("for i := n - 1; i >= 0; i-- {\nv = v<<8 | uint32(b[i])\n}\n")
+	}
+	g.p("return v, nil\n}\n\n")
+	g.doc("writeReg writes v to the register reg of n bytes.")
+	g.p("func (d *%s) writeReg(reg byte, v uint32, n int) error {\n", name)
+	g.p("b := make([]byte, 1+n)\nb[0] = reg\n")
+	if n == 1 {
+		g.p("b[1] = byte(v)\n")
+	} else if c.BigEndian {
+		g.p("for i := n; i > 0; i-- {\nb[i] = byte(v)\nv >>= 8\n}\n")
+	} else {
+		g.p("for i := 1; i <= n; i++ {\nb[i] = byte(v)\nv >>= 8\n}\n")
+	}
+	g.p("return d.Device.Write(b)\n}\n")
+
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting the code of the %s failed - %v", c.Name, err)
+	}
+	return src, nil
+}
+
+// register writes the methods of the register r.
+func (g *gen) register(c *Chip, r *Register) {
+	name, t, n := c.Name, regType(r), r.Width/8
+	if r.Read {
+		g.doc("Read%s reads the %s register%s.", r.Name, r.Name, sentenceDoc(r.Doc))
+		g.p("func (d *%s) Read%s() (%s, error) {\n", name, r.Name, t)
+		g.p("v, err := d.readReg(Reg%s, %d)\n", r.Name, n)
+		if r.Signed && r.Width == 24 {
+			g.p("return int32(v<<8) >> 8, err\n}\n\n")
+		} else {
+			g.p("return %s(v), err\n}\n\n", t)
+		}
+	}
+	if r.Write {
+		g.doc("Write%s writes the %s register%s.", r.Name, r.Name, sentenceDoc(r.Doc))
+		g.p("func (d *%s) Write%s(v %s) error {\n", name, r.Name, t)
+		g.p("return d.writeReg(Reg%s, uint32(v), %d)\n}\n\n", r.Name, n)
+	}
+	for _, f := range r.Fields {
+		fn := r.Name + f.Name
+		if r.Read {
+			g.doc("Read%s reads the %s field of the %s register%s.", fn, f.Name, r.Name, sentenceDoc(f.Doc))
+			g.p("func (d *%s) Read%s() (%s, error) {\n", name, fn, t)
+			g.p("v, err := d.Read%s()\n", r.Name)
+			g.p("return v & %sMask >> %sShift, err\n}\n\n", fn, fn)
+		}
+		if r.Read && r.Write {
+			g.doc("Write%s writes the %s field of the %s register, its other fields are left as they are.", fn, f.Name, r.Name)
+			g.p("func (d *%s) Write%s(v %s) error {\n", name, fn, t)
+			g.p("r, err := d.Read%s()\nif err != nil {\nreturn err\n}\n", r.Name)
+			g.p("return d.Write%s(r&^%sMask | v<<%sShift&%sMask)\n}\n\n", r.Name, fn, fn, fn)
+		}
+	}
+	for _, cv := range r.Conversions {
+		fn := r.Name + cv.Name
+		if cv.read != nil {
+			g.doc("%s reads the %s register%s.", fn, r.Name, unitDoc(cv.Unit))
+			g.p("func (d *%s) %s() (float64, error) {\n", name, fn)
+			g.p("raw, err := d.Read%s()\nif err != nil {\nreturn 0, err\n}\n", r.Name)
+			g.p("return %s, nil\n}\n\n", cv.read.code(0, "float64(raw)"))
+		}
+		if cv.write != nil {
+			g.doc("Set%s writes the %s register%s.", fn, r.Name, unitDoc(cv.Unit))
+			g.p("func (d *%s) Set%s(v float64) error {\n", name, fn)
+			g.p("x := math.Round(%s)\n", cv.write.code(0, "v"))
+			lo, hi := "0", fmt.Sprintf("1<<%d - 1", r.Width)
+			if r.Signed {
+				lo, hi = fmt.Sprintf("-1 << %d", r.Width-1), fmt.Sprintf("1<<%d - 1", r.Width-1)
+			}
+			g.p("if x < %s || x > %s {\n", lo, hi)
+			g.p("return fmt.Errorf(\"%%v%s out of the range of the %s register\", v)\n}\n", unitSuffix(cv.Unit), r.Name)
+			g.p("return d.Write%s(%s(x))\n}\n\n", r.Name, t)
+		}
+	}
+}
+
+// gen accumulates the source generated.
+type gen struct {
+	buf bytes.Buffer
+}
+
+func (g *gen) p(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// doc writes a doc comment, wrapped at 76 columns.
+func (g *gen) doc(format string, args ...interface{}) {
+	n := 0
+	for i, w := range strings.Fields(fmt.Sprintf(format, args...)) {
+		switch {
+		case i == 0:
+			g.p("// %s", w)
+			n = 3 + len(w)
+		case n+1+len(w) > 76:
+			g.p("\n// %s", w)
+			n = 3 + len(w)
+		default:
+			g.p(" %s", w)
+			n += 1 + len(w)
+		}
+	}
+	g.p("\n")
+}
+
+func (c *Chip) usesFmt() bool {
+	for _, r := range c.Registers {
+		for _, cv := range r.Conversions {
+			if cv.write != nil {
+				return true
+			}
+		}
+	}
+	return len(c.Init) > 0
+}
+
+func (c *Chip) usesMath() bool {
+	for _, r := range c.Registers {
+		for _, cv := range r.Conversions {
+			if cv.write != nil || cv.read != nil && cv.read.usesMath() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// regType returns the Go type of the values of r.
+func regType(r *Register) string {
+	w := r.Width
+	if w == 24 {
+		w = 32
+	}
+	if r.Signed {
+		return fmt.Sprintf("int%d", w)
+	}
+	return fmt.Sprintf("uint%d", w)
+}
+
+// hex returns v in hexadecimal, with the digits of a register of width
+// bits.
+func hex(v uint32, width int) string {
+	return fmt.Sprintf("%#0*x", width/4, v)
+}
+
+func lineDoc(doc string) string {
+	if doc == "" {
+		return ""
+	}
+	return " // " + strings.TrimSuffix(doc, ".")
+}
+
+func sentenceDoc(doc string) string {
+	if doc == "" {
+		return ""
+	}
+	return ", " + strings.TrimSuffix(doc, ".")
+}
+
+func unitDoc(unit string) string {
+	if unit == "" {
+		return ""
+	}
+	return " in " + unit
+}
+
+func unitSuffix(unit string) string {
+	if unit == "" {
+		return ""
+	}
+	return " " + unit
+}
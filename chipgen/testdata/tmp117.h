@@ -0,0 +1,98 @@
+/* Code generated by chipgen from the TMP117 descriptor. DO NOT EDIT. */
+
+#ifndef TMP117_H
+#define TMP117_H
+
+#include <stdint.h>
+#include <math.h>
+
+/* Default I2C address of the TMP117. */
+#define TMP117_ADDR 0x48
+
+/* Registers of the TMP117, in big endian when wider than a byte. */
+#define TMP117_REG_TEMP 0x00 /* int16_t, the temperature of the last conversion */
+#define TMP117_REG_CONFIG 0x01 /* uint16_t */
+#define TMP117_REG_HIGH_LIMIT 0x02 /* int16_t */
+#define TMP117_REG_LOW_LIMIT 0x03 /* int16_t */
+#define TMP117_REG_EEPROM_UNLOCK 0x04 /* uint16_t */
+#define TMP117_REG_OFFSET 0x07 /* int16_t, the offset added to the temperatures */
+#define TMP117_REG_DEVICE_ID 0x0f /* uint16_t */
+
+/* Fields of the Config register, and their values. */
+#define TMP117_CONFIG_HIGH_ALERT_MASK 0x8000
+#define TMP117_CONFIG_HIGH_ALERT_SHIFT 15
+#define TMP117_CONFIG_LOW_ALERT_MASK 0x4000
+#define TMP117_CONFIG_LOW_ALERT_SHIFT 14
+#define TMP117_CONFIG_DATA_READY_MASK 0x2000
+#define TMP117_CONFIG_DATA_READY_SHIFT 13
+#define TMP117_CONFIG_EEPROM_BUSY_MASK 0x1000
+#define TMP117_CONFIG_EEPROM_BUSY_SHIFT 12
+#define TMP117_CONFIG_MODE_MASK 0x0c00
+#define TMP117_CONFIG_MODE_SHIFT 10
+#define TMP117_CONFIG_MODE_CONTINUOUS 0
+#define TMP117_CONFIG_MODE_SHUTDOWN 1
+#define TMP117_CONFIG_MODE_ONE_SHOT 3
+#define TMP117_CONFIG_CYCLE_MASK 0x0380
+#define TMP117_CONFIG_CYCLE_SHIFT 7
+#define TMP117_CONFIG_AVERAGING_MASK 0x0060
+#define TMP117_CONFIG_AVERAGING_SHIFT 5
+#define TMP117_CONFIG_AVERAGING_NONE 0
+#define TMP117_CONFIG_AVERAGING_AVG8 1
+#define TMP117_CONFIG_AVERAGING_AVG32 2
+#define TMP117_CONFIG_AVERAGING_AVG64 3
+#define TMP117_CONFIG_THERM_MODE_MASK 0x0010
+#define TMP117_CONFIG_THERM_MODE_SHIFT 4
+#define TMP117_CONFIG_POLARITY_MASK 0x0008
+#define TMP117_CONFIG_POLARITY_SHIFT 3
+#define TMP117_CONFIG_DR_ALERT_MASK 0x0004
+#define TMP117_CONFIG_DR_ALERT_SHIFT 2
+#define TMP117_CONFIG_SOFT_RESET_MASK 0x0002
+#define TMP117_CONFIG_SOFT_RESET_SHIFT 1
+
+/* Fields of the DeviceID register, and their values. */
+#define TMP117_DEVICE_ID_REVISION_MASK 0xf000
+#define TMP117_DEVICE_ID_REVISION_SHIFT 12
+
+/* The DeviceID register masked by the mask identifies the TMP117. */
+#define TMP117_ID_MASK 0x0fff
+#define TMP117_ID_VALUE 0x0117
+
+/* Values written to the registers when the TMP117 is opened, in order. */
+#define TMP117_INIT_CONFIG 0x0220
+
+/* tmp117_temp_celsius converts a value of the Temp register in °C. */
+static inline double tmp117_temp_celsius(int16_t raw) {
+	return (double)raw * 0.0078125;
+}
+
+/* tmp117_high_limit_celsius converts a value of the HighLimit register in °C. */
+static inline double tmp117_high_limit_celsius(int16_t raw) {
+	return (double)raw * 0.0078125;
+}
+
+/* tmp117_high_limit_from_celsius converts a value in °C to the HighLimit register. */
+static inline int16_t tmp117_high_limit_from_celsius(double v) {
+	return (int16_t)lround(v / 0.0078125);
+}
+
+/* tmp117_low_limit_celsius converts a value of the LowLimit register in °C. */
+static inline double tmp117_low_limit_celsius(int16_t raw) {
+	return (double)raw * 0.0078125;
+}
+
+/* tmp117_low_limit_from_celsius converts a value in °C to the LowLimit register. */
+static inline int16_t tmp117_low_limit_from_celsius(double v) {
+	return (int16_t)lround(v / 0.0078125);
+}
+
+/* tmp117_offset_celsius converts a value of the Offset register in °C. */
+static inline double tmp117_offset_celsius(int16_t raw) {
+	return (double)raw * 0.0078125;
+}
+
+/* tmp117_offset_from_celsius converts a value in °C to the Offset register. */
+static inline int16_t tmp117_offset_from_celsius(double v) {
+	return (int16_t)lround(v / 0.0078125);
+}
+
+#endif /* TMP117_H */
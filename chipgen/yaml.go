@@ -0,0 +1,203 @@
+package chipgen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// node is a node of a YAML document: a scalar, a mapping or a sequence.
+type node struct {
+	line   int
+	scalar string
+	keys   []string // of a mapping, in order
+	m      map[string]*node
+	items  []*node // of a sequence
+}
+
+func (n *node) isMap() bool { return n.m != nil }
+func (n *node) isSeq() bool { return n.items != nil }
+
+// line is a line of a YAML document, without its indentation and comment.
+type line struct {
+	n      int
+	indent int
+	text   string
+}
+
+// parseYAML parses the subset of YAML of the descriptors: block mappings
+// and sequences, plain and quoted scalars, and comments. Flow collections,
+// anchors, tags and multi-line scalars are not supported.
+func parseYAML(b []byte) (*node, error) {
+	var lines []*line
+	for i, s := range strings.Split(string(b), "\n") {
+		s = strings.TrimRight(stripComment(s), " \t\r")
+		t := strings.TrimLeft(s, " ")
+		if t == "" || t == "---" {
+			continue
+		}
+		if strings.HasPrefix(t, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed in the indentation", i+1)
+		}
+		lines = append(lines, &line{n: i + 1, indent: len(s) - len(t), text: t})
+	}
+	if len(lines) == 0 {
+		return &node{m: map[string]*node{}}, nil
+	}
+	p := &yamlParser{lines: lines}
+	n, err := p.block(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.i < len(lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", lines[p.i].n)
+	}
+	return n, nil
+}
+
+// stripComment removes the comment of s, a # at its start or after a
+// space, outside of the quotes.
+func stripComment(s string) string {
+	var quote rune
+	for i, c := range s {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i]
+		}
+	}
+	return s
+}
+
+type yamlParser struct {
+	lines []*line
+	i     int
+}
+
+// block parses the mapping or the sequence at indent.
+func (p *yamlParser) block(indent int) (*node, error) {
+	l := p.lines[p.i]
+	if isItem(l.text) {
+		return p.sequence(indent)
+	}
+	return p.mapping(indent)
+}
+
+func isItem(s string) bool { return s == "-" || strings.HasPrefix(s, "- ") }
+
+func (p *yamlParser) sequence(indent int) (*node, error) {
+	n := &node{line: p.lines[p.i].n, items: []*node{}}
+	for p.i < len(p.lines) {
+		l := p.lines[p.i]
+		if l.indent != indent || !isItem(l.text) {
+			break
+		}
+		rest := strings.TrimLeft(strings.TrimPrefix(l.text, "-"), " ")
+		switch {
+		case rest == "":
+			// the item is the block below
+			p.i++
+			if p.i == len(p.lines) || p.lines[p.i].indent <= indent {
+				n.items = append(n.items, &node{line: l.n})
+				continue
+			}
+			item, err := p.block(p.lines[p.i].indent)
+			if err != nil {
+				return nil, err
+			}
+			n.items = append(n.items, item)
+		case isItem(rest) || isKey(rest):
+			// a block starting on the line of the item
+			l.indent, l.text = l.indent+len(l.text)-len(rest), rest
+			item, err := p.block(l.indent)
+			if err != nil {
+				return nil, err
+			}
+			n.items = append(n.items, item)
+		default:
+			s, err := scalar(rest, l.n)
+			if err != nil {
+				return nil, err
+			}
+			n.items = append(n.items, &node{line: l.n, scalar: s})
+			p.i++
+		}
+	}
+	return n, nil
+}
+
+// isKey reports whether s starts with a key of a mapping.
+func isKey(s string) bool {
+	if s[0] == '"' || s[0] == '\'' {
+		return false
+	}
+	i := strings.Index(s, ":")
+	return i > 0 && (i == len(s)-1 || s[i+1] == ' ')
+}
+
+func (p *yamlParser) mapping(indent int) (*node, error) {
+	n := &node{line: p.lines[p.i].n, m: map[string]*node{}}
+	for p.i < len(p.lines) {
+		l := p.lines[p.i]
+		if l.indent < indent {
+			break
+		}
+		if l.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", l.n)
+		}
+		if isItem(l.text) || !isKey(l.text) {
+			return nil, fmt.Errorf("line %d: expected a key", l.n)
+		}
+		i := strings.Index(l.text, ":")
+		key, rest := l.text[:i], strings.TrimSpace(l.text[i+1:])
+		if _, ok := n.m[key]; ok {
+			return nil, fmt.Errorf("line %d: duplicate key %q", l.n, key)
+		}
+		p.i++
+		var v *node
+		switch {
+		case rest != "":
+			s, err := scalar(rest, l.n)
+			if err != nil {
+				return nil, err
+			}
+			v = &node{line: l.n, scalar: s}
+		case p.i < len(p.lines) && (p.lines[p.i].indent > indent ||
+			p.lines[p.i].indent == indent && isItem(p.lines[p.i].text)):
+			var err error
+			if v, err = p.block(p.lines[p.i].indent); err != nil {
+				return nil, err
+			}
+		default:
+			v = &node{line: l.n}
+		}
+		n.keys = append(n.keys, key)
+		n.m[key] = v
+	}
+	return n, nil
+}
+
+// scalar returns the value of the scalar s, unquoted.
+func scalar(s string, line int) (string, error) {
+	switch s[0] {
+	case '"':
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return "", fmt.Errorf("line %d: invalid string %s", line, s)
+		}
+		return v, nil
+	case '\'':
+		if len(s) < 2 || s[len(s)-1] != '\'' {
+			return "", fmt.Errorf("line %d: invalid string %s", line, s)
+		}
+		return strings.Replace(s[1:len(s)-1], "''", "'", -1), nil
+	case '[', '{', '&', '*', '!', '|', '>':
+		return "", fmt.Errorf("line %d: unsupported YAML %s", line, s)
+	}
+	return s, nil
+}
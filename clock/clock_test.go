@@ -0,0 +1,123 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFakeTimers(t *testing.T) {
+	c := NewFake(epoch)
+	var fired []string
+	c.AfterFunc(3*time.Second, func() { fired = append(fired, "3s") })
+	c.AfterFunc(time.Second, func() { fired = append(fired, "1s") })
+	stopped := c.AfterFunc(2*time.Second, func() { fired = append(fired, "2s") })
+	timer := c.NewTimer(2 * time.Second)
+
+	if !stopped.Stop() {
+		t.Error("Stop of an armed timer returned false")
+	}
+	c.Advance(time.Second)
+	if len(fired) != 1 || c.Now() != epoch.Add(time.Second) {
+		t.Errorf("after 1s: fired %v at %v", fired, c.Now())
+	}
+	select {
+	case <-timer.C():
+		t.Error("timer fired early")
+	default:
+	}
+	c.Advance(5 * time.Second)
+	if len(fired) != 2 || fired[1] != "3s" {
+		t.Errorf("fired %v; want 1s and 3s", fired)
+	}
+	if at := <-timer.C(); at != epoch.Add(2*time.Second) {
+		t.Errorf("timer fired at %v", at)
+	}
+	if timer.Stop() {
+		t.Error("Stop of a fired timer returned true")
+	}
+	timer.Reset(time.Second)
+	c.Advance(time.Second)
+	if at := <-timer.C(); at != epoch.Add(7*time.Second) {
+		t.Errorf("reset timer fired at %v", at)
+	}
+}
+
+func TestFakeTicker(t *testing.T) {
+	c := NewFake(epoch)
+	tk := c.NewTicker(time.Second)
+	for i := 1; i <= 3; i++ {
+		c.Advance(time.Second)
+		if at := <-tk.C(); at != epoch.Add(time.Duration(i)*time.Second) {
+			t.Errorf("tick %d at %v", i, at)
+		}
+	}
+	c.Advance(10 * time.Second) // the ticks are dropped
+	<-tk.C()
+	tk.Stop()
+	c.Advance(time.Second)
+	select {
+	case <-tk.C():
+		t.Error("tick after Stop")
+	default:
+	}
+}
+
+func TestFakeSleep(t *testing.T) {
+	c := NewFake(epoch)
+	done := make(chan time.Time)
+	go func() {
+		c.Sleep(time.Minute)
+		done <- c.Now()
+	}()
+	c.BlockUntil(1)
+	c.Advance(30 * time.Second)
+	select {
+	case <-done:
+		t.Fatal("Sleep returned early")
+	default:
+	}
+	c.Advance(30 * time.Second)
+	if now := <-done; now != epoch.Add(time.Minute) {
+		t.Errorf("Sleep returned at %v", now)
+	}
+	c.Sleep(0)
+
+	c.SetAutoSleep(true)
+	c.Sleep(time.Hour)
+	if now := c.Now(); now != epoch.Add(time.Hour+time.Minute) {
+		t.Errorf("auto sleep returned at %v", now)
+	}
+}
+
+func TestScaled(t *testing.T) {
+	c := Scaled(1000)
+	start := c.Now()
+	c.Sleep(time.Second)
+	if d := c.Now().Sub(start); d < time.Second {
+		t.Errorf("slept %v; want at least 1s", d)
+	}
+	tk := c.NewTicker(time.Second)
+	defer tk.Stop()
+	select {
+	case <-tk.C():
+	case <-time.After(time.Second):
+		t.Error("no tick after a real second")
+	}
+	select {
+	case <-c.After(time.Second):
+	case <-time.After(time.Second):
+		t.Error("After did not fire after a real second")
+	}
+}
+
+func TestOr(t *testing.T) {
+	if Or(nil) != Real {
+		t.Error("Or(nil) is not Real")
+	}
+	c := NewFake(epoch)
+	if Or(c) != c {
+		t.Error("Or(c) is not c")
+	}
+}
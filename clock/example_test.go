@@ -0,0 +1,22 @@
+package clock_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/goiot/devices/clock"
+)
+
+func ExampleFake() {
+	c := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	done := make(chan struct{})
+	go func() {
+		c.Sleep(time.Hour)
+		fmt.Println("woke up at", c.Now().Format("15:04"))
+		close(done)
+	}()
+	c.BlockUntil(1) // wait for the goroutine to sleep
+	c.Advance(time.Hour)
+	<-done
+	// Output: woke up at 01:00
+}
@@ -0,0 +1,188 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Fake is a clock whose time only moves with Advance, the timers and
+// tickers fire during Advance, in the order of their deadlines. It can be
+// used by multiple goroutines.
+type Fake struct {
+	mu     sync.Mutex
+	cond   *sync.Cond // signaled when a timer is armed
+	now    time.Time
+	timers []*fakeTimer
+	auto   bool
+}
+
+// NewFake returns a fake clock set to now.
+func NewFake(now time.Time) *Fake {
+	f := &Fake{now: now}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+// Now implements Clock.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Sleep implements Clock, it returns once the clock is advanced by d, or
+// right away after advancing it with SetAutoSleep.
+func (f *Fake) Sleep(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	f.mu.Lock()
+	auto := f.auto
+	f.mu.Unlock()
+	if auto {
+		f.Advance(d)
+		return
+	}
+	<-f.After(d)
+}
+
+// SetAutoSleep sets whether Sleep advances the clock itself instead of
+// waiting for Advance, e.g. for the short delays of a driver polling a
+// device in the goroutine of the test.
+func (f *Fake) SetAutoSleep(auto bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.auto = auto
+}
+
+// After implements Clock.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+// NewTimer implements Clock.
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{f: f, c: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+// NewTicker implements Clock.
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	t := &fakeTimer{f: f, c: make(chan time.Time, 1), period: d}
+	t.Reset(d)
+	return fakeTicker{t}
+}
+
+// AfterFunc implements Clock, f runs in the goroutine calling Advance.
+func (f *Fake) AfterFunc(d time.Duration, fn func()) Timer {
+	t := &fakeTimer{f: f, fn: fn}
+	t.Reset(d)
+	return t
+}
+
+// Advance moves the time forward by d, firing the timers and tickers
+// due in order. A ticker fires at most once per period, the ticks not
+// received are dropped as with time.Ticker.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	end := f.now.Add(d)
+	for {
+		var next *fakeTimer
+		for _, t := range f.timers {
+			if !t.at.After(end) && (next == nil || t.at.Before(next.at)) {
+				next = t
+			}
+		}
+		if next == nil {
+			break
+		}
+		at := next.at
+		f.now = at
+		if next.period > 0 {
+			next.at = at.Add(next.period)
+		} else {
+			f.remove(next)
+		}
+		f.mu.Unlock()
+		next.fire(at)
+		f.mu.Lock()
+	}
+	f.now = end
+	f.mu.Unlock()
+}
+
+// BlockUntil waits until at least n timers, tickers or sleeps are armed,
+// e.g. to advance the clock once the code under test waits.
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.timers) < n {
+		f.cond.Wait()
+	}
+}
+
+func (f *Fake) remove(t *fakeTimer) bool {
+	for i, u := range f.timers {
+		if u == t {
+			f.timers = append(f.timers[:i], f.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+type fakeTimer struct {
+	f      *Fake
+	c      chan time.Time
+	fn     func()
+	period time.Duration // tickers only
+	at     time.Time
+}
+
+func (t *fakeTimer) fire(now time.Time) {
+	if t.fn != nil {
+		t.fn()
+		return
+	}
+	select {
+	case t.c <- now:
+	default:
+	}
+}
+
+type fakeTicker struct{ *fakeTimer }
+
+func (t fakeTicker) Stop() { t.fakeTimer.Stop() }
+
+func (t *fakeTimer) C() <-chan time.Time { return t.c }
+
+func (t *fakeTimer) Stop() bool {
+	t.f.mu.Lock()
+	defer t.f.mu.Unlock()
+	return t.f.remove(t)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	f := t.f
+	f.mu.Lock()
+	active := f.remove(t)
+	if d <= 0 && t.period == 0 {
+		now := f.now
+		f.mu.Unlock()
+		if t.fn != nil {
+			go t.fn()
+		} else {
+			t.fire(now)
+		}
+		return active
+	}
+	t.at = f.now.Add(d)
+	f.timers = append(f.timers, t)
+	f.cond.Broadcast()
+	f.mu.Unlock()
+	return active
+}
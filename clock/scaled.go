@@ -0,0 +1,90 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Scaled returns a clock starting at the current time and running factor
+// times faster than the real time, e.g. 60 to simulate an hour per minute.
+// The durations are divided by factor before waiting.
+func Scaled(factor float64) Clock {
+	if factor <= 0 {
+		panic("clock: non-positive factor for Scaled")
+	}
+	return &scaled{start: time.Now(), factor: factor}
+}
+
+type scaled struct {
+	start  time.Time
+	factor float64
+}
+
+func (s *scaled) real(d time.Duration) time.Duration {
+	return time.Duration(float64(d) / s.factor)
+}
+
+func (s *scaled) Now() time.Time {
+	return s.start.Add(time.Duration(float64(time.Since(s.start)) * s.factor))
+}
+
+func (s *scaled) Sleep(d time.Duration) { time.Sleep(s.real(d)) }
+
+func (s *scaled) After(d time.Duration) <-chan time.Time { return s.NewTimer(d).C() }
+
+func (s *scaled) NewTimer(d time.Duration) Timer {
+	t := &scaledTimer{s: s, c: make(chan time.Time, 1)}
+	t.t = time.AfterFunc(s.real(d), func() {
+		select {
+		case t.c <- s.Now():
+		default:
+		}
+	})
+	return t
+}
+
+func (s *scaled) AfterFunc(d time.Duration, f func()) Timer {
+	return &scaledTimer{s: s, t: time.AfterFunc(s.real(d), f)}
+}
+
+func (s *scaled) NewTicker(d time.Duration) Ticker {
+	t := &scaledTicker{t: time.NewTicker(s.real(d)), c: make(chan time.Time, 1), done: make(chan struct{})}
+	go func() {
+		for {
+			select {
+			case <-t.t.C:
+			case <-t.done:
+				return
+			}
+			select {
+			case t.c <- s.Now():
+			default:
+			}
+		}
+	}()
+	return t
+}
+
+type scaledTimer struct {
+	s *scaled
+	t *time.Timer
+	c chan time.Time // nil for AfterFunc
+}
+
+func (t *scaledTimer) C() <-chan time.Time        { return t.c }
+func (t *scaledTimer) Stop() bool                 { return t.t.Stop() }
+func (t *scaledTimer) Reset(d time.Duration) bool { return t.t.Reset(t.s.real(d)) }
+
+type scaledTicker struct {
+	t    *time.Ticker
+	c    chan time.Time
+	done chan struct{}
+	once sync.Once
+}
+
+func (t *scaledTicker) C() <-chan time.Time { return t.c }
+
+func (t *scaledTicker) Stop() {
+	t.t.Stop()
+	t.once.Do(func() { close(t.done) })
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/goiot/devices/bme280"
+)
+
+// Config is the configuration of the monitor, read from a JSON file; the
+// fields left out keep their default.
+type Config struct {
+	// Bus is the I2C bus of the sensor and the display, see i2cbus.Find.
+	Bus string `json:"bus"`
+
+	// Sensor is the address of the BME280.
+	Sensor int `json:"sensor"`
+
+	Display DisplayConfig `json:"display"`
+
+	// Interval is the time between two readings.
+	Interval Duration `json:"interval"`
+
+	// History is the time the readings are kept, and drawn by the graph.
+	History Duration `json:"history"`
+
+	Alerts []AlertConfig `json:"alerts"`
+
+	// Listen is the address of the dashboard streaming the screen and the
+	// readings, e.g. ":8080"; none if empty.
+	Listen string `json:"listen"`
+
+	// Watchdog is the hardware watchdog kept alive while the readings are
+	// taken, e.g. "/dev/watchdog"; none if empty.
+	Watchdog string `json:"watchdog"`
+}
+
+// DisplayConfig is the configuration of the OLED.
+type DisplayConfig struct {
+	Addr     int `json:"addr"`
+	Width    int `json:"width"`
+	Height   int `json:"height"`
+	Rotation int `json:"rotation"`
+}
+
+// AlertConfig is an alert on a reading: temperature, humidity or
+// pressure.
+type AlertConfig struct {
+	Name    string `json:"name"`
+	Reading string `json:"reading"`
+
+	// Above or Below is the threshold.
+	Above *float64 `json:"above"`
+	Below *float64 `json:"below"`
+
+	Hysteresis float64  `json:"hysteresis"`
+	For        Duration `json:"for"`
+}
+
+// Duration is a time.Duration in JSON, as a string such as "10s".
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("invalid duration %s, should be a string such as \"10s\"", b)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// readings are the readings of the BME280, with their unit.
+var readings = map[string]string{
+	"temperature": "°C",
+	"humidity":    "%",
+	"pressure":    "hPa",
+}
+
+func defaultConfig() Config {
+	return Config{
+		Bus:      "primary",
+		Sensor:   bme280.Addr,
+		Display:  DisplayConfig{Width: 128, Height: 64},
+		Interval: Duration{10 * time.Second},
+		History:  Duration{24 * time.Hour},
+	}
+}
+
+// loadConfig reads the configuration of the file at path, the default one
+// if path is empty.
+func loadConfig(path string) (Config, error) {
+	c := defaultConfig()
+	if path != "" {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return Config{}, err
+		}
+		if err := json.Unmarshal(b, &c); err != nil {
+			return Config{}, fmt.Errorf("invalid configuration %v - %v", path, err)
+		}
+	}
+	return c, c.check()
+}
+
+func (c Config) check() error {
+	if c.Interval.Duration <= 0 {
+		return fmt.Errorf("invalid interval %v, should be positive", c.Interval)
+	}
+	if c.History.Duration < c.Interval.Duration {
+		return fmt.Errorf("history of %v shorter than the interval of %v", c.History, c.Interval)
+	}
+	for _, a := range c.Alerts {
+		if _, ok := readings[a.Reading]; !ok {
+			return fmt.Errorf("alert %q on the unknown reading %q, should be temperature, humidity or pressure", a.Name, a.Reading)
+		}
+		if (a.Above == nil) == (a.Below == nil) {
+			return fmt.Errorf("alert %q needs either a threshold above or below", a.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/bme280"
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/displaytest"
+	"github.com/goiot/devices/monochromeoled"
+	"github.com/goiot/devices/monochromeoled/oledsim"
+	"github.com/goiot/devices/text"
+)
+
+func TestConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "envmon.json")
+	ioutil.WriteFile(path, []byte(`{
+		"interval": "30s",
+		"display": {"width": 128, "height": 32},
+		"alerts": [{"name": "hot", "reading": "temperature", "above": 28, "hysteresis": 1, "for": "1m"}]
+	}`), 0644)
+	c, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	above := 28.0
+	want := defaultConfig()
+	want.Interval.Duration = 30 * time.Second
+	want.Display.Height = 32
+	want.Alerts = []AlertConfig{{Name: "hot", Reading: "temperature", Above: &above, Hysteresis: 1, For: Duration{time.Minute}}}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("loadConfig() = %+v; want %+v", c, want)
+	}
+
+	for _, s := range []string{
+		`{"interval": 10}`,
+		`{"interval": "0s"}`,
+		`{"history": "1s"}`,
+		`{"alerts": [{"name": "co2", "reading": "co2", "above": 1500}]}`,
+		`{"alerts": [{"name": "hot", "reading": "temperature"}]}`,
+	} {
+		ioutil.WriteFile(path, []byte(s), 0644)
+		if _, err := loadConfig(path); err == nil {
+			t.Errorf("loadConfig() succeeded with %s", s)
+		}
+	}
+}
+
+func TestMonitor(t *testing.T) {
+	sim := oledsim.New(128, 64)
+	oled, err := monochromeoled.Open(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clk := clock.NewFake(time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC))
+	above := 28.0
+	c := defaultConfig()
+	c.History.Duration = time.Hour
+	c.Alerts = []AlertConfig{{Name: "hot", Reading: "temperature", Above: &above}}
+	temp := 20.0
+	read := func() (bme280.Measurement, error) {
+		return bme280.Measurement{Temperature: temp, Humidity: 45, Pressure: 1013}, nil
+	}
+	m := newMonitor(c, read, oled)
+	m.clk = clk
+
+	if err := m.draw(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := image.NewGray(image.Rect(0, 0, 128, 64))
+	text.Small.Draw(want, 0, 0, "12:00", color.White, 1)
+	text.Small.Draw(want, 0, 12, "waiting for the sensor", color.White, 1)
+	if n, r, _ := displaytest.Diff(sim.Image(), want); n != 0 {
+		t.Errorf("%d pixels differ before the readings:\n%s", n, displaytest.ASCII(sim.Image(), r))
+	}
+
+	// the temperature rising for half an hour
+	for i := 0; i < 60; i++ {
+		temp = 20 + float64(i)/6
+		if err := m.sample(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		clk.Advance(30 * time.Second)
+	}
+	if got := m.raised(); !reflect.DeepEqual(got, []string{"hot"}) {
+		t.Errorf("alerts raised %q; want hot", got)
+	}
+	if err := m.draw(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	img := sim.Image()
+	top := image.NewGray(image.Rect(0, 0, 128, 24))
+	text.Small.Draw(top, 0, 0, "12:30", color.White, 1)
+	text.Small.Draw(top, 128-4*6, 0, "!hot", color.White, 1)
+	text.Small.Draw(top, 0, 8, "29.8C", color.White, 2)
+	text.Small.Draw(top, 128-3*6, 8, "45%", color.White, 1)
+	text.Small.Draw(top, 128-7*6, 16, "1013hPa", color.White, 1)
+	if n, r, _ := displaytest.Diff(img.SubImage(top.Rect).(*image.Gray), top); n != 0 {
+		t.Errorf("%d pixels of the readings differ:\n%s", n, displaytest.ASCII(img, r))
+	}
+	// the graph rises from the middle of the history to its end
+	lit := func(x0, x1, y0, y1 int) bool {
+		for x := x0; x < x1; x++ {
+			for y := y0; y < y1; y++ {
+				if img.GrayAt(x, y).Y != 0 {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	if lit(0, 60, 24, 64) {
+		t.Error("graph drawn before the first reading")
+	}
+	if !lit(60, 68, 56, 64) || !lit(120, 128, 24, 32) {
+		t.Errorf("graph not rising from the bottom to the top:\n%s", displaytest.ASCII(img, image.Rect(0, 24, 128, 64)))
+	}
+}
+
+func TestRecover(t *testing.T) {
+	oled, err := monochromeoled.Open(oledsim.New(128, 64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	readErr := errors.New("no ack")
+	read := func() (bme280.Measurement, error) { return bme280.Measurement{}, readErr }
+	m := newMonitor(defaultConfig(), read, oled)
+	var resumed int
+	var failures []error
+	action := m.recover(func() error { resumed++; return nil }, func(err error) { failures = append(failures, err) })
+
+	// a reading after opening the sensor again starts the count over
+	for i := 0; i < maxRecoveries; i++ {
+		if err := action("sensor"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	readErr = nil
+	if err := m.sample(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	readErr = errors.New("no ack")
+	for i := 0; i < maxRecoveries; i++ {
+		if err := action("sensor"); err != nil {
+			t.Fatalf("recovery %d failed after a reading: %v", i, err)
+		}
+	}
+	if m.sample(context.Background()) == nil {
+		t.Fatal("sample() succeeded without reading")
+	}
+	if len(failures) != 0 {
+		t.Fatalf("failures reported while recovering: %v", failures)
+	}
+	if err := action("sensor"); err == nil || len(failures) != 1 {
+		t.Errorf("action() = %v after %d recoveries, %d failures reported; want an error reported", err, maxRecoveries, len(failures))
+	}
+	if resumed != 2*maxRecoveries {
+		t.Errorf("sensor opened %d times; want %d", resumed, 2*maxRecoveries)
+	}
+}
@@ -0,0 +1,136 @@
+// Envmon is an environmental monitor: it reads a BME280 and shows the
+// temperature, the humidity, the pressure and the graph of the temperature
+// on an SSD1306 OLED, raises the alerts of its configuration, and streams
+// the screen and the readings to a dashboard in the browsers.
+//
+// It runs as a service, see envmon.service, configured by a JSON file:
+//
+//	envmon -config /etc/envmon.json
+//
+// The devices share the bus through i2csched, the readings first; the
+// readings are watched by a watchdog, and the devices are initialized
+// again after the sleeps of the system. It exits with an error if the
+// readings don't come back after opening the sensor again a few times,
+// for systemd to restart it.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/goiot/devices/i2cbus"
+	"github.com/goiot/devices/i2csched"
+	"github.com/goiot/devices/monochromeoled"
+	"github.com/goiot/devices/scheduler"
+	"github.com/goiot/devices/stream"
+	"github.com/goiot/devices/suspend"
+	"github.com/goiot/devices/watchdog"
+)
+
+func main() {
+	path := flag.String("config", "", "configuration file, the defaults if empty")
+	flag.Parse()
+	if err := run(*path); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(path string) error {
+	c, err := loadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	i2c, err := i2cbus.Open(c.Bus)
+	if err != nil {
+		return err
+	}
+	bus := i2csched.New(i2c)
+	bus.SetBudget(c.Sensor, i2csched.Budget{Priority: 10})
+	oled, err := monochromeoled.OpenWithConfig(bus, monochromeoled.Config{
+		Addr:     c.Display.Addr,
+		Width:    c.Display.Width,
+		Height:   c.Display.Height,
+		Rotation: c.Display.Rotation,
+	})
+	if err != nil {
+		return err
+	}
+	defer oled.Close()
+	s, err := openSensor(bus, c.Sensor)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	m := newMonitor(c, s.Read, oled)
+	// the first failure stops the monitor
+	failed := make(chan error, 1)
+	fail := func(err error) {
+		select {
+		case failed <- err:
+		default:
+		}
+	}
+	if c.Listen != "" {
+		m.stream = stream.NewServer()
+		go func() {
+			fail(http.ListenAndServe(c.Listen, m.stream))
+		}()
+	}
+
+	wd := watchdog.New()
+	defer wd.Close()
+	wd.OnError = func(name string, err error) { log.Printf("%s: %v", name, err) }
+	m.loop = wd.Watch("sensor", 3*c.Interval.Duration, m.recover(s.Resume, fail))
+	if c.Watchdog != "" {
+		hw, err := watchdog.OpenHardware(c.Watchdog)
+		if err != nil {
+			return err
+		}
+		defer hw.Close()
+		go wd.Keep(hw, time.Second)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	var hooks suspend.Hooks
+	hooks.OnError = func(name string, err error) { log.Printf("%s: %v", name, err) }
+	hooks.Add(suspend.Hook{Name: "sensor", Resume: s.Resume})
+	hooks.Add(suspend.Hook{Name: "oled", Suspend: oled.Off, Resume: oled.Reinit})
+	go func() {
+		if err := hooks.Listen(ctx); err != nil && err != context.Canceled {
+			log.Printf("the devices will not be resumed after the sleeps: %v", err)
+		}
+	}()
+
+	sched := scheduler.New()
+	sched.OnError = func(name string, err error) { log.Printf("%s: %v", name, err) }
+	if err := m.sample(ctx); err != nil {
+		log.Print(err)
+	}
+	if err := sched.Every(c.Interval.Duration, scheduler.Task{Name: "sample", Run: m.sample}); err != nil {
+		sched.Stop()
+		return fmt.Errorf("scheduling the readings failed - %v", err)
+	}
+	if err := sched.Every(time.Second, scheduler.Task{Name: "draw", Run: m.draw}); err != nil {
+		sched.Stop()
+		return fmt.Errorf("scheduling the screen failed - %v", err)
+	}
+
+	select {
+	case <-ctx.Done():
+	case err = <-failed:
+	}
+	sched.Stop()
+	oled.Clear()
+	return err
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/goiot/devices/alerts"
+	"github.com/goiot/devices/bme280"
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/monochromeoled"
+	"github.com/goiot/devices/stream"
+	"github.com/goiot/devices/timeseries"
+	"github.com/goiot/devices/watchdog"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+// monitor takes the readings, raises the alerts and draws the screen.
+type monitor struct {
+	read    func() (bme280.Measurement, error)
+	oled    *monochromeoled.OLED
+	history time.Duration
+
+	store  *timeseries.Store
+	alerts *alerts.Monitor
+
+	// stream sends the readings and the screen to the dashboard, nil
+	// without one.
+	stream *stream.Server
+
+	// loop is kicked by the readings, nil without watchdog.
+	loop *watchdog.Loop
+
+	clk clock.Clock // times the readings, clock.Real if nil
+
+	mu     sync.Mutex
+	active []string // names of the alerts raised, in order
+	stale  int      // times the readings stopped since the last one
+}
+
+// maxRecoveries is the number of times the sensor is opened again without
+// readings before giving up.
+const maxRecoveries = 3
+
+func newMonitor(c Config, read func() (bme280.Measurement, error), oled *monochromeoled.OLED) *monitor {
+	// a bucket of the history per column of the graph
+	res := c.History.Duration / time.Duration(oled.Width())
+	if res < c.Interval.Duration {
+		res = c.Interval.Duration
+	}
+	m := &monitor{
+		read:    read,
+		oled:    oled,
+		history: c.History.Duration,
+		store:   timeseries.NewStore(c.History.Duration, res),
+		alerts:  alerts.NewMonitor(),
+	}
+	for _, a := range c.Alerts {
+		alert := &alerts.Alert{
+			Name:       a.Name,
+			Hysteresis: a.Hysteresis,
+			For:        a.For.Duration,
+			Actions:    []alerts.Action{m.notify},
+		}
+		if a.Above != nil {
+			alert.Kind, alert.Threshold = alerts.Above, *a.Above
+		} else {
+			alert.Kind, alert.Threshold = alerts.Below, *a.Below
+		}
+		m.alerts.Add(a.Reading, alert)
+	}
+	return m
+}
+
+// notify logs the alerts raised and cleared, and keeps them for the
+// screen.
+func (m *monitor) notify(e alerts.Event) error {
+	log.Print(e)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name := e.Alert.Name
+	for i, a := range m.active {
+		if a == name {
+			m.active = append(m.active[:i], m.active[i+1:]...)
+			break
+		}
+	}
+	if e.Active {
+		m.active = append(m.active, name)
+	}
+	return nil
+}
+
+// raised returns the names of the alerts raised.
+func (m *monitor) raised() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.active...)
+}
+
+// sample takes a reading.
+func (m *monitor) sample(ctx context.Context) error {
+	v, err := m.read()
+	if err != nil {
+		return fmt.Errorf("reading the sensor failed - %v", err)
+	}
+	now := clock.Or(m.clk).Now()
+	for name, x := range map[string]float64{
+		"temperature": v.Temperature,
+		"humidity":    v.Humidity,
+		"pressure":    v.Pressure,
+	} {
+		m.store.Add(name, x, now)
+		if err := m.alerts.Observe(name, x, now); err != nil {
+			log.Printf("alert on the %s: %v", name, err)
+		}
+		if m.stream != nil {
+			m.stream.Sample(stream.Sample{Sensor: name, Value: x, Unit: readings[name], Time: now})
+		}
+	}
+	m.mu.Lock()
+	m.stale = 0
+	m.mu.Unlock()
+	if m.loop != nil {
+		m.loop.Kick()
+	}
+	return nil
+}
+
+// recover returns the watchdog action run when the readings stop: it
+// opens the sensor again with resume, and once it did maxRecoveries times
+// without readings, reports the failure to fail instead.
+func (m *monitor) recover(resume func() error, fail func(error)) watchdog.Action {
+	return func(name string) error {
+		m.mu.Lock()
+		m.stale++
+		n := m.stale
+		m.mu.Unlock()
+		if n > maxRecoveries {
+			err := fmt.Errorf("no reading after opening the sensor again %d times", maxRecoveries)
+			fail(err)
+			return err
+		}
+		log.Printf("no reading, opening the sensor again (%d/%d)", n, maxRecoveries)
+		return resume()
+	}
+}
+
+// last returns the last reading of name.
+func (m *monitor) last(name string) (float64, bool) {
+	s := m.store.Series(name)
+	if s == nil {
+		return 0, false
+	}
+	p, ok := s.Last()
+	return p.Avg(), ok
+}
+
+// sensor is the BME280, opened again after the sleeps of the system.
+type sensor struct {
+	o    driver.Opener
+	addr int
+
+	mu sync.Mutex
+	s  *bme280.BME280
+}
+
+func openSensor(o driver.Opener, addr int) (*sensor, error) {
+	s := &sensor{o: o, addr: addr}
+	if err := s.Resume(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *sensor) Read() (bme280.Measurement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.s == nil {
+		return bme280.Measurement{}, fmt.Errorf("BME280 at %#x not opened", s.addr)
+	}
+	return s.s.Read()
+}
+
+// Resume opens the sensor again, its configuration is lost when its power
+// is cut.
+func (s *sensor) Resume() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.s != nil {
+		s.s.Close()
+		s.s = nil
+	}
+	b, err := bme280.Open(s.o, s.addr)
+	if err != nil {
+		return fmt.Errorf("opening the BME280 at %#x failed - %v", s.addr, err)
+	}
+	s.s = b
+	return nil
+}
+
+func (s *sensor) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.s == nil {
+		return nil
+	}
+	err := s.s.Close()
+	s.s = nil
+	return err
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"sync"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/gfx"
+	"github.com/goiot/devices/monochromeoled"
+	"github.com/goiot/devices/text"
+)
+
+// widget draws a part of the screen.
+type widget struct {
+	r    image.Rectangle
+	draw func(c *monochromeoled.Canvas)
+}
+
+// widgets returns the widgets of the screen: the status bar, the readings
+// and, if there is room left, the graph of the temperature.
+func (m *monitor) widgets() []widget {
+	w, h := m.oled.Width(), m.oled.Height()
+	ws := []widget{
+		{image.Rect(0, 0, w, 8), m.status},
+		{image.Rect(0, 8, w, 24), m.values},
+	}
+	if h >= 32 {
+		ws = append(ws, widget{image.Rect(0, 24, w, h), m.graph})
+	}
+	return ws
+}
+
+// draw draws the widgets, each on its canvas at the same time, and shows
+// them at once.
+func (m *monitor) draw(ctx context.Context) error {
+	ws := m.widgets()
+	canvases := make([]*monochromeoled.Canvas, len(ws))
+	var wg sync.WaitGroup
+	for i, w := range ws {
+		wg.Add(1)
+		go func(i int, w widget) {
+			defer wg.Done()
+			c := monochromeoled.NewCanvas(w.r.Dx(), w.r.Dy())
+			w.draw(c)
+			canvases[i] = c
+		}(i, w)
+	}
+	wg.Wait()
+	for i, w := range ws {
+		if err := m.oled.DrawCanvas(w.r.Min.X, w.r.Min.Y, canvases[i]); err != nil {
+			return err
+		}
+	}
+	if err := m.oled.Draw(); err != nil {
+		return err
+	}
+	if m.stream != nil {
+		return m.stream.Frame(m.oled)
+	}
+	return nil
+}
+
+// status draws the time and the alerts raised.
+func (m *monitor) status(c *monochromeoled.Canvas) {
+	f := text.Small
+	f.Draw(c, 0, 0, clock.Or(m.clk).Now().Format("15:04"), color.White, 1)
+	raised := m.raised()
+	if len(raised) == 0 {
+		return
+	}
+	s := "!" + raised[0]
+	if len(raised) > 1 {
+		s += fmt.Sprintf(" +%d", len(raised)-1)
+	}
+	f.Draw(c, c.Width()-f.Size(s, 1).X, 0, s, color.White, 1)
+}
+
+// values draws the last readings, the temperature in large.
+func (m *monitor) values(c *monochromeoled.Canvas) {
+	f := text.Small
+	temp, ok := m.last("temperature")
+	if !ok {
+		f.Draw(c, 0, 4, "waiting for the sensor", color.White, 1)
+		return
+	}
+	f.Draw(c, 0, 0, fmt.Sprintf("%.1fC", temp), color.White, 2)
+	hum, _ := m.last("humidity")
+	press, _ := m.last("pressure")
+	for i, s := range []string{fmt.Sprintf("%.0f%%", hum), fmt.Sprintf("%.0fhPa", press)} {
+		f.Draw(c, c.Width()-f.Size(s, 1).X, i*f.Height, s, color.White, 1)
+	}
+}
+
+// graph draws the temperature over the history, a column per bucket of
+// the time series, scaled to its range.
+func (m *monitor) graph(c *monochromeoled.Canvas) {
+	now := clock.Or(m.clk).Now()
+	w, h := c.Width(), c.Height()
+	s := m.store.Series("temperature")
+	if s == nil {
+		return
+	}
+	pts := s.Downsample(now.Add(-m.history), now, m.history/time.Duration(w))
+	if len(pts) < 2 {
+		return
+	}
+	lo, hi := pts[0].Min, pts[0].Max
+	for _, p := range pts {
+		if p.Min < lo {
+			lo = p.Min
+		}
+		if p.Max > hi {
+			hi = p.Max
+		}
+	}
+	if hi-lo < 1 {
+		// a flat line in the middle, not the noise in full height
+		mid := (hi + lo) / 2
+		lo, hi = mid-0.5, mid+0.5
+	}
+	at := func(i int) image.Point {
+		p := pts[i]
+		x := int(float64(w-1) * float64(p.Time.Sub(now.Add(-m.history))) / float64(m.history))
+		y := int(float64(h-1) * (hi - p.Avg()) / (hi - lo))
+		return image.Pt(x, y)
+	}
+	for i := 1; i < len(pts); i++ {
+		gfx.Line(c, at(i-1), at(i), color.White)
+	}
+}
@@ -0,0 +1,122 @@
+// Package compensate routes the ambient conditions measured by a sensor to
+// the sensors whose measurements are compensated for them, e.g. the
+// temperature and humidity of a BME280 to the gas sensors, or the pressure
+// to a CO2 sensor, without plumbing the readings in each application.
+package compensate
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/goiot/devices/clock"
+)
+
+// Conditions are ambient conditions. The conditions not measured are NaN.
+type Conditions struct {
+	Temperature float64 // degrees Celsius
+	Humidity    float64 // percent of relative humidity
+	Pressure    float64 // hPa
+}
+
+// Unknown returns conditions without any measurement.
+func Unknown() Conditions {
+	return Conditions{Temperature: math.NaN(), Humidity: math.NaN(), Pressure: math.NaN()}
+}
+
+// AbsoluteHumidity returns the absolute humidity in g/m³, the compensation
+// the SGP30 expects, from the temperature and the relative humidity.
+func (c Conditions) AbsoluteHumidity() float64 {
+	// Magnus formula of the saturation vapor pressure in hPa, and ideal
+	// gas law.
+	ps := 6.112 * math.Exp(17.62*c.Temperature/(243.12+c.Temperature))
+	return 216.7 * c.Humidity / 100 * ps / (273.15 + c.Temperature)
+}
+
+// Source is a sensor measuring ambient conditions.
+type Source interface {
+	Conditions() (Conditions, error)
+}
+
+// Compensated is a sensor compensated for the ambient conditions. It
+// ignores the conditions not measured.
+type Compensated interface {
+	Compensate(c Conditions) error
+}
+
+// SourceFunc adapts a function to a Source.
+type SourceFunc func() (Conditions, error)
+
+// Conditions implements Source.
+func (f SourceFunc) Conditions() (Conditions, error) { return f() }
+
+// CompensatedFunc adapts a function to Compensated.
+type CompensatedFunc func(c Conditions) error
+
+// Compensate implements Compensated.
+func (f CompensatedFunc) Compensate(c Conditions) error { return f(c) }
+
+type route struct {
+	src  Source
+	dsts []Compensated
+}
+
+// Router routes the conditions of the sources to the compensated sensors.
+// It can be used by multiple goroutines.
+type Router struct {
+	// Clock times Run, clock.Real if nil.
+	Clock clock.Clock
+
+	mu     sync.Mutex
+	routes []route
+}
+
+// Route routes the conditions measured by src to the sensors dsts.
+func (r *Router) Route(src Source, dsts ...Compensated) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.routes = append(r.routes, route{src, dsts})
+}
+
+// Update reads the sources and compensates the sensors, in the order of
+// the routes. The routes are all updated when one fails, the first error
+// is returned.
+func (r *Router) Update() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var first error
+	for _, rt := range r.routes {
+		c, err := rt.src.Conditions()
+		if err != nil {
+			if first == nil {
+				first = fmt.Errorf("reading the conditions failed - %v", err)
+			}
+			continue
+		}
+		for _, d := range rt.dsts {
+			if err := d.Compensate(c); err != nil && first == nil {
+				first = fmt.Errorf("compensation failed - %v", err)
+			}
+		}
+	}
+	return first
+}
+
+// Run updates the routes every interval until ctx is done. The errors are
+// passed to errs, if not nil, and do not stop the updates.
+func (r *Router) Run(ctx context.Context, interval time.Duration, errs func(error)) error {
+	t := clock.Or(r.Clock).NewTicker(interval)
+	defer t.Stop()
+	for {
+		if err := r.Update(); err != nil && errs != nil {
+			errs(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C():
+		}
+	}
+}
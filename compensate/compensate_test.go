@@ -0,0 +1,58 @@
+package compensate
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestAbsoluteHumidity(t *testing.T) {
+	c := Conditions{Temperature: 25, Humidity: 50}
+	if ah := c.AbsoluteHumidity(); math.Abs(ah-11.5) > 0.1 {
+		t.Errorf("absolute humidity at 25C 50%% = %.2f g/m3; want 11.5", ah)
+	}
+}
+
+func TestRouter(t *testing.T) {
+	var gas, co2 []Conditions
+	var router Router
+	router.Route(SourceFunc(func() (Conditions, error) {
+		return Conditions{Temperature: 21, Humidity: 40, Pressure: 1013}, nil
+	}), CompensatedFunc(func(c Conditions) error { gas = append(gas, c); return nil }))
+	router.Route(SourceFunc(func() (Conditions, error) {
+		c := Unknown()
+		c.Pressure = 990
+		return c, nil
+	}), CompensatedFunc(func(c Conditions) error { co2 = append(co2, c); return nil }))
+
+	if err := router.Update(); err != nil {
+		t.Fatal(err)
+	}
+	if len(gas) != 1 || gas[0].Temperature != 21 || gas[0].Humidity != 40 {
+		t.Errorf("gas sensor compensated with %v", gas)
+	}
+	if len(co2) != 1 || co2[0].Pressure != 990 || !math.IsNaN(co2[0].Temperature) {
+		t.Errorf("CO2 sensor compensated with %v", co2)
+	}
+
+	// A failing source does not prevent the other routes.
+	router.Route(SourceFunc(func() (Conditions, error) { return Unknown(), errors.New("i2c error") }))
+	if err := router.Update(); err == nil {
+		t.Error("Update succeeded with a failing source")
+	}
+	if len(gas) != 2 || len(co2) != 2 {
+		t.Errorf("%d and %d compensations; want 2 and 2", len(gas), len(co2))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var errs int
+	if err := router.Run(ctx, 10*time.Millisecond, func(error) { errs++ }); err != context.DeadlineExceeded {
+		t.Errorf("Run = %v", err)
+	}
+	if errs == 0 || len(gas) < 4 {
+		t.Errorf("Run: %d updates, %d errors", len(gas)-2, errs)
+	}
+}
@@ -0,0 +1,177 @@
+// Package derived computes virtual sensors from the samples of others, e.g.
+// the dew point from a temperature and a humidity, and publishes their
+// samples on the event bus like the real sensors, so the loggers, the
+// bridges and the dashboards handle them the same way.
+package derived
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/goiot/devices/caps"
+	"github.com/goiot/devices/events"
+)
+
+// Sensor is a virtual sensor.
+type Sensor struct {
+	// Source is the source of the samples of the sensor, e.g.
+	// garden/dewpoint.
+	Source string
+
+	// Measurement describes the samples, its unit is the one of the
+	// samples.
+	Measurement caps.Measurement
+
+	// Inputs are the sources of the samples the sensor is computed from,
+	// in the order of the arguments of Func.
+	Inputs []string
+
+	// MaxAge is the age, from the sample received, beyond which the last
+	// samples of the other inputs are too old to be used. The last samples
+	// are used whatever their age if zero.
+	MaxAge time.Duration
+
+	// Func computes the value of the sensor from the last sample of each
+	// input. It returns false if there is no value, e.g. if the inputs are
+	// out of the range of the formula.
+	Func func(in []events.Sample) (float64, bool)
+}
+
+// Capabilities implements caps.Describer.
+func (s *Sensor) Capabilities() caps.Capabilities {
+	return caps.Capabilities{Name: s.Source, Bus: "virtual", Measurements: []caps.Measurement{s.Measurement}}
+}
+
+// Registry computes the sensors added to it. It can be used by multiple
+// goroutines.
+type Registry struct {
+	mu      sync.Mutex
+	sensors []*Sensor
+	last    map[string]events.Sample
+}
+
+// NewRegistry returns a registry without sensors.
+func NewRegistry() *Registry {
+	return &Registry{last: make(map[string]events.Sample)}
+}
+
+// Add adds a sensor. The inputs of a sensor can be other virtual sensors,
+// but not the sensor itself, even through others.
+func (r *Registry) Add(s *Sensor) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, o := range r.sensors {
+		if o.Source == s.Source {
+			return fmt.Errorf("%v is already a virtual sensor", s.Source)
+		}
+	}
+	if r.depends(s, s.Source) {
+		return fmt.Errorf("%v is computed from itself", s.Source)
+	}
+	r.sensors = append(r.sensors, s)
+	return nil
+}
+
+// depends reports whether s is computed from the source.
+func (r *Registry) depends(s *Sensor, source string) bool {
+	for _, in := range s.Inputs {
+		if in == source {
+			return true
+		}
+		for _, o := range r.sensors {
+			if o.Source == in && r.depends(o, source) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Sensors returns the sensors, in the order they were added.
+func (r *Registry) Sensors() []*Sensor {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Sensor(nil), r.sensors...)
+}
+
+// is reports whether source is a virtual sensor.
+func (r *Registry) is(source string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.sensors {
+		if s.Source == source {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle updates the inputs with a sample and returns the samples of the
+// sensors computed from it, including the sensors computed from those,
+// dated as the sample. The other events are ignored.
+func (r *Registry) Handle(ev events.Event) []events.Sample {
+	in, ok := ev.(events.Sample)
+	if !ok {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []events.Sample
+	queue := []events.Sample{in}
+	for len(queue) > 0 {
+		in, queue = queue[0], queue[1:]
+		r.last[in.Source] = in
+		for _, s := range r.sensors {
+			v, ok := r.compute(s, in)
+			if !ok {
+				continue
+			}
+			d := events.Sample{Source: s.Source, Value: v, Unit: s.Measurement.Unit, Time: in.Time}
+			out = append(out, d)
+			queue = append(queue, d)
+		}
+	}
+	return out
+}
+
+// compute computes s if it is computed from the sample and the last
+// samples of its other inputs are recent enough.
+func (r *Registry) compute(s *Sensor, sample events.Sample) (float64, bool) {
+	used := false
+	in := make([]events.Sample, len(s.Inputs))
+	for i, src := range s.Inputs {
+		last, ok := r.last[src]
+		if !ok || s.MaxAge > 0 && sample.Time.Sub(last.Time) > s.MaxAge {
+			return 0, false
+		}
+		in[i] = last
+		used = used || src == sample.Source
+	}
+	if !used {
+		return 0, false
+	}
+	return s.Func(in)
+}
+
+// Run computes the sensors from the samples published on the bus, and
+// publishes their samples, until ctx is done.
+func (r *Registry) Run(ctx context.Context, bus *events.Bus) error {
+	sub := bus.Subscribe("sample/#", 64)
+	defer sub.Close()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ev := <-sub.C:
+			// The samples published were handled along with their inputs.
+			if s, ok := ev.(events.Sample); ok && r.is(s.Source) {
+				continue
+			}
+			for _, s := range r.Handle(ev) {
+				bus.Publish(s)
+			}
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package derived
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/events"
+)
+
+var t0 = time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC)
+
+func sample(source string, v float64, d time.Duration) events.Sample {
+	return events.Sample{Source: source, Value: v, Time: t0.Add(d)}
+}
+
+func TestHandle(t *testing.T) {
+	r := NewRegistry()
+	dp := DewPoint("dewpoint", "bme280/temperature", "bme280/humidity")
+	dp.MaxAge = time.Minute
+	if err := r.Add(dp); err != nil {
+		t.Fatal(err)
+	}
+	if out := r.Handle(sample("bme280/temperature", 20, 0)); len(out) != 0 {
+		t.Errorf("computed %v without the humidity", out)
+	}
+	out := r.Handle(sample("bme280/humidity", 50, time.Second))
+	if len(out) != 1 || out[0].Source != "dewpoint" || out[0].Unit != "C" || !out[0].Time.Equal(t0.Add(time.Second)) {
+		t.Fatalf("samples = %v; want the dew point", out)
+	}
+	if v := out[0].Value; math.Abs(v-9.26) > 0.01 {
+		t.Errorf("dew point at 20°C and 50%% = %.2f°C; want 9.26°C", v)
+	}
+	if out := r.Handle(sample("bme280/humidity", 50, 2*time.Minute)); len(out) != 0 {
+		t.Errorf("computed %v from a temperature too old", out)
+	}
+	if out := r.Handle(events.Status{Source: "bme280"}); out != nil {
+		t.Errorf("computed %v from a status", out)
+	}
+}
+
+func TestChain(t *testing.T) {
+	r := NewRegistry()
+	r.Add(Power("power", "ina219/voltage", "ina219/current"))
+	r.Add(&Sensor{
+		Source: "power/kw",
+		Inputs: []string{"power"},
+		Func:   func(in []events.Sample) (float64, bool) { return in[0].Value / 1000, true },
+	})
+	r.Handle(sample("ina219/voltage", 230, 0))
+	out := r.Handle(sample("ina219/current", 10, 0))
+	if len(out) != 2 || out[0].Value != 2300 || out[1].Source != "power/kw" || out[1].Value != 2.3 {
+		t.Errorf("samples = %v; want 2300W then 2.3kW", out)
+	}
+
+	loop := &Sensor{Source: "ina219/voltage", Inputs: []string{"power/kw"}}
+	if err := r.Add(loop); err == nil {
+		t.Error("added a sensor computed from itself")
+	}
+	if err := r.Add(Power("power", "a", "b")); err == nil {
+		t.Error("added a sensor twice")
+	}
+}
+
+func TestSensors(t *testing.T) {
+	h := Heading("heading", "x", "y")
+	for _, tt := range []struct{ x, y, want float64 }{{1, 0, 0}, {0, -1, 90}, {-1, 0, 180}, {0, 1, 270}} {
+		v, _ := h.Func([]events.Sample{{Value: tt.x}, {Value: tt.y}})
+		if math.Abs(v-tt.want) > 1e-9 {
+			t.Errorf("heading at x %v y %v = %v; want %v", tt.x, tt.y, v, tt.want)
+		}
+	}
+
+	vs := VerticalSpeed("vspeed", "pressure")
+	if _, ok := vs.Func([]events.Sample{sample("pressure", 1013.25, 0)}); ok {
+		t.Error("vertical speed computed from a single sample")
+	}
+	// About 8.3m per hPa at the sea level.
+	v, ok := vs.Func([]events.Sample{sample("pressure", 1012.25, 10*time.Second)})
+	if !ok || math.Abs(v-0.833) > 0.005 {
+		t.Errorf("vertical speed = %.3f, %v; want 0.833m/s", v, ok)
+	}
+
+	for _, tt := range []struct{ seaLevel, want float64 }{{0, 110.9}, {1020, 166.7}} {
+		a := Altitude("altitude", "pressure", tt.seaLevel)
+		if v, ok := a.Func([]events.Sample{sample("pressure", 1000, 0)}); !ok || math.Abs(v-tt.want) > 0.1 {
+			t.Errorf("altitude at 1000hPa with %vhPa at the sea level = %.1f, %v; want %v", tt.seaLevel, v, ok, tt.want)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	bus := events.New()
+	r := NewRegistry()
+	r.Add(Power("power", "voltage", "current"))
+	sub := bus.Subscribe("sample/power", 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- r.Run(ctx, bus) }()
+
+	// Wait for the subscription of Run.
+	for {
+		bus.Publish(sample("voltage", 12, 0))
+		bus.Publish(sample("current", 0.5, 0))
+		select {
+		case ev := <-sub.C:
+			if s := ev.(events.Sample); s.Value != 6 {
+				t.Errorf("power = %v; want 6W", s.Value)
+			}
+			cancel()
+			if err := <-done; err != context.Canceled {
+				t.Errorf("Run = %v; want context.Canceled", err)
+			}
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package derived_test
+
+import (
+	"fmt"
+
+	"github.com/goiot/devices/derived"
+	"github.com/goiot/devices/events"
+)
+
+func Example() {
+	r := derived.NewRegistry()
+	r.Add(derived.DewPoint("garden/dewpoint", "bme280/temperature", "bme280/humidity"))
+
+	// Run publishes the samples returned by Handle on the bus.
+	r.Handle(events.Sample{Source: "bme280/temperature", Value: 25, Unit: "C"})
+	for _, s := range r.Handle(events.Sample{Source: "bme280/humidity", Value: 60, Unit: "%"}) {
+		fmt.Printf("%v %.1f%v on %v\n", s.Source, s.Value, s.Unit, s.Topic())
+	}
+	// Output:
+	// garden/dewpoint 16.7C on sample/garden/dewpoint
+}
@@ -0,0 +1,115 @@
+package derived
+
+import (
+	"math"
+
+	"github.com/goiot/devices/caps"
+	"github.com/goiot/devices/events"
+)
+
+// DewPoint returns the sensor of the dew point, in degrees Celsius, from
+// a temperature in degrees Celsius and a relative humidity in percent.
+func DewPoint(source, temperature, humidity string) *Sensor {
+	return &Sensor{
+		Source:      source,
+		Measurement: caps.Measurement{Kind: caps.Temperature, Unit: "C", Min: -60, Max: 85},
+		Inputs:      []string{temperature, humidity},
+		Func: func(in []events.Sample) (float64, bool) {
+			t, rh := in[0].Value, in[1].Value
+			if rh <= 0 || rh > 100 {
+				return 0, false
+			}
+			// Magnus formula, the constants of compensate.
+			g := math.Log(rh/100) + 17.62*t/(243.12+t)
+			return 243.12 * g / (17.62 - g), true
+		},
+	}
+}
+
+// Power returns the sensor of the power, in watts, from a voltage in volts
+// and a current in amperes.
+func Power(source, voltage, current string) *Sensor {
+	return &Sensor{
+		Source:      source,
+		Measurement: caps.Measurement{Kind: caps.Power, Unit: "W", Min: -math.MaxFloat64, Max: math.MaxFloat64},
+		Inputs:      []string{voltage, current},
+		Func: func(in []events.Sample) (float64, bool) {
+			return in[0].Value * in[1].Value, true
+		},
+	}
+}
+
+// Heading returns the sensor of the heading, in degrees clockwise from the
+// magnetic north, from the x and y axes of a level magnetometer, x
+// pointing forward and y to the right.
+func Heading(source, x, y string) *Sensor {
+	return &Sensor{
+		Source:      source,
+		Measurement: caps.Measurement{Kind: caps.Heading, Unit: "°", Min: 0, Max: 360},
+		Inputs:      []string{x, y},
+		Func: func(in []events.Sample) (float64, bool) {
+			x, y := in[0].Value, in[1].Value
+			if x == 0 && y == 0 {
+				return 0, false
+			}
+			h := math.Atan2(-y, x) * 180 / math.Pi
+			if h < 0 {
+				h += 360
+			}
+			return h, true
+		},
+	}
+}
+
+// VerticalSpeed returns the sensor of the vertical speed, in meters per
+// second, from the variation of a pressure in hPa between two samples.
+// The altitude is the one of the standard atmosphere, the pressure noise
+// of the sensor is best filtered by its oversampling.
+func VerticalSpeed(source, pressure string) *Sensor {
+	var prev events.Sample
+	return &Sensor{
+		Source:      source,
+		Measurement: caps.Measurement{Kind: caps.VerticalSpeed, Unit: "m/s", Min: -math.MaxFloat64, Max: math.MaxFloat64},
+		Inputs:      []string{pressure},
+		Func: func(in []events.Sample) (float64, bool) {
+			p := in[0]
+			last := prev
+			prev = p
+			dt := p.Time.Sub(last.Time).Seconds()
+			if last.Time.IsZero() || dt <= 0 || p.Value <= 0 || last.Value <= 0 {
+				return 0, false
+			}
+			return (altitude(p.Value, standardPressure) - altitude(last.Value, standardPressure)) / dt, true
+		},
+	}
+}
+
+// Altitude returns the sensor of the altitude in meters from a pressure in
+// hPa, with seaLevel the pressure at the sea level in hPa (the QNH of the
+// weather reports), the one of the standard atmosphere if zero.
+func Altitude(source, pressure string, seaLevel float64) *Sensor {
+	if seaLevel == 0 {
+		seaLevel = standardPressure
+	}
+	return &Sensor{
+		Source:      source,
+		Measurement: caps.Measurement{Kind: caps.Altitude, Unit: "m", Min: -500, Max: 11000},
+		Inputs:      []string{pressure},
+		Func: func(in []events.Sample) (float64, bool) {
+			if in[0].Value <= 0 {
+				return 0, false
+			}
+			return altitude(in[0].Value, seaLevel), true
+		},
+	}
+}
+
+// standardPressure is the pressure at the sea level in the standard
+// atmosphere, in hPa.
+const standardPressure = 1013.25
+
+// altitude returns the altitude in meters at the pressure p in hPa, with
+// p0 the pressure at the sea level.
+func altitude(p, p0 float64) float64 {
+	return 44330 * (1 - math.Pow(p/p0, 1/5.255))
+}
@@ -0,0 +1,124 @@
+// Package display defines the interface implemented by the displays with
+// a frame buffer, a compositor presenting several of them as one and a
+// limiter of their frame rate.
+package display
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+)
+
+// Display is a display with a frame buffer. The buffer is drawn with the
+// image/draw package and shown by Draw. It is implemented by the drivers
+// of monochromeoled, st7735 and sensehat among others.
+type Display interface {
+	draw.Image
+
+	// Draw sends the frame buffer to the display.
+	Draw() error
+}
+
+// Panel places a physical display on a Canvas.
+type Panel struct {
+	Display Display
+
+	// Offset is the position on the canvas of the top left corner of
+	// the area shown by the panel.
+	Offset image.Point
+
+	// Rotation is the angle of the area of the canvas on the panel in
+	// degrees, counter-clockwise: 0, 90, 180 or 270. It is typically set
+	// for panels mounted sideways or upside down.
+	Rotation int
+}
+
+// rect returns the area of the canvas shown by the panel.
+func (p Panel) rect() image.Rectangle {
+	s := p.Display.Bounds().Size()
+	if p.Rotation == 90 || p.Rotation == 270 {
+		s.X, s.Y = s.Y, s.X
+	}
+	return image.Rectangle{p.Offset, p.Offset.Add(s)}
+}
+
+// native returns the position on the panel of the pixel x, y of its area
+// of the canvas, relative to the top left corner of the area.
+func (p Panel) native(x, y int) (int, int) {
+	b := p.Display.Bounds()
+	w, h := b.Dx(), b.Dy()
+	switch p.Rotation {
+	case 90:
+		x, y = y, h-1-x
+	case 180:
+		x, y = w-1-x, h-1-y
+	case 270:
+		x, y = w-1-y, x
+	}
+	return b.Min.X + x, b.Min.Y + y
+}
+
+// Canvas is a virtual display made of several panels, e.g. four SSD1306
+// OLEDs in a 256x128 square. The areas of the panels can overlap, or
+// leave parts of the canvas not shown. Canvas implements Display.
+type Canvas struct {
+	panels []Panel
+	img    *image.RGBA
+}
+
+// NewCanvas returns a canvas covering the panels, the panels with a
+// rotation other than 0, 90, 180 or 270 are shown without rotation.
+func NewCanvas(panels ...Panel) *Canvas {
+	var r image.Rectangle
+	for i, p := range panels {
+		switch p.Rotation {
+		case 0, 90, 180, 270:
+		default:
+			panels[i].Rotation = 0
+		}
+		r = r.Union(panels[i].rect())
+	}
+	return &Canvas{panels: panels, img: image.NewRGBA(r)}
+}
+
+// ColorModel implements image.Image.
+func (c *Canvas) ColorModel() color.Model { return color.RGBAModel }
+
+// Bounds implements image.Image.
+func (c *Canvas) Bounds() image.Rectangle { return c.img.Bounds() }
+
+// At implements image.Image. It returns the pixel of the canvas, before
+// the conversion to the color models of the panels.
+func (c *Canvas) At(x, y int) color.Color { return c.img.At(x, y) }
+
+// Set implements draw.Image.
+func (c *Canvas) Set(x, y int, cl color.Color) { c.img.Set(x, y, cl) }
+
+// Draw copies the canvas to the frame buffers of the panels and draws
+// them in parallel. It returns the first error of the panels.
+func (c *Canvas) Draw() error {
+	errs := make([]error, len(c.panels))
+	var wg sync.WaitGroup
+	for i, p := range c.panels {
+		r := p.rect()
+		for y := 0; y < r.Dy(); y++ {
+			for x := 0; x < r.Dx(); x++ {
+				nx, ny := p.native(x, y)
+				p.Display.Set(nx, ny, c.img.RGBAAt(r.Min.X+x, r.Min.Y+y))
+			}
+		}
+		wg.Add(1)
+		go func(i int, d Display) {
+			defer wg.Done()
+			errs[i] = d.Draw()
+		}(i, p.Display)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package display
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/goiot/devices/displaytest"
+	"github.com/goiot/devices/text"
+)
+
+// panel is a fake display counting its draws.
+type panel struct {
+	*image.Gray
+	draws int
+	err   error
+}
+
+func (p *panel) Draw() error { p.draws++; return p.err }
+
+func newPanel(w, h int) *panel { return &panel{Gray: image.NewGray(image.Rect(0, 0, w, h))} }
+
+func TestCanvas(t *testing.T) {
+	left, right := newPanel(4, 2), newPanel(4, 2)
+	c := NewCanvas(
+		Panel{Display: left},
+		Panel{Display: right, Offset: image.Pt(4, 0), Rotation: 90},
+	)
+	if b := c.Bounds(); b != image.Rect(0, 0, 6, 4) {
+		t.Fatalf("Bounds = %v, want (0,0)-(6,4)", b)
+	}
+	white := color.Gray{Y: 0xFF}
+	c.Set(1, 1, white)
+	c.Set(4, 0, white) // top left of the rotated panel
+	c.Set(5, 3, white) // bottom right
+	if err := c.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	if left.draws != 1 || right.draws != 1 {
+		t.Errorf("draws = %d and %d, want 1 and 1", left.draws, right.draws)
+	}
+	if left.GrayAt(1, 1) != white {
+		t.Error("pixel (1, 1) of the left panel should be lit")
+	}
+	for _, p := range []image.Point{{0, 1}, {3, 0}} {
+		if right.GrayAt(p.X, p.Y) != white {
+			t.Errorf("pixel %v of the rotated panel should be lit", p)
+		}
+	}
+	if right.GrayAt(0, 0) != (color.Gray{}) {
+		t.Error("pixel (0, 0) of the rotated panel should be off")
+	}
+
+	errPanel := errors.New("panel unplugged")
+	right.err = errPanel
+	if err := c.Draw(); err != errPanel {
+		t.Errorf("Draw error = %v, want %v", err, errPanel)
+	}
+}
+
+func TestCanvasGolden(t *testing.T) {
+	// A 128x64 OLED upright and one mounted sideways, under a frame and
+	// text across both.
+	up, upSim := displaytest.SSD1306(t, 128, 64)
+	side, sideSim := displaytest.SSD1306(t, 128, 64)
+	c := NewCanvas(
+		Panel{Display: up},
+		Panel{Display: side, Offset: image.Pt(128, 0), Rotation: 270},
+	)
+	b := c.Bounds()
+	for x := b.Min.X; x < b.Max.X; x++ {
+		c.Set(x, 0, color.White)
+		c.Set(x, b.Max.Y-1, color.White)
+	}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		c.Set(0, y, color.White)
+		c.Set(b.Max.X-1, y, color.White)
+	}
+	text.Default.Draw(c, 8, 8, "Upright, then", color.White, 1)
+	text.Default.Draw(c, 120, 40, "270", color.White, 2)
+	if err := c.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	displaytest.Golden(t, "canvas_upright", upSim.Image())
+	displaytest.Golden(t, "canvas_270", sideSim.Image())
+}
@@ -0,0 +1,57 @@
+package display_test
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/goiot/devices/display"
+	"github.com/goiot/devices/monochromeoled"
+	"github.com/goiot/devices/monochromeoled/oledsim"
+	"github.com/goiot/devices/sensehat"
+	"github.com/goiot/devices/st7735"
+)
+
+var (
+	_ display.Display = (*monochromeoled.OLED)(nil)
+	_ display.Display = (*st7735.Display)(nil)
+	_ display.Display = (*sensehat.LEDs)(nil)
+)
+
+func Example() {
+	// Four 128x64 OLEDs in a square, simulated here. On a Raspberry Pi,
+	// each would be opened on its own bus or at its own address.
+	var panels []display.Panel
+	var sims []*oledsim.Display
+	for i := 0; i < 4; i++ {
+		sim := oledsim.New(128, 64)
+		oled, err := monochromeoled.Open(sim)
+		if err != nil {
+			panic(err)
+		}
+		sims = append(sims, sim)
+		panels = append(panels, display.Panel{Display: oled, Offset: image.Pt(128*(i%2), 64*(i/2))})
+	}
+	canvas := display.NewCanvas(panels...)
+
+	// a square in the middle, across the four panels
+	draw.Draw(canvas, image.Rect(120, 60, 136, 68), image.NewUniform(color.White), image.Point{}, draw.Src)
+	if err := canvas.Draw(); err != nil {
+		panic(err)
+	}
+	fmt.Println(canvas.Bounds().Size())
+	for _, sim := range sims {
+		lit := 0
+		for _, v := range sim.Image().Pix {
+			if v != 0 {
+				lit++
+			}
+		}
+		fmt.Print(lit, " ")
+	}
+	fmt.Println("pixels lit")
+	// Output:
+	// (256,128)
+	// 32 32 32 32 pixels lit
+}
@@ -0,0 +1,159 @@
+package display
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+	"time"
+
+	"github.com/goiot/devices/clock"
+)
+
+// Limiter is a display drawing on another one at most a given number of
+// frames per second, to spare slow buses and e-paper panels from update
+// loops drawing as fast as they can. Its Draw returns without waiting for
+// the display: the frames drawn faster are coalesced and only the latest
+// one is shown. Limiter implements Display.
+type Limiter struct {
+	// Clock times the frames, clock.Real if nil. It must be set before
+	// the first Draw.
+	Clock clock.Clock
+
+	d        Display
+	interval time.Duration
+	img      *image.RGBA // drawn by the user
+
+	mu      sync.Mutex
+	shownC  *sync.Cond // signaled when a frame is shown
+	frame   *image.RGBA
+	drawn   int   // number of the latest frame drawn
+	shown   int   // number of the latest frame shown
+	err     error // error of the latest frame shown
+	closing bool
+	wake    chan struct{}
+	done    chan struct{}
+}
+
+// NewLimiter returns a limiter drawing on d at most fps frames per second.
+// It must be closed when no longer in use.
+func NewLimiter(d Display, fps float64) *Limiter {
+	if fps <= 0 {
+		fps = 10
+	}
+	l := &Limiter{
+		d:        d,
+		interval: time.Duration(float64(time.Second) / fps),
+		img:      image.NewRGBA(d.Bounds()),
+		frame:    image.NewRGBA(d.Bounds()),
+		wake:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	l.shownC = sync.NewCond(&l.mu)
+	go l.run()
+	return l
+}
+
+// ColorModel implements image.Image.
+func (l *Limiter) ColorModel() color.Model { return color.RGBAModel }
+
+// Bounds implements image.Image.
+func (l *Limiter) Bounds() image.Rectangle { return l.img.Bounds() }
+
+// At implements image.Image.
+func (l *Limiter) At(x, y int) color.Color { return l.img.At(x, y) }
+
+// Set implements draw.Image.
+func (l *Limiter) Set(x, y int, c color.Color) { l.img.Set(x, y, c) }
+
+// Draw queues the frame buffer to be shown, replacing the frame queued
+// before if it was not shown yet. It returns the error of the latest
+// frame shown. Frames drawn once the limiter is closed are dropped.
+func (l *Limiter) Draw() error {
+	l.mu.Lock()
+	err := l.err
+	if l.closing {
+		l.mu.Unlock()
+		return err
+	}
+	copy(l.frame.Pix, l.img.Pix)
+	l.drawn++
+	l.mu.Unlock()
+	select {
+	case l.wake <- struct{}{}:
+	default:
+	}
+	return err
+}
+
+// Flush waits until the latest frame drawn is shown and returns the error
+// of the display.
+func (l *Limiter) Flush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for l.shown < l.drawn {
+		l.shownC.Wait()
+	}
+	return l.err
+}
+
+// Close shows the frame queued, if any, and stops the limiter. It returns
+// the error of the latest frame shown.
+func (l *Limiter) Close() error {
+	l.mu.Lock()
+	if l.closing {
+		l.mu.Unlock()
+		return l.err
+	}
+	l.closing = true
+	l.mu.Unlock()
+	close(l.done)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for l.shown < l.drawn {
+		l.shownC.Wait()
+	}
+	return l.err
+}
+
+func (l *Limiter) run() {
+	var last time.Time
+	for {
+		select {
+		case <-l.wake:
+		case <-l.done:
+			l.show()
+			return
+		}
+		c := clock.Or(l.Clock)
+		if wait := l.interval - c.Now().Sub(last); wait > 0 {
+			select {
+			case <-c.After(wait):
+			case <-l.done:
+			}
+		}
+		last = c.Now()
+		l.show()
+	}
+}
+
+// show draws the latest frame on the display if it was not shown yet.
+// The frames drawn meanwhile are queued for the next call.
+func (l *Limiter) show() {
+	l.mu.Lock()
+	n := l.drawn
+	if l.shown == n {
+		l.mu.Unlock()
+		return
+	}
+	draw.Draw(l.d, l.d.Bounds(), l.frame, l.frame.Bounds().Min, draw.Src)
+	l.mu.Unlock()
+
+	err := l.d.Draw()
+
+	l.mu.Lock()
+	l.err = err
+	l.shown = n
+	l.shownC.Broadcast()
+	l.mu.Unlock()
+}
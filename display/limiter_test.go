@@ -0,0 +1,104 @@
+package display
+
+import (
+	"errors"
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/clock"
+)
+
+func TestLimiter(t *testing.T) {
+	p := newPanel(2, 1)
+	l := NewLimiter(p, 20)
+	defer l.Close()
+
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		l.Set(0, 0, color.Gray{Y: uint8(i)})
+		if err := l.Draw(); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	// At 20 frames per second, a frame every 50ms at most.
+	if limit := int(time.Since(start)/(50*time.Millisecond)) + 1; p.draws > limit || p.draws < 2 {
+		t.Errorf("%d draws in %v; want between 2 and %d", p.draws, time.Since(start), limit)
+	}
+	if got := p.GrayAt(0, 0).Y; got != 99 {
+		t.Errorf("pixel of the frame shown = %d; want the latest, 99", got)
+	}
+
+	// Nothing new to show.
+	n := p.draws
+	l.Flush()
+	if p.draws != n {
+		t.Errorf("Flush without a new frame drew the panel")
+	}
+
+	p.err = errors.New("bus error")
+	l.Draw()
+	if err := l.Flush(); err != p.err {
+		t.Errorf("Flush = %v; want %v", err, p.err)
+	}
+	if err := l.Draw(); err != p.err {
+		t.Errorf("Draw after a failure = %v; want %v", err, p.err)
+	}
+}
+
+func TestLimiterClock(t *testing.T) {
+	p := newPanel(1, 1)
+	l := NewLimiter(p, 1)
+	c := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	l.Clock = c
+	defer l.Close()
+
+	l.Draw()
+	l.Flush()
+	l.Set(0, 0, color.White)
+	l.Draw()
+	c.BlockUntil(1) // the limiter waits for the next second
+	c.Advance(999 * time.Millisecond)
+	if p.draws != 1 {
+		t.Errorf("%d draws before the next second; want 1", p.draws)
+	}
+	c.Advance(time.Millisecond)
+	l.Flush()
+	if p.draws != 2 || p.GrayAt(0, 0).Y != 0xFF {
+		t.Errorf("%d draws, pixel %d; want 2 draws of the latest frame", p.draws, p.GrayAt(0, 0).Y)
+	}
+}
+
+func TestLimiterClose(t *testing.T) {
+	p := newPanel(1, 1)
+	l := NewLimiter(p, 1)
+	l.Draw()
+	l.Flush()
+
+	// The frame queued is shown on Close without waiting for the next
+	// second.
+	l.Set(0, 0, color.White)
+	l.Draw()
+	start := time.Now()
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Errorf("Close took %v", d)
+	}
+	if p.draws != 2 || p.GrayAt(0, 0).Y != 0xFF {
+		t.Errorf("%d draws, pixel %d; want 2 draws of the latest frame", p.draws, p.GrayAt(0, 0).Y)
+	}
+
+	l.Draw()
+	if err := l.Flush(); err != nil || p.draws != 2 {
+		t.Errorf("Draw after Close: %d draws, Flush = %v", p.draws, err)
+	}
+	if err := l.Close(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,169 @@
+// Package displaytest compares the frames drawn on the displays with
+// golden PNG files, so the visual regressions of the fonts, the screens
+// and the display code are caught by the tests.
+//
+// The golden files are in the testdata directory of the package under
+// test, and are written, or rewritten after an intended change, by
+// running the tests with the -update flag:
+//
+//	go test ./apps -update
+package displaytest
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/goiot/devices/monochromeoled"
+	"github.com/goiot/devices/monochromeoled/oledsim"
+)
+
+var update = flag.Bool("update", false, "write the golden files of the displaytest package")
+
+// SSD1306 returns an SSD1306 driver of a w x h panel, one of the
+// geometries of monochromeoled, and the simulator it drives, whose Image
+// is what the panel shows.
+func SSD1306(t testing.TB, w, h int) (*monochromeoled.OLED, *oledsim.Display) {
+	t.Helper()
+	var p monochromeoled.Panel
+	first := 0
+	switch {
+	case w == 128 && h == 64:
+		p = monochromeoled.Panel128x64
+	case w == 128 && h == 32:
+		p = monochromeoled.Panel128x32
+	case w == 96 && h == 16:
+		p = monochromeoled.Panel96x16
+	case w == 64 && h == 48:
+		p, first = monochromeoled.Panel64x48, 32
+	default:
+		t.Fatalf("no %dx%d SSD1306 panel", w, h)
+	}
+	sim := oledsim.NewOffset(w, h, first)
+	o, err := monochromeoled.OpenPanel(sim, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return o, sim
+}
+
+// Golden compares img with the golden file testdata/name.png. On a
+// mismatch, the test fails with a summary and, for the small images, a
+// text rendering of the differences; the image and the differences are
+// saved in a temporary directory to be compared side by side.
+func Golden(t testing.TB, name string, img image.Image) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".png")
+	if *update {
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := writePNG(path, img); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("%v, run the tests with -update to write the golden file", err)
+	}
+	want, err := png.Decode(f)
+	f.Close()
+	if err != nil {
+		t.Fatalf("decoding %s failed - %v", path, err)
+	}
+	n, r, diff := Diff(want, img)
+	if n == 0 {
+		return
+	}
+	msg := fmt.Sprintf("%s: %d pixels differ in %v", path, n, r)
+	if want.Bounds() != img.Bounds() {
+		msg = fmt.Sprintf("%s: bounds are %v, want %v", path, img.Bounds(), want.Bounds())
+	} else if r.Dx() <= 128 && r.Dy() <= 64 {
+		msg += "; want, got:\n" + sideBySide(ASCII(want, r), ASCII(img, r))
+	}
+	if dir, err := ioutil.TempDir("", "displaytest"); err == nil {
+		base := filepath.Join(dir, strings.Replace(name, "/", "_", -1))
+		if writePNG(base+".got.png", img) == nil && writePNG(base+".diff.png", diff) == nil {
+			msg += fmt.Sprintf("\nimages in %s.{got,diff}.png", base)
+		}
+	}
+	t.Error(msg)
+}
+
+// Diff returns the number of pixels of different colors in a and b, the
+// rectangle bounding them and an image of the differences, in red over
+// a faded a. Images of different bounds differ everywhere.
+func Diff(a, b image.Image) (int, image.Rectangle, *image.RGBA) {
+	r := a.Bounds().Union(b.Bounds())
+	diff := image.NewRGBA(r)
+	n := 0
+	var box image.Rectangle
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			p := image.Pt(x, y)
+			ca, cb := colorAt(a, p), colorAt(b, p)
+			if ca == cb && p.In(a.Bounds()) == p.In(b.Bounds()) {
+				g := color.GrayModel.Convert(ca).(color.Gray)
+				diff.Set(x, y, color.Gray{Y: g.Y / 4})
+				continue
+			}
+			diff.Set(x, y, color.RGBA{R: 0xFF, A: 0xFF})
+			box = box.Union(image.Rect(x, y, x+1, y+1))
+			n++
+		}
+	}
+	return n, box, diff
+}
+
+func colorAt(img image.Image, p image.Point) color.RGBA64 {
+	if !p.In(img.Bounds()) {
+		return color.RGBA64{}
+	}
+	return color.RGBA64Model.Convert(img.At(p.X, p.Y)).(color.RGBA64)
+}
+
+// ASCII renders the region r of img as text, # for the lit pixels and .
+// for the black ones, a line per row.
+func ASCII(img image.Image, r image.Rectangle) string {
+	var b strings.Builder
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			c := color.GrayModel.Convert(img.At(x, y)).(color.Gray)
+			if c.Y >= 0x80 {
+				b.WriteByte('#')
+			} else {
+				b.WriteByte('.')
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func sideBySide(a, b string) string {
+	la, lb := strings.Split(strings.TrimSuffix(a, "\n"), "\n"), strings.Split(strings.TrimSuffix(b, "\n"), "\n")
+	for i := range la {
+		la[i] += "  " + lb[i]
+	}
+	return strings.Join(la, "\n")
+}
+
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,47 @@
+package displaytest
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/goiot/devices/text"
+)
+
+func TestDiff(t *testing.T) {
+	a := image.NewGray(image.Rect(0, 0, 8, 4))
+	b := image.NewGray(image.Rect(0, 0, 8, 4))
+	if n, _, _ := Diff(a, b); n != 0 {
+		t.Errorf("%d pixels differ in equal images", n)
+	}
+	b.SetGray(2, 1, color.Gray{Y: 0xFF})
+	b.SetGray(5, 3, color.Gray{Y: 0xFF})
+	n, r, diff := Diff(a, b)
+	if n != 2 || r != image.Rect(2, 1, 6, 4) {
+		t.Errorf("%d pixels differ in %v; want 2 in (2,1)-(6,4)", n, r)
+	}
+	if c := diff.RGBAAt(2, 1); c.R != 0xFF || c.G != 0 {
+		t.Errorf("difference shown in %v; want red", c)
+	}
+	if n, _, _ := Diff(a, image.NewGray(image.Rect(0, 0, 8, 5))); n != 8 {
+		t.Errorf("%d pixels differ in images of different bounds; want 8", n)
+	}
+}
+
+func TestASCII(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 3, 2))
+	img.SetGray(1, 0, color.Gray{Y: 0xFF})
+	if got, want := ASCII(img, img.Bounds()), ".#.\n...\n"; got != want {
+		t.Errorf("ASCII = %q; want %q", got, want)
+	}
+}
+
+func TestGolden(t *testing.T) {
+	o, sim := SSD1306(t, 128, 64)
+	text.Default.Draw(o, 4, 4, "Hello, world", color.White, 1)
+	text.Default.Draw(o, 4, 20, "0123", color.White, 2)
+	if err := o.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	Golden(t, "text", sim.Image())
+}
@@ -0,0 +1,54 @@
+// Package distance defines the interface of the rangefinders, so that
+// navigation code can use any of them.
+package distance
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrOutOfRange is returned by the sensors which got no echo, or one too
+// weak to be reliable: nothing is in their range.
+var ErrOutOfRange = errors.New("distance: nothing in range")
+
+// Sensor is a rangefinder, it is implemented by the drivers of srf08,
+// tfluna and us100.
+type Sensor interface {
+	// Distance measures the distance to the nearest object in meters.
+	Distance() (float64, error)
+}
+
+// Func is a Sensor calling the function.
+type Func func() (float64, error)
+
+// Distance calls f.
+func (f Func) Distance() (float64, error) { return f() }
+
+// Median returns the median of n measurements of s, which ignores the
+// spurious echoes of the ultrasonic sensors. The measurements out of
+// range are not counted; ErrOutOfRange is returned if none is in range.
+func Median(s Sensor, n int) (float64, error) {
+	if n < 1 {
+		return 0, fmt.Errorf("invalid number of measurements %d", n)
+	}
+	var d []float64
+	for i := 0; i < n; i++ {
+		v, err := s.Distance()
+		if err == ErrOutOfRange {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		d = append(d, v)
+	}
+	if len(d) == 0 {
+		return 0, ErrOutOfRange
+	}
+	sort.Float64s(d)
+	if len(d)%2 == 0 {
+		return (d[len(d)/2-1] + d[len(d)/2]) / 2, nil
+	}
+	return d[len(d)/2], nil
+}
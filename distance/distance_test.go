@@ -0,0 +1,42 @@
+package distance
+
+import (
+	"errors"
+	"testing"
+)
+
+// sequence returns its distances in turn, the negative ones being out of
+// range.
+func sequence(d ...float64) Func {
+	return func() (float64, error) {
+		v := d[0]
+		d = d[1:]
+		if v < 0 {
+			return 0, ErrOutOfRange
+		}
+		return v, nil
+	}
+}
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		d    []float64
+		want float64
+	}{
+		{[]float64{1.2, 4.5, 1.1}, 1.2},
+		{[]float64{1.2, -1, 1.0, 3.0, -1}, 1.2},
+		{[]float64{2, 1}, 1.5},
+	}
+	for _, tt := range tests {
+		if got, err := Median(sequence(tt.d...), len(tt.d)); err != nil || got != tt.want {
+			t.Errorf("Median of %v = %v, %v; want %v", tt.d, got, err, tt.want)
+		}
+	}
+	if _, err := Median(sequence(-1, -1), 2); err != ErrOutOfRange {
+		t.Errorf("Median with no echo = %v; want ErrOutOfRange", err)
+	}
+	failing := Func(func() (float64, error) { return 0, errors.New("bus error") })
+	if _, err := Median(failing, 3); err == nil || err == ErrOutOfRange {
+		t.Errorf("Median of a failing sensor = %v; want its error", err)
+	}
+}
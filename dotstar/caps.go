@@ -0,0 +1,19 @@
+package dotstar
+
+import "github.com/goiot/devices/caps"
+
+// Caps are the capabilities of the LEDs, the length of the strips varies.
+var Caps = caps.Capabilities{
+	Name:    "APA102",
+	Bus:     "spi",
+	Outputs: []caps.Output{{Kind: caps.LEDs, Color: "rgb888"}},
+}
+
+func init() { caps.Register(Caps) }
+
+// Capabilities implements caps.Describer.
+func (l *LEDs) Capabilities() caps.Capabilities {
+	c := Caps
+	c.Outputs = []caps.Output{{Kind: caps.LEDs, Width: len(l.vals), Height: 1, Color: "rgb888"}}
+	return c
+}
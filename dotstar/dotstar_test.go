@@ -1,7 +1,10 @@
 package dotstar_test
 
 import (
+	"testing"
+
 	"github.com/goiot/devices/dotstar"
+	"github.com/goiot/devices/spisim"
 	"golang.org/x/exp/io/spi"
 )
 
@@ -22,3 +25,20 @@ func Example() {
 		panic(err)
 	}
 }
+
+func BenchmarkDraw(b *testing.B) {
+	d, err := dotstar.Open(spisim.New(spisim.DeviceFunc(func(w, r []byte) error { return nil })), 144)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < 144; i++ {
+		d.SetRGBA(i, dotstar.RGBA{byte(i), 0, 255 - byte(i), 16})
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := d.Draw(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+// Package ds2482 implements a driver for the Maxim DS2482-100 and
+// DS2482-800 I2C to 1-Wire bridges, which time the 1-Wire slots
+// themselves on the hosts where bit-banged 1-Wire is unreliable. The
+// bridge implements onewire.Bus.
+package ds2482
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"golang.org/x/exp/io/i2c"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+// Addr is the I2C address of the bridge with its address pins low. The
+// DS2482-100 answers up to 0x1B and the DS2482-800 up to 0x1F with the
+// pins high.
+const Addr = 0x18
+
+// Commands.
+const (
+	cmdDeviceReset   = 0xF0
+	cmdReadPointer   = 0xE1
+	cmdWriteConfig   = 0xD2
+	cmdChannelSelect = 0xC3
+	cmdReset         = 0xB4
+	cmdSingleBit     = 0x87
+	cmdWriteByte     = 0xA5
+	cmdReadByte      = 0x96
+)
+
+// Registers of the read pointer.
+const (
+	regStatus = 0xF0
+	regData   = 0xE1
+)
+
+// Status bits.
+const (
+	statusBusy     = 0x01
+	statusPresence = 0x02
+	statusShort    = 0x04
+	statusBit      = 0x20 // single bit result
+	statusRST      = 0x10 // the bridge was reset
+)
+
+// Configuration bits.
+const (
+	configActivePullup = 0x01
+	configStrongPullup = 0x04
+)
+
+// channels are the codes of the channel selection of the DS2482-800, and
+// channelCodes the ones read back.
+var (
+	channels     = []byte{0xF0, 0xE1, 0xD2, 0xC3, 0xB4, 0xA5, 0x96, 0x87}
+	channelCodes = []byte{0xB8, 0xB1, 0xAA, 0xA3, 0x9C, 0x95, 0x8E, 0x87}
+)
+
+// DS2482 represents a DS2482 bridge.
+type DS2482 struct {
+	Device *i2c.Device
+	// Clock times the polling of the 1-Wire commands, clock.Real if nil.
+	Clock clock.Clock
+}
+
+// Open resets the bridge at addr and enables its active pullup, which is
+// recommended with more than one device on the bus.
+func Open(o driver.Opener, addr int) (*DS2482, error) {
+	dev, err := i2c.Open(o, addr)
+	if err != nil {
+		return nil, err
+	}
+	b := &DS2482{Device: dev}
+	if err := b.init(); err != nil {
+		dev.Close()
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *DS2482) init() error {
+	st := make([]byte, 1)
+	if err := b.Device.ReadReg(cmdDeviceReset, st); err != nil {
+		return err
+	}
+	if st[0]&statusRST == 0 {
+		return fmt.Errorf("unexpected status %#x after the reset of the bridge", st[0])
+	}
+	return b.config(configActivePullup)
+}
+
+// config writes the configuration c, its upper nibble is the complement
+// of the bits.
+func (b *DS2482) config(c byte) error {
+	if err := b.Device.Write([]byte{cmdWriteConfig, c | ^c<<4}); err != nil {
+		return fmt.Errorf("configuring the bridge failed - %v", err)
+	}
+	return nil
+}
+
+// wait polls the status until the end of a 1-Wire command, a reset takes
+// the longest with 1.25ms.
+func (b *DS2482) wait() (byte, error) {
+	st := make([]byte, 1)
+	c := clock.Or(b.Clock)
+	for i := 0; ; i++ {
+		if err := b.Device.Read(st); err != nil {
+			return 0, err
+		}
+		if st[0]&statusBusy == 0 {
+			return st[0], nil
+		}
+		if i == 20 {
+			return 0, errors.New("1-Wire command timed out")
+		}
+		c.Sleep(250 * time.Microsecond)
+	}
+}
+
+func (b *DS2482) command(cmd ...byte) (byte, error) {
+	if err := b.Device.Write(cmd); err != nil {
+		return 0, err
+	}
+	return b.wait()
+}
+
+// Reset sends a reset pulse and reports whether a device answered.
+func (b *DS2482) Reset() (bool, error) {
+	st, err := b.command(cmdReset)
+	if err != nil {
+		return false, err
+	}
+	if st&statusShort != 0 {
+		return false, errors.New("short circuit on the 1-Wire bus")
+	}
+	return st&statusPresence != 0, nil
+}
+
+// WriteBit writes a bit.
+func (b *DS2482) WriteBit(bit bool) error {
+	v := byte(0x00)
+	if bit {
+		v = 0x80
+	}
+	_, err := b.command(cmdSingleBit, v)
+	return err
+}
+
+// ReadBit reads a bit, with the write time slot of a 1.
+func (b *DS2482) ReadBit() (bool, error) {
+	st, err := b.command(cmdSingleBit, 0x80)
+	if err != nil {
+		return false, err
+	}
+	return st&statusBit != 0, nil
+}
+
+// WriteByte writes a byte.
+func (b *DS2482) WriteByte(v byte) error {
+	_, err := b.command(cmdWriteByte, v)
+	return err
+}
+
+// ReadByte reads a byte.
+func (b *DS2482) ReadByte() (byte, error) {
+	if _, err := b.command(cmdReadByte); err != nil {
+		return 0, err
+	}
+	if err := b.Device.Write([]byte{cmdReadPointer, regData}); err != nil {
+		return 0, err
+	}
+	v := make([]byte, 1)
+	if err := b.Device.Read(v); err != nil {
+		return 0, err
+	}
+	return v[0], nil
+}
+
+// StrongPullup enables the strong pullup after the next byte or bit
+// written, until the next command, to power parasite powered devices, e.g.
+// during the temperature conversion of a DS18B20.
+func (b *DS2482) StrongPullup() error {
+	return b.config(configActivePullup | configStrongPullup)
+}
+
+// SelectChannel selects the 1-Wire channel ch of a DS2482-800, from 0 to
+// 7.
+func (b *DS2482) SelectChannel(ch int) error {
+	if ch < 0 || ch >= len(channels) {
+		return fmt.Errorf("invalid channel %d, should be between 0-7", ch)
+	}
+	if err := b.Device.Write([]byte{cmdChannelSelect, channels[ch]}); err != nil {
+		return err
+	}
+	// The bridge answers with the read code of the channel.
+	v := make([]byte, 1)
+	if err := b.Device.Read(v); err != nil {
+		return err
+	}
+	if want := channelCodes[ch]; v[0] != want {
+		return fmt.Errorf("channel %d not selected, the bridge answered %#x", ch, v[0])
+	}
+	return nil
+}
+
+// Close closes the bridge.
+func (b *DS2482) Close() error {
+	return b.Device.Close()
+}
@@ -0,0 +1,146 @@
+package ds2482
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/i2csim"
+	"github.com/goiot/devices/onewire"
+)
+
+var _ onewire.Bus = (*DS2482)(nil)
+
+// bridge is a fake DS2482, busy for the first status read of a 1-Wire
+// command.
+type bridge struct {
+	config  byte
+	pointer byte
+	status  byte
+	data    byte
+	channel byte
+	busy    bool
+
+	present bool
+	short   bool
+	bits    []bool // read from the 1-Wire bus
+	bytes   []byte // read from the 1-Wire bus
+	written []byte // written to the 1-Wire bus
+}
+
+func (b *bridge) Tx(w, r []byte) error {
+	if len(w) > 0 {
+		b.pointer = regStatus
+		b.busy = true
+		switch w[0] {
+		case cmdDeviceReset:
+			b.status, b.busy = statusRST, false
+		case cmdWriteConfig:
+			b.config, b.busy = w[1], false
+			b.status &^= statusRST
+		case cmdReadPointer:
+			b.pointer, b.busy = w[1], false
+		case cmdChannelSelect:
+			for i, c := range channels {
+				if c == w[1] {
+					b.channel = channelCodes[i]
+				}
+			}
+			b.pointer, b.busy = cmdChannelSelect, false
+		case cmdReset:
+			b.status = 0
+			if b.present {
+				b.status = statusPresence
+			}
+			if b.short {
+				b.status |= statusShort
+			}
+		case cmdSingleBit:
+			b.status = 0
+			if w[1] == 0x80 && len(b.bits) > 0 {
+				if b.bits[0] {
+					b.status = statusBit
+				}
+				b.bits = b.bits[1:]
+			}
+		case cmdWriteByte:
+			b.written = append(b.written, w[1])
+		case cmdReadByte:
+			b.data, b.bytes = b.bytes[0], b.bytes[1:]
+		}
+	}
+	if len(r) == 0 {
+		return nil
+	}
+	switch b.pointer {
+	case regStatus:
+		r[0] = b.status
+		if b.busy {
+			r[0] |= statusBusy
+			b.busy = false
+		}
+	case regData:
+		r[0] = b.data
+	case cmdChannelSelect:
+		r[0] = b.channel
+	}
+	return nil
+}
+
+func newBridge(t *testing.T, b *bridge) *DS2482 {
+	bus := i2csim.NewBus()
+	bus.Attach(Addr, b)
+	d, err := Open(bus, Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := clock.NewFake(time.Time{})
+	c.SetAutoSleep(true)
+	d.Clock = c
+	return d
+}
+
+func TestBus(t *testing.T) {
+	b := &bridge{present: true, bits: []bool{true, false}, bytes: []byte{0x42}}
+	d := newBridge(t, b)
+	if b.config != 0xE1 {
+		t.Errorf("configuration %#x; want the active pullup 0xe1", b.config)
+	}
+
+	if ok, err := d.Reset(); err != nil || !ok {
+		t.Errorf("Reset = %v, %v; want a presence", ok, err)
+	}
+	for _, want := range []bool{true, false} {
+		if bit, err := d.ReadBit(); err != nil || bit != want {
+			t.Errorf("ReadBit = %v, %v; want %v", bit, err, want)
+		}
+	}
+	if err := d.WriteByte(0xCC); err != nil || string(b.written) != "\xCC" {
+		t.Errorf("WriteByte = %v, wrote %x", err, b.written)
+	}
+	if v, err := d.ReadByte(); err != nil || v != 0x42 {
+		t.Errorf("ReadByte = %#x, %v; want 0x42", v, err)
+	}
+
+	if err := d.StrongPullup(); err != nil || b.config != 0xA5 {
+		t.Errorf("StrongPullup = %v, configuration %#x; want 0xa5", err, b.config)
+	}
+	if err := d.SelectChannel(5); err != nil {
+		t.Error(err)
+	}
+	if err := d.SelectChannel(8); err == nil {
+		t.Error("expected an error with channel 8")
+	}
+
+	b.present = false
+	if ok, err := d.Reset(); err != nil || ok {
+		t.Errorf("Reset = %v, %v; want no presence", ok, err)
+	}
+}
+
+func TestShort(t *testing.T) {
+	d := newBridge(t, &bridge{present: true, short: true})
+	if _, err := d.Reset(); err == nil {
+		t.Error("expected an error with a short circuit")
+	}
+}
@@ -0,0 +1,65 @@
+package ds2482_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/goiot/devices/ds2482"
+	"github.com/goiot/devices/i2cbus"
+	"github.com/goiot/devices/onewire"
+)
+
+// Example reads the temperatures of a chain of DS18B20.
+func Example() {
+	bus, err := i2cbus.Open("primary")
+	if err != nil {
+		panic(err)
+	}
+	bridge, err := ds2482.Open(bus, ds2482.Addr)
+	if err != nil {
+		panic(err)
+	}
+	defer bridge.Close()
+
+	sensors, err := onewire.Search(bridge)
+	if err != nil {
+		panic(err)
+	}
+
+	// start the conversions of all the sensors, powering the parasite
+	// powered ones for the 750ms of the conversion
+	if err := onewire.Skip(bridge); err != nil {
+		panic(err)
+	}
+	if err := bridge.StrongPullup(); err != nil {
+		panic(err)
+	}
+	if err := bridge.WriteByte(0x44); err != nil {
+		panic(err)
+	}
+	time.Sleep(750 * time.Millisecond)
+
+	for _, a := range sensors {
+		if a.Family() != 0x28 {
+			continue
+		}
+		// read the scratchpad
+		if err := onewire.Select(bridge, a); err != nil {
+			panic(err)
+		}
+		if err := bridge.WriteByte(0xBE); err != nil {
+			panic(err)
+		}
+		b := make([]byte, 9)
+		for i := range b {
+			if b[i], err = bridge.ReadByte(); err != nil {
+				panic(err)
+			}
+		}
+		if onewire.CRC8(b) != 0 {
+			fmt.Printf("%v: invalid CRC\n", a)
+			continue
+		}
+		fmt.Printf("%v: %.2fC\n", a, float64(int16(uint16(b[0])|uint16(b[1])<<8))/16)
+	}
+}
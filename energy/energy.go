@@ -0,0 +1,43 @@
+// Package energy defines the interface of the energy meters, so that the
+// code monitoring the consumption of appliances can use any of them.
+package energy
+
+// Measurement is a measurement of an energy meter.
+type Measurement struct {
+	Voltage     float64 // RMS volts
+	Current     float64 // RMS amperes
+	Power       float64 // active power in watts
+	Energy      float64 // active energy in watt-hours, accumulated
+	Frequency   float64 // Hz
+	PowerFactor float64 // 0 to 1
+}
+
+// Meter is an energy meter, it is implemented by the drivers of pzem004t
+// and ade7953.
+type Meter interface {
+	Read() (Measurement, error)
+}
+
+// Calibration corrects the gains of a meter, found by comparing its
+// readings with a reference meter: e.g. a Voltage of 1.02 for a meter
+// reading 230V when the reference reads 234.6V. The zero gains are 1.
+type Calibration struct {
+	Voltage, Current float64
+}
+
+// Apply returns m corrected by the gains, the power and the energy are
+// corrected by the gains of the voltage and of the current.
+func (c Calibration) Apply(m Measurement) Measurement {
+	v, i := c.Voltage, c.Current
+	if v == 0 {
+		v = 1
+	}
+	if i == 0 {
+		i = 1
+	}
+	m.Voltage *= v
+	m.Current *= i
+	m.Power *= v * i
+	m.Energy *= v * i
+	return m
+}
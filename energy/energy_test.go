@@ -0,0 +1,24 @@
+package energy
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalibration(t *testing.T) {
+	m := Measurement{Voltage: 230, Current: 2, Power: 400, Energy: 1000, Frequency: 50, PowerFactor: 0.87}
+	if got := (Calibration{}).Apply(m); got != m {
+		t.Errorf("uncalibrated = %+v; want %+v", got, m)
+	}
+	got := Calibration{Voltage: 1.02, Current: 0.5}.Apply(m)
+	want := Measurement{Voltage: 234.6, Current: 1, Power: 204, Energy: 510, Frequency: 50, PowerFactor: 0.87}
+	for _, v := range [][2]float64{{got.Voltage, want.Voltage}, {got.Current, want.Current}, {got.Power, want.Power}, {got.Energy, want.Energy}} {
+		if math.Abs(v[0]-v[1]) > 1e-9 {
+			t.Errorf("calibrated = %+v; want %+v", got, want)
+			break
+		}
+	}
+	if got.Frequency != want.Frequency || got.PowerFactor != want.PowerFactor {
+		t.Errorf("calibrated = %+v; want %+v", got, want)
+	}
+}
@@ -0,0 +1,160 @@
+// Package enviro opens the sensors and the display of the Pimoroni Enviro
+// and Enviro+ boards for Raspberry Pi as a single set of devices:
+//
+//	BME280   temperature, pressure and humidity
+//	LTR-559  light and proximity
+//	ST7735   0.96" 160x80 color display
+//	MICS6814 oxidising, reducing and NH3 gas sensor, Enviro+ only
+//	PMS5003  particulate matter sensor, optional on the Enviro+
+package enviro
+
+import (
+	"errors"
+	"io"
+
+	"github.com/goiot/devices/ads1x15"
+	"github.com/goiot/devices/analog"
+	"github.com/goiot/devices/bme280"
+	"github.com/goiot/devices/gpio"
+	"github.com/goiot/devices/ltr559"
+	"github.com/goiot/devices/pms5003"
+	"github.com/goiot/devices/st7735"
+	i2cdriver "golang.org/x/exp/io/i2c/driver"
+	spidriver "golang.org/x/exp/io/spi/driver"
+)
+
+// Address of the converter of the gas sensor.
+const adcAddr = 0x49
+
+// Config lists the buses and pins the board is connected to.
+type Config struct {
+	I2C i2cdriver.Opener // I2C bus of the Pi
+	SPI spidriver.Opener // display, /dev/spidev0.1
+
+	DC        gpio.Pin // data/command pin of the display, GPIO9
+	Backlight gpio.Pin // GPIO12, can be nil
+
+	// Heater is the heater of the gas sensor on GPIO24. It is nil on the
+	// Enviro, which has no gas sensor.
+	Heater gpio.Pin
+	// ADC is the converter of the gas sensor: ADS1015 on the first
+	// Enviro+ boards, ADS1115 on the later ones.
+	ADC ads1x15.Chip
+
+	// PMS is the serial port of the PMS5003, /dev/ttyAMA0 at 9600 bauds.
+	// It is nil without PMS5003.
+	PMS io.Reader
+}
+
+// Board represents the devices of the board. It must be closed if no
+// longer in use.
+type Board struct {
+	Weather   *bme280.BME280
+	Light     *ltr559.LTR559
+	Display   *st7735.Display
+	Gas       *Gas             // nil on the Enviro
+	Particles *pms5003.PMS5003 // nil without PMS5003
+
+	backlight gpio.Pin
+}
+
+// Open opens the devices of the board and turns on the display.
+func Open(c Config) (*Board, error) {
+	b := &Board{backlight: c.Backlight}
+	fail := func(err error) (*Board, error) {
+		b.Close()
+		return nil, err
+	}
+	var err error
+	if b.Weather, err = bme280.Open(c.I2C, bme280.Addr); err != nil {
+		return fail(err)
+	}
+	if b.Light, err = ltr559.Open(c.I2C); err != nil {
+		return fail(err)
+	}
+	if b.Display, err = st7735.Open(c.SPI, c.DC, st7735.Enviro); err != nil {
+		return fail(err)
+	}
+	if c.Backlight != nil {
+		if err := c.Backlight.Write(1); err != nil {
+			return fail(err)
+		}
+	}
+	if c.Heater != nil {
+		adc, err := ads1x15.Open(c.I2C, adcAddr, c.ADC, ads1x15.FS6V144)
+		if err != nil {
+			return fail(err)
+		}
+		b.Gas = &Gas{ADC: adc, heater: c.Heater}
+		if err := c.Heater.Write(1); err != nil {
+			return fail(err)
+		}
+	}
+	if c.PMS != nil {
+		b.Particles = pms5003.New(c.PMS)
+	}
+	return b, nil
+}
+
+// Close turns off the display and the gas sensor heater and closes the
+// devices. The buses and pins of the configuration are not closed.
+func (b *Board) Close() error {
+	var first error
+	keep := func(err error) {
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+	if b.Weather != nil {
+		keep(b.Weather.Close())
+	}
+	if b.Light != nil {
+		keep(b.Light.Close())
+	}
+	if b.Display != nil {
+		keep(b.Display.Off())
+		keep(b.Display.Close())
+		if b.backlight != nil {
+			keep(b.backlight.Write(0))
+		}
+	}
+	if b.Gas != nil {
+		keep(b.Gas.heater.Write(0))
+		keep(b.Gas.ADC.Close())
+	}
+	return first
+}
+
+// Gas is the MICS6814 gas sensor of the Enviro+. The heater needs a few
+// minutes after Open for the readings to settle.
+type Gas struct {
+	// ADC is the converter the sensor is read through. Most users don't
+	// have to access this field.
+	ADC *ads1x15.ADC
+
+	heater gpio.Pin
+}
+
+// GasReading is the resistance in ohms of the sensing layers. The
+// resistance of the oxidising layer rises with NO2, the resistances of the
+// reducing and NH3 layers fall with CO and NH3.
+type GasReading struct {
+	Oxidising, Reducing, NH3 float64
+}
+
+// Read reads the resistances of the 3 sensing layers.
+func (g *Gas) Read() (GasReading, error) {
+	var r [3]float64
+	for ch := range r {
+		v, err := analog.Volts(g.ADC, ch, ads1x15.FS6V144.Volts())
+		if err != nil {
+			return GasReading{}, err
+		}
+		if v >= 3.3 {
+			return GasReading{}, errors.New("gas sensor reading out of range")
+		}
+		// each layer is in series with a 56k resistor on 3.3V
+		r[ch] = v * 56000 / (3.3 - v)
+	}
+	return GasReading{Oxidising: r[0], Reducing: r[1], NH3: r[2]}, nil
+}
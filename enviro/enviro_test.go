@@ -0,0 +1,74 @@
+package enviro
+
+import (
+	"testing"
+
+	i2cdriver "golang.org/x/exp/io/i2c/driver"
+	spidriver "golang.org/x/exp/io/spi/driver"
+)
+
+// bus is a fake I2C bus, every device is a map of 256 registers.
+type bus map[int]*[256]byte
+
+func (b bus) Open(addr int, tenbit bool) (i2cdriver.Conn, error) {
+	if b[addr] == nil {
+		b[addr] = &[256]byte{}
+	}
+	return &conn{regs: b[addr]}, nil
+}
+
+type conn struct {
+	regs *[256]byte
+	reg  byte
+}
+
+func (c *conn) Tx(w, r []byte) error {
+	if len(w) > 0 {
+		c.reg = w[0]
+		copy(c.regs[c.reg:], w[1:])
+	}
+	copy(r, c.regs[c.reg:])
+	return nil
+}
+
+func (c *conn) Close() error { return nil }
+
+// display is a fake SPI display accepting everything.
+type display struct{}
+
+func (display) Open() (spidriver.Conn, error) { return display{}, nil }
+func (display) Configure(k, v int) error      { return nil }
+func (display) Tx(w, r []byte) error          { return nil }
+func (display) Close() error                  { return nil }
+
+type pin struct{ v int }
+
+func (p *pin) Read() (int, error) { return p.v, nil }
+func (p *pin) Write(v int) error  { p.v = v; return nil }
+func (p *pin) Close() error       { return nil }
+
+func TestOpen(t *testing.T) {
+	i2c := bus{0x76: {0xD0: 0x60}, 0x23: {0x86: 0x92}}
+	backlight, heater := &pin{}, &pin{}
+	b, err := Open(Config{I2C: i2c, SPI: display{}, DC: &pin{}, Backlight: backlight, Heater: heater})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Gas == nil || b.Particles != nil {
+		t.Errorf("got gas sensor %v and particle sensor %v, want a gas sensor only", b.Gas, b.Particles)
+	}
+	if backlight.v != 1 || heater.v != 1 {
+		t.Error("the backlight and the heater should be on")
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if backlight.v != 0 || heater.v != 0 {
+		t.Error("the backlight and the heater should be off after Close")
+	}
+
+	delete(i2c, 0x23)
+	if _, err := Open(Config{I2C: i2c, SPI: display{}, DC: &pin{}}); err == nil {
+		t.Error("expected an error without LTR-559")
+	}
+}
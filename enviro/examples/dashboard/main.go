@@ -0,0 +1,163 @@
+// Dashboard shows the readings of an Enviro+ on its display, one row per
+// reading with a bar colored from green to red.
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/goiot/devices/ads1x15"
+	"github.com/goiot/devices/enviro"
+	"github.com/goiot/devices/gpio/gpiod"
+	"github.com/goiot/devices/i2cbus"
+	"github.com/goiot/devices/oled96x96"
+	"github.com/goiot/devices/st7735"
+	"golang.org/x/exp/io/spi"
+)
+
+// row is a line of the dashboard, level is between 0 (good) and 1 (bad).
+type row struct {
+	label, value string
+	level        float64
+}
+
+func main() {
+	bus, err := i2cbus.Open("primary")
+	if err != nil {
+		panic(err)
+	}
+	chip, err := gpiod.Open("/dev/gpiochip0")
+	if err != nil {
+		panic(err)
+	}
+	defer chip.Close()
+	dc, err := chip.Output(9, 0, 0)
+	if err != nil {
+		panic(err)
+	}
+	backlight, err := chip.Output(12, 0, 0)
+	if err != nil {
+		panic(err)
+	}
+	heater, err := chip.Output(24, 0, 0)
+	if err != nil {
+		panic(err)
+	}
+	// configured with: stty -F /dev/ttyAMA0 9600 cs8 -parenb -cstopb raw
+	port, err := os.Open("/dev/ttyAMA0")
+	if err != nil {
+		panic(err)
+	}
+	defer port.Close()
+
+	board, err := enviro.Open(enviro.Config{
+		I2C:       bus,
+		SPI:       &spi.Devfs{Dev: "/dev/spidev0.1", Mode: spi.Mode0, MaxSpeed: st7735.Speed},
+		DC:        dc,
+		Backlight: backlight,
+		Heater:    heater,
+		ADC:       ads1x15.ADS1115,
+		PMS:       port,
+	})
+	if err != nil {
+		panic(err)
+	}
+	defer board.Close()
+
+	// the PMS5003 sends its readings every second
+	var (
+		mu   sync.Mutex
+		pm25 = -1
+	)
+	go func() {
+		for {
+			r, err := board.Particles.Read()
+			if err != nil {
+				continue
+			}
+			mu.Lock()
+			pm25 = r.PM25Atm
+			mu.Unlock()
+		}
+	}()
+
+	for range time.Tick(time.Second) {
+		m, err := board.Weather.Read()
+		if err != nil {
+			panic(err)
+		}
+		lux, err := board.Light.Lux()
+		if err != nil {
+			panic(err)
+		}
+		gas, err := board.Gas.Read()
+		if err != nil {
+			panic(err)
+		}
+		mu.Lock()
+		pm := pm25
+		mu.Unlock()
+
+		rows := []row{
+			{"Temp", fmt.Sprintf("%.1fC", m.Temperature), between(m.Temperature, 20, 35)},
+			{"Humidity", fmt.Sprintf("%.0f%%", m.Humidity), between(m.Humidity, 40, 90)},
+			{"Pressure", fmt.Sprintf("%.0fhPa", m.Pressure), between(1013-m.Pressure, 0, 50)},
+			{"Light", fmt.Sprintf("%.0flx", lux), 0},
+			{"NO2", fmt.Sprintf("%.0fk", gas.Oxidising/1000), between(gas.Oxidising, 20000, 200000)},
+			{"PM2.5", fmt.Sprintf("%dug", pm), between(float64(pm), 12, 55)},
+		}
+		render(board.Display, rows)
+		if err := board.Display.Draw(); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// between returns where v is between lo and hi, from 0 to 1.
+func between(v, lo, hi float64) float64 {
+	l := (v - lo) / (hi - lo)
+	if l < 0 {
+		return 0
+	}
+	if l > 1 {
+		return 1
+	}
+	return l
+}
+
+func render(dst draw.Image, rows []row) {
+	b := dst.Bounds()
+	draw.Draw(dst, b, image.Black, image.Point{}, draw.Src)
+	h := b.Dy() / len(rows)
+	for i, r := range rows {
+		y := b.Min.Y + i*h
+		level := color.RGBA{uint8(255 * r.level), uint8(255 * (1 - r.level)), 0, 0xFF}
+		bar := image.Rect(b.Min.X, y+h-3, b.Min.X+int(float64(b.Dx())*r.level)+2, y+h-1)
+		draw.Draw(dst, bar, &image.Uniform{level}, image.Point{}, draw.Src)
+		text(dst, b.Min.X+2, y+1, r.label, color.White)
+		text(dst, b.Max.X-2-8*len(r.value), y+1, r.value, color.White)
+	}
+}
+
+// text draws s with the 8x8 font of the oled96x96 package, its glyphs are
+// columns of 8 pixels.
+func text(dst draw.Image, x, y int, s string, c color.Color) {
+	font := oled96x96.DefaultFont()
+	for _, r := range s {
+		if int(r) < len(font) {
+			for i, col := range font[r] {
+				for j := uint(0); j < 8; j++ {
+					if col>>j&1 != 0 {
+						dst.Set(x+i, y+int(j), c)
+					}
+				}
+			}
+		}
+		x += 8
+	}
+}
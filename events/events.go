@@ -0,0 +1,160 @@
+// Package events is an in-process event bus: the sensors publish their
+// samples, the buttons their input events and the devices their status,
+// and the displays, actuators and loggers subscribe to the topics they
+// use, so the parts of an application don't depend on each other.
+package events
+
+import (
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goiot/devices/gpio"
+)
+
+// Event is published on the bus, it is a Sample, an Input or a Status.
+type Event interface {
+	// Topic is the topic of the event, e.g. sample/bme280/temperature.
+	Topic() string
+}
+
+// Sample is a reading of a sensor, published on sample/<source>.
+type Sample struct {
+	Source string // e.g. bme280/temperature
+	Value  float64
+	Unit   string
+	Time   time.Time
+}
+
+// Topic implements Event.
+func (s Sample) Topic() string { return "sample/" + s.Source }
+
+// Input is an event of an input such as a button, published on
+// input/<source>.
+type Input struct {
+	Source string // e.g. bonnet/A
+	Edge   gpio.Edge
+	Time   time.Time
+}
+
+// Topic implements Event.
+func (i Input) Topic() string { return "input/" + i.Source }
+
+// Status is the status of a device, published on status/<source> when it
+// changes.
+type Status struct {
+	Source string
+	Err    error // nil when the device works
+	Time   time.Time
+}
+
+// Topic implements Event.
+func (s Status) Topic() string { return "status/" + s.Source }
+
+// Bus dispatches the events to the subscriptions. It can be used by
+// multiple goroutines.
+type Bus struct {
+	mu   sync.RWMutex
+	subs map[*Subscription]bool
+}
+
+// New returns a bus without subscriptions.
+func New() *Bus {
+	return &Bus{subs: make(map[*Subscription]bool)}
+}
+
+// Publish sends e to the subscriptions matching its topic. It does not
+// block: the events are dropped for the subscriptions whose queue is full.
+func (b *Bus) Publish(e Event) {
+	topic := e.Topic()
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for s := range b.subs {
+		if !match(s.pattern, topic) {
+			continue
+		}
+		select {
+		case s.c <- e:
+		default:
+			s.mu.Lock()
+			s.dropped++
+			s.mu.Unlock()
+		}
+	}
+}
+
+// Subscribe subscribes to the topics matching pattern, queuing up to n
+// events. In the pattern, * matches a level of the topic and a final #
+// the remaining levels: sample/bme280/* or input/#.
+func (b *Bus) Subscribe(pattern string, n int) *Subscription {
+	s := &Subscription{pattern: pattern, c: make(chan Event, n), b: b}
+	s.C = s.c
+	b.mu.Lock()
+	b.subs[s] = true
+	b.mu.Unlock()
+	return s
+}
+
+// match reports whether topic matches pattern.
+func match(pattern, topic string) bool {
+	if strings.HasSuffix(pattern, "#") {
+		prefix := strings.TrimSuffix(pattern, "#")
+		if prefix == "" {
+			return true
+		}
+		levels := strings.Count(prefix, "/")
+		parts := strings.SplitN(topic, "/", levels+1)
+		if len(parts) <= levels {
+			return false
+		}
+		return match(strings.TrimSuffix(prefix, "/"), strings.Join(parts[:levels], "/"))
+	}
+	if strings.Count(pattern, "/") != strings.Count(topic, "/") {
+		return false
+	}
+	ok, err := path.Match(pattern, topic)
+	return err == nil && ok
+}
+
+// Subscription receives the events of the topics it matches.
+type Subscription struct {
+	// C receives the events, it is closed by Close.
+	C <-chan Event
+
+	pattern string
+	c       chan Event
+	b       *Bus
+
+	mu      sync.Mutex
+	dropped int
+}
+
+// Dropped returns the number of events dropped because the queue was full.
+func (s *Subscription) Dropped() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+// Close unsubscribes and closes C.
+func (s *Subscription) Close() {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	if s.b.subs[s] {
+		delete(s.b.subs, s)
+		close(s.c)
+	}
+}
+
+// Watch publishes the edges of w as input events of source until w fails,
+// e.g. when it is closed. It returns the error of w.
+func (b *Bus) Watch(source string, w gpio.Watcher) error {
+	for {
+		e, err := w.Wait(-1)
+		if err != nil {
+			return err
+		}
+		b.Publish(Input{Source: source, Edge: e.Edge, Time: e.Time})
+	}
+}
@@ -0,0 +1,91 @@
+package events
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/gpio"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, topic string
+		want           bool
+	}{
+		{"sample/bme280/temperature", "sample/bme280/temperature", true},
+		{"sample/bme280/*", "sample/bme280/humidity", true},
+		{"sample/*", "sample/bme280/humidity", false},
+		{"sample/#", "sample/bme280/humidity", true},
+		{"sample/#", "input/bonnet/A", false},
+		{"#", "status/oled", true},
+		{"*/oled", "status/oled", true},
+		{"input/bonnet/#", "input/bonnet", false},
+	}
+	for _, tt := range tests {
+		if got := match(tt.pattern, tt.topic); got != tt.want {
+			t.Errorf("match(%q, %q) = %v; want %v", tt.pattern, tt.topic, got, tt.want)
+		}
+	}
+}
+
+func TestBus(t *testing.T) {
+	b := New()
+	temps := b.Subscribe("sample/*/temperature", 1)
+	all := b.Subscribe("#", 10)
+
+	b.Publish(Sample{Source: "bme280/temperature", Value: 21.5})
+	b.Publish(Sample{Source: "bme280/humidity", Value: 40})
+	b.Publish(Sample{Source: "sensehat/temperature", Value: 22})
+	b.Publish(Status{Source: "oled", Err: errors.New("nak")})
+
+	if e := <-temps.C; e.(Sample).Value != 21.5 {
+		t.Errorf("first temperature %v", e)
+	}
+	if n := temps.Dropped(); n != 1 {
+		t.Errorf("%d events dropped; want 1", n)
+	}
+	if n := len(all.C); n != 4 {
+		t.Errorf("%d events for #; want 4", n)
+	}
+
+	temps.Close()
+	temps.Close()
+	if _, ok := <-temps.C; ok {
+		t.Error("C not closed")
+	}
+	b.Publish(Sample{Source: "bme280/temperature"})
+}
+
+type button struct {
+	events []gpio.Event
+}
+
+func (b *button) Read() (int, error) { return 0, nil }
+func (b *button) Write(int) error    { return nil }
+func (b *button) Close() error       { return nil }
+
+func (b *button) Wait(time.Duration) (gpio.Event, error) {
+	if len(b.events) == 0 {
+		return gpio.Event{}, errors.New("closed")
+	}
+	e := b.events[0]
+	b.events = b.events[1:]
+	return e, nil
+}
+
+func TestWatch(t *testing.T) {
+	b := New()
+	s := b.Subscribe("input/#", 10)
+	at := time.Now()
+	w := &button{events: []gpio.Event{{Edge: gpio.FallingEdge, Time: at}, {Edge: gpio.RisingEdge}}}
+	if err := b.Watch("bonnet/A", w); err == nil {
+		t.Error("Watch returned without the error of the pin")
+	}
+	if e := (<-s.C).(Input); e.Source != "bonnet/A" || e.Edge != gpio.FallingEdge || !e.Time.Equal(at) {
+		t.Errorf("input event %+v", e)
+	}
+	if e := (<-s.C).(Input); e.Edge != gpio.RisingEdge || e.Topic() != "input/bonnet/A" {
+		t.Errorf("input event %+v on %v", e, e.Topic())
+	}
+}
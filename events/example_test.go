@@ -0,0 +1,27 @@
+package events_test
+
+import (
+	"fmt"
+
+	"github.com/goiot/devices/events"
+)
+
+func Example() {
+	bus := events.New()
+
+	// The display shows the temperatures, whichever sensor measures them.
+	temps := bus.Subscribe("sample/*/temperature", 16)
+	defer temps.Close()
+
+	bus.Publish(events.Sample{Source: "bme280/temperature", Value: 21.5, Unit: "C"})
+	bus.Publish(events.Sample{Source: "bme280/humidity", Value: 40, Unit: "%"})
+	bus.Publish(events.Sample{Source: "sensehat/temperature", Value: 23.25, Unit: "C"})
+
+	for i := 0; i < 2; i++ {
+		s := (<-temps.C).(events.Sample)
+		fmt.Println(s.Source, s.Value, s.Unit)
+	}
+	// Output:
+	// bme280/temperature 21.5 C
+	// sensehat/temperature 23.25 C
+}
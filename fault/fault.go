@@ -0,0 +1,145 @@
+// Package fault describes the faults injected by the simulated backends
+// (i2csim, spisim and gpiosim), so the retry and recovery logic of the
+// drivers can be tested deterministically.
+//
+// Faults are queued and consumed one per transfer, the zero Fault lets a
+// transfer through, e.g. to make the third transfer time out:
+//
+//	bus.Inject(addr, fault.Fault{}, fault.Fault{}, fault.Fault{Kind: fault.Timeout})
+package fault
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/goiot/devices/clock"
+)
+
+var (
+	// ErrInjected is returned by the Error faults without an error.
+	ErrInjected = errors.New("injected fault")
+	// ErrNAK is returned by the NAK faults without an error.
+	ErrNAK = errors.New("no acknowledge from the device")
+	// ErrTimeout is returned by the Timeout faults without an error.
+	ErrTimeout = errors.New("transfer timed out")
+)
+
+// Kind is a kind of fault.
+type Kind int
+
+const (
+	// None lets the transfer through.
+	None Kind = iota
+	// Error fails the transfer, which doesn't reach the device.
+	Error
+	// NAK stops the transfer after Bytes bytes are written and fails it.
+	NAK
+	// Timeout fails the transfer after Delay, it doesn't reach the device.
+	Timeout
+	// BitFlip flips the bits of Mask in the byte Bytes read from the
+	// device. On a pin, it inverts the level read or written.
+	BitFlip
+	// Partial writes only the first Bytes bytes, without error. A write
+	// to a pin is ignored.
+	Partial
+)
+
+// Fault is a fault injected in one transfer.
+type Fault struct {
+	Kind  Kind
+	Bytes int
+	Mask  byte
+	Delay time.Duration
+	// Clock times Delay, clock.Real if nil. The simulated backends set
+	// it to their own clock.
+	Clock clock.Clock
+	// Err is returned by the failed transfers instead of the default
+	// error of the kind.
+	Err error
+}
+
+func (f Fault) err(def error) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	return def
+}
+
+// Tx runs the transfer tx of w and r with the fault. It can be used by
+// simulated devices to inject faults in their own transfers.
+func (f Fault) Tx(w, r []byte, tx func(w, r []byte) error) error {
+	switch f.Kind {
+	case Error:
+		return f.err(ErrInjected)
+	case NAK:
+		if n := f.bytes(len(w)); n > 0 {
+			tx(w[:n], nil)
+		}
+		return f.err(ErrNAK)
+	case Timeout:
+		clock.Or(f.Clock).Sleep(f.Delay)
+		return f.err(ErrTimeout)
+	case BitFlip:
+		err := tx(w, r)
+		if f.Bytes >= 0 && f.Bytes < len(r) {
+			r[f.Bytes] ^= f.Mask
+		}
+		return err
+	case Partial:
+		n := f.bytes(len(w))
+		if len(r) == len(w) {
+			// full duplex, the device only clocks n bytes
+			for i := n; i < len(r); i++ {
+				r[i] = 0
+			}
+			r = r[:n]
+		}
+		return tx(w[:n], r)
+	}
+	return tx(w, r)
+}
+
+func (f Fault) bytes(max int) int {
+	switch {
+	case f.Bytes < 0:
+		return 0
+	case f.Bytes > max:
+		return max
+	}
+	return f.Bytes
+}
+
+// Queue is a queue of faults, one per transfer. The zero Queue is empty
+// and can be used by multiple goroutines.
+type Queue struct {
+	mu     sync.Mutex
+	faults []Fault
+}
+
+// Inject appends faults to the queue.
+func (q *Queue) Inject(faults ...Fault) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.faults = append(q.faults, faults...)
+}
+
+// Next removes and returns the fault of the next transfer, the zero
+// Fault if the queue is empty.
+func (q *Queue) Next() Fault {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.faults) == 0 {
+		return Fault{}
+	}
+	f := q.faults[0]
+	q.faults = q.faults[1:]
+	return f
+}
+
+// Clear removes the faults not injected yet.
+func (q *Queue) Clear() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.faults = nil
+}
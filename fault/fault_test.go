@@ -0,0 +1,71 @@
+package fault
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+// echo is a device reading back the bytes it received, plus one.
+type echo struct{ got []byte }
+
+func (e *echo) Tx(w, r []byte) error {
+	e.got = append([]byte(nil), w...)
+	for i := range r {
+		r[i] = w[i] + 1
+	}
+	return nil
+}
+
+func TestTx(t *testing.T) {
+	errBus := errors.New("bus error")
+	for _, tt := range []struct {
+		name    string
+		f       Fault
+		err     error
+		got, r  []byte
+		minTime time.Duration
+	}{
+		{"none", Fault{}, nil, []byte{1, 2, 3}, []byte{2, 3, 4}, 0},
+		{"error", Fault{Kind: Error}, ErrInjected, nil, []byte{0, 0, 0}, 0},
+		{"custom error", Fault{Kind: Error, Err: errBus}, errBus, nil, []byte{0, 0, 0}, 0},
+		{"nak", Fault{Kind: NAK, Bytes: 2}, ErrNAK, []byte{1, 2}, []byte{0, 0, 0}, 0},
+		{"timeout", Fault{Kind: Timeout, Delay: 10 * time.Millisecond}, ErrTimeout, nil, []byte{0, 0, 0}, 10 * time.Millisecond},
+		{"bit flip", Fault{Kind: BitFlip, Bytes: 1, Mask: 0x81}, nil, []byte{1, 2, 3}, []byte{2, 0x82, 4}, 0},
+		{"partial", Fault{Kind: Partial, Bytes: 1}, nil, []byte{1}, []byte{2, 0, 0}, 0},
+	} {
+		dev := &echo{}
+		r := make([]byte, 3)
+		start := time.Now()
+		err := tt.f.Tx([]byte{1, 2, 3}, r, dev.Tx)
+		if err != tt.err {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.err)
+		}
+		if !bytes.Equal(dev.got, tt.got) || !bytes.Equal(r, tt.r) {
+			t.Errorf("%s: device got %v and read %v, want %v and %v", tt.name, dev.got, r, tt.got, tt.r)
+		}
+		if d := time.Since(start); d < tt.minTime {
+			t.Errorf("%s: took %v, want at least %v", tt.name, d, tt.minTime)
+		}
+	}
+}
+
+func TestQueue(t *testing.T) {
+	var q Queue
+	q.Inject(Fault{}, Fault{Kind: NAK})
+	if f := q.Next(); f.Kind != None {
+		t.Errorf("first fault = %v, want None", f.Kind)
+	}
+	if f := q.Next(); f.Kind != NAK {
+		t.Errorf("second fault = %v, want NAK", f.Kind)
+	}
+	if f := q.Next(); f.Kind != None {
+		t.Errorf("fault of an empty queue = %v, want None", f.Kind)
+	}
+	q.Inject(Fault{Kind: Error})
+	q.Clear()
+	if f := q.Next(); f.Kind != None {
+		t.Errorf("fault after Clear = %v, want None", f.Kind)
+	}
+}
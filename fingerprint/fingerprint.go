@@ -0,0 +1,78 @@
+// Package fingerprint implements drivers for the optical fingerprint
+// sensors with a UART: the R503, its R30x, AS608 and ZFM relatives, and
+// the GT-521F. They enroll the fingerprints in their flash and identify
+// the fingers on their own, e.g. to open a lock with a relay.
+//
+// The serial port must be configured by the caller at the baud rate of
+// the sensor, 57600 for the R503 and 9600 for the GT-521F, 8N1, e.g. with
+//
+//	stty -F /dev/ttyAMA0 57600 cs8 -parenb -cstopb raw
+package fingerprint
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/goiot/devices/clock"
+)
+
+var (
+	// ErrNoMatch is returned by Identify when the finger is not enrolled.
+	ErrNoMatch = errors.New("fingerprint: no match")
+
+	// ErrNoFinger is returned when there is no finger on the sensor.
+	ErrNoFinger = errors.New("fingerprint: no finger on the sensor")
+)
+
+// Sensor is a fingerprint sensor storing the templates of the enrolled
+// fingerprints at IDs from 0 to its capacity.
+type Sensor interface {
+	// Enroll enrolls a finger at id, placed and lifted several times on
+	// the sensor until ctx is done.
+	Enroll(ctx context.Context, id int) error
+
+	// Identify waits for a finger until ctx is done, and returns the ID
+	// it matches.
+	Identify(ctx context.Context) (int, error)
+
+	// Delete deletes the template at id.
+	Delete(id int) error
+
+	// DeleteAll deletes all the templates.
+	DeleteAll() error
+
+	// Count returns the number of templates enrolled.
+	Count() (int, error)
+}
+
+const (
+	// poll is the interval the sensors are polled for a finger at.
+	poll = 100 * time.Millisecond
+
+	// timeout is the time waited for an answer, the longest commands
+	// search the templates.
+	timeout = 2 * time.Second
+)
+
+// deadliner is implemented by the serial ports supporting read timeouts,
+// such as *os.File.
+type deadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// waitFor polls finger every poll until it returns true, an error, or ctx
+// is done.
+func waitFor(ctx context.Context, c clock.Clock, finger func() (bool, error)) error {
+	for {
+		ok, err := finger()
+		if err != nil || ok {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.After(poll):
+		}
+	}
+}
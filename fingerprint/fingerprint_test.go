@@ -0,0 +1,318 @@
+package fingerprint
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/clock"
+)
+
+var (
+	_ Sensor = (*R503)(nil)
+	_ Sensor = (*GT521F)(nil)
+)
+
+// port answers the packets written with answer, read back.
+type port struct {
+	answer func(b []byte) []byte
+	out    []byte
+}
+
+func (p *port) Write(b []byte) (int, error) {
+	p.out = append(p.out, p.answer(b)...)
+	return len(b), nil
+}
+
+func (p *port) Read(b []byte) (int, error) {
+	if len(p.out) == 0 {
+		return 0, io.EOF // timed out
+	}
+	n := copy(b, p.out)
+	p.out = p.out[n:]
+	return n, nil
+}
+
+// finger is the finger on a fake sensor: fingers[i] is whether it is
+// placed at the poll i, and print is the fingerprint.
+type finger struct {
+	fingers []bool
+	print   int
+}
+
+func (f *finger) placed() bool {
+	if len(f.fingers) == 0 {
+		return false
+	}
+	on := f.fingers[0]
+	f.fingers = f.fingers[1:]
+	return on
+}
+
+// drive runs f, advancing c while f waits.
+func drive(c *clock.Fake, f func() error) error {
+	done := make(chan error, 1)
+	go func() { done <- f() }()
+	for {
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(time.Millisecond):
+			c.Advance(poll)
+		}
+	}
+}
+
+// r503 is a fake R503 of 300 templates.
+type r503 struct {
+	finger
+	password  uint32
+	buffers   [3]int // the prints of the character buffers, 0 if empty
+	templates map[int]int
+	led       []byte
+}
+
+func (s *r503) answer(b []byte) []byte {
+	p := b[9 : len(b)-2]
+	ack := func(code byte, params ...byte) []byte {
+		return packet(pidAck, append([]byte{code}, params...)...)
+	}
+	switch p[0] {
+	case cmdVerifyPassword:
+		if uint32(p[1])<<24|uint32(p[2])<<16|uint32(p[3])<<8|uint32(p[4]) != s.password {
+			return ack(0x13)
+		}
+	case cmdReadSysPara:
+		return ack(codeOK, 0, 4, 0, 0, 300>>8, 300&0xFF, 0, 3, 0xFF, 0xFF, 0xFF, 0xFF, 0, 2, 0, 6)
+	case cmdGenImg:
+		if !s.placed() {
+			return ack(codeNoFinger)
+		}
+		s.buffers[0] = s.print
+	case cmdImg2Tz:
+		s.buffers[p[1]] = s.buffers[0]
+	case cmdRegModel:
+		if s.buffers[1] != s.buffers[2] {
+			return ack(0x0A)
+		}
+	case cmdStore:
+		s.templates[int(p[2])<<8|int(p[3])] = s.buffers[p[1]]
+	case cmdSearch:
+		for id, print := range s.templates {
+			if print == s.buffers[p[1]] {
+				return ack(codeOK, byte(id>>8), byte(id), 0, 150)
+			}
+		}
+		return ack(codeNotFound)
+	case cmdDeleteChar:
+		delete(s.templates, int(p[1])<<8|int(p[2]))
+	case cmdEmpty:
+		s.templates = map[int]int{}
+	case cmdTemplateNum:
+		return ack(codeOK, 0, byte(len(s.templates)))
+	case cmdReadIndexTable:
+		table := make([]byte, 32)
+		for id := range s.templates {
+			if id/256 == int(p[1]) {
+				table[id%256/8] |= 1 << uint(id%8)
+			}
+		}
+		return ack(codeOK, table...)
+	case cmdAuraLEDConfig:
+		s.led = p[1:]
+	}
+	return ack(codeOK)
+}
+
+func TestR503(t *testing.T) {
+	if _, err := NewR503(&port{answer: (&r503{password: 1234}).answer}, 0); err == nil {
+		t.Error("NewR503() succeeded with a wrong password")
+	}
+	s := &r503{templates: map[int]int{}}
+	c := clock.NewFake(time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC))
+	r, err := NewR503(&port{answer: s.answer}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Clock = c
+	if r.Capacity() != 300 {
+		t.Errorf("Capacity() = %d; want 300", r.Capacity())
+	}
+
+	// placed after a while, lifted, placed again and lifted
+	s.finger = finger{fingers: []bool{false, false, true, true, false, true, false}, print: 7}
+	if err := drive(c, func() error { return r.Enroll(context.Background(), 260) }); err != nil {
+		t.Fatal(err)
+	}
+	if s.templates[260] != 7 {
+		t.Errorf("templates %v; want 7 at 260", s.templates)
+	}
+	if ids, err := r.Templates(); err != nil || len(ids) != 1 || ids[0] != 260 {
+		t.Errorf("Templates() = %v, %v; want [260]", ids, err)
+	}
+
+	s.finger = finger{fingers: []bool{false, true}, print: 7}
+	var id, score int
+	if err := drive(c, func() (err error) { id, score, err = r.Search(context.Background()); return err }); err != nil || id != 260 || score != 150 {
+		t.Errorf("Search() = %d, %d, %v; want 260, 150", id, score, err)
+	}
+	s.finger = finger{fingers: []bool{true}, print: 8}
+	if _, err := r.Identify(context.Background()); err != ErrNoMatch {
+		t.Errorf("Identify() of another finger = %v; want %v", err, ErrNoMatch)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := r.Identify(ctx); err != context.Canceled {
+		t.Errorf("Identify() without finger = %v; want %v", err, context.Canceled)
+	}
+	if err := r.Enroll(ctx, 300); err == nil {
+		t.Error("Enroll() beyond the capacity succeeded")
+	}
+
+	if n, err := r.Count(); err != nil || n != 1 {
+		t.Errorf("Count() = %d, %v; want 1", n, err)
+	}
+	if err := r.Delete(260); err != nil || len(s.templates) != 0 {
+		t.Errorf("Delete(260) = %v, templates %v", err, s.templates)
+	}
+	s.templates[3] = 1
+	if err := r.DeleteAll(); err != nil || len(s.templates) != 0 {
+		t.Errorf("DeleteAll() = %v, templates %v", err, s.templates)
+	}
+	if err := r.SetLED(Breathing, Blue, 100, 3); err != nil || string(s.led) != string([]byte{1, 100, 2, 3}) {
+		t.Errorf("SetLED() = %v, LED % X; want 01 64 02 03", err, s.led)
+	}
+}
+
+func TestR503Errors(t *testing.T) {
+	s := &r503{templates: map[int]int{}}
+	p := &port{answer: s.answer}
+	r, err := NewR503(p, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// two different fingers
+	r.Clock = clock.NewFake(time.Time{})
+	s.finger = finger{fingers: []bool{true, false}, print: 1}
+	p.answer = func(b []byte) []byte {
+		if b[9] == cmdGenImg && len(s.fingers) == 0 {
+			s.finger = finger{fingers: []bool{true, false}, print: 2}
+		}
+		return s.answer(b)
+	}
+	if err := r.Enroll(context.Background(), 1); err != R503Error(0x0A) || err.Error() != "fingerprint: failed to combine the character files" {
+		t.Errorf("Enroll() of two fingers = %v; want %v", err, R503Error(0x0A))
+	}
+
+	p.answer = func(b []byte) []byte {
+		a := s.answer(b)
+		a[len(a)-1]++
+		return a
+	}
+	if _, err := r.Count(); err == nil {
+		t.Error("Count() succeeded with an invalid checksum")
+	}
+}
+
+// gt521f is a fake GT-521F.
+type gt521f struct {
+	finger
+	backlight bool
+	enrolling int
+	samples   []int
+	templates map[int]int
+	captured  int
+}
+
+func (s *gt521f) answer(b []byte) []byte {
+	param := uint32(b[4]) | uint32(b[5])<<8 | uint32(b[6])<<16 | uint32(b[7])<<24
+	cmd := uint16(b[8]) | uint16(b[9])<<8
+	reply := func(ack uint16, param uint32) []byte {
+		a := gtPacket(ack, param)
+		a[0], a[1] = gtStart1, gtStart2
+		return a
+	}
+	nack := func(code uint32) []byte { return reply(gtNack, code) }
+	switch cmd {
+	case gtCmosLED:
+		s.backlight = param == 1
+	case gtIsPressFinger:
+		if !s.backlight || !s.placed() {
+			return reply(gtAck, 1)
+		}
+	case gtCaptureFinger:
+		s.captured = s.print
+	case gtEnrollStart:
+		if _, ok := s.templates[int(param)]; ok {
+			return nack(0x1005)
+		}
+		s.enrolling, s.samples = int(param), nil
+	case gtEnroll1, gtEnroll1 + 1, gtEnroll1 + 2:
+		s.samples = append(s.samples, s.captured)
+		if cmd == gtEnroll1+2 {
+			s.templates[s.enrolling] = s.captured
+		}
+	case gtIdentify:
+		if len(s.templates) == 0 {
+			return nack(gtErrDBEmpty)
+		}
+		for id, print := range s.templates {
+			if print == s.captured {
+				return reply(gtAck, uint32(id))
+			}
+		}
+		return nack(gtErrIdentify)
+	case gtDeleteID:
+		delete(s.templates, int(param))
+	case gtDeleteAll:
+		s.templates = map[int]int{}
+	case gtGetEnrollCount:
+		return reply(gtAck, uint32(len(s.templates)))
+	}
+	return reply(gtAck, 0)
+}
+
+func TestGT521F(t *testing.T) {
+	s := &gt521f{templates: map[int]int{}}
+	c := clock.NewFake(time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC))
+	g, err := NewGT521F(&port{answer: s.answer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Clock = c
+	s.finger = finger{fingers: []bool{false, true, false, true, true, false, true}, print: 5}
+	if err := drive(c, func() error { return g.Enroll(context.Background(), 12) }); err != nil {
+		t.Fatal(err)
+	}
+	if s.templates[12] != 5 || len(s.samples) != 3 || s.backlight {
+		t.Errorf("templates %v from %d samples, backlight %v; want 5 at 12 from 3 samples", s.templates, len(s.samples), s.backlight)
+	}
+	if err := g.Enroll(context.Background(), 12); err != GT521FError(0x1005) {
+		t.Errorf("Enroll() of a used ID = %v; want %v", err, GT521FError(0x1005))
+	}
+
+	s.finger = finger{fingers: []bool{true}, print: 5}
+	if id, err := g.Identify(context.Background()); err != nil || id != 12 {
+		t.Errorf("Identify() = %d, %v; want 12", id, err)
+	}
+	s.finger = finger{fingers: []bool{true}, print: 6}
+	if _, err := g.Identify(context.Background()); err != ErrNoMatch {
+		t.Errorf("Identify() of another finger = %v; want %v", err, ErrNoMatch)
+	}
+	if n, err := g.Count(); err != nil || n != 1 {
+		t.Errorf("Count() = %d, %v; want 1", n, err)
+	}
+	if err := g.Delete(12); err != nil || len(s.templates) != 0 {
+		t.Errorf("Delete(12) = %v, templates %v", err, s.templates)
+	}
+	s.finger = finger{fingers: []bool{true}, print: 5}
+	if _, err := g.Identify(context.Background()); err != ErrNoMatch {
+		t.Errorf("Identify() without templates = %v; want %v", err, ErrNoMatch)
+	}
+	s.templates[1] = 1
+	if err := g.DeleteAll(); err != nil || len(s.templates) != 0 {
+		t.Errorf("DeleteAll() = %v, templates %v", err, s.templates)
+	}
+}
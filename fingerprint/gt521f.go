@@ -0,0 +1,243 @@
+package fingerprint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/goiot/devices/clock"
+)
+
+const (
+	gtStart1, gtStart2 = 0x55, 0xAA
+	gtDevice           = 0x0001
+	gtAck              = 0x30
+	gtNack             = 0x31
+
+	gtOpen           = 0x01
+	gtCmosLED        = 0x12
+	gtGetEnrollCount = 0x20
+	gtEnrollStart    = 0x22
+	gtEnroll1        = 0x23 // to gtEnroll3 at 0x25
+	gtIsPressFinger  = 0x26
+	gtDeleteID       = 0x40
+	gtDeleteAll      = 0x41
+	gtIdentify       = 0x51
+	gtCaptureFinger  = 0x60
+
+	gtErrIdentify    = 0x1008
+	gtErrDBEmpty     = 0x100A
+	gtErrNotPressed  = 0x1012
+	gtEnrollSamples  = 3
+	gtPacketSize     = 12
+	gtCaptureQuality = 1 // best image, slower, for the enrollment
+)
+
+// GT521FError is an error code of a GT-521F failing a command.
+type GT521FError uint32
+
+var gtErrors = map[GT521FError]string{
+	0x1001: "capture timeout",
+	0x1003: "invalid ID",
+	0x1004: "ID not used",
+	0x1005: "ID already used",
+	0x1006: "communication error",
+	0x1007: "verification failed",
+	0x1009: "database full",
+	0x100C: "bad finger",
+	0x100D: "failed to enroll the finger",
+	0x100E: "command not supported",
+	0x1011: "invalid parameter",
+}
+
+func (e GT521FError) Error() string {
+	if s, ok := gtErrors[e]; ok {
+		return "fingerprint: " + s
+	}
+	if e < 0x1000 {
+		return fmt.Sprintf("fingerprint: ID %d already enrolled", uint32(e))
+	}
+	return fmt.Sprintf("fingerprint: error %#x", uint32(e))
+}
+
+// GT521F represents a GT-521F. It implements Sensor, and can be used by
+// multiple goroutines.
+type GT521F struct {
+	// Clock times the polling for a finger, clock.Real if nil.
+	Clock clock.Clock
+
+	port io.ReadWriter
+	mu   sync.Mutex
+}
+
+// NewGT521F returns the sensor on the serial port.
+func NewGT521F(port io.ReadWriter) (*GT521F, error) {
+	g := &GT521F{port: port}
+	if _, err := g.command(gtOpen, 0); err != nil {
+		return nil, fmt.Errorf("opening the sensor failed - %v", err)
+	}
+	return g, nil
+}
+
+// gtPacket returns the command packet of cmd with its parameter.
+func gtPacket(cmd uint16, param uint32) []byte {
+	b := []byte{
+		gtStart1, gtStart2, gtDevice & 0xFF, gtDevice >> 8,
+		byte(param), byte(param >> 8), byte(param >> 16), byte(param >> 24),
+		byte(cmd), byte(cmd >> 8),
+	}
+	var sum uint16
+	for _, v := range b {
+		sum += uint16(v)
+	}
+	return append(b, byte(sum), byte(sum>>8))
+}
+
+// command sends the command cmd with its parameter and returns the
+// parameter of the acknowledgement.
+func (g *GT521F) command(cmd uint16, param uint32) (uint32, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, err := g.port.Write(gtPacket(cmd, param)); err != nil {
+		return 0, err
+	}
+	if d, ok := g.port.(deadliner); ok {
+		d.SetReadDeadline(time.Now().Add(timeout))
+		defer d.SetReadDeadline(time.Time{})
+	}
+	b := make([]byte, gtPacketSize)
+	if _, err := io.ReadFull(g.port, b); err != nil {
+		return 0, fmt.Errorf("reading the answer of the sensor failed - %v", err)
+	}
+	if b[0] != gtStart1 || b[1] != gtStart2 {
+		return 0, fmt.Errorf("invalid answer % X of the sensor", b)
+	}
+	var sum uint16
+	for _, v := range b[:10] {
+		sum += uint16(v)
+	}
+	if sum != uint16(b[10])|uint16(b[11])<<8 {
+		return 0, errors.New("invalid checksum in the answer of the sensor")
+	}
+	p := uint32(b[4]) | uint32(b[5])<<8 | uint32(b[6])<<16 | uint32(b[7])<<24
+	switch uint16(b[8]) | uint16(b[9])<<8 {
+	case gtAck:
+		return p, nil
+	case gtNack:
+		switch p {
+		case gtErrIdentify, gtErrDBEmpty:
+			return 0, ErrNoMatch
+		case gtErrNotPressed:
+			return 0, ErrNoFinger
+		}
+		return 0, GT521FError(p)
+	}
+	return 0, fmt.Errorf("invalid answer % X of the sensor", b)
+}
+
+// SetBacklight turns the backlight of the sensor on or off. It is turned
+// on to capture the fingers.
+func (g *GT521F) SetBacklight(on bool) error {
+	var p uint32
+	if on {
+		p = 1
+	}
+	_, err := g.command(gtCmosLED, p)
+	return err
+}
+
+// pressed reports whether a finger is on the sensor.
+func (g *GT521F) pressed() (bool, error) {
+	p, err := g.command(gtIsPressFinger, 0)
+	return p == 0, err
+}
+
+// capture waits for a finger and captures its image.
+func (g *GT521F) capture(ctx context.Context, quality uint32) error {
+	if err := waitFor(ctx, clock.Or(g.Clock), g.pressed); err != nil {
+		return err
+	}
+	_, err := g.command(gtCaptureFinger, quality)
+	return err
+}
+
+// lifted waits until the finger is lifted.
+func (g *GT521F) lifted(ctx context.Context) error {
+	return waitFor(ctx, clock.Or(g.Clock), func() (bool, error) {
+		ok, err := g.pressed()
+		return !ok, err
+	})
+}
+
+// Enroll implements Sensor, the finger is placed three times.
+func (g *GT521F) Enroll(ctx context.Context, id int) error {
+	if id < 0 {
+		return fmt.Errorf("invalid ID %d", id)
+	}
+	if err := g.SetBacklight(true); err != nil {
+		return err
+	}
+	defer g.SetBacklight(false)
+	if _, err := g.command(gtEnrollStart, uint32(id)); err != nil {
+		return err
+	}
+	for i := 0; i < gtEnrollSamples; i++ {
+		if err := g.capture(ctx, gtCaptureQuality); err != nil {
+			return err
+		}
+		if _, err := g.command(gtEnroll1+uint16(i), 0); err != nil {
+			return err
+		}
+		if i < gtEnrollSamples-1 {
+			if err := g.lifted(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Identify implements Sensor.
+func (g *GT521F) Identify(ctx context.Context) (int, error) {
+	if err := g.SetBacklight(true); err != nil {
+		return 0, err
+	}
+	defer g.SetBacklight(false)
+	if err := g.capture(ctx, 0); err != nil {
+		return 0, err
+	}
+	id, err := g.command(gtIdentify, 0)
+	return int(id), err
+}
+
+// Delete implements Sensor.
+func (g *GT521F) Delete(id int) error {
+	if id < 0 {
+		return fmt.Errorf("invalid ID %d", id)
+	}
+	_, err := g.command(gtDeleteID, uint32(id))
+	return err
+}
+
+// DeleteAll implements Sensor.
+func (g *GT521F) DeleteAll() error {
+	_, err := g.command(gtDeleteAll, 0)
+	return err
+}
+
+// Count implements Sensor.
+func (g *GT521F) Count() (int, error) {
+	n, err := g.command(gtGetEnrollCount, 0)
+	return int(n), err
+}
+
+// Close closes the port if it is an io.Closer.
+func (g *GT521F) Close() error {
+	if c, ok := g.port.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,317 @@
+package fingerprint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/goiot/devices/clock"
+)
+
+const (
+	r503Header  = 0xEF01
+	r503Address = 0xFFFFFFFF // the default address of the sensors
+
+	pidCommand = 0x01
+	pidAck     = 0x07
+
+	cmdGenImg         = 0x01
+	cmdImg2Tz         = 0x02
+	cmdSearch         = 0x04
+	cmdRegModel       = 0x05
+	cmdStore          = 0x06
+	cmdDeleteChar     = 0x0C
+	cmdEmpty          = 0x0D
+	cmdReadSysPara    = 0x0F
+	cmdVerifyPassword = 0x13
+	cmdTemplateNum    = 0x1D
+	cmdReadIndexTable = 0x1F
+	cmdAuraLEDConfig  = 0x35
+
+	codeOK       = 0x00
+	codeNoFinger = 0x02
+	codeNotFound = 0x09
+)
+
+// R503Error is a confirmation code of an R503 failing a command.
+type R503Error byte
+
+var r503Errors = map[R503Error]string{
+	0x01: "packet receive error",
+	0x03: "failed to enroll the finger",
+	0x06: "image too messy",
+	0x07: "too few feature points",
+	0x08: "fingerprints not matching",
+	0x0A: "failed to combine the character files",
+	0x0B: "ID beyond the library",
+	0x10: "failed to delete the template",
+	0x11: "failed to clear the library",
+	0x13: "wrong password",
+	0x18: "flash write error",
+}
+
+func (e R503Error) Error() string {
+	if s, ok := r503Errors[e]; ok {
+		return "fingerprint: " + s
+	}
+	return fmt.Sprintf("fingerprint: error %#x", byte(e))
+}
+
+// LEDMode is a mode of the LED ring of the R503.
+type LEDMode byte
+
+const (
+	Breathing LEDMode = iota + 1
+	Flashing
+	LEDOn
+	LEDOff
+	FadeIn
+	FadeOut
+)
+
+// LEDColor is a color of the LED ring of the R503, the early sensors
+// only have red, blue and purple.
+type LEDColor byte
+
+const (
+	Red LEDColor = iota + 1
+	Blue
+	Purple
+	Green
+	Yellow
+	Cyan
+	White
+)
+
+// R503 represents an R503, or a sensor of the same protocol. It
+// implements Sensor, and can be used by multiple goroutines.
+type R503 struct {
+	// Clock times the polling for a finger, clock.Real if nil.
+	Clock clock.Clock
+
+	port     io.ReadWriter
+	capacity int
+
+	mu sync.Mutex
+}
+
+// NewR503 returns the sensor on the serial port, protected by password,
+// 0 by default.
+func NewR503(port io.ReadWriter, password uint32) (*R503, error) {
+	r := &R503{port: port}
+	if _, err := r.command(cmdVerifyPassword, byte(password>>24), byte(password>>16), byte(password>>8), byte(password)); err != nil {
+		return nil, fmt.Errorf("verifying the password of the sensor failed - %v", err)
+	}
+	p, err := r.command(cmdReadSysPara)
+	if err != nil {
+		return nil, fmt.Errorf("reading the parameters of the sensor failed - %v", err)
+	}
+	if len(p) < 6 {
+		return nil, errors.New("invalid parameters of the sensor")
+	}
+	r.capacity = int(p[4])<<8 | int(p[5])
+	return r, nil
+}
+
+// packet returns the packet of the identifier pid with payload.
+func packet(pid byte, payload ...byte) []byte {
+	n := len(payload) + 2
+	b := []byte{r503Header >> 8, r503Header & 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, pid, byte(n >> 8), byte(n)}
+	b = append(b, payload...)
+	sum := 0
+	for _, v := range b[6:] {
+		sum += int(v)
+	}
+	return append(b, byte(sum>>8), byte(sum))
+}
+
+// command sends the command cmd with its parameters and returns the
+// parameters of the acknowledgement, after its confirmation code.
+func (r *R503) command(cmd byte, params ...byte) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.port.Write(packet(pidCommand, append([]byte{cmd}, params...)...)); err != nil {
+		return nil, err
+	}
+	if d, ok := r.port.(deadliner); ok {
+		d.SetReadDeadline(time.Now().Add(timeout))
+		defer d.SetReadDeadline(time.Time{})
+	}
+	hdr := make([]byte, 9)
+	if _, err := io.ReadFull(r.port, hdr); err != nil {
+		return nil, fmt.Errorf("reading the answer of the sensor failed - %v", err)
+	}
+	if int(hdr[0])<<8|int(hdr[1]) != r503Header || hdr[6] != pidAck {
+		return nil, fmt.Errorf("invalid answer % X of the sensor", hdr)
+	}
+	n := int(hdr[7])<<8 | int(hdr[8])
+	if n < 3 {
+		return nil, fmt.Errorf("answer of %d bytes", n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r.port, b); err != nil {
+		return nil, fmt.Errorf("reading the answer of the sensor failed - %v", err)
+	}
+	sum := 0
+	for _, v := range append(hdr[6:], b[:n-2]...) {
+		sum += int(v)
+	}
+	if uint16(sum) != uint16(b[n-2])<<8|uint16(b[n-1]) {
+		return nil, errors.New("invalid checksum in the answer of the sensor")
+	}
+	switch b[0] {
+	case codeOK:
+		return b[1 : n-2], nil
+	case codeNoFinger:
+		return nil, ErrNoFinger
+	case codeNotFound:
+		return nil, ErrNoMatch
+	}
+	return nil, R503Error(b[0])
+}
+
+// Capacity returns the number of templates the sensor stores.
+func (r *R503) Capacity() int { return r.capacity }
+
+// capture waits for a finger and stores the features of its image in the
+// character buffer buf.
+func (r *R503) capture(ctx context.Context, buf byte) error {
+	if err := waitFor(ctx, clock.Or(r.Clock), func() (bool, error) {
+		_, err := r.command(cmdGenImg)
+		if err == ErrNoFinger {
+			return false, nil
+		}
+		return err == nil, err
+	}); err != nil {
+		return err
+	}
+	_, err := r.command(cmdImg2Tz, buf)
+	return err
+}
+
+// lifted waits until the finger is lifted.
+func (r *R503) lifted(ctx context.Context) error {
+	return waitFor(ctx, clock.Or(r.Clock), func() (bool, error) {
+		_, err := r.command(cmdGenImg)
+		if err == ErrNoFinger {
+			return true, nil
+		}
+		return false, err
+	})
+}
+
+func (r *R503) checkID(id int) error {
+	if id < 0 || id >= r.capacity {
+		return fmt.Errorf("invalid ID %d, the sensor stores %d templates", id, r.capacity)
+	}
+	return nil
+}
+
+// Enroll implements Sensor, the finger is placed twice.
+func (r *R503) Enroll(ctx context.Context, id int) error {
+	if err := r.checkID(id); err != nil {
+		return err
+	}
+	for buf := byte(1); buf <= 2; buf++ {
+		if err := r.capture(ctx, buf); err != nil {
+			return err
+		}
+		if err := r.lifted(ctx); err != nil {
+			return err
+		}
+	}
+	if _, err := r.command(cmdRegModel); err != nil {
+		return err
+	}
+	_, err := r.command(cmdStore, 1, byte(id>>8), byte(id))
+	return err
+}
+
+// Identify implements Sensor.
+func (r *R503) Identify(ctx context.Context) (int, error) {
+	id, _, err := r.Search(ctx)
+	return id, err
+}
+
+// Search waits for a finger until ctx is done, and returns the ID it
+// matches with the score of the match.
+func (r *R503) Search(ctx context.Context) (id, score int, err error) {
+	if err := r.capture(ctx, 1); err != nil {
+		return 0, 0, err
+	}
+	p, err := r.command(cmdSearch, 1, 0, 0, byte(r.capacity>>8), byte(r.capacity))
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(p) < 4 {
+		return 0, 0, errors.New("invalid search result of the sensor")
+	}
+	return int(p[0])<<8 | int(p[1]), int(p[2])<<8 | int(p[3]), nil
+}
+
+// Delete implements Sensor.
+func (r *R503) Delete(id int) error {
+	if err := r.checkID(id); err != nil {
+		return err
+	}
+	_, err := r.command(cmdDeleteChar, byte(id>>8), byte(id), 0, 1)
+	return err
+}
+
+// DeleteAll implements Sensor.
+func (r *R503) DeleteAll() error {
+	_, err := r.command(cmdEmpty)
+	return err
+}
+
+// Count implements Sensor.
+func (r *R503) Count() (int, error) {
+	p, err := r.command(cmdTemplateNum)
+	if err != nil {
+		return 0, err
+	}
+	if len(p) < 2 {
+		return 0, errors.New("invalid template count of the sensor")
+	}
+	return int(p[0])<<8 | int(p[1]), nil
+}
+
+// Templates returns the IDs of the templates enrolled, e.g. to find a
+// free one.
+func (r *R503) Templates() ([]int, error) {
+	var ids []int
+	// the index tables are pages of 256 templates
+	for page := 0; page*256 < r.capacity; page++ {
+		p, err := r.command(cmdReadIndexTable, byte(page))
+		if err != nil {
+			return nil, err
+		}
+		for i, b := range p {
+			for bit := 0; bit < 8; bit++ {
+				if id := page*256 + i*8 + bit; b&(1<<uint(bit)) != 0 && id < r.capacity {
+					ids = append(ids, id)
+				}
+			}
+		}
+	}
+	return ids, nil
+}
+
+// SetLED sets the LED ring to the mode in the color c. The speed of the
+// breathing, flashing and fading modes goes from 0, the fastest, to 255;
+// the flashing and breathing modes stop after cycles, 0 for ever.
+func (r *R503) SetLED(mode LEDMode, c LEDColor, speed, cycles byte) error {
+	_, err := r.command(cmdAuraLEDConfig, byte(mode), speed, byte(c), cycles)
+	return err
+}
+
+// Close closes the port if it is an io.Closer.
+func (r *R503) Close() error {
+	if c, ok := r.port.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,290 @@
+// Package firmata implements a client of the Firmata protocol, spoken by
+// Arduino boards running the StandardFirmata sketch. The pins of the
+// board are exposed through the gpio, analog and pwm interfaces and its
+// I2C bus as an I2C opener, so the drivers of this repo can run against an
+// Arduino connected to the host over USB.
+//
+// The serial port must be configured by the caller at the baud rate of the
+// sketch, 57600 for StandardFirmata, e.g. with
+//
+//	stty -F /dev/ttyACM0 57600 cs8 -parenb -cstopb raw
+package firmata
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/goiot/devices/clock"
+)
+
+var errClosed = errors.New("the board is closed")
+
+// Board represents an Arduino running Firmata. It can be used by multiple
+// goroutines and must be closed if no longer in use.
+type Board struct {
+	// Clock times the responses waited for, clock.Real if nil; set by
+	// OpenWithClock.
+	Clock clock.Clock
+
+	port io.ReadWriter
+
+	wmu sync.Mutex // serializes writes
+	qmu sync.Mutex // serializes sysex queries
+
+	sysex chan []byte // sysex messages waited for by a query
+	done  chan struct{}
+
+	// set during Open
+	firmware string
+	modes    []map[byte]int // per pin, resolution of each supported mode
+	channels []int          // analog channel to pin
+
+	mu      sync.Mutex
+	err     error
+	inputs  [16]byte // reported digital ports
+	outputs [16]byte // digital ports written
+	analog  map[int]int
+	i2cOn   bool
+}
+
+// Open waits for the board to start, which takes up to a few seconds if
+// opening the port reset it, and queries its capabilities.
+func Open(port io.ReadWriter) (*Board, error) {
+	return OpenWithClock(port, nil)
+}
+
+// OpenWithClock is Open timed by c, clock.Real if nil.
+func OpenWithClock(port io.ReadWriter, c clock.Clock) (*Board, error) {
+	b := &Board{
+		Clock:  c,
+		port:   port,
+		sysex:  make(chan []byte, 8),
+		done:   make(chan struct{}),
+		analog: make(map[int]int),
+	}
+	go b.read()
+
+	fail := func(err error) (*Board, error) {
+		b.Close()
+		return nil, err
+	}
+	// The query is repeated as the bytes sent while the bootloader runs
+	// are lost.
+	var err error
+	var resp []byte
+	for i := 0; i < 10; i++ {
+		if resp, err = b.query(time.Second, sysexReportFirmware); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return fail(fmt.Errorf("no answer from the board, is it running StandardFirmata? - %v", err))
+	}
+	if len(resp) >= 2 {
+		b.firmware = fmt.Sprintf("%s %d.%d", decodeString(resp[2:]), resp[0], resp[1])
+	}
+
+	if resp, err = b.query(time.Second, sysexCapabilityQuery); err != nil {
+		return fail(err)
+	}
+	b.modes = parseCapabilities(resp)
+
+	if resp, err = b.query(time.Second, sysexAnalogMappingQuery); err != nil {
+		return fail(err)
+	}
+	b.channels = parseMapping(resp, len(b.modes))
+	return b, nil
+}
+
+// parseCapabilities parses a capability response: for each pin, pairs of
+// mode and resolution terminated by noChannel.
+func parseCapabilities(resp []byte) []map[byte]int {
+	var pins []map[byte]int
+	modes := map[byte]int{}
+	for i := 0; i < len(resp); i++ {
+		if resp[i] == noChannel {
+			pins = append(pins, modes)
+			modes = map[byte]int{}
+			continue
+		}
+		if i+1 < len(resp) {
+			modes[resp[i]] = int(resp[i+1])
+			i++
+		}
+	}
+	return pins
+}
+
+// parseMapping parses an analog mapping response, the analog channel of
+// each pin, into the pin of each channel. Missing channels map to -1.
+func parseMapping(resp []byte, pins int) []int {
+	var channels []int
+	for pin, ch := range resp {
+		if ch == noChannel || pin >= pins {
+			continue
+		}
+		for len(channels) <= int(ch) {
+			channels = append(channels, -1)
+		}
+		channels[ch] = pin
+	}
+	return channels
+}
+
+// Firmware returns the name and version of the sketch.
+func (b *Board) Firmware() string { return b.firmware }
+
+// Pins returns the number of pins of the board.
+func (b *Board) Pins() int { return len(b.modes) }
+
+// read parses the messages sent by the board until the port is closed.
+func (b *Board) read() {
+	r := bufio.NewReader(b.port)
+	var err error
+	defer func() {
+		b.mu.Lock()
+		b.err = err
+		b.mu.Unlock()
+	}()
+	data := make([]byte, 2)
+	for {
+		var c byte
+		if c, err = r.ReadByte(); err != nil {
+			return
+		}
+		switch {
+		case c == msgSysexStart:
+			var msg []byte
+			if msg, err = readSysex(r); err != nil {
+				return
+			}
+			if msg == nil {
+				continue
+			}
+			select {
+			case b.sysex <- msg:
+			default: // nobody is waiting for it
+			}
+		case c&0xF0 == msgDigital, c&0xF0 == msgAnalog, c == msgVersion:
+			if _, err = io.ReadFull(r, data); err != nil {
+				return
+			}
+			v := int(data[0]&0x7F) | int(data[1]&0x7F)<<7
+			b.mu.Lock()
+			switch c & 0xF0 {
+			case msgDigital:
+				b.inputs[c&0x0F] = byte(v)
+			case msgAnalog:
+				b.analog[int(c&0x0F)] = v
+			}
+			b.mu.Unlock()
+		}
+	}
+}
+
+// readSysex reads a sysex message up to its end byte, which is removed.
+// Messages longer than maxSysex are skipped and returned as nil, so noise
+// on the line can't use up the memory.
+func readSysex(r *bufio.Reader) ([]byte, error) {
+	var msg []byte
+	n := 0
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if c == msgSysexEnd {
+			if n > maxSysex {
+				return nil, nil
+			}
+			return msg, nil
+		}
+		if n++; n <= maxSysex {
+			msg = append(msg, c)
+		} else {
+			msg = nil
+		}
+	}
+}
+
+func (b *Board) write(msg ...byte) error {
+	b.mu.Lock()
+	err := b.err
+	b.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	b.wmu.Lock()
+	defer b.wmu.Unlock()
+	_, err = b.port.Write(msg)
+	return err
+}
+
+// query sends a sysex command and waits for the response, the command
+// byte of the response is removed.
+func (b *Board) query(timeout time.Duration, cmd byte, data ...byte) ([]byte, error) {
+	b.qmu.Lock()
+	defer b.qmu.Unlock()
+	return b.queryLocked(timeout, cmd, data...)
+}
+
+// queryLocked is query with b.qmu held.
+func (b *Board) queryLocked(timeout time.Duration, cmd byte, data ...byte) ([]byte, error) {
+	if err := b.write(append(append([]byte{msgSysexStart, cmd}, data...), msgSysexEnd)...); err != nil {
+		return nil, err
+	}
+	want := responses[cmd]
+	deadline := clock.Or(b.Clock).After(timeout)
+	for {
+		select {
+		case msg := <-b.sysex:
+			if len(msg) > 0 && msg[0] == want {
+				return msg[1:], nil
+			}
+		case <-deadline:
+			return nil, fmt.Errorf("no response to the command %#x", cmd)
+		case <-b.done:
+			return nil, errClosed
+		}
+	}
+}
+
+// Close closes the serial port if it is an io.Closer.
+func (b *Board) Close() error {
+	select {
+	case <-b.done:
+		return nil
+	default:
+	}
+	close(b.done)
+	if c, ok := b.port.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// encode7 splits the bytes in pairs of 7-bit bytes.
+func encode7(b []byte) []byte {
+	out := make([]byte, 0, 2*len(b))
+	for _, v := range b {
+		out = append(out, v&0x7F, v>>7)
+	}
+	return out
+}
+
+// decode7 joins the pairs of 7-bit bytes.
+func decode7(b []byte) []byte {
+	out := make([]byte, len(b)/2)
+	for i := range out {
+		out[i] = b[2*i]&0x7F | b[2*i+1]<<7
+	}
+	return out
+}
+
+func decodeString(b []byte) string {
+	return string(decode7(b))
+}
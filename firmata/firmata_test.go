@@ -0,0 +1,272 @@
+package firmata
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"golang.org/x/exp/io/i2c"
+)
+
+// arduino simulates an Uno running StandardFirmata with 4 pins: 0-1
+// digital with PWM and servo on 1, 2-3 analog inputs A0-A1.
+type arduino struct {
+	conn net.Conn
+
+	mu       sync.Mutex
+	received [][]byte
+}
+
+func newArduino(t *testing.T) (*arduino, *Board) {
+	host, dev := net.Pipe()
+	a := &arduino{conn: dev}
+	go a.serve()
+	b, err := Open(host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a, b
+}
+
+func (a *arduino) send(b ...byte) { a.conn.Write(b) }
+
+func (a *arduino) serve() {
+	r := bufio.NewReader(a.conn)
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return
+		}
+		msg := []byte{c}
+		switch {
+		case c == msgSysexStart:
+			rest, err := r.ReadBytes(msgSysexEnd)
+			if err != nil {
+				return
+			}
+			msg = append(msg, rest...)
+			a.sysex(msg[1 : len(msg)-1])
+		case c == msgSetPinMode:
+			msg = append(msg, 0, 0)
+			r.Read(msg[1:])
+		default:
+			msg = append(msg, 0, 0)
+			r.Read(msg[1:2])
+			if c&0xF0 != msgReportDigital && c&0xF0 != msgReportAnalog {
+				r.Read(msg[2:])
+			} else {
+				msg = msg[:2]
+			}
+			if c == msgReportAnalog|1 {
+				a.send(msgAnalog|1, 0x7F, 0x03) // 511
+			}
+		}
+		a.mu.Lock()
+		a.received = append(a.received, msg)
+		a.mu.Unlock()
+	}
+}
+
+func (a *arduino) sysex(msg []byte) {
+	switch msg[0] {
+	case sysexReportFirmware:
+		a.send(append(append([]byte{msgSysexStart, sysexReportFirmware, 2, 5}, encode7([]byte("Std"))...), msgSysexEnd)...)
+	case sysexCapabilityQuery:
+		a.send(msgSysexStart, sysexCapabilityResponse,
+			modeInput, 1, modeOutput, 1, modePullUp, 1, noChannel,
+			modeInput, 1, modeOutput, 1, modePWM, 8, modeServo, 14, noChannel,
+			modeAnalog, 10, noChannel,
+			modeAnalog, 10, noChannel,
+			msgSysexEnd)
+	case sysexAnalogMappingQuery:
+		a.send(msgSysexStart, sysexAnalogMappingResponse, noChannel, noChannel, 0, 1, msgSysexEnd)
+	case sysexI2CRequest:
+		if msg[2] == i2cReadOnce {
+			reply := append([]byte{msgSysexStart, sysexI2CReply}, encode7([]byte{msg[1], decode7(msg[3:5])[0], 0xAB, 0xCD})...)
+			a.send(append(reply, msgSysexEnd)...)
+		}
+	}
+}
+
+// last returns the last message received starting with prefix.
+func (a *arduino) last(prefix ...byte) []byte {
+	for i := 0; i < 100; i++ {
+		a.mu.Lock()
+		for j := len(a.received) - 1; j >= 0; j-- {
+			if bytes.HasPrefix(a.received[j], prefix) {
+				a.mu.Unlock()
+				return a.received[j]
+			}
+		}
+		a.mu.Unlock()
+		time.Sleep(time.Millisecond)
+	}
+	return nil
+}
+
+func TestOpen(t *testing.T) {
+	_, b := newArduino(t)
+	defer b.Close()
+	if b.Firmware() != "Std 2.5" {
+		t.Errorf("Firmware = %q, want Std 2.5", b.Firmware())
+	}
+	if b.Pins() != 4 || b.ADC().Channels() != 2 || b.ADC().Resolution() != 10 {
+		t.Errorf("got %d pins and %d analog channels", b.Pins(), b.ADC().Channels())
+	}
+}
+
+func TestOpenTimeout(t *testing.T) {
+	// a board not answering, the queries time out on the clock
+	host, dev := net.Pipe()
+	defer dev.Close()
+	go io.Copy(ioutil.Discard, dev)
+	c := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	done := make(chan error)
+	go func() {
+		_, err := OpenWithClock(host, c)
+		done <- err
+	}()
+	for i := 0; i < 10; i++ {
+		c.BlockUntil(1)
+		c.Advance(time.Second)
+	}
+	if err := <-done; err == nil {
+		t.Error("Open succeeded without answer")
+	}
+}
+
+func TestPins(t *testing.T) {
+	a, b := newArduino(t)
+	defer b.Close()
+
+	led, err := b.Output(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := a.last(msgDigital); !bytes.Equal(got, []byte{msgDigital, 0x02, 0}) {
+		t.Errorf("digital message = %x, want pin 1 high", got)
+	}
+	if v, _ := led.Read(); v != 1 {
+		t.Errorf("Read = %d, want 1", v)
+	}
+	if _, err := b.Output(2, 0); err == nil {
+		t.Error("expected an error on an analog only pin")
+	}
+
+	button, err := b.Input(0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.send(msgDigital, 0x01, 0)
+	time.Sleep(10 * time.Millisecond)
+	if v, _ := button.Read(); v != 1 {
+		t.Errorf("Read = %d, want 1", v)
+	}
+
+	p, err := b.PWM(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetDuty(0.5); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.last(msgAnalog | 1); !bytes.Equal(got, []byte{msgAnalog | 1, 0x00, 0x01}) {
+		t.Errorf("analog message = %x, want 128", got)
+	}
+
+	if v, err := b.ADC().Read(1); err != nil || v != 511 {
+		t.Errorf("ADC = %v, %v; want 511", v, err)
+	}
+}
+
+func TestI2C(t *testing.T) {
+	_, b := newArduino(t)
+	defer b.Close()
+	dev, err := i2c.Open(b.I2C(), 0x3C)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 2)
+	if err := dev.ReadReg(0x10, buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf, []byte{0xAB, 0xCD}) {
+		t.Errorf("got %x, want abcd", buf)
+	}
+}
+
+func FuzzRead(f *testing.F) {
+	f.Add([]byte{msgDigital, 0x01, 0, msgAnalog | 1, 0x7F, 0x03, msgSysexStart, sysexI2CReply, 0x3C, 0, msgSysexEnd})
+	f.Add([]byte{msgSysexStart, 1, 2, 3})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		b := &Board{
+			port:   readOnly{bytes.NewReader(data)},
+			sysex:  make(chan []byte, 8),
+			done:   make(chan struct{}),
+			analog: make(map[int]int),
+		}
+		b.read() // returns at the end of the data
+		for _, msg := range drain(b.sysex) {
+			if len(msg) > maxSysex {
+				t.Errorf("sysex message of %d bytes, above the limit", len(msg))
+			}
+		}
+	})
+}
+
+func FuzzCapabilities(f *testing.F) {
+	f.Add([]byte{modeInput, 1, modeOutput, 1, noChannel, modeAnalog, 10, noChannel}, []byte{noChannel, 0})
+	f.Fuzz(func(t *testing.T, caps, mapping []byte) {
+		b := &Board{modes: parseCapabilities(caps)}
+		b.channels = parseMapping(mapping, len(b.modes))
+		for _, pin := range b.channels {
+			if pin >= len(b.modes) {
+				t.Errorf("channel mapped to the pin %d of %d", pin, len(b.modes))
+			}
+		}
+		b.ADC().Resolution()
+	})
+}
+
+type readOnly struct{ io.Reader }
+
+func (readOnly) Write(b []byte) (int, error) { return len(b), nil }
+
+func drain(c chan []byte) [][]byte {
+	var msgs [][]byte
+	for {
+		select {
+		case msg := <-c:
+			msgs = append(msgs, msg)
+		default:
+			return msgs
+		}
+	}
+}
+
+func BenchmarkRead(b *testing.B) {
+	// the stream of a board reporting 6 analog inputs and 2 ports
+	var msgs []byte
+	for ch := byte(0); ch < 6; ch++ {
+		msgs = append(msgs, msgAnalog|ch, 0x7F, 0x03)
+	}
+	msgs = append(msgs, msgDigital, 0x01, 0, msgDigital|1, 0x7F, 1)
+	stream := bytes.Repeat(msgs, 1000)
+	b.SetBytes(int64(len(stream)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		board := &Board{
+			port:   readOnly{bytes.NewReader(stream)},
+			sysex:  make(chan []byte, 8),
+			done:   make(chan struct{}),
+			analog: make(map[int]int),
+		}
+		board.read()
+	}
+}
@@ -0,0 +1,76 @@
+package firmata
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+// I2C returns the I2C bus of the board (A4/A5 on an Uno). Any I2C driver
+// can use it, the transfers are slower than on a native bus.
+func (b *Board) I2C() driver.Opener {
+	return i2cBus{b}
+}
+
+type i2cBus struct{ b *Board }
+
+func (bus i2cBus) Open(addr int, tenbit bool) (driver.Conn, error) {
+	if tenbit {
+		return nil, errors.New("10-bit addresses are not supported")
+	}
+	b := bus.b
+	b.mu.Lock()
+	on := b.i2cOn
+	b.mu.Unlock()
+	if !on {
+		// no delay between the register write and the read
+		if err := b.write(msgSysexStart, sysexI2CConfig, 0, 0, msgSysexEnd); err != nil {
+			return nil, err
+		}
+		b.mu.Lock()
+		b.i2cOn = true
+		b.mu.Unlock()
+	}
+	return &i2cConn{b: b, addr: byte(addr)}, nil
+}
+
+type i2cConn struct {
+	b    *Board
+	addr byte
+}
+
+// Tx writes w and reads len(r) bytes. The firmware reads with a register
+// when w is a single byte, which is the usual register read of the drivers.
+func (c *i2cConn) Tx(w, r []byte) error {
+	if len(r) == 0 {
+		return c.request(i2cWrite, w)
+	}
+	c.b.qmu.Lock()
+	defer c.b.qmu.Unlock()
+	if len(w) > 1 {
+		if err := c.request(i2cWrite, w); err != nil {
+			return err
+		}
+		w = nil
+	}
+	n := []byte{byte(len(r) & 0x7F), byte(len(r) >> 7)}
+	resp, err := c.b.queryLocked(time.Second, sysexI2CRequest, append([]byte{c.addr, i2cReadOnce}, append(encode7(w), n...)...)...)
+	if err != nil {
+		return fmt.Errorf("no response from the device %#x - %v", c.addr, err)
+	}
+	data := decode7(resp)
+	// address and register, then the data
+	if len(data) < 2+len(r) || data[0] != c.addr {
+		return fmt.Errorf("invalid reply from the device %#x", c.addr)
+	}
+	copy(r, data[2:])
+	return nil
+}
+
+func (c *i2cConn) request(mode byte, w []byte) error {
+	return c.b.write(append(append([]byte{msgSysexStart, sysexI2CRequest, c.addr, mode}, encode7(w)...), msgSysexEnd)...)
+}
+
+func (c *i2cConn) Close() error { return nil }
@@ -0,0 +1,208 @@
+package firmata
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/goiot/devices/analog"
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/gpio"
+	"github.com/goiot/devices/pwm"
+)
+
+func (b *Board) supports(pin int, mode byte) error {
+	if pin < 0 || pin >= len(b.modes) {
+		return fmt.Errorf("the board has no pin %d", pin)
+	}
+	if _, ok := b.modes[pin][mode]; !ok {
+		return fmt.Errorf("pin %d doesn't support the mode %#x", pin, mode)
+	}
+	return nil
+}
+
+// setMode checks that the pin supports the mode and sets it.
+func (b *Board) setMode(pin int, mode byte) error {
+	if err := b.supports(pin, mode); err != nil {
+		return err
+	}
+	return b.write(msgSetPinMode, byte(pin), mode)
+}
+
+// writeAnalog sends a 14-bit value to the pin, with an extended analog
+// message above pin 15.
+func (b *Board) writeAnalog(pin, v int) error {
+	if pin < 16 {
+		return b.write(msgAnalog|byte(pin), byte(v&0x7F), byte(v>>7&0x7F))
+	}
+	return b.write(msgSysexStart, sysexExtendedAnalog, byte(pin), byte(v&0x7F), byte(v>>7&0x7F), msgSysexEnd)
+}
+
+// Input configures a pin as a digital input, with the internal pull-up
+// enabled if pullUp is true. The board reports the changes of the pin.
+func (b *Board) Input(pin int, pullUp bool) (gpio.Pin, error) {
+	mode := byte(modeInput)
+	if pullUp {
+		mode = modePullUp
+	}
+	if err := b.setMode(pin, mode); err != nil {
+		return nil, err
+	}
+	if err := b.write(msgReportDigital|byte(pin/8), 1); err != nil {
+		return nil, err
+	}
+	return &digitalPin{b: b, pin: pin}, nil
+}
+
+// Output configures a pin as a digital output set to v.
+func (b *Board) Output(pin int, v int) (gpio.Pin, error) {
+	if err := b.setMode(pin, modeOutput); err != nil {
+		return nil, err
+	}
+	p := &digitalPin{b: b, pin: pin, output: true}
+	if err := p.Write(v); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+type digitalPin struct {
+	b      *Board
+	pin    int
+	output bool
+}
+
+func (p *digitalPin) Read() (int, error) {
+	p.b.mu.Lock()
+	defer p.b.mu.Unlock()
+	ports := &p.b.inputs
+	if p.output {
+		ports = &p.b.outputs
+	}
+	return int(ports[p.pin/8] >> uint(p.pin%8) & 1), p.b.err
+}
+
+func (p *digitalPin) Write(v int) error {
+	if !p.output {
+		return fmt.Errorf("pin %d is not configured as an output", p.pin)
+	}
+	port, bit := p.pin/8, byte(1)<<uint(p.pin%8)
+	p.b.mu.Lock()
+	if v == 0 {
+		p.b.outputs[port] &^= bit
+	} else {
+		p.b.outputs[port] |= bit
+	}
+	state := p.b.outputs[port]
+	p.b.mu.Unlock()
+	return p.b.write(msgDigital|byte(port), state&0x7F, state>>7)
+}
+
+func (p *digitalPin) Close() error {
+	if p.output {
+		return nil
+	}
+	return p.b.write(msgReportDigital|byte(p.pin/8), 0)
+}
+
+// ADC returns the analog inputs of the board, channel 0 is A0. The
+// channels are reported continuously once read.
+func (b *Board) ADC() analog.ADC {
+	return adc{b}
+}
+
+type adc struct{ b *Board }
+
+func (a adc) Read(ch int) (int, error) {
+	b := a.b
+	if err := analog.CheckChannel(ch, a.Channels()); err != nil {
+		return 0, err
+	}
+	b.mu.Lock()
+	v, ok := b.analog[ch]
+	b.mu.Unlock()
+	if ok {
+		return v, nil
+	}
+	if err := b.setMode(b.channels[ch], modeAnalog); err != nil {
+		return 0, err
+	}
+	if err := b.write(msgReportAnalog|byte(ch), 1); err != nil {
+		return 0, err
+	}
+	c := clock.Or(b.Clock)
+	for i := 0; i < 100; i++ {
+		c.Sleep(10 * time.Millisecond)
+		b.mu.Lock()
+		v, ok := b.analog[ch]
+		b.mu.Unlock()
+		if ok {
+			return v, nil
+		}
+	}
+	return 0, fmt.Errorf("channel %d is not reported", ch)
+}
+
+func (a adc) Channels() int { return len(a.b.channels) }
+
+func (a adc) Resolution() int {
+	for _, pin := range a.b.channels {
+		if pin >= 0 {
+			return a.b.modes[pin][modeAnalog]
+		}
+	}
+	return 10
+}
+
+// PWM configures a pin as a PWM output, at the fixed frequency of the
+// board (490Hz or 980Hz on an Uno).
+func (b *Board) PWM(pin int) (pwm.Output, error) {
+	if err := b.setMode(pin, modePWM); err != nil {
+		return nil, err
+	}
+	return &pwmPin{b: b, pin: pin, max: 1<<uint(b.modes[pin][modePWM]) - 1}, nil
+}
+
+type pwmPin struct {
+	b   *Board
+	pin int
+	max int
+}
+
+func (p *pwmPin) SetDuty(duty float64) error {
+	if duty < 0 || duty > 1 {
+		return fmt.Errorf("duty cycle %v is out of range, must be between 0 and 1", duty)
+	}
+	return p.b.writeAnalog(p.pin, int(duty*float64(p.max)+0.5))
+}
+
+// Servo is a hobby servo connected to a pin of the board.
+type Servo struct {
+	b   *Board
+	pin int
+}
+
+// Servo configures a pin to drive a servo, with the pulse widths of the
+// servo at 0 and 180 degrees, usually around 544µs and 2400µs.
+func (b *Board) Servo(pin int, min, max time.Duration) (*Servo, error) {
+	if err := b.supports(pin, modeServo); err != nil {
+		return nil, err
+	}
+	minUS, maxUS := int(min/time.Microsecond), int(max/time.Microsecond)
+	if err := b.write(msgSysexStart, sysexServoConfig, byte(pin),
+		byte(minUS&0x7F), byte(minUS>>7&0x7F), byte(maxUS&0x7F), byte(maxUS>>7&0x7F),
+		msgSysexEnd); err != nil {
+		return nil, err
+	}
+	if err := b.setMode(pin, modeServo); err != nil {
+		return nil, err
+	}
+	return &Servo{b: b, pin: pin}, nil
+}
+
+// SetAngle moves the servo to the angle, between 0 and 180 degrees.
+func (s *Servo) SetAngle(deg float64) error {
+	if deg < 0 || deg > 180 {
+		return fmt.Errorf("angle %v is out of range, must be between 0 and 180", deg)
+	}
+	return s.b.writeAnalog(s.pin, int(deg+0.5))
+}
@@ -0,0 +1,61 @@
+package firmata
+
+// Messages.
+const (
+	msgDigital       = 0x90 // | port, 8 pins as 2 bytes
+	msgAnalog        = 0xE0 // | pin, 14-bit value as 2 bytes
+	msgReportAnalog  = 0xC0 // | analog pin, 1 byte
+	msgReportDigital = 0xD0 // | port, 1 byte
+	msgSysexStart    = 0xF0
+	msgSetPinMode    = 0xF4
+	msgSysexEnd      = 0xF7
+	msgVersion       = 0xF9
+	msgReset         = 0xFF
+)
+
+// Sysex commands.
+const (
+	sysexAnalogMappingQuery    = 0x69
+	sysexAnalogMappingResponse = 0x6A
+	sysexCapabilityQuery       = 0x6B
+	sysexCapabilityResponse    = 0x6C
+	sysexExtendedAnalog        = 0x6F
+	sysexServoConfig           = 0x70
+	sysexI2CRequest            = 0x76
+	sysexI2CReply              = 0x77
+	sysexI2CConfig             = 0x78
+	sysexReportFirmware        = 0x79
+)
+
+// responses maps the queries to the command of their response.
+var responses = map[byte]byte{
+	sysexReportFirmware:     sysexReportFirmware,
+	sysexCapabilityQuery:    sysexCapabilityResponse,
+	sysexAnalogMappingQuery: sysexAnalogMappingResponse,
+	sysexI2CRequest:         sysexI2CReply,
+}
+
+// Pin modes.
+const (
+	modeInput  = 0x00
+	modeOutput = 0x01
+	modeAnalog = 0x02
+	modePWM    = 0x03
+	modeServo  = 0x04
+	modeI2C    = 0x06
+	modePullUp = 0x0B
+)
+
+// I2C request modes, in bits 3-4 of the second byte.
+const (
+	i2cWrite    = 0x00
+	i2cReadOnce = 0x08
+)
+
+// noChannel marks the pins without analog input in the analog mapping
+// and ends the modes of a pin in the capabilities.
+const noChannel = 0x7F
+
+// maxSysex bounds the length of the sysex messages, the capability
+// response of an Arduino Mega is about 1KB.
+const maxSysex = 4096
@@ -0,0 +1,303 @@
+// Package flashloader programs STM32 microcontrollers through the ROM
+// bootloader on their USART (ST application note AN3155), so the firmware
+// of a coprocessor can be updated by the program driving it.
+//
+// The serial port must be configured by the caller at up to 115200 bauds,
+// 8 data bits, even parity and 1 stop bit, e.g. with
+//
+//	stty -F /dev/ttyAMA0 115200 cs8 parenb -parodd -cstopb raw
+package flashloader
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/goiot/devices/gpio"
+)
+
+const (
+	ack   = 0x79
+	nack  = 0x1F
+	start = 0x7F
+
+	cmdGet           = 0x00
+	cmdGetID         = 0x02
+	cmdReadMemory    = 0x11
+	cmdGo            = 0x21
+	cmdWriteMemory   = 0x31
+	cmdErase         = 0x43
+	cmdExtendedErase = 0x44
+)
+
+// FlashBase is the address of the flash on all the STM32s.
+const FlashBase = 0x08000000
+
+// maxChunk is the largest read or write of the bootloader.
+const maxChunk = 256
+
+// deadliner is implemented by the serial ports supporting read timeouts,
+// such as *os.File.
+type deadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// Loader talks to the bootloader of a target. It must be closed if no
+// longer in use.
+type Loader struct {
+	port        io.ReadWriter
+	boot0, nrst gpio.Pin
+	cmds        []byte
+}
+
+// Open restarts the target in its bootloader and synchronizes with it.
+// boot0 and nrst are outputs connected to the BOOT0 and NRST pins of the
+// target; they can be nil if the target is put in its bootloader another
+// way, e.g. with a jumper and a button.
+func Open(port io.ReadWriter, boot0, nrst gpio.Pin) (*Loader, error) {
+	l := &Loader{port: port, boot0: boot0, nrst: nrst}
+	if err := l.restart(1); err != nil {
+		return nil, err
+	}
+	// The bootloader detects the baud rate on the first start byte, and
+	// NACKs the later ones if it is synchronized already.
+	var err error
+	for i := 0; i < 3; i++ {
+		if _, err = port.Write([]byte{start}); err != nil {
+			return nil, err
+		}
+		var b byte
+		if b, err = l.readByte(time.Second); err == nil && (b == ack || b == nack) {
+			break
+		}
+		if err == nil {
+			err = fmt.Errorf("unexpected reply %#x", b)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot synchronize with the bootloader - %v", err)
+	}
+	if err := l.command(cmdGet); err != nil {
+		return nil, err
+	}
+	resp, err := l.readFrame()
+	if err != nil {
+		return nil, err
+	}
+	l.cmds = resp[1:] // resp[0] is the version of the bootloader
+	return l, nil
+}
+
+// restart resets the target with BOOT0 at v.
+func (l *Loader) restart(v int) error {
+	if l.boot0 != nil {
+		if err := l.boot0.Write(v); err != nil {
+			return err
+		}
+	}
+	if l.nrst == nil {
+		return nil
+	}
+	if err := l.nrst.Write(0); err != nil {
+		return err
+	}
+	time.Sleep(10 * time.Millisecond)
+	if err := l.nrst.Write(1); err != nil {
+		return err
+	}
+	time.Sleep(50 * time.Millisecond) // the bootloader starts
+	return nil
+}
+
+func (l *Loader) readByte(timeout time.Duration) (byte, error) {
+	if d, ok := l.port.(deadliner); ok {
+		d.SetReadDeadline(time.Now().Add(timeout))
+	}
+	var b [1]byte
+	if _, err := io.ReadFull(l.port, b[:]); err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (l *Loader) waitACK(timeout time.Duration) error {
+	b, err := l.readByte(timeout)
+	if err != nil {
+		return err
+	}
+	switch b {
+	case ack:
+		return nil
+	case nack:
+		return errors.New("the bootloader refused the command, the flash may be read protected")
+	}
+	return fmt.Errorf("unexpected reply %#x", b)
+}
+
+// send writes b followed by its XOR checksum and waits for the ACK.
+func (l *Loader) send(timeout time.Duration, b ...byte) error {
+	sum := byte(0)
+	if len(b) == 1 {
+		sum = 0xFF
+	}
+	for _, v := range b {
+		sum ^= v
+	}
+	if _, err := l.port.Write(append(b, sum)); err != nil {
+		return err
+	}
+	return l.waitACK(timeout)
+}
+
+func (l *Loader) command(cmd byte) error {
+	if err := l.send(time.Second, cmd); err != nil {
+		return fmt.Errorf("command %#x failed - %v", cmd, err)
+	}
+	return nil
+}
+
+// readFrame reads the replies made of a length, the data and an ACK.
+func (l *Loader) readFrame() ([]byte, error) {
+	n, err := l.readByte(time.Second)
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, int(n)+1)
+	if _, err := io.ReadFull(l.port, b); err != nil {
+		return nil, err
+	}
+	return b, l.waitACK(time.Second)
+}
+
+func (l *Loader) supports(cmd byte) bool {
+	return bytes.IndexByte(l.cmds, cmd) >= 0
+}
+
+func (l *Loader) address(addr uint32) error {
+	return l.send(time.Second, byte(addr>>24), byte(addr>>16), byte(addr>>8), byte(addr))
+}
+
+// ID returns the product ID of the target, e.g. 0x410 for the
+// STM32F103 medium-density line.
+func (l *Loader) ID() (uint16, error) {
+	if err := l.command(cmdGetID); err != nil {
+		return 0, err
+	}
+	b, err := l.readFrame()
+	if err != nil {
+		return 0, err
+	}
+	if len(b) != 2 {
+		return 0, fmt.Errorf("invalid product ID %x", b)
+	}
+	return uint16(b[0])<<8 | uint16(b[1]), nil
+}
+
+// Read reads len(b) bytes of memory starting at addr.
+func (l *Loader) Read(addr uint32, b []byte) error {
+	for len(b) > 0 {
+		n := len(b)
+		if n > maxChunk {
+			n = maxChunk
+		}
+		if err := l.command(cmdReadMemory); err != nil {
+			return err
+		}
+		if err := l.address(addr); err != nil {
+			return err
+		}
+		if err := l.send(time.Second, byte(n-1)); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(l.port, b[:n]); err != nil {
+			return err
+		}
+		b = b[n:]
+		addr += uint32(n)
+	}
+	return nil
+}
+
+// Write writes data to memory starting at addr, which must be a multiple
+// of 4. The flash must have been erased.
+func (l *Loader) Write(addr uint32, data []byte) error {
+	if addr%4 != 0 {
+		return fmt.Errorf("address %#x is not aligned on 4 bytes", addr)
+	}
+	for len(data) > 0 {
+		n := len(data)
+		if n > maxChunk {
+			n = maxChunk
+		}
+		chunk := data[:n]
+		for len(chunk)%4 != 0 {
+			chunk = append(chunk[:len(chunk):len(chunk)], 0xFF)
+		}
+		if err := l.command(cmdWriteMemory); err != nil {
+			return err
+		}
+		if err := l.address(addr); err != nil {
+			return err
+		}
+		if err := l.send(time.Second, append([]byte{byte(len(chunk) - 1)}, chunk...)...); err != nil {
+			return fmt.Errorf("cannot write at %#x - %v", addr, err)
+		}
+		data = data[n:]
+		addr += uint32(n)
+	}
+	return nil
+}
+
+// Erase erases the whole flash of the target. It can take several
+// seconds on the larger parts.
+func (l *Loader) Erase() error {
+	if l.supports(cmdExtendedErase) {
+		if err := l.command(cmdExtendedErase); err != nil {
+			return err
+		}
+		return l.send(30*time.Second, 0xFF, 0xFF)
+	}
+	if err := l.command(cmdErase); err != nil {
+		return err
+	}
+	return l.send(30*time.Second, 0xFF)
+}
+
+// Program erases the flash, writes the firmware at FlashBase and verifies
+// it. The firmware is a raw binary, as produced by
+// arm-none-eabi-objcopy -O binary.
+func (l *Loader) Program(firmware []byte) error {
+	if err := l.Erase(); err != nil {
+		return err
+	}
+	if err := l.Write(FlashBase, firmware); err != nil {
+		return err
+	}
+	got := make([]byte, len(firmware))
+	if err := l.Read(FlashBase, got); err != nil {
+		return err
+	}
+	for i := range firmware {
+		if got[i] != firmware[i] {
+			return fmt.Errorf("verification failed at %#x: wrote %#x, read %#x", FlashBase+i, firmware[i], got[i])
+		}
+	}
+	return nil
+}
+
+// Go jumps to the code at addr, usually FlashBase. The loader can't be
+// used anymore after a successful call.
+func (l *Loader) Go(addr uint32) error {
+	if err := l.command(cmdGo); err != nil {
+		return err
+	}
+	return l.address(addr)
+}
+
+// Close restarts the target from its flash if the BOOT0 and NRST pins are
+// connected.
+func (l *Loader) Close() error {
+	return l.restart(0)
+}
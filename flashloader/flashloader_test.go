@@ -0,0 +1,96 @@
+package flashloader
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/goiot/devices/gpio"
+)
+
+// port is a fake bootloader, it records the writes and replies with the
+// queued responses.
+type port struct {
+	w    bytes.Buffer
+	resp bytes.Buffer
+}
+
+func (p *port) Write(b []byte) (int, error) { return p.w.Write(b) }
+func (p *port) Read(b []byte) (int, error)  { return p.resp.Read(b) }
+
+type pin struct{ writes []int }
+
+func (p *pin) Read() (int, error) { return 0, nil }
+func (p *pin) Write(v int) error  { p.writes = append(p.writes, v); return nil }
+func (p *pin) Close() error       { return nil }
+
+func openLoader(t *testing.T, boot0, nrst gpio.Pin) (*Loader, *port) {
+	p := &port{}
+	p.resp.Write([]byte{
+		ack,                                                                  // start
+		ack, 4, 0x31, cmdGet, cmdGetID, cmdReadMemory, cmdExtendedErase, ack, // get
+	})
+	l, err := Open(p, boot0, nrst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.w.Reset()
+	return l, p
+}
+
+func TestOpen(t *testing.T) {
+	boot0, nrst := &pin{}, &pin{}
+	l, p := openLoader(t, boot0, nrst)
+	if len(boot0.writes) != 1 || boot0.writes[0] != 1 {
+		t.Errorf("BOOT0 writes = %v, want [1]", boot0.writes)
+	}
+	if len(nrst.writes) != 2 || nrst.writes[0] != 0 || nrst.writes[1] != 1 {
+		t.Errorf("NRST writes = %v, want a pulse", nrst.writes)
+	}
+	if !l.supports(cmdExtendedErase) || l.supports(cmdErase) {
+		t.Errorf("commands = %x", l.cmds)
+	}
+
+	p.resp.Write([]byte{ack, 1, 0x04, 0x10, ack})
+	id, err := l.ID()
+	if err != nil || id != 0x410 {
+		t.Errorf("ID = %#x, %v; want 0x410", id, err)
+	}
+	if want := []byte{cmdGetID, 0xFD}; !bytes.Equal(p.w.Bytes(), want) {
+		t.Errorf("wrote %x, want %x", p.w.Bytes(), want)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	l, p := openLoader(t, nil, nil)
+	p.resp.Write([]byte{ack, ack, ack})
+	if err := l.Write(FlashBase, []byte{1, 2, 3, 4, 5}); err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{
+		cmdWriteMemory, 0xCE,
+		0x08, 0x00, 0x00, 0x00, 0x08,
+		7, 1, 2, 3, 4, 5, 0xFF, 0xFF, 0xFF, 0xF9, // padded to 8 bytes
+	}
+	if !bytes.Equal(p.w.Bytes(), want) {
+		t.Errorf("wrote %x, want %x", p.w.Bytes(), want)
+	}
+}
+
+func TestProgram(t *testing.T) {
+	l, p := openLoader(t, nil, nil)
+	fw := []byte{0xDE, 0xAD, 0xBE, 0xEF}
+	p.resp.Write([]byte{ack, ack})      // erase
+	p.resp.Write([]byte{ack, ack, ack}) // write
+	p.resp.Write([]byte{ack, ack, ack, 0xDE, 0xAD, 0xBE, 0xEE})
+	if err := l.Program(fw); err == nil {
+		t.Fatal("expected a verification error")
+	}
+	if want := []byte{cmdExtendedErase, 0xBB, 0xFF, 0xFF, 0x00}; !bytes.HasPrefix(p.w.Bytes(), want) {
+		t.Errorf("erase = %x, want %x", p.w.Bytes()[:5], want)
+	}
+
+	p.resp.Write([]byte{nack})
+	if err := l.Erase(); err == nil {
+		t.Error("expected an error on NACK")
+	}
+}
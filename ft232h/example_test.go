@@ -0,0 +1,25 @@
+package ft232h_test
+
+import (
+	"github.com/goiot/devices/ft232h"
+	"github.com/goiot/devices/monochromeoled"
+)
+
+func Example() {
+	bridge, err := ft232h.Open()
+	if err != nil {
+		panic(err)
+	}
+	defer bridge.Close()
+
+	// any I2C driver can use the bridge
+	oled, err := monochromeoled.Open(bridge.I2C())
+	if err != nil {
+		panic(err)
+	}
+	defer oled.Close()
+
+	if err := oled.Clear(); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,268 @@
+// Package ft232h implements a backend for the FTDI FT232H USB to
+// I2C/SPI/GPIO bridge. It allows the drivers in this repo to run from a
+// desktop or laptop, which makes developing display and sensor code
+// possible without an embedded board.
+//
+// The bridge has a single MPSSE engine on its D0-D3 pins which is used
+// either as an I2C or as a SPI master, and 12 GPIO pins: D4-D7 and C0-C7.
+package ft232h
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/goiot/devices/gpio"
+)
+
+// Pin identifies one of the GPIO pins of the bridge.
+type Pin int
+
+const (
+	D4 Pin = 4 + iota
+	D5
+	D6
+	D7
+	C0
+	C1
+	C2
+	C3
+	C4
+	C5
+	C6
+	C7
+)
+
+func (p Pin) String() string {
+	if p >= C0 {
+		return fmt.Sprintf("C%d", p-C0)
+	}
+	return fmt.Sprintf("D%d", p)
+}
+
+type mode int
+
+const (
+	modeNone mode = iota
+	modeI2C
+	modeSPI
+)
+
+// FT232H represents an FT232H bridge. Its I2C, SPI and GPIO functions
+// can be used from multiple goroutines.
+type FT232H struct {
+	mu    sync.Mutex
+	port  io.ReadWriteCloser
+	mode  mode
+	conns int // open I2C or SPI connections
+
+	lowVal, lowDir   byte // ADBUS state
+	highVal, highDir byte // ACBUS state
+}
+
+// Open opens the first FT232H connected to the host. The ftdi_sio kernel
+// driver is detached from the bridge while it is open. Once not in use,
+// it needs to be closed by calling Close.
+func Open() (*FT232H, error) {
+	port, err := openUSB(vendorFTDI, productFT232H)
+	if err != nil {
+		return nil, err
+	}
+	d, err := newFT232H(port)
+	if err != nil {
+		port.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+func newFT232H(port io.ReadWriteCloser) (*FT232H, error) {
+	d := &FT232H{port: port}
+	// An invalid opcode is echoed back by the engine, which lets us
+	// discard whatever is left from a previous session.
+	if _, err := port.Write([]byte{mpsseBogusCommand}); err != nil {
+		return nil, err
+	}
+	resp := make([]byte, 2)
+	if _, err := io.ReadFull(port, resp); err != nil {
+		return nil, err
+	}
+	if resp[0] != mpsseBadCommand || resp[1] != mpsseBogusCommand {
+		return nil, fmt.Errorf("cannot synchronize with the MPSSE engine, got %#x", resp)
+	}
+	if _, err := port.Write([]byte{
+		mpsseDisableDiv5,
+		mpsseDisableAdaptive,
+		mpsseLoopbackOff,
+		mpsseSetBitsLow, 0, 0,
+		mpsseSetBitsHigh, 0, 0,
+	}); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// I2C returns an I2C opener using the bridge, D0 is SCL and D1 is SDA.
+// D2 needs to be wired to D1 to read back SDA, and SCL and SDA need
+// external pull-up resistors.
+func (d *FT232H) I2C() *I2C {
+	return &I2C{d: d, Speed: 100000}
+}
+
+// SPI returns a SPI opener using the bridge, D0 is SCK, D1 is MOSI, D2
+// is MISO and D3 is the active low chip select.
+func (d *FT232H) SPI() *SPI {
+	return &SPI{d: d}
+}
+
+// Input configures p as an input pin.
+func (d *FT232H) Input(p Pin) (gpio.Pin, error) {
+	return d.pin(p, false, 0)
+}
+
+// Output configures p as an output pin initially driven to v.
+func (d *FT232H) Output(p Pin, v int) (gpio.Pin, error) {
+	return d.pin(p, true, v)
+}
+
+func (d *FT232H) pin(p Pin, out bool, v int) (gpio.Pin, error) {
+	if p < D4 || p > C7 {
+		return nil, fmt.Errorf("%d is not a GPIO pin", p)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	val, dir := d.gpioState(p)
+	mask := p.mask()
+	*dir &^= mask
+	if out {
+		*dir |= mask
+		d.setLevel(val, mask, v)
+	}
+	if err := d.flushGPIO(p); err != nil {
+		return nil, err
+	}
+	return &pin{d: d, p: p, out: out}, nil
+}
+
+// Close closes the bridge.
+func (d *FT232H) Close() error {
+	return d.port.Close()
+}
+
+func (p Pin) mask() byte {
+	if p >= C0 {
+		return 1 << uint(p-C0)
+	}
+	return 1 << uint(p)
+}
+
+func (d *FT232H) gpioState(p Pin) (val, dir *byte) {
+	if p >= C0 {
+		return &d.highVal, &d.highDir
+	}
+	return &d.lowVal, &d.lowDir
+}
+
+func (d *FT232H) setLevel(val *byte, mask byte, v int) {
+	if v == 0 {
+		*val &^= mask
+	} else {
+		*val |= mask
+	}
+}
+
+func (d *FT232H) flushGPIO(p Pin) error {
+	cmd := []byte{mpsseSetBitsLow, d.lowVal, d.lowDir}
+	if p >= C0 {
+		cmd = []byte{mpsseSetBitsHigh, d.highVal, d.highDir}
+	}
+	_, err := d.port.Write(cmd)
+	return err
+}
+
+// acquire switches the MPSSE engine to m. It fails if connections are
+// open in another mode.
+func (d *FT232H) acquire(m mode, setup []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.mode != m && d.conns > 0 {
+		return errors.New("the MPSSE engine is in use by another bus")
+	}
+	if _, err := d.port.Write(setup); err != nil {
+		return err
+	}
+	d.mode = m
+	d.conns++
+	return nil
+}
+
+func (d *FT232H) release() {
+	d.mu.Lock()
+	d.conns--
+	d.mu.Unlock()
+}
+
+// setLow returns the command setting the serial engine pins of ADBUS
+// while leaving D4-D7 untouched.
+func (d *FT232H) setLow(val, dir byte) []byte {
+	return []byte{
+		mpsseSetBitsLow,
+		d.lowVal&^lowMask | val,
+		d.lowDir&^lowMask | dir,
+	}
+}
+
+// exec sends cmd to the engine and reads n bytes of response.
+func (d *FT232H) exec(cmd []byte, n int) ([]byte, error) {
+	if n > 0 {
+		cmd = append(cmd, mpsseSendImmediate)
+	}
+	if _, err := d.port.Write(cmd); err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	resp := make([]byte, n)
+	if _, err := io.ReadFull(d.port, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+type pin struct {
+	d   *FT232H
+	p   Pin
+	out bool
+}
+
+func (p *pin) Read() (int, error) {
+	p.d.mu.Lock()
+	defer p.d.mu.Unlock()
+	op := byte(mpsseReadBitsLow)
+	if p.p >= C0 {
+		op = mpsseReadBitsHigh
+	}
+	resp, err := p.d.exec([]byte{op}, 1)
+	if err != nil {
+		return 0, err
+	}
+	if resp[0]&p.p.mask() != 0 {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (p *pin) Write(v int) error {
+	if !p.out {
+		return fmt.Errorf("%v is not configured as an output", p.p)
+	}
+	p.d.mu.Lock()
+	defer p.d.mu.Unlock()
+	val, _ := p.d.gpioState(p.p)
+	p.d.setLevel(val, p.p.mask(), v)
+	return p.d.flushGPIO(p.p)
+}
+
+func (p *pin) Close() error { return nil }
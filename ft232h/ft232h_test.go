@@ -0,0 +1,148 @@
+package ft232h
+
+import (
+	"bytes"
+	"testing"
+
+	"golang.org/x/exp/io/i2c"
+	"golang.org/x/exp/io/spi"
+)
+
+// port is a fake MPSSE engine, it records the commands and replies
+// with the queued responses.
+type port struct {
+	w    bytes.Buffer
+	resp bytes.Buffer
+}
+
+func (p *port) Write(b []byte) (int, error) { return p.w.Write(b) }
+func (p *port) Read(b []byte) (int, error)  { return p.resp.Read(b) }
+func (p *port) Close() error                { return nil }
+
+func openFT232H(t *testing.T) (*FT232H, *port) {
+	p := &port{}
+	p.resp.Write([]byte{mpsseBadCommand, mpsseBogusCommand})
+	d, err := newFT232H(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.w.Reset()
+	return d, p
+}
+
+func TestSync(t *testing.T) {
+	p := &port{}
+	p.resp.Write([]byte{0x00, 0x00})
+	if _, err := newFT232H(p); err == nil {
+		t.Fatal("expected an error when the engine doesn't echo the bogus command")
+	}
+}
+
+func TestI2CWrite(t *testing.T) {
+	d, p := openFT232H(t)
+	dev, err := i2c.Open(d.I2C(), 0x3C)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.w.Reset()
+	p.resp.Write([]byte{0x00, 0x00, 0x00}) // address and 2 data bytes acknowledged
+	if err := dev.Write([]byte{0xAE, 0xAF}); err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{mpsseWriteBytesNeg, 0, 0, 0x3C << 1}
+	if !bytes.Contains(p.w.Bytes(), want) {
+		t.Errorf("address byte not found in %x", p.w.Bytes())
+	}
+	if got := bytes.Count(p.w.Bytes(), []byte{mpsseReadBitsPos, 0}); got != 3 {
+		t.Errorf("got %d ACK reads, want 3", got)
+	}
+	if last := p.w.Bytes()[p.w.Len()-1]; last != mpsseSendImmediate {
+		t.Errorf("last command = %#x, want send immediate", last)
+	}
+}
+
+func TestI2CNoDevice(t *testing.T) {
+	d, p := openFT232H(t)
+	dev, err := i2c.Open(d.I2C(), 0x3C)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.resp.Write([]byte{0x01, 0x01})
+	if err := dev.Write([]byte{0xAE}); err == nil {
+		t.Fatal("expected an error when the address is not acknowledged")
+	}
+}
+
+func TestI2CRead(t *testing.T) {
+	d, p := openFT232H(t)
+	dev, err := i2c.Open(d.I2C(), 0x4C)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// write address, register, read address, then two data bytes
+	p.resp.Write([]byte{0x00, 0x00, 0x00, 0x12, 0x34})
+	buf := make([]byte, 2)
+	if err := dev.ReadReg(0x03, buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf, []byte{0x12, 0x34}) {
+		t.Errorf("got %x, want 1234", buf)
+	}
+	if !bytes.Contains(p.w.Bytes(), []byte{mpsseWriteBytesNeg, 0, 0, 0x4C<<1 | 1}) {
+		t.Errorf("read address not found in %x", p.w.Bytes())
+	}
+}
+
+func TestSPIBusy(t *testing.T) {
+	d, _ := openFT232H(t)
+	if _, err := i2c.Open(d.I2C(), 0x3C); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := spi.Open(d.SPI()); err == nil {
+		t.Fatal("expected an error opening SPI while I2C is in use")
+	}
+}
+
+func TestSPITx(t *testing.T) {
+	d, p := openFT232H(t)
+	dev, err := spi.Open(d.SPI())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.w.Reset()
+	p.resp.Write([]byte{0xAA, 0xBB})
+	r := make([]byte, 2)
+	if err := dev.Tx([]byte{0x01, 0x02}, r); err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{
+		mpsseSetBitsLow, 0x00, pinSCK | pinDO | pinCS, // CS low
+		mpsseRWBytesNegPos, 1, 0, 0x01, 0x02,
+		mpsseSetBitsLow, pinCS, pinSCK | pinDO | pinCS, // CS high
+		mpsseSendImmediate,
+	}
+	if !bytes.Equal(p.w.Bytes(), want) {
+		t.Errorf("got %x, want %x", p.w.Bytes(), want)
+	}
+	if !bytes.Equal(r, []byte{0xAA, 0xBB}) {
+		t.Errorf("got %x, want aabb", r)
+	}
+}
+
+func TestGPIO(t *testing.T) {
+	d, p := openFT232H(t)
+	pin, err := d.Output(C2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pin.Write(0); err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{
+		mpsseSetBitsHigh, 0x04, 0x04,
+		mpsseSetBitsHigh, 0x00, 0x04,
+	}
+	if !bytes.Equal(p.w.Bytes(), want) {
+		t.Errorf("got %x, want %x", p.w.Bytes(), want)
+	}
+}
@@ -0,0 +1,140 @@
+package ft232h
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+// I2C is an I2C driver using the FT232H as the bus master.
+type I2C struct {
+	// Speed is the SCL frequency in Hz. Defaults to 100kHz.
+	Speed int
+
+	d *FT232H
+}
+
+// Open opens a connection to the device at addr.
+func (b *I2C) Open(addr int, tenbit bool) (driver.Conn, error) {
+	if tenbit {
+		return nil, errors.New("10-bit addresses are not supported")
+	}
+	speed := b.Speed
+	if speed <= 0 {
+		speed = 100000
+	}
+	// Three phase clocking keeps SDA valid on both SCL edges, which
+	// stretches the period by half.
+	div := baseClock/(3*speed) - 1
+	if div < 0 || div > 0xFFFF {
+		return nil, fmt.Errorf("unsupported I2C speed %dHz", speed)
+	}
+	setup := []byte{
+		mpsseEnable3Phase,
+		mpsseSetDivisor, byte(div), byte(div >> 8),
+		// Only drive the lines low (open drain), the pull-ups release them.
+		mpsseDriveZero, pinSCK | pinDO | pinDI, 0,
+	}
+	setup = append(setup, b.d.setLow(pinSCK|pinDO, pinSCK|pinDO)...)
+	if err := b.d.acquire(modeI2C, setup); err != nil {
+		return nil, err
+	}
+	return &i2cConn{d: b.d, addr: byte(addr)}, nil
+}
+
+type i2cConn struct {
+	d    *FT232H
+	addr byte
+}
+
+// Tx writes w and then reads into r, using a repeated start between the two.
+func (c *i2cConn) Tx(w, r []byte) error {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	if c.d.mode != modeI2C {
+		return errors.New("the MPSSE engine is not in I2C mode")
+	}
+
+	var cmd []byte
+	var acks int // bytes of response holding an ACK bit
+	if len(w) > 0 || len(r) == 0 {
+		cmd = c.start(cmd)
+		cmd = c.writeByte(cmd, c.addr<<1)
+		for _, b := range w {
+			cmd = c.writeByte(cmd, b)
+		}
+		acks = 1 + len(w)
+	}
+	if len(r) > 0 {
+		cmd = c.start(cmd)
+		cmd = c.writeByte(cmd, c.addr<<1|1)
+		acks++
+		for i := range r {
+			cmd = c.readByte(cmd, i == len(r)-1)
+		}
+	}
+	cmd = c.stop(cmd)
+
+	resp, err := c.d.exec(cmd, acks+len(r))
+	if err != nil {
+		return err
+	}
+	for i, ack := range resp[:acks] {
+		if ack&0x01 != 0 {
+			if i == 0 || (len(w) > 0 && i == len(w)+1) {
+				return fmt.Errorf("no device responding at address %#x", c.addr)
+			}
+			return fmt.Errorf("device %#x did not acknowledge byte %d", c.addr, i)
+		}
+	}
+	copy(r, resp[acks:])
+	return nil
+}
+
+// repeat holds each line state long enough to meet the I2C setup and
+// hold times at any supported speed.
+const repeat = 4
+
+func (c *i2cConn) set(cmd []byte, val, dir byte) []byte {
+	for i := 0; i < repeat; i++ {
+		cmd = append(cmd, c.d.setLow(val, dir)...)
+	}
+	return cmd
+}
+
+func (c *i2cConn) start(cmd []byte) []byte {
+	cmd = c.set(cmd, pinSCK|pinDO, pinSCK|pinDO)
+	cmd = c.set(cmd, pinSCK, pinSCK|pinDO)
+	return c.set(cmd, 0, pinSCK|pinDO)
+}
+
+func (c *i2cConn) stop(cmd []byte) []byte {
+	cmd = c.set(cmd, 0, pinSCK|pinDO)
+	cmd = c.set(cmd, pinSCK, pinSCK|pinDO)
+	return c.set(cmd, pinSCK|pinDO, pinSCK|pinDO)
+}
+
+func (c *i2cConn) writeByte(cmd []byte, b byte) []byte {
+	cmd = append(cmd, mpsseWriteBytesNeg, 0, 0, b)
+	// release SDA and clock the ACK bit in
+	cmd = append(cmd, c.d.setLow(0, pinSCK)...)
+	cmd = append(cmd, mpsseReadBitsPos, 0)
+	return append(cmd, c.d.setLow(0, pinSCK|pinDO)...)
+}
+
+func (c *i2cConn) readByte(cmd []byte, last bool) []byte {
+	cmd = append(cmd, c.d.setLow(0, pinSCK)...)
+	cmd = append(cmd, mpsseReadBytesPos, 0, 0)
+	cmd = append(cmd, c.d.setLow(0, pinSCK|pinDO)...)
+	ack := byte(0x00)
+	if last {
+		ack = 0xFF // NACK the last byte to end the read
+	}
+	return append(cmd, mpsseWriteBitsNeg, 0, ack)
+}
+
+func (c *i2cConn) Close() error {
+	c.d.release()
+	return nil
+}
@@ -0,0 +1,40 @@
+package ft232h
+
+// MPSSE opcodes, see FTDI AN_108 "Command Processor for MPSSE and MCU
+// Host Bus Emulation Modes".
+const (
+	mpsseWriteBytesNeg    = 0x11 // clock bytes out on -ve edge, MSB first
+	mpsseWriteBitsNeg     = 0x13 // clock bits out on -ve edge, MSB first
+	mpsseReadBytesPos     = 0x20 // clock bytes in on +ve edge, MSB first
+	mpsseReadBitsPos      = 0x22 // clock bits in on +ve edge, MSB first
+	mpsseWriteBytesPos    = 0x10 // clock bytes out on +ve edge, MSB first
+	mpsseRWBytesNegPos    = 0x31 // out on -ve edge, in on +ve edge, MSB first
+	mpsseRWBytesPosNeg    = 0x34 // out on +ve edge, in on -ve edge, MSB first
+	mpsseLSBFirst         = 0x08 // or'ed with the clocking opcodes
+	mpsseSetBitsLow       = 0x80 // value, direction of ADBUS0-7
+	mpsseReadBitsLow      = 0x81
+	mpsseSetBitsHigh      = 0x82 // value, direction of ACBUS0-7
+	mpsseReadBitsHigh     = 0x83
+	mpsseLoopbackOff      = 0x85
+	mpsseSetDivisor       = 0x86
+	mpsseSendImmediate    = 0x87
+	mpsseDisableDiv5      = 0x8A
+	mpsseEnable3Phase     = 0x8C
+	mpsseDisable3Phase    = 0x8D
+	mpsseDisableAdaptive  = 0x97
+	mpsseDriveZero        = 0x9E
+	mpsseBadCommand       = 0xFA // echoed by the engine after an invalid opcode
+	mpsseBogusCommand     = 0xAA // used to synchronize with the engine
+	mpsseMaxTransferBytes = 65536
+)
+
+// ADBUS pin assignments in the MPSSE serial modes.
+const (
+	pinSCK  = 1 << 0 // SCL in I2C mode
+	pinDO   = 1 << 1 // MOSI, SDA out in I2C mode
+	pinDI   = 1 << 2 // MISO, SDA in in I2C mode, wire it to D1
+	pinCS   = 1 << 3
+	lowMask = 0x0F // pins owned by the serial engine
+)
+
+const baseClock = 60000000 // clock of the MPSSE engine once divide by 5 is disabled
@@ -0,0 +1,140 @@
+package ft232h
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/exp/io/spi/driver"
+)
+
+// SPI is a SPI driver using the FT232H as the bus master.
+type SPI struct {
+	d *FT232H
+}
+
+// Open opens a connection to the device selected by D3.
+// The default configuration is mode 0 at 1MHz, MSB first.
+func (b *SPI) Open() (driver.Conn, error) {
+	c := &spiConn{d: b.d, speed: 1000000}
+	setup, err := c.setup()
+	if err != nil {
+		return nil, err
+	}
+	if err := b.d.acquire(modeSPI, setup); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+type spiConn struct {
+	d        *FT232H
+	mode     int
+	speed    int
+	lsb      bool
+	csChange bool
+}
+
+func (c *spiConn) idle() byte {
+	if c.mode&0x2 != 0 { // CPOL=1, the clock idles high
+		return pinCS | pinSCK
+	}
+	return pinCS
+}
+
+func (c *spiConn) setup() ([]byte, error) {
+	div := baseClock/(2*c.speed) - 1
+	if div < 0 || div > 0xFFFF {
+		return nil, fmt.Errorf("unsupported SPI speed %dHz", c.speed)
+	}
+	cmd := []byte{
+		mpsseDisable3Phase,
+		mpsseSetDivisor, byte(div), byte(div >> 8),
+	}
+	return append(cmd, c.d.setLow(c.idle(), pinSCK|pinDO|pinCS)...), nil
+}
+
+// Configure configures the connection, the FT232H only supports 8 bits per word.
+func (c *spiConn) Configure(k, v int) error {
+	switch k {
+	case driver.Mode:
+		if v < 0 || v > 3 {
+			return fmt.Errorf("invalid SPI mode %d", v)
+		}
+		c.mode = v
+	case driver.MaxSpeed:
+		c.speed = v
+	case driver.Order:
+		c.lsb = v != 0
+	case driver.Bits:
+		if v != 8 {
+			return fmt.Errorf("%d bits per word is not supported", v)
+		}
+		return nil
+	case driver.CSChange:
+		c.csChange = v != 0
+		return nil
+	case driver.Delay:
+		return errors.New("inter-frame delays are not supported")
+	default:
+		return fmt.Errorf("unknown configuration key %d", k)
+	}
+	cmd, err := c.setup()
+	if err != nil {
+		return err
+	}
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	_, err = c.d.port.Write(cmd)
+	return err
+}
+
+// Tx asserts the chip select, writes w while reading into r, and
+// de-asserts the chip select unless CSChange is set.
+func (c *spiConn) Tx(w, r []byte) error {
+	if r != nil && len(w) != len(r) {
+		return errors.New("len(w) must be equal to len(r)")
+	}
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	if c.d.mode != modeSPI {
+		return errors.New("the MPSSE engine is not in SPI mode")
+	}
+
+	// Modes 0 and 3 sample on the rising edge, modes 1 and 2 on the falling one.
+	op := byte(mpsseRWBytesNegPos)
+	if c.mode == 1 || c.mode == 2 {
+		op = mpsseRWBytesPosNeg
+	}
+	if r == nil {
+		op &^= 0x24 // write only, 0x11 or 0x10
+	}
+	if c.lsb {
+		op |= mpsseLSBFirst
+	}
+
+	idle := c.idle()
+	cmd := c.d.setLow(idle&^pinCS, pinSCK|pinDO|pinCS)
+	for len(w) > 0 {
+		n := len(w)
+		if n > mpsseMaxTransferBytes {
+			n = mpsseMaxTransferBytes
+		}
+		cmd = append(cmd, op, byte(n-1), byte((n-1)>>8))
+		cmd = append(cmd, w[:n]...)
+		w = w[n:]
+	}
+	if !c.csChange {
+		cmd = append(cmd, c.d.setLow(idle, pinSCK|pinDO|pinCS)...)
+	}
+	resp, err := c.d.exec(cmd, len(r))
+	if err != nil {
+		return err
+	}
+	copy(r, resp)
+	return nil
+}
+
+func (c *spiConn) Close() error {
+	c.d.release()
+	return nil
+}
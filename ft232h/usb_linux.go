@@ -0,0 +1,255 @@
+//go:build linux && !tinygo
+// +build linux,!tinygo
+
+package ft232h
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const (
+	vendorFTDI    = 0x0403
+	productFT232H = 0x6014
+
+	// FTDI vendor requests.
+	sioReset      = 0x00
+	sioSetLatency = 0x09
+	sioSetBitmode = 0x0B
+
+	sioResetPurgeRX = 1
+	sioResetPurgeTX = 2
+	bitmodeReset    = 0x00
+	bitmodeMPSSE    = 0x02
+
+	epOut       = 0x02
+	epIn        = 0x81
+	packetSize  = 512 // high speed bulk packets
+	usbTimeout  = 1000
+	readTimeout = time.Second
+)
+
+type ctrlTransfer struct {
+	requestType uint8
+	request     uint8
+	value       uint16
+	index       uint16
+	length      uint16
+	timeout     uint32
+	data        unsafe.Pointer
+}
+
+type bulkTransfer struct {
+	ep      uint32
+	length  uint32
+	timeout uint32
+	data    unsafe.Pointer
+}
+
+type usbIoctl struct {
+	ifno int32
+	code int32
+	data unsafe.Pointer
+}
+
+var (
+	usbdevfsControl      = usbIoc(3, 0, unsafe.Sizeof(ctrlTransfer{}))
+	usbdevfsBulk         = usbIoc(3, 2, unsafe.Sizeof(bulkTransfer{}))
+	usbdevfsClaim        = usbIoc(2, 15, 4)
+	usbdevfsRelease      = usbIoc(2, 16, 4)
+	usbdevfsIoctl        = usbIoc(3, 18, unsafe.Sizeof(usbIoctl{}))
+	usbdevfsDisconnect   = usbIoc(0, 22, 0)
+	usbdevfsConnect      = usbIoc(0, 23, 0)
+	sysfsUSBDevices      = "/sys/bus/usb/devices"
+	errDeviceNotFound    = errors.New("no FT232H found, check the USB connection")
+	errDeviceMissingNode = errors.New("cannot find the usbfs node of the FT232H")
+)
+
+func usbIoc(dir, nr, size uintptr) uintptr {
+	return dir<<30 | size<<16 | 'U'<<8 | nr
+}
+
+// usbPort is the bulk pipe to the MPSSE engine of interface A, using usbfs.
+type usbPort struct {
+	f   *os.File
+	buf []byte // received bytes not yet read
+}
+
+// openUSB finds the first device matching vid:pid and puts it in MPSSE mode.
+func openUSB(vid, pid uint16) (*usbPort, error) {
+	dev, err := findUSB(vid, pid)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(dev, os.O_RDWR, os.ModeDevice)
+	if err != nil {
+		return nil, err
+	}
+	p := &usbPort{f: f}
+	// Detach ftdi_sio, it fails with ENODATA if no driver is bound.
+	detach := usbIoctl{ifno: 0, code: int32(usbdevfsDisconnect)}
+	if err := p.ioctl(usbdevfsIoctl, unsafe.Pointer(&detach)); err != nil && err != syscall.ENODATA {
+		f.Close()
+		return nil, fmt.Errorf("cannot detach the kernel driver: %v", err)
+	}
+	ifno := uint32(0)
+	if err := p.ioctl(usbdevfsClaim, unsafe.Pointer(&ifno)); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("cannot claim the USB interface: %v", err)
+	}
+	for _, req := range [][2]uint16{
+		{sioReset, 0},
+		{sioReset, sioResetPurgeRX},
+		{sioReset, sioResetPurgeTX},
+		{sioSetLatency, 1},
+		{sioSetBitmode, bitmodeReset << 8},
+		{sioSetBitmode, bitmodeMPSSE << 8},
+	} {
+		if err := p.control(uint8(req[0]), req[1]); err != nil {
+			p.Close()
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func findUSB(vid, pid uint16) (string, error) {
+	dirs, err := filepath.Glob(filepath.Join(sysfsUSBDevices, "*"))
+	if err != nil {
+		return "", err
+	}
+	for _, dir := range dirs {
+		if readHex(filepath.Join(dir, "idVendor")) != int(vid) ||
+			readHex(filepath.Join(dir, "idProduct")) != int(pid) {
+			continue
+		}
+		bus, err1 := readInt(filepath.Join(dir, "busnum"))
+		dev, err2 := readInt(filepath.Join(dir, "devnum"))
+		if err1 != nil || err2 != nil {
+			return "", errDeviceMissingNode
+		}
+		return fmt.Sprintf("/dev/bus/usb/%03d/%03d", bus, dev), nil
+	}
+	return "", errDeviceNotFound
+}
+
+func readHex(path string) int {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return -1
+	}
+	v, err := strconv.ParseInt(strings.TrimSpace(string(b)), 16, 32)
+	if err != nil {
+		return -1
+	}
+	return int(v)
+}
+
+func readInt(path string) (int, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(b)))
+}
+
+func (p *usbPort) control(req uint8, value uint16) error {
+	t := ctrlTransfer{
+		requestType: 0x40, // vendor request, host to device
+		request:     req,
+		value:       value,
+		index:       1, // interface A
+		timeout:     usbTimeout,
+	}
+	if err := p.ioctl(usbdevfsControl, unsafe.Pointer(&t)); err != nil {
+		return fmt.Errorf("control request %#x failed: %v", req, err)
+	}
+	return nil
+}
+
+func (p *usbPort) bulk(ep uint32, b []byte) (int, error) {
+	if len(b) == 0 {
+		return 0, nil
+	}
+	t := bulkTransfer{
+		ep:      ep,
+		length:  uint32(len(b)),
+		timeout: usbTimeout,
+		data:    unsafe.Pointer(&b[0]),
+	}
+	n, _, errno := syscall.Syscall(syscall.SYS_IOCTL, p.f.Fd(), usbdevfsBulk, uintptr(unsafe.Pointer(&t)))
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(n), nil
+}
+
+func (p *usbPort) Write(b []byte) (int, error) {
+	var n int
+	for len(b) > 0 {
+		chunk := b
+		if len(chunk) > 4096 {
+			chunk = chunk[:4096]
+		}
+		if _, err := p.bulk(epOut, chunk); err != nil {
+			return n, err
+		}
+		n += len(chunk)
+		b = b[len(chunk):]
+	}
+	return n, nil
+}
+
+// Read reads the bytes sent back by the engine. Every USB packet starts
+// with two modem status bytes which are dropped.
+func (p *usbPort) Read(b []byte) (int, error) {
+	deadline := time.Now().Add(readTimeout)
+	pkt := make([]byte, 4*packetSize)
+	for len(p.buf) == 0 {
+		if time.Now().After(deadline) {
+			return 0, errors.New("timed out reading from the FT232H")
+		}
+		n, err := p.bulk(epIn, pkt)
+		if err != nil {
+			return 0, err
+		}
+		for off := 0; off < n; off += packetSize {
+			end := off + packetSize
+			if end > n {
+				end = n
+			}
+			if end-off > 2 {
+				p.buf = append(p.buf, pkt[off+2:end]...)
+			}
+		}
+	}
+	n := copy(b, p.buf)
+	p.buf = p.buf[n:]
+	return n, nil
+}
+
+func (p *usbPort) Close() error {
+	p.control(sioSetBitmode, bitmodeReset<<8)
+	ifno := uint32(0)
+	p.ioctl(usbdevfsRelease, unsafe.Pointer(&ifno))
+	// give the interface back to ftdi_sio
+	attach := usbIoctl{ifno: 0, code: int32(usbdevfsConnect)}
+	p.ioctl(usbdevfsIoctl, unsafe.Pointer(&attach))
+	return p.f.Close()
+}
+
+func (p *usbPort) ioctl(req uintptr, arg unsafe.Pointer) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, p.f.Fd(), req, uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
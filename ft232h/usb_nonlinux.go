@@ -0,0 +1,18 @@
+//go:build !linux || tinygo
+// +build !linux tinygo
+
+package ft232h
+
+import (
+	"errors"
+	"io"
+)
+
+const (
+	vendorFTDI    = 0x0403
+	productFT232H = 0x6014
+)
+
+func openUSB(vid, pid uint16) (io.ReadWriteCloser, error) {
+	return nil, errors.New("not implemented on this platform")
+}
@@ -0,0 +1,168 @@
+// Package gfx draws lines, rectangles, circles and triangles on any
+// image/draw image, the frame buffers of the displays included, for the
+// gauges and boxes of simple user interfaces. The shapes are drawn with
+// solid colors and no antialiasing, so they stay sharp on the 1-bit
+// displays.
+package gfx
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Line draws the line from p0 to p1, both included, with Bresenham's
+// algorithm.
+func Line(dst draw.Image, p0, p1 image.Point, c color.Color) {
+	dx, sx := abs(p1.X-p0.X), sign(p1.X-p0.X)
+	dy, sy := -abs(p1.Y-p0.Y), sign(p1.Y-p0.Y)
+	err := dx + dy
+	for p := p0; ; {
+		dst.Set(p.X, p.Y, c)
+		if p == p1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			p.X += sx
+		}
+		if e2 <= dx {
+			err += dx
+			p.Y += sy
+		}
+	}
+}
+
+// Rect draws the outline of r, on its edge pixels: r.Max is excluded as
+// everywhere in the image package.
+func Rect(dst draw.Image, r image.Rectangle, c color.Color) {
+	r = r.Canon()
+	if r.Empty() {
+		return
+	}
+	last := r.Max.Sub(image.Pt(1, 1))
+	Line(dst, r.Min, image.Pt(last.X, r.Min.Y), c)
+	Line(dst, image.Pt(r.Min.X, last.Y), last, c)
+	Line(dst, r.Min, image.Pt(r.Min.X, last.Y), c)
+	Line(dst, image.Pt(last.X, r.Min.Y), last, c)
+}
+
+// FillRect fills r.
+func FillRect(dst draw.Image, r image.Rectangle, c color.Color) {
+	draw.Draw(dst, r.Canon(), image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+// Circle draws the circle of center p and radius r with the midpoint
+// algorithm.
+func Circle(dst draw.Image, p image.Point, r int, c color.Color) {
+	circle(r, func(x, y int) {
+		for _, q := range []image.Point{{x, y}, {y, x}, {-y, x}, {-x, y}, {-x, -y}, {-y, -x}, {y, -x}, {x, -y}} {
+			dst.Set(p.X+q.X, p.Y+q.Y, c)
+		}
+	})
+}
+
+// FillCircle fills the disc of center p and radius r, covering the pixels
+// of Circle.
+func FillCircle(dst draw.Image, p image.Point, r int, c color.Color) {
+	circle(r, func(x, y int) {
+		hline(dst, p.X-x, p.X+x, p.Y+y, c)
+		hline(dst, p.X-x, p.X+x, p.Y-y, c)
+		hline(dst, p.X-y, p.X+y, p.Y+x, c)
+		hline(dst, p.X-y, p.X+y, p.Y-x, c)
+	})
+}
+
+// circle calls f with the points of the first octant of the circle of
+// radius r, from (r, 0) to x = y.
+func circle(r int, f func(x, y int)) {
+	if r < 0 {
+		return
+	}
+	x, y, err := r, 0, 1-r
+	for x >= y {
+		f(x, y)
+		y++
+		if err < 0 {
+			err += 2*y + 1
+		} else {
+			x--
+			err += 2*(y-x) + 1
+		}
+	}
+}
+
+// Triangle draws the outline of the triangle p0, p1, p2.
+func Triangle(dst draw.Image, p0, p1, p2 image.Point, c color.Color) {
+	Line(dst, p0, p1, c)
+	Line(dst, p1, p2, c)
+	Line(dst, p2, p0, c)
+}
+
+// FillTriangle fills the triangle p0, p1, p2, covering the pixels of
+// Triangle.
+func FillTriangle(dst draw.Image, p0, p1, p2 image.Point, c color.Color) {
+	// sort the vertices by y
+	if p1.Y < p0.Y {
+		p0, p1 = p1, p0
+	}
+	if p2.Y < p1.Y {
+		p1, p2 = p2, p1
+	}
+	if p1.Y < p0.Y {
+		p0, p1 = p1, p0
+	}
+	for y := p0.Y; y <= p2.Y; y++ {
+		// the long edge p0-p2 spans all the rows, the two short ones meet
+		// at p1
+		xa := edgeX(p0, p2, y)
+		var xb int
+		if y < p1.Y {
+			xb = edgeX(p0, p1, y)
+		} else {
+			xb = edgeX(p1, p2, y)
+		}
+		hline(dst, xa, xb, y, c)
+	}
+	Triangle(dst, p0, p1, p2, c)
+}
+
+// edgeX returns the x coordinate of the edge a-b on the row y, rounded
+// to the nearest pixel.
+func edgeX(a, b image.Point, y int) int {
+	if a.Y == b.Y {
+		return a.X
+	}
+	n, d := (y-a.Y)*(b.X-a.X), b.Y-a.Y
+	// round half away from zero, d is positive
+	if n < 0 {
+		return a.X - (-n+d/2)/d
+	}
+	return a.X + (n+d/2)/d
+}
+
+// hline draws the row y from x0 to x1, both included.
+func hline(dst draw.Image, x0, x1, y int, c color.Color) {
+	if x1 < x0 {
+		x0, x1 = x1, x0
+	}
+	FillRect(dst, image.Rect(x0, y, x1+1, y+1), c)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func sign(v int) int {
+	switch {
+	case v < 0:
+		return -1
+	case v > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,126 @@
+package gfx
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"strings"
+	"testing"
+)
+
+func newImage() *image.Gray { return image.NewGray(image.Rect(0, 0, 16, 16)) }
+
+func lit(img *image.Gray, x, y int) bool { return img.GrayAt(x, y).Y != 0 }
+
+func count(img *image.Gray) int {
+	n := 0
+	for _, v := range img.Pix {
+		if v != 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func ascii(img *image.Gray) string {
+	var b strings.Builder
+	for y := 0; y < img.Rect.Dy(); y++ {
+		for x := 0; x < img.Rect.Dx(); x++ {
+			if lit(img, x, y) {
+				b.WriteByte('#')
+			} else {
+				b.WriteByte('.')
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func TestLine(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 6, 3))
+	Line(img, image.Pt(0, 0), image.Pt(5, 2), color.White)
+	want := "##....\n" +
+		"..##..\n" +
+		"....##\n"
+	if got := ascii(img); got != want {
+		t.Errorf("line drawn as\n%swant\n%s", got, want)
+	}
+
+	for _, p1 := range []image.Point{{15, 3}, {2, 15}, {0, 0}, {9, 9}} {
+		img := newImage()
+		Line(img, p1, image.Pt(4, 6), color.White)
+		n := abs(p1.X - 4)
+		if d := abs(p1.Y - 6); d > n {
+			n = d
+		}
+		if !lit(img, p1.X, p1.Y) || !lit(img, 4, 6) || count(img) != n+1 {
+			t.Errorf("line from %v to (4,6) lit %d pixels; want %d with both ends", p1, count(img), n+1)
+		}
+	}
+}
+
+func TestRect(t *testing.T) {
+	img := newImage()
+	Rect(img, image.Rect(2, 3, 7, 7), color.White)
+	if n := count(img); n != 14 {
+		t.Errorf("5x4 outline lit %d pixels; want 14", n)
+	}
+	if !lit(img, 2, 3) || !lit(img, 6, 6) || lit(img, 7, 7) || lit(img, 4, 5) {
+		t.Errorf("outline drawn as\n%s", ascii(img))
+	}
+
+	img = newImage()
+	FillRect(img, image.Rect(7, 7, 2, 3), color.White)
+	if n := count(img); n != 20 {
+		t.Errorf("5x4 filled rectangle lit %d pixels; want 20", n)
+	}
+}
+
+func TestCircle(t *testing.T) {
+	img, fill := newImage(), newImage()
+	c := image.Pt(7, 7)
+	Circle(img, c, 5, color.White)
+	FillCircle(fill, c, 5, color.White)
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			d := math.Hypot(float64(x-c.X), float64(y-c.Y))
+			if lit(img, x, y) && math.Abs(d-5) >= 1 {
+				t.Errorf("pixel %d,%d at %.2f from the center", x, y, d)
+			}
+			if lit(img, x, y) && !lit(fill, x, y) {
+				t.Errorf("pixel %d,%d of the circle not filled", x, y)
+			}
+			if d <= 5 && !lit(fill, x, y) || d > 6 && lit(fill, x, y) {
+				t.Errorf("pixel %d,%d at %.2f from the center filled %v", x, y, d, lit(fill, x, y))
+			}
+		}
+	}
+	if !lit(img, 12, 7) || !lit(img, 7, 2) || !lit(img, 2, 7) || !lit(img, 7, 12) {
+		t.Errorf("circle drawn as\n%s", ascii(img))
+	}
+}
+
+func TestTriangle(t *testing.T) {
+	p := []image.Point{{1, 1}, {14, 5}, {4, 13}}
+	img, fill := newImage(), newImage()
+	Triangle(img, p[0], p[1], p[2], color.White)
+	FillTriangle(fill, p[2], p[0], p[1], color.White)
+	side := func(a, b image.Point, x, y int) int {
+		return (b.X-a.X)*(y-a.Y) - (b.Y-a.Y)*(x-a.X)
+	}
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			if lit(img, x, y) && !lit(fill, x, y) {
+				t.Errorf("pixel %d,%d of the outline not filled", x, y)
+			}
+			inside := side(p[0], p[1], x, y) > 0 && side(p[1], p[2], x, y) > 0 && side(p[2], p[0], x, y) > 0
+			if inside && !lit(fill, x, y) {
+				t.Errorf("pixel %d,%d inside the triangle not filled:\n%s", x, y, ascii(fill))
+			}
+		}
+	}
+	if lit(fill, 0, 0) || lit(fill, 15, 15) || lit(fill, 14, 13) {
+		t.Errorf("pixels outside of the triangle filled:\n%s", ascii(fill))
+	}
+}
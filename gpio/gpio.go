@@ -0,0 +1,69 @@
+// Package gpio defines the interfaces implemented by the GPIO backends and
+// used by drivers that need digital lines (resets, chip selects, buttons,
+// interrupt outputs...).
+package gpio
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrTimeout is returned by Watcher.Wait if no edge was detected in time.
+var ErrTimeout = errors.New("gpio: timed out waiting for an edge")
+
+// Pin represents a single digital line.
+type Pin interface {
+	// Read returns the current logical level of the line, 0 or 1.
+	Read() (int, error)
+
+	// Write sets the logical level of the line, 0 or 1.
+	// It fails if the line is not configured as an output.
+	Write(v int) error
+
+	// Close releases the line.
+	Close() error
+}
+
+// Edge represents the transition of a digital line.
+type Edge int
+
+const (
+	// NoEdge disables edge detection.
+	NoEdge Edge = iota
+	// RisingEdge is a low to high transition.
+	RisingEdge
+	// FallingEdge is a high to low transition.
+	FallingEdge
+	// BothEdges matches rising and falling edges.
+	BothEdges
+)
+
+func (e Edge) String() string {
+	switch e {
+	case RisingEdge:
+		return "rising"
+	case FallingEdge:
+		return "falling"
+	case BothEdges:
+		return "both"
+	default:
+		return "none"
+	}
+}
+
+// Event is an edge detected on a line.
+type Event struct {
+	Edge Edge      // Edge is either RisingEdge or FallingEdge.
+	Time time.Time // Time is when the edge was detected.
+}
+
+// Watcher is a line that reports edge events, typically used for
+// buttons and for the interrupt outputs of sensors.
+type Watcher interface {
+	Pin
+
+	// Wait blocks until the next edge event. If no event is detected
+	// within timeout, ErrTimeout is returned. A negative timeout waits
+	// forever.
+	Wait(timeout time.Duration) (Event, error)
+}
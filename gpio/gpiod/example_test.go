@@ -0,0 +1,42 @@
+package gpiod_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/goiot/devices/gpio"
+	"github.com/goiot/devices/gpio/gpiod"
+)
+
+func Example() {
+	chip, err := gpiod.Open("/dev/gpiochip0")
+	if err != nil {
+		panic(err)
+	}
+	defer chip.Close()
+
+	// a LED on GPIO17 and a button to ground on GPIO27
+	led, err := chip.Output(17, 0, 0)
+	if err != nil {
+		panic(err)
+	}
+	defer led.Close()
+
+	button, err := chip.Watch(27, gpio.FallingEdge, gpiod.PullUp)
+	if err != nil {
+		panic(err)
+	}
+	defer button.Close()
+
+	for i := 0; i < 10; i++ {
+		e, err := button.Wait(10 * time.Second)
+		if err == gpio.ErrTimeout {
+			continue
+		}
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println("pressed at", e.Time)
+		led.Write(i & 1)
+	}
+}
@@ -0,0 +1,257 @@
+//go:build linux && !tinygo
+// +build linux,!tinygo
+
+// Package gpiod implements a GPIO backend using the Linux GPIO character
+// device (/dev/gpiochipN). Unlike the deprecated sysfs interface, lines
+// are requested by the process and released when it exits, bias can be
+// configured and edge events are timestamped by the kernel.
+package gpiod
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/goiot/devices/gpio"
+)
+
+// Flags configure a requested line.
+type Flags uint32
+
+const (
+	// ActiveLow inverts the logical level of the line.
+	ActiveLow Flags = handleActiveLow
+	// OpenDrain configures an output as open drain.
+	OpenDrain Flags = handleOpenDrain
+	// OpenSource configures an output as open source.
+	OpenSource Flags = handleOpenSource
+	// PullUp enables the internal pull-up resistor (kernel 5.5+).
+	PullUp Flags = handleBiasPullUp
+	// PullDown enables the internal pull-down resistor (kernel 5.5+).
+	PullDown Flags = handleBiasPullDown
+	// BiasDisabled disables the internal resistors (kernel 5.5+).
+	BiasDisabled Flags = handleBiasDisable
+)
+
+// Chip represents a GPIO controller, e.g. /dev/gpiochip0.
+type Chip struct {
+	// Name is the kernel name of the chip, e.g. gpiochip0.
+	Name string
+	// Label is the functional name of the chip, e.g. pinctrl-bcm2835.
+	Label string
+	// Lines is the number of lines the chip controls.
+	Lines int
+	// Consumer is the label lines are requested with, visible in
+	// gpioinfo. Defaults to "goiot".
+	Consumer string
+
+	f *os.File
+}
+
+// Open opens the GPIO chip at dev, e.g. /dev/gpiochip0. A chip must
+// be closed if no longer in use; lines requested from it stay valid
+// until they are closed.
+func Open(dev string) (*Chip, error) {
+	f, err := os.OpenFile(dev, os.O_RDWR, os.ModeDevice)
+	if err != nil {
+		return nil, err
+	}
+	var info chipInfo
+	if err := ioctl(f, getChipInfoIoctl, unsafe.Pointer(&info)); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("cannot read chip info of %v: %v", dev, err)
+	}
+	return &Chip{
+		Name:     cstring(info.name[:]),
+		Label:    cstring(info.label[:]),
+		Lines:    int(info.lines),
+		Consumer: "goiot",
+		f:        f,
+	}, nil
+}
+
+// Close closes the chip.
+func (c *Chip) Close() error {
+	return c.f.Close()
+}
+
+// Input requests the line at offset as an input.
+func (c *Chip) Input(offset int, flags Flags) (*Line, error) {
+	return c.request(offset, handleInput|uint32(flags), 0)
+}
+
+// Output requests the line at offset as an output initially driven to v.
+func (c *Chip) Output(offset int, v int, flags Flags) (*Line, error) {
+	return c.request(offset, handleOutput|uint32(flags), byte(v&1))
+}
+
+func (c *Chip) request(offset int, flags uint32, v byte) (*Line, error) {
+	if err := c.checkOffset(offset); err != nil {
+		return nil, err
+	}
+	req := handleRequest{flags: flags, lines: 1}
+	req.lineOffsets[0] = uint32(offset)
+	req.defaultValues[0] = v
+	copy(req.consumerLabel[:len(req.consumerLabel)-1], c.Consumer)
+	if err := ioctl(c.f, getLineHandleIoctl, unsafe.Pointer(&req)); err != nil {
+		return nil, fmt.Errorf("cannot request line %d of %v: %v", offset, c.Name, err)
+	}
+	return &Line{
+		f:      os.NewFile(uintptr(req.fd), fmt.Sprintf("%v:%d", c.Name, offset)),
+		offset: offset,
+		output: flags&handleOutput != 0,
+	}, nil
+}
+
+// Watch requests the line at offset as an input reporting edge events.
+func (c *Chip) Watch(offset int, edge gpio.Edge, flags Flags) (*Watcher, error) {
+	if err := c.checkOffset(offset); err != nil {
+		return nil, err
+	}
+	var events uint32
+	switch edge {
+	case gpio.RisingEdge:
+		events = eventRisingEdge
+	case gpio.FallingEdge:
+		events = eventFallingEdge
+	case gpio.BothEdges:
+		events = eventRisingEdge | eventFallingEdge
+	default:
+		return nil, fmt.Errorf("invalid edge %v", edge)
+	}
+	req := eventRequest{
+		lineOffset:  uint32(offset),
+		handleFlags: handleInput | uint32(flags),
+		eventFlags:  events,
+	}
+	copy(req.consumerLabel[:len(req.consumerLabel)-1], c.Consumer)
+	if err := ioctl(c.f, getLineEventIoctl, unsafe.Pointer(&req)); err != nil {
+		return nil, fmt.Errorf("cannot watch line %d of %v: %v", offset, c.Name, err)
+	}
+	// A non-blocking descriptor is handed to the runtime poller, which
+	// allows Wait to rely on read deadlines.
+	if err := syscall.SetNonblock(int(req.fd), true); err != nil {
+		syscall.Close(int(req.fd))
+		return nil, err
+	}
+	return &Watcher{Line{
+		f:      os.NewFile(uintptr(req.fd), fmt.Sprintf("%v:%d", c.Name, offset)),
+		offset: offset,
+	}}, nil
+}
+
+func (c *Chip) checkOffset(offset int) error {
+	if offset < 0 || offset >= c.Lines {
+		return fmt.Errorf("line %d is out of range, %v has %d lines", offset, c.Name, c.Lines)
+	}
+	return nil
+}
+
+// Line is a requested GPIO line. It implements gpio.Pin.
+type Line struct {
+	f      *os.File
+	offset int
+	output bool
+}
+
+// Offset returns the offset of the line on its chip.
+func (l *Line) Offset() int { return l.offset }
+
+// Read returns the logical level of the line.
+func (l *Line) Read() (int, error) {
+	var data handleData
+	if err := ioctl(l.f, getLineValuesIoctl, unsafe.Pointer(&data)); err != nil {
+		return 0, err
+	}
+	return int(data.values[0]), nil
+}
+
+// Write sets the logical level of an output line.
+func (l *Line) Write(v int) error {
+	if !l.output {
+		return errors.New("line is not configured as an output")
+	}
+	var data handleData
+	data.values[0] = byte(v & 1)
+	return ioctl(l.f, setLineValuesIoctl, unsafe.Pointer(&data))
+}
+
+// Close releases the line.
+func (l *Line) Close() error {
+	return l.f.Close()
+}
+
+// Watcher is an input line reporting edge events. It implements gpio.Watcher.
+type Watcher struct {
+	Line
+}
+
+// Wait waits for the next edge event on the line. Events are queued by
+// the kernel, so edges happening between two calls are not lost; their
+// time is when the kernel detected them.
+func (w *Watcher) Wait(timeout time.Duration) (gpio.Event, error) {
+	var deadline time.Time
+	if timeout >= 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	if err := w.f.SetReadDeadline(deadline); err != nil {
+		return gpio.Event{}, err
+	}
+	var data eventData
+	buf := (*[unsafe.Sizeof(data)]byte)(unsafe.Pointer(&data))[:]
+	if _, err := w.f.Read(buf); err != nil {
+		if os.IsTimeout(err) {
+			return gpio.Event{}, gpio.ErrTimeout
+		}
+		return gpio.Event{}, err
+	}
+	e := gpio.Event{Edge: gpio.FallingEdge, Time: eventTime(data.timestamp)}
+	if data.id == eventIDRisingEdge {
+		e.Edge = gpio.RisingEdge
+	}
+	return e, nil
+}
+
+func ioctl(f *os.File, req uintptr, arg unsafe.Pointer) error {
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno syscall.Errno
+	if err := rc.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(arg))
+	}); err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func cstring(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
+
+// eventTime returns the wall time of the kernel timestamp ts of an event,
+// on the CLOCK_MONOTONIC since Linux 5.7 and on the CLOCK_REALTIME before.
+func eventTime(ts uint64) time.Time {
+	now := time.Now()
+	var mono syscall.Timespec
+	if _, _, errno := syscall.Syscall(syscall.SYS_CLOCK_GETTIME, clockMonotonic, uintptr(unsafe.Pointer(&mono)), 0); errno != 0 {
+		return now
+	}
+	// the realtime timestamps are since 1970, far after the monotonic ones
+	if int64(ts) > mono.Nano() {
+		return time.Unix(0, int64(ts))
+	}
+	return now.Add(-time.Duration(mono.Nano() - int64(ts)))
+}
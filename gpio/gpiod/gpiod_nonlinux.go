@@ -0,0 +1,87 @@
+//go:build !linux || tinygo
+// +build !linux tinygo
+
+// Package gpiod implements a GPIO backend using the Linux GPIO character
+// device (/dev/gpiochipN). Unlike the deprecated sysfs interface, lines
+// are requested by the process and released when it exits, bias can be
+// configured and edge events are timestamped by the kernel.
+package gpiod
+
+import (
+	"errors"
+	"time"
+
+	"github.com/goiot/devices/gpio"
+)
+
+var errNotImplemented = errors.New("not implemented on this platform")
+
+// Flags configure a requested line.
+type Flags uint32
+
+const (
+	// ActiveLow inverts the logical level of the line.
+	ActiveLow Flags = 1 << 2
+	// OpenDrain configures an output as open drain.
+	OpenDrain Flags = 1 << 3
+	// OpenSource configures an output as open source.
+	OpenSource Flags = 1 << 4
+	// PullUp enables the internal pull-up resistor.
+	PullUp Flags = 1 << 5
+	// PullDown enables the internal pull-down resistor.
+	PullDown Flags = 1 << 6
+	// BiasDisabled disables the internal resistors.
+	BiasDisabled Flags = 1 << 7
+)
+
+// Chip is no-implementation so developers using cross compilation
+// can rely on local tools even though the real implementation isn't
+// available on their platform.
+type Chip struct {
+	Name     string
+	Label    string
+	Lines    int
+	Consumer string
+}
+
+// Open is not implemented on this platform.
+func Open(dev string) (*Chip, error) { return nil, errNotImplemented }
+
+// Close is not implemented on this platform.
+func (c *Chip) Close() error { return errNotImplemented }
+
+// Input is not implemented on this platform.
+func (c *Chip) Input(offset int, flags Flags) (*Line, error) { return nil, errNotImplemented }
+
+// Output is not implemented on this platform.
+func (c *Chip) Output(offset int, v int, flags Flags) (*Line, error) { return nil, errNotImplemented }
+
+// Watch is not implemented on this platform.
+func (c *Chip) Watch(offset int, edge gpio.Edge, flags Flags) (*Watcher, error) {
+	return nil, errNotImplemented
+}
+
+// Line is a requested GPIO line.
+type Line struct{}
+
+// Offset is not implemented on this platform.
+func (l *Line) Offset() int { return 0 }
+
+// Read is not implemented on this platform.
+func (l *Line) Read() (int, error) { return 0, errNotImplemented }
+
+// Write is not implemented on this platform.
+func (l *Line) Write(v int) error { return errNotImplemented }
+
+// Close is not implemented on this platform.
+func (l *Line) Close() error { return errNotImplemented }
+
+// Watcher is an input line reporting edge events.
+type Watcher struct {
+	Line
+}
+
+// Wait is not implemented on this platform.
+func (w *Watcher) Wait(timeout time.Duration) (gpio.Event, error) {
+	return gpio.Event{}, errNotImplemented
+}
@@ -0,0 +1,62 @@
+//go:build linux && !tinygo
+// +build linux,!tinygo
+
+package gpiod
+
+import (
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+func TestABI(t *testing.T) {
+	sizes := []struct {
+		name      string
+		got, want uintptr
+	}{
+		{"gpiochip_info", unsafe.Sizeof(chipInfo{}), 68},
+		{"gpiohandle_request", unsafe.Sizeof(handleRequest{}), 364},
+		{"gpiohandle_data", unsafe.Sizeof(handleData{}), 64},
+		{"gpioevent_request", unsafe.Sizeof(eventRequest{}), 48},
+		{"gpioevent_data", unsafe.Sizeof(eventData{}), 16},
+	}
+	for _, s := range sizes {
+		if s.got != s.want {
+			t.Errorf("sizeof(%v) = %v, want %v", s.name, s.got, s.want)
+		}
+	}
+
+	codes := []struct {
+		name      string
+		got, want uintptr
+	}{
+		{"GPIO_GET_CHIPINFO_IOCTL", getChipInfoIoctl, 0x8044B401},
+		{"GPIO_GET_LINEHANDLE_IOCTL", getLineHandleIoctl, 0xC16CB403},
+		{"GPIO_GET_LINEEVENT_IOCTL", getLineEventIoctl, 0xC030B404},
+		{"GPIOHANDLE_GET_LINE_VALUES_IOCTL", getLineValuesIoctl, 0xC040B408},
+		{"GPIOHANDLE_SET_LINE_VALUES_IOCTL", setLineValuesIoctl, 0xC040B409},
+	}
+	for _, c := range codes {
+		if c.got != c.want {
+			t.Errorf("%v = %#x, want %#x", c.name, c.got, c.want)
+		}
+	}
+}
+
+func TestEventTime(t *testing.T) {
+	var mono syscall.Timespec
+	if _, _, errno := syscall.Syscall(syscall.SYS_CLOCK_GETTIME, clockMonotonic, uintptr(unsafe.Pointer(&mono)), 0); errno != 0 {
+		t.Fatal(errno)
+	}
+	// a monotonic timestamp of a second ago
+	want := time.Now().Add(-time.Second)
+	if got := eventTime(uint64(mono.Nano() - int64(time.Second))); got.Sub(want) < -100*time.Millisecond || got.Sub(want) > 100*time.Millisecond {
+		t.Errorf("eventTime() = %v of a monotonic timestamp; want %v", got, want)
+	}
+	// a realtime timestamp, before Linux 5.7
+	want = time.Unix(1700000000, 0)
+	if got := eventTime(uint64(want.UnixNano())); !got.Equal(want) {
+		t.Errorf("eventTime() = %v of a realtime timestamp; want %v", got, want)
+	}
+}
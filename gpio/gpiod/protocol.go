@@ -0,0 +1,79 @@
+//go:build linux && !tinygo
+// +build linux,!tinygo
+
+package gpiod
+
+// The structures below mirror the v1 GPIO character device ABI
+// defined in include/uapi/linux/gpio.h.
+
+const maxLines = 64
+
+type chipInfo struct {
+	name  [32]byte
+	label [32]byte
+	lines uint32
+}
+
+type handleRequest struct {
+	lineOffsets   [maxLines]uint32
+	flags         uint32
+	defaultValues [maxLines]byte
+	consumerLabel [32]byte
+	lines         uint32
+	fd            int32
+}
+
+type handleData struct {
+	values [maxLines]byte
+}
+
+type eventRequest struct {
+	lineOffset    uint32
+	handleFlags   uint32
+	eventFlags    uint32
+	consumerLabel [32]byte
+	fd            int32
+}
+
+const clockMonotonic = 1 // CLOCK_MONOTONIC, the clock of the events since Linux 5.7
+
+type eventData struct {
+	timestamp uint64
+	id        uint32
+	_         uint32
+}
+
+const (
+	handleInput        = 1 << 0
+	handleOutput       = 1 << 1
+	handleActiveLow    = 1 << 2
+	handleOpenDrain    = 1 << 3
+	handleOpenSource   = 1 << 4
+	handleBiasPullUp   = 1 << 5
+	handleBiasPullDown = 1 << 6
+	handleBiasDisable  = 1 << 7
+
+	eventRisingEdge  = 1 << 0
+	eventFallingEdge = 1 << 1
+
+	eventIDRisingEdge  = 0x01
+	eventIDFallingEdge = 0x02
+)
+
+const (
+	iocWrite = 1
+	iocRead  = 2
+)
+
+var (
+	getChipInfoIoctl   = ioc(iocRead, 0x01, 68)
+	getLineHandleIoctl = ioc(iocRead|iocWrite, 0x03, 364)
+	getLineEventIoctl  = ioc(iocRead|iocWrite, 0x04, 48)
+	getLineValuesIoctl = ioc(iocRead|iocWrite, 0x08, maxLines)
+	setLineValuesIoctl = ioc(iocRead|iocWrite, 0x09, maxLines)
+)
+
+// ioc returns the request code of a GPIO ioctl.
+func ioc(dir, nr, size uintptr) uintptr {
+	return dir<<30 | size<<16 | 0xB4<<8 | nr
+}
@@ -0,0 +1,23 @@
+package gpiomem_test
+
+import (
+	"github.com/goiot/devices/gpio/gpiomem"
+)
+
+func Example() {
+	chip, err := gpiomem.Open()
+	if err != nil {
+		panic(err)
+	}
+	defer chip.Close()
+
+	// a square wave of a few MHz on GPIO18
+	pin, err := chip.Output(18, 0)
+	if err != nil {
+		panic(err)
+	}
+	defer pin.Close()
+	for i := 0; i < 1000000; i++ {
+		pin.Write(i & 1)
+	}
+}
@@ -0,0 +1,171 @@
+// Package gpiomem implements a GPIO backend for the Raspberry Pi accessing
+// the registers of the BCM283x and BCM2711 GPIO controller mapped from
+// /dev/gpiomem. Reading or writing a pin takes a fraction of a microsecond,
+// which the timing critical bit-banged protocols such as DHT22, 1-Wire or
+// WS2812 require, while a system call of the character device takes several.
+//
+// The pins are not requested from the kernel: nothing prevents another
+// process or driver from using them, and they keep their mode and level
+// once closed.
+package gpiomem
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Pins is the number of GPIO pins of the controller, numbered as the BCM
+// GPIOs.
+const Pins = 54
+
+// Offsets of the registers, in 32-bit words.
+const (
+	regFsel   = 0x00 / 4 // function select, 10 pins per register
+	regSet    = 0x1C / 4 // output set, 32 pins per register
+	regClr    = 0x28 / 4 // output clear
+	regLev    = 0x34 / 4 // pin level
+	regPud    = 0x94 / 4 // BCM283x pull-up/down control
+	regPudClk = 0x98 / 4 // BCM283x pull-up/down clock
+	regPupPdn = 0xE4 / 4 // BCM2711 pull-up/down, 16 pins per register
+	regsSize  = 0xF4 / 4
+)
+
+const (
+	fselInput  = 0
+	fselOutput = 1
+
+	// pudSetup is longer than the 150 cycles the BCM283x needs to set up
+	// the pull-up/down control.
+	pudSetup = time.Microsecond
+)
+
+// Pull selects the internal resistor of an input.
+type Pull int
+
+const (
+	// PullNone disables the internal resistors.
+	PullNone Pull = iota
+	// PullUp enables the internal pull-up resistor.
+	PullUp
+	// PullDown enables the internal pull-down resistor.
+	PullDown
+)
+
+// Chip is the memory mapped GPIO controller.
+type Chip struct {
+	mu      sync.Mutex // serializes the read-modify-write of the registers
+	regs    []uint32
+	bcm2711 bool
+	unmap   func() error
+}
+
+func newChip(regs []uint32, bcm2711 bool) *Chip {
+	return &Chip{regs: regs, bcm2711: bcm2711, unmap: func() error { return nil }}
+}
+
+// Close unmaps the registers. The pins of the chip must not be used
+// afterwards.
+func (c *Chip) Close() error {
+	return c.unmap()
+}
+
+// Input configures pin as an input with the given pull resistor.
+func (c *Chip) Input(pin int, pull Pull) (*Pin, error) {
+	if err := checkPin(pin); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setPull(pin, pull)
+	c.setFunction(pin, fselInput)
+	return &Pin{c: c, n: pin}, nil
+}
+
+// Output configures pin as an output initially driven to v.
+func (c *Chip) Output(pin int, v int) (*Pin, error) {
+	if err := checkPin(pin); err != nil {
+		return nil, err
+	}
+	p := &Pin{c: c, n: pin, output: true}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p.Write(v)
+	c.setFunction(pin, fselOutput)
+	return p, nil
+}
+
+func checkPin(pin int) error {
+	if pin < 0 || pin >= Pins {
+		return fmt.Errorf("pin %d is out of range, the controller has %d pins", pin, Pins)
+	}
+	return nil
+}
+
+func (c *Chip) setFunction(pin int, f uint32) {
+	r, shift := regFsel+pin/10, uint(pin%10)*3
+	atomic.StoreUint32(&c.regs[r], atomic.LoadUint32(&c.regs[r])&^(7<<shift)|f<<shift)
+}
+
+func (c *Chip) setPull(pin int, pull Pull) {
+	if c.bcm2711 {
+		var v uint32 // 0 none, 1 up, 2 down
+		switch pull {
+		case PullUp:
+			v = 1
+		case PullDown:
+			v = 2
+		}
+		r, shift := regPupPdn+pin/16, uint(pin%16)*2
+		atomic.StoreUint32(&c.regs[r], atomic.LoadUint32(&c.regs[r])&^(3<<shift)|v<<shift)
+		return
+	}
+	var v uint32 // 0 none, 1 down, 2 up
+	switch pull {
+	case PullDown:
+		v = 1
+	case PullUp:
+		v = 2
+	}
+	// The control signal is latched by the pins clocked in after the set
+	// up time, then both registers are reset.
+	atomic.StoreUint32(&c.regs[regPud], v)
+	time.Sleep(pudSetup)
+	atomic.StoreUint32(&c.regs[regPudClk+pin/32], 1<<uint(pin%32))
+	time.Sleep(pudSetup)
+	atomic.StoreUint32(&c.regs[regPud], 0)
+	atomic.StoreUint32(&c.regs[regPudClk+pin/32], 0)
+}
+
+// Pin is a GPIO pin of the chip. It implements gpio.Pin.
+type Pin struct {
+	c      *Chip
+	n      int
+	output bool
+}
+
+// Number returns the BCM GPIO number of the pin.
+func (p *Pin) Number() int { return p.n }
+
+// Read returns the level of the pin.
+func (p *Pin) Read() (int, error) {
+	return int(atomic.LoadUint32(&p.c.regs[regLev+p.n/32]) >> uint(p.n%32) & 1), nil
+}
+
+// Write sets the level of an output pin.
+func (p *Pin) Write(v int) error {
+	if !p.output {
+		return errors.New("pin is not configured as an output")
+	}
+	r := regClr
+	if v&1 != 0 {
+		r = regSet
+	}
+	atomic.StoreUint32(&p.c.regs[r+p.n/32], 1<<uint(p.n%32))
+	return nil
+}
+
+// Close releases the pin, leaving its mode and level unchanged.
+func (p *Pin) Close() error { return nil }
@@ -0,0 +1,38 @@
+//go:build linux && !tinygo
+// +build linux,!tinygo
+
+package gpiomem
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// Open maps the GPIO registers from /dev/gpiomem, which users of the gpio
+// group can open on Raspberry Pi OS. The chip must be closed when no longer
+// in use.
+func Open() (*Chip, error) {
+	f, err := os.OpenFile("/dev/gpiomem", os.O_RDWR|os.O_SYNC, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	mem, err := syscall.Mmap(int(f.Fd()), 0, os.Getpagesize(), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("cannot map the GPIO registers - %v", err)
+	}
+	c := newChip((*[regsSize]uint32)(unsafe.Pointer(&mem[0]))[:], isBCM2711())
+	c.unmap = func() error { return syscall.Munmap(mem) }
+	return c, nil
+}
+
+// isBCM2711 reports whether the board is a Raspberry Pi 4 or 400, or a
+// Compute Module 4.
+func isBCM2711() bool {
+	b, err := ioutil.ReadFile("/proc/device-tree/compatible")
+	return err == nil && bytes.Contains(b, []byte("bcm2711"))
+}
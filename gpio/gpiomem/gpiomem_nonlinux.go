@@ -0,0 +1,9 @@
+//go:build !linux || tinygo
+// +build !linux tinygo
+
+package gpiomem
+
+import "errors"
+
+// Open is not implemented on this platform.
+func Open() (*Chip, error) { return nil, errors.New("not implemented on this platform") }
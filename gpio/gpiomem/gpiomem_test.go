@@ -0,0 +1,97 @@
+package gpiomem
+
+import (
+	"testing"
+
+	"github.com/goiot/devices/gpio"
+)
+
+var _ gpio.Pin = (*Pin)(nil)
+
+func TestOutput(t *testing.T) {
+	regs := make([]uint32, regsSize)
+	regs[regFsel+1] = 0xFFFFFFFF
+	c := newChip(regs, false)
+
+	p, err := c.Output(17, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// GPIO17 is the 8th pin of GPFSEL1, the other pins are unchanged.
+	if got, want := regs[regFsel+1], uint32(0xFFFFFFFF)&^(7<<21)|1<<21; got != want {
+		t.Errorf("GPFSEL1 = %#x; want %#x", got, want)
+	}
+	if regs[regSet] != 1<<17 {
+		t.Errorf("GPSET0 = %#x; want %#x", regs[regSet], 1<<17)
+	}
+	p.Write(0)
+	if regs[regClr] != 1<<17 {
+		t.Errorf("GPCLR0 = %#x; want %#x", regs[regClr], 1<<17)
+	}
+
+	p, err = c.Output(40, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if regs[regClr+1] != 1<<8 {
+		t.Errorf("GPCLR1 = %#x; want %#x", regs[regClr+1], 1<<8)
+	}
+
+	for _, pin := range []int{-1, Pins} {
+		if _, err := c.Output(pin, 0); err == nil {
+			t.Errorf("Output(%d) succeeded", pin)
+		}
+	}
+}
+
+func TestInput(t *testing.T) {
+	regs := make([]uint32, regsSize)
+	regs[regFsel+2] = 1 << 21 // GPIO27 is an output
+	c := newChip(regs, true)
+
+	p, err := c.Input(27, PullUp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if regs[regFsel+2] != 0 {
+		t.Errorf("GPFSEL2 = %#x; want 0", regs[regFsel+2])
+	}
+	if got, want := regs[regPupPdn+1], uint32(1<<22); got != want {
+		t.Errorf("GPIO_PUP_PDN_CNTRL_REG1 = %#x; want %#x", got, want)
+	}
+	if err := p.Write(1); err == nil {
+		t.Error("Write succeeded on an input")
+	}
+
+	regs[regLev] = 1 << 27
+	if v, _ := p.Read(); v != 1 {
+		t.Errorf("Read = %d; want 1", v)
+	}
+	regs[regLev] = ^uint32(1 << 27)
+	if v, _ := p.Read(); v != 0 {
+		t.Errorf("Read = %d; want 0", v)
+	}
+
+	// The BCM283x latches the pull setting, then resets the registers.
+	regs = make([]uint32, regsSize)
+	c = newChip(regs, false)
+	if _, err := c.Input(4, PullDown); err != nil {
+		t.Fatal(err)
+	}
+	if regs[regPud] != 0 || regs[regPudClk] != 0 {
+		t.Errorf("GPPUD = %#x, GPPUDCLK0 = %#x; want 0", regs[regPud], regs[regPudClk])
+	}
+}
+
+func BenchmarkWrite(b *testing.B) {
+	c := newChip(make([]uint32, regsSize), false)
+	p, err := c.Output(18, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.Write(i & 1)
+	}
+}
@@ -0,0 +1,157 @@
+// Package gpiosim provides simulated GPIO lines for tests. A Pin
+// implements gpio.Watcher: the test drives its level with Set and the
+// driver reads it and waits for its edges, or the driver writes it and
+// the test checks it with Level.
+package gpiosim
+
+import (
+	"sync"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/fault"
+	"github.com/goiot/devices/gpio"
+)
+
+// events is the number of edges kept for Wait, older edges are dropped.
+const events = 64
+
+// Pin is a simulated line. It can be used by multiple goroutines.
+type Pin struct {
+	// Clock dates the edges and times Wait and the delays of the faults,
+	// clock.Real if nil.
+	Clock clock.Clock
+
+	faults fault.Queue
+	events chan gpio.Event
+
+	mu   sync.Mutex
+	v    int
+	edge gpio.Edge
+}
+
+// NewPin returns a line at the level v, reporting both edges.
+func NewPin(v int) *Pin {
+	return &Pin{v: v, edge: gpio.BothEdges, events: make(chan gpio.Event, events)}
+}
+
+// SetEdge selects the edges reported by Wait.
+func (p *Pin) SetEdge(e gpio.Edge) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.edge = e
+}
+
+// Set drives the line to v, as the device connected to it would.
+func (p *Pin) Set(v int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if v == p.v {
+		return
+	}
+	p.v = v
+	e := gpio.FallingEdge
+	if v == 1 {
+		e = gpio.RisingEdge
+	}
+	if p.edge != gpio.BothEdges && p.edge != e {
+		return
+	}
+	select {
+	case p.events <- gpio.Event{Edge: e, Time: clock.Or(p.Clock).Now()}:
+	default:
+	}
+}
+
+// Level returns the level of the line, e.g. to check what the driver
+// wrote, bypassing the faults.
+func (p *Pin) Level() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.v
+}
+
+// Inject queues faults for the next calls of Read, Write and Wait, one
+// per call. BitFlip faults invert the level read or written.
+func (p *Pin) Inject(faults ...fault.Fault) {
+	p.faults.Inject(faults...)
+}
+
+// Read implements gpio.Pin.
+func (p *Pin) Read() (int, error) {
+	f := p.faults.Next()
+	if err := p.fail(f); err != nil {
+		return 0, err
+	}
+	v := p.Level()
+	if f.Kind == fault.BitFlip {
+		v ^= 1
+	}
+	return v, nil
+}
+
+// Write implements gpio.Pin.
+func (p *Pin) Write(v int) error {
+	f := p.faults.Next()
+	if err := p.fail(f); err != nil {
+		return err
+	}
+	switch f.Kind {
+	case fault.Partial:
+		return nil
+	case fault.BitFlip:
+		v ^= 1
+	}
+	p.Set(v)
+	return nil
+}
+
+// Wait implements gpio.Watcher. A Timeout fault returns gpio.ErrTimeout
+// after its delay, even if an edge is pending.
+func (p *Pin) Wait(timeout time.Duration) (gpio.Event, error) {
+	f := p.faults.Next()
+	if f.Kind == fault.Timeout && f.Err == nil {
+		f.Err = gpio.ErrTimeout
+	}
+	if err := p.fail(f); err != nil {
+		return gpio.Event{}, err
+	}
+	if timeout < 0 {
+		return <-p.events, nil
+	}
+	select {
+	case e := <-p.events:
+		return e, nil
+	default:
+	}
+	select {
+	case e := <-p.events:
+		return e, nil
+	case <-clock.Or(p.Clock).After(timeout):
+		return gpio.Event{}, gpio.ErrTimeout
+	}
+}
+
+// Close implements gpio.Pin.
+func (p *Pin) Close() error { return nil }
+
+// fail returns the error of the faults failing a call.
+func (p *Pin) fail(f fault.Fault) error {
+	switch f.Kind {
+	case fault.Error, fault.NAK:
+		if f.Err != nil {
+			return f.Err
+		}
+		return fault.ErrInjected
+	case fault.Timeout:
+		if f.Clock == nil {
+			f.Clock = p.Clock
+		}
+		clock.Or(f.Clock).Sleep(f.Delay)
+		if f.Err != nil {
+			return f.Err
+		}
+		return fault.ErrTimeout
+	}
+	return nil
+}
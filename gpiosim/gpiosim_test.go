@@ -0,0 +1,100 @@
+package gpiosim
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/fault"
+	"github.com/goiot/devices/gpio"
+)
+
+var _ gpio.Watcher = (*Pin)(nil)
+
+func TestPin(t *testing.T) {
+	p := NewPin(0)
+	if err := p.Write(1); err != nil || p.Level() != 1 {
+		t.Errorf("level after Write(1) = %d, %v", p.Level(), err)
+	}
+	p.Set(0)
+	if v, err := p.Read(); err != nil || v != 0 {
+		t.Errorf("Read = %d, %v; want 0", v, err)
+	}
+	for _, want := range []gpio.Edge{gpio.RisingEdge, gpio.FallingEdge} {
+		if e, err := p.Wait(0); err != nil || e.Edge != want {
+			t.Errorf("Wait = %v, %v; want %v", e.Edge, err, want)
+		}
+	}
+	if _, err := p.Wait(time.Millisecond); err != gpio.ErrTimeout {
+		t.Errorf("Wait error = %v, want %v", err, gpio.ErrTimeout)
+	}
+
+	p.SetEdge(gpio.RisingEdge)
+	p.Set(1)
+	p.Set(0)
+	if e, _ := p.Wait(0); e.Edge != gpio.RisingEdge {
+		t.Errorf("Wait = %v, want only the rising edge", e.Edge)
+	}
+	if _, err := p.Wait(0); err != gpio.ErrTimeout {
+		t.Errorf("Wait error = %v, want %v", err, gpio.ErrTimeout)
+	}
+}
+
+func TestFaults(t *testing.T) {
+	p := NewPin(1)
+	p.Inject(
+		fault.Fault{Kind: fault.Error},
+		fault.Fault{Kind: fault.BitFlip},
+		fault.Fault{Kind: fault.Partial},
+		fault.Fault{Kind: fault.Timeout},
+	)
+	if _, err := p.Read(); err != fault.ErrInjected {
+		t.Errorf("Read error = %v, want %v", err, fault.ErrInjected)
+	}
+	if v, _ := p.Read(); v != 0 {
+		t.Errorf("Read = %d, want the inverted level", v)
+	}
+	if p.Write(0); p.Level() != 1 {
+		t.Error("the partial write changed the level")
+	}
+	p.Set(0)
+	if _, err := p.Wait(-1); err != gpio.ErrTimeout {
+		t.Errorf("Wait error = %v, want %v", err, gpio.ErrTimeout)
+	}
+	if e, err := p.Wait(-1); err != nil || e.Edge != gpio.FallingEdge {
+		t.Errorf("Wait = %v, %v; want the pending falling edge", e.Edge, err)
+	}
+}
+
+func TestClock(t *testing.T) {
+	c := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	p := NewPin(0)
+	p.Clock = c
+	p.Inject(fault.Fault{}, fault.Fault{Kind: fault.Timeout, Delay: time.Hour})
+
+	errs := make(chan error)
+	go func() {
+		_, err := p.Wait(time.Minute)
+		errs <- err
+	}()
+	c.BlockUntil(1)
+	c.Advance(time.Minute)
+	if err := <-errs; err != gpio.ErrTimeout {
+		t.Errorf("Wait error = %v, want %v", err, gpio.ErrTimeout)
+	}
+
+	go func() {
+		_, err := p.Wait(-1)
+		errs <- err
+	}()
+	c.BlockUntil(1) // the fault delay
+	c.Advance(time.Hour)
+	if err := <-errs; err != gpio.ErrTimeout {
+		t.Errorf("Wait error = %v, want %v", err, gpio.ErrTimeout)
+	}
+
+	p.Set(1)
+	if e, _ := p.Wait(0); e.Time != c.Now() {
+		t.Errorf("edge at %v, want %v", e.Time, c.Now())
+	}
+}
@@ -0,0 +1,129 @@
+// Package grovehat implements a driver for the Seeed Grove Base HAT for
+// Raspberry Pi. The HAT converts the analog Grove ports with an onboard
+// microcontroller (STM32, or MM32 on the later boards), which implements
+// analog.ADC.
+package grovehat
+
+import (
+	"fmt"
+
+	"github.com/goiot/devices/analog"
+	"golang.org/x/exp/io/i2c"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+// I2C addresses of the STM32 and MM32 versions of the HAT.
+const (
+	Addr     = 0x04
+	AddrMM32 = 0x08
+)
+
+// Registers of the microcontroller, 16-bit little endian.
+const (
+	regProductID = 0x00
+	regVersion   = 0x03
+	regRaw       = 0x10 // + channel, 12-bit conversion result
+	regVoltage   = 0x20 // + channel, in mV
+
+	pidHAT     = 0x0004
+	pidHATZero = 0x0005
+)
+
+// Vref is the reference voltage of the converter, to give to
+// analog.Volts.
+const Vref = 3.3
+
+// Analog ports and the first of their two channels; the second one is
+// the next channel, e.g. A0 is read on channels 0 and 1.
+const (
+	A0 = 0
+	A2 = 2
+	A4 = 4
+	A6 = 6
+)
+
+// GPIOs of the digital ports; the second pin of a port is the next GPIO.
+const (
+	D5  = 5
+	D16 = 16
+	D18 = 18
+	D22 = 22
+	D24 = 24
+	D26 = 26
+	PWM = 12
+)
+
+// HAT represents a Grove Base HAT.
+type HAT struct {
+	Device *i2c.Device
+	name   string
+}
+
+// Open opens the HAT at addr, either Addr or AddrMM32.
+func Open(o driver.Opener, addr int) (*HAT, error) {
+	dev, err := i2c.Open(o, addr)
+	if err != nil {
+		return nil, err
+	}
+	h := &HAT{Device: dev}
+	pid, err := h.readReg(regProductID)
+	if err != nil {
+		dev.Close()
+		return nil, err
+	}
+	switch pid {
+	case pidHAT:
+		h.name = "Grove Base Hat RPi"
+	case pidHATZero:
+		h.name = "Grove Base Hat RPi Zero"
+	default:
+		dev.Close()
+		return nil, fmt.Errorf("unknown product ID %#x at address %#x", pid, addr)
+	}
+	return h, nil
+}
+
+func (h *HAT) readReg(reg byte) (int, error) {
+	b := make([]byte, 2)
+	if err := h.Device.ReadReg(reg, b); err != nil {
+		return 0, err
+	}
+	return int(b[0]) | int(b[1])<<8, nil
+}
+
+// Name returns the model of the HAT.
+func (h *HAT) Name() string { return h.name }
+
+// Version returns the firmware version of the microcontroller.
+func (h *HAT) Version() (int, error) {
+	return h.readReg(regVersion)
+}
+
+// Read returns the conversion result of the channel ch, between 0 and 4095.
+func (h *HAT) Read(ch int) (int, error) {
+	if err := analog.CheckChannel(ch, h.Channels()); err != nil {
+		return 0, err
+	}
+	return h.readReg(regRaw + byte(ch))
+}
+
+// Voltage returns the voltage of the channel ch in volts, as calibrated
+// by the microcontroller.
+func (h *HAT) Voltage(ch int) (float64, error) {
+	if err := analog.CheckChannel(ch, h.Channels()); err != nil {
+		return 0, err
+	}
+	mv, err := h.readReg(regVoltage + byte(ch))
+	return float64(mv) / 1000, err
+}
+
+// Channels returns 8.
+func (h *HAT) Channels() int { return 8 }
+
+// Resolution returns 12.
+func (h *HAT) Resolution() int { return 12 }
+
+// Close closes the HAT.
+func (h *HAT) Close() error {
+	return h.Device.Close()
+}
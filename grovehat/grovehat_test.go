@@ -0,0 +1,54 @@
+package grovehat
+
+import (
+	"testing"
+
+	"github.com/goiot/devices/analog"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+// mcu is a fake microcontroller with 16-bit registers.
+type mcu struct {
+	regs map[byte]int
+	reg  byte
+}
+
+func (m *mcu) Open(addr int, tenbit bool) (driver.Conn, error) { return m, nil }
+func (m *mcu) Close() error                                    { return nil }
+
+func (m *mcu) Tx(w, r []byte) error {
+	if len(w) > 0 {
+		m.reg = w[0]
+	}
+	if len(r) == 2 {
+		v := m.regs[m.reg]
+		r[0], r[1] = byte(v), byte(v>>8)
+	}
+	return nil
+}
+
+func TestRead(t *testing.T) {
+	m := &mcu{regs: map[byte]int{regProductID: pidHAT, regRaw + A2: 2048, regVoltage + A2: 1650}}
+	h, err := Open(m, Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var _ analog.ADC = h
+	if h.Name() != "Grove Base Hat RPi" {
+		t.Errorf("Name = %q", h.Name())
+	}
+	if v, err := h.Read(A2); err != nil || v != 2048 {
+		t.Errorf("Read = %v, %v; want 2048", v, err)
+	}
+	if v, err := h.Voltage(A2); err != nil || v != 1.65 {
+		t.Errorf("Voltage = %v, %v; want 1.65", v, err)
+	}
+	if _, err := h.Read(8); err == nil {
+		t.Error("expected an error on channel 8")
+	}
+
+	m.regs[regProductID] = 0x1234
+	if _, err := Open(m, Addr); err == nil {
+		t.Error("expected an error on an unknown product")
+	}
+}
@@ -0,0 +1,138 @@
+package hidout
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// The report descriptors of the boot keyboard and of a mouse with a
+// wheel, from the Linux HID gadget documentation.
+var (
+	keyboardDesc = []byte{
+		0x05, 0x01, 0x09, 0x06, 0xA1, 0x01, 0x05, 0x07, 0x19, 0xE0, 0x29, 0xE7, 0x15, 0x00, 0x25, 0x01,
+		0x75, 0x01, 0x95, 0x08, 0x81, 0x02, 0x95, 0x01, 0x75, 0x08, 0x81, 0x03, 0x95, 0x05, 0x75, 0x01,
+		0x05, 0x08, 0x19, 0x01, 0x29, 0x05, 0x91, 0x02, 0x95, 0x01, 0x75, 0x03, 0x91, 0x03, 0x95, 0x06,
+		0x75, 0x08, 0x15, 0x00, 0x25, 0x65, 0x05, 0x07, 0x19, 0x00, 0x29, 0x65, 0x81, 0x00, 0xC0,
+	}
+	mouseDesc = []byte{
+		0x05, 0x01, 0x09, 0x02, 0xA1, 0x01, 0x09, 0x01, 0xA1, 0x00, 0x05, 0x09, 0x19, 0x01, 0x29, 0x03,
+		0x15, 0x00, 0x25, 0x01, 0x95, 0x03, 0x75, 0x01, 0x81, 0x02, 0x95, 0x01, 0x75, 0x05, 0x81, 0x03,
+		0x05, 0x01, 0x09, 0x30, 0x09, 0x31, 0x09, 0x38, 0x15, 0x81, 0x25, 0x7F, 0x75, 0x08, 0x95, 0x03,
+		0x81, 0x06, 0xC0, 0xC0,
+	}
+)
+
+// Gadget is a USB gadget with a keyboard and a mouse.
+type Gadget struct {
+	// Name is the directory of the gadget in Root, "hidout" if empty.
+	Name string
+
+	// VendorID and ProductID identify the gadget, the ones of the Linux
+	// multifunction composite gadget if zero.
+	VendorID, ProductID uint16
+
+	Manufacturer, Product, Serial string
+
+	// Root is the directory of the gadgets in the configfs,
+	// /sys/kernel/config/usb_gadget if empty.
+	Root string
+
+	// UDC is the USB device controller the gadget is bound to, the first
+	// one of /sys/class/udc if empty.
+	UDC string
+}
+
+func (g *Gadget) dir() string {
+	root, name := g.Root, g.Name
+	if root == "" {
+		root = "/sys/kernel/config/usb_gadget"
+	}
+	if name == "" {
+		name = "hidout"
+	}
+	return filepath.Join(root, name)
+}
+
+// Create creates the gadget and binds it to the controller, the host then
+// enumerates the keyboard and the mouse. It requires the root privileges.
+func (g *Gadget) Create() error {
+	udc := g.UDC
+	if udc == "" {
+		udcs, err := ioutil.ReadDir("/sys/class/udc")
+		if err != nil || len(udcs) == 0 {
+			return fmt.Errorf("no USB device controller, is the dwc2 overlay enabled? - %v", err)
+		}
+		udc = udcs[0].Name()
+	}
+	vendor, product := g.VendorID, g.ProductID
+	if vendor == 0 && product == 0 {
+		vendor, product = 0x1D6B, 0x0104
+	}
+	d := g.dir()
+	config := filepath.Join(d, "configs/c.1")
+	files := []struct {
+		name string
+		data string
+	}{
+		{"idVendor", fmt.Sprintf("0x%04x", vendor)},
+		{"idProduct", fmt.Sprintf("0x%04x", product)},
+		{"bcdDevice", "0x0100"},
+		{"bcdUSB", "0x0200"},
+		{"strings/0x409/manufacturer", g.Manufacturer},
+		{"strings/0x409/product", g.Product},
+		{"strings/0x409/serialnumber", g.Serial},
+		{"configs/c.1/strings/0x409/configuration", "Keyboard and mouse"},
+		{"configs/c.1/MaxPower", "250"},
+		{"functions/hid.usb0/protocol", "1"},
+		{"functions/hid.usb0/subclass", "1"},
+		{"functions/hid.usb0/report_length", "8"},
+		{"functions/hid.usb0/report_desc", string(keyboardDesc)},
+		{"functions/hid.usb1/protocol", "2"},
+		{"functions/hid.usb1/subclass", "1"},
+		{"functions/hid.usb1/report_length", "4"},
+		{"functions/hid.usb1/report_desc", string(mouseDesc)},
+	}
+	for _, f := range files {
+		path := filepath.Join(d, f.name)
+		// the configfs creates the attributes with their directories
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("creating the gadget failed - %v", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(f.data), 0644); err != nil {
+			return fmt.Errorf("creating the gadget failed - %v", err)
+		}
+	}
+	// the keyboard is linked first to be /dev/hidg0
+	for _, f := range []string{"hid.usb0", "hid.usb1"} {
+		if err := os.Symlink(filepath.Join(d, "functions", f), filepath.Join(config, f)); err != nil {
+			return fmt.Errorf("creating the gadget failed - %v", err)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(d, "UDC"), []byte(udc), 0644); err != nil {
+		return fmt.Errorf("binding the gadget to %s failed - %v", udc, err)
+	}
+	return nil
+}
+
+// Remove unbinds the gadget and removes it.
+func (g *Gadget) Remove() error {
+	d := g.dir()
+	if err := ioutil.WriteFile(filepath.Join(d, "UDC"), []byte("\n"), 0644); err != nil {
+		return fmt.Errorf("unbinding the gadget failed - %v", err)
+	}
+	// the configfs removes the attributes with their directories, in the
+	// reverse order of their creation
+	for _, dir := range []string{
+		"configs/c.1/hid.usb0", "configs/c.1/hid.usb1",
+		"configs/c.1/strings/0x409", "configs/c.1",
+		"functions/hid.usb0", "functions/hid.usb1",
+		"strings/0x409", "",
+	} {
+		if err := os.Remove(filepath.Join(d, dir)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing the gadget failed - %v", err)
+		}
+	}
+	return nil
+}
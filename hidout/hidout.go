@@ -0,0 +1,244 @@
+// Package hidout presents a device with a USB device controller, such as
+// the Raspberry Pi Zero on its USB port, as a USB keyboard and mouse
+// driven by Go code, e.g. to forward the keys of a keypad or the turns of
+// an encoder to the host computer.
+//
+// Gadget creates the USB gadget with the Linux configfs, which requires
+// the dwc2 overlay (dtoverlay=dwc2 in /boot/config.txt) and the
+// libcomposite module loaded. The host then sees a keyboard, written to
+// with /dev/hidg0, and a mouse, written to with /dev/hidg1.
+package hidout
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Key is the usage ID of a key, in the keyboard page of the HID usage
+// tables. The modifier keys are the usages from LeftCtrl to RightGUI.
+type Key byte
+
+// The keys of a US keyboard, the letters and digits follow A and Num1.
+const (
+	A          Key = 0x04
+	Num1       Key = 0x1E
+	Num0       Key = 0x27
+	Enter      Key = 0x28
+	Escape     Key = 0x29
+	Backspace  Key = 0x2A
+	Tab        Key = 0x2B
+	Space      Key = 0x2C
+	Minus      Key = 0x2D
+	Equal      Key = 0x2E
+	LeftBrace  Key = 0x2F
+	RightBrace Key = 0x30
+	Backslash  Key = 0x31
+	Semicolon  Key = 0x33
+	Apostrophe Key = 0x34
+	Grave      Key = 0x35
+	Comma      Key = 0x36
+	Dot        Key = 0x37
+	Slash      Key = 0x38
+	CapsLock   Key = 0x39
+	F1         Key = 0x3A // to F12 at 0x45
+	Insert     Key = 0x49
+	Home       Key = 0x4A
+	PageUp     Key = 0x4B
+	Delete     Key = 0x4C
+	End        Key = 0x4D
+	PageDown   Key = 0x4E
+	Right      Key = 0x4F
+	Left       Key = 0x50
+	Down       Key = 0x51
+	Up         Key = 0x52
+
+	LeftCtrl   Key = 0xE0
+	LeftShift  Key = 0xE1
+	LeftAlt    Key = 0xE2
+	LeftGUI    Key = 0xE3
+	RightCtrl  Key = 0xE4
+	RightShift Key = 0xE5
+	RightAlt   Key = 0xE6
+	RightGUI   Key = 0xE7
+)
+
+// rollover is the number of keys other than the modifiers pressed at once
+// in a boot keyboard report.
+const rollover = 6
+
+// Keyboard is a boot protocol keyboard. It can be used by multiple
+// goroutines.
+type Keyboard struct {
+	w io.Writer
+
+	mu   sync.Mutex
+	mods byte
+	keys []Key
+}
+
+// NewKeyboard returns a keyboard sending its reports to w.
+func NewKeyboard(w io.Writer) *Keyboard {
+	return &Keyboard{w: w}
+}
+
+// OpenKeyboard opens the keyboard of the HID gadget device at path, e.g.
+// /dev/hidg0.
+func OpenKeyboard(path string) (*Keyboard, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeyboard(f), nil
+}
+
+// report sends the keys pressed.
+func (k *Keyboard) report() error {
+	b := make([]byte, 2+rollover)
+	b[0] = k.mods
+	for i, key := range k.keys {
+		b[2+i] = byte(key)
+	}
+	if _, err := k.w.Write(b); err != nil {
+		return fmt.Errorf("writing the keyboard report failed - %v", err)
+	}
+	return nil
+}
+
+func (k *Keyboard) press(key Key) {
+	if key >= LeftCtrl && key <= RightGUI {
+		k.mods |= 1 << (key - LeftCtrl)
+		return
+	}
+	for _, p := range k.keys {
+		if p == key {
+			return
+		}
+	}
+	k.keys = append(k.keys, key)
+}
+
+func (k *Keyboard) release(key Key) {
+	if key >= LeftCtrl && key <= RightGUI {
+		k.mods &^= 1 << (key - LeftCtrl)
+		return
+	}
+	for i, p := range k.keys {
+		if p == key {
+			k.keys = append(k.keys[:i], k.keys[i+1:]...)
+			return
+		}
+	}
+}
+
+// Press presses the keys, in addition to the ones already pressed. Up to
+// 6 keys other than the modifiers can be pressed at once.
+func (k *Keyboard) Press(keys ...Key) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	mods, pressed := k.mods, append([]Key(nil), k.keys...)
+	for _, key := range keys {
+		k.press(key)
+	}
+	if len(k.keys) > rollover {
+		k.mods, k.keys = mods, pressed
+		return fmt.Errorf("more than %d keys pressed at once", rollover)
+	}
+	return k.report()
+}
+
+// Release releases the keys.
+func (k *Keyboard) Release(keys ...Key) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for _, key := range keys {
+		k.release(key)
+	}
+	return k.report()
+}
+
+// ReleaseAll releases all the keys.
+func (k *Keyboard) ReleaseAll() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.mods, k.keys = 0, nil
+	return k.report()
+}
+
+// Tap presses the keys together and releases them, e.g. for the shortcut
+// Ctrl+Alt+Delete.
+func (k *Keyboard) Tap(keys ...Key) error {
+	if err := k.Press(keys...); err != nil {
+		return err
+	}
+	return k.Release(keys...)
+}
+
+// Type types s with the keys of a US layout, it supports the printable
+// ASCII characters, tabs and newlines.
+func (k *Keyboard) Type(s string) error {
+	for _, r := range s {
+		key, shift, ok := keyOf(r)
+		if !ok {
+			return fmt.Errorf("no key types %q", r)
+		}
+		keys := []Key{key}
+		if shift {
+			keys = []Key{LeftShift, key}
+		}
+		if err := k.Tap(keys...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shifted are the characters typed with shift on the keys of the
+// characters of unshifted.
+const (
+	unshifted = "1234567890-=[]\\;'`,./"
+	shifted   = "!@#$%^&*()_+{}|:\"~<>?"
+)
+
+// symbols are the keys of unshifted.
+var symbols = []Key{
+	Num1, Num1 + 1, Num1 + 2, Num1 + 3, Num1 + 4, Num1 + 5, Num1 + 6, Num1 + 7, Num1 + 8, Num0,
+	Minus, Equal, LeftBrace, RightBrace, Backslash, Semicolon, Apostrophe, Grave, Comma, Dot, Slash,
+}
+
+// keyOf returns the key typing r on a US layout, and whether it is
+// shifted.
+func keyOf(r rune) (Key, bool, bool) {
+	switch {
+	case r >= 'a' && r <= 'z':
+		return A + Key(r-'a'), false, true
+	case r >= 'A' && r <= 'Z':
+		return A + Key(r-'A'), true, true
+	case r == ' ':
+		return Space, false, true
+	case r == '\n':
+		return Enter, false, true
+	case r == '\t':
+		return Tab, false, true
+	}
+	for i, c := range unshifted {
+		if c == r {
+			return symbols[i], false, true
+		}
+	}
+	for i, c := range shifted {
+		if c == r {
+			return symbols[i], true, true
+		}
+	}
+	return 0, false, false
+}
+
+// Close closes the device if it is an io.Closer.
+func (k *Keyboard) Close() error {
+	if c, ok := k.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
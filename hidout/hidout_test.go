@@ -0,0 +1,119 @@
+package hidout
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// reports returns the reports of w, of n bytes each.
+func reports(w *bytes.Buffer, n int) []string {
+	var r []string
+	for b := w.Bytes(); len(b) >= n; b = b[n:] {
+		r = append(r, fmt.Sprintf("% X", b[:n]))
+	}
+	w.Reset()
+	return r
+}
+
+func TestKeyboard(t *testing.T) {
+	var w bytes.Buffer
+	k := NewKeyboard(&w)
+	if err := k.Tap(LeftCtrl, LeftAlt, Delete); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"05 00 4C 00 00 00 00 00", "00 00 00 00 00 00 00 00"}
+	if got := reports(&w, 8); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Ctrl+Alt+Delete reports %q; want %q", got, want)
+	}
+
+	k.Press(A, A+1, A+2, A+3, A+4, A+5)
+	if err := k.Press(A + 6); err == nil {
+		t.Error("Press() of a 7th key succeeded")
+	}
+	k.Release(A + 1)
+	k.Press(Up)
+	if got := reports(&w, 8); got[len(got)-1] != "00 00 04 06 07 08 09 52" {
+		t.Errorf("report %q; want 00 00 04 06 07 08 09 52", got[len(got)-1])
+	}
+	k.ReleaseAll()
+	w.Reset()
+
+	if err := k.Type("Hi!\n"); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{
+		"02 00 0B 00 00 00 00 00", "00 00 00 00 00 00 00 00",
+		"00 00 0C 00 00 00 00 00", "00 00 00 00 00 00 00 00",
+		"02 00 1E 00 00 00 00 00", "00 00 00 00 00 00 00 00",
+		"00 00 28 00 00 00 00 00", "00 00 00 00 00 00 00 00",
+	}
+	if got := reports(&w, 8); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Type(\"Hi!\\n\") reports %q; want %q", got, want)
+	}
+	if err := k.Type("é"); err == nil {
+		t.Error("Type(\"é\") succeeded")
+	}
+}
+
+func TestKeyOf(t *testing.T) {
+	for _, tt := range []struct {
+		r     rune
+		key   Key
+		shift bool
+	}{
+		{'z', A + 25, false},
+		{'0', Num0, false},
+		{')', Num0, true},
+		{'\\', Backslash, false},
+		{'|', Backslash, true},
+		{'"', Apostrophe, true},
+		{'?', Slash, true},
+		{'`', Grave, false},
+	} {
+		if key, shift, ok := keyOf(tt.r); !ok || key != tt.key || shift != tt.shift {
+			t.Errorf("keyOf(%q) = %#x, %v, %v; want %#x, %v", tt.r, key, shift, ok, tt.key, tt.shift)
+		}
+	}
+}
+
+func TestMouse(t *testing.T) {
+	var w bytes.Buffer
+	m := NewMouse(&w)
+	if err := m.Move(200, -10); err != nil {
+		t.Fatal(err)
+	}
+	m.Scroll(-1)
+	m.Click(LeftButton | RightButton)
+	want := []string{"00 7F F6 00", "00 49 00 00", "00 00 00 FF", "03 00 00 00", "00 00 00 00"}
+	if got := reports(&w, 4); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("reports %q; want %q", got, want)
+	}
+}
+
+func TestGadget(t *testing.T) {
+	root, err := ioutil.TempDir("", "hidout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	g := &Gadget{Root: root, UDC: "20980000.usb", Product: "Keypad"}
+	if err := g.Create(); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"idVendor":                         "0x1d6b",
+		"UDC":                              "20980000.usb",
+		"strings/0x409/product":            "Keypad",
+		"functions/hid.usb0/report_length": "8",
+		"configs/c.1/hid.usb1/protocol":    "2",
+	} {
+		b, err := ioutil.ReadFile(filepath.Join(root, "hidout", name))
+		if err != nil || string(b) != want {
+			t.Errorf("%s = %q, %v; want %q", name, b, err, want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package hidout
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Button is a set of mouse buttons.
+type Button byte
+
+const (
+	LeftButton Button = 1 << iota
+	RightButton
+	MiddleButton
+)
+
+// Mouse is a mouse with a wheel, moving relatively. It can be used by
+// multiple goroutines.
+type Mouse struct {
+	w io.Writer
+
+	mu      sync.Mutex
+	buttons Button
+}
+
+// NewMouse returns a mouse sending its reports to w.
+func NewMouse(w io.Writer) *Mouse {
+	return &Mouse{w: w}
+}
+
+// OpenMouse opens the mouse of the HID gadget device at path, e.g.
+// /dev/hidg1.
+func OpenMouse(path string) (*Mouse, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	return NewMouse(f), nil
+}
+
+// report sends the buttons pressed with a move and a scroll.
+func (m *Mouse) report(dx, dy, wheel int8) error {
+	if _, err := m.w.Write([]byte{byte(m.buttons), byte(dx), byte(dy), byte(wheel)}); err != nil {
+		return fmt.Errorf("writing the mouse report failed - %v", err)
+	}
+	return nil
+}
+
+// step returns the part of v sent in a report, from -127 to 127.
+func step(v int) int {
+	if v > 127 {
+		return 127
+	}
+	if v < -127 {
+		return -127
+	}
+	return v
+}
+
+// Move moves the pointer by dx and dy counts, right and down, with as
+// many reports as needed.
+func (m *Mouse) Move(dx, dy int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for dx != 0 || dy != 0 {
+		x, y := step(dx), step(dy)
+		if err := m.report(int8(x), int8(y), 0); err != nil {
+			return err
+		}
+		dx, dy = dx-x, dy-y
+	}
+	return nil
+}
+
+// Scroll turns the wheel by n detents, up if positive.
+func (m *Mouse) Scroll(n int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for n != 0 {
+		s := step(n)
+		if err := m.report(0, 0, int8(s)); err != nil {
+			return err
+		}
+		n -= s
+	}
+	return nil
+}
+
+// Press presses the buttons b.
+func (m *Mouse) Press(b Button) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.buttons |= b
+	return m.report(0, 0, 0)
+}
+
+// Release releases the buttons b.
+func (m *Mouse) Release(b Button) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.buttons &^= b
+	return m.report(0, 0, 0)
+}
+
+// Click presses and releases the buttons b.
+func (m *Mouse) Click(b Button) error {
+	if err := m.Press(b); err != nil {
+		return err
+	}
+	return m.Release(b)
+}
+
+// Close closes the device if it is an io.Closer.
+func (m *Mouse) Close() error {
+	if c, ok := m.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+// Package i2cbus discovers the I2C buses of a Linux host and returns
+// openers for them by friendly name, so programs don't have to hard code
+// device paths such as /dev/i2c-1.
+//
+// The buses are listed from /sys/class/i2c-dev, the "i2c-dev" kernel
+// module needs to be loaded.
+package i2cbus
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/io/i2c"
+)
+
+var (
+	sysfsDir = "/sys/class/i2c-dev"
+	devDir   = "/dev"
+)
+
+// ErrNotFound is returned if no bus matches the requested name.
+var ErrNotFound = errors.New("i2c bus not found")
+
+// Kind is what a bus is wired to.
+type Kind int
+
+const (
+	// Other is a bus of unknown usage.
+	Other Kind = iota
+	// Header is a bus available on the GPIO header of the board.
+	Header
+	// DDC is a display data channel, the bus of an HDMI, DVI or
+	// DisplayPort output reading the monitor's EDID.
+	DDC
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Header:
+		return "header"
+	case DDC:
+		return "ddc"
+	default:
+		return "other"
+	}
+}
+
+// Bus describes an I2C bus.
+type Bus struct {
+	// Number is the bus number, N in /dev/i2c-N.
+	Number int
+	// Dev is the device path of the bus, e.g. /dev/i2c-1.
+	Dev string
+	// Adapter is the name the kernel gives to the bus adapter.
+	Adapter string
+	// Kind is what the bus is wired to.
+	Kind Kind
+}
+
+// Name returns the friendly name of the bus, "busN".
+func (b Bus) Name() string {
+	return fmt.Sprintf("bus%d", b.Number)
+}
+
+// Opener returns the opener of the bus.
+func (b Bus) Opener() *i2c.Devfs {
+	return &i2c.Devfs{Dev: b.Dev}
+}
+
+// headerAdapters are device tree nodes of the I2C controllers wired to
+// the GPIO header of common boards.
+var headerAdapters = []string{
+	"i2c@7e804000", // Raspberry Pi 1-4, I2C1 on pins 3 and 5
+	"i2c@fe804000", // Raspberry Pi 4, I2C1 with the low peripheral mode disabled
+	"i2c@74000",    // Raspberry Pi 5, I2C1 on the RP1
+}
+
+// ddcAdapters are substrings of the names of display adapters.
+var ddcAdapters = []string{
+	"ddc", "hdmi", "dp aux", "dpddc", "gmbus", "i915",
+	"amdgpu", "radeon", "nvidia", "nouveau",
+	"i2c@7e805000",                 // Raspberry Pi 1-3 HDMI
+	"fef04500.i2c", "fef09500.i2c", // Raspberry Pi 4 HDMI0 and HDMI1
+}
+
+// Buses returns the I2C buses of the host, ordered by number.
+func Buses() ([]Bus, error) {
+	dirs, err := filepath.Glob(filepath.Join(sysfsDir, "i2c-*"))
+	if err != nil {
+		return nil, err
+	}
+	var buses []Bus
+	for _, dir := range dirs {
+		n, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(dir), "i2c-"))
+		if err != nil {
+			continue
+		}
+		name, _ := ioutil.ReadFile(filepath.Join(dir, "name"))
+		b := Bus{
+			Number:  n,
+			Dev:     filepath.Join(devDir, filepath.Base(dir)),
+			Adapter: strings.TrimSpace(string(name)),
+		}
+		// The device tree node tells controllers apart when the
+		// adapter name is generic.
+		node, _ := filepath.EvalSymlinks(filepath.Join(dir, "device", "of_node"))
+		b.Kind = classify(strings.ToLower(b.Adapter + " " + filepath.Base(node)))
+		buses = append(buses, b)
+	}
+	sort.Sort(byNumber(buses))
+	return buses, nil
+}
+
+func classify(id string) Kind {
+	for _, a := range headerAdapters {
+		if strings.Contains(id, a) {
+			return Header
+		}
+	}
+	for _, a := range ddcAdapters {
+		if strings.Contains(id, a) {
+			return DDC
+		}
+	}
+	return Other
+}
+
+type byNumber []Bus
+
+func (b byNumber) Len() int           { return len(b) }
+func (b byNumber) Less(i, j int) bool { return b[i].Number < b[j].Number }
+func (b byNumber) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+// Primary returns the bus devices are usually connected to: the bus
+// of the GPIO header if it is known, otherwise the first bus which is
+// not a display data channel.
+func Primary() (Bus, error) {
+	buses, err := Buses()
+	if err != nil {
+		return Bus{}, err
+	}
+	for _, b := range buses {
+		if b.Kind == Header {
+			return b, nil
+		}
+	}
+	for _, b := range buses {
+		if b.Kind != DDC {
+			return b, nil
+		}
+	}
+	return Bus{}, ErrNotFound
+}
+
+// Find returns the bus with the given name: "primary" (see Primary),
+// "busN" or a device path such as /dev/i2c-1.
+func Find(name string) (Bus, error) {
+	if name == "primary" {
+		return Primary()
+	}
+	buses, err := Buses()
+	if err != nil {
+		return Bus{}, err
+	}
+	for _, b := range buses {
+		if b.Name() == name || b.Dev == name {
+			return b, nil
+		}
+	}
+	return Bus{}, fmt.Errorf("%v: %q", ErrNotFound, name)
+}
+
+// Open returns the opener of the bus with the given name, see Find.
+func Open(name string) (*i2c.Devfs, error) {
+	b, err := Find(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(b.Dev); err != nil {
+		return nil, err
+	}
+	return b.Opener(), nil
+}
@@ -0,0 +1,89 @@
+package i2cbus
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeSysfs creates a sysfs and a dev tree with the given adapter names.
+func fakeSysfs(t *testing.T, adapters map[string]string) func() {
+	root, err := ioutil.TempDir("", "i2cbus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldSysfs, oldDev := sysfsDir, devDir
+	sysfsDir, devDir = filepath.Join(root, "sys"), filepath.Join(root, "dev")
+	os.MkdirAll(devDir, 0755)
+	for bus, name := range adapters {
+		dir := filepath.Join(sysfsDir, bus)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		ioutil.WriteFile(filepath.Join(dir, "name"), []byte(name+"\n"), 0644)
+		ioutil.WriteFile(filepath.Join(devDir, bus), nil, 0644)
+	}
+	return func() {
+		sysfsDir, devDir = oldSysfs, oldDev
+		os.RemoveAll(root)
+	}
+}
+
+func TestRaspberryPi(t *testing.T) {
+	defer fakeSysfs(t, map[string]string{
+		"i2c-1":  "bcm2835 (i2c@7e804000)",
+		"i2c-20": "fef04500.i2c",
+		"i2c-21": "fef09500.i2c",
+	})()
+	buses, err := Buses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Kind{Header, DDC, DDC}
+	if len(buses) != len(want) {
+		t.Fatalf("got %d buses, want %d", len(buses), len(want))
+	}
+	for i, b := range buses {
+		if b.Kind != want[i] {
+			t.Errorf("%v is %v, want %v", b.Name(), b.Kind, want[i])
+		}
+	}
+	p, err := Primary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Number != 1 {
+		t.Errorf("primary bus is %v, want bus1", p.Name())
+	}
+}
+
+func TestPrimarySkipsDDC(t *testing.T) {
+	defer fakeSysfs(t, map[string]string{
+		"i2c-0": "i915 gmbus dpc",
+		"i2c-3": "CH341 I2C USB bus 003 device 004",
+	})()
+	p, err := Primary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Number != 3 {
+		t.Errorf("primary bus is %v, want bus3", p.Name())
+	}
+}
+
+func TestOpen(t *testing.T) {
+	defer fakeSysfs(t, map[string]string{
+		"i2c-1": "bcm2835 (i2c@7e804000)",
+	})()
+	o, err := Open("bus1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(devDir, "i2c-1"); o.Dev != want {
+		t.Errorf("got %v, want %v", o.Dev, want)
+	}
+	if _, err := Open("bus2"); err == nil {
+		t.Error("expected an error opening a missing bus")
+	}
+}
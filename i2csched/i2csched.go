@@ -0,0 +1,257 @@
+// Package i2csched schedules the transfers of the devices sharing an I2C
+// bus. Each device has a budget, in transfers and in bytes per second,
+// and a priority: a chatty driver, such as the flushes of a display, is
+// throttled to its budget, and the time-sensitive reads of a sensor get
+// the bus first when several devices wait for it. The time waited by each
+// device is accounted, to find out the contention of the bus.
+//
+// The bus wraps the opener of the bus, and is given to the drivers in its
+// place:
+//
+//	bus := i2csched.New(opener)
+//	bus.SetBudget(0x3C, i2csched.Budget{Bandwidth: 4096}) // the OLED
+//	bus.SetBudget(0x68, i2csched.Budget{Priority: 10})    // an IMU
+//	oled, err := monochromeoled.Open(bus)
+package i2csched
+
+import (
+	"sync"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/writev"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+// Budget is the budget of a device.
+type Budget struct {
+	// Priority orders the transfers waiting for the bus, the highest
+	// first, in the order they came for the same priority.
+	Priority int
+
+	// Rate is the transfers per second of the device, unlimited if 0.
+	Rate float64
+
+	// Bandwidth is the bytes per second written and read by the device,
+	// unlimited if 0.
+	Bandwidth int
+}
+
+// Stats are the statistics of the transfers of a device.
+type Stats struct {
+	Transfers int64
+	Bytes     int64
+
+	// Busy is the time of the transfers on the bus.
+	Busy time.Duration
+
+	// Throttled is the time the transfers were delayed by the budget, and
+	// Waited the time they waited for the transfers of the other devices.
+	Throttled time.Duration
+	Waited    time.Duration
+
+	// MaxWait is the longest time a transfer waited for the bus.
+	MaxWait time.Duration
+}
+
+// Bus is an I2C bus shared by devices with budgets. It implements
+// driver.Opener, and can be used by multiple goroutines.
+type Bus struct {
+	// Clock times the budgets and the transfers, clock.Real if nil. It must
+	// be set before the devices are opened.
+	Clock clock.Clock
+
+	o driver.Opener
+
+	mu      sync.Mutex
+	devices map[int]*device
+	busy    bool
+	waiting []*waiter
+	seq     uint64
+}
+
+// device is the state of the devices at an address.
+type device struct {
+	budget Budget
+
+	// tokens left of the budget at last, below 0 while the transfers
+	// spending them wait
+	transfers, bytes float64
+	last             time.Time
+
+	stats Stats
+}
+
+// waiter is a transfer waiting for the bus.
+type waiter struct {
+	priority int
+	seq      uint64
+	ready    chan struct{}
+}
+
+// New returns the bus opened by o, the devices have no budget until it is
+// set.
+func New(o driver.Opener) *Bus {
+	return &Bus{o: o, devices: make(map[int]*device)}
+}
+
+// SetBudget sets the budget of the device at addr. A budget of 0
+// transfers and bytes per second is unlimited.
+func (b *Bus) SetBudget(addr int, budget Budget) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	d := b.device(addr)
+	d.budget = budget
+	d.transfers, d.bytes = budget.Rate, float64(budget.Bandwidth)
+	d.last = clock.Or(b.Clock).Now()
+}
+
+// Stats returns the statistics of the devices, by address.
+func (b *Bus) Stats() map[int]Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := make(map[int]Stats, len(b.devices))
+	for addr, d := range b.devices {
+		s[addr] = d.stats
+	}
+	return s
+}
+
+// device returns the device at addr, b.mu is held.
+func (b *Bus) device(addr int) *device {
+	d := b.devices[addr]
+	if d == nil {
+		d = &device{last: clock.Or(b.Clock).Now()}
+		b.devices[addr] = d
+	}
+	return d
+}
+
+// Open implements driver.Opener.
+func (b *Bus) Open(addr int, tenbit bool) (driver.Conn, error) {
+	c, err := b.o.Open(addr, tenbit)
+	if err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	b.device(addr)
+	b.mu.Unlock()
+	return &conn{bus: b, addr: addr, c: c}, nil
+}
+
+type conn struct {
+	bus  *Bus
+	addr int
+	c    driver.Conn
+}
+
+func (c *conn) Tx(w, r []byte) error {
+	return c.do(len(w)+len(r), func() error { return c.c.Tx(w, r) })
+}
+
+// WriteVec implements writev.Writer, the segments are passed on to the
+// connection of the bus as one transfer.
+func (c *conn) WriteVec(segs [][]byte) error {
+	n := 0
+	for _, s := range segs {
+		n += len(s)
+	}
+	return c.do(n, func() error { return writev.Write(c.c, segs...) })
+}
+
+// do runs the transfer tx of n bytes, within the budget of the device and
+// once the bus is granted.
+func (c *conn) do(n int, tx func() error) error {
+	b, clk := c.bus, clock.Or(c.bus.Clock)
+
+	start := clk.Now()
+	if d := b.spend(c.addr, n, start); d > 0 {
+		clk.Sleep(d)
+	}
+	throttled := clk.Now()
+	b.acquire(c.addr)
+	granted := clk.Now()
+	err := tx()
+	done := clk.Now()
+	b.release()
+
+	b.mu.Lock()
+	s := &b.devices[c.addr].stats
+	s.Transfers++
+	s.Bytes += int64(n)
+	s.Busy += done.Sub(granted)
+	s.Throttled += throttled.Sub(start)
+	s.Waited += granted.Sub(throttled)
+	if wait := granted.Sub(throttled); wait > s.MaxWait {
+		s.MaxWait = wait
+	}
+	b.mu.Unlock()
+	return err
+}
+
+func (c *conn) Close() error { return c.c.Close() }
+
+// spend spends a transfer of n bytes from the budget of the device at addr,
+// and returns the time the transfer must wait for the budget to be back
+// to 0. The budget refills up to a second of transfers, the burst of the
+// device.
+func (b *Bus) spend(addr, n int, now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	d := b.devices[addr]
+	elapsed := now.Sub(d.last).Seconds()
+	d.last = now
+	var wait time.Duration
+	refill := func(tokens *float64, rate, cost float64) {
+		if rate <= 0 {
+			return
+		}
+		*tokens += elapsed * rate
+		if *tokens > rate {
+			*tokens = rate
+		}
+		*tokens -= cost
+		if *tokens < 0 {
+			if w := time.Duration(-*tokens / rate * float64(time.Second)); w > wait {
+				wait = w
+			}
+		}
+	}
+	refill(&d.transfers, d.budget.Rate, 1)
+	refill(&d.bytes, float64(d.budget.Bandwidth), float64(n))
+	return wait
+}
+
+// acquire waits for the bus, granted to the transfers waiting by priority.
+func (b *Bus) acquire(addr int) {
+	b.mu.Lock()
+	if !b.busy {
+		b.busy = true
+		b.mu.Unlock()
+		return
+	}
+	b.seq++
+	w := &waiter{priority: b.devices[addr].budget.Priority, seq: b.seq, ready: make(chan struct{})}
+	b.waiting = append(b.waiting, w)
+	b.mu.Unlock()
+	<-w.ready
+}
+
+// release hands the bus over to the first transfer waiting.
+func (b *Bus) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.waiting) == 0 {
+		b.busy = false
+		return
+	}
+	next := 0
+	for i, w := range b.waiting {
+		if f := b.waiting[next]; w.priority > f.priority || w.priority == f.priority && w.seq < f.seq {
+			next = i
+		}
+	}
+	w := b.waiting[next]
+	b.waiting = append(b.waiting[:next], b.waiting[next+1:]...)
+	close(w.ready)
+}
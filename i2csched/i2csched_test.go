@@ -0,0 +1,154 @@
+package i2csched
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/i2csim"
+	"github.com/goiot/devices/writev"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+func TestBudget(t *testing.T) {
+	sim := i2csim.NewBus()
+	sim.Attach(0x3C, i2csim.DeviceFunc(func(w, r []byte) error { return nil }))
+	c := clock.NewFake(time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC))
+	c.SetAutoSleep(true)
+	start := c.Now()
+	bus := New(sim)
+	bus.Clock = c
+	bus.SetBudget(0x3C, Budget{Rate: 10, Bandwidth: 1000})
+	conn, err := bus.Open(0x3C, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a burst of a second of transfers, then a transfer every 100ms
+	for i := 0; i < 12; i++ {
+		if err := conn.Tx([]byte{0x40, 0}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := c.Now().Sub(start); d != 200*time.Millisecond {
+		t.Errorf("12 transfers at 10 per second took %v; want 200ms", d)
+	}
+
+	// 1500 bytes at 1000 bytes per second, from a full budget
+	c.Advance(10 * time.Second)
+	start = c.Now()
+	if err := conn.Tx(make([]byte, 1500), nil); err != nil {
+		t.Fatal(err)
+	}
+	if d := c.Now().Sub(start); d != 500*time.Millisecond {
+		t.Errorf("1500 bytes at 1000 bytes per second waited %v; want 500ms", d)
+	}
+
+	s := bus.Stats()[0x3C]
+	if s.Transfers != 13 || s.Bytes != 12*2+1500 || s.Throttled != 700*time.Millisecond || s.Waited != 0 {
+		t.Errorf("stats = %+v", s)
+	}
+}
+
+func TestPriority(t *testing.T) {
+	sim := i2csim.NewBus()
+	hold := make(chan struct{})
+	var mu sync.Mutex
+	var order []int
+	for _, addr := range []int{0x3C, 0x48, 0x68} {
+		addr := addr
+		sim.Attach(addr, i2csim.DeviceFunc(func(w, r []byte) error {
+			if addr == 0x3C {
+				<-hold
+			}
+			mu.Lock()
+			order = append(order, addr)
+			mu.Unlock()
+			return nil
+		}))
+	}
+	c := clock.NewFake(time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC))
+	bus := New(sim)
+	bus.Clock = c
+	bus.SetBudget(0x68, Budget{Priority: 10}) // the IMU
+	var wg sync.WaitGroup
+	tx := func(addr int) {
+		conn, err := bus.Open(addr, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := conn.Tx([]byte{0}, nil); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	waiting := func(n int) {
+		for i := 0; ; i++ {
+			bus.mu.Lock()
+			busy, w := bus.busy, len(bus.waiting)
+			bus.mu.Unlock()
+			if busy && w == n {
+				return
+			}
+			if i == 1000 {
+				t.Fatalf("%d transfers waiting, want %d", w, n)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	tx(0x3C) // the display flushing
+	waiting(0)
+	tx(0x48)
+	waiting(1)
+	tx(0x68)
+	waiting(2)
+	c.Advance(20 * time.Millisecond)
+	close(hold)
+	wg.Wait()
+
+	if len(order) != 3 || order[1] != 0x68 || order[2] != 0x48 {
+		t.Errorf("transfers in the order % x; want 3c 68 48", order)
+	}
+	s := bus.Stats()
+	if s[0x3C].Busy != 20*time.Millisecond || s[0x68].Waited != 20*time.Millisecond || s[0x48].MaxWait != 20*time.Millisecond {
+		t.Errorf("stats = %+v", s)
+	}
+}
+
+// vecBus opens connections writing segments as they are.
+type vecBus struct {
+	segs [][]byte
+}
+
+func (b *vecBus) Open(addr int, tenbit bool) (driver.Conn, error) { return b, nil }
+func (b *vecBus) Tx(w, r []byte) error                            { return nil }
+func (b *vecBus) Close() error                                    { return nil }
+
+func (b *vecBus) WriteVec(segs [][]byte) error {
+	b.segs = segs
+	return nil
+}
+
+func TestWriteVec(t *testing.T) {
+	sim := &vecBus{}
+	bus := New(sim)
+	bus.Clock = clock.NewFake(time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC))
+	conn, err := bus.Open(0x3C, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writev.Write(conn, []byte{0x40}, make([]byte, 128), make([]byte, 128)); err != nil {
+		t.Fatal(err)
+	}
+	if len(sim.segs) != 3 {
+		t.Errorf("the bus got %d segments; want 3", len(sim.segs))
+	}
+	if s := bus.Stats()[0x3C]; s.Transfers != 1 || s.Bytes != 257 {
+		t.Errorf("stats = %+v; want 1 transfer of 257 bytes", s)
+	}
+}
@@ -0,0 +1,30 @@
+package i2csim_test
+
+import (
+	"fmt"
+
+	"github.com/goiot/devices/i2csim"
+	"github.com/goiot/devices/ltr559"
+)
+
+func Example() {
+	// An LTR-559 light sensor answering at its address.
+	sensor := i2csim.NewRegisters()
+	sensor.Set(0x86, 0x92)           // part ID
+	sensor.Set(0x88, 100, 0, 200, 0) // light channels
+	sensor.ReadOnly(0x86, 0x88, 0x89, 0x8A, 0x8B)
+
+	bus := i2csim.NewBus()
+	bus.Attach(0x23, sensor)
+
+	dev, err := ltr559.Open(bus)
+	if err != nil {
+		panic(err)
+	}
+	lux, err := dev.Lux()
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("%.0f lux, ALS control %#x\n", lux, sensor.Get(0x80, 1)[0])
+	// Output: 465 lux, ALS control 0x1
+}
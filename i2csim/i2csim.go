@@ -0,0 +1,119 @@
+// Package i2csim provides a virtual I2C bus on which tests attach
+// emulated slave devices. The bus implements driver.Opener, so drivers
+// talk to the emulated devices exactly as they would to real ones and can
+// be tested end to end without hardware.
+package i2csim
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/fault"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+// ErrNAK is returned by a transfer to an address no device answers, and
+// by the injected NAK faults.
+var ErrNAK = fault.ErrNAK
+
+// Device is an emulated slave device. Tx is called for each transfer
+// addressed to the device, with the bytes written by the master in w and
+// r to be filled with the bytes read back.
+type Device interface {
+	Tx(w, r []byte) error
+}
+
+// DeviceFunc adapts a function to a Device.
+type DeviceFunc func(w, r []byte) error
+
+// Tx implements Device.
+func (f DeviceFunc) Tx(w, r []byte) error { return f(w, r) }
+
+// Bus is a virtual I2C bus. It can be used by multiple goroutines.
+type Bus struct {
+	// Clock times the delays of the faults injected, clock.Real if nil.
+	Clock clock.Clock
+
+	mu      sync.Mutex
+	devices map[int]Device
+	faults  map[int]*fault.Queue
+}
+
+// NewBus returns a bus without any device.
+func NewBus() *Bus {
+	return &Bus{
+		devices: make(map[int]Device),
+		faults:  make(map[int]*fault.Queue),
+	}
+}
+
+// Attach connects d to the bus at addr, replacing the device previously
+// attached there.
+func (b *Bus) Attach(addr int, d Device) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.devices[addr] = d
+}
+
+// Detach disconnects the device at addr, the following transfers to it
+// fail with ErrNAK.
+func (b *Bus) Detach(addr int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.devices, addr)
+}
+
+// Fail makes the next n transfers to addr fail with err, without
+// reaching the device.
+func (b *Bus) Fail(addr int, n int, err error) {
+	for i := 0; i < n; i++ {
+		b.Inject(addr, fault.Fault{Kind: fault.Error, Err: err})
+	}
+}
+
+// Inject queues faults for the next transfers to addr, one per transfer.
+func (b *Bus) Inject(addr int, faults ...fault.Fault) {
+	b.queue(addr).Inject(faults...)
+}
+
+func (b *Bus) queue(addr int) *fault.Queue {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	q := b.faults[addr]
+	if q == nil {
+		q = &fault.Queue{}
+		b.faults[addr] = q
+	}
+	return q
+}
+
+// Open implements driver.Opener. Like an i2c-dev file, opening an address
+// always succeeds, transfers fail if no device is attached to it.
+func (b *Bus) Open(addr int, tenbit bool) (driver.Conn, error) {
+	if tenbit {
+		return nil, fmt.Errorf("i2csim: 10-bit addresses are not supported")
+	}
+	return &conn{bus: b, addr: addr}, nil
+}
+
+type conn struct {
+	bus  *Bus
+	addr int
+}
+
+func (c *conn) Tx(w, r []byte) error {
+	f := c.bus.queue(c.addr).Next()
+	c.bus.mu.Lock()
+	d := c.bus.devices[c.addr]
+	c.bus.mu.Unlock()
+	if d == nil {
+		return ErrNAK
+	}
+	if f.Clock == nil {
+		f.Clock = c.bus.Clock
+	}
+	return f.Tx(w, r, d.Tx)
+}
+
+func (c *conn) Close() error { return nil }
@@ -0,0 +1,116 @@
+package i2csim
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/goiot/devices/fault"
+	"golang.org/x/exp/io/i2c"
+)
+
+func TestBus(t *testing.T) {
+	b := NewBus()
+	regs := NewRegisters()
+	b.Attach(0x40, regs)
+
+	dev, err := i2c.Open(b, 0x40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dev.WriteReg(0x10, []byte{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	if got := regs.Get(0x10, 3); !bytes.Equal(got, []byte{1, 2, 3}) {
+		t.Errorf("registers = %v, want [1 2 3]", got)
+	}
+
+	errBus := errors.New("arbitration lost")
+	b.Fail(0x40, 2, errBus)
+	buf := make([]byte, 2)
+	for i := 0; i < 2; i++ {
+		if err := dev.ReadReg(0x10, buf); err != errBus {
+			t.Errorf("ReadReg error = %v, want %v", err, errBus)
+		}
+	}
+	if err := dev.ReadReg(0x11, buf); err != nil || !bytes.Equal(buf, []byte{2, 3}) {
+		t.Errorf("ReadReg = %v, %v; want [2 3]", buf, err)
+	}
+
+	b.Detach(0x40)
+	if err := dev.ReadReg(0x10, buf); err != ErrNAK {
+		t.Errorf("ReadReg error = %v, want %v", err, ErrNAK)
+	}
+	if _, err := b.Open(0x40, true); err == nil {
+		t.Error("expected 10-bit addresses to be rejected")
+	}
+}
+
+func TestRegistersHooks(t *testing.T) {
+	regs := NewRegisters()
+	regs.Set(0xFF, 0xAA, 0xBB) // wraps around
+	if got := regs.Get(0x00, 1); got[0] != 0xBB {
+		t.Errorf("register 0 = %#x, want 0xbb", got[0])
+	}
+
+	regs.ReadOnly(0x01)
+	var reads int
+	regs.OnRead(0x02, func() byte { reads++; return byte(reads) })
+	var written []byte
+	regs.OnWrite(0x03, func(v byte) {
+		written = append(written, v)
+		regs.Set(0x04, v+1) // e.g. a conversion result
+	})
+
+	if err := regs.Tx([]byte{0x01, 0x11, 0x22, 0x33}, nil); err != nil {
+		t.Fatal(err)
+	}
+	r := make([]byte, 4)
+	if err := regs.Tx([]byte{0x01}, r); err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{0x00, 0x01, 0x33, 0x34}; !bytes.Equal(r, want) {
+		t.Errorf("read %x, want %x", r, want)
+	}
+	if !bytes.Equal(written, []byte{0x33}) {
+		t.Errorf("OnWrite got %x, want 33", written)
+	}
+
+	// the pointer moves on after a read
+	if err := regs.Tx(nil, r[:1]); err != nil || r[0] != 0 {
+		t.Errorf("read %#x, %v; want register 5", r[0], err)
+	}
+}
+
+func TestInject(t *testing.T) {
+	b := NewBus()
+	regs := NewRegisters()
+	regs.Set(0x00, 0x12, 0x34)
+	b.Attach(0x40, regs)
+	dev, err := i2c.Open(b, 0x40)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b.Inject(0x40,
+		fault.Fault{Kind: fault.NAK, Bytes: 2},
+		fault.Fault{Kind: fault.Partial, Bytes: 2},
+		fault.Fault{Kind: fault.BitFlip, Bytes: 0, Mask: 0x01},
+	)
+	if err := dev.WriteReg(0x10, []byte{1, 2, 3}); err != ErrNAK {
+		t.Errorf("WriteReg error = %v, want %v", err, ErrNAK)
+	}
+	if got := regs.Get(0x10, 3); !bytes.Equal(got, []byte{1, 0, 0}) {
+		t.Errorf("registers after the NAK = %v, want [1 0 0]", got)
+	}
+	if err := dev.WriteReg(0x20, []byte{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	if got := regs.Get(0x20, 3); !bytes.Equal(got, []byte{1, 0, 0}) {
+		t.Errorf("registers after the partial write = %v, want [1 0 0]", got)
+	}
+	buf := make([]byte, 2)
+	if err := dev.ReadReg(0x00, buf); err != nil || !bytes.Equal(buf, []byte{0x13, 0x34}) {
+		t.Errorf("ReadReg = %x, %v; want 1334", buf, err)
+	}
+}
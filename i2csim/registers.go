@@ -0,0 +1,118 @@
+package i2csim
+
+import "sync"
+
+// Registers emulates the usual register based device: the first byte of a
+// write selects a register, the following bytes are written from it and
+// reads start from the selected register. The register address is
+// incremented after each byte and wraps around at 256.
+//
+// Hooks make registers read only, compute the value of a register when it
+// is read or react to the writes, e.g. to start a conversion.
+type Registers struct {
+	mu       sync.Mutex
+	regs     [256]byte
+	ptr      byte
+	readOnly [256]bool
+	onRead   map[byte]func() byte
+	onWrite  map[byte]func(v byte)
+}
+
+// NewRegisters returns a device with all its registers set to zero.
+func NewRegisters() *Registers {
+	return &Registers{
+		onRead:  make(map[byte]func() byte),
+		onWrite: make(map[byte]func(v byte)),
+	}
+}
+
+// Set sets the registers starting at reg to v, bypassing the hooks.
+func (d *Registers) Set(reg byte, v ...byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, b := range v {
+		d.regs[reg] = b
+		reg++
+	}
+}
+
+// Get returns the n registers starting at reg, bypassing the hooks.
+func (d *Registers) Get(reg byte, n int) []byte {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	v := make([]byte, n)
+	for i := range v {
+		v[i] = d.regs[reg]
+		reg++
+	}
+	return v
+}
+
+// ReadOnly makes the master writes to the given registers ignored.
+func (d *Registers) ReadOnly(regs ...byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, r := range regs {
+		d.readOnly[r] = true
+	}
+}
+
+// OnRead makes f compute the value of reg each time the master reads it.
+func (d *Registers) OnRead(reg byte, f func() byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onRead[reg] = f
+}
+
+// OnWrite calls f each time the master writes reg, after the register is
+// updated. f is called without holding the device lock, so it can use Set
+// and Get.
+func (d *Registers) OnWrite(reg byte, f func(v byte)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onWrite[reg] = f
+}
+
+// Tx implements Device.
+func (d *Registers) Tx(w, r []byte) error {
+	type write struct {
+		f func(byte)
+		v byte
+	}
+	var hooks []write
+
+	d.mu.Lock()
+	if len(w) > 0 {
+		d.ptr = w[0]
+		for _, v := range w[1:] {
+			if !d.readOnly[d.ptr] {
+				d.regs[d.ptr] = v
+				if f := d.onWrite[d.ptr]; f != nil {
+					hooks = append(hooks, write{f, v})
+				}
+			}
+			d.ptr++
+		}
+	}
+	reads := make([]func() byte, len(r))
+	ptr := d.ptr
+	for i := range r {
+		r[i] = d.regs[ptr]
+		reads[i] = d.onRead[ptr]
+		ptr++
+	}
+	if len(r) > 0 {
+		d.ptr = ptr
+	}
+	d.mu.Unlock()
+
+	for _, h := range hooks {
+		h.f(h.v)
+	}
+	for i, f := range reads {
+		if f != nil {
+			r[i] = f()
+		}
+	}
+	return nil
+}
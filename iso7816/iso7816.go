@@ -0,0 +1,192 @@
+// Package iso7816 exchanges the ISO7816-4 APDUs with the smart cards: the
+// contactless ones of the transit, payment and access systems read by an
+// NFC reader such as the pn532 package, and the contact ones of a serial
+// reader. Exchange sends a command and collects its whole response, and
+// Select, ReadBinary and ReadRecord are the usual commands to read the
+// files of a card, whose records are BER-TLV decoded by ParseTLV.
+package iso7816
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Card is a card behind a reader.
+type Card interface {
+	// Transmit sends the command APDU and returns the response APDU,
+	// with its status bytes.
+	Transmit(apdu []byte) ([]byte, error)
+}
+
+// Command is a command APDU, in the short format.
+type Command struct {
+	Class, Ins, P1, P2 byte
+
+	// Data are the data sent, up to 255 bytes.
+	Data []byte
+
+	// Le is the number of bytes expected in the response, up to 256; 0
+	// if the command has no response data.
+	Le int
+}
+
+// Bytes returns the encoding of c.
+func (c Command) Bytes() ([]byte, error) {
+	if len(c.Data) > 255 {
+		return nil, fmt.Errorf("%d bytes of data, the short APDUs send up to 255", len(c.Data))
+	}
+	if c.Le < 0 || c.Le > 256 {
+		return nil, fmt.Errorf("invalid length %d expected", c.Le)
+	}
+	b := []byte{c.Class, c.Ins, c.P1, c.P2}
+	if len(c.Data) > 0 {
+		b = append(b, byte(len(c.Data)))
+		b = append(b, c.Data...)
+	}
+	if c.Le > 0 {
+		b = append(b, byte(c.Le)) // 256 is 0
+	}
+	return b, nil
+}
+
+// Status is the status word of a response, SW1 and SW2.
+type Status uint16
+
+// OK is the status of the commands completed.
+const OK Status = 0x9000
+
+var statuses = map[Status]string{
+	0x6281: "part of the data may be corrupted",
+	0x6282: "end of file reached",
+	0x6283: "file deactivated",
+	0x6300: "verification failed",
+	0x6581: "memory failure",
+	0x6700: "wrong length",
+	0x6881: "logical channel not supported",
+	0x6882: "secure messaging not supported",
+	0x6982: "security status not satisfied",
+	0x6983: "authentication method blocked",
+	0x6985: "conditions of use not satisfied",
+	0x6986: "command not allowed",
+	0x6A80: "incorrect data",
+	0x6A81: "function not supported",
+	0x6A82: "file or application not found",
+	0x6A83: "record not found",
+	0x6A86: "incorrect parameters",
+	0x6A88: "referenced data not found",
+	0x6B00: "wrong parameters",
+	0x6D00: "instruction not supported",
+	0x6E00: "class not supported",
+	0x6F00: "unknown error",
+}
+
+func (s Status) Error() string {
+	if m, ok := statuses[s]; ok {
+		return fmt.Sprintf("iso7816: %s (%04X)", m, uint16(s))
+	}
+	if s>>8 == 0x63 && s&0xF0 == 0xC0 {
+		return fmt.Sprintf("iso7816: verification failed, %d tries left", s&0x0F)
+	}
+	return fmt.Sprintf("iso7816: status %04X", uint16(s))
+}
+
+// Warning reports whether s is a warning: the command completed, and the
+// data may be returned.
+func (s Status) Warning() bool {
+	return s>>8 == 0x62 || s>>8 == 0x63
+}
+
+// Instructions of the commands of the package.
+const (
+	insSelect      = 0xA4
+	insReadBinary  = 0xB0
+	insReadRecord  = 0xB2
+	insGetResponse = 0xC0
+)
+
+// maxGetResponse bounds the GET RESPONSE chained by a card.
+const maxGetResponse = 64
+
+// Exchange sends c to card and returns the data of the response: if the
+// card has more data, as told by the status 61XX, they are collected with
+// GET RESPONSE, and if it asks for another length expected, with the
+// status 6CXX, c is sent again with it. The status of the responses other
+// than OK is returned as the error, with the data of the warnings.
+func Exchange(card Card, c Command) ([]byte, error) {
+	apdu, err := c.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	for i := 0; ; i++ {
+		if i > maxGetResponse {
+			return nil, errors.New("iso7816: too many GET RESPONSE")
+		}
+		r, err := card.Transmit(apdu)
+		if err != nil {
+			return nil, err
+		}
+		if len(r) < 2 {
+			return nil, fmt.Errorf("iso7816: response of %d bytes", len(r))
+		}
+		sw := Status(r[len(r)-2])<<8 | Status(r[len(r)-1])
+		data = append(data, r[:len(r)-2]...)
+		switch {
+		case sw == OK:
+			return data, nil
+		case sw>>8 == 0x61:
+			apdu = []byte{c.Class, insGetResponse, 0, 0, byte(sw)}
+		case sw>>8 == 0x6C && i == 0:
+			c.Le = le(byte(sw))
+			if apdu, err = c.Bytes(); err != nil {
+				return nil, err
+			}
+		case sw.Warning():
+			return data, sw
+		default:
+			return nil, sw
+		}
+	}
+}
+
+// le returns the length expected of the SW2 of a status, 0 for 256.
+func le(sw2 byte) int {
+	if sw2 == 0 {
+		return 256
+	}
+	return int(sw2)
+}
+
+// Select selects the application aid, e.g. the Proximity Payment System
+// Environment "2PAY.SYS.DDF01" of the contactless payment cards, and
+// returns its file control information.
+func Select(card Card, aid []byte) ([]byte, error) {
+	return Exchange(card, Command{Ins: insSelect, P1: 0x04, Data: aid, Le: 256})
+}
+
+// SelectFile selects the elementary or dedicated file of the identifier
+// fid under the current dedicated file.
+func SelectFile(card Card, fid uint16) ([]byte, error) {
+	return Exchange(card, Command{Ins: insSelect, P2: 0x0C, Data: []byte{byte(fid >> 8), byte(fid)}})
+}
+
+// ReadBinary reads n bytes, up to 256, of the transparent file selected
+// from offset, below 0x8000.
+func ReadBinary(card Card, offset, n int) ([]byte, error) {
+	if offset < 0 || offset > 0x7FFF {
+		return nil, fmt.Errorf("invalid offset %d", offset)
+	}
+	return Exchange(card, Command{Ins: insReadBinary, P1: byte(offset >> 8), P2: byte(offset), Le: n})
+}
+
+// ReadRecord reads the record of the number rec, from 1, of the record
+// file of the short identifier sfi, 1 to 30.
+func ReadRecord(card Card, sfi, rec int) ([]byte, error) {
+	if sfi < 1 || sfi > 30 {
+		return nil, fmt.Errorf("invalid short file identifier %d", sfi)
+	}
+	if rec < 1 || rec > 255 {
+		return nil, fmt.Errorf("invalid record number %d", rec)
+	}
+	return Exchange(card, Command{Ins: insReadRecord, P1: byte(rec), P2: byte(sfi<<3 | 0x04), Le: 256})
+}
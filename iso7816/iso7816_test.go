@@ -0,0 +1,107 @@
+package iso7816
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// card is a fake card answering the hex APDUs with the hex responses.
+type card struct {
+	apdus map[string]string
+	sent  []string
+}
+
+func (c *card) Transmit(apdu []byte) ([]byte, error) {
+	h := hex.EncodeToString(apdu)
+	c.sent = append(c.sent, h)
+	r, ok := c.apdus[h]
+	if !ok {
+		return []byte{0x6D, 0x00}, nil
+	}
+	return hex.DecodeString(r)
+}
+
+func TestBytes(t *testing.T) {
+	for _, tt := range []struct {
+		c    Command
+		want string
+	}{
+		{Command{Ins: 0xA4, P2: 0x0C, Data: []byte{0x3F, 0x00}}, "00a4000c023f00"},
+		{Command{Class: 0x80, Ins: 0xCA, P1: 0x9F, P2: 0x17, Le: 5}, "80ca9f1705"},
+		{Command{Ins: 0xB0, Le: 256}, "00b0000000"},
+		{Command{Ins: 0x20, P2: 0x80}, "00200080"},
+	} {
+		b, err := tt.c.Bytes()
+		if err != nil || hex.EncodeToString(b) != tt.want {
+			t.Errorf("%+v.Bytes() = %x, %v; want %s", tt.c, b, err, tt.want)
+		}
+	}
+	if _, err := (Command{Data: make([]byte, 256)}).Bytes(); err == nil {
+		t.Error("Bytes() succeeded with 256 bytes of data")
+	}
+	if _, err := (Command{Le: 257}).Bytes(); err == nil {
+		t.Error("Bytes() succeeded with 257 bytes expected")
+	}
+}
+
+func TestExchange(t *testing.T) {
+	c := &card{apdus: map[string]string{
+		// the data in two parts
+		"00b0000010": "01020304" + "6104",
+		"00c0000004": "05060708" + "9000",
+		// the wrong length expected
+		"00b2010c00": "6c03",
+		"00b2010c03": "aabbcc9000",
+		// end of file
+		"00b0000100": "09" + "6282",
+		// a PIN
+		"0020008000": "63c2",
+	}}
+	if b, err := ReadBinary(c, 0, 16); err != nil || !bytes.Equal(b, []byte{1, 2, 3, 4, 5, 6, 7, 8}) {
+		t.Errorf("ReadBinary() = % X, %v; want 01 to 08", b, err)
+	}
+	if b, err := ReadRecord(c, 1, 1); err != nil || !bytes.Equal(b, []byte{0xAA, 0xBB, 0xCC}) {
+		t.Errorf("ReadRecord() = % X, %v; want AA BB CC", b, err)
+	}
+	b, err := ReadBinary(c, 1, 256)
+	if err != Status(0x6282) || !bytes.Equal(b, []byte{9}) {
+		t.Errorf("ReadBinary() at the end = % X, %v; want 09, end of file", b, err)
+	}
+	if _, err := Exchange(c, Command{Ins: 0x20, P2: 0x80, Le: 256}); err != Status(0x63C2) {
+		t.Errorf("VERIFY = %v; want 63C2", err)
+	} else if got := err.Error(); got != "iso7816: verification failed, 2 tries left" {
+		t.Errorf("VERIFY error = %q", got)
+	}
+	if _, err := SelectFile(c, 0x2F00); err != Status(0x6D00) {
+		t.Errorf("SelectFile() = %v; want 6D00", err)
+	}
+	if _, err := ReadRecord(c, 31, 1); err == nil {
+		t.Error("ReadRecord() succeeded in the file 31")
+	}
+}
+
+func TestTLV(t *testing.T) {
+	// the FCI of a payment application, with a two bytes tag and a long
+	// form length
+	b, _ := hex.DecodeString("6f1e8407a0000000031010a513500a564953412044454249549f388103020000")
+	tlvs, err := ParseTLV(b)
+	if err != nil || len(tlvs) != 1 || tlvs[0].Tag != 0x6F || !tlvs[0].Constructed() {
+		t.Fatalf("ParseTLV() = %+v, %v; want a 6F template", tlvs, err)
+	}
+	if v, ok := Find(b, 0x84); !ok || hex.EncodeToString(v) != "a0000000031010" {
+		t.Errorf("AID = %x, %v", v, ok)
+	}
+	if v, ok := Find(b, 0x50); !ok || string(v) != "VISA DEBIT" {
+		t.Errorf("label = %q, %v", v, ok)
+	}
+	if v, ok := Find(b, 0x9F38); !ok || !bytes.Equal(v, []byte{0x02, 0x00, 0x00}) {
+		t.Errorf("PDOL = % X, %v", v, ok)
+	}
+	if _, ok := Find(b, 0x87); ok {
+		t.Error("found the missing tag 87")
+	}
+	if _, err := ParseTLV([]byte{0x6F, 0x05, 0x84}); err == nil {
+		t.Error("ParseTLV() succeeded with a truncated value")
+	}
+}
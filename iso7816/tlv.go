@@ -0,0 +1,88 @@
+package iso7816
+
+import "fmt"
+
+// TLV is a BER-TLV data object of the records of the cards.
+type TLV struct {
+	Tag   uint32 // with its class and constructed bits, e.g. 0x6F
+	Value []byte
+}
+
+// Constructed reports whether the value of t is made of other objects.
+func (t TLV) Constructed() bool {
+	first := t.Tag
+	for first > 0xFF {
+		first >>= 8
+	}
+	return first&0x20 != 0
+}
+
+// ParseTLV decodes the data objects of b, without their children; the
+// padding bytes 0x00 and 0xFF between them are skipped.
+func ParseTLV(b []byte) ([]TLV, error) {
+	var tlvs []TLV
+	for len(b) > 0 {
+		if b[0] == 0x00 || b[0] == 0xFF {
+			b = b[1:]
+			continue
+		}
+		tag, n := uint32(b[0]), 1
+		if b[0]&0x1F == 0x1F {
+			for {
+				if n >= len(b) || n > 3 {
+					return nil, fmt.Errorf("invalid tag % X", b[:n])
+				}
+				tag = tag<<8 | uint32(b[n])
+				n++
+				if b[n-1]&0x80 == 0 {
+					break
+				}
+			}
+		}
+		if n >= len(b) {
+			return nil, fmt.Errorf("missing length of the tag %X", tag)
+		}
+		l := int(b[n])
+		n++
+		if l > 0x80 {
+			sz := l & 0x7F
+			if sz > 3 || n+sz > len(b) {
+				return nil, fmt.Errorf("invalid length of the tag %X", tag)
+			}
+			l = 0
+			for _, v := range b[n : n+sz] {
+				l = l<<8 | int(v)
+			}
+			n += sz
+		} else if l == 0x80 {
+			return nil, fmt.Errorf("indefinite length of the tag %X", tag)
+		}
+		if n+l > len(b) {
+			return nil, fmt.Errorf("value of the tag %X of %d bytes, %d left", tag, l, len(b)-n)
+		}
+		tlvs = append(tlvs, TLV{Tag: tag, Value: b[n : n+l]})
+		b = b[n+l:]
+	}
+	return tlvs, nil
+}
+
+// Find returns the value of the first data object of the tag in b, looked
+// for in the constructed objects depth first, e.g. the application label
+// 0x50 of the file control information of Select.
+func Find(b []byte, tag uint32) ([]byte, bool) {
+	tlvs, err := ParseTLV(b)
+	if err != nil {
+		return nil, false
+	}
+	for _, t := range tlvs {
+		if t.Tag == tag {
+			return t.Value, true
+		}
+		if t.Constructed() {
+			if v, ok := Find(t.Value, tag); ok {
+				return v, true
+			}
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,21 @@
+package lc709203
+
+import "github.com/goiot/devices/caps"
+
+// Caps are the capabilities of the gauge.
+var Caps = caps.Capabilities{
+	Name:      "LC709203F",
+	Bus:       "i2c",
+	Addresses: []int{addr},
+	Measurements: []caps.Measurement{
+		{Kind: caps.Voltage, Unit: "V", Min: 2.5, Max: 5, Resolution: 1e-3},
+		{Kind: caps.Charge, Unit: "%", Min: 0, Max: 100, Resolution: 0.1},
+		{Kind: caps.Temperature, Unit: "C", Min: -30, Max: 80, Resolution: 0.1},
+	},
+	PowerModes: []string{"operational", "sleep"},
+}
+
+func init() { caps.Register(Caps) }
+
+// Capabilities implements caps.Describer.
+func (g *LC709203) Capabilities() caps.Capabilities { return Caps }
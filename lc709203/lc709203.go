@@ -0,0 +1,217 @@
+// Package lc709203 implements a driver for the ON Semiconductor LC709203F
+// fuel gauge, which reports the state of charge of a single cell lithium
+// battery.
+package lc709203
+
+import (
+	"fmt"
+
+	"golang.org/x/exp/io/i2c"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+const (
+	addr = 0x0B
+
+	regInitRSOC    = 0x07
+	regTemperature = 0x08
+	regVoltage     = 0x09
+	regAPA         = 0x0B
+	regRSOC        = 0x0D
+	regITE         = 0x0F
+	regVersion     = 0x11
+	regProfile     = 0x12
+	regAlarmRSOC   = 0x13
+	regAlarmVolt   = 0x14
+	regPowerMode   = 0x15
+	regStatus      = 0x16
+
+	powerOperational = 0x0001
+	powerSleep       = 0x0002
+
+	initRSOC = 0xAA55
+)
+
+// apas are the adjustment pack applications of the usual capacities of
+// the batteries, in mAh, from the datasheet.
+var apas = []struct {
+	capacity int
+	apa      uint16
+}{
+	{100, 0x08}, {200, 0x0B}, {500, 0x10}, {1000, 0x19}, {2000, 0x2D}, {3000, 0x36},
+}
+
+// Config describes the battery.
+type Config struct {
+	// Capacity is the design capacity of the battery in mAh, the gauge is
+	// adjusted for the closest of 100, 200, 500, 1000, 2000 and 3000mAh.
+	Capacity int
+
+	// Profile is the battery profile, 0 for the usual 3.7V batteries
+	// charged at 4.2V, 1 for the 3.8V batteries charged at 4.35V.
+	Profile int
+
+	// Thermistor is set if a thermistor is connected to the TSENSE pin,
+	// the temperature is set by SetTemperature otherwise.
+	Thermistor bool
+}
+
+// LC709203 represents an LC709203F fuel gauge.
+type LC709203 struct {
+	Device *i2c.Device
+}
+
+// Open opens the gauge and configures it for the battery. The state of
+// charge is estimated again from the voltage.
+func Open(o driver.Opener, c Config) (*LC709203, error) {
+	if c.Profile != 0 && c.Profile != 1 {
+		return nil, fmt.Errorf("invalid battery profile %d, must be 0 or 1", c.Profile)
+	}
+	dev, err := i2c.Open(o, addr)
+	if err != nil {
+		return nil, err
+	}
+	g := &LC709203{Device: dev}
+	status := uint16(0)
+	if c.Thermistor {
+		status = 1
+	}
+	for _, w := range []struct {
+		reg byte
+		v   uint16
+	}{
+		{regPowerMode, powerOperational},
+		{regAPA, apa(c.Capacity)},
+		{regProfile, uint16(c.Profile)},
+		{regStatus, status},
+		{regInitRSOC, initRSOC},
+	} {
+		if err := g.write(w.reg, w.v); err != nil {
+			dev.Close()
+			return nil, fmt.Errorf("configuring the gauge failed - %v", err)
+		}
+	}
+	return g, nil
+}
+
+// apa returns the adjustment pack application of the capacity in mAh.
+func apa(capacity int) uint16 {
+	best := apas[0]
+	for _, a := range apas[1:] {
+		if abs(a.capacity-capacity) < abs(best.capacity-capacity) {
+			best = a
+		}
+	}
+	return best.apa
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// crc8 is the CRC-8-ATM of the transfers, polynomial x^8+x^2+x+1.
+func crc8(b ...byte) byte {
+	var crc byte
+	for _, v := range b {
+		crc ^= v
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+func (g *LC709203) read(reg byte) (uint16, error) {
+	b := make([]byte, 3)
+	if err := g.Device.ReadReg(reg, b); err != nil {
+		return 0, err
+	}
+	if crc := crc8(addr<<1, reg, addr<<1|1, b[0], b[1]); crc != b[2] {
+		return 0, fmt.Errorf("invalid CRC %#02x of register %#02x, want %#02x", b[2], reg, crc)
+	}
+	return uint16(b[0]) | uint16(b[1])<<8, nil
+}
+
+func (g *LC709203) write(reg byte, v uint16) error {
+	lo, hi := byte(v), byte(v>>8)
+	return g.Device.Write([]byte{reg, lo, hi, crc8(addr<<1, reg, lo, hi)})
+}
+
+// Voltage returns the voltage of the battery in volts.
+func (g *LC709203) Voltage() (float64, error) {
+	v, err := g.read(regVoltage)
+	if err != nil {
+		return 0, err
+	}
+	return float64(v) / 1000, nil
+}
+
+// Charge returns the state of charge of the battery in percent.
+func (g *LC709203) Charge() (float64, error) {
+	v, err := g.read(regITE)
+	if err != nil {
+		return 0, err
+	}
+	return float64(v) / 10, nil
+}
+
+// Temperature returns the temperature of the battery in degrees Celsius,
+// measured by the thermistor or set by SetTemperature.
+func (g *LC709203) Temperature() (float64, error) {
+	v, err := g.read(regTemperature)
+	if err != nil {
+		return 0, err
+	}
+	return float64(v)/10 - 273.15, nil
+}
+
+// SetTemperature sets the temperature of the battery, in degrees Celsius
+// from -30 to 80, when there is no thermistor. It is 25 by default.
+func (g *LC709203) SetTemperature(celsius float64) error {
+	if celsius < -30 || celsius > 80 {
+		return fmt.Errorf("invalid temperature %v°C, should be between -30 and 80", celsius)
+	}
+	return g.write(regTemperature, uint16((celsius+273.15)*10+0.5))
+}
+
+// Version returns the version of the gauge.
+func (g *LC709203) Version() (uint16, error) {
+	return g.read(regVersion)
+}
+
+// SetAlarm sets the state of charge in percent and the voltage in volts
+// below which the gauge pulls its ALARMB pin low, zero disables an alarm.
+func (g *LC709203) SetAlarm(percent int, volts float64) error {
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("invalid alarm threshold %d%%, should be between 0 and 100", percent)
+	}
+	if volts < 0 || volts > 5 {
+		return fmt.Errorf("invalid alarm voltage %vV, should be between 0 and 5", volts)
+	}
+	if err := g.write(regAlarmRSOC, uint16(percent)); err != nil {
+		return err
+	}
+	return g.write(regAlarmVolt, uint16(volts*1000+0.5))
+}
+
+// Sleep puts the gauge in sleep mode, where it stops measuring, or wakes
+// it up.
+func (g *LC709203) Sleep(sleep bool) error {
+	mode := uint16(powerOperational)
+	if sleep {
+		mode = powerSleep
+	}
+	return g.write(regPowerMode, mode)
+}
+
+// Close closes the gauge.
+func (g *LC709203) Close() error {
+	return g.Device.Close()
+}
@@ -0,0 +1,105 @@
+package lc709203
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/goiot/devices/i2csim"
+)
+
+// gauge is a fake LC709203F with 16-bit registers, checking the CRC of
+// the writes and appending one to the reads.
+type gauge struct {
+	regs    map[byte]uint16
+	badCRC  bool
+	corrupt bool
+}
+
+func (g *gauge) Tx(w, r []byte) error {
+	reg := w[0]
+	if len(w) == 4 {
+		if crc8(addr<<1, reg, w[1], w[2]) != w[3] {
+			g.badCRC = true
+			return errors.New("NACK")
+		}
+		g.regs[reg] = uint16(w[1]) | uint16(w[2])<<8
+	}
+	if len(r) == 3 {
+		v := g.regs[reg]
+		r[0], r[1] = byte(v), byte(v>>8)
+		r[2] = crc8(addr<<1, reg, addr<<1|1, r[0], r[1])
+		if g.corrupt {
+			r[2]++
+		}
+	}
+	return nil
+}
+
+func newGauge(t *testing.T, c Config) (*LC709203, *gauge) {
+	fake := &gauge{regs: make(map[byte]uint16)}
+	bus := i2csim.NewBus()
+	bus.Attach(addr, fake)
+	g, err := Open(bus, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g, fake
+}
+
+func TestCRC(t *testing.T) {
+	// Example of the datasheet: write 0x0001 to 0x15 at the address 0x16.
+	if crc := crc8(0x16, 0x15, 0x01, 0x00); crc != 0x64 {
+		t.Errorf("crc8 = %#02x; want 0x64", crc)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	_, fake := newGauge(t, Config{Capacity: 400, Profile: 1})
+	if fake.badCRC {
+		t.Fatal("invalid CRC written")
+	}
+	for reg, want := range map[byte]uint16{regPowerMode: powerOperational, regAPA: 0x10, regProfile: 1, regInitRSOC: initRSOC} {
+		if v := fake.regs[reg]; v != want {
+			t.Errorf("register %#02x = %#04x; want %#04x", reg, v, want)
+		}
+	}
+	if _, err := Open(i2csim.NewBus(), Config{Profile: 2}); err == nil {
+		t.Error("expected an error on an invalid profile")
+	}
+}
+
+func TestRead(t *testing.T) {
+	g, fake := newGauge(t, Config{Capacity: 2000})
+	fake.regs[regVoltage] = 3812
+	fake.regs[regITE] = 875
+	fake.regs[regTemperature] = 2982
+	if v, err := g.Voltage(); err != nil || v != 3.812 {
+		t.Errorf("Voltage = %v, %v; want 3.812V", v, err)
+	}
+	if v, err := g.Charge(); err != nil || v != 87.5 {
+		t.Errorf("Charge = %v, %v; want 87.5%%", v, err)
+	}
+	if v, err := g.Temperature(); err != nil || v < 25.04 || v > 25.06 {
+		t.Errorf("Temperature = %v, %v; want 25.05°C", v, err)
+	}
+	fake.corrupt = true
+	if _, err := g.Voltage(); err == nil {
+		t.Error("expected an error on an invalid CRC")
+	}
+}
+
+func TestAlarm(t *testing.T) {
+	g, fake := newGauge(t, Config{Capacity: 1000})
+	if err := g.SetAlarm(10, 3.4); err != nil {
+		t.Fatal(err)
+	}
+	if fake.regs[regAlarmRSOC] != 10 || fake.regs[regAlarmVolt] != 3400 {
+		t.Errorf("alarms = %v%% %vmV; want 10%% 3400mV", fake.regs[regAlarmRSOC], fake.regs[regAlarmVolt])
+	}
+	if err := g.SetAlarm(120, 0); err == nil {
+		t.Error("expected an error on a threshold above 100%")
+	}
+	if err := g.SetTemperature(-5); err != nil || fake.regs[regTemperature] != 2682 {
+		t.Errorf("SetTemperature = %v; register %v, want 2682", err, fake.regs[regTemperature])
+	}
+}
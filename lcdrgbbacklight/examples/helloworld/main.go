@@ -3,14 +3,18 @@ package main
 import (
 	"time"
 
+	"github.com/goiot/devices/i2cbus"
 	"github.com/goiot/devices/lcdrgbbacklight"
-	"golang.org/x/exp/io/i2c"
 )
 
 func main() {
-	display, err := lcdrgbbacklight.Open(
-		&i2c.Devfs{Dev: "/dev/i2c-1"},
-	)
+	// the bus of the GPIO header, see i2cbus.Buses to list the others
+	bus, err := i2cbus.Open("primary")
+	if err != nil {
+		panic(err)
+	}
+
+	display, err := lcdrgbbacklight.Open(bus)
 	if err != nil {
 		panic(err)
 	}
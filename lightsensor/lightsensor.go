@@ -0,0 +1,106 @@
+// Package lightsensor defines the interface of the ambient light sensors,
+// and dims the displays in the dark with any of them.
+package lightsensor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/goiot/devices/clock"
+)
+
+// Sensor is an ambient light sensor, it is implemented by the drivers of
+// ltr559, max44009 and opt3001.
+type Sensor interface {
+	// Lux returns the ambient light in lux.
+	Lux() (float64, error)
+}
+
+// Func is a Sensor calling the function, e.g. Func(veml.AutoLux) for
+// the auto-ranged readings of a VEML7700.
+type Func func() (float64, error)
+
+// Lux calls f.
+func (f Func) Lux() (float64, error) { return f() }
+
+// Display is a display with an adjustable contrast, it is implemented by
+// monochromeoled.OLED.
+type Display interface {
+	SetContrast(level byte) error
+}
+
+// Contrast returns the contrast of a display for the light level, which
+// grows with the logarithm of the lux to be at its maximum of 255 from
+// 1000 lux, about the light of an office.
+func Contrast(lux float64) byte {
+	c := 255 * math.Log10(math.Max(lux, 0)+1) / 3
+	return byte(math.Min(c, 255))
+}
+
+// Dimmer sets the contrast of a display from the readings of a sensor.
+type Dimmer struct {
+	Sensor  Sensor
+	Display Display
+
+	// Min is the lowest contrast, for the display to stay readable in the
+	// dark.
+	Min byte
+
+	// ErrorLog logs the errors of Run, the standard logger is used if
+	// nil.
+	ErrorLog *log.Logger
+
+	// Clock times Run, clock.Real if nil.
+	Clock clock.Clock
+
+	level int // the contrast of the display, 0 until set
+}
+
+// Update reads the sensor and sets the contrast of the display if it
+// changed.
+func (d *Dimmer) Update() error {
+	lux, err := d.Sensor.Lux()
+	if err != nil {
+		return fmt.Errorf("reading the light sensor failed - %v", err)
+	}
+	c := Contrast(lux)
+	if c < d.Min {
+		c = d.Min
+	}
+	if int(c)+1 == d.level {
+		return nil
+	}
+	if err := d.Display.SetContrast(c); err != nil {
+		return fmt.Errorf("setting the contrast failed - %v", err)
+	}
+	d.level = int(c) + 1
+	return nil
+}
+
+// Run updates the contrast every interval until ctx is done, logging the
+// errors.
+func (d *Dimmer) Run(ctx context.Context, interval time.Duration) error {
+	t := clock.Or(d.Clock).NewTicker(interval)
+	defer t.Stop()
+	for {
+		if err := d.Update(); err != nil {
+			d.logf("lightsensor: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C():
+		}
+	}
+}
+
+func (d *Dimmer) logf(format string, args ...interface{}) {
+	if d.ErrorLog != nil {
+		d.ErrorLog.Printf(format, args...)
+		return
+	}
+	log.Printf(format, args...)
+}
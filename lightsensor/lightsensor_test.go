@@ -0,0 +1,60 @@
+package lightsensor
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/goiot/devices/ltr559"
+	"github.com/goiot/devices/max44009"
+	"github.com/goiot/devices/monochromeoled"
+	"github.com/goiot/devices/opt3001"
+	"github.com/goiot/devices/veml7700"
+)
+
+var (
+	_ Sensor  = (*ltr559.LTR559)(nil)
+	_ Sensor  = (*max44009.MAX44009)(nil)
+	_ Sensor  = (*opt3001.OPT3001)(nil)
+	_ Sensor  = Func((*veml7700.VEML7700)(nil).AutoLux)
+	_ Display = (*monochromeoled.OLED)(nil)
+)
+
+type display struct {
+	levels []byte
+}
+
+func (d *display) SetContrast(level byte) error {
+	d.levels = append(d.levels, level)
+	return nil
+}
+
+func TestContrast(t *testing.T) {
+	for _, tt := range []struct {
+		lux  float64
+		want byte
+	}{{-1, 0}, {0, 0}, {9, 85}, {999, 255}, {50000, 255}} {
+		if c := Contrast(tt.lux); c != tt.want {
+			t.Errorf("Contrast(%v) = %v; want %v", tt.lux, c, tt.want)
+		}
+	}
+}
+
+func TestDimmer(t *testing.T) {
+	lux, err := 999.0, error(nil)
+	d := &display{}
+	dim := &Dimmer{Sensor: Func(func() (float64, error) { return lux, err }), Display: d, Min: 10}
+	for _, l := range []float64{999, 999, 0, 0.1, 9} {
+		lux = l
+		if err := dim.Update(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Set once per change, not below Min.
+	if want := []byte{255, 10, 85}; string(d.levels) != string(want) {
+		t.Errorf("contrasts %v; want %v", d.levels, want)
+	}
+	err = errors.New("no sensor")
+	if dim.Update() == nil {
+		t.Error("expected an error of the sensor")
+	}
+}
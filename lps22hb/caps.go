@@ -0,0 +1,30 @@
+package lps22hb
+
+import "github.com/goiot/devices/caps"
+
+// Caps are the capabilities of the sensor.
+var Caps = caps.Capabilities{
+	Name:      "LPS22HB",
+	Bus:       "i2c",
+	Addresses: []int{Addr, Addr + 1},
+	Measurements: []caps.Measurement{
+		{Kind: caps.Pressure, Unit: "hPa", Min: 260, Max: 1260, Resolution: 1.0 / 4096},
+		{Kind: caps.Temperature, Unit: "C", Min: -40, Max: 85, Resolution: 0.01},
+	},
+	PowerModes: []string{"power-down", "one-shot", "continuous"},
+}
+
+func init() { caps.Register(Caps) }
+
+// Capabilities implements caps.Describer.
+func (s *LPS22HB) Capabilities() caps.Capabilities {
+	c := Caps
+	if s.chip == lps25h {
+		c.Name = "LPS25H"
+		c.Measurements = []caps.Measurement{
+			Caps.Measurements[0],
+			{Kind: caps.Temperature, Unit: "C", Min: -30, Max: 105, Resolution: 1.0 / 480},
+		}
+	}
+	return c
+}
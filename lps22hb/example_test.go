@@ -0,0 +1,46 @@
+package lps22hb_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/goiot/devices/derived"
+	"github.com/goiot/devices/events"
+	"github.com/goiot/devices/i2cbus"
+	"github.com/goiot/devices/lps22hb"
+)
+
+func Example() {
+	bus, err := i2cbus.Open("primary")
+	if err != nil {
+		panic(err)
+	}
+	s, err := lps22hb.Open(bus, lps22hb.Addr)
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	// 25 measurements per second, averaged by 8 to lower the noise
+	if err := s.SetRate(25); err != nil {
+		panic(err)
+	}
+	if err := s.SetAverage(8); err != nil {
+		panic(err)
+	}
+
+	// the altitude and vertical speed from the pressure, with the QNH of
+	// the nearest airport
+	r := derived.NewRegistry()
+	r.Add(derived.Altitude("altitude", "lps22hb/pressure", 1021))
+	r.Add(derived.VerticalSpeed("vspeed", "lps22hb/pressure"))
+	for range time.Tick(time.Second) {
+		hPa, _, err := s.Read()
+		if err != nil {
+			panic(err)
+		}
+		for _, sample := range r.Handle(events.Sample{Source: "lps22hb/pressure", Time: time.Now(), Value: hPa, Unit: "hPa"}) {
+			fmt.Printf("%v %.1f%v\n", sample.Source, sample.Value, sample.Unit)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+// Package lps22hb implements a driver for the ST LPS22HB and LPS25H
+// pressure sensors.
+package lps22hb
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"golang.org/x/exp/io/i2c"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+const (
+	// Addr is the I2C address of the sensors with their SA0 pin low, the
+	// one of the Sense HAT. They answer at 0x5D with the pin high.
+	Addr = 0x5C
+
+	regWhoAmI   = 0x0F
+	regStatus   = 0x27
+	regPressure = 0x28 // 5 bytes: 24 bits of pressure, 16 bits of temperature
+
+	powerOn  = 0x80 // in CTRL_REG1 of the LPS25H
+	oneShot  = 0x01 // in CTRL_REG2
+	fifoOn   = 0x40 // in CTRL_REG2
+	pressure = 0x01 // in STATUS, a new pressure is available
+
+	fifoStream = 0x40 // FIFO mode of the LPS22HB, of the last 32 samples
+	fifoMean   = 0xC0 // FIFO mode of the LPS25H, of the running average
+)
+
+// chip is the register map of a sensor.
+type chip struct {
+	id                   byte
+	ctrl1, ctrl2         byte
+	fifoCtrl, fifoStatus byte
+	on                   byte // control 1 of the power on, with block data update
+	ctrl2On              byte
+	autoInc              byte // sub-address bit of the multiple bytes reads
+	rates                []float64
+	celsius              func(int16) float64
+}
+
+var (
+	lps22hb = &chip{
+		id:    0xB1,
+		ctrl1: 0x10, ctrl2: 0x11,
+		fifoCtrl: 0x14, fifoStatus: 0x26,
+		on:      0x02,
+		ctrl2On: 0x10, // register address auto-increment
+		rates:   []float64{1, 10, 25, 50, 75},
+		celsius: func(t int16) float64 { return float64(t) / 100 },
+	}
+	lps25h = &chip{
+		id:    0xBD,
+		ctrl1: 0x20, ctrl2: 0x21,
+		fifoCtrl: 0x2E, fifoStatus: 0x2F,
+		on:      powerOn | 0x04,
+		autoInc: 0x80,
+		rates:   []float64{1, 7, 12.5, 25},
+		celsius: func(t int16) float64 { return 42.5 + float64(t)/480 },
+	}
+)
+
+// LPS22HB represents an LPS22HB or LPS25H sensor.
+type LPS22HB struct {
+	Device *i2c.Device
+	// Clock times the waits for the one-shot measurements, clock.Real if
+	// nil.
+	Clock clock.Clock
+
+	chip *chip
+	rate float64
+	avg  int
+}
+
+// Open opens an LPS22HB sensor at the address addr, in one-shot mode.
+func Open(o driver.Opener, addr int) (*LPS22HB, error) {
+	return open(o, addr, lps22hb)
+}
+
+// OpenLPS25H opens an LPS25H sensor at the address addr, in one-shot mode.
+func OpenLPS25H(o driver.Opener, addr int) (*LPS22HB, error) {
+	return open(o, addr, lps25h)
+}
+
+func open(o driver.Opener, addr int, c *chip) (*LPS22HB, error) {
+	dev, err := i2c.Open(o, addr)
+	if err != nil {
+		return nil, err
+	}
+	id := make([]byte, 1)
+	if err := dev.ReadReg(regWhoAmI, id); err != nil {
+		dev.Close()
+		return nil, err
+	}
+	if id[0] != c.id {
+		dev.Close()
+		return nil, fmt.Errorf("unexpected device ID %#x, expected %#x", id[0], c.id)
+	}
+	s := &LPS22HB{Device: dev, chip: c, avg: 1}
+	if err := s.configure(0, 1); err != nil {
+		dev.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *LPS22HB) configure(rate float64, avg int) error {
+	odr := byte(0)
+	for i, r := range s.chip.rates {
+		if r == rate {
+			odr = byte(i+1) << 4
+		}
+	}
+	ctrl2, fifo := s.chip.ctrl2On, byte(0)
+	if avg > 1 {
+		ctrl2 |= fifoOn
+		if fifo = fifoStream; s.chip == lps25h {
+			fifo = fifoMean | byte(avg-1)
+		}
+	}
+	for _, w := range [][]byte{
+		{s.chip.ctrl1, s.chip.on | odr},
+		{s.chip.ctrl2, ctrl2},
+		{s.chip.fifoCtrl, fifo},
+	} {
+		if err := s.Device.Write(w); err != nil {
+			return fmt.Errorf("configuring the sensor failed - %v", err)
+		}
+	}
+	s.rate, s.avg = rate, avg
+	return nil
+}
+
+// SetRate sets the output data rate in Hz, one of 1, 10, 25, 50 or 75 for
+// the LPS22HB and 1, 7, 12.5 or 25 for the LPS25H. A rate of zero is the
+// one-shot mode, the sensor measures once per call to Measure and is in
+// power down between them.
+func (s *LPS22HB) SetRate(hz float64) error {
+	if hz == 0 {
+		return s.configure(0, s.avg)
+	}
+	for _, r := range s.chip.rates {
+		if r == hz {
+			return s.configure(hz, s.avg)
+		}
+	}
+	return fmt.Errorf("unsupported output data rate %vHz", hz)
+}
+
+// SetAverage sets the number of measurements averaged in the FIFO of the
+// sensor to lower the noise, one of 1 (no averaging), 2, 4, 8, 16 or 32.
+// The LPS25H averages the last n measurements, the LPS22HB the ones
+// since the previous read, up to the last n.
+func (s *LPS22HB) SetAverage(n int) error {
+	switch n {
+	case 1, 2, 4, 8, 16, 32:
+		return s.configure(s.rate, n)
+	}
+	return fmt.Errorf("unsupported average of %v measurements", n)
+}
+
+// Read returns the last pressure in hPa and temperature of the sensor in
+// degrees Celsius, measured at the output data rate.
+func (s *LPS22HB) Read() (hPa, celsius float64, err error) {
+	if s.avg == 1 || s.chip == lps25h {
+		return s.read()
+	}
+	// The output registers of the LPS22HB are the oldest sample of the
+	// FIFO, each read pops it.
+	st := make([]byte, 1)
+	if err := s.Device.ReadReg(s.chip.fifoStatus, st); err != nil {
+		return 0, 0, err
+	}
+	n := int(st[0] & 0x3F)
+	if n == 0 {
+		return 0, 0, errors.New("no measurement in the FIFO")
+	}
+	var sumP, sumT float64
+	for i := 0; i < n; i++ {
+		p, t, err := s.read()
+		if err != nil {
+			return 0, 0, err
+		}
+		if i >= n-s.avg {
+			sumP, sumT = sumP+p, sumT+t
+		}
+	}
+	if n > s.avg {
+		n = s.avg
+	}
+	return sumP / float64(n), sumT / float64(n), nil
+}
+
+func (s *LPS22HB) read() (hPa, celsius float64, err error) {
+	b := make([]byte, 5)
+	if err := s.Device.ReadReg(s.chip.autoInc|regPressure, b); err != nil {
+		return 0, 0, err
+	}
+	p := int32(uint32(b[0])|uint32(b[1])<<8|uint32(b[2])<<16) << 8 >> 8 // 24-bit two's complement
+	return float64(p) / 4096, s.chip.celsius(int16(uint16(b[3]) | uint16(b[4])<<8)), nil
+}
+
+// Measure triggers a measurement in one-shot mode and returns the pressure
+// in hPa and temperature in degrees Celsius.
+func (s *LPS22HB) Measure() (hPa, celsius float64, err error) {
+	if s.rate != 0 {
+		return 0, 0, errors.New("the sensor is not in one-shot mode")
+	}
+	ctrl2 := s.chip.ctrl2On
+	if s.avg > 1 {
+		ctrl2 |= fifoOn
+	}
+	if err := s.Device.Write([]byte{s.chip.ctrl2, ctrl2 | oneShot}); err != nil {
+		return 0, 0, err
+	}
+	c := clock.Or(s.Clock)
+	st := make([]byte, 1)
+	for i := 0; ; i++ {
+		if err := s.Device.ReadReg(regStatus, st); err != nil {
+			return 0, 0, err
+		}
+		if st[0]&pressure != 0 {
+			break
+		}
+		if i == 10 {
+			return 0, 0, errors.New("measurement timed out")
+		}
+		c.Sleep(10 * time.Millisecond)
+	}
+	return s.read()
+}
+
+// Close powers the sensor down and closes it.
+func (s *LPS22HB) Close() error {
+	if err := s.Device.Write([]byte{s.chip.ctrl1, s.chip.on &^ powerOn}); err != nil {
+		s.Device.Close()
+		return err
+	}
+	return s.Device.Close()
+}
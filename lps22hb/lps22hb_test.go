@@ -0,0 +1,142 @@
+package lps22hb
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/i2csim"
+)
+
+// sensor is a fake LPS22HB or LPS25H, its FIFO holds the samples of the
+// pressure and temperature registers.
+type sensor struct {
+	chip *chip
+	regs [256]byte
+	fifo [][]byte
+	busy int // status reads until the end of a one-shot measurement
+}
+
+func (s *sensor) Tx(w, r []byte) error {
+	reg := w[0] &^ s.chip.autoInc
+	copy(s.regs[reg:], w[1:])
+	if reg == s.chip.ctrl2 && len(w) > 1 && w[1]&oneShot != 0 {
+		s.regs[reg] &^= oneShot
+		s.busy = 2
+	}
+	switch {
+	case len(r) == 0:
+	case reg == regStatus:
+		r[0] = 0
+		if s.busy > 0 {
+			s.busy--
+		} else {
+			r[0] = pressure
+		}
+	case reg == s.chip.fifoStatus:
+		r[0] = byte(len(s.fifo))
+	case reg == regPressure && s.regs[s.chip.ctrl2]&fifoOn != 0 && s.chip == lps22hb:
+		copy(r, s.fifo[0])
+		s.fifo = s.fifo[1:]
+	default:
+		copy(r, s.regs[reg:])
+	}
+	return nil
+}
+
+func newSensor(t *testing.T, c *chip) (*LPS22HB, *sensor) {
+	s := &sensor{chip: c}
+	s.regs[regWhoAmI] = c.id
+	bus := i2csim.NewBus()
+	bus.Attach(Addr, s)
+	open := Open
+	if c == lps25h {
+		open = OpenLPS25H
+	}
+	dev, err := open(bus, Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clk := clock.NewFake(time.Time{})
+	clk.SetAutoSleep(true)
+	dev.Clock = clk
+	return dev, s
+}
+
+func TestMeasure(t *testing.T) {
+	for _, tt := range []struct {
+		c       *chip
+		temp    []byte
+		celsius float64
+	}{
+		{lps22hb, []byte{0xC4, 0x09}, 25}, // 2500
+		{lps25h, []byte{0x90, 0xE8}, 30},  // -6000
+	} {
+		dev, s := newSensor(t, tt.c)
+		if ctrl1 := s.regs[tt.c.ctrl1]; ctrl1 != tt.c.on {
+			t.Errorf("CTRL_REG1 = %#x; want the one-shot mode %#x", ctrl1, tt.c.on)
+		}
+		copy(s.regs[regPressure:], append([]byte{0x00, 0x54, 0x3F}, tt.temp...))
+		p, c, err := dev.Measure()
+		if err != nil || p != 1013.25 || c != tt.celsius {
+			t.Errorf("Measure = %v, %v, %v; want 1013.25, %v", p, c, err, tt.celsius)
+		}
+
+		if err := dev.SetRate(10); (err == nil) != (tt.c == lps22hb) {
+			t.Errorf("SetRate(10) = %v", err)
+		}
+		if err := dev.SetRate(25); err != nil {
+			t.Fatal(err)
+		}
+		if ctrl1 := s.regs[tt.c.ctrl1]; ctrl1&0x70 == 0 {
+			t.Errorf("CTRL_REG1 = %#x without an output data rate", ctrl1)
+		}
+		if _, _, err := dev.Measure(); err == nil {
+			t.Error("expected an error with Measure out of the one-shot mode")
+		}
+		if p, _, err := dev.Read(); err != nil || p != 1013.25 {
+			t.Errorf("Read = %v, %v; want 1013.25", p, err)
+		}
+		if err := dev.Close(); err != nil || s.regs[tt.c.ctrl1]&0x70 != 0 || s.regs[tt.c.ctrl1]&powerOn != 0 {
+			t.Errorf("Close = %v, the sensor is not powered down: %#x", err, s.regs[tt.c.ctrl1])
+		}
+	}
+}
+
+func TestAverage(t *testing.T) {
+	dev, s := newSensor(t, lps25h)
+	if err := dev.SetAverage(3); err == nil {
+		t.Error("expected an error with an average of 3 measurements")
+	}
+	if err := dev.SetAverage(8); err != nil {
+		t.Fatal(err)
+	}
+	if s.regs[lps25h.ctrl2]&fifoOn == 0 || s.regs[lps25h.fifoCtrl] != fifoMean|7 {
+		t.Errorf("the FIFO mean mode is not set: CTRL_REG2 %#x FIFO_CTRL %#x", s.regs[lps25h.ctrl2], s.regs[lps25h.fifoCtrl])
+	}
+
+	// The LPS22HB averages the last samples in the FIFO.
+	dev, s = newSensor(t, lps22hb)
+	if err := dev.SetAverage(2); err != nil {
+		t.Fatal(err)
+	}
+	if s.regs[lps22hb.fifoCtrl] != fifoStream {
+		t.Errorf("FIFO_CTRL = %#x; want the stream mode", s.regs[lps22hb.fifoCtrl])
+	}
+	s.fifo = [][]byte{
+		{0x00, 0x00, 0x3E, 0x00, 0x00}, // 992hPa, dropped
+		{0x00, 0x00, 0x3F, 0xE8, 0x03}, // 1008hPa and 10°C
+		{0x00, 0x20, 0x3F, 0xD0, 0x07}, // 1010hPa and 20°C
+	}
+	p, c, err := dev.Read()
+	if err != nil || math.Abs(p-1009) > 1e-9 || math.Abs(c-15) > 1e-9 {
+		t.Errorf("Read = %v, %v, %v; want 1009, 15", p, c, err)
+	}
+	if len(s.fifo) != 0 {
+		t.Errorf("%v samples left in the FIFO", len(s.fifo))
+	}
+	if _, _, err := dev.Read(); err == nil {
+		t.Error("expected an error with an empty FIFO")
+	}
+}
@@ -0,0 +1,20 @@
+package ltr559
+
+import "github.com/goiot/devices/caps"
+
+// Caps are the capabilities of the sensor.
+var Caps = caps.Capabilities{
+	Name:      "LTR-559",
+	Bus:       "i2c",
+	Addresses: []int{addr},
+	Measurements: []caps.Measurement{
+		{Kind: caps.Illuminance, Unit: "lx", Min: 0.01, Max: 64000, Resolution: 0.01},
+		{Kind: caps.Proximity, Unit: "counts", Min: 0, Max: 2047, Resolution: 1},
+	},
+	PowerModes: []string{"standby", "active"},
+}
+
+func init() { caps.Register(Caps) }
+
+// Capabilities implements caps.Describer.
+func (s *LTR559) Capabilities() caps.Capabilities { return Caps }
@@ -0,0 +1,100 @@
+// Package ltr559 implements a driver for the Lite-On LTR-559 ambient light
+// and proximity sensor.
+package ltr559
+
+import (
+	"fmt"
+
+	"golang.org/x/exp/io/i2c"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+const (
+	addr = 0x23
+
+	regALSControl = 0x80
+	regPSControl  = 0x81
+	regPartID     = 0x86
+	regALSData    = 0x88 // 4 bytes: channel 1 then channel 0
+	regPSData     = 0x8D // 2 bytes, 11 bits
+
+	partID = 0x92
+
+	alsActive = 0x01 // gain 1x
+	psActive  = 0x03
+
+	// integration time of the default ALS_MEAS_RATE, in units of 100ms
+	alsIntegration = 1
+)
+
+// LTR559 represents an LTR-559 sensor.
+type LTR559 struct {
+	Device *i2c.Device
+}
+
+// Open opens the sensor and starts the light and proximity measurements.
+func Open(o driver.Opener) (*LTR559, error) {
+	dev, err := i2c.Open(o, addr)
+	if err != nil {
+		return nil, err
+	}
+	id := make([]byte, 1)
+	if err := dev.ReadReg(regPartID, id); err != nil {
+		dev.Close()
+		return nil, err
+	}
+	if id[0] != partID {
+		dev.Close()
+		return nil, fmt.Errorf("unexpected part ID %#x, expected %#x", id[0], partID)
+	}
+	for _, w := range [][]byte{
+		{regALSControl, alsActive},
+		{regPSControl, psActive},
+	} {
+		if err := dev.Write(w); err != nil {
+			dev.Close()
+			return nil, err
+		}
+	}
+	return &LTR559{Device: dev}, nil
+}
+
+// Lux returns the ambient light in lux.
+func (s *LTR559) Lux() (float64, error) {
+	b := make([]byte, 4)
+	if err := s.Device.ReadReg(regALSData, b); err != nil {
+		return 0, err
+	}
+	ch1 := float64(uint16(b[0]) | uint16(b[1])<<8)
+	ch0 := float64(uint16(b[2]) | uint16(b[3])<<8)
+	if ch0+ch1 == 0 {
+		return 0, nil
+	}
+
+	// Appendix A of the datasheet.
+	var lux float64
+	switch ratio := ch1 / (ch0 + ch1); {
+	case ratio < 0.45:
+		lux = 1.7743*ch0 + 1.1059*ch1
+	case ratio < 0.64:
+		lux = 4.2785*ch0 - 1.9548*ch1
+	case ratio < 0.85:
+		lux = 0.5926*ch0 + 0.1185*ch1
+	}
+	return lux / alsIntegration, nil
+}
+
+// Proximity returns the raw proximity reading, between 0 (nothing in
+// front of the sensor) and 2047.
+func (s *LTR559) Proximity() (int, error) {
+	b := make([]byte, 2)
+	if err := s.Device.ReadReg(regPSData, b); err != nil {
+		return 0, err
+	}
+	return int(b[0]) | int(b[1]&0x07)<<8, nil
+}
+
+// Close closes the sensor.
+func (s *LTR559) Close() error {
+	return s.Device.Close()
+}
@@ -0,0 +1,47 @@
+package ltr559
+
+import (
+	"math"
+	"testing"
+
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+// sensor is a fake LTR-559 with a map of 256 registers.
+type sensor struct {
+	regs [256]byte
+	reg  byte
+}
+
+func (s *sensor) Open(addr int, tenbit bool) (driver.Conn, error) { return s, nil }
+func (s *sensor) Close() error                                    { return nil }
+
+func (s *sensor) Tx(w, r []byte) error {
+	if len(w) > 0 {
+		s.reg = w[0]
+		copy(s.regs[s.reg:], w[1:])
+	}
+	copy(r, s.regs[s.reg:])
+	return nil
+}
+
+func TestRead(t *testing.T) {
+	s := &sensor{}
+	s.regs[regPartID] = partID
+	dev, err := Open(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.regs[regALSControl] != alsActive || s.regs[regPSControl] != psActive {
+		t.Error("the measurements are not started")
+	}
+
+	copy(s.regs[regALSData:], []byte{100, 0, 200, 0}) // ch1=100 ch0=200
+	if lux, err := dev.Lux(); err != nil || math.Abs(lux-465.45) > 1e-9 {
+		t.Errorf("Lux = %v, %v", lux, err)
+	}
+	copy(s.regs[regPSData:], []byte{0x34, 0xF2}) // upper bits are reserved
+	if p, err := dev.Proximity(); err != nil || p != 0x234 {
+		t.Errorf("Proximity = %#x, %v; want 0x234", p, err)
+	}
+}
@@ -0,0 +1,30 @@
+package max17043
+
+import "github.com/goiot/devices/caps"
+
+// Caps are the capabilities of the gauge.
+var Caps = caps.Capabilities{
+	Name:      "MAX17043",
+	Bus:       "i2c",
+	Addresses: []int{addr},
+	Measurements: []caps.Measurement{
+		{Kind: caps.Voltage, Unit: "V", Min: 0, Max: 5.12, Resolution: 1.25e-3},
+		{Kind: caps.Charge, Unit: "%", Min: 0, Max: 100, Resolution: 1.0 / 256},
+	},
+	PowerModes: []string{"active", "sleep"},
+}
+
+func init() { caps.Register(Caps) }
+
+// Capabilities implements caps.Describer.
+func (g *MAX17043) Capabilities() caps.Capabilities {
+	c := Caps
+	if g.cells == 2 {
+		c.Name = "MAX17044"
+		c.Measurements = []caps.Measurement{
+			{Kind: caps.Voltage, Unit: "V", Min: 0, Max: 10.24, Resolution: 2.5e-3},
+			Caps.Measurements[1],
+		}
+	}
+	return c
+}
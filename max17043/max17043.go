@@ -0,0 +1,159 @@
+// Package max17043 implements a driver for the Maxim MAX17043 and MAX17044
+// fuel gauges, which report the state of charge of a lithium battery from
+// its voltage alone.
+package max17043
+
+import (
+	"fmt"
+
+	"golang.org/x/exp/io/i2c"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+const (
+	addr = 0x36
+
+	regVCell   = 0x02
+	regSOC     = 0x04
+	regMode    = 0x06
+	regVersion = 0x08
+	regConfig  = 0x0C
+	regCommand = 0xFE
+
+	modeQuickStart = 0x4000
+	commandPOR     = 0x5400
+
+	configSleep = 0x80
+	configAlert = 0x20
+)
+
+// MAX17043 represents a MAX17043 fuel gauge.
+type MAX17043 struct {
+	Device *i2c.Device
+
+	cells int
+}
+
+// Open opens the gauge of a single cell battery, the MAX17043.
+func Open(o driver.Opener) (*MAX17043, error) {
+	return open(o, 1)
+}
+
+// OpenMAX17044 opens the gauge of a two cells battery, the MAX17044.
+func OpenMAX17044(o driver.Opener) (*MAX17043, error) {
+	return open(o, 2)
+}
+
+func open(o driver.Opener, cells int) (*MAX17043, error) {
+	dev, err := i2c.Open(o, addr)
+	if err != nil {
+		return nil, err
+	}
+	g := &MAX17043{Device: dev, cells: cells}
+	if _, err := g.read(regVersion); err != nil {
+		dev.Close()
+		return nil, fmt.Errorf("reading the version failed - %v", err)
+	}
+	return g, nil
+}
+
+func (g *MAX17043) read(reg byte) (uint16, error) {
+	b := make([]byte, 2)
+	if err := g.Device.ReadReg(reg, b); err != nil {
+		return 0, err
+	}
+	return uint16(b[0])<<8 | uint16(b[1]), nil
+}
+
+func (g *MAX17043) write(reg byte, v uint16) error {
+	return g.Device.Write([]byte{reg, byte(v >> 8), byte(v)})
+}
+
+// Voltage returns the voltage of the battery in volts.
+func (g *MAX17043) Voltage() (float64, error) {
+	v, err := g.read(regVCell)
+	if err != nil {
+		return 0, err
+	}
+	// 12 bits in units of 1.25mV per cell.
+	return float64(v>>4) * 1.25e-3 * float64(g.cells), nil
+}
+
+// Charge returns the state of charge of the battery in percent.
+func (g *MAX17043) Charge() (float64, error) {
+	v, err := g.read(regSOC)
+	if err != nil {
+		return 0, err
+	}
+	return float64(v) / 256, nil
+}
+
+// Version returns the production version of the gauge.
+func (g *MAX17043) Version() (uint16, error) {
+	return g.read(regVersion)
+}
+
+// QuickStart restarts the estimation of the state of charge, e.g. after
+// the battery was swapped. The voltage is best settled when it is called.
+func (g *MAX17043) QuickStart() error {
+	return g.write(regMode, modeQuickStart)
+}
+
+// Reset resets the gauge as at power-on.
+func (g *MAX17043) Reset() error {
+	// The gauge resets without acknowledging the command.
+	g.write(regCommand, commandPOR)
+	return nil
+}
+
+// SetAlert sets the state of charge, from 1 to 32 percent, below which the
+// gauge raises its alert and pulls its ALRT pin low. It clears the alert.
+func (g *MAX17043) SetAlert(percent int) error {
+	if percent < 1 || percent > 32 {
+		return fmt.Errorf("invalid alert threshold %d%%, should be between 1 and 32", percent)
+	}
+	c, err := g.read(regConfig)
+	if err != nil {
+		return err
+	}
+	c = c&^(configAlert|0x1F) | uint16(32-percent)
+	return g.write(regConfig, c)
+}
+
+// Alert reports whether the alert is raised. It stays raised until it is
+// cleared by ClearAlert.
+func (g *MAX17043) Alert() (bool, error) {
+	c, err := g.read(regConfig)
+	if err != nil {
+		return false, err
+	}
+	return c&configAlert != 0, nil
+}
+
+// ClearAlert clears the alert.
+func (g *MAX17043) ClearAlert() error {
+	c, err := g.read(regConfig)
+	if err != nil {
+		return err
+	}
+	return g.write(regConfig, c&^configAlert)
+}
+
+// Sleep puts the gauge in sleep mode, where it draws less than 1µA and
+// stops measuring, or wakes it up.
+func (g *MAX17043) Sleep(sleep bool) error {
+	c, err := g.read(regConfig)
+	if err != nil {
+		return err
+	}
+	c &^= configSleep
+	if sleep {
+		c |= configSleep
+	}
+	return g.write(regConfig, c)
+}
+
+// Close closes the gauge.
+func (g *MAX17043) Close() error {
+	return g.Device.Close()
+}
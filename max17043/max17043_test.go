@@ -0,0 +1,61 @@
+package max17043
+
+import (
+	"testing"
+
+	"github.com/goiot/devices/i2csim"
+)
+
+func newGauge(t *testing.T) (*MAX17043, *i2csim.Registers) {
+	regs := i2csim.NewRegisters()
+	regs.Set(regVersion, 0x00, 0x03)
+	regs.Set(regConfig, 0x97, 0x1C) // power-on default, 4% alert
+	bus := i2csim.NewBus()
+	bus.Attach(addr, regs)
+	g, err := Open(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g, regs
+}
+
+func TestRead(t *testing.T) {
+	g, regs := newGauge(t)
+	regs.Set(regVCell, 0xC8, 0x00) // 3200 * 1.25mV
+	if v, err := g.Voltage(); err != nil || v != 4 {
+		t.Errorf("Voltage = %v, %v; want 4V", v, err)
+	}
+	regs.Set(regSOC, 0x38, 0x80)
+	if v, err := g.Charge(); err != nil || v != 56.5 {
+		t.Errorf("Charge = %v, %v; want 56.5%%", v, err)
+	}
+	if v, err := g.Version(); err != nil || v != 3 {
+		t.Errorf("Version = %v, %v; want 3", v, err)
+	}
+	if err := g.QuickStart(); err != nil || regs.Get(regMode, 1)[0] != 0x40 {
+		t.Errorf("QuickStart = %v; mode not written", err)
+	}
+}
+
+func TestAlert(t *testing.T) {
+	g, regs := newGauge(t)
+	if err := g.SetAlert(15); err != nil {
+		t.Fatal(err)
+	}
+	if c := regs.Get(regConfig, 2); c[0] != 0x97 || c[1] != 32-15 {
+		t.Errorf("config = %#x; want 0x97 0x11", c)
+	}
+	if err := g.SetAlert(40); err == nil {
+		t.Error("expected an error on a threshold above 32%")
+	}
+	regs.Set(regConfig+1, 0x11|configAlert)
+	if a, err := g.Alert(); err != nil || !a {
+		t.Errorf("Alert = %v, %v; want raised", a, err)
+	}
+	if err := g.ClearAlert(); err != nil || regs.Get(regConfig+1, 1)[0] != 0x11 {
+		t.Errorf("ClearAlert = %v; the alert is still raised", err)
+	}
+	if err := g.Sleep(true); err != nil || regs.Get(regConfig+1, 1)[0] != 0x91 {
+		t.Errorf("Sleep = %v; the sleep bit is not set", err)
+	}
+}
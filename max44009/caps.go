@@ -0,0 +1,19 @@
+package max44009
+
+import "github.com/goiot/devices/caps"
+
+// Caps are the capabilities of the sensor.
+var Caps = caps.Capabilities{
+	Name:      "MAX44009",
+	Bus:       "i2c",
+	Addresses: []int{Addr, Addr + 1},
+	Measurements: []caps.Measurement{
+		{Kind: caps.Illuminance, Unit: "lx", Min: 0.045, Max: 188000, Resolution: resolution},
+	},
+	PowerModes: []string{"default", "continuous"},
+}
+
+func init() { caps.Register(Caps) }
+
+// Capabilities implements caps.Describer.
+func (s *MAX44009) Capabilities() caps.Capabilities { return Caps }
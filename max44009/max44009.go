@@ -0,0 +1,132 @@
+// Package max44009 implements a driver for the Maxim MAX44009 ambient
+// light sensor.
+package max44009
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/exp/io/i2c"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+const (
+	// Addr is the I2C address of the sensor with its A0 pin low, it
+	// answers at 0x4B with the pin high.
+	Addr = 0x4A
+
+	regIntStatus = 0x00
+	regIntEnable = 0x01
+	regConfig    = 0x02
+	regLuxHigh   = 0x03
+	regLuxLow    = 0x04
+	regUpper     = 0x05
+	regLower     = 0x06
+	regTimer     = 0x07
+
+	continuous = 0x80 // measures every 100ms instead of 800ms
+
+	// resolution is the lux of the least significant bit of the mantissa.
+	resolution = 0.045
+)
+
+// MAX44009 represents a MAX44009 sensor.
+type MAX44009 struct {
+	Device *i2c.Device
+}
+
+// Open opens the sensor at the address addr. It picks its gain and
+// integration time by itself, and measures every 100ms if continuous is
+// true, every 800ms otherwise to draw less current.
+func Open(o driver.Opener, addr int, continuousMode bool) (*MAX44009, error) {
+	dev, err := i2c.Open(o, addr)
+	if err != nil {
+		return nil, err
+	}
+	conf := byte(0)
+	if continuousMode {
+		conf = continuous
+	}
+	if err := dev.WriteReg(regConfig, []byte{conf}); err != nil {
+		dev.Close()
+		return nil, fmt.Errorf("configuring the sensor failed - %v", err)
+	}
+	return &MAX44009{Device: dev}, nil
+}
+
+// Lux returns the ambient light in lux, from 0.045 to 188000 lux. An
+// error is returned when the light is over the range.
+func (s *MAX44009) Lux() (float64, error) {
+	// both bytes in one transfer with a repeated start, so that they are
+	// of the same conversion
+	b := make([]byte, 2)
+	if err := s.Device.ReadReg(regLuxHigh, b); err != nil {
+		return 0, err
+	}
+	e := uint(b[0] >> 4)
+	if e == 0x0F {
+		return 0, errors.New("light over the range of 188000 lux")
+	}
+	m := int(b[0]&0x0F)<<4 | int(b[1]&0x0F)
+	return float64(m<<e) * resolution, nil
+}
+
+// threshold returns the threshold register of the lux, an exponent and
+// the 4 upper bits of the mantissa.
+func threshold(lux float64) byte {
+	m := lux / resolution / 16
+	e := 0
+	for ; m >= 16 && e < 14; e++ {
+		m /= 2
+	}
+	if m >= 16 {
+		m = 15
+	}
+	return byte(e)<<4 | byte(m)
+}
+
+// SetThresholds enables the interrupt of the sensor, its INT pin is
+// pulled low once the light has been out of the low to high lux window
+// for the duration d, up to 25.5s. The thresholds are rounded down to
+// about 6%.
+func (s *MAX44009) SetThresholds(low, high float64, d time.Duration) error {
+	if low < 0 || high < low {
+		return fmt.Errorf("invalid thresholds %v and %v lux", low, high)
+	}
+	timer := d / (100 * time.Millisecond)
+	if timer > 255 {
+		return fmt.Errorf("duration %v above 25.5s", d)
+	}
+	for _, w := range [][]byte{
+		{regUpper, threshold(high)},
+		{regLower, threshold(low)},
+		{regTimer, byte(timer)},
+		{regIntEnable, 0x01},
+	} {
+		if err := s.Device.Write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DisableInterrupt disables the interrupt of the sensor.
+func (s *MAX44009) DisableInterrupt() error {
+	return s.Device.WriteReg(regIntEnable, []byte{0x00})
+}
+
+// Interrupted reports whether the light went out of the window of the
+// thresholds, and releases the INT pin.
+func (s *MAX44009) Interrupted() (bool, error) {
+	b := make([]byte, 1)
+	if err := s.Device.ReadReg(regIntStatus, b); err != nil {
+		return false, err
+	}
+	return b[0]&0x01 != 0, nil
+}
+
+// Close closes the sensor.
+func (s *MAX44009) Close() error {
+	return s.Device.Close()
+}
@@ -0,0 +1,74 @@
+package max44009
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/i2csim"
+)
+
+func TestLux(t *testing.T) {
+	regs := i2csim.NewRegisters()
+	bus := i2csim.NewBus()
+	var txs int
+	bus.Attach(Addr, i2csim.DeviceFunc(func(w, r []byte) error {
+		txs++
+		return regs.Tx(w, r)
+	}))
+	s, err := Open(bus, Addr, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf := regs.Get(regConfig, 1)[0]; conf != continuous {
+		t.Errorf("configuration %#x; want the continuous mode", conf)
+	}
+	for _, tt := range []struct {
+		hi, lo byte
+		want   float64
+	}{
+		{0x00, 0x01, 0.045},
+		{0x6A, 0x0B, 0xAB * 64 * 0.045}, // 492.48
+		{0xEF, 0x0F, 0xFF * (1 << 14) * 0.045},
+	} {
+		regs.Set(regLuxHigh, tt.hi, tt.lo)
+		txs = 0
+		if lux, err := s.Lux(); err != nil || math.Abs(lux-tt.want) > 1e-9 {
+			t.Errorf("Lux = %v, %v with %#02x %#02x; want %v", lux, err, tt.hi, tt.lo, tt.want)
+		}
+		if txs != 1 {
+			t.Errorf("lux read in %d transfers; want 1", txs)
+		}
+	}
+	regs.Set(regLuxHigh, 0xFF, 0x0F)
+	if lux, err := s.Lux(); err == nil {
+		t.Errorf("Lux = %v over the range; want an error", lux)
+	}
+}
+
+func TestThresholds(t *testing.T) {
+	regs := i2csim.NewRegisters()
+	bus := i2csim.NewBus()
+	bus.Attach(Addr, regs)
+	s, err := Open(bus, Addr, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetThresholds(10, 1000, 2*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	// Rounded down to 2^7*10*16*0.045 = 921.6 lux and 13*16*0.045 = 9.36.
+	if got := regs.Get(regUpper, 3); got[0] != 0x7A || got[1] != 0x0D || got[2] != 20 {
+		t.Errorf("thresholds %#x; want 0x7a 0x0d 20", got)
+	}
+	if regs.Get(regIntEnable, 1)[0] != 0x01 {
+		t.Error("the interrupt is not enabled")
+	}
+	regs.Set(regIntStatus, 0x01)
+	if ok, err := s.Interrupted(); err != nil || !ok {
+		t.Errorf("Interrupted = %v, %v; want true", ok, err)
+	}
+	if err := s.SetThresholds(10, 1000, time.Minute); err == nil {
+		t.Error("expected an error with a duration of a minute")
+	}
+}
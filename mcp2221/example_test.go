@@ -0,0 +1,39 @@
+package mcp2221_test
+
+import (
+	"fmt"
+
+	"github.com/goiot/devices/analog"
+	"github.com/goiot/devices/mcp2221"
+	"github.com/goiot/devices/piglow"
+)
+
+func Example() {
+	bridge, err := mcp2221.Open()
+	if err != nil {
+		panic(err)
+	}
+	defer bridge.Close()
+
+	// any I2C driver can use the bridge
+	p, err := piglow.Open(bridge.I2C())
+	if err != nil {
+		panic(err)
+	}
+	defer p.Close()
+	if err := p.Setup(); err != nil {
+		panic(err)
+	}
+
+	// read a potentiometer wired to GP1
+	adc, err := bridge.ADC(mcp2221.Vdd)
+	if err != nil {
+		panic(err)
+	}
+	v, err := analog.Volts(adc, 0, 3.3)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("%.2fV\n", v)
+	p.SetBrightness(int(v / 3.3 * 255))
+}
@@ -0,0 +1,57 @@
+//go:build linux && !tinygo
+// +build linux,!tinygo
+
+package mcp2221
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var sysfsHidraw = "/sys/class/hidraw"
+
+// openHID opens the hidraw node of the first HID device matching vid:pid.
+func openHID(vid, pid uint16) (*hidraw, error) {
+	nodes, err := filepath.Glob(filepath.Join(sysfsHidraw, "hidraw*"))
+	if err != nil {
+		return nil, err
+	}
+	id := fmt.Sprintf("HID_ID=0003:%08X:%08X", vid, pid)
+	for _, node := range nodes {
+		uevent, err := ioutil.ReadFile(filepath.Join(node, "device", "uevent"))
+		if err != nil || !strings.Contains(string(uevent), id) {
+			continue
+		}
+		f, err := os.OpenFile(filepath.Join("/dev", filepath.Base(node)), os.O_RDWR, os.ModeDevice)
+		if err != nil {
+			return nil, err
+		}
+		return &hidraw{f: f}, nil
+	}
+	return nil, errors.New("no MCP2221 found, check the USB connection")
+}
+
+// hidraw reads and writes reports of a device without numbered reports.
+type hidraw struct {
+	f *os.File
+}
+
+func (h *hidraw) Write(b []byte) (int, error) {
+	// the first byte is the report number, 0 if reports are not numbered
+	if _, err := h.f.Write(append([]byte{0}, b...)); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (h *hidraw) Read(b []byte) (int, error) {
+	return h.f.Read(b)
+}
+
+func (h *hidraw) Close() error {
+	return h.f.Close()
+}
@@ -0,0 +1,13 @@
+//go:build !linux || tinygo
+// +build !linux tinygo
+
+package mcp2221
+
+import (
+	"errors"
+	"io"
+)
+
+func openHID(vid, pid uint16) (io.ReadWriteCloser, error) {
+	return nil, errors.New("not implemented on this platform")
+}
@@ -0,0 +1,156 @@
+package mcp2221
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+// I2C is an I2C driver using the MCP2221 as the bus master.
+type I2C struct {
+	// Speed is the SCL frequency in Hz, up to 400kHz. Defaults to 100kHz.
+	Speed int
+
+	d *MCP2221
+}
+
+// Open opens a connection to the device at addr.
+func (b *I2C) Open(addr int, tenbit bool) (driver.Conn, error) {
+	if tenbit {
+		return nil, errors.New("10-bit addresses are not supported")
+	}
+	speed := b.Speed
+	if speed <= 0 {
+		speed = 100000
+	}
+	div := internalClock/speed - 3
+	if div < 0 || div > 0xFF {
+		return nil, fmt.Errorf("unsupported I2C speed %dHz", speed)
+	}
+	b.d.mu.Lock()
+	defer b.d.mu.Unlock()
+	// Setting the speed fails while a transfer is stuck, cancel it first.
+	if _, err := b.d.xfer([]byte{cmdStatus, 0, statusCancel}); err != nil {
+		return nil, err
+	}
+	resp, err := b.d.xfer([]byte{cmdStatus, 0, 0, statusSetSpeed, byte(div)})
+	if err != nil {
+		return nil, err
+	}
+	if resp[3] != statusSetSpeed {
+		return nil, errors.New("cannot set the I2C speed, the bus is busy")
+	}
+	return &i2cConn{d: b.d, addr: byte(addr)}, nil
+}
+
+type i2cConn struct {
+	d    *MCP2221
+	addr byte
+}
+
+// Tx writes w and then reads into r, using a repeated start between the two.
+func (c *i2cConn) Tx(w, r []byte) error {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	if len(w) > 0 {
+		cmd := byte(cmdI2CWrite)
+		if len(r) > 0 {
+			cmd = cmdI2CWriteNoStop
+		}
+		if err := c.write(cmd, w); err != nil {
+			c.cancel()
+			return err
+		}
+	}
+	if len(r) > 0 {
+		cmd := byte(cmdI2CRead)
+		if len(w) > 0 {
+			cmd = cmdI2CReadRepStart
+		}
+		if err := c.read(cmd, r); err != nil {
+			c.cancel()
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *i2cConn) write(cmd byte, w []byte) error {
+	for off := 0; off < len(w); {
+		end := off + i2cMaxChunk
+		if end > len(w) {
+			end = len(w)
+		}
+		report := append([]byte{cmd, byte(len(w)), byte(len(w) >> 8), c.addr << 1}, w[off:end]...)
+		resp, err := c.d.xfer(report)
+		if err != nil {
+			return err
+		}
+		switch resp[1] {
+		case respSuccess:
+			off = end
+		case respBusy:
+			// the engine is still sending the previous chunk, retry
+		default:
+			return fmt.Errorf("write to %#x failed", c.addr)
+		}
+	}
+	return c.wait()
+}
+
+// wait polls the I2C engine until the current transfer is over.
+func (c *i2cConn) wait() error {
+	for i := 0; i < i2cMaxRetries; i++ {
+		resp, err := c.d.xfer([]byte{cmdStatus})
+		if err != nil {
+			return err
+		}
+		switch resp[statusI2CState] {
+		case i2cIdle:
+			return nil
+		case i2cAddrNack:
+			return fmt.Errorf("no device responding at address %#x", c.addr)
+		}
+	}
+	return fmt.Errorf("timed out while writing to %#x", c.addr)
+}
+
+func (c *i2cConn) read(cmd byte, r []byte) error {
+	resp, err := c.d.xfer([]byte{cmd, byte(len(r)), byte(len(r) >> 8), c.addr<<1 | 1})
+	if err != nil {
+		return err
+	}
+	if resp[1] != respSuccess {
+		return fmt.Errorf("read from %#x failed", c.addr)
+	}
+	for off, retries := 0, 0; off < len(r); {
+		resp, err := c.d.xfer([]byte{cmdI2CGetData})
+		if err != nil {
+			return err
+		}
+		n := int(resp[3])
+		if resp[1] != respSuccess || n == i2cReadError {
+			if resp[2] == i2cAddrNack {
+				return fmt.Errorf("no device responding at address %#x", c.addr)
+			}
+			// data is not available yet
+			if retries++; retries == i2cMaxRetries {
+				return fmt.Errorf("timed out while reading from %#x", c.addr)
+			}
+			continue
+		}
+		if n > reportSize-4 {
+			return fmt.Errorf("invalid response length %d", n)
+		}
+		off += copy(r[off:], resp[4:4+n])
+		retries = 0
+	}
+	return nil
+}
+
+func (c *i2cConn) cancel() {
+	c.d.xfer([]byte{cmdStatus, 0, statusCancel})
+}
+
+func (c *i2cConn) Close() error { return nil }
@@ -0,0 +1,260 @@
+// Package mcp2221 implements a backend for the Microchip MCP2221A USB to
+// I2C/GPIO bridge. It allows the drivers in this repo to run from a
+// desktop or laptop.
+//
+// The bridge also has a 3 channel 10-bit ADC on GP1-GP3 and a 5-bit DAC
+// on GP2 and GP3, available through the analog.ADC and analog.DAC
+// interfaces.
+package mcp2221
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/goiot/devices/analog"
+	"github.com/goiot/devices/gpio"
+)
+
+// Vref is the reference voltage of the ADC or of the DAC.
+type Vref byte
+
+const (
+	// Vdd uses the supply voltage as the reference.
+	Vdd Vref = 0x00
+	// Vref1V024 uses the internal 1.024V reference.
+	Vref1V024 Vref = 0x03
+	// Vref2V048 uses the internal 2.048V reference.
+	Vref2V048 Vref = 0x05
+	// Vref4V096 uses the internal 4.096V reference.
+	Vref4V096 Vref = 0x07
+)
+
+// MCP2221 represents an MCP2221 or MCP2221A bridge. It can be used from
+// multiple goroutines.
+type MCP2221 struct {
+	mu  sync.Mutex
+	hid io.ReadWriteCloser
+	gp  [4]byte // GP0-3 settings
+}
+
+// Open opens the first MCP2221 connected to the host. Once not in use,
+// it needs to be closed by calling Close.
+func Open() (*MCP2221, error) {
+	hid, err := openHID(vendorMicrochip, productMCP2221)
+	if err != nil {
+		return nil, err
+	}
+	d, err := newMCP2221(hid)
+	if err != nil {
+		hid.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+func newMCP2221(hid io.ReadWriteCloser) (*MCP2221, error) {
+	d := &MCP2221{hid: hid}
+	resp, err := d.xfer([]byte{cmdGetSRAM})
+	if err != nil {
+		return nil, err
+	}
+	copy(d.gp[:], resp[sramGPSettings:])
+	return d, nil
+}
+
+// Close closes the bridge.
+func (d *MCP2221) Close() error {
+	return d.hid.Close()
+}
+
+// xfer sends the command report and returns the response report.
+func (d *MCP2221) xfer(cmd []byte) ([]byte, error) {
+	report := make([]byte, reportSize)
+	copy(report, cmd)
+	if _, err := d.hid.Write(report); err != nil {
+		return nil, err
+	}
+	resp := make([]byte, reportSize)
+	if _, err := io.ReadFull(d.hid, resp); err != nil {
+		return nil, err
+	}
+	if resp[0] != cmd[0] {
+		return nil, fmt.Errorf("unexpected response %#x to command %#x", resp[0], cmd[0])
+	}
+	return resp, nil
+}
+
+// setGP changes the designation of the pin p in the SRAM settings.
+func (d *MCP2221) setGP(p int, setting byte) error {
+	if d.gp[p] == setting {
+		return nil
+	}
+	gp := d.gp
+	gp[p] = setting
+	cmd := make([]byte, setSRAMGP+4)
+	cmd[0] = cmdSetSRAM
+	cmd[setSRAMGPAlter] = setSRAMAlter
+	copy(cmd[setSRAMGP:], gp[:])
+	resp, err := d.xfer(cmd)
+	if err != nil {
+		return err
+	}
+	if resp[1] != respSuccess {
+		return fmt.Errorf("cannot configure GP%d", p)
+	}
+	d.gp = gp
+	return nil
+}
+
+// I2C returns an I2C opener using the bridge.
+func (d *MCP2221) I2C() *I2C {
+	return &I2C{d: d, Speed: 100000}
+}
+
+// Input configures the pin GPp (0-3) as an input.
+func (d *MCP2221) Input(p int) (gpio.Pin, error) {
+	return d.pin(p, gpInput)
+}
+
+// Output configures the pin GPp (0-3) as an output initially driven to v.
+func (d *MCP2221) Output(p int, v int) (gpio.Pin, error) {
+	var setting byte
+	if v != 0 {
+		setting = gpHigh
+	}
+	return d.pin(p, setting)
+}
+
+func (d *MCP2221) pin(p int, setting byte) (gpio.Pin, error) {
+	if p < 0 || p > 3 {
+		return nil, fmt.Errorf("GP%d is not a GPIO pin", p)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.setGP(p, gpModeGPIO|setting); err != nil {
+		return nil, err
+	}
+	return &pin{d: d, p: p, out: setting&gpInput == 0}, nil
+}
+
+type pin struct {
+	d   *MCP2221
+	p   int
+	out bool
+}
+
+func (p *pin) Read() (int, error) {
+	p.d.mu.Lock()
+	defer p.d.mu.Unlock()
+	resp, err := p.d.xfer([]byte{cmdGetGPIO})
+	if err != nil {
+		return 0, err
+	}
+	v := resp[2+2*p.p]
+	if v == gpioUnavailable {
+		return 0, fmt.Errorf("GP%d is not configured as a GPIO", p.p)
+	}
+	return int(v & 1), nil
+}
+
+func (p *pin) Write(v int) error {
+	if !p.out {
+		return fmt.Errorf("GP%d is not configured as an output", p.p)
+	}
+	p.d.mu.Lock()
+	defer p.d.mu.Unlock()
+	cmd := make([]byte, 18)
+	cmd[0] = cmdSetGPIO
+	cmd[2+4*p.p] = 1 // alter the output value
+	cmd[3+4*p.p] = byte(v & 1)
+	_, err := p.d.xfer(cmd)
+	return err
+}
+
+func (p *pin) Close() error { return nil }
+
+// ADC returns the ADC of the bridge, channels 0-2 are GP1-GP3.
+// A channel also configures its pin as an analog input when read.
+func (d *MCP2221) ADC(vref Vref) (analog.ADC, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	cmd := make([]byte, setSRAMADCVref+1)
+	cmd[0] = cmdSetSRAM
+	cmd[setSRAMADCVref] = setSRAMAlter | byte(vref)
+	if _, err := d.xfer(cmd); err != nil {
+		return nil, err
+	}
+	return &adc{d: d}, nil
+}
+
+type adc struct {
+	d *MCP2221
+}
+
+func (a *adc) Read(ch int) (int, error) {
+	if err := analog.CheckChannel(ch, 3); err != nil {
+		return 0, err
+	}
+	a.d.mu.Lock()
+	defer a.d.mu.Unlock()
+	if err := a.d.setGP(ch+1, gpModeADC); err != nil {
+		return 0, err
+	}
+	resp, err := a.d.xfer([]byte{cmdStatus})
+	if err != nil {
+		return 0, err
+	}
+	off := statusADC + 2*ch
+	return int(resp[off]) | int(resp[off+1])<<8, nil
+}
+
+func (a *adc) Channels() int   { return 3 }
+func (a *adc) Resolution() int { return 10 }
+
+// DAC returns the DAC of the bridge. It has a single channel, output
+// on GP3 (and on GP2 if it is configured as a DAC output).
+func (d *MCP2221) DAC(vref Vref) (analog.DAC, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.setGP(3, gpModeDAC); err != nil {
+		return nil, err
+	}
+	cmd := make([]byte, setSRAMDACVref+1)
+	cmd[0] = cmdSetSRAM
+	cmd[setSRAMDACVref] = setSRAMAlter | byte(vref)
+	if _, err := d.xfer(cmd); err != nil {
+		return nil, err
+	}
+	return &dac{d: d}, nil
+}
+
+type dac struct {
+	d *MCP2221
+}
+
+func (a *dac) Write(ch int, v int) error {
+	if err := analog.CheckChannel(ch, 1); err != nil {
+		return err
+	}
+	if v < 0 || v > 31 {
+		return fmt.Errorf("invalid DAC value %d, should be between 0-31", v)
+	}
+	a.d.mu.Lock()
+	defer a.d.mu.Unlock()
+	cmd := make([]byte, setSRAMDAC+1)
+	cmd[0] = cmdSetSRAM
+	cmd[setSRAMDAC] = setSRAMAlter | byte(v)
+	resp, err := a.d.xfer(cmd)
+	if err != nil {
+		return err
+	}
+	if resp[1] != respSuccess {
+		return errors.New("cannot set the DAC output")
+	}
+	return nil
+}
+
+func (a *dac) Channels() int   { return 1 }
+func (a *dac) Resolution() int { return 5 }
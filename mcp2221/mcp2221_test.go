@@ -0,0 +1,176 @@
+package mcp2221
+
+import (
+	"bytes"
+	"testing"
+
+	"golang.org/x/exp/io/i2c"
+)
+
+// hid is a fake MCP2221, it records the command reports and replies with
+// the queued response reports.
+type hid struct {
+	cmds [][]byte
+	resp [][]byte
+}
+
+func (h *hid) Write(b []byte) (int, error) {
+	h.cmds = append(h.cmds, append([]byte(nil), b...))
+	return len(b), nil
+}
+
+func (h *hid) Read(b []byte) (int, error) {
+	r := make([]byte, reportSize)
+	if len(h.resp) > 0 {
+		copy(r, h.resp[0])
+		h.resp = h.resp[1:]
+	} else {
+		// echo the command with a success status
+		r[0] = h.cmds[len(h.cmds)-1][0]
+	}
+	return copy(b, r), nil
+}
+
+func (h *hid) Close() error { return nil }
+
+func (h *hid) queue(resp ...byte) {
+	h.resp = append(h.resp, resp)
+}
+
+func openMCP2221(t *testing.T) (*MCP2221, *hid) {
+	h := &hid{}
+	d, err := newMCP2221(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.cmds = nil
+	return d, h
+}
+
+func TestI2CWrite(t *testing.T) {
+	d, h := openMCP2221(t)
+	set := make([]byte, 4)
+	set[0], set[3] = cmdStatus, statusSetSpeed
+	h.queue(cmdStatus) // cancel
+	h.queue(set...)
+	dev, err := i2c.Open(d.I2C(), 0x3C)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if div := h.cmds[1][4]; div != 117 {
+		t.Errorf("divider = %d, want 117 for 100kHz", div)
+	}
+	h.cmds = nil
+	if err := dev.Write([]byte{0xAE, 0xAF}); err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{cmdI2CWrite, 2, 0, 0x3C << 1, 0xAE, 0xAF}
+	if got := h.cmds[0][:len(want)]; !bytes.Equal(got, want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+	if h.cmds[1][0] != cmdStatus {
+		t.Errorf("expected a status poll after the write, got %#x", h.cmds[1][0])
+	}
+}
+
+func TestI2CNack(t *testing.T) {
+	d, h := openMCP2221(t)
+	h.queue(cmdStatus)
+	h.queue(cmdStatus, 0, 0, statusSetSpeed)
+	dev, err := i2c.Open(d.I2C(), 0x3C)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.queue(cmdI2CWrite)
+	status := make([]byte, reportSize)
+	status[0], status[statusI2CState] = cmdStatus, i2cAddrNack
+	h.queue(status...)
+	if err := dev.Write([]byte{0xAE}); err == nil {
+		t.Fatal("expected an error when the address is not acknowledged")
+	}
+}
+
+func TestI2CRead(t *testing.T) {
+	d, h := openMCP2221(t)
+	h.queue(cmdStatus)
+	h.queue(cmdStatus, 0, 0, statusSetSpeed)
+	dev, err := i2c.Open(d.I2C(), 0x4C)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.queue(cmdI2CRead)
+	h.queue(cmdI2CGetData, respBusy, 0, i2cReadError) // not ready yet
+	h.queue(cmdI2CGetData, respSuccess, 0, 3, 0x01, 0x02, 0x03)
+	buf := make([]byte, 3)
+	if err := dev.Read(buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf, []byte{0x01, 0x02, 0x03}) {
+		t.Errorf("got %x, want 010203", buf)
+	}
+}
+
+func TestADC(t *testing.T) {
+	d, h := openMCP2221(t)
+	a, err := d.ADC(Vdd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.queue(cmdSetSRAM) // GP2 designation
+	status := make([]byte, reportSize)
+	status[0] = cmdStatus
+	status[statusADC+2], status[statusADC+3] = 0xFF, 0x03
+	h.queue(status...)
+	v, err := a.Read(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != 1023 {
+		t.Errorf("got %d, want 1023", v)
+	}
+	if gp := h.cmds[1][setSRAMGP+2]; gp != gpModeADC {
+		t.Errorf("GP2 setting = %#x, want ADC", gp)
+	}
+	if _, err := a.Read(3); err == nil {
+		t.Error("expected an error reading channel 3")
+	}
+}
+
+func TestDAC(t *testing.T) {
+	d, h := openMCP2221(t)
+	a, err := d.DAC(Vref2V048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Write(0, 16); err != nil {
+		t.Fatal(err)
+	}
+	if got := h.cmds[len(h.cmds)-1][setSRAMDAC]; got != setSRAMAlter|16 {
+		t.Errorf("DAC byte = %#x, want %#x", got, setSRAMAlter|16)
+	}
+	if err := a.Write(0, 32); err == nil {
+		t.Error("expected an error writing an out of range value")
+	}
+}
+
+func TestGPIO(t *testing.T) {
+	d, h := openMCP2221(t)
+	p, err := d.Output(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Write(1); err != nil {
+		t.Fatal(err)
+	}
+	cmd := h.cmds[len(h.cmds)-1]
+	if cmd[0] != cmdSetGPIO || cmd[2] != 1 || cmd[3] != 1 {
+		t.Errorf("got %x, want GP0 set high", cmd[:6])
+	}
+	in, err := d.Input(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := in.Write(1); err == nil {
+		t.Error("expected an error writing to an input")
+	}
+}
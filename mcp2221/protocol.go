@@ -0,0 +1,53 @@
+package mcp2221
+
+// HID commands, see the "USB HID Commands" section of the datasheet.
+// Every command and response is a 64 bytes report.
+const (
+	reportSize = 64
+
+	cmdStatus          = 0x10 // status/set parameters
+	cmdSetSRAM         = 0x60
+	cmdGetSRAM         = 0x61
+	cmdSetGPIO         = 0x50
+	cmdGetGPIO         = 0x51
+	cmdI2CWrite        = 0x90
+	cmdI2CWriteNoStop  = 0x94
+	cmdI2CRead         = 0x91
+	cmdI2CReadRepStart = 0x93
+	cmdI2CGetData      = 0x40
+
+	respSuccess = 0x00
+	respBusy    = 0x01
+)
+
+const (
+	// Status/set parameters report.
+	statusCancel    = 0x10 // byte 2, cancels the current I2C transfer
+	statusSetSpeed  = 0x20 // byte 3, byte 4 holds the divider
+	statusI2CState  = 8    // offset of the I2C engine state
+	statusADC       = 50   // offset of the 3 little endian ADC values
+	i2cIdle         = 0x00
+	i2cAddrNack     = 0x25
+	i2cReadError    = 0x7F // returned as byte count by get data
+	i2cMaxChunk     = 60   // data bytes carried by an I2C write report
+	i2cMaxRetries   = 20
+	internalClock   = 12000000
+	sramGPSettings  = 22 // offset of the GP0-3 settings in get SRAM
+	setSRAMDACVref  = 3
+	setSRAMDAC      = 4
+	setSRAMADCVref  = 5
+	setSRAMGPAlter  = 7
+	setSRAMGP       = 8 // offset of the GP0-3 settings in set SRAM
+	setSRAMAlter    = 0x80
+	gpModeGPIO      = 0x00
+	gpModeADC       = 0x02 // ADC1-3 on GP1-3
+	gpModeDAC       = 0x03 // DAC1-2 on GP2-3
+	gpInput         = 1 << 3
+	gpHigh          = 1 << 4
+	gpioUnavailable = 0xEE
+)
+
+const (
+	vendorMicrochip = 0x04D8
+	productMCP2221  = 0x00DD
+)
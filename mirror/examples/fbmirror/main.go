@@ -0,0 +1,61 @@
+// Fbmirror shows the console or the desktop of a Raspberry Pi on an
+// SSD1306 OLED connected to its I2C bus.
+package main
+
+import (
+	"context"
+	"flag"
+	"image"
+	"os"
+	"os/signal"
+
+	"github.com/goiot/devices/i2cbus"
+	"github.com/goiot/devices/mirror"
+	"github.com/goiot/devices/monochromeoled"
+)
+
+func main() {
+	dev := flag.String("fb", "/dev/fb0", "framebuffer device")
+	fps := flag.Float64("fps", 10, "frames per second")
+	x0 := flag.Int("x", 0, "left of the mirrored region")
+	y0 := flag.Int("y", 0, "top of the mirrored region")
+	w := flag.Int("w", 0, "width of the mirrored region, the whole screen if zero")
+	h := flag.Int("h", 0, "height of the mirrored region")
+	flag.Parse()
+
+	fb, err := mirror.OpenFramebuffer(*dev)
+	if err != nil {
+		panic(err)
+	}
+	defer fb.Close()
+
+	bus, err := i2cbus.Open("primary")
+	if err != nil {
+		panic(err)
+	}
+	oled, err := monochromeoled.Open(bus)
+	if err != nil {
+		panic(err)
+	}
+	defer oled.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	go func() {
+		<-sig
+		cancel()
+	}()
+
+	m := &mirror.Mirror{
+		Source:  fb,
+		Display: oled,
+		Region:  image.Rect(*x0, *y0, *x0+*w, *y0+*h),
+		Palette: mirror.Monochrome,
+		FPS:     *fps,
+	}
+	if err := m.Run(ctx); err != context.Canceled {
+		panic(err)
+	}
+	oled.Clear()
+}
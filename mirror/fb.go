@@ -0,0 +1,122 @@
+package mirror
+
+import (
+	"fmt"
+	"image"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Framebuffer captures the frames of a Linux framebuffer device, e.g.
+// /dev/fb0. The pixel depths of 16 (RGB565), 24 and 32 bits are
+// supported.
+type Framebuffer struct {
+	f      *os.File
+	w, h   int
+	stride int
+	bpp    int
+	buf    []byte
+	img    *image.RGBA
+}
+
+// OpenFramebuffer opens the framebuffer device dev, its geometry is read
+// from sysfs.
+func OpenFramebuffer(dev string) (*Framebuffer, error) {
+	sys := filepath.Join("/sys/class/graphics", filepath.Base(dev))
+	size, err := readSys(sys, "virtual_size")
+	if err != nil {
+		return nil, err
+	}
+	wh := strings.Split(size, ",")
+	if len(wh) != 2 {
+		return nil, fmt.Errorf("invalid framebuffer size %q", size)
+	}
+	w, err1 := strconv.Atoi(wh[0])
+	h, err2 := strconv.Atoi(wh[1])
+	bpp, err3 := readSysInt(sys, "bits_per_pixel")
+	stride, err4 := readSysInt(sys, "stride")
+	for _, err := range []error{err1, err2, err3, err4} {
+		if err != nil {
+			return nil, fmt.Errorf("reading the framebuffer geometry failed - %v", err)
+		}
+	}
+	f, err := os.Open(dev)
+	if err != nil {
+		return nil, err
+	}
+	return newFramebuffer(f, w, h, stride, bpp)
+}
+
+func newFramebuffer(f *os.File, w, h, stride, bpp int) (*Framebuffer, error) {
+	switch bpp {
+	case 16, 24, 32:
+	default:
+		f.Close()
+		return nil, fmt.Errorf("unsupported pixel depth of %d bits", bpp)
+	}
+	if stride < w*bpp/8 {
+		f.Close()
+		return nil, fmt.Errorf("invalid stride %d for a width of %d pixels", stride, w)
+	}
+	return &Framebuffer{
+		f: f, w: w, h: h, stride: stride, bpp: bpp,
+		buf: make([]byte, stride*h),
+		img: image.NewRGBA(image.Rect(0, 0, w, h)),
+	}, nil
+}
+
+// Capture implements Source, the image is reused by the next call.
+func (fb *Framebuffer) Capture() (image.Image, error) {
+	if _, err := fb.f.ReadAt(fb.buf, 0); err != nil {
+		return nil, err
+	}
+	decode(fb.img, fb.buf, fb.stride, fb.bpp)
+	return fb.img, nil
+}
+
+// Close closes the device.
+func (fb *Framebuffer) Close() error {
+	return fb.f.Close()
+}
+
+// decode converts the little endian pixels of a framebuffer to dst.
+func decode(dst *image.RGBA, buf []byte, stride, bpp int) {
+	b := dst.Bounds()
+	for y := 0; y < b.Dy(); y++ {
+		row := buf[y*stride:]
+		p := dst.Pix[y*dst.Stride:]
+		for x := 0; x < b.Dx(); x++ {
+			var r, g, bl byte
+			switch bpp {
+			case 16:
+				v := uint16(row[2*x]) | uint16(row[2*x+1])<<8
+				r, g, bl = byte(v>>11)<<3, byte(v>>5)<<2, byte(v)<<3
+				r, g, bl = r|r>>5, g|g>>6, bl|bl>>5
+			case 24:
+				bl, g, r = row[3*x], row[3*x+1], row[3*x+2]
+			case 32:
+				bl, g, r = row[4*x], row[4*x+1], row[4*x+2]
+			}
+			p[4*x], p[4*x+1], p[4*x+2], p[4*x+3] = r, g, bl, 0xFF
+		}
+	}
+}
+
+func readSys(dir, name string) (string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+func readSysInt(dir, name string) (int, error) {
+	s, err := readSys(dir, name)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(s)
+}
@@ -0,0 +1,155 @@
+// Package mirror shows the content of a screen on a small display: it
+// captures frames from a source such as the Linux framebuffer, scales
+// them down to the display, optionally dithers them for monochrome
+// panels, and draws them at a target frame rate.
+//
+// Other sources, e.g. the capture of an X11 or Wayland window, implement
+// the Source interface.
+package mirror
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/display"
+)
+
+// Monochrome is the palette of the monochrome displays such as the
+// SSD1306 OLEDs.
+var Monochrome = color.Palette{color.Black, color.White}
+
+// Source is a source of frames.
+type Source interface {
+	// Capture returns the current frame. The image can be reused by the
+	// next call.
+	Capture() (image.Image, error)
+}
+
+// Mirror copies the frames of Source to Display.
+type Mirror struct {
+	Source  Source
+	Display display.Display
+
+	// Region is the part of the frames mirrored, the whole frame if
+	// empty. It is scaled to the bounds of the display.
+	Region image.Rectangle
+
+	// Palette, if set, is the palette the frames are dithered to, e.g.
+	// Monochrome.
+	Palette color.Palette
+
+	// FPS is the frame rate of Run, 10 if zero.
+	FPS float64
+
+	// Clock times Run, clock.Real if nil.
+	Clock clock.Clock
+
+	src    *image.RGBA // frame converted to RGBA, for other sources
+	scaled *image.RGBA
+	out    *image.Paletted
+}
+
+// Frame captures, scales and draws a frame.
+func (m *Mirror) Frame() error {
+	img, err := m.Source.Capture()
+	if err != nil {
+		return err
+	}
+	r := m.Region
+	if r.Empty() {
+		r = img.Bounds()
+	}
+	r = r.Intersect(img.Bounds())
+
+	src, ok := img.(*image.RGBA)
+	if !ok {
+		if m.src == nil || m.src.Bounds() != r {
+			m.src = image.NewRGBA(r)
+		}
+		draw.Draw(m.src, r, img, r.Min, draw.Src)
+		src = m.src
+	}
+
+	b := m.Display.Bounds()
+	if m.scaled == nil || m.scaled.Bounds() != b {
+		m.scaled = image.NewRGBA(b)
+	}
+	scale(m.scaled, src, r)
+
+	var frame image.Image = m.scaled
+	if m.Palette != nil {
+		if m.out == nil || m.out.Bounds() != b {
+			m.out = image.NewPaletted(b, m.Palette)
+		}
+		m.out.Palette = m.Palette
+		draw.FloydSteinberg.Draw(m.out, b, m.scaled, b.Min)
+		frame = m.out
+	}
+	draw.Draw(m.Display, b, frame, b.Min, draw.Src)
+	return m.Display.Draw()
+}
+
+// Run draws frames until ctx is done or an error occurs.
+func (m *Mirror) Run(ctx context.Context) error {
+	fps := m.FPS
+	if fps <= 0 {
+		fps = 10
+	}
+	t := clock.Or(m.Clock).NewTicker(time.Duration(float64(time.Second) / fps))
+	defer t.Stop()
+	for {
+		if err := m.Frame(); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C():
+		}
+	}
+}
+
+// scale scales the region r of src to dst, averaging the source pixels
+// covered by each destination pixel.
+func scale(dst, src *image.RGBA, r image.Rectangle) {
+	db := dst.Bounds()
+	dw, dh := db.Dx(), db.Dy()
+	sw, sh := r.Dx(), r.Dy()
+	if sw == 0 || sh == 0 {
+		return
+	}
+	for y := 0; y < dh; y++ {
+		y0, y1 := r.Min.Y+y*sh/dh, r.Min.Y+(y+1)*sh/dh
+		if y1 == y0 {
+			y1++
+		}
+		for x := 0; x < dw; x++ {
+			x0, x1 := r.Min.X+x*sw/dw, r.Min.X+(x+1)*sw/dw
+			if x1 == x0 {
+				x1++
+			}
+			var sr, sg, sb, sa, n int
+			for sy := y0; sy < y1; sy++ {
+				i := src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					p := src.Pix[i : i+4 : i+4]
+					sr += int(p[0])
+					sg += int(p[1])
+					sb += int(p[2])
+					sa += int(p[3])
+					n++
+					i += 4
+				}
+			}
+			j := dst.PixOffset(db.Min.X+x, db.Min.Y+y)
+			dst.Pix[j] = byte(sr / n)
+			dst.Pix[j+1] = byte(sg / n)
+			dst.Pix[j+2] = byte(sb / n)
+			dst.Pix[j+3] = byte(sa / n)
+		}
+	}
+}
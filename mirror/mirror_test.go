@@ -0,0 +1,114 @@
+package mirror
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+// screen is a fake display counting its draws.
+type screen struct {
+	*image.RGBA
+	draws int
+}
+
+func (s *screen) Draw() error { s.draws++; return nil }
+
+// frame is a source returning the same image.
+type frame struct{ image.Image }
+
+func (f frame) Capture() (image.Image, error) { return f.Image, nil }
+
+func TestFrame(t *testing.T) {
+	// left half white, right half black
+	src := image.NewGray(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 20; x++ {
+			src.SetGray(x, y, color.Gray{Y: 0xFF})
+		}
+	}
+	s := &screen{RGBA: image.NewRGBA(image.Rect(0, 0, 4, 2))}
+	m := &Mirror{Source: frame{src}, Display: s}
+	if err := m.Frame(); err != nil {
+		t.Fatal(err)
+	}
+	if s.RGBAAt(1, 1) != (color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}) || s.RGBAAt(2, 0) != (color.RGBA{0, 0, 0, 0xFF}) {
+		t.Errorf("got %v and %v, want white and black", s.RGBAAt(1, 1), s.RGBAAt(2, 0))
+	}
+
+	// a region across the edge averages to gray, dithered to half lit
+	m.Region = image.Rect(10, 0, 30, 20)
+	s.RGBA = image.NewRGBA(image.Rect(0, 0, 1, 1))
+	if err := m.Frame(); err != nil {
+		t.Fatal(err)
+	}
+	if r := s.RGBAAt(0, 0).R; r != 0x7F {
+		t.Errorf("got %#x, want gray", r)
+	}
+	m.Palette = Monochrome
+	s.RGBA = image.NewRGBA(image.Rect(0, 0, 8, 8))
+	m.Source = frame{image.NewUniform(color.Gray{Y: 0x80})}
+	m.Region = image.Rect(0, 0, 16, 16)
+	if err := m.Frame(); err != nil {
+		t.Fatal(err)
+	}
+	lit := 0
+	for i := 0; i < len(s.Pix); i += 4 {
+		if s.Pix[i] == 0xFF {
+			lit++
+		}
+	}
+	if lit < 28 || lit > 36 {
+		t.Errorf("%d pixels of 64 lit, want about half", lit)
+	}
+	if s.draws != 3 {
+		t.Errorf("%d draws, want 3", s.draws)
+	}
+}
+
+func TestRun(t *testing.T) {
+	s := &screen{RGBA: image.NewRGBA(image.Rect(0, 0, 2, 2))}
+	m := &Mirror{Source: frame{image.NewGray(image.Rect(0, 0, 4, 4))}, Display: s, FPS: 100}
+	ctx, cancel := context.WithTimeout(context.Background(), 55*time.Millisecond)
+	defer cancel()
+	if err := m.Run(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Run error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if s.draws < 3 {
+		t.Errorf("%d frames drawn in 55ms at 100 FPS", s.draws)
+	}
+}
+
+func TestFramebuffer(t *testing.T) {
+	f, err := ioutil.TempFile("", "fb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	// 2x1 pixels of RGB565 with a stride of 6 bytes: red, blue
+	f.Write([]byte{0x00, 0xF8, 0x1F, 0x00, 0, 0})
+	fb, err := newFramebuffer(f, 2, 1, 6, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fb.Close()
+	img, err := fb.Capture()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rgba := img.(*image.RGBA)
+	if rgba.RGBAAt(0, 0) != (color.RGBA{0xFF, 0, 0, 0xFF}) || rgba.RGBAAt(1, 0) != (color.RGBA{0, 0, 0xFF, 0xFF}) {
+		t.Errorf("got %v and %v, want red and blue", rgba.RGBAAt(0, 0), rgba.RGBAAt(1, 0))
+	}
+
+	buf := []byte{1, 2, 3, 0, 4, 5, 6, 0}
+	dst := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	decode(dst, buf, 8, 32)
+	if dst.RGBAAt(1, 0) != (color.RGBA{6, 5, 4, 0xFF}) {
+		t.Errorf("32 bits pixel = %v, want {6 5 4 255}", dst.RGBAAt(1, 0))
+	}
+}
@@ -0,0 +1,211 @@
+package monochromeoled
+
+import (
+	"fmt"
+	"image"
+	"math/bits"
+	"time"
+
+	"github.com/goiot/devices/clock"
+)
+
+// regionSize is the size of the regions whose on-time is accounted, a
+// page high.
+const regionSize = 8
+
+// burnIn is the state of the burn-in protection.
+type burnIn struct {
+	period time.Duration
+	orbit  []image.Point // shifts, a pixel apart
+	step   int
+	moved  time.Time // last move of the shift
+
+	since  time.Time       // end of the on-time accounted
+	lit    []int           // pixels lit per region of the frame shown
+	onTime []time.Duration // per region, averaged over its pixels
+}
+
+// orbit returns the shifts of up to max pixels in each direction, row by
+// row forth and back so that each step moves by a pixel.
+func orbit(max int) []image.Point {
+	var forth []image.Point
+	for y := -max; y <= max; y++ {
+		for i := -max; i <= max; i++ {
+			x := i
+			if (y+max)%2 == 1 {
+				x = -i
+			}
+			forth = append(forth, image.Pt(x, y))
+		}
+	}
+	o := forth
+	for i := len(forth) - 2; i > 0; i-- {
+		o = append(o, forth[i])
+	}
+	return o
+}
+
+// SetBurnInProtection protects the panels left on 24/7 from burn-in: the
+// frames shown are shifted by up to max pixels, 1 or 2, in each direction,
+// moving by a pixel every period, a minute if 0. Content drawn within max
+// pixels of the edges is cut while shifted. The shift moves at the first
+// Draw after each period, a display must be redrawn for it to move. A max
+// of 0 disables the protection, the frames are shown in place from the
+// next Draw.
+//
+// The protection also accounts the time the pixels of the panel are on,
+// see OnTime.
+func (o *OLED) SetBurnInProtection(max int, period time.Duration) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if max < 0 || max > 2 {
+		return fmt.Errorf("invalid shift of %d pixels, must be between 0 and 2", max)
+	}
+	if max == 0 {
+		o.burn = nil
+		if o.shift != (image.Point{}) {
+			o.shift, o.dirty = image.Point{}, o.pages()
+		}
+		return nil
+	}
+	if period == 0 {
+		period = time.Minute
+	}
+	now := clock.Or(o.Clock).Now()
+	b := &burnIn{period: period, orbit: orbit(max), moved: now, since: now}
+	if o.shift.X < -max || o.shift.X > max || o.shift.Y < -max || o.shift.Y > max {
+		o.shift, o.dirty = image.Point{}, o.pages()
+	}
+	for i, p := range b.orbit {
+		if p == o.shift {
+			b.step = i
+		}
+	}
+	regions := (o.w / regionSize) * (o.h / regionSize)
+	if prev := o.burn; prev != nil {
+		o.accrue(prev, now)
+		b.onTime = prev.onTime
+	} else {
+		b.onTime = make([]time.Duration, regions)
+	}
+	b.lit = make([]int, regions)
+	o.burn = b
+	o.account(o.frame())
+	return nil
+}
+
+// OnTime returns the time the regions of 8x8 pixels of the panel were on
+// since the burn-in protection was enabled, the average of their pixels:
+// a region half lit for an hour was on for 30 minutes. The regions are in
+// rows from the top left corner of the panel, before the rotation. It
+// returns nil without the protection.
+func (o *OLED) OnTime() [][]time.Duration {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	b := o.burn
+	if b == nil {
+		return nil
+	}
+	o.accrue(b, clock.Or(o.Clock).Now())
+	cols := o.w / regionSize
+	t := make([][]time.Duration, o.h/regionSize)
+	for r := range t {
+		t[r] = append([]time.Duration(nil), b.onTime[r*cols:(r+1)*cols]...)
+	}
+	return t
+}
+
+// moveShift moves the shift to its next step once its period elapsed.
+func (o *OLED) moveShift() {
+	b := o.burn
+	if b == nil {
+		return
+	}
+	now := clock.Or(o.Clock).Now()
+	if now.Sub(b.moved) < b.period {
+		return
+	}
+	b.moved = now
+	b.step = (b.step + 1) % len(b.orbit)
+	o.shift, o.dirty = b.orbit[b.step], o.pages()
+}
+
+// accrue adds the on-time of the frame shown until now.
+func (o *OLED) accrue(b *burnIn, now time.Time) {
+	d := float64(now.Sub(b.since)) / (regionSize * regionSize)
+	for i, n := range b.lit {
+		b.onTime[i] += time.Duration(d * float64(n))
+	}
+	b.since = now
+}
+
+// account accounts the on-time of the frame shown until now, and counts
+// the pixels lit of the frame buf shown from now.
+func (o *OLED) account(buf []byte) {
+	b := o.burn
+	if b == nil {
+		return
+	}
+	o.accrue(b, clock.Or(o.Clock).Now())
+	cols := o.w / regionSize
+	for p := 0; p < o.h/8; p++ {
+		for c := 0; c < cols; c++ {
+			n := 0
+			if o.on {
+				i := 1 + p*o.w + c*regionSize
+				for _, v := range buf[i : i+regionSize] {
+					n += bits.OnesCount8(v)
+				}
+			}
+			b.lit[p*cols+c] = n
+		}
+	}
+}
+
+// frame returns the buffer as shown, shifted by the burn-in protection.
+func (o *OLED) frame() []byte {
+	s := o.shift
+	if s == (image.Point{}) {
+		return o.buf
+	}
+	b := make([]byte, len(o.buf))
+	b[0] = o.buf[0]
+	pages := o.h / 8
+	for x := 0; x < o.w; x++ {
+		to := x + s.X
+		if to < 0 || to >= o.w {
+			continue
+		}
+		// the column of up to 64 pixels
+		var col uint64
+		for p := 0; p < pages; p++ {
+			col |= uint64(o.buf[1+p*o.w+x]) << uint(8*p)
+		}
+		if s.Y > 0 {
+			col <<= uint(s.Y)
+		} else {
+			col >>= uint(-s.Y)
+		}
+		for p := 0; p < pages; p++ {
+			b[1+p*o.w+to] = byte(col >> uint(8*p))
+		}
+	}
+	return b
+}
+
+// window returns the window of the shifted frame showing the window d of
+// the buffer.
+func (o *OLED) window(d image.Rectangle) image.Rectangle {
+	s := o.shift
+	if s == (image.Point{}) {
+		return d
+	}
+	page := func(row int) int {
+		if row < 0 {
+			return -1
+		}
+		return row / 8
+	}
+	w := image.Rect(d.Min.X+s.X, page(d.Min.Y*8+s.Y), d.Max.X+s.X, page(d.Max.Y*8-1+s.Y)+1)
+	return w.Intersect(o.pages())
+}
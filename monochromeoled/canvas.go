@@ -0,0 +1,135 @@
+package monochromeoled
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// Canvas is an off-screen 1-bit image with the drawing methods of the
+// OLEDs, composited on a display by DrawCanvas. The widgets of an
+// application can be drawn each on its canvas, by their own goroutines,
+// and shown together in one pass. A canvas can be used by one goroutine
+// at a time.
+type Canvas struct {
+	w, h int
+	pix  []byte // in the layout of the sprites of Blit
+}
+
+// NewCanvas returns a blank canvas of w x h pixels.
+func NewCanvas(w, h int) *Canvas {
+	if w < 0 || h < 0 {
+		w, h = 0, 0
+	}
+	return &Canvas{w: w, h: h, pix: make([]byte, w*((h+7)/8))}
+}
+
+// bit returns the byte and the bit of the pixel x, y.
+func (c *Canvas) bit(x, y int) (int, byte) {
+	return x + (y/8)*c.w, 1 << uint(y&7)
+}
+
+// SetPixel sets the pixel x, y, lit if v is 1.
+func (c *Canvas) SetPixel(x, y int, v byte) error {
+	if !image.Pt(x, y).In(c.Bounds()) {
+		return fmt.Errorf("(x=%v, y=%v) is out of bounds on this %vx%v canvas", x, y, c.w, c.h)
+	}
+	if v > 1 {
+		return fmt.Errorf("value needs to be either 0 or 1; given %v", v)
+	}
+	i, mask := c.bit(x, y)
+	c.pix[i] &^= mask
+	if v != 0 {
+		c.pix[i] |= mask
+	}
+	return nil
+}
+
+// ColorModel implements image.Image, the model is Model.
+func (c *Canvas) ColorModel() color.Model { return Model }
+
+// Bounds implements image.Image.
+func (c *Canvas) Bounds() image.Rectangle { return image.Rect(0, 0, c.w, c.h) }
+
+// At implements image.Image, the lit pixels are white.
+func (c *Canvas) At(x, y int) color.Color {
+	if !image.Pt(x, y).In(c.Bounds()) {
+		return color.Gray{}
+	}
+	if i, mask := c.bit(x, y); c.pix[i]&mask != 0 {
+		return color.Gray{Y: 0xFF}
+	}
+	return color.Gray{}
+}
+
+// Set implements draw.Image, the pixel is lit unless col is black as in
+// SetImage. Pixels out of the canvas are ignored.
+func (c *Canvas) Set(x, y int, col color.Color) {
+	if !image.Pt(x, y).In(c.Bounds()) {
+		return
+	}
+	var v byte
+	if lit(col) {
+		v = 1
+	}
+	c.SetPixel(x, y, v)
+}
+
+// Snapshot returns a copy of the canvas, the lit pixels are white.
+func (c *Canvas) Snapshot() *image.Gray {
+	img := image.NewGray(c.Bounds())
+	for y := 0; y < c.h; y++ {
+		for x := 0; x < c.w; x++ {
+			if i, mask := c.bit(x, y); c.pix[i]&mask != 0 {
+				img.Pix[y*img.Stride+x] = 0xFF
+			}
+		}
+	}
+	return img
+}
+
+// SetImage draws img on the canvas at x, y as OLED.SetImage.
+func (c *Canvas) SetImage(x, y int, img image.Image) error {
+	return c.SetImageWith(x, y, img, SetImageOptions{})
+}
+
+// SetImageWith draws img on the canvas at x, y as OLED.SetImageWith.
+func (c *Canvas) SetImageWith(x, y int, img image.Image, opts SetImageOptions) error {
+	return setImage(c, x, y, img, opts)
+}
+
+// DrawText draws s on the canvas as OLED.DrawText. It returns an error as
+// the one of the OLEDs, but never fails.
+func (c *Canvas) DrawText(x, y int, s string) error {
+	drawText(c, x, y, s)
+	return nil
+}
+
+// Blit copies the sprite to the canvas as OLED.Blit.
+func (c *Canvas) Blit(x, y int, sprite, mask []byte, w, h int) error {
+	if err := checkSprite(sprite, mask, w, h); err != nil {
+		return err
+	}
+	blit(c.pix, c.w, c.h, x, y, sprite, mask, w, h)
+	return nil
+}
+
+// Clear clears the canvas.
+func (c *Canvas) Clear() {
+	for i := range c.pix {
+		c.pix[i] = 0
+	}
+}
+
+// Width returns the canvas width.
+func (c *Canvas) Width() int { return c.w }
+
+// Height returns the canvas height.
+func (c *Canvas) Height() int { return c.h }
+
+// DrawCanvas copies the canvas c to the buffer with its top left corner at
+// x, y, over the pixels under it; the parts out of the display are cut. It
+// is as fast as Blit, a call to Draw is required to display it.
+func (o *OLED) DrawCanvas(x, y int, c *Canvas) error {
+	return o.Blit(x, y, c.pix, nil, c.w, c.h)
+}
@@ -0,0 +1,22 @@
+package monochromeoled
+
+import "github.com/goiot/devices/caps"
+
+// Caps are the capabilities of the 128x64 display.
+var Caps = caps.Capabilities{
+	Name:       "SSD1306",
+	Bus:        "i2c",
+	Addresses:  []int{addr, addr + 1},
+	Outputs:    []caps.Output{{Kind: caps.Pixels, Width: ssd1306_LCDWIDTH, Height: ssd1306_LCDHEIGHT, Color: "monochrome"}},
+	PowerModes: []string{"on", "off"},
+}
+
+func init() { caps.Register(Caps) }
+
+// Capabilities implements caps.Describer.
+func (o *OLED) Capabilities() caps.Capabilities {
+	c := Caps
+	c.Outputs = []caps.Output{{Kind: caps.Pixels, Width: o.Width(), Height: o.Height(), Color: "monochrome"}}
+	c.Name = o.controller.String()
+	return c
+}
@@ -0,0 +1,109 @@
+package monochromeoled
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+	"sync"
+
+	"github.com/goiot/devices/text"
+)
+
+// Console is a tiny terminal on an OLED, e.g. the boot log of a headless
+// device: the lines printed are appended at its bottom, wrapped to its
+// width, and scroll up once it is full. It is an io.Writer, the output of
+// a log.Logger for instance, and can be used by multiple goroutines.
+type Console struct {
+	OLED *OLED
+
+	// Font draws the text, text.Small if nil.
+	Font text.Chain
+
+	// Area is the area of the display of the console; the whole display if
+	// empty.
+	Area image.Rectangle
+
+	mu      sync.Mutex
+	lines   []string // wrapped, the last ones shown
+	partial string   // last line, not ended yet
+}
+
+// Write implements io.Writer. The lines end with a newline, the last one
+// is shown before it ends.
+func (c *Console) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	font, area := c.font(), c.area()
+	rows := area.Dy() / font.Height()
+
+	s := strings.Replace(c.partial+string(p), "\r", "", -1)
+	i := strings.LastIndex(s, "\n")
+	if i >= 0 {
+		c.lines = append(c.lines, font.Wrap(s[:i], area.Dx(), 1)...)
+		s = s[i+1:]
+	}
+	c.partial = s
+	if len(c.lines) > rows {
+		c.lines = append([]string(nil), c.lines[len(c.lines)-rows:]...)
+	}
+
+	shown := c.lines
+	if c.partial != "" {
+		shown = append(shown[:len(shown):len(shown)], font.Wrap(c.partial, area.Dx(), 1)...)
+	}
+	if len(shown) > rows {
+		shown = shown[len(shown)-rows:]
+	}
+	// drawn at once, not to show the area half cleared
+	cv := NewCanvas(area.Dx(), area.Dy())
+	for i, l := range shown {
+		font.Draw(cv, 0, i*font.Height(), l, color.White, 1)
+	}
+	if err := c.show(area, cv); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Println prints its operands on a line, formatted as by fmt.Println.
+func (c *Console) Println(a ...interface{}) error {
+	_, err := fmt.Fprintln(c, a...)
+	return err
+}
+
+// Printf prints a text formatted as by fmt.Printf.
+func (c *Console) Printf(format string, a ...interface{}) error {
+	_, err := fmt.Fprintf(c, format, a...)
+	return err
+}
+
+// Clear clears the console.
+func (c *Console) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lines, c.partial = nil, ""
+	area := c.area()
+	return c.show(area, NewCanvas(area.Dx(), area.Dy()))
+}
+
+func (c *Console) show(area image.Rectangle, cv *Canvas) error {
+	if err := c.OLED.DrawCanvas(area.Min.X, area.Min.Y, cv); err != nil {
+		return err
+	}
+	return c.OLED.Draw()
+}
+
+func (c *Console) font() text.Chain {
+	if c.Font == nil {
+		return text.Chain{text.Small}
+	}
+	return c.Font
+}
+
+func (c *Console) area() image.Rectangle {
+	if c.Area.Empty() {
+		return c.OLED.Bounds()
+	}
+	return c.Area.Intersect(c.OLED.Bounds())
+}
@@ -0,0 +1,46 @@
+package monochromeoled_test
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/goiot/devices/monochromeoled"
+	"github.com/goiot/devices/monochromeoled/oledsim"
+)
+
+func ExampleOLED_draw() {
+	// A simulated display, see i2cbus.Open for a real one.
+	sim := oledsim.New(128, 64)
+	oled, err := monochromeoled.Open(sim)
+	if err != nil {
+		panic(err)
+	}
+	defer oled.Close()
+
+	// The display is a draw.Image, image/draw and the 2D libraries render
+	// in its buffer. A white square on the left half...
+	draw.Draw(oled, image.Rect(0, 0, 64, 64), image.White, image.Point{}, draw.Src)
+
+	// ...and a 50% gray on the right half, dithered to black and white.
+	gray := image.NewPaletted(image.Rect(64, 0, 128, 64), color.Palette{color.Black, color.White})
+	draw.FloydSteinberg.Draw(gray, gray.Bounds(), image.NewUniform(color.Gray{Y: 0x80}), image.Point{})
+	draw.Draw(oled, gray.Bounds(), gray, gray.Bounds().Min, draw.Src)
+
+	if err := oled.Draw(); err != nil {
+		panic(err)
+	}
+	img := sim.Image()
+	lit := map[bool]int{} // on the left
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 128; x++ {
+			if img.GrayAt(x, y).Y != 0 {
+				lit[x < 64]++
+			}
+		}
+	}
+	fmt.Printf("%v pixels lit on the left, %v on the right\n", lit[true], lit[false])
+	// Output:
+	// 4096 pixels lit on the left, 2052 on the right
+}
@@ -6,8 +6,8 @@ import (
 
 	_ "image/png"
 
+	"github.com/goiot/devices/i2cbus"
 	"github.com/goiot/devices/monochromeoled"
-	"golang.org/x/exp/io/i2c"
 )
 
 func main() {
@@ -22,7 +22,13 @@ func main() {
 		panic(err)
 	}
 
-	d, err := monochromeoled.Open(&i2c.Devfs{Dev: "/dev/i2c-1"})
+	// the bus of the GPIO header, see i2cbus.Buses to list the others
+	bus, err := i2cbus.Open("primary")
+	if err != nil {
+		panic(err)
+	}
+
+	d, err := monochromeoled.Open(bus)
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,67 @@
+//go:build js && wasm
+// +build js,wasm
+
+// This example runs the monochromeoled driver against the simulator in a
+// browser. Build it with:
+//
+//	GOOS=js GOARCH=wasm go build -o main.wasm
+//
+// and serve this directory along with wasm_exec.js from $(go env GOROOT)/misc/wasm.
+package main
+
+import (
+	"math"
+	"syscall/js"
+	"time"
+
+	"github.com/goiot/devices/monochromeoled"
+	"github.com/goiot/devices/monochromeoled/oledsim"
+)
+
+const scale = 4 // size of a simulated pixel on the canvas
+
+func main() {
+	sim := oledsim.New(128, 64)
+	d, err := monochromeoled.Open(sim)
+	if err != nil {
+		panic(err)
+	}
+	defer d.Close()
+
+	doc := js.Global().Get("document")
+	canvas := doc.Call("getElementById", "oled")
+	canvas.Set("width", d.Width()*scale)
+	canvas.Set("height", d.Height()*scale)
+	ctx := canvas.Call("getContext", "2d")
+
+	for frame := 0; ; frame++ {
+		// a sine wave scrolling to the left
+		for x := 0; x < d.Width(); x++ {
+			for y := 0; y < d.Height(); y++ {
+				d.SetPixel(x, y, 0)
+			}
+			y := 32 + int(24*math.Sin(float64(x+frame)/10))
+			d.SetPixel(x, y, 1)
+		}
+		if err := d.Draw(); err != nil {
+			panic(err)
+		}
+		paint(ctx, sim)
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// paint renders the simulated panel on the canvas.
+func paint(ctx js.Value, sim *oledsim.Display) {
+	img := sim.Image()
+	ctx.Set("fillStyle", "#000")
+	ctx.Call("fillRect", 0, 0, img.Rect.Dx()*scale, img.Rect.Dy()*scale)
+	ctx.Set("fillStyle", "#8cf")
+	for y := 0; y < img.Rect.Dy(); y++ {
+		for x := 0; x < img.Rect.Dx(); x++ {
+			if img.GrayAt(x, y).Y != 0 {
+				ctx.Call("fillRect", x*scale, y*scale, scale, scale)
+			}
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package monochromeoled
+
+import (
+	"time"
+
+	"github.com/goiot/devices/clock"
+)
+
+// fadeStep is the time between the levels of a fade.
+const fadeStep = 20 * time.Millisecond
+
+// FadeOut dims the panel to black over d by ramping down its contrast and
+// its pre-charge period, which still lights the panel at the lowest
+// contrast, then turns it off. The levels are restored for On and FadeIn.
+func (o *OLED) FadeOut(d time.Duration) error {
+	c := clock.Or(o.Clock)
+	n := o.fadeSteps(d)
+	for i := 1; i <= n; i++ {
+		if err := o.fade(float64(n-i) / float64(n)); err != nil {
+			return err
+		}
+		c.Sleep(d / time.Duration(n))
+	}
+	if err := o.Off(); err != nil {
+		return err
+	}
+	return o.fade(1)
+}
+
+// FadeIn turns the panel on at the lowest brightness and brightens it to
+// its contrast over d, e.g. after FadeOut.
+func (o *OLED) FadeIn(d time.Duration) error {
+	c := clock.Or(o.Clock)
+	if err := o.fade(0); err != nil {
+		return err
+	}
+	if err := o.On(); err != nil {
+		return err
+	}
+	n := o.fadeSteps(d)
+	for i := 1; i <= n; i++ {
+		c.Sleep(d / time.Duration(n))
+		if err := o.fade(float64(i) / float64(n)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *OLED) fadeSteps(d time.Duration) int {
+	if n := int(d / fadeStep); n > 1 {
+		return n
+	}
+	return 1
+}
+
+// fade sets the contrast and the pre-charge period to the fraction f of
+// their levels. The phase 2 of the pre-charge, in its high nibble, is
+// ramped down to 1 clock; the phase 1 is kept.
+func (o *OLED) fade(f float64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	contrast := byte(float64(o.contrast) * f)
+	phase2 := 1 + byte(float64(o.precharge>>4-1)*f)
+	return o.write([]byte{0x00, 0x81, contrast, 0xd9, phase2<<4 | o.precharge&0x0f})
+}
@@ -0,0 +1,128 @@
+package monochromeoled
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"time"
+
+	"github.com/goiot/devices/clock"
+)
+
+// Animator plays animated GIFs on an OLED, e.g. for splash screens.
+type Animator struct {
+	OLED *OLED
+
+	// Options convert the frames to the pixels of the display, e.g. the
+	// FloydSteinberg dither for the GIFs of photos.
+	Options SetImageOptions
+
+	// Clock times the frames, clock.Real if nil.
+	Clock clock.Clock
+}
+
+// minDelay is the delay of the frames whose delay is shorter, as in the
+// browsers: many GIFs have no delays and expect it.
+const minDelay = 100 * time.Millisecond
+
+// Play composes the frames of g as they are disposed, draws them from the
+// top left corner of the display for their delays, and loops as many
+// times as g says, until ctx is done.
+func (a *Animator) Play(ctx context.Context, g *gif.GIF) error {
+	if len(g.Image) == 0 {
+		return errors.New("the GIF has no frames")
+	}
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if bounds.Empty() {
+		for _, f := range g.Image {
+			bounds = bounds.Union(f.Bounds())
+		}
+	}
+	c := clock.Or(a.Clock)
+	canvas := image.NewRGBA(bounds)
+	for n := 0; ; n++ {
+		draw.Draw(canvas, bounds, image.Transparent, image.Point{}, draw.Src)
+		for i, f := range g.Image {
+			var disposal byte
+			if i < len(g.Disposal) {
+				disposal = g.Disposal[i]
+			}
+			var prev *image.RGBA
+			if disposal == gif.DisposalPrevious {
+				prev = image.NewRGBA(bounds)
+				copy(prev.Pix, canvas.Pix)
+			}
+			draw.Draw(canvas, f.Bounds(), f, f.Bounds().Min, draw.Over)
+			if err := a.OLED.SetImageWith(0, 0, canvas, a.Options); err != nil {
+				return err
+			}
+			if err := a.OLED.SwapBuffers(); err != nil {
+				return err
+			}
+
+			delay := minDelay
+			if i < len(g.Delay) && g.Delay[i] > 1 {
+				delay = time.Duration(g.Delay[i]) * 10 * time.Millisecond
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-c.After(delay):
+			}
+
+			switch disposal {
+			case gif.DisposalBackground:
+				draw.Draw(canvas, f.Bounds(), image.Transparent, image.Point{}, draw.Src)
+			case gif.DisposalPrevious:
+				canvas = prev
+			}
+		}
+		// played once without loop count, the first time and LoopCount
+		// times more with one, forever with 0
+		if g.LoopCount < 0 || (g.LoopCount > 0 && n >= g.LoopCount) {
+			break
+		}
+	}
+	return nil
+}
+
+// Animate draws frames at fps frames per second, 30 if 0, until ctx is
+// done or fn fails: fn sets the buffer of the OLED to the frame, which is
+// then shown with SwapBuffers. The frames are numbered from the start of
+// the animation, a frame late by more than a period is dropped, so that
+// the animation keeps its pace when fn or the display are slow.
+func (a *Animator) Animate(ctx context.Context, fps int, fn func(frame int) error) error {
+	if fps < 0 {
+		return fmt.Errorf("invalid frame rate %d", fps)
+	}
+	if fps == 0 {
+		fps = 30
+	}
+	c := clock.Or(a.Clock)
+	period := time.Second / time.Duration(fps)
+	start := c.Now()
+	t := c.NewTicker(period)
+	defer t.Stop()
+	for frame := 0; ; {
+		if err := fn(frame); err != nil {
+			return err
+		}
+		if err := a.OLED.SwapBuffers(); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C():
+		}
+		// the ticks are never early, the ticks missed are dropped
+		next := int(c.Now().Sub(start) / period)
+		if next <= frame {
+			next = frame + 1
+		}
+		frame = next
+	}
+}
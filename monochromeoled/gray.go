@@ -0,0 +1,118 @@
+package monochromeoled
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"sync"
+	"time"
+
+	"github.com/goiot/devices/clock"
+)
+
+// Gray emulates levels of gray on an OLED with temporal dithering: Run
+// redraws the panel at a high rate, lighting each pixel in a number of
+// frames proportional to its level. The pixels are lit in different
+// frames from one pixel to the next, so the panel as a whole does not
+// pulse. Gray implements display.Display, its color model is a palette
+// of the levels of gray.
+//
+// The mode is experimental: it needs all the bandwidth of the bus, a
+// 400kHz I2C bus draws about 40 frames per second, enough for 3 levels
+// without visible flicker, and the panel flickers on camera.
+type Gray struct {
+	// Clock times Run, clock.Real if nil.
+	Clock clock.Clock
+
+	o      *OLED
+	period int // frames per cycle, the levels minus one
+	img    *image.Paletted
+
+	mu    sync.Mutex
+	frame []uint8 // levels of the pixels shown by Run
+}
+
+// NewGray returns a frame buffer of 2 to 4 levels of gray for o, 4 if
+// levels is out of range.
+func NewGray(o *OLED, levels int) *Gray {
+	if levels < 2 || levels > 4 {
+		levels = 4
+	}
+	p := make(color.Palette, levels)
+	for i := range p {
+		p[i] = color.Gray{Y: uint8(i * 0xFF / (levels - 1))}
+	}
+	r := o.Bounds()
+	return &Gray{o: o, period: levels - 1, img: image.NewPaletted(r, p), frame: make([]uint8, r.Dx()*r.Dy())}
+}
+
+// ColorModel implements image.Image, the model is the palette of grays.
+func (g *Gray) ColorModel() color.Model { return g.img.Palette }
+
+// Bounds implements image.Image.
+func (g *Gray) Bounds() image.Rectangle { return g.img.Bounds() }
+
+// At implements image.Image.
+func (g *Gray) At(x, y int) color.Color { return g.img.At(x, y) }
+
+// Set implements draw.Image, c is converted to the nearest level.
+func (g *Gray) Set(x, y int, c color.Color) { g.img.Set(x, y, c) }
+
+// Draw queues the frame buffer to be shown by Run from its next frame.
+func (g *Gray) Draw() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	copy(g.frame, g.img.Pix)
+	return nil
+}
+
+// Run draws the frames at fps frames per second, 60 if zero, until ctx is
+// done or the display fails.
+func (g *Gray) Run(ctx context.Context, fps float64) error {
+	if fps <= 0 {
+		fps = 60
+	}
+	t := clock.Or(g.Clock).NewTicker(time.Duration(float64(time.Second) / fps))
+	defer t.Stop()
+	for k := 0; ; k = (k + 1) % g.period {
+		if err := g.show(k); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C():
+		}
+	}
+}
+
+// show draws the frame k of the cycle.
+func (g *Gray) show(k int) error {
+	g.o.mu.Lock()
+	defer g.o.mu.Unlock()
+	g.subframe(k)
+	return g.o.flush()
+}
+
+// subframe sets the buffer of the OLED to the frame k of the cycle: a
+// pixel of level v is lit in v frames of the cycle. The lock of the OLED
+// is held.
+func (g *Gray) subframe(k int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for i := 1; i < len(g.o.buf); i++ {
+		g.o.buf[i] = 0
+	}
+	b := g.o.bounds()
+	w, h := b.Dx(), b.Dy()
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := int(g.frame[y*w+x])
+			if (k+x+y)%g.period < v {
+				i, mask := g.o.bit(x, y)
+				g.o.buf[i] |= mask
+			}
+		}
+	}
+	g.o.dirty = g.o.pages()
+}
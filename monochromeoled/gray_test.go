@@ -0,0 +1,65 @@
+package monochromeoled
+
+import (
+	"context"
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/i2csim"
+)
+
+func TestGray(t *testing.T) {
+	bus := i2csim.NewBus()
+	drawn := make(chan struct{}, 1)
+	bus.Attach(addr, i2csim.DeviceFunc(func(w, r []byte) error {
+		if len(w) == 1+ssd1306_LCDWIDTH*ssd1306_LCDHEIGHT/8 {
+			select {
+			case drawn <- struct{}{}:
+			default:
+			}
+		}
+		return nil
+	}))
+	o, err := Open(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGray(o, 4)
+	for x := 0; x < 4; x++ {
+		g.Set(x, 0, color.Gray{Y: uint8(x * 0x55)})
+	}
+	g.Set(4, 0, color.Gray{Y: 0x60}) // nearest level is 1
+	g.Draw()
+
+	lit := make([]int, 5)
+	for k := 0; k < 3; k++ {
+		g.subframe(k)
+		for x := range lit {
+			if o.At(x, 0) != (color.Gray{}) {
+				lit[x]++
+			}
+		}
+	}
+	for x, want := range []int{0, 1, 2, 3, 1} {
+		if lit[x] != want {
+			t.Errorf("pixel %d lit in %d frames; want %d", x, lit[x], want)
+		}
+	}
+
+	c := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	g.Clock = c
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- g.Run(ctx, 50) }()
+	for i := 0; i < 3; i++ {
+		<-drawn
+		c.Advance(20 * time.Millisecond)
+	}
+	<-drawn
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Run = %v", err)
+	}
+}
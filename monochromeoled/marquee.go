@@ -0,0 +1,137 @@
+package monochromeoled
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/text"
+)
+
+// Marquee scrolls a line of text wider than its area across it, a pixel at
+// a time, e.g. the title of a song. Unlike the scrolling of the
+// controller, it scrolls the text itself, leaving the rest of the display
+// as it is.
+type Marquee struct {
+	OLED *OLED
+	Text string
+
+	// Font draws the text, text.Small if nil.
+	Font text.Chain
+
+	// Area is the area of the display the text scrolls in, drawn from its
+	// top; the whole display if empty.
+	Area image.Rectangle
+
+	// Speed is the speed of the text in pixels per second, 30 if 0.
+	Speed int
+
+	// Pause is the time the text stops at its start, and at its end if it
+	// does not loop.
+	Pause time.Duration
+
+	// Loop loops the text forever: it scrolls on and comes in again after
+	// Gap pixels, a quarter of the area if 0. Otherwise it scrolls once to
+	// its end.
+	Loop bool
+	Gap  int
+
+	// Clock times the frames, clock.Real if nil.
+	Clock clock.Clock
+}
+
+var errMarqueeDone = errors.New("marquee done")
+
+// Run scrolls the text until it is done or ctx is done, the text is left
+// at its end. A text fitting in the area is drawn in place.
+func (m *Marquee) Run(ctx context.Context) error {
+	if m.Speed < 0 {
+		return fmt.Errorf("invalid speed of %d pixels per second", m.Speed)
+	}
+	font := m.Font
+	if font == nil {
+		font = text.Chain{text.Small}
+	}
+	area := m.Area.Intersect(m.OLED.Bounds())
+	if m.Area.Empty() {
+		area = m.OLED.Bounds()
+	}
+	speed := m.Speed
+	if speed == 0 {
+		speed = 30
+	}
+
+	// the text is drawn once, and copied to the area at each frame
+	size := font.Size(m.Text, 1)
+	strip := image.NewGray(image.Rect(0, 0, size.X, size.Y))
+	font.Draw(strip, 0, 0, m.Text, color.White, 1)
+	period := size.X
+	if m.Loop {
+		gap := m.Gap
+		if gap <= 0 {
+			gap = area.Dx() / 4
+		}
+		period += gap
+	}
+	show := func(pos int) error {
+		// copied at once, not to show the area half drawn
+		cv := NewCanvas(area.Dx(), area.Dy())
+		for y := 0; y < area.Dy(); y++ {
+			for x := 0; x < area.Dx(); x++ {
+				sx := pos + x
+				if m.Loop {
+					sx %= period
+				}
+				if sx < size.X && strip.GrayAt(sx, y).Y != 0 {
+					cv.SetPixel(x, y, 1)
+				}
+			}
+		}
+		return m.OLED.DrawCanvas(area.Min.X, area.Min.Y, cv)
+	}
+
+	if size.X <= area.Dx() {
+		if err := show(0); err != nil {
+			return err
+		}
+		if err := m.OLED.Draw(); err != nil || !m.Loop {
+			return err
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	pause := int(m.Pause * time.Duration(speed) / time.Second) // in frames
+	end := size.X - area.Dx()
+	a := &Animator{OLED: m.OLED, Clock: m.Clock}
+	err := a.Animate(ctx, speed, func(frame int) error {
+		if m.Loop {
+			pos := frame%(pause+period) - pause
+			if pos < 0 {
+				pos = 0
+			}
+			return show(pos)
+		}
+		if frame > pause+end+pause {
+			return errMarqueeDone
+		}
+		pos := frame - pause
+		if pos < 0 {
+			pos = 0
+		} else if pos > end {
+			pos = end
+		}
+		return show(pos)
+	})
+	if err == errMarqueeDone {
+		// the frames late may have skipped the end
+		if err := show(end); err != nil {
+			return err
+		}
+		return m.OLED.SwapBuffers()
+	}
+	return err
+}
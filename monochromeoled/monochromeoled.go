@@ -1,13 +1,26 @@
 // Package monochromeoled contains an Adafruit Monochrome OLED (SSD1306)
-// display driver.
+// display driver. It also drives the modules with an SH1106, SSD1309 or
+// SSD1305 controller, see Config.
 package monochromeoled
 
 import (
+	"errors"
 	"fmt"
 	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/gpio"
+	"github.com/goiot/devices/text"
+	"github.com/goiot/devices/writev"
 	"golang.org/x/exp/io/i2c"
 	"golang.org/x/exp/io/i2c/driver"
+	"golang.org/x/exp/io/spi"
+	spidriver "golang.org/x/exp/io/spi/driver"
 )
 
 const (
@@ -16,6 +29,9 @@ const (
 
 	addr = 0x3C // addr is the I2C address of the device.
 
+	// SPISpeed is the SPI clock used to talk to the controller.
+	SPISpeed = 8000000
+
 	// On or off registers.
 	ssd1306_DISPLAY_ON  = 0xAF
 	ssd1306_DISPLAY_OFF = 0xAE
@@ -30,125 +46,819 @@ const (
 	ssd1306_VERTICAL_AND_LEFT_HORIZONTAL_SCROLL  = 0x2A
 )
 
-// OLED represents an SSD1306 OLED display.
+// OLED represents an SSD1306 OLED display. It can be used by multiple
+// goroutines: each call is done at once, a Draw never sends a pixel set
+// half way. To show several calls at once, e.g. a widget drawn by its own
+// goroutine, draw them on a Canvas for DrawCanvas, or with double
+// buffering.
 type OLED struct {
-	dev *i2c.Device
+	mu sync.Mutex // of the buffer, the state and the controller
+
+	dev  *i2c.Device
+	spi  *spi.Device // SPI bus, instead of dev
+	dc   gpio.Pin    // D/C pin on SPI
+	conn writev.Conn // of dev or spi, for the flushes in segments
 
 	w   int    // width of the display
 	h   int    // height of the display
+	col int    // first column of the display RAM shown
+	rot int    // rotation of the image, by 90 degrees in the buffer
 	buf []byte // each pixel is represented by a bit
+
+	// dirty are the columns and pages of the buffer changed since the
+	// last Draw.
+	dirty image.Rectangle
+
+	flipH, flipV bool // mirrors of the panel, along its columns and rows
+
+	on       bool // whether the panel was turned on
+	readable int  // 1 if the controller answered a read, -1 if it failed
+
+	scrollTop, scrollRows int // vertical scroll area
+
+	doubleBuffered bool // Draw waits for SwapBuffers
+
+	controller Controller
+	init       []byte // initialization of the controller, sent by Reinit
+
+	contrast, precharge byte // levels restored by FadeIn
+
+	shift image.Point // of the frame shown, by the burn-in protection
+	burn  *burnIn
+
+	// Clock times the fades and the burn-in protection, clock.Real if nil;
+	// Config.Clock at first.
+	Clock clock.Clock
+}
+
+// Panel is the geometry of a panel: its size and how it is wired to the
+// controller.
+type Panel struct {
+	Width, Height int
+
+	comPins byte // COM pins hardware configuration
+	column  int  // first column of the display RAM shown
+}
+
+// Geometries of the usual panels.
+var (
+	Panel128x64 = Panel{Width: 128, Height: 64, comPins: 0x12}
+	Panel128x32 = Panel{Width: 128, Height: 32, comPins: 0x02}
+	Panel96x16  = Panel{Width: 96, Height: 16, comPins: 0x02}
+	Panel64x48  = Panel{Width: 64, Height: 48, comPins: 0x12, column: 32}
+)
+
+var panels = []Panel{Panel128x64, Panel128x32, Panel96x16, Panel64x48}
+
+// Config is the configuration of a display.
+type Config struct {
+	// Addr is the I2C address, 0x3C if zero. The modules with their SA0
+	// pin strapped high answer at 0x3D.
+	Addr int
+
+	// Width and Height are the size of the panel, one of the geometries of
+	// Panel; 128x64 if zero.
+	Width, Height int
+
+	// ExternalVCC is set for the panels powered by an external VCC, the
+	// charge pump of the controller is disabled.
+	ExternalVCC bool
+
+	// Rotation is the angle of the image on the panel in degrees,
+	// counter-clockwise: 0, 90, 180 or 270.
+	Rotation int
+
+	// Controller is the controller of the module, most 1.3" modules have
+	// an SH1106 and the 2.42" ones an SSD1309.
+	Controller Controller
+
+	// Clock times the reset over SPI, and is the Clock of the OLED;
+	// clock.Real if nil.
+	Clock clock.Clock
+}
+
+// Controller is a controller of the panels.
+type Controller int
+
+const (
+	SSD1306 Controller = iota
+
+	// SH1106 has a RAM of 132 columns, the 128 of the panel shown from
+	// the column 2, and is only updated page by page. It cannot scroll.
+	SH1106
+
+	// SSD1309 is an SSD1306 without charge pump, the modules have their
+	// own boost converter.
+	SSD1309
+
+	// SSD1305 has a RAM of 132 columns, the 128 of the panel shown from
+	// the column 4. Its scroll speeds are slower, the 5, 25, 64, 128 and
+	// 256 frames intervals are 6, 5, 32, 64 and 128 frames.
+	SSD1305
+)
+
+var controllers = []string{"SSD1306", "SH1106", "SSD1309", "SSD1305"}
+
+func (c Controller) String() string {
+	if c < 0 || int(c) >= len(controllers) {
+		return fmt.Sprintf("Controller(%d)", int(c))
+	}
+	return controllers[c]
 }
 
-var initSeq = []byte{
-	0xae,
-	0x00 | 0x00, // row offset
-	0x10 | 0x00, // column offset
-	0xd5, 0x40,
-	0xa8, ssd1306_LCDHEIGHT - 1,
-	0xd3, 0x00, // set display offset to no offset
-	0x40 | 0,
-	0x8d, 0x14,
-	0x20, 0x0,
+// column returns the first column of the RAM shown, added to the one of
+// the panel.
+func (c Controller) column() int {
+	switch c {
+	case SH1106:
+		return 2
+	case SSD1305:
+		return 4
+	}
+	return 0
+}
+
+var errNoScroll = errors.New("the SH1106 cannot scroll")
+
+// panel returns the geometry of the panel.
+func (c Config) panel() (Panel, error) {
+	if c.Width == 0 && c.Height == 0 {
+		return Panel128x64, nil
+	}
+	for _, p := range panels {
+		if p.Width == c.Width && p.Height == c.Height {
+			return p, nil
+		}
+	}
+	return Panel{}, fmt.Errorf("unsupported %vx%v panel", c.Width, c.Height)
+}
+
+// initSeq returns the initialization of the controller of the panel.
+func initSeq(p Panel, c Config) []byte {
+	pump, precharge, contrast := levels(c)
+	remap, scan := scanDirection(c.Rotation)
+	switch c.Controller {
+	case SSD1309:
+		return []byte{
+			0x00,       // command stream
+			0xfd, 0x12, // unlock the commands
+			0xae,
+			0xd5, 0xa0,
+			0xa8, byte(p.Height - 1), // multiplex ratio
+			0xd3, 0x00, // set display offset to no offset
+			0x40 | 0,
+			0x20, 0x0,
+
+			remap,
+			scan,
+			0xda, p.comPins,
+			0x81, contrast, // set contrast
+			0xd9, precharge, // pre-charge period
+			0xdb, 0x34,
+			0xa4, 0xa6,
+
+			0x2e,
+			0xaf,
+		}
+	case SSD1305:
+		return []byte{
+			0x00, // command stream
+			0xae,
+			0xd5, 0xf0,
+			0xa8, byte(p.Height - 1), // multiplex ratio
+			0xd3, 0x00, // set display offset to no offset
+			0x40 | 0,
+			0xad, 0x8e, // external VCC
+			0xd8, 0x05, // monochrome, low power mode
+			0x20, 0x0,
+
+			remap,
+			scan,
+			0xda, 0x12, // alternative COM pins on all the panels
+			0x81, contrast, // set contrast
+			0xd9, precharge, // pre-charge period
+			0xdb, 0x34,
+			0xa4, 0xa6,
+
+			0x2e,
+			0xaf,
+		}
+	case SH1106:
+		dcdc := byte(0x8b)
+		if c.ExternalVCC {
+			dcdc = 0x8a
+		}
+		return []byte{
+			0x00, // command stream
+			0xae,
+			0xd5, 0x80,
+			0xa8, byte(p.Height - 1), // multiplex ratio
+			0xd3, 0x00, // set display offset to no offset
+			0x40 | 0,
+			0xad, dcdc, // DC-DC converter
+
+			remap,
+			scan,
+			0xda, p.comPins,
+			0x81, contrast, // set contrast
+			0xd9, precharge, // pre-charge period
+			0xdb, 0x40,
+			0xa4, 0xa6,
+
+			0xaf,
+		}
+	}
+	return []byte{
+		0x00, // command stream
+		0xae,
+		0x00 | 0x00, // row offset
+		0x10 | 0x00, // column offset
+		0xd5, 0x40,
+		0xa8, byte(p.Height - 1), // multiplex ratio
+		0xd3, 0x00, // set display offset to no offset
+		0x40 | 0,
+		0x8d, pump,
+		0x20, 0x0,
+
+		remap,
+		scan,
+		0xda, p.comPins,
+		0x81, contrast, // set contrast
+		0xd9, precharge, // pre-charge period
+		0xdb, 0x40,
+		0xa4, 0xa6,
+
+		0x2e,
+		0xaf,
+	}
+}
+
+// levels returns the charge pump setting, the pre-charge period and the
+// contrast of the controller for its VCC.
+func levels(c Config) (pump, precharge, contrast byte) {
+	switch c.Controller {
+	case SSD1309:
+		return 0, 0x82, 0xdf
+	case SSD1305:
+		return 0, 0xd2, 0x80
+	}
+	if c.ExternalVCC {
+		return 0x10, 0x22, 0x9f
+	}
+	return 0x14, 0xf1, 0xcf
+}
 
-	0xA0 | 0x1,
-	0xC8,
-	0xda, 0x12,
-	0x81, 0xcf, // set contrast
-	0x9d, 0xf1,
-	0xdb, 0x40,
-	0xa4, 0xa6,
+// scanDirection returns the segment remap and COM scan direction commands
+// of the rotation. The controller rotates by 180 degrees, and by 270 with
+// the rotation by 90 of the buffer.
+func scanDirection(rotation int) (remap, scan byte) {
+	if rotation == 180 || rotation == 270 {
+		return 0xA0, 0xC0
+	}
+	return 0xA0 | 0x1, 0xC8
+}
 
-	0x2e,
-	0xaf,
+func newOLED(p Panel, c Config) *OLED {
+	buf := make([]byte, p.Width*(p.Height/8)+1)
+	buf[0] = 0x40 // start frame of pixel data
+	o := &OLED{w: p.Width, h: p.Height, col: p.column + c.Controller.column(), rot: c.Rotation, buf: buf, on: true, scrollRows: p.Height}
+	o.controller, o.Clock = c.Controller, c.Clock
+	o.init = initSeq(p, c)
+	_, o.precharge, o.contrast = levels(c)
+	o.dirty = o.pages() // the RAM is random at power up
+	return o
 }
 
-// Open opens an SSD1306 OLED display. Once not in use, it needs to
+// Open opens a 128x64 SSD1306 OLED display. Once not in use, it needs to
 // be close by calling Close.
-// The default width is 128, height is 64 if zero values are given.
 func Open(o driver.Opener) (*OLED, error) {
-	w := ssd1306_LCDWIDTH
-	h := ssd1306_LCDHEIGHT
-	dev, err := i2c.Open(o, addr)
+	return OpenWithConfig(o, Config{})
+}
+
+// OpenPanel opens an SSD1306 OLED display of the geometry p, e.g.
+// Panel128x32. Once not in use, it needs to be closed by calling Close.
+func OpenPanel(o driver.Opener, p Panel) (*OLED, error) {
+	return OpenWithConfig(o, Config{Width: p.Width, Height: p.Height})
+}
+
+// OpenWithConfig opens an SSD1306 OLED display configured by c. Once not
+// in use, it needs to be closed by calling Close.
+func OpenWithConfig(o driver.Opener, c Config) (*OLED, error) {
+	p, err := c.check()
 	if err != nil {
 		return nil, err
 	}
-	if err := dev.Write(initSeq); err != nil {
+	a := c.Addr
+	if a == 0 {
+		a = addr
+	}
+	dev, conn, err := writev.OpenI2C(o, a)
+	if err != nil {
 		return nil, err
 	}
-	buf := make([]byte, w*(h/8)+1)
-	buf[0] = 0x40 // start frame of pixel data
-	return &OLED{dev: dev, w: w, h: h, buf: buf}, nil
+	oled := newOLED(p, c)
+	if err := dev.Write(oled.init); err != nil {
+		dev.Close()
+		return nil, err
+	}
+	oled.dev, oled.conn = dev, conn
+	return oled, nil
+}
+
+func (c Config) check() (Panel, error) {
+	if err := checkRotation(c.Rotation); err != nil {
+		return Panel{}, err
+	}
+	if c.Controller < SSD1306 || c.Controller > SSD1305 {
+		return Panel{}, fmt.Errorf("unknown controller %d", c.Controller)
+	}
+	return c.panel()
+}
+
+func checkRotation(rotation int) error {
+	switch rotation {
+	case 0, 90, 180, 270:
+		return nil
+	}
+	return fmt.Errorf("invalid rotation %d, must be 0, 90, 180 or 270", rotation)
+}
+
+// OpenSPI opens an SSD1306 OLED display configured by c wired over 4-wire
+// SPI, which draws the frames several times faster than I2C; the address
+// of c is not used. dc is the output pin connected to the D/C pin of the
+// module, reset the one connected to its RES pin, or nil if it is tied
+// high. Once not in use, it needs to be closed by calling Close. The
+// controller cannot be read over SPI.
+func OpenSPI(o spidriver.Opener, dc, reset gpio.Pin, c Config) (*OLED, error) {
+	p, err := c.check()
+	if err != nil {
+		return nil, err
+	}
+	dev, conn, err := writev.OpenSPI(o)
+	if err != nil {
+		return nil, err
+	}
+	oled := newOLED(p, c)
+	oled.spi, oled.conn, oled.dc, oled.readable = dev, conn, dc, -1
+	if err := oled.initSPI(reset, oled.init); err != nil {
+		dev.Close()
+		return nil, err
+	}
+	return oled, nil
+}
+
+func (o *OLED) initSPI(reset gpio.Pin, init []byte) error {
+	if err := o.spi.SetMode(spi.Mode0); err != nil {
+		return err
+	}
+	if err := o.spi.SetBitsPerWord(8); err != nil {
+		return err
+	}
+	if err := o.spi.SetMaxSpeed(SPISpeed); err != nil {
+		return err
+	}
+	if reset != nil {
+		// The controller resets on a low pulse of at least 3us.
+		for _, v := range []int{1, 0, 1} {
+			if err := reset.Write(v); err != nil {
+				return fmt.Errorf("resetting the controller failed - %v", err)
+			}
+			clock.Or(o.Clock).Sleep(time.Millisecond)
+		}
+	}
+	return o.write(init)
 }
 
+// write sends b framed as on I2C: a control byte, 0x00 before commands
+// and 0x40 before pixel data. Over SPI, the D/C pin is set instead.
+func (o *OLED) write(b []byte) error {
+	if o.spi == nil {
+		return o.dev.Write(b)
+	}
+	if err := o.dc.Write(int(b[0]>>6) & 1); err != nil {
+		return err
+	}
+	return o.spi.Tx(b[1:], nil)
+}
+
+// writeVec sends the segments as write, in one transfer, the control byte
+// at the head of the first one.
+func (o *OLED) writeVec(segs ...[]byte) error {
+	if o.spi != nil {
+		if err := o.dc.Write(int(segs[0][0]>>6) & 1); err != nil {
+			return err
+		}
+		segs[0] = segs[0][1:]
+	}
+	return writev.Write(o.conn, segs...)
+}
+
+// deviceConn writes on an I2C device opened by the caller, whose
+// connection is unknown.
+type deviceConn struct {
+	dev *i2c.Device
+}
+
+func (c deviceConn) Tx(w, r []byte) error { return c.dev.Write(w) }
+
 // OpenWithI2c create an OLED object using a giving i2cDevice . Once not in use, it needs to
 // be close by calling Close.
-// The default width is 128, height is 64 if zero values are given.
+// The display is 128 pixels wide, the controller must already be initialized
+// for its height.
 func OpenWithI2c(i2cDevice *i2c.Device, height int) (*OLED, error) {
-	w := ssd1306_LCDWIDTH
-	h := height
+	oled := newOLED(Panel{Width: ssd1306_LCDWIDTH, Height: height}, Config{})
+	oled.dev, oled.conn, oled.init = i2cDevice, deviceConn{i2cDevice}, nil
+	return oled, nil
+}
 
-	buf := make([]byte, w*(h/8)+1)
-	buf[0] = 0x40 // start frame of pixel data
-	return &OLED{dev: i2cDevice, w: w, h: h, buf: buf}, nil
+// Reinit initializes the controller again and draws the whole buffer,
+// e.g. after the system resumed from a sleep which cut the power of the
+// module. The rotation, the flips, the contrast, the scroll area and
+// whether the display is on are restored, the scrolling is stopped. The
+// controller of a display opened by OpenWithI2c is not initialized, only
+// these settings are sent.
+func (o *OLED) Reinit() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.init != nil {
+		if err := o.write(o.init); err != nil {
+			return fmt.Errorf("initializing the controller failed - %v", err)
+		}
+	}
+	if err := o.scan(o.rot, o.flipH, o.flipV); err != nil {
+		return err
+	}
+	if err := o.write([]byte{0x00, 0x81, o.contrast}); err != nil {
+		return err
+	}
+	if o.controller != SH1106 && (o.scrollTop != 0 || o.scrollRows != o.h) {
+		if err := o.write([]byte{0x00, ssd1306_SET_VERTICAL_SCROLL_AREA, byte(o.scrollTop), byte(o.scrollRows)}); err != nil {
+			return err
+		}
+	}
+	if !o.on {
+		if err := o.power(false); err != nil {
+			return err
+		}
+	}
+	return o.drawAll()
 }
 
 // On turns on the display if it is off.
 func (o *OLED) On() error {
-	return o.dev.Write([]byte{ssd1306_DISPLAY_ON})
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.power(true)
 }
 
 // Off turns off the display if it is on.
 func (o *OLED) Off() error {
-	return o.dev.Write([]byte{ssd1306_DISPLAY_OFF})
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.power(false)
+}
+
+// power turns the display on or off.
+func (o *OLED) power(on bool) error {
+	cmd := byte(ssd1306_DISPLAY_OFF)
+	if on {
+		cmd = ssd1306_DISPLAY_ON
+	}
+	if err := o.write([]byte{0x00, cmd}); err != nil {
+		return err
+	}
+	o.on = on
+	o.account(o.frame())
+	return nil
+}
+
+// SetRotation sets the rotation of the image on the panel in degrees,
+// counter-clockwise: 0, 90, 180 or 270, e.g. for a display mounted upside
+// down. The coordinates of the following calls are the rotated ones, the
+// image has to be drawn again.
+func (o *OLED) SetRotation(rotation int) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := checkRotation(rotation); err != nil {
+		return err
+	}
+	if err := o.scan(rotation, o.flipH, o.flipV); err != nil {
+		return err
+	}
+	o.rot = rotation
+	return nil
+}
+
+// FlipHorizontal mirrors the panel horizontally if on is true, e.g. for a
+// display seen through a mirror, and reverts it otherwise. The panel is
+// mirrored by the controller, the image does not have to be drawn again.
+func (o *OLED) FlipHorizontal(on bool) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := o.scan(o.rot, on, o.flipV); err != nil {
+		return err
+	}
+	o.flipH = on
+	return nil
+}
+
+// FlipVertical mirrors the panel vertically if on is true, and reverts it
+// otherwise. Both flips rotate the image by 180 degrees.
+func (o *OLED) FlipVertical(on bool) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := o.scan(o.rot, o.flipH, on); err != nil {
+		return err
+	}
+	o.flipV = on
+	return nil
+}
+
+// scan sends the segment remap and COM scan direction of the rotation
+// and flips.
+func (o *OLED) scan(rotation int, flipH, flipV bool) error {
+	remap, scan := scanDirection(rotation)
+	if flipH {
+		remap ^= 0x1
+	}
+	if flipV {
+		scan ^= 0x08
+	}
+	return o.write([]byte{0x00, remap, scan})
+}
+
+// Invert inverts the pixels of the display if on is true, e.g. to flash
+// the screen for an alert, and reverts it to normal otherwise. The buffer
+// is not changed.
+func (o *OLED) Invert(on bool) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	cmd := byte(0xa6)
+	if on {
+		cmd = 0xa7
+	}
+	return o.write([]byte{0x00, cmd})
+}
+
+// SetContrast sets the contrast of the display, from 0 to 255. The panel
+// draws less current with a lower contrast.
+func (o *OLED) SetContrast(level byte) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := o.write([]byte{0x00, 0x81, level}); err != nil {
+		return err
+	}
+	o.contrast = level
+	return nil
 }
 
 // Clear clears the entire display.
 func (o *OLED) Clear() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
 	for i := 1; i < len(o.buf); i++ {
 		o.buf[i] = 0
 	}
-	return o.Draw()
+	return o.drawAll()
 }
 
+// SetPixel sets the pixel x, y of the buffer, lit if v is 1. A call to
+// Draw is required to display it.
 func (o *OLED) SetPixel(x, y int, v byte) error {
-	if x >= o.w || y >= o.h {
-		return fmt.Errorf("(x=%v, y=%v) is out of bounds on this %vx%v display", x, y, o.w, o.h)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.setPixel(x, y, v)
+}
+
+func (o *OLED) setPixel(x, y int, v byte) error {
+	if b := o.bounds(); x >= b.Dx() || y >= b.Dy() {
+		return fmt.Errorf("(x=%v, y=%v) is out of bounds on this %vx%v display", x, y, b.Dx(), b.Dy())
 	}
 	if v > 1 {
 		return fmt.Errorf("value needs to be either 0 or 1; given %v", v)
 	}
-	i := 1 + x + (y/8)*o.w
-	if v == 0 {
-		o.buf[i] &= ^(1 << uint((y & 7)))
-	} else {
-		o.buf[i] |= 1 << uint((y & 7))
+	i, mask := o.bit(x, y)
+	b := o.buf[i] &^ mask
+	if v != 0 {
+		b |= mask
+	}
+	if b != o.buf[i] {
+		o.buf[i] = b
+		x, p := (i-1)%o.w, (i-1)/o.w
+		o.dirty = o.dirty.Union(image.Rect(x, p, x+1, p+1))
 	}
 	return nil
 }
 
-// SetImage draws an image on the display buffer starting from x, y.
-// A call to Draw is required to display it on the OLED display.
+// pages returns the columns and pages of the whole buffer.
+func (o *OLED) pages() image.Rectangle {
+	return image.Rect(0, 0, o.w, o.h/8)
+}
+
+// bit returns the byte of the buffer and the bit of the pixel x, y of the
+// image.
+func (o *OLED) bit(x, y int) (int, byte) {
+	if o.rot == 90 || o.rot == 270 {
+		x, y = y, o.h-1-x
+	}
+	return 1 + x + (y/8)*o.w, 1 << uint(y&7)
+}
+
+// Model is the 1-bit color model of the display: the pixels are lit,
+// white, unless their color is black. Draw the images with levels of gray
+// with SetImageWith and FloydSteinberg, or dither them to a palette of
+// black and white first.
+var Model = color.ModelFunc(func(c color.Color) color.Color {
+	if lit(c) {
+		return color.Gray{Y: 0xFF}
+	}
+	return color.Gray{}
+})
+
+func lit(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r+g+b > 0
+}
+
+// luma returns the 8 bits luma of c.
+func luma(c color.Color) uint8 {
+	return color.GrayModel.Convert(c).(color.Gray).Y
+}
+
+// ColorModel implements image.Image, the model is Model.
+func (o *OLED) ColorModel() color.Model { return Model }
+
+// Bounds implements image.Image.
+func (o *OLED) Bounds() image.Rectangle {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.bounds()
+}
+
+func (o *OLED) bounds() image.Rectangle {
+	if o.rot == 90 || o.rot == 270 {
+		return image.Rect(0, 0, o.h, o.w)
+	}
+	return image.Rect(0, 0, o.w, o.h)
+}
+
+// At implements image.Image, the lit pixels are white.
+func (o *OLED) At(x, y int) color.Color {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.at(x, y)
+}
+
+func (o *OLED) at(x, y int) color.Color {
+	if !image.Pt(x, y).In(o.bounds()) {
+		return color.Gray{}
+	}
+	if i, mask := o.bit(x, y); o.buf[i]&mask != 0 {
+		return color.Gray{Y: 0xFF}
+	}
+	return color.Gray{}
+}
+
+// Snapshot returns a copy of the buffer, the lit pixels are white: the
+// image shown by the panel once drawn, e.g. to save a screenshot or to
+// compare with a golden image.
+func (o *OLED) Snapshot() *image.Gray {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	img := image.NewGray(o.bounds())
+	for y := 0; y < img.Rect.Dy(); y++ {
+		for x := 0; x < img.Rect.Dx(); x++ {
+			if i, mask := o.bit(x, y); o.buf[i]&mask != 0 {
+				img.Pix[y*img.Stride+x] = 0xFF
+			}
+		}
+	}
+	return img
+}
+
+// Set implements draw.Image, the pixel is lit unless c is black as in
+// SetImage. Pixels out of the display are ignored.
+func (o *OLED) Set(x, y int, c color.Color) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.set(x, y, c)
+}
+
+func (o *OLED) set(x, y int, c color.Color) {
+	if !image.Pt(x, y).In(o.bounds()) {
+		return
+	}
+	var v byte
+	if lit(c) {
+		v = 1
+	}
+	o.setPixel(x, y, v)
+}
+
+// buffer is the buffer of an OLED whose lock is held, for the drawing
+// functions shared with Canvas.
+type buffer struct{ o *OLED }
+
+func (b buffer) ColorModel() color.Model         { return Model }
+func (b buffer) Bounds() image.Rectangle         { return b.o.bounds() }
+func (b buffer) At(x, y int) color.Color         { return b.o.at(x, y) }
+func (b buffer) Set(x, y int, c color.Color)     { b.o.set(x, y, c) }
+func (b buffer) SetPixel(x, y int, v byte) error { return b.o.setPixel(x, y, v) }
+func (b buffer) Width() int                      { return b.o.bounds().Dx() }
+func (b buffer) Height() int                     { return b.o.bounds().Dy() }
+
+// Dither is the way SetImageWith reduces the colors of an image to the
+// lit and off pixels.
+type Dither int
+
+const (
+	// Threshold lights the pixels whose color is not black, or whose
+	// luma reaches the Level of the options.
+	Threshold Dither = iota
+	// FloydSteinberg diffuses the errors of the pixels to their
+	// neighbors, so the levels of gray of photos show as the density of
+	// the lit pixels.
+	FloydSteinberg
+)
+
+// SetImageOptions are the options of SetImageWith.
+type SetImageOptions struct {
+	Dither Dither
+
+	// Level is the luma, from the weighted red, green and blue of the
+	// pixels as in color.GrayModel, from which the Threshold dither lights
+	// the pixels, e.g. 128 so that the antialiased edges of rendered text
+	// stay thin. At 0 every pixel which is not black is lit.
+	Level uint8
+}
+
+// SetImage draws an image on the display buffer starting from x, y,
+// lighting the pixels which are not black. A call to Draw is required to
+// display it on the OLED display.
 func (o *OLED) SetImage(x, y int, img image.Image) error {
+	return o.SetImageWith(x, y, img, SetImageOptions{})
+}
+
+// SetImageWith draws an image on the display buffer starting from x, y
+// like SetImage, dithered as set by opts.
+func (o *OLED) SetImageWith(x, y int, img image.Image, opts SetImageOptions) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return setImage(buffer{o}, x, y, img, opts)
+}
+
+// pixels is a 1-bit buffer, of an OLED or a Canvas.
+type pixels interface {
+	SetPixel(x, y int, v byte) error
+	Width() int
+	Height() int
+}
+
+// setImage draws img on the buffer o as SetImageWith.
+func setImage(o pixels, x, y int, img image.Image, opts SetImageOptions) error {
+	on := lit
+	switch opts.Dither {
+	case Threshold:
+		if opts.Level > 0 {
+			on = func(c color.Color) bool { return luma(c) >= opts.Level }
+		}
+	case FloydSteinberg:
+		b := img.Bounds()
+		// only the part on the display is dithered
+		if w := o.Width() - x; b.Dx() > w {
+			b.Max.X = b.Min.X + w
+		}
+		if h := o.Height() - y; b.Dy() > h {
+			b.Max.Y = b.Min.Y + h
+		}
+		if b.Empty() {
+			return nil
+		}
+		bw := image.NewPaletted(image.Rect(0, 0, b.Dx(), b.Dy()), color.Palette{color.Black, color.White})
+		draw.FloydSteinberg.Draw(bw, bw.Bounds(), img, b.Min)
+		img = bw
+	default:
+		return fmt.Errorf("invalid dither %d", opts.Dither)
+	}
 	imgW := img.Bounds().Dx()
 	imgH := img.Bounds().Dy()
 
 	endX := x + imgW
 	endY := y + imgH
 
-	if endX >= o.w {
-		endX = o.w
+	if w := o.Width(); endX >= w {
+		endX = w
 	}
-	if endY >= o.h {
-		endY = o.h
+	if h := o.Height(); endY >= h {
+		endY = h
 	}
 
 	var imgI, imgY int
 	for i := x; i < endX; i++ {
 		imgY = 0
 		for j := y; j < endY; j++ {
-			r, g, b, _ := img.At(imgI, imgY).RGBA()
 			var v byte
-			if r+g+b > 0 {
+			if on(img.At(imgI, imgY)) {
 				v = 0x1
 			}
 			if err := o.SetPixel(i, j, v); err != nil {
@@ -162,37 +872,275 @@ func (o *OLED) SetImage(x, y int, img image.Image) error {
 }
 
 // Draw draws the intermediate pixel buffer on the display.
-// See SetPixel and SetImage to mutate the buffer.
+// See SetPixel and SetImage to mutate the buffer. Only the window of the
+// columns and pages changed since the last Draw is sent, see DrawAll.
+// With double buffering, Draw does nothing until SwapBuffers.
 func (o *OLED) Draw() error {
-	if err := o.dev.Write([]byte{
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.draw()
+}
+
+func (o *OLED) draw() error {
+	if o.doubleBuffered {
+		return nil
+	}
+	return o.flush()
+}
+
+// SetDoubleBuffering sets whether the buffer is a back buffer, only shown
+// by SwapBuffers: Draw, and the methods calling it such as DrawText and
+// Clear, then leave the display as it is, so that a frame composed by
+// several of them is never shown half drawn.
+func (o *OLED) SetDoubleBuffering(on bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.doubleBuffered = on
+}
+
+// SwapBuffers shows the back buffer, sending the window of the columns
+// and pages changed since the last swap in one write. Without double
+// buffering it is the same as Draw.
+func (o *OLED) SwapBuffers() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.flush()
+}
+
+// flush sends the window of the buffer changed since the last flush,
+// shifted by the burn-in protection.
+func (o *OLED) flush() error {
+	o.moveShift()
+	if o.dirty.Empty() {
+		return nil
+	}
+	buf, d := o.frame(), o.window(o.dirty)
+	if !d.Empty() {
+		send := o.flushWindow
+		if o.controller == SH1106 {
+			send = o.flushPages
+		}
+		if err := send(buf, d); err != nil {
+			return err
+		}
+	}
+	o.dirty = image.Rectangle{}
+	o.account(buf)
+	return nil
+}
+
+// flushWindow sends the window d of the frame buf.
+func (o *OLED) flushWindow(buf []byte, d image.Rectangle) error {
+	if err := o.write([]byte{
+		0x00,     // command stream
 		0xa4,     // write mode
 		0x40 | 0, // start line = 0
-		0x21, 0, ssd1306_LCDWIDTH,
-		0x22, 0, 7,
+		0x21, byte(o.col + d.Min.X), byte(o.col + d.Max.X - 1),
+		0x22, byte(d.Min.Y), byte(d.Max.Y - 1),
 	}); err != nil { // the write mode
 		return err
 	}
-	return o.dev.Write(o.buf)
+	if d == o.pages() {
+		return o.write(buf)
+	}
+	// the start frame of pixel data, then the columns of each page
+	segs := make([][]byte, 1, 1+d.Dy())
+	segs[0] = buf[:1]
+	for p := d.Min.Y; p < d.Max.Y; p++ {
+		i := 1 + p*o.w
+		segs = append(segs, buf[i+d.Min.X:i+d.Max.X])
+	}
+	return o.writeVec(segs...)
+}
+
+// flushPages sends the window d of the frame buf page by page, as the
+// SH1106 has no horizontal addressing mode.
+func (o *OLED) flushPages(buf []byte, d image.Rectangle) error {
+	col := o.col + d.Min.X
+	for p := d.Min.Y; p < d.Max.Y; p++ {
+		if err := o.write([]byte{0x00, 0xb0 | byte(p), byte(col & 0x0f), 0x10 | byte(col>>4)}); err != nil {
+			return err
+		}
+		i := 1 + p*o.w
+		if err := o.writeVec(buf[:1], buf[i+d.Min.X:i+d.Max.X]); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// EnableScroll starts scrolling in the horizontal direction starting from
-// startY column to endY column.
-func (o *OLED) EnableScroll(startY, endY int) error {
-	panic("not implemented")
+// DrawAll draws the whole buffer on the display, e.g. after a reset of
+// the controller.
+func (o *OLED) DrawAll() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.drawAll()
 }
 
-// DisableScroll stops the scrolling on the display.
+func (o *OLED) drawAll() error {
+	o.dirty = o.pages()
+	return o.draw()
+}
+
+// DrawText draws s in the 6x8 font text.Small with its top left corner at
+// x, y and displays it. The lines of s are drawn 8 pixels apart, over a
+// cleared background so that a status text can be redrawn in place. Use
+// the text package for other fonts and sizes.
+func (o *OLED) DrawText(x, y int, s string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	drawText(buffer{o}, x, y, s)
+	return o.draw()
+}
+
+// drawText draws s on dst as DrawText.
+func drawText(dst draw.Image, x, y int, s string) {
+	f := text.Small
+	for i, l := range strings.Split(s, "\n") {
+		at := image.Pt(x, y+i*f.Height)
+		draw.Draw(dst, image.Rectangle{at, at.Add(f.Size(l, 1))}, image.Black, image.Point{}, draw.Src)
+		f.Draw(dst, at.X, at.Y, l, color.White, 1)
+	}
+}
+
+// ScrollDirection is the direction of the horizontal scrolling.
+type ScrollDirection int
+
+const (
+	ScrollRight ScrollDirection = iota
+	ScrollLeft
+)
+
+// ScrollSpeed is the interval between two scroll steps, in frames.
+type ScrollSpeed byte
+
+const (
+	Scroll2Frames   ScrollSpeed = 0x7
+	Scroll3Frames   ScrollSpeed = 0x4
+	Scroll4Frames   ScrollSpeed = 0x5
+	Scroll5Frames   ScrollSpeed = 0x0
+	Scroll25Frames  ScrollSpeed = 0x6
+	Scroll64Frames  ScrollSpeed = 0x1
+	Scroll128Frames ScrollSpeed = 0x2
+	Scroll256Frames ScrollSpeed = 0x3
+)
+
+// EnableScroll starts scrolling the pages startPage to endPage (rows of 8
+// pixels, 0 to 7 on a 64 pixels high display) in the direction dir by a
+// column every speed frames. The controller scrolls its RAM by itself,
+// the buffer must not be drawn while scrolling.
+func (o *OLED) EnableScroll(dir ScrollDirection, startPage, endPage int, speed ScrollSpeed) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.controller == SH1106 {
+		return errNoScroll
+	}
+	if startPage < 0 || endPage >= o.h/8 || startPage > endPage {
+		return fmt.Errorf("invalid pages %v to %v, should be between 0 and %v", startPage, endPage, o.h/8-1)
+	}
+	if speed > Scroll2Frames {
+		return fmt.Errorf("invalid scroll speed %#x", byte(speed))
+	}
+	cmd := byte(ssd1306_RIGHT_HORIZONTAL_SCROLL)
+	if dir == ScrollLeft {
+		cmd = ssd1306_LEFT_HORIZONTAL_SCROLL
+	}
+	args := []byte{0x00, byte(startPage), byte(speed), byte(endPage), 0x00, 0xFF}
+	if o.controller == SSD1305 {
+		// a column per step, without the columns scrolled
+		args = []byte{0x01, byte(startPage), byte(speed), byte(endPage)}
+	}
+	b := append([]byte{0x00, ssd1306_DEACTIVATE_SCROLL, cmd}, args...)
+	return o.write(append(b, ssd1306_ACTIVATE_SCROLL))
+}
+
+// SetScrollArea restricts the vertical scrolling to the scrollRows rows
+// under the topFixedRows top rows, e.g. to keep a status bar fixed above
+// a scrolling body. The whole display scrolls by default.
+func (o *OLED) SetScrollArea(topFixedRows, scrollRows int) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.controller == SH1106 {
+		return errNoScroll
+	}
+	if topFixedRows < 0 || scrollRows < 1 || topFixedRows+scrollRows > o.h {
+		return fmt.Errorf("invalid scroll area of %v rows under %v fixed rows on this %v rows display", scrollRows, topFixedRows, o.h)
+	}
+	if err := o.write([]byte{0x00, ssd1306_SET_VERTICAL_SCROLL_AREA, byte(topFixedRows), byte(scrollRows)}); err != nil {
+		return err
+	}
+	o.scrollTop, o.scrollRows = topFixedRows, scrollRows
+	return nil
+}
+
+// EnableDiagonalScroll starts scrolling the display vertically by offset
+// rows every step, and the pages startPage to endPage horizontally in the
+// direction dir, a step every speed frames. The vertical scrolling is
+// restricted to the area set by SetScrollArea, offset must be less than
+// its number of rows.
+func (o *OLED) EnableDiagonalScroll(dir ScrollDirection, startPage, endPage int, speed ScrollSpeed, offset int) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.controller == SH1106 {
+		return errNoScroll
+	}
+	if startPage < 0 || endPage >= o.h/8 || startPage > endPage {
+		return fmt.Errorf("invalid pages %v to %v, should be between 0 and %v", startPage, endPage, o.h/8-1)
+	}
+	if speed > Scroll2Frames {
+		return fmt.Errorf("invalid scroll speed %#x", byte(speed))
+	}
+	if offset < 1 || offset >= o.scrollRows {
+		return fmt.Errorf("invalid vertical offset %v, should be between 1 and %v", offset, o.scrollRows-1)
+	}
+	cmd := byte(ssd1306_VERTICAL_AND_RIGHT_HORIZONTAL_SCROLL)
+	if dir == ScrollLeft {
+		cmd = ssd1306_VERTICAL_AND_LEFT_HORIZONTAL_SCROLL
+	}
+	args := []byte{0x00, byte(startPage), byte(speed), byte(endPage), byte(offset)}
+	switch o.controller {
+	case SSD1309:
+		args = append(args, 0x00, 0x7F) // the columns scrolled
+	case SSD1305:
+		args[0] = 0x01 // a column per step
+	}
+	b := append([]byte{
+		0x00, // command stream
+		ssd1306_DEACTIVATE_SCROLL,
+		ssd1306_SET_VERTICAL_SCROLL_AREA, byte(o.scrollTop), byte(o.scrollRows),
+		cmd,
+	}, args...)
+	return o.write(append(b, ssd1306_ACTIVATE_SCROLL))
+}
+
+// DisableScroll stops the scrolling on the display and draws the buffer
+// again, as the RAM was scrolled.
 func (o *OLED) DisableScroll() error {
-	panic("not implemented")
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.controller != SH1106 {
+		if err := o.write([]byte{0x00, ssd1306_DEACTIVATE_SCROLL}); err != nil {
+			return err
+		}
+	}
+	// all of it, even with double buffering, the changes of the RAM are
+	// not in the dirty window
+	o.dirty = o.pages()
+	return o.flush()
 }
 
-// Width returns the display width.
-func (o *OLED) Width() int { return o.w }
+// Width returns the display width, after rotation.
+func (o *OLED) Width() int { return o.Bounds().Dx() }
 
-// Height returns the display height.
-func (o *OLED) Height() int { return o.h }
+// Height returns the display height, after rotation.
+func (o *OLED) Height() int { return o.Bounds().Dy() }
 
 // Close closes the display.
 func (o *OLED) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.spi != nil {
+		return o.spi.Close()
+	}
 	return o.dev.Close()
 }
@@ -0,0 +1,427 @@
+package monochromeoled
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/gpio"
+	"github.com/goiot/devices/gpiosim"
+	"github.com/goiot/devices/i2csim"
+	"github.com/goiot/devices/monochromeoled/oledsim"
+	"github.com/goiot/devices/spisim"
+	i2cdriver "golang.org/x/exp/io/i2c/driver"
+	"golang.org/x/exp/io/spi/driver"
+)
+
+func BenchmarkDraw(b *testing.B) {
+	bus := i2csim.NewBus()
+	bus.Attach(addr, i2csim.DeviceFunc(func(w, r []byte) error { return nil }))
+	o, err := Open(bus)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(o.buf) - 1))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := o.DrawAll(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDrawPixel(b *testing.B) {
+	bus := i2csim.NewBus()
+	bus.Attach(addr, i2csim.DeviceFunc(func(w, r []byte) error { return nil }))
+	o, err := Open(bus)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		o.SetPixel(i%128, 10, byte(i/128%2))
+		if err := o.Draw(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBlit(b *testing.B) {
+	bus := i2csim.NewBus()
+	bus.Attach(addr, i2csim.DeviceFunc(func(w, r []byte) error { return nil }))
+	o, err := Open(bus)
+	if err != nil {
+		b.Fatal(err)
+	}
+	sprite, mask := make([]byte, 16*2), make([]byte, 16*2)
+	for i := range sprite {
+		sprite[i], mask[i] = byte(i*37), 0x7E
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := o.Blit(i%112, i%48, sprite, mask, 16, 16); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestOpenSPI(t *testing.T) {
+	// The 4-wire module, the D/C pin selects whether the simulator gets
+	// commands or data.
+	sim := oledsim.New(128, 64)
+	c, _ := sim.Open(addr, false)
+	dc, reset := gpiosim.NewPin(0), gpiosim.NewPin(1)
+	port := spisim.New(spisim.DeviceFunc(func(w, r []byte) error {
+		return c.Tx(append([]byte{byte(dc.Level() << 6)}, w...), r)
+	}))
+	clk := clock.NewFake(time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC))
+	opened := make(chan error)
+	var o *OLED
+	go func() {
+		var err error
+		o, err = OpenSPI(port, dc, reset, Config{Clock: clk})
+		opened <- err
+	}()
+	// the reset pulse is timed by the clock of the configuration
+	for i := 0; i < 3; i++ {
+		clk.BlockUntil(1)
+		clk.Advance(time.Millisecond)
+	}
+	if err := <-opened; err != nil {
+		t.Fatal(err)
+	}
+	if o.Clock != clk {
+		t.Error("the OLED is not timed by the clock of the configuration")
+	}
+	for _, want := range []gpio.Edge{gpio.FallingEdge, gpio.RisingEdge} {
+		if e, err := reset.Wait(0); err != nil || e.Edge != want {
+			t.Fatalf("reset edge = %v, %v; want %v", e.Edge, err, want)
+		}
+	}
+	if v, _ := port.Config(driver.MaxSpeed); v != SPISpeed {
+		t.Errorf("SPI clock = %d; want %d", v, SPISpeed)
+	}
+	if !sim.On() {
+		t.Error("the display is off after OpenSPI")
+	}
+	o.SetPixel(3, 5, 1)
+	o.SetPixel(127, 63, 1)
+	if err := o.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	img := sim.Image()
+	if img.GrayAt(3, 5).Y == 0 || img.GrayAt(127, 63).Y == 0 || img.GrayAt(4, 5).Y != 0 {
+		t.Error("the buffer is not drawn on the display")
+	}
+	if _, err := o.Status(); err != ErrWriteOnly {
+		t.Errorf("Status error = %v; want ErrWriteOnly", err)
+	}
+	if err := o.Off(); err != nil || sim.On() {
+		t.Errorf("Off = %v; the display is still on", err)
+	}
+}
+
+// vecSim is an opener of the simulator whose connections write the
+// flushes in segments.
+type vecSim struct {
+	*oledsim.Display
+	segs []int // segments of each vectored write
+}
+
+func (s *vecSim) Open(addr int, tenbit bool) (i2cdriver.Conn, error) {
+	c, err := s.Display.Open(addr, tenbit)
+	return &vecConn{c, s}, err
+}
+
+type vecConn struct {
+	i2cdriver.Conn
+	sim *vecSim
+}
+
+func (c *vecConn) WriteVec(segs [][]byte) error {
+	c.sim.segs = append(c.sim.segs, len(segs))
+	var b []byte
+	for _, s := range segs {
+		b = append(b, s...)
+	}
+	return c.Tx(b, nil)
+}
+
+func TestFlushSegments(t *testing.T) {
+	for _, c := range []Controller{SSD1306, SH1106} {
+		sim := &vecSim{Display: oledsim.New(128, 64)}
+		if c == SH1106 {
+			sim.Display = oledsim.NewSH1106(128, 64)
+		}
+		o, err := OpenWithConfig(sim, Config{Controller: c})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := o.Draw(); err != nil {
+			t.Fatal(err)
+		}
+		sim.segs = nil
+		o.SetPixel(10, 3, 1)
+		o.SetPixel(2, 12, 1)
+		if err := o.Draw(); err != nil {
+			t.Fatal(err)
+		}
+		// the control byte and the columns of both pages, page by page on
+		// the SH1106
+		want := []int{3}
+		if c == SH1106 {
+			want = []int{2, 2}
+		}
+		if len(sim.segs) != len(want) || sim.segs[0] != want[0] {
+			t.Errorf("controller %d: writes of %v segments; want %v", c, sim.segs, want)
+		}
+		if img := sim.Image(); img.GrayAt(10, 3).Y == 0 || img.GrayAt(2, 12).Y == 0 || img.GrayAt(2, 3).Y != 0 {
+			t.Errorf("controller %d: the pixels are not drawn", c)
+		}
+	}
+}
+
+func TestConfig(t *testing.T) {
+	var init []byte
+	bus := i2csim.NewBus()
+	bus.Attach(0x3D, i2csim.DeviceFunc(func(w, r []byte) error {
+		if init == nil {
+			init = append([]byte(nil), w...)
+		}
+		return nil
+	}))
+	o, err := OpenWithConfig(bus, Config{Addr: 0x3D, Width: 128, Height: 32, ExternalVCC: true, Rotation: 90})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cmd := range [][]byte{{0xa8, 31}, {0x8d, 0x10}, {0xda, 0x02}} {
+		if !bytes.Contains(init, cmd) {
+			t.Errorf("init sequence %x without %x", init, cmd)
+		}
+	}
+	if b := o.Bounds(); b.Dx() != 32 || b.Dy() != 128 {
+		t.Errorf("Bounds = %v; want 32x128 once rotated", b)
+	}
+	o.SetPixel(0, 0, 1) // the bottom left corner of the panel
+	if o.buf[1+3*128] != 0x80 {
+		t.Error("the pixel is not rotated")
+	}
+
+	for _, c := range []Config{{Rotation: 45}, {Width: 100, Height: 20}} {
+		if _, err := OpenWithConfig(bus, c); err == nil {
+			t.Errorf("expected an error with %+v", c)
+		}
+	}
+}
+
+func TestBlit(t *testing.T) {
+	bus := i2csim.NewBus()
+	bus.Attach(addr, i2csim.DeviceFunc(func(w, r []byte) error { return nil }))
+	rnd := rand.New(rand.NewSource(1))
+	for _, rot := range []int{0, 90} {
+		o, err := OpenWithConfig(bus, Config{Rotation: rot})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := OpenWithConfig(bus, Config{Rotation: rot})
+		for i := 0; i < 200; i++ {
+			w, h := 1+rnd.Intn(20), 1+rnd.Intn(20)
+			x, y := rnd.Intn(o.Width()+20)-10, rnd.Intn(o.Height()+20)-10
+			sprite := make([]byte, w*((h+7)/8))
+			rnd.Read(sprite)
+			var mask []byte
+			if i%2 == 0 {
+				mask = make([]byte, len(sprite))
+				rnd.Read(mask)
+			}
+			if err := o.Blit(x, y, sprite, mask, w, h); err != nil {
+				t.Fatal(err)
+			}
+			// pixel by pixel
+			for sy := 0; sy < h; sy++ {
+				for sx := 0; sx < w; sx++ {
+					j, bit := sx+(sy/8)*w, uint(sy&7)
+					if image.Pt(x+sx, y+sy).In(want.Bounds()) && (mask == nil || mask[j]>>bit&1 != 0) {
+						want.SetPixel(x+sx, y+sy, sprite[j]>>bit&1)
+					}
+				}
+			}
+			if !bytes.Equal(o.buf, want.buf) {
+				t.Fatalf("rotation %d: the %dx%d sprite at %d,%d differs from its pixels", rot, w, h, x, y)
+			}
+		}
+		if o.dirty != want.dirty {
+			t.Errorf("rotation %d: dirty pages %v; want %v", rot, o.dirty, want.dirty)
+		}
+	}
+
+	o, _ := Open(bus)
+	if err := o.Blit(0, 0, make([]byte, 15), nil, 8, 9); err == nil {
+		t.Error("Blit() succeeded with a sprite too short")
+	}
+
+	// a ball, transparent around
+	img := image.NewNRGBA(image.Rect(10, 10, 13, 13))
+	for _, p := range []image.Point{{11, 10}, {10, 11}, {12, 11}, {11, 12}} {
+		img.Set(p.X, p.Y, color.White)
+	}
+	img.Set(11, 11, color.Black)
+	sprite, mask := Sprite(img)
+	if !bytes.Equal(sprite, []byte{0x02, 0x05, 0x02}) || !bytes.Equal(mask, []byte{0x02, 0x07, 0x02}) {
+		t.Errorf("Sprite() = % x, % x; want 02 05 02, 02 07 02", sprite, mask)
+	}
+	if _, mask := Sprite(image.NewGray(image.Rect(0, 0, 4, 4))); mask != nil {
+		t.Errorf("mask of an opaque image = % x; want nil", mask)
+	}
+}
+
+func TestCanvas(t *testing.T) {
+	bus := i2csim.NewBus()
+	bus.Attach(addr, i2csim.DeviceFunc(func(w, r []byte) error { return nil }))
+	img := image.NewGray(image.Rect(0, 0, 20, 10))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i * 37)
+	}
+	// the methods of both the OLEDs and the canvases
+	type drawer interface {
+		DrawText(x, y int, s string) error
+		SetImageWith(x, y int, img image.Image, opts SetImageOptions) error
+	}
+	type widget struct {
+		at   image.Point
+		draw func(dst drawer, at image.Point)
+	}
+	widgets := []widget{
+		{image.Pt(3, 5), func(dst drawer, at image.Point) {
+			dst.DrawText(at.X, at.Y, "12:34\n21 C")
+		}},
+		{image.Pt(50, 13), func(dst drawer, at image.Point) {
+			dst.SetImageWith(at.X, at.Y, img, SetImageOptions{Dither: FloydSteinberg})
+		}},
+	}
+	for _, rot := range []int{0, 90} {
+		o, _ := OpenWithConfig(bus, Config{Rotation: rot})
+		want, _ := OpenWithConfig(bus, Config{Rotation: rot})
+		for x := 0; x < o.Width(); x++ {
+			for y := 0; y < o.Height(); y++ {
+				o.SetPixel(x, y, byte(x+y)&1)
+				want.SetPixel(x, y, byte(x+y)&1)
+			}
+		}
+
+		// drawn concurrently, of 30x17 pixels each, the last one over the
+		// edge of the rotated display
+		canvases := make([]*Canvas, len(widgets))
+		var wg sync.WaitGroup
+		for i, w := range widgets {
+			wg.Add(1)
+			go func(i int, w widget) {
+				defer wg.Done()
+				c := NewCanvas(30, 17)
+				w.draw(c, image.Point{})
+				canvases[i] = c
+			}(i, w)
+		}
+		wg.Wait()
+		for i, w := range widgets {
+			if err := o.DrawCanvas(w.at.X, w.at.Y, canvases[i]); err != nil {
+				t.Fatal(err)
+			}
+			// the canvas covers the pixels under it
+			r := image.Rectangle{w.at, w.at.Add(image.Pt(30, 17))}
+			for x := r.Min.X; x < r.Max.X; x++ {
+				for y := r.Min.Y; y < r.Max.Y; y++ {
+					want.Set(x, y, color.Black)
+				}
+			}
+			w.draw(want, w.at)
+		}
+		if !bytes.Equal(o.Snapshot().Pix, want.Snapshot().Pix) {
+			t.Errorf("rotation %d: the canvases differ from the widgets drawn on the display", rot)
+		}
+	}
+
+	c := NewCanvas(10, 10)
+	if err := c.SetPixel(10, 0, 1); err == nil {
+		t.Error("SetPixel(10, 0) succeeded on a 10x10 canvas")
+	}
+	c.Set(9, 9, color.White)
+	if got := c.At(9, 9); got != (color.Gray{Y: 0xFF}) {
+		t.Errorf("At(9, 9) = %v; want white", got)
+	}
+	c.Clear()
+	if got := c.At(9, 9); got != (color.Gray{}) {
+		t.Errorf("At(9, 9) = %v after Clear; want black", got)
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	sim := oledsim.New(128, 64)
+	o, err := Open(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := image.NewGray(image.Rect(0, 0, 128, 32))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i * 37)
+	}
+	// a render goroutine on the top half, a status one on the bottom half
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			if err := o.SetImageWith(0, 0, img, SetImageOptions{Dither: FloydSteinberg}); err != nil {
+				errs <- err
+				return
+			}
+			if err := o.Draw(); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			for x := 0; x < 128; x++ {
+				o.SetPixel(x, 63, byte(i&1))
+			}
+			if err := o.DrawText(0, 40, "status"); err != nil {
+				errs <- err
+				return
+			}
+			if err := o.Draw(); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	want, _ := Open(oledsim.New(128, 64))
+	want.SetImageWith(0, 0, img, SetImageOptions{Dither: FloydSteinberg})
+	want.DrawText(0, 40, "status")
+	for x := 0; x < 128; x++ {
+		want.SetPixel(x, 63, 1)
+	}
+	if !bytes.Equal(o.Snapshot().Pix, want.Snapshot().Pix) {
+		t.Error("the buffer differs from the one drawn by a single goroutine")
+	}
+	if got := sim.Image(); !bytes.Equal(got.Pix, want.Snapshot().Pix) {
+		t.Error("the display differs from the buffer")
+	}
+}
@@ -0,0 +1,506 @@
+// Package oledsim simulates an SSD1306, SH1106, SSD1309 or SSD1305 OLED
+// controller behind an I2C
+// opener, so code driving the monochromeoled package can run and be
+// tested without a display, including in a browser when compiled to
+// WebAssembly.
+//
+// The simulator decodes the command and data stream the way the
+// controller does and renders the visible part of the display RAM.
+package oledsim
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"sync"
+
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+const (
+	ramWidth = 132 // of the SH1106 and SSD1305, the SSD1306 has 128 columns
+	ramPages = 8
+
+	addrHorizontal = 0x00
+	addrVertical   = 0x01
+	addrPage       = 0x02
+)
+
+// ErrNAK is returned by the reads of a write-only display.
+var ErrNAK = errors.New("oledsim: read not acknowledged")
+
+// argCount is the number of argument bytes of the multi-byte commands.
+var argCount = map[byte]int{
+	0x20: 1, // memory addressing mode
+	0x21: 2, // column address
+	0x22: 2, // page address
+	0x26: 6, // right horizontal scroll
+	0x27: 6, // left horizontal scroll
+	0x29: 5, // vertical and right horizontal scroll
+	0x2A: 5, // vertical and left horizontal scroll
+	0x81: 1, // contrast
+	0x8D: 1, // charge pump
+	0xA3: 2, // vertical scroll area
+	0xA8: 1, // multiplex ratio
+	0xD3: 1, // display offset
+	0xD5: 1, // clock divide ratio
+	0xD9: 1, // pre-charge period
+	0xDA: 1, // COM pins configuration
+	0xDB: 1, // VCOMH deselect level
+}
+
+// Display is a simulated SSD1306 display. It implements driver.Opener
+// and can be used by multiple goroutines.
+type Display struct {
+	w, h  int
+	first int // first column of the RAM shown
+	cols  int // columns of the RAM
+
+	sh1106 bool         // only the page addressing commands
+	args   map[byte]int // argument bytes of the multi-byte commands
+
+	mu       sync.Mutex
+	readable bool
+	ram      [ramPages][ramWidth]byte
+
+	on        bool
+	inverted  bool
+	allOn     bool
+	contrast  byte
+	precharge byte
+	pump      bool // charge pump enabled
+	remap     bool // segment remap, column 127 is SEG0
+	comFlip   bool // COM scan from COM[N-1] to COM0
+	startLine int
+	offset    int
+	mux       int
+	scroll    []byte // last horizontal scroll setup
+	scrolling bool
+	areaTop   int // vertical scroll area
+	areaRows  int
+
+	mode               byte
+	colStart, colEnd   int
+	pageStart, pageEnd int
+	col, page          int
+
+	cmd     []byte // command being decoded
+	pending int    // argument bytes the command still needs
+}
+
+// New returns a simulated w x h display, in its reset state. Like most
+// modules, it is write-only until SetReadable is called.
+func New(w, h int) *Display {
+	return NewOffset(w, h, 0)
+}
+
+// NewOffset returns a simulated w x h display whose panel shows the RAM
+// from the column first, e.g. 32 for the 64x48 panels.
+func NewOffset(w, h, first int) *Display {
+	d := &Display{w: w, h: h, first: first, cols: 128, args: argCount}
+	d.reset()
+	return d
+}
+
+// NewSH1106 returns a simulated w x h display with an SH1106 controller,
+// its panel shows the RAM from the column 2.
+func NewSH1106(w, h int) *Display {
+	d := &Display{w: w, h: h, first: 2, cols: ramWidth, sh1106: true, args: sh1106Args}
+	d.reset()
+	return d
+}
+
+// NewSSD1309 returns a simulated w x h display with an SSD1309 controller.
+func NewSSD1309(w, h int) *Display {
+	d := &Display{w: w, h: h, cols: 128, args: ssd1309Args}
+	d.reset()
+	return d
+}
+
+// NewSSD1305 returns a simulated w x h display with an SSD1305 controller,
+// its panel shows the RAM from the column 4.
+func NewSSD1305(w, h int) *Display {
+	d := &Display{w: w, h: h, first: 4, cols: ramWidth, args: ssd1305Args}
+	d.reset()
+	return d
+}
+
+// sh1106Args is the number of argument bytes of the multi-byte commands
+// of the SH1106, the other commands of the SSD1306 are unknown to it.
+var sh1106Args = map[byte]int{
+	0x81: 1, // contrast
+	0xA8: 1, // multiplex ratio
+	0xAD: 1, // DC-DC converter
+	0xD3: 1, // display offset
+	0xD5: 1, // clock divide ratio
+	0xD9: 1, // pre-charge period
+	0xDA: 1, // COM pins configuration
+	0xDB: 1, // VCOMH deselect level
+}
+
+// ssd1309Args and ssd1305Args are the number of argument bytes of the
+// multi-byte commands of the SSD1309 and SSD1305, which have no charge
+// pump and take other arguments to scroll.
+var ssd1309Args, ssd1305Args = map[byte]int{
+	0xFD: 1, // command lock
+	0x29: 7, // with the columns scrolled
+	0x2A: 7,
+}, map[byte]int{
+	0x26: 4, // with the number of columns per step
+	0x27: 4,
+	0x91: 4, // look up table
+	0xAD: 1, // master configuration
+	0xD8: 1, // area color and low power modes
+}
+
+func init() {
+	for c, n := range argCount {
+		for _, args := range []map[byte]int{ssd1309Args, ssd1305Args} {
+			if _, ok := args[c]; !ok && c != 0x8D {
+				args[c] = n
+			}
+		}
+	}
+}
+
+func (d *Display) reset() {
+	d.ram = [ramPages][ramWidth]byte{}
+	d.on, d.inverted, d.allOn = false, false, false
+	d.contrast, d.precharge, d.pump = 0x7F, 0x22, false
+	d.remap, d.comFlip = false, false
+	d.startLine, d.offset, d.mux = 0, 0, 64
+	d.mode = addrPage
+	d.colStart, d.colEnd = 0, d.cols-1
+	d.pageStart, d.pageEnd = 0, ramPages-1
+	d.col, d.page = 0, 0
+	d.cmd, d.pending = d.cmd[:0], 0
+	d.scroll, d.scrolling = nil, false
+	d.areaTop, d.areaRows = 0, 64
+}
+
+// Reset puts the controller back in its reset state, display off and RAM
+// cleared, as after a brown-out.
+func (d *Display) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.reset()
+}
+
+// SetReadable sets whether the controller answers reads: a read returns
+// the status register, and a read following the data control byte 0x40
+// returns a dummy byte and the RAM from the current address. The reads
+// of a write-only display fail with ErrNAK.
+func (d *Display) SetReadable(readable bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.readable = readable
+}
+
+// Open implements driver.Opener, the simulator answers at any address.
+func (d *Display) Open(addr int, tenbit bool) (driver.Conn, error) {
+	return &conn{d: d}, nil
+}
+
+type conn struct {
+	d *Display
+}
+
+func (c *conn) Tx(w, r []byte) error {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	if len(r) == 0 {
+		c.d.write(w)
+		return nil
+	}
+	if !c.d.readable {
+		return ErrNAK
+	}
+	switch {
+	case len(w) == 0:
+		r[0] = 0
+		if !c.d.on {
+			r[0] = 0x40
+		}
+		for i := 1; i < len(r); i++ {
+			r[i] = r[0]
+		}
+	case len(w) == 1 && w[0] == 0x40:
+		r[0] = 0 // dummy read
+		for i := 1; i < len(r); i++ {
+			r[i] = c.d.ram[c.d.page][c.d.col]
+			c.d.advance()
+		}
+	default:
+		c.d.write(w)
+		for i := range r {
+			r[i] = 0
+		}
+	}
+	return nil
+}
+
+func (c *conn) Close() error { return nil }
+
+// write decodes an I2C write: each control byte tells whether the
+// following bytes are commands or data. If its continuation bit is set,
+// it only applies to the next byte and another control byte follows.
+func (d *Display) write(b []byte) {
+	for len(b) > 0 {
+		ctrl := b[0]
+		b = b[1:]
+		n := len(b)
+		if ctrl&0x80 != 0 && n > 1 {
+			n = 1
+		}
+		for _, v := range b[:n] {
+			if ctrl&0x40 != 0 {
+				d.data(v)
+			} else {
+				d.command(v)
+			}
+		}
+		b = b[n:]
+	}
+}
+
+func (d *Display) data(v byte) {
+	d.ram[d.page][d.col] = v
+	d.advance()
+}
+
+// advance moves the RAM address after a data byte.
+func (d *Display) advance() {
+	switch d.mode {
+	case addrHorizontal:
+		d.col++
+		if d.col > d.colEnd {
+			d.col = d.colStart
+			if d.page++; d.page > d.pageEnd {
+				d.page = d.pageStart
+			}
+		}
+	case addrVertical:
+		d.page++
+		if d.page > d.pageEnd {
+			d.page = d.pageStart
+			if d.col++; d.col > d.colEnd {
+				d.col = d.colStart
+			}
+		}
+	default:
+		if d.col < d.cols-1 {
+			d.col++
+		}
+	}
+}
+
+func (d *Display) command(v byte) {
+	if d.pending > 0 {
+		d.cmd = append(d.cmd, v)
+		if d.pending--; d.pending == 0 {
+			d.exec(d.cmd)
+		}
+		return
+	}
+	d.cmd = append(d.cmd[:0], v)
+	if n, ok := d.args[v]; ok {
+		d.pending = n
+		return
+	}
+	d.exec(d.cmd)
+}
+
+func (d *Display) exec(cmd []byte) {
+	if c := cmd[0]; d.sh1106 && (c >= 0x20 && c <= 0x2F || c == 0x8D || c == 0xA3) {
+		return // unknown, its arguments are taken for commands
+	}
+	switch c := cmd[0]; {
+	case c <= 0x0F: // lower column nibble, page addressing mode
+		d.col = d.col&0xF0 | int(c)
+	case c <= 0x1F:
+		d.col = (d.col&0x0F | int(c&0x0F)<<4) % d.cols
+	case c == 0x20:
+		d.mode = cmd[1] & 0x03
+	case c == 0x26, c == 0x27, c == 0x29, c == 0x2A:
+		d.scroll = append([]byte(nil), cmd...)
+	case c == 0xA3:
+		d.areaTop, d.areaRows = int(cmd[1]&0x3F), int(cmd[2]&0x7F)
+	case c == 0x2E, c == 0x2F:
+		d.scrolling = c == 0x2F && d.scroll != nil
+	case c == 0x21:
+		d.colStart, d.colEnd = d.column(cmd[1]), d.column(cmd[2])
+		d.col = d.colStart
+	case c == 0x22:
+		d.pageStart, d.pageEnd = int(cmd[1]&0x07), int(cmd[2]&0x07)
+		d.page = d.pageStart
+	case c >= 0x40 && c <= 0x7F:
+		d.startLine = int(c & 0x3F)
+	case c == 0x81:
+		d.contrast = cmd[1]
+	case c == 0xD9:
+		d.precharge = cmd[1]
+	case c == 0x8D && len(cmd) > 1: // unknown to the SSD1309 and SSD1305
+		d.pump = cmd[1]&0x04 != 0
+	case c == 0xA0, c == 0xA1:
+		d.remap = c == 0xA1
+	case c == 0xA4, c == 0xA5:
+		d.allOn = c == 0xA5
+	case c == 0xA6, c == 0xA7:
+		d.inverted = c == 0xA7
+	case c == 0xA8:
+		d.mux = int(cmd[1]&0x3F) + 1
+	case c == 0xAE, c == 0xAF:
+		d.on = c == 0xAF
+	case c >= 0xB0 && c <= 0xB7:
+		d.page = int(c & 0x07)
+	case c == 0xC0, c == 0xC8:
+		d.comFlip = c == 0xC8
+	case c == 0xD3:
+		d.offset = int(cmd[1] & 0x3F)
+	}
+}
+
+// column returns the column address v of the column address command.
+func (d *Display) column(v byte) int {
+	if d.cols == 128 {
+		return int(v & 0x7F)
+	}
+	return int(v) % d.cols
+}
+
+// Image returns what the panel currently shows, lit pixels are white.
+// It is rendered as if the panel was mounted the way the driver is
+// configured, with segment remap and reversed COM scan.
+func (d *Display) Image() *image.Gray {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	img := image.NewGray(image.Rect(0, 0, d.w, d.h))
+	if !d.on {
+		return img
+	}
+	lit := color.Gray{Y: 0xFF}
+	for y := 0; y < d.h && y < d.mux; y++ {
+		// physical row y is driven by COM y, which shows RAM row
+		// (y + start line - offset) once the scan direction applied;
+		// the usual remapped mounting displays RAM row y at row y
+		row := y
+		if !d.comFlip {
+			row = d.h - 1 - y
+		}
+		row = (row + d.startLine - d.offset + 64) % 64
+		for x := 0; x < d.w; x++ {
+			col := d.first + x
+			if !d.remap {
+				col = d.first + d.w - 1 - x
+			}
+			on := d.ram[row/8][col]&(1<<uint(row%8)) != 0
+			if d.allOn {
+				on = true
+			}
+			if on != d.inverted {
+				img.SetGray(x, y, lit)
+			}
+		}
+	}
+	return img
+}
+
+// On reports whether the display is turned on.
+func (d *Display) On() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.on
+}
+
+// Inverted reports whether the display is in inverse mode.
+func (d *Display) Inverted() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.inverted
+}
+
+// Contrast returns the current contrast setting.
+func (d *Display) Contrast() byte {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.contrast
+}
+
+// Precharge returns the current pre-charge period setting.
+func (d *Display) Precharge() byte {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.precharge
+}
+
+// ChargePump reports whether the charge pump of the SSD1306 is enabled,
+// as needed by the panels without an external VCC.
+func (d *Display) ChargePump() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.pump
+}
+
+// ScrollArea returns the vertical scroll area: the number of fixed rows at
+// the top and of scrolled rows under them.
+func (d *Display) ScrollArea() (top, rows int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.areaTop, d.areaRows
+}
+
+// Scrolling is the scrolling set up on the controller.
+type Scrolling struct {
+	Left       bool // false for the right horizontal scroll
+	Start, End int  // pages scrolled
+	Speed      byte // interval register
+	Vertical   int  // vertical offset per step, 0 for the horizontal scroll
+}
+
+// Step moves the pages scrolled horizontally in the RAM by n columns, as
+// the controller does every step of the scrolling: the RAM stays moved
+// once the scrolling is deactivated, until it is written again.
+func (d *Display) Step(n int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.scrolling {
+		return
+	}
+	s := d.scroll
+	left := s[0] == 0x27 || s[0] == 0x2A
+	for p := int(s[2] & 0x07); p <= int(s[4]&0x07); p++ {
+		row := d.ram[p][:d.cols]
+		for i := 0; i < n; i++ {
+			if left {
+				v := row[0]
+				copy(row, row[1:])
+				row[len(row)-1] = v
+			} else {
+				v := row[len(row)-1]
+				copy(row[1:], row)
+				row[0] = v
+			}
+		}
+	}
+}
+
+// Scroll returns the scrolling of the display, false if it does not
+// scroll.
+func (d *Display) Scroll() (Scrolling, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.scrolling {
+		return Scrolling{}, false
+	}
+	s := d.scroll
+	sc := Scrolling{
+		Left:  s[0] == 0x27 || s[0] == 0x2A,
+		Start: int(s[2] & 0x07),
+		End:   int(s[4] & 0x07),
+		Speed: s[3] & 0x07,
+	}
+	if s[0] == 0x29 || s[0] == 0x2A {
+		sc.Vertical = int(s[5] & 0x3F)
+	}
+	return sc, true
+}
@@ -0,0 +1,1148 @@
+package oledsim_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/displaytest"
+	"github.com/goiot/devices/i2csim"
+	"github.com/goiot/devices/monochromeoled"
+	"github.com/goiot/devices/monochromeoled/oledsim"
+	"github.com/goiot/devices/text"
+)
+
+func TestDraw(t *testing.T) {
+	sim := oledsim.New(128, 64)
+	oled, err := monochromeoled.Open(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sim.On() {
+		t.Fatal("display should be on after Open")
+	}
+	if err := oled.SetPixel(3, 10, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := oled.SetPixel(127, 63, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := oled.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	img := sim.Image()
+	for _, p := range []image.Point{{3, 10}, {127, 63}} {
+		if img.GrayAt(p.X, p.Y).Y != 0xFF {
+			t.Errorf("pixel %v should be lit", p)
+		}
+	}
+	if img.GrayAt(4, 10) != (color.Gray{}) {
+		t.Error("pixel (4, 10) should be off")
+	}
+
+	if err := oled.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if sim.Image().GrayAt(3, 10).Y != 0 {
+		t.Error("pixel (3, 10) should be off after Clear")
+	}
+}
+
+func TestReadBack(t *testing.T) {
+	sim := oledsim.New(128, 64)
+	sim.SetReadable(true)
+	oled, err := monochromeoled.Open(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oled.SetPixel(3, 10, 1)
+	oled.SetPixel(127, 63, 1)
+	if err := oled.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	if err := oled.Verify(); err != nil {
+		t.Errorf("Verify after Draw: %v", err)
+	}
+	if s, err := oled.Status(); err != nil || !s.On() {
+		t.Errorf("Status = %#x, %v; want on", s, err)
+	}
+	if err := oled.Check(); err != nil {
+		t.Errorf("Check: %v", err)
+	}
+
+	sim.Reset()
+	if err := oled.Verify(); err == nil {
+		t.Error("Verify succeeded after a reset of the controller")
+	}
+	if err := oled.Check(); err == nil {
+		t.Error("Check succeeded with the panel off")
+	}
+}
+
+func TestPartialDraw(t *testing.T) {
+	sim := oledsim.New(128, 64)
+	sim.SetReadable(true)
+	conn, err := sim.Open(0x3C, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data int // bytes of pixel data written
+	bus := i2csim.NewBus()
+	bus.Attach(0x3C, i2csim.DeviceFunc(func(w, r []byte) error {
+		if len(w) > 0 && w[0] == 0x40 {
+			data += len(w) - 1
+		}
+		return conn.Tx(w, r)
+	}))
+	oled, err := monochromeoled.Open(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name string
+		draw func() error
+		want int
+	}{
+		{"first Draw", oled.Draw, 1024},
+		{"unchanged", oled.Draw, 0},
+		{"one pixel", func() error { oled.SetPixel(5, 20, 1); return oled.Draw() }, 1},
+		{"same pixel", func() error { oled.SetPixel(5, 20, 1); return oled.Draw() }, 0},
+		{"two pages", func() error { oled.SetPixel(10, 3, 1); oled.SetPixel(2, 12, 1); return oled.Draw() }, 2 * 9},
+		{"DrawAll", oled.DrawAll, 1024},
+	} {
+		data = 0
+		if err := tt.draw(); err != nil {
+			t.Fatal(err)
+		}
+		if data != tt.want {
+			t.Errorf("%v: %v bytes drawn; want %v", tt.name, data, tt.want)
+		}
+		if err := oled.Verify(); err != nil {
+			t.Errorf("%v: %v", tt.name, err)
+		}
+	}
+}
+
+func TestWriteOnly(t *testing.T) {
+	sim := oledsim.New(128, 64)
+	oled, err := monochromeoled.Open(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := oled.Verify(); err != monochromeoled.ErrWriteOnly {
+		t.Errorf("Verify = %v; want ErrWriteOnly", err)
+	}
+	if _, err := oled.Status(); err != monochromeoled.ErrWriteOnly {
+		t.Errorf("Status error = %v; want ErrWriteOnly", err)
+	}
+	if err := oled.Check(); err != nil {
+		t.Errorf("Check of a write-only module: %v", err)
+	}
+}
+
+func TestScroll(t *testing.T) {
+	sim := oledsim.New(128, 64)
+	oled, err := monochromeoled.Open(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := oled.EnableScroll(monochromeoled.ScrollLeft, 2, 5, monochromeoled.Scroll25Frames); err != nil {
+		t.Fatal(err)
+	}
+	if s, on := sim.Scroll(); !on || s != (oledsim.Scrolling{Left: true, Start: 2, End: 5, Speed: 0x6}) {
+		t.Errorf("Scroll = %+v, %v; want left pages 2 to 5 every 25 frames", s, on)
+	}
+	if err := oled.DisableScroll(); err != nil {
+		t.Fatal(err)
+	}
+	if _, on := sim.Scroll(); on {
+		t.Error("still scrolling after DisableScroll")
+	}
+
+	// the RAM scrolled is drawn again, double buffered or not
+	for _, double := range []bool{false, true} {
+		oled.SetDoubleBuffering(false)
+		oled.Clear()
+		for x := 0; x < 40; x++ {
+			oled.SetPixel(x, 20, 1)
+		}
+		if err := oled.Draw(); err != nil {
+			t.Fatal(err)
+		}
+		oled.SetDoubleBuffering(double)
+		if err := oled.EnableScroll(monochromeoled.ScrollLeft, 2, 5, monochromeoled.Scroll25Frames); err != nil {
+			t.Fatal(err)
+		}
+		sim.Step(10)
+		if bytes.Equal(sim.Image().Pix, oled.Snapshot().Pix) {
+			t.Fatal("the RAM not moved by the scrolling")
+		}
+		if err := oled.DisableScroll(); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(sim.Image().Pix, oled.Snapshot().Pix) {
+			t.Errorf("double buffering %v: the display still scrolled after DisableScroll:\n%s", double, displaytest.ASCII(sim.Image(), image.Rect(0, 16, 64, 24)))
+		}
+	}
+	oled.SetDoubleBuffering(false)
+	for _, pages := range [][2]int{{-1, 3}, {3, 8}, {5, 2}} {
+		if err := oled.EnableScroll(monochromeoled.ScrollRight, pages[0], pages[1], monochromeoled.Scroll2Frames); err == nil {
+			t.Errorf("EnableScroll of pages %d to %d succeeded", pages[0], pages[1])
+		}
+	}
+}
+
+func TestDiagonalScroll(t *testing.T) {
+	sim := oledsim.New(128, 64)
+	oled, err := monochromeoled.Open(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := oled.EnableDiagonalScroll(monochromeoled.ScrollRight, 0, 7, monochromeoled.Scroll5Frames, 1); err != nil {
+		t.Fatal(err)
+	}
+	if s, on := sim.Scroll(); !on || s != (oledsim.Scrolling{Start: 0, End: 7, Speed: 0x0, Vertical: 1}) {
+		t.Errorf("Scroll = %+v, %v; want right and down a row every 5 frames", s, on)
+	}
+	if err := oled.SetScrollArea(16, 48); err != nil {
+		t.Fatal(err)
+	}
+	if top, rows := sim.ScrollArea(); top != 16 || rows != 48 {
+		t.Errorf("ScrollArea = %d, %d; want 16 fixed rows and 48 scrolled", top, rows)
+	}
+	if err := oled.EnableDiagonalScroll(monochromeoled.ScrollRight, 2, 7, monochromeoled.Scroll5Frames, 1); err != nil {
+		t.Fatal(err)
+	}
+	if top, rows := sim.ScrollArea(); top != 16 || rows != 48 {
+		t.Errorf("diagonal scroll reset the area to %d, %d", top, rows)
+	}
+	for _, area := range [][2]int{{-1, 8}, {16, 0}, {16, 49}} {
+		if err := oled.SetScrollArea(area[0], area[1]); err == nil {
+			t.Errorf("SetScrollArea(%d, %d) succeeded", area[0], area[1])
+		}
+	}
+	for _, offset := range []int{0, 48} {
+		if err := oled.EnableDiagonalScroll(monochromeoled.ScrollLeft, 0, 7, monochromeoled.Scroll5Frames, offset); err == nil {
+			t.Errorf("EnableDiagonalScroll with an offset of %d succeeded", offset)
+		}
+	}
+}
+
+func TestPanels(t *testing.T) {
+	for _, p := range []struct {
+		monochromeoled.Panel
+		first int
+	}{
+		{monochromeoled.Panel128x32, 0},
+		{monochromeoled.Panel96x16, 0},
+		{monochromeoled.Panel64x48, 32},
+	} {
+		sim := oledsim.NewOffset(p.Width, p.Height, p.first)
+		oled, err := monochromeoled.OpenPanel(sim, p.Panel)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b := oled.Bounds(); b.Dx() != p.Width || b.Dy() != p.Height {
+			t.Errorf("Bounds = %v; want %dx%d", b, p.Width, p.Height)
+		}
+		corners := []image.Point{{0, 0}, {p.Width - 1, 0}, {0, p.Height - 1}, {p.Width - 1, p.Height - 1}}
+		for _, c := range corners {
+			oled.SetPixel(c.X, c.Y, 1)
+		}
+		if err := oled.Draw(); err != nil {
+			t.Fatal(err)
+		}
+		img := sim.Image()
+		lit := 0
+		for _, v := range img.Pix {
+			if v != 0 {
+				lit++
+			}
+		}
+		for _, c := range corners {
+			if img.GrayAt(c.X, c.Y).Y == 0 {
+				t.Errorf("%dx%d: corner %v should be lit", p.Width, p.Height, c)
+			}
+		}
+		if lit != 4 {
+			t.Errorf("%dx%d: %d pixels lit; want the 4 corners", p.Width, p.Height, lit)
+		}
+	}
+}
+
+func TestRotationGolden(t *testing.T) {
+	for _, rot := range []int{0, 90, 180, 270} {
+		sim := oledsim.New(128, 64)
+		oled, err := monochromeoled.OpenWithConfig(sim, monochromeoled.Config{Rotation: rot})
+		if err != nil {
+			t.Fatal(err)
+		}
+		drawUp(t, oled)
+		displaytest.Golden(t, fmt.Sprintf("rotation%d", rot), sim.Image())
+	}
+}
+
+func TestSetRotation(t *testing.T) {
+	sim := oledsim.New(128, 64)
+	oled, err := monochromeoled.Open(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rot := range []int{180, 90, 270, 0} {
+		if err := oled.SetRotation(rot); err != nil {
+			t.Fatal(err)
+		}
+		oled.Clear()
+		drawUp(t, oled)
+		displaytest.Golden(t, fmt.Sprintf("rotation%d", rot), sim.Image())
+	}
+	if err := oled.SetRotation(45); err == nil {
+		t.Error("expected an error with a rotation of 45 degrees")
+	}
+}
+
+// drawUp draws "Up" in the top left corner and a line on the bottom edge.
+func drawUp(t *testing.T, oled *monochromeoled.OLED) {
+	text.Default.Draw(oled, 0, 0, "Up", color.White, 2)
+	b := oled.Bounds()
+	for x := 0; x < b.Dx(); x++ {
+		oled.Set(x, b.Dy()-1, color.White)
+	}
+	if err := oled.Draw(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestContrast(t *testing.T) {
+	sim := oledsim.New(128, 64)
+	oled, err := monochromeoled.Open(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := sim.Contrast(); c != 0xcf {
+		t.Errorf("Contrast = %#x after init; want 0xcf", c)
+	}
+	if err := oled.SetContrast(0x10); err != nil {
+		t.Fatal(err)
+	}
+	if c := sim.Contrast(); c != 0x10 {
+		t.Errorf("Contrast = %#x; want 0x10", c)
+	}
+}
+
+func TestExternalVCC(t *testing.T) {
+	for _, tt := range []struct {
+		external            bool
+		pump                bool
+		contrast, precharge byte
+	}{
+		{false, true, 0xcf, 0xf1},
+		{true, false, 0x9f, 0x22},
+	} {
+		sim := oledsim.New(128, 64)
+		if _, err := monochromeoled.OpenWithConfig(sim, monochromeoled.Config{ExternalVCC: tt.external}); err != nil {
+			t.Fatal(err)
+		}
+		if sim.ChargePump() != tt.pump || sim.Contrast() != tt.contrast || sim.Precharge() != tt.precharge {
+			t.Errorf("ExternalVCC %v: charge pump %v, contrast %#x, pre-charge %#x; want %v, %#x, %#x",
+				tt.external, sim.ChargePump(), sim.Contrast(), sim.Precharge(), tt.pump, tt.contrast, tt.precharge)
+		}
+	}
+}
+
+func TestFade(t *testing.T) {
+	sim := oledsim.New(128, 64)
+	oled, err := monochromeoled.Open(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := clock.NewFake(time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC))
+	oled.Clock = c
+	done := make(chan error)
+	go func() { done <- oled.FadeOut(100 * time.Millisecond) }()
+	type level struct{ contrast, precharge byte }
+	var got []level
+	for i := 0; i < 5; i++ {
+		c.BlockUntil(1)
+		got = append(got, level{sim.Contrast(), sim.Precharge()})
+		c.Advance(20 * time.Millisecond)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	want := []level{{165, 0xC1}, {124, 0x91}, {82, 0x61}, {41, 0x31}, {0, 0x11}}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("levels %v; want %v", got, want)
+	}
+	// off, with the levels restored
+	if sim.On() || sim.Contrast() != 0xcf || sim.Precharge() != 0xf1 {
+		t.Errorf("on %v at %#x, %#x after FadeOut; want off at 0xcf, 0xf1", sim.On(), sim.Contrast(), sim.Precharge())
+	}
+
+	if err := oled.SetContrast(0x40); err != nil {
+		t.Fatal(err)
+	}
+	c.SetAutoSleep(true)
+	start := c.Now()
+	if err := oled.FadeIn(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if !sim.On() || sim.Contrast() != 0x40 || sim.Precharge() != 0xf1 {
+		t.Errorf("on %v at %#x, %#x after FadeIn; want on at 0x40, 0xf1", sim.On(), sim.Contrast(), sim.Precharge())
+	}
+	if d := c.Now().Sub(start); d != time.Second {
+		t.Errorf("FadeIn() lasted %v; want 1s", d)
+	}
+}
+
+func TestInvert(t *testing.T) {
+	sim := oledsim.New(128, 64)
+	oled, err := monochromeoled.Open(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oled.SetPixel(0, 0, 1)
+	if err := oled.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	for _, on := range []bool{true, false} {
+		if err := oled.Invert(on); err != nil {
+			t.Fatal(err)
+		}
+		if sim.Inverted() != on {
+			t.Errorf("Inverted = %v; want %v", sim.Inverted(), on)
+		}
+		img := sim.Image()
+		if lit := img.GrayAt(0, 0).Y != 0; lit == on {
+			t.Errorf("the pixel at 0,0 is lit %v with the display inverted %v", lit, on)
+		}
+		if lit := img.GrayAt(1, 0).Y != 0; lit != on {
+			t.Errorf("the pixel at 1,0 is lit %v with the display inverted %v", lit, on)
+		}
+	}
+}
+
+func TestFlip(t *testing.T) {
+	sim := oledsim.New(128, 64)
+	oled, err := monochromeoled.Open(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oled.SetPixel(1, 2, 1)
+	if err := oled.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		h, v bool
+		x, y int
+	}{
+		{true, false, 126, 2},
+		{true, true, 126, 61},
+		{false, true, 1, 61},
+		{false, false, 1, 2},
+	} {
+		if err := oled.FlipHorizontal(tt.h); err != nil {
+			t.Fatal(err)
+		}
+		if err := oled.FlipVertical(tt.v); err != nil {
+			t.Fatal(err)
+		}
+		if img := sim.Image(); img.GrayAt(tt.x, tt.y).Y == 0 {
+			t.Errorf("the pixel is not at %v,%v flipped horizontally %v and vertically %v", tt.x, tt.y, tt.h, tt.v)
+		}
+	}
+}
+
+func TestDrawText(t *testing.T) {
+	sim := oledsim.New(128, 64)
+	oled, err := monochromeoled.Open(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := oled.DrawText(2, 4, "Temp 23C\nRH 40%"); err != nil {
+		t.Fatal(err)
+	}
+	want := image.NewGray(image.Rect(0, 0, 128, 64))
+	text.Small.Draw(want, 2, 4, "Temp 23C", color.White, 1)
+	text.Small.Draw(want, 2, 12, "RH 40%", color.White, 1)
+	if n, r, _ := displaytest.Diff(sim.Image(), want); n != 0 {
+		t.Errorf("%d pixels differ:\n%s", n, displaytest.ASCII(sim.Image(), r))
+	}
+
+	// redrawn in place, "40%" leaves no pixel behind "9%"
+	if err := oled.DrawText(20, 12, " 9%"); err != nil {
+		t.Fatal(err)
+	}
+	want = image.NewGray(image.Rect(0, 0, 128, 64))
+	text.Small.Draw(want, 2, 4, "Temp 23C", color.White, 1)
+	text.Small.Draw(want, 2, 12, "RH  9%", color.White, 1)
+	if n, r, _ := displaytest.Diff(sim.Image(), want); n != 0 {
+		t.Errorf("%d pixels differ:\n%s", n, displaytest.ASCII(sim.Image(), r))
+	}
+}
+
+func TestSetImageDither(t *testing.T) {
+	sim := oledsim.New(128, 64)
+	oled, err := monochromeoled.Open(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a gradient from black to white over the 128 columns
+	grad := image.NewGray(image.Rect(0, 0, 128, 64))
+	for x := 0; x < 128; x++ {
+		for y := 0; y < 64; y++ {
+			grad.SetGray(x, y, color.Gray{Y: uint8(x * 2)})
+		}
+	}
+	lit := func(x0, x1 int) int {
+		n := 0
+		img := sim.Image()
+		for x := x0; x < x1; x++ {
+			for y := 0; y < 64; y++ {
+				if img.GrayAt(x, y).Y != 0 {
+					n++
+				}
+			}
+		}
+		return n
+	}
+
+	// thresholded, all but the black column are lit
+	if err := oled.SetImage(0, 0, grad); err != nil {
+		t.Fatal(err)
+	}
+	oled.Draw()
+	if n := lit(0, 128); n != 127*64 {
+		t.Errorf("%d pixels lit; want %d", n, 127*64)
+	}
+
+	if err := oled.SetImageWith(0, 0, grad, monochromeoled.SetImageOptions{Dither: monochromeoled.FloydSteinberg}); err != nil {
+		t.Fatal(err)
+	}
+	oled.Draw()
+	// each quarter of the gradient lights about its mean level of pixels
+	for q, want := range []float64{0.125, 0.375, 0.625, 0.875} {
+		got := float64(lit(q*32, q*32+32)) / (32 * 64)
+		if got < want-0.05 || got > want+0.05 {
+			t.Errorf("quarter %d: %.2f of the pixels lit; want about %.2f", q, got, want)
+		}
+	}
+
+	// clipped to the display
+	oled.Clear()
+	if err := oled.SetImageWith(120, 60, image.NewUniform(color.White), monochromeoled.SetImageOptions{Dither: monochromeoled.FloydSteinberg}); err != nil {
+		t.Fatal(err)
+	}
+	oled.Draw()
+	if n := lit(0, 128); n != 8*4 {
+		t.Errorf("%d pixels lit; want %d", n, 8*4)
+	}
+
+	if err := oled.SetImageWith(0, 0, grad, monochromeoled.SetImageOptions{Dither: 7}); err == nil {
+		t.Error("SetImageWith() succeeded with an invalid dither")
+	}
+}
+
+func TestSetImageLevel(t *testing.T) {
+	sim := oledsim.New(128, 64)
+	oled, err := monochromeoled.Open(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := image.NewRGBA(image.Rect(0, 0, 4, 1))
+	img.Set(0, 0, color.RGBA{R: 0x60, A: 0xFF})                   // dark red, luma 28
+	img.Set(1, 0, color.RGBA{G: 0xC0, A: 0xFF})                   // green, luma 113
+	img.Set(2, 0, color.RGBA{R: 0x90, G: 0x90, B: 0x90, A: 0xFF}) // gray, luma 144
+	img.Set(3, 0, color.RGBA{B: 0xFF, A: 0xFF})                   // blue, luma 29
+	for _, tt := range []struct {
+		level uint8
+		want  string
+	}{
+		{0, "####"},
+		{29, ".###"},
+		{100, ".##."},
+		{128, "..#."},
+		{200, "...."},
+	} {
+		if err := oled.SetImageWith(0, 0, img, monochromeoled.SetImageOptions{Level: tt.level}); err != nil {
+			t.Fatal(err)
+		}
+		oled.Draw()
+		got := ""
+		for x := 0; x < 4; x++ {
+			if sim.Image().GrayAt(x, 0).Y != 0 {
+				got += "#"
+			} else {
+				got += "."
+			}
+		}
+		if got != tt.want {
+			t.Errorf("level %d: pixels %s; want %s", tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestAnimator(t *testing.T) {
+	sim := oledsim.New(128, 64)
+	oled, err := monochromeoled.Open(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	palette := color.Palette{color.Black, color.White}
+	frame := func(r image.Rectangle, c uint8) *image.Paletted {
+		f := image.NewPaletted(r, palette)
+		for i := range f.Pix {
+			f.Pix[i] = c
+		}
+		return f
+	}
+	g := &gif.GIF{
+		Image: []*image.Paletted{
+			frame(image.Rect(0, 0, 8, 8), 1),
+			frame(image.Rect(4, 4, 8, 8), 0),
+			frame(image.Rect(0, 0, 2, 2), 0),
+		},
+		Delay:     []int{20, 0, 5},
+		Disposal:  []byte{gif.DisposalNone, gif.DisposalBackground, gif.DisposalPrevious},
+		LoopCount: 1,
+		Config:    image.Config{Width: 8, Height: 8},
+	}
+	c := clock.NewFake(time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC))
+	a := &monochromeoled.Animator{OLED: oled, Clock: c}
+	done := make(chan error)
+	go func() { done <- a.Play(context.Background(), g) }()
+	lit := func() int {
+		n := 0
+		for _, v := range sim.Image().Pix {
+			if v != 0 {
+				n++
+			}
+		}
+		return n
+	}
+	// the second frame is cleared to the background, the third one
+	// restores the second, and the loop starts over from a blank canvas
+	for i, want := range []int{64, 48, 44, 64, 48, 44} {
+		c.BlockUntil(1)
+		if n := lit(); n != want {
+			t.Errorf("frame %d: %d pixels lit; want %d", i, n, want)
+		}
+		c.Advance([]time.Duration{200 * time.Millisecond, 100 * time.Millisecond, 50 * time.Millisecond}[i%3])
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// played once without loop count
+	g.LoopCount = -1
+	go func() { done <- a.Play(context.Background(), g) }()
+	for i, want := range []int{64, 48, 44} {
+		c.BlockUntil(1)
+		if n := lit(); n != want {
+			t.Errorf("frame %d played once: %d pixels lit; want %d", i, n, want)
+		}
+		c.Advance(200 * time.Millisecond)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// looping forever until canceled
+	g.LoopCount = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { done <- a.Play(ctx, g) }()
+	for i := 0; i < 7; i++ {
+		c.BlockUntil(1)
+		c.Advance(200 * time.Millisecond)
+	}
+	c.BlockUntil(1)
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Play() = %v; want %v", err, context.Canceled)
+	}
+
+	if err := a.Play(context.Background(), &gif.GIF{}); err == nil {
+		t.Error("Play() succeeded without frames")
+	}
+}
+
+func TestAnimate(t *testing.T) {
+	sim := oledsim.New(128, 64)
+	oled, err := monochromeoled.Open(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := clock.NewFake(time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC))
+	a := &monochromeoled.Animator{OLED: oled, Clock: c}
+	const period = 100 * time.Millisecond
+	errStop := errors.New("stop")
+	frames := make(chan int)
+	done := make(chan error)
+	go func() {
+		done <- a.Animate(context.Background(), 10, func(frame int) error {
+			frames <- frame
+			oled.SetPixel(frame, 0, 1)
+			switch frame {
+			case 2:
+				c.Advance(2*period + period/2) // a slow frame
+			case 5:
+				return errStop
+			}
+			return nil
+		})
+	}()
+	var got []int
+	for _, advance := range []time.Duration{period, period, 0, period / 2, 0} {
+		got = append(got, <-frames)
+		c.Advance(advance)
+	}
+	if err := <-done; err != errStop {
+		t.Errorf("Animate() = %v; want %v", err, errStop)
+	}
+	// the frame 3 is dropped after the slow frame
+	if fmt.Sprint(got) != "[0 1 2 4 5]" {
+		t.Errorf("frames %v; want [0 1 2 4 5]", got)
+	}
+	// the frame failing is not shown
+	for x := 0; x < 6; x++ {
+		if lit := sim.Image().GrayAt(x, 0).Y != 0; lit != (x != 3 && x != 5) {
+			t.Errorf("pixel (%d, 0) lit: %v", x, lit)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := a.Animate(ctx, 0, func(int) error { return nil }); err != context.Canceled {
+		t.Errorf("Animate() = %v; want %v", err, context.Canceled)
+	}
+	if err := a.Animate(ctx, -1, func(int) error { return nil }); err == nil {
+		t.Error("Animate() succeeded at -1 fps")
+	}
+}
+
+func TestMarquee(t *testing.T) {
+	sim := oledsim.New(128, 64)
+	oled, err := monochromeoled.Open(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := clock.NewFake(time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC))
+	const s = "Now playing: a song with a long title"
+	area := image.Rect(10, 20, 70, 28)
+	oled.SetPixel(0, 0, 1) // left as is
+	m := &monochromeoled.Marquee{OLED: oled, Text: s, Area: area, Speed: 20, Pause: 500 * time.Millisecond, Clock: c}
+
+	// want returns the display with the text at pos in the area
+	size := text.Small.Size(s, 1)
+	strip := image.NewGray(image.Rect(0, 0, size.X, size.Y))
+	text.Small.Draw(strip, 0, 0, s, color.White, 1)
+	want := func(pos int) *image.Gray {
+		img := image.NewGray(image.Rect(0, 0, 128, 64))
+		img.Set(0, 0, color.White)
+		draw.Draw(img, area, strip, image.Pt(pos, 0), draw.Src)
+		return img
+	}
+	run := func(ctx context.Context) error {
+		done := make(chan error)
+		go func() { done <- m.Run(ctx) }()
+		for i := 0; i < 1000; i++ {
+			select {
+			case err := <-done:
+				return err
+			default:
+				c.Advance(50 * time.Millisecond)
+				time.Sleep(time.Millisecond)
+			}
+		}
+		t.Fatal("the marquee never ended")
+		return nil
+	}
+
+	if err := run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n, r, _ := displaytest.Diff(sim.Image(), want(size.X-area.Dx())); n != 0 {
+		t.Errorf("%d pixels differ at the end:\n%s", n, displaytest.ASCII(sim.Image(), r))
+	}
+
+	// looping until canceled
+	m.Loop = true
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := run(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Run() looping = %v; want %v", err, context.DeadlineExceeded)
+	}
+
+	// a short text is drawn in place
+	m.Text, m.Loop = "Hi", false
+	strip = image.NewGray(image.Rect(0, 0, size.X, size.Y))
+	text.Small.Draw(strip, 0, 0, "Hi", color.White, 1)
+	if err := m.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n, r, _ := displaytest.Diff(sim.Image(), want(0)); n != 0 {
+		t.Errorf("%d pixels differ with a short text:\n%s", n, displaytest.ASCII(sim.Image(), r))
+	}
+}
+
+func TestConsole(t *testing.T) {
+	sim := oledsim.New(128, 64)
+	oled, err := monochromeoled.Open(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oled.SetPixel(0, 0, 1) // left as is
+
+	area := image.Rect(4, 16, 124, 48) // 20 columns, 4 rows
+	c := &monochromeoled.Console{OLED: oled, Area: area}
+
+	// want returns the display with the lines in the area
+	want := func(lines ...string) *image.Gray {
+		img := image.NewGray(image.Rect(0, 0, 128, 64))
+		img.Set(0, 0, color.White)
+		for i, l := range lines {
+			text.Small.Draw(img, area.Min.X, area.Min.Y+8*i, l, color.White, 1)
+		}
+		return img
+	}
+	check := func(name string, lines ...string) {
+		t.Helper()
+		if n, r, _ := displaytest.Diff(sim.Image(), want(lines...)); n != 0 {
+			t.Errorf("%s: %d pixels differ:\n%s", name, n, displaytest.ASCII(sim.Image(), r))
+		}
+	}
+
+	c.Println("booting")
+	check("a line", "booting")
+	fmt.Fprint(c, "mounting /data... ")
+	check("a line not ended", "booting", "mounting /data...")
+	fmt.Fprintln(c, "ok")
+	c.Printf("eth0: %s\n\n", "10.0.0.2")
+	check("blank line", "booting", "mounting /data... ok", "eth0: 10.0.0.2", "")
+	c.Println("the sensors answer at 0x44 and 0x76")
+	check("scrolled and wrapped", "eth0: 10.0.0.2", "", "the sensors answer", "at 0x44 and 0x76")
+
+	if err := c.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	check("cleared")
+}
+
+func TestSnapshot(t *testing.T) {
+	for _, rotation := range []int{0, 90} {
+		sim := oledsim.New(128, 64)
+		oled, err := monochromeoled.OpenWithConfig(sim, monochromeoled.Config{Rotation: rotation})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := oled.DrawText(2, 3, "Snap"); err != nil {
+			t.Fatal(err)
+		}
+		got := oled.Snapshot()
+		if got.Bounds() != oled.Bounds() {
+			t.Fatalf("rotated by %d: Snapshot() of %v; want %v", rotation, got.Bounds(), oled.Bounds())
+		}
+		want := image.NewGray(oled.Bounds())
+		text.Small.Draw(want, 2, 3, "Snap", color.White, 1)
+		if n, r, _ := displaytest.Diff(got, want); n != 0 {
+			t.Errorf("rotated by %d: %d pixels differ:\n%s", rotation, n, displaytest.ASCII(got, r))
+		}
+		// a copy
+		oled.SetPixel(0, 0, 1)
+		if got.GrayAt(0, 0).Y != 0 {
+			t.Errorf("rotated by %d: the snapshot changed with the buffer", rotation)
+		}
+		if rotation == 0 {
+			if n, r, _ := displaytest.Diff(got, sim.Image()); n != 0 {
+				t.Errorf("%d pixels differ from the panel:\n%s", n, displaytest.ASCII(got, r))
+			}
+		}
+	}
+}
+
+func TestSH1106(t *testing.T) {
+	want := image.NewGray(image.Rect(0, 0, 128, 64))
+	text.Small.Draw(want, 100, 50, "SH1106", color.White, 1)
+	draw := func(c monochromeoled.Controller) (*oledsim.Display, *monochromeoled.OLED) {
+		sim := oledsim.NewSH1106(128, 64)
+		sim.SetReadable(true)
+		oled, err := monochromeoled.OpenWithConfig(sim, monochromeoled.Config{Controller: c})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := oled.DrawText(100, 50, "SH1106"); err != nil {
+			t.Fatal(err)
+		}
+		return sim, oled
+	}
+
+	// the SSD1306 updates are garbage on the SH1106
+	if sim, _ := draw(monochromeoled.SSD1306); !sim.On() {
+		t.Error("the SH1106 is off")
+	} else if n, _, _ := displaytest.Diff(sim.Image(), want); n == 0 {
+		t.Error("the SSD1306 updates draw on the SH1106")
+	}
+
+	sim, oled := draw(monochromeoled.SH1106)
+	if n, r, _ := displaytest.Diff(sim.Image(), want); n != 0 {
+		t.Errorf("%d pixels differ:\n%s", n, displaytest.ASCII(sim.Image(), r))
+	}
+	if err := oled.Verify(); err != nil {
+		t.Error(err)
+	}
+	// a partial update
+	oled.SetPixel(0, 0, 1)
+	if err := oled.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	want.SetGray(0, 0, color.Gray{Y: 0xFF})
+	if n, r, _ := displaytest.Diff(sim.Image(), want); n != 0 {
+		t.Errorf("%d pixels differ after the partial update:\n%s", n, displaytest.ASCII(sim.Image(), r))
+	}
+	if err := oled.EnableScroll(monochromeoled.ScrollLeft, 0, 7, monochromeoled.Scroll2Frames); err == nil {
+		t.Error("EnableScroll() succeeded on the SH1106")
+	}
+	if name := oled.Capabilities().Name; name != "SH1106" {
+		t.Errorf("Capabilities().Name = %q; want SH1106", name)
+	}
+	if _, err := monochromeoled.OpenWithConfig(sim, monochromeoled.Config{Controller: 9}); err == nil {
+		t.Error("OpenWithConfig() succeeded with an unknown controller")
+	}
+}
+
+func TestVariants(t *testing.T) {
+	for _, tt := range []struct {
+		c   monochromeoled.Controller
+		sim func(w, h int) *oledsim.Display
+	}{
+		{monochromeoled.SSD1309, oledsim.NewSSD1309},
+		{monochromeoled.SSD1305, oledsim.NewSSD1305},
+	} {
+		for _, p := range []monochromeoled.Panel{monochromeoled.Panel128x64, monochromeoled.Panel128x32} {
+			sim := tt.sim(p.Width, p.Height)
+			oled, err := monochromeoled.OpenWithConfig(sim, monochromeoled.Config{Width: p.Width, Height: p.Height, Controller: tt.c})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := image.NewGray(image.Rect(0, 0, p.Width, p.Height))
+			text.Small.Draw(want, 90, 20, tt.c.String(), color.White, 1)
+			if err := oled.DrawText(90, 20, tt.c.String()); err != nil {
+				t.Fatal(err)
+			}
+			if n, r, _ := displaytest.Diff(sim.Image(), want); n != 0 {
+				t.Errorf("%v %dx%d: %d pixels differ:\n%s", tt.c, p.Width, p.Height, n, displaytest.ASCII(sim.Image(), r))
+			}
+			if name := oled.Capabilities().Name; name != tt.c.String() {
+				t.Errorf("Capabilities().Name = %q; want %v", name, tt.c)
+			}
+
+			if err := oled.EnableScroll(monochromeoled.ScrollLeft, 1, 3, monochromeoled.Scroll25Frames); err != nil {
+				t.Fatal(err)
+			}
+			if s, on := sim.Scroll(); !on || s != (oledsim.Scrolling{Left: true, Start: 1, End: 3, Speed: 0x6}) {
+				t.Errorf("%v: Scroll = %+v, %v; want left pages 1 to 3", tt.c, s, on)
+			}
+			if err := oled.EnableDiagonalScroll(monochromeoled.ScrollRight, 0, 3, monochromeoled.Scroll2Frames, 2); err != nil {
+				t.Fatal(err)
+			}
+			if s, on := sim.Scroll(); !on || s != (oledsim.Scrolling{Start: 0, End: 3, Speed: 0x7, Vertical: 2}) {
+				t.Errorf("%v: Scroll = %+v, %v; want right and down 2 rows", tt.c, s, on)
+			}
+			if err := oled.DisableScroll(); err != nil {
+				t.Fatal(err)
+			}
+			if _, on := sim.Scroll(); on {
+				t.Errorf("%v: still scrolling after DisableScroll", tt.c)
+			}
+		}
+	}
+
+	// the SSD1306 updates are shifted on the SSD1305
+	sim := oledsim.NewSSD1305(128, 64)
+	oled, err := monochromeoled.Open(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := oled.DrawText(0, 0, "SSD1305"); err != nil {
+		t.Fatal(err)
+	}
+	want := image.NewGray(image.Rect(0, 0, 128, 64))
+	text.Small.Draw(want, 0, 0, "SSD1305", color.White, 1)
+	if n, _, _ := displaytest.Diff(sim.Image(), want); n == 0 {
+		t.Error("the SSD1306 updates draw on the SSD1305")
+	}
+}
+
+func TestBurnIn(t *testing.T) {
+	sim := oledsim.New(128, 64)
+	sim.SetReadable(true)
+	oled, err := monochromeoled.Open(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := clock.NewFake(time.Time{})
+	oled.Clock = fake
+	if err := oled.SetBurnInProtection(1, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	// a lit region of 8x8 pixels, and a pixel
+	block := func(img interface{ Set(x, y int, c color.Color) }, dx, dy int) {
+		for y := 8; y < 16; y++ {
+			for x := 16; x < 24; x++ {
+				img.Set(x+dx, y+dy, color.White)
+			}
+		}
+	}
+	check := func(dx, dy int, pixel bool) {
+		t.Helper()
+		want := image.NewGray(image.Rect(0, 0, 128, 64))
+		block(want, dx, dy)
+		if pixel {
+			want.Set(100+dx, 40+dy, color.White)
+		}
+		if n, r, _ := displaytest.Diff(sim.Image(), want); n != 0 {
+			t.Errorf("shifted by %d, %d: %d pixels differ:\n%s", dx, dy, n, displaytest.ASCII(sim.Image(), r))
+		}
+		if err := oled.Verify(); err != nil {
+			t.Error(err)
+		}
+	}
+	block(oled, 0, 0)
+	if err := oled.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	check(0, 0, false)
+
+	// the shift moves at the first Draw after the period
+	fake.Advance(time.Minute)
+	if err := oled.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	check(1, 0, false)
+	fake.Advance(time.Minute)
+	on := oled.OnTime()
+	if len(on) != 8 || len(on[1]) != 16 || on[1][2] != time.Minute+52500*time.Millisecond || on[1][3] != 7500*time.Millisecond || on[0][2] != 0 {
+		t.Errorf("OnTime() = %v; want 1m52.5s and 7.5s in the regions of the block", on)
+	}
+
+	// the partial updates are shifted
+	fake.Advance(time.Minute)
+	if err := oled.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	oled.SetPixel(100, 40, 1)
+	if err := oled.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	check(1, -1, true)
+
+	// the panel off is not worn
+	before := oled.OnTime()[1][2]
+	if err := oled.Off(); err != nil {
+		t.Fatal(err)
+	}
+	fake.Advance(time.Hour)
+	if got := oled.OnTime()[1][2]; got != before {
+		t.Errorf("on-time = %v after an hour off; want %v", got, before)
+	}
+	if err := oled.On(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := oled.SetBurnInProtection(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := oled.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	check(0, 0, true)
+	if oled.OnTime() != nil {
+		t.Error("OnTime() without the protection is not nil")
+	}
+	if err := oled.SetBurnInProtection(3, 0); err == nil {
+		t.Error("SetBurnInProtection(3) succeeded")
+	}
+}
+
+func TestDoubleBuffering(t *testing.T) {
+	sim := oledsim.New(128, 64)
+	oled, err := monochromeoled.Open(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oled.SetDoubleBuffering(true)
+	oled.SetPixel(3, 10, 1)
+	if err := oled.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	if err := oled.DrawText(2, 20, "OK"); err != nil {
+		t.Fatal(err)
+	}
+	if n, r, _ := displaytest.Diff(sim.Image(), image.NewGray(image.Rect(0, 0, 128, 64))); n != 0 {
+		t.Errorf("%d pixels shown before the swap:\n%s", n, displaytest.ASCII(sim.Image(), r))
+	}
+	if err := oled.SwapBuffers(); err != nil {
+		t.Fatal(err)
+	}
+	want := image.NewGray(image.Rect(0, 0, 128, 64))
+	want.SetGray(3, 10, color.Gray{Y: 0xFF})
+	text.Small.Draw(want, 2, 20, "OK", color.White, 1)
+	if n, r, _ := displaytest.Diff(sim.Image(), want); n != 0 {
+		t.Errorf("%d pixels differ after the swap:\n%s", n, displaytest.ASCII(sim.Image(), r))
+	}
+
+	// back to drawing right away
+	oled.SetDoubleBuffering(false)
+	oled.SetPixel(3, 10, 0)
+	oled.Draw()
+	if sim.Image().GrayAt(3, 10).Y != 0 {
+		t.Error("pixel (3, 10) is still lit after Draw")
+	}
+}
+
+func TestReinit(t *testing.T) {
+	sim := oledsim.New(128, 64)
+	oled, err := monochromeoled.Open(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oled.SetRotation(180)
+	oled.SetContrast(0x30)
+	oled.SetScrollArea(8, 40)
+	drawUp(t, oled)
+	want := sim.Image()
+
+	sim.Reset() // the power cut by a sleep
+	if err := oled.Reinit(); err != nil {
+		t.Fatal(err)
+	}
+	if !sim.On() {
+		t.Error("display should be on after Reinit")
+	}
+	if n, r, _ := displaytest.Diff(sim.Image(), want); n != 0 {
+		t.Errorf("%d pixels differ after Reinit:\n%s", n, displaytest.ASCII(sim.Image(), r))
+	}
+	if c := sim.Contrast(); c != 0x30 {
+		t.Errorf("Contrast = %#x after Reinit; want 0x30", c)
+	}
+	if top, rows := sim.ScrollArea(); top != 8 || rows != 40 {
+		t.Errorf("ScrollArea() = %d, %d after Reinit; want 8, 40", top, rows)
+	}
+
+	oled.Off()
+	sim.Reset()
+	if err := oled.Reinit(); err != nil {
+		t.Fatal(err)
+	}
+	if sim.On() {
+		t.Error("display turned off should stay off after Reinit")
+	}
+}
@@ -0,0 +1,145 @@
+package monochromeoled
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrWriteOnly is returned by the read-back functions on the modules
+// whose controller does not answer reads, which is the case of most of
+// them.
+var ErrWriteOnly = errors.New("monochromeoled: the controller of this module cannot be read")
+
+// Status is the status register of the controller.
+type Status byte
+
+// On reports whether the controller drives the panel.
+func (s Status) On() bool { return s&0x40 == 0 }
+
+// read runs a read of the controller. The first failing read marks the
+// module as write-only, the reads fail with ErrWriteOnly afterwards;
+// once a read succeeded, the failures are reported as is.
+func (o *OLED) read(ctrl []byte, b []byte) error {
+	if o.readable < 0 {
+		return ErrWriteOnly
+	}
+	var err error
+	if ctrl == nil {
+		err = o.dev.Read(b)
+	} else {
+		err = o.dev.ReadReg(ctrl[0], b)
+	}
+	switch {
+	case err == nil:
+		o.readable = 1
+	case o.readable == 0:
+		o.readable = -1
+		return ErrWriteOnly
+	}
+	return err
+}
+
+// Status reads the status register of the controller.
+func (o *OLED) Status() (Status, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.status()
+}
+
+func (o *OLED) status() (Status, error) {
+	b := make([]byte, 1)
+	if err := o.read(nil, b); err != nil {
+		return 0, err
+	}
+	return Status(b[0]), nil
+}
+
+// ReadRAM reads the display RAM of the controller, in the layout of the
+// buffer drawn by Draw: a byte per column of 8 pixels, page after page.
+func (o *OLED) ReadRAM() ([]byte, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.readRAM()
+}
+
+func (o *OLED) readRAM() ([]byte, error) {
+	if o.readable < 0 {
+		return nil, ErrWriteOnly
+	}
+	if o.controller == SH1106 {
+		return o.readPages()
+	}
+	if err := o.write([]byte{
+		0x00, // command stream
+		0x21, byte(o.col), byte(o.col + o.w - 1),
+		0x22, 0, byte(o.h/8 - 1),
+	}); err != nil {
+		return nil, err
+	}
+	b := make([]byte, len(o.buf)) // the dummy byte and the RAM
+	if err := o.read([]byte{0x40}, b); err != nil {
+		return nil, err
+	}
+	return b[1:], nil
+}
+
+// readPages reads the display RAM page by page, on the SH1106.
+func (o *OLED) readPages() ([]byte, error) {
+	ram := make([]byte, 0, len(o.buf)-1)
+	b := make([]byte, 1+o.w) // the dummy byte and the page
+	for p := 0; p < o.h/8; p++ {
+		if err := o.write([]byte{0x00, 0xb0 | byte(p), byte(o.col & 0x0f), 0x10 | byte(o.col>>4)}); err != nil {
+			return nil, err
+		}
+		if err := o.read([]byte{0x40}, b); err != nil {
+			return nil, err
+		}
+		ram = append(ram, b[1:]...)
+	}
+	return ram, nil
+}
+
+// Verify checks that the panel holds the buffer, as after a Draw, shifted
+// by the burn-in protection. It returns ErrWriteOnly on the write-only
+// modules.
+func (o *OLED) Verify() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	ram, err := o.readRAM()
+	if err != nil {
+		return err
+	}
+	buf := o.frame()
+	for i, v := range ram {
+		if want := buf[1+i]; v != want {
+			return fmt.Errorf("the display RAM differs from the buffer at column %d of page %d: %#02x, want %#02x", i%o.w, i/o.w, v, want)
+		}
+	}
+	return nil
+}
+
+// Check detects a wedged or reset controller: one that stopped answering
+// the reads, or whose panel is off while it was turned on. It returns nil
+// on the write-only modules, there is nothing to check. Once opened again,
+// DrawAll redraws the panel.
+func (o *OLED) Check() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	s, err := o.status()
+	switch {
+	case err == ErrWriteOnly:
+		return nil
+	case err != nil:
+		return fmt.Errorf("the controller does not respond - %v", err)
+	case s.On() != o.on:
+		return fmt.Errorf("the panel is unexpectedly %v, the controller was probably reset", onOff(s.On()))
+	}
+	return nil
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
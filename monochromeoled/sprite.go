@@ -0,0 +1,130 @@
+package monochromeoled
+
+import (
+	"fmt"
+	"image"
+)
+
+// Blit copies the sprite of w x h pixels to the buffer at x, y, where its
+// mask is set; the whole sprite if mask is nil. The sprite and its mask are
+// in the layout of the buffer: rows of 8 pixels high, the pages, of a byte
+// per column, the least significant bit on top. Copying whole bytes, it is
+// much faster than SetPixel for the games and the animations. The parts
+// of the sprite out of the display are cut. A call to Draw is required to
+// display it.
+func (o *OLED) Blit(x, y int, sprite, mask []byte, w, h int) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := checkSprite(sprite, mask, w, h); err != nil {
+		return err
+	}
+	if o.rot == 90 || o.rot == 270 {
+		// the pages of the buffer are columns of the image
+		o.blitPixels(x, y, sprite, mask, w, h)
+		return nil
+	}
+	d := blit(o.buf[1:], o.w, o.h, x, y, sprite, mask, w, h)
+	o.dirty = o.dirty.Union(d)
+	return nil
+}
+
+func checkSprite(sprite, mask []byte, w, h int) error {
+	if w < 0 || h < 0 {
+		return fmt.Errorf("invalid sprite size %dx%d", w, h)
+	}
+	n := w * ((h + 7) / 8)
+	if len(sprite) < n {
+		return fmt.Errorf("sprite of %d bytes, %dx%d pixels need %d", len(sprite), w, h, n)
+	}
+	if mask != nil && len(mask) < n {
+		return fmt.Errorf("mask of %d bytes, %dx%d pixels need %d", len(mask), w, h, n)
+	}
+	return nil
+}
+
+// blit copies the sprite to the pages of bw x bh pixels at x, y, and
+// returns the columns and pages changed.
+func blit(pages []byte, bw, bh int, x, y int, sprite, mask []byte, w, h int) image.Rectangle {
+	var changed image.Rectangle
+	set := func(x, p int, v, mask byte) {
+		if mask == 0 || p < 0 || p >= (bh+7)/8 {
+			return
+		}
+		i := p*bw + x
+		if b := pages[i]&^mask | v; b != pages[i] {
+			pages[i] = b
+			changed = changed.Union(image.Rect(x, p, x+1, p+1))
+		}
+	}
+	shift := uint(y & 7)
+	top := (y - int(shift)) / 8 // page of the first row
+	for p := 0; p < (h+7)/8; p++ {
+		bits := byte(0xFF)
+		if last := h - 8*p; last < 8 {
+			bits = 1<<uint(last) - 1
+		}
+		for sx := 0; sx < w; sx++ {
+			dx := x + sx
+			if dx < 0 || dx >= bw {
+				continue
+			}
+			m := bits
+			if mask != nil {
+				m &= mask[p*w+sx]
+			}
+			v := sprite[p*w+sx] & m
+			set(dx, top+p, v<<shift, m<<shift)
+			if shift > 0 {
+				set(dx, top+p+1, v>>(8-shift), m>>(8-shift))
+			}
+		}
+	}
+	return changed
+}
+
+// blitPixels is Blit pixel by pixel, for the rotated displays.
+func (o *OLED) blitPixels(x, y int, sprite, mask []byte, w, h int) {
+	b := o.bounds()
+	for sy := 0; sy < h; sy++ {
+		for sx := 0; sx < w; sx++ {
+			i, bit := sx+(sy/8)*w, byte(1)<<uint(sy&7)
+			if !image.Pt(x+sx, y+sy).In(b) || mask != nil && mask[i]&bit == 0 {
+				continue
+			}
+			var v byte
+			if sprite[i]&bit != 0 {
+				v = 1
+			}
+			o.setPixel(x+sx, y+sy, v)
+		}
+	}
+}
+
+// Sprite returns the sprite and the mask of img for Blit, the pixels lit
+// unless they are black as in SetImage, and masked out where they are
+// fully transparent. The mask is nil if img is opaque.
+func Sprite(img image.Image) (sprite, mask []byte) {
+	b := img.Bounds()
+	w := b.Dx()
+	sprite = make([]byte, w*((b.Dy()+7)/8))
+	mask = make([]byte, len(sprite))
+	opaque := true
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < w; x++ {
+			c := img.At(b.Min.X+x, b.Min.Y+y)
+			i, bit := x+(y/8)*w, byte(1)<<uint(y&7)
+			if _, _, _, a := c.RGBA(); a == 0 {
+				opaque = false
+				continue
+			}
+			mask[i] |= bit
+			if lit(c) {
+				sprite[i] |= bit
+			}
+		}
+	}
+	if opaque {
+		mask = nil
+	}
+	return sprite, mask
+}
@@ -0,0 +1,30 @@
+// Package motor defines the interfaces implemented by the drivers of DC
+// and stepper motors and of their encoders, so that the code moving a
+// robot or a mechanism can use any of them.
+package motor
+
+// Motor is a DC motor driven by an H-bridge.
+type Motor interface {
+	// SetSpeed sets the speed and the direction of the motor, from -1
+	// full speed backwards to 1 full speed forwards. The motor coasts at
+	// 0.
+	SetSpeed(speed float64) error
+}
+
+// Stepper is a stepper motor.
+type Stepper interface {
+	// Step turns the motor by n full steps, backwards if n is negative,
+	// and holds it in place.
+	Step(n int) error
+
+	// Release turns the coils off, the shaft turns freely and the motor
+	// cools down.
+	Release() error
+}
+
+// Encoder is a rotary encoder on the shaft of a motor or on its wheel.
+type Encoder interface {
+	// Count returns the number of counts since the encoder was opened,
+	// decreasing when the shaft turns backwards.
+	Count() (int, error)
+}
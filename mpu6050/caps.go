@@ -0,0 +1,24 @@
+package mpu6050
+
+import "github.com/goiot/devices/caps"
+
+// Caps are the capabilities of the sensor.
+var Caps = caps.Capabilities{
+	Name:      "MPU-6050",
+	Bus:       "i2c",
+	Addresses: []int{Addr, Addr + 1},
+	Measurements: []caps.Measurement{
+		{Kind: caps.Acceleration, Unit: "g", Min: -2, Max: 2, Resolution: accelScale, Channels: 3},
+		{Kind: caps.AngularRate, Unit: "dps", Min: -250, Max: 250, Resolution: gyroScale, Channels: 3},
+	},
+	PowerModes: []string{"sleep", "active"},
+}
+
+func init() { caps.Register(Caps) }
+
+// Capabilities implements caps.Describer.
+func (s *MPU6050) Capabilities() caps.Capabilities {
+	c := Caps
+	c.Name = s.name
+	return c
+}
@@ -0,0 +1,254 @@
+// Package mpu6050 implements a driver for the InvenSense MPU-6050 and
+// the accelerometer and gyroscope of the MPU-6500 and MPU-9250 motion
+// sensors.
+//
+// At high rates, the samples are collected by the FIFO of the sensor and
+// drained in bursts by ReadFIFO, hundreds per second without a transfer
+// per sample.
+package mpu6050
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"golang.org/x/exp/io/i2c"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+const (
+	// Addr is the I2C address of the sensor with its AD0 pin low, it
+	// answers at 0x69 with the pin high.
+	Addr = 0x68
+
+	regSampleRate  = 0x19
+	regConfig      = 0x1A
+	regGyroConfig  = 0x1B
+	regAccelConfig = 0x1C
+	regFIFOEnable  = 0x23
+	regIntEnable   = 0x38
+	regIntStatus   = 0x3A
+	regAccel       = 0x3B // 6 bytes, big endian X, Y and Z
+	regGyro        = 0x43 // 6 bytes, big endian X, Y and Z
+	regUserCtrl    = 0x6A
+	regPower1      = 0x6B
+	regFIFOCount   = 0x72 // 2 bytes, big endian
+	regFIFO        = 0x74
+	regWhoAmI      = 0x75
+
+	dlpf184Hz  = 0x01 // in CONFIG, the gyroscope output at 1kHz
+	clockPLL   = 0x01 // in PWR_MGMT_1, awake on the PLL of the X gyroscope
+	fifoAG     = 0x78 // in FIFO_EN, the accelerometer and the 3 gyroscopes
+	fifoOn     = 0x40 // in USER_CTRL
+	fifoReset  = 0x04 // in USER_CTRL
+	fifoOvflow = 0x10 // in INT_ENABLE and INT_STATUS
+
+	// sampleSize is the size of a sample in the FIFO, the acceleration
+	// then the rotation.
+	sampleSize = 12
+
+	// Sensitivities at the full scales of the reset, 2g and 250dps.
+	accelScale = 1.0 / 16384 // g/LSB
+	gyroScale  = 1.0 / 131   // dps/LSB
+
+	// outputRate is the rate of the gyroscope output with the low-pass
+	// filter, divided into the sample rate.
+	outputRate = 1000
+)
+
+// ids are the identities of the supported sensors.
+var ids = map[byte]string{
+	0x68: "MPU-6050",
+	0x70: "MPU-6500",
+	0x71: "MPU-9250",
+	0x73: "MPU-9255",
+}
+
+// ErrOverflow is returned by ReadFIFO when the FIFO filled up and samples
+// were lost; the FIFO is emptied and the next samples are collected.
+var ErrOverflow = errors.New("mpu6050: FIFO overflow, samples lost")
+
+// Vector is a measurement along the axes of the sensor.
+type Vector struct {
+	X, Y, Z float64
+}
+
+// Sample is a sample of the FIFO.
+type Sample struct {
+	Time         time.Time
+	Acceleration Vector // in g
+	Rotation     Vector // in degrees per second
+}
+
+// MPU6050 represents an MPU-6050, MPU-6500 or MPU-9250 sensor.
+type MPU6050 struct {
+	Device *i2c.Device
+	// Clock times the samples of the FIFO, clock.Real if nil.
+	Clock clock.Clock
+
+	name   string
+	period time.Duration // between the samples of the FIFO, 0 if stopped
+	last   time.Time     // of the last sample read from the FIFO
+}
+
+// Open opens the sensor at the address addr, measuring at 1kHz with full
+// scales of 2g and 250 degrees per second.
+func Open(o driver.Opener, addr int) (*MPU6050, error) {
+	dev, err := i2c.Open(o, addr)
+	if err != nil {
+		return nil, err
+	}
+	id := make([]byte, 1)
+	if err := dev.ReadReg(regWhoAmI, id); err != nil {
+		dev.Close()
+		return nil, err
+	}
+	name, ok := ids[id[0]]
+	if !ok {
+		dev.Close()
+		return nil, fmt.Errorf("unexpected WHO_AM_I %#x, expected an MPU-6050, MPU-6500 or MPU-9250", id[0])
+	}
+	for _, w := range [][]byte{
+		{regPower1, clockPLL},
+		{regConfig, dlpf184Hz},
+		{regGyroConfig, 0},
+		{regAccelConfig, 0},
+	} {
+		if err := dev.Write(w); err != nil {
+			dev.Close()
+			return nil, fmt.Errorf("configuring the sensor failed - %v", err)
+		}
+	}
+	return &MPU6050{Device: dev, name: name}, nil
+}
+
+func vector(b []byte, scale float64) Vector {
+	return Vector{
+		X: float64(int16(uint16(b[0])<<8|uint16(b[1]))) * scale,
+		Y: float64(int16(uint16(b[2])<<8|uint16(b[3]))) * scale,
+		Z: float64(int16(uint16(b[4])<<8|uint16(b[5]))) * scale,
+	}
+}
+
+func (s *MPU6050) read(reg byte, scale float64) (Vector, error) {
+	b := make([]byte, 6)
+	if err := s.Device.ReadReg(reg, b); err != nil {
+		return Vector{}, err
+	}
+	return vector(b, scale), nil
+}
+
+// Acceleration returns the last acceleration in g.
+func (s *MPU6050) Acceleration() (Vector, error) {
+	return s.read(regAccel, accelScale)
+}
+
+// Rotation returns the last angular rate in degrees per second.
+func (s *MPU6050) Rotation() (Vector, error) {
+	return s.read(regGyro, gyroScale)
+}
+
+// StartFIFO empties the FIFO and starts collecting the samples at rate
+// samples per second, from 4 to 1000. The FIFO holds 85 samples of the
+// MPU-6050, 42 of the MPU-6500 and MPU-9250: it needs to be read before
+// it fills up, e.g. every 50ms at 500Hz. The INT pin signals the
+// overflows.
+func (s *MPU6050) StartFIFO(rate int) error {
+	if rate < outputRate/256 || rate > outputRate {
+		return fmt.Errorf("invalid rate %d, should be between %d and %d samples per second", rate, outputRate/256, outputRate)
+	}
+	div := outputRate/rate - 1
+	for _, w := range [][]byte{
+		{regSampleRate, byte(div)},
+		{regFIFOEnable, fifoAG},
+		{regIntEnable, fifoOvflow},
+	} {
+		if err := s.Device.Write(w); err != nil {
+			return err
+		}
+	}
+	s.period = time.Duration(div+1) * time.Second / outputRate
+	return s.resetFIFO()
+}
+
+// resetFIFO empties the FIFO, the next samples are timed again.
+func (s *MPU6050) resetFIFO() error {
+	if err := s.Device.WriteReg(regUserCtrl, []byte{fifoReset}); err != nil {
+		return err
+	}
+	s.last = time.Time{}
+	return s.Device.WriteReg(regUserCtrl, []byte{fifoOn})
+}
+
+// StopFIFO stops collecting the samples.
+func (s *MPU6050) StopFIFO() error {
+	s.period = 0
+	for _, w := range [][]byte{
+		{regUserCtrl, 0},
+		{regFIFOEnable, 0},
+		{regIntEnable, 0},
+	} {
+		if err := s.Device.Write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadFIFO drains the samples collected by the FIFO, in one transfer.
+// The sensor does not time its samples: they are spaced by the period of
+// the rate from the last one read, and timed again from the time of the
+// read when it drifts by more than a period.
+func (s *MPU6050) ReadFIFO() ([]Sample, error) {
+	if s.period == 0 {
+		return nil, errors.New("the FIFO is not started")
+	}
+	// reading the status clears it
+	st := make([]byte, 1)
+	if err := s.Device.ReadReg(regIntStatus, st); err != nil {
+		return nil, err
+	}
+	if st[0]&fifoOvflow != 0 {
+		if err := s.resetFIFO(); err != nil {
+			return nil, err
+		}
+		return nil, ErrOverflow
+	}
+	now := clock.Or(s.Clock).Now()
+	c := make([]byte, 2)
+	if err := s.Device.ReadReg(regFIFOCount, c); err != nil {
+		return nil, err
+	}
+	// the bytes of a sample being written are left for the next read
+	n := (int(c[0])<<8 | int(c[1])) / sampleSize
+	if n == 0 {
+		return nil, nil
+	}
+	b := make([]byte, n*sampleSize)
+	if err := s.Device.ReadReg(regFIFO, b); err != nil {
+		return nil, err
+	}
+
+	// the last sample was taken within a period before the read
+	last := s.last.Add(time.Duration(n) * s.period)
+	if s.last.IsZero() || last.After(now) || now.Sub(last) >= 2*s.period {
+		last = now
+	}
+	samples := make([]Sample, n)
+	for i := range samples {
+		p := b[i*sampleSize:]
+		samples[i] = Sample{
+			Time:         last.Add(-time.Duration(n-1-i) * s.period),
+			Acceleration: vector(p, accelScale),
+			Rotation:     vector(p[6:], gyroScale),
+		}
+	}
+	s.last = last
+	return samples, nil
+}
+
+// Close closes the sensor.
+func (s *MPU6050) Close() error {
+	return s.Device.Close()
+}
@@ -0,0 +1,168 @@
+package mpu6050
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+// sensor is a fake MPU-6050 with a map of 256 registers and a FIFO read
+// from FIFO_R_W.
+type sensor struct {
+	regs   [256]byte
+	reg    byte
+	fifo   []byte
+	bursts int // reads of the FIFO
+}
+
+func (s *sensor) Open(addr int, tenbit bool) (driver.Conn, error) { return s, nil }
+func (s *sensor) Close() error                                    { return nil }
+
+func (s *sensor) Tx(w, r []byte) error {
+	if len(w) > 0 {
+		s.reg = w[0]
+		copy(s.regs[s.reg:], w[1:])
+		if s.reg == regUserCtrl && len(w) > 1 && w[1]&fifoReset != 0 {
+			s.fifo = nil
+		}
+	}
+	switch {
+	case len(r) == 0:
+	case s.reg == regFIFO:
+		s.bursts++
+		s.fifo = s.fifo[copy(r, s.fifo):]
+	case s.reg == regFIFOCount:
+		r[0], r[1] = byte(len(s.fifo)>>8), byte(len(s.fifo))
+	case s.reg == regIntStatus:
+		copy(r, s.regs[s.reg:])
+		s.regs[regIntStatus] = 0
+	default:
+		copy(r, s.regs[s.reg:])
+	}
+	return nil
+}
+
+// push adds n samples to the FIFO, the acceleration along X being i/16384g
+// and the rotation along Z i/131dps for the ith of them.
+func (s *sensor) push(n int) {
+	for i := 1; i <= n; i++ {
+		s.fifo = append(s.fifo, 0, byte(i), 0, 0, 0, 0, 0, 0, 0, 0, 0xFF, byte(-i))
+	}
+}
+
+func open(t *testing.T) (*sensor, *MPU6050, *clock.Fake) {
+	s := &sensor{}
+	s.regs[regWhoAmI] = 0x71
+	dev, err := Open(s, Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := clock.NewFake(time.Unix(0, 0))
+	dev.Clock = c
+	return s, dev, c
+}
+
+func TestRead(t *testing.T) {
+	s, dev, _ := open(t)
+	if s.regs[regPower1] != clockPLL {
+		t.Error("the sensor is not woken up")
+	}
+	if got := dev.Capabilities().Name; got != "MPU-9250" {
+		t.Errorf("Name = %q, want MPU-9250", got)
+	}
+	copy(s.regs[regAccel:], []byte{0x40, 0, 0xC0, 0, 0x20, 0})
+	if a, err := dev.Acceleration(); err != nil || a != (Vector{1, -1, 0.5}) {
+		t.Errorf("Acceleration = %v, %v; want {1 -1 0.5}", a, err)
+	}
+	copy(s.regs[regGyro:], []byte{0, 131, 0xFF, 0x7D, 0, 0})
+	if r, err := dev.Rotation(); err != nil || r != (Vector{1, -1, 0}) {
+		t.Errorf("Rotation = %v, %v; want {1 -1 0}", r, err)
+	}
+
+	s.regs[regWhoAmI] = 0x12
+	if _, err := Open(s, Addr); err == nil {
+		t.Error("Open succeeded with an unknown WHO_AM_I")
+	}
+}
+
+func TestReadFIFO(t *testing.T) {
+	s, dev, c := open(t)
+	if _, err := dev.ReadFIFO(); err == nil {
+		t.Error("ReadFIFO succeeded with the FIFO stopped")
+	}
+	if err := dev.StartFIFO(2000); err == nil {
+		t.Error("StartFIFO succeeded at 2000Hz")
+	}
+	if err := dev.StartFIFO(500); err != nil {
+		t.Fatal(err)
+	}
+	if s.regs[regSampleRate] != 1 || s.regs[regFIFOEnable] != fifoAG || s.regs[regUserCtrl] != fifoOn {
+		t.Errorf("SMPLRT_DIV = %d, FIFO_EN = %#x, USER_CTRL = %#x", s.regs[regSampleRate], s.regs[regFIFOEnable], s.regs[regUserCtrl])
+	}
+
+	c.Advance(20 * time.Millisecond)
+	s.push(10)
+	s.fifo = append(s.fifo, 1, 2, 3) // a sample being written
+	samples, err := dev.ReadFIFO()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 10 || s.bursts != 1 || len(s.fifo) != 3 {
+		t.Fatalf("read %d samples in %d bursts, %d bytes left; want 10 in 1, 3 left", len(samples), s.bursts, len(s.fifo))
+	}
+	for i, p := range samples {
+		if want := time.Unix(0, 0).Add(time.Duration(2+2*i) * time.Millisecond); !p.Time.Equal(want) {
+			t.Errorf("sample %d at %v, want %v", i, p.Time, want)
+		}
+		if want := float64(i+1) / 16384; p.Acceleration.X != want {
+			t.Errorf("sample %d acceleration = %v, want X %v", i, p.Acceleration, want)
+		}
+		if want := -float64(i+1) / 131; p.Rotation.Z != want {
+			t.Errorf("sample %d rotation = %v, want Z %v", i, p.Rotation, want)
+		}
+	}
+
+	// the next samples follow, even read late
+	s.fifo = s.fifo[:0]
+	s.push(5)
+	c.Advance(11 * time.Millisecond)
+	samples, err = dev.ReadFIFO()
+	if err != nil || len(samples) != 5 {
+		t.Fatalf("ReadFIFO = %d samples, %v; want 5", len(samples), err)
+	}
+	if want := time.Unix(0, 0).Add(22 * time.Millisecond); !samples[0].Time.Equal(want) {
+		t.Errorf("first sample at %v, want %v", samples[0].Time, want)
+	}
+
+	// timed again from the read once behind by 2 periods
+	s.push(1)
+	c.Advance(time.Second)
+	if samples, err = dev.ReadFIFO(); err != nil || len(samples) != 1 || !samples[0].Time.Equal(c.Now()) {
+		t.Errorf("ReadFIFO = %v, %v; want a sample at %v", samples, err, c.Now())
+	}
+}
+
+func TestOverflow(t *testing.T) {
+	s, dev, _ := open(t)
+	if err := dev.StartFIFO(1000); err != nil {
+		t.Fatal(err)
+	}
+	s.push(85)
+	s.regs[regIntStatus] = fifoOvflow
+	if _, err := dev.ReadFIFO(); err != ErrOverflow {
+		t.Errorf("ReadFIFO = %v, want ErrOverflow", err)
+	}
+	if len(s.fifo) != 0 || s.regs[regUserCtrl] != fifoOn {
+		t.Errorf("the FIFO is not reset, %d bytes left", len(s.fifo))
+	}
+	s.push(1)
+	if samples, err := dev.ReadFIFO(); err != nil || len(samples) != 1 {
+		t.Errorf("ReadFIFO = %d samples, %v; want 1", len(samples), err)
+	}
+
+	if err := dev.StopFIFO(); err != nil || s.regs[regUserCtrl] != 0 || s.regs[regFIFOEnable] != 0 {
+		t.Errorf("StopFIFO = %v, USER_CTRL = %#x, FIFO_EN = %#x", err, s.regs[regUserCtrl], s.regs[regFIFOEnable])
+	}
+}
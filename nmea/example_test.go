@@ -0,0 +1,37 @@
+package nmea_test
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goiot/devices/nmea"
+)
+
+func Example() {
+	// a GPS receiver on /dev/ttyS0 in a real program
+	port := strings.NewReader("$GPRMC,123519,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W*6A\r\n" +
+		"$IIHDT,274.1,T*22\r\n")
+	r := nmea.NewReader(port)
+	for {
+		s, err := r.Read()
+		if err != nil {
+			break
+		}
+		fmt.Println(s.Type)
+	}
+
+	v, err := nmea.Decode("$GPRMC,123519,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W*6A")
+	if err != nil {
+		panic(err)
+	}
+	if fix, ok := v.(nmea.RMC); ok {
+		fmt.Printf("%.4f %.4f at %v\n", fix.Latitude, fix.Longitude, fix.Time)
+	}
+
+	fmt.Println(nmea.MWV{Angle: 45, Relative: true, Speed: 10.5, Unit: "N", Valid: true}.Encode("II"))
+	// Output:
+	// RMC
+	// HDT
+	// 48.1173 11.5167 at 1994-03-23 12:35:19 +0000 UTC
+	// $IIMWV,45.0,R,10.5,N,A*38
+}
@@ -0,0 +1,135 @@
+package nmea
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// fields parses the fields of a sentence, keeping the first error so
+// decoders can check it once. Empty numeric fields, common for the
+// values a device doesn't know yet, are zero.
+type fields struct {
+	s   Sentence
+	err error
+}
+
+func (p *fields) fail(i int, what string) {
+	if p.err == nil {
+		p.err = fmt.Errorf("nmea: %s: invalid %s in field %d", p.s.Type, what, i+1)
+	}
+}
+
+func (p *fields) str(i int) string {
+	if i >= len(p.s.Fields) {
+		if p.err == nil {
+			p.err = fmt.Errorf("nmea: %s: %d fields, expected at least %d", p.s.Type, len(p.s.Fields), i+1)
+		}
+		return ""
+	}
+	return p.s.Fields[i]
+}
+
+func (p *fields) float(i int) float64 {
+	f := p.str(i)
+	if f == "" {
+		return 0
+	}
+	v, err := strconv.ParseFloat(f, 64)
+	if err != nil {
+		p.fail(i, "number")
+	}
+	return v
+}
+
+func (p *fields) int(i int) int {
+	f := p.str(i)
+	if f == "" {
+		return 0
+	}
+	v, err := strconv.Atoi(f)
+	if err != nil {
+		p.fail(i, "integer")
+	}
+	return v
+}
+
+// status parses an A (valid) or V (invalid) field.
+func (p *fields) status(i int) bool {
+	switch p.str(i) {
+	case "A":
+		return true
+	case "V", "":
+		return false
+	}
+	p.fail(i, "status")
+	return false
+}
+
+// coord parses a latitude or longitude, ddmm.mmmm or dddmm.mmmm followed
+// by its hemisphere, into signed degrees.
+func (p *fields) coord(i int) float64 {
+	v := p.float(i)
+	deg := float64(int(v / 100))
+	v = deg + (v-deg*100)/60
+	switch p.str(i + 1) {
+	case "N", "E", "":
+	case "S", "W":
+		v = -v
+	default:
+		p.fail(i+1, "hemisphere")
+	}
+	return v
+}
+
+// clock parses a UTC time of day, hhmmss.ss, into a duration since
+// midnight.
+func (p *fields) clock(i int) time.Duration {
+	f := p.str(i)
+	if f == "" {
+		return 0
+	}
+	if len(f) < 6 {
+		p.fail(i, "time")
+		return 0
+	}
+	h, err1 := strconv.Atoi(f[0:2])
+	m, err2 := strconv.Atoi(f[2:4])
+	s, err3 := strconv.ParseFloat(f[4:], 64)
+	if err1 != nil || err2 != nil || err3 != nil || h < 0 || h > 23 || m < 0 || m > 59 || !(s >= 0 && s < 61) {
+		p.fail(i, "time")
+		return 0
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s*float64(time.Second))
+}
+
+// date parses a date, ddmmyy, with the years from 80 to 99 in the 20th
+// century.
+func (p *fields) date(i int) time.Time {
+	f := p.str(i)
+	if f == "" {
+		return time.Time{}
+	}
+	if len(f) != 6 {
+		p.fail(i, "date")
+		return time.Time{}
+	}
+	d, err1 := strconv.Atoi(f[0:2])
+	m, err2 := strconv.Atoi(f[2:4])
+	y, err3 := strconv.Atoi(f[4:6])
+	if err1 != nil || err2 != nil || err3 != nil || d < 1 || d > 31 || m < 1 || m > 12 {
+		p.fail(i, "date")
+		return time.Time{}
+	}
+	if y < 80 {
+		y += 2000
+	} else {
+		y += 1900
+	}
+	return time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC)
+}
+
+// format formats a number for a field, with the given decimals.
+func format(v float64, decimals int) string {
+	return strconv.FormatFloat(v, 'f', decimals, 64)
+}
@@ -0,0 +1,142 @@
+// Package nmea parses and generates NMEA 0183 sentences, the line
+// protocol of GPS receivers and of marine instruments such as wind,
+// depth and heading sensors.
+//
+// Parse splits a sentence into its fields and validates its checksum.
+// Decode goes further and returns the typed value of the common sentence
+// types, e.g. an RMC; the parsers of other types, including proprietary
+// sentences, can be added with Register.
+package nmea
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxLen bounds the length of the sentences, the standard limits them to
+// 82 characters but some receivers send longer proprietary sentences.
+const maxLen = 1024
+
+// Sentence is an NMEA sentence split in its fields.
+type Sentence struct {
+	// Talker identifies the kind of the sender, e.g. GP for a GPS, GN for
+	// a multi-constellation receiver or II for integrated instruments.
+	// It is "P" for proprietary sentences.
+	Talker string
+	// Type is the sentence type, e.g. RMC. For proprietary sentences it
+	// holds the manufacturer code and what follows it, e.g. GRME.
+	Type string
+	// Fields are the comma separated fields after the address.
+	Fields []string
+	// Encapsulated is true for sentences starting with '!' instead of
+	// '$', such as the AIS messages.
+	Encapsulated bool
+}
+
+// Parse parses a sentence, with or without its line ending. The checksum
+// is optional but must be valid if present.
+func Parse(s string) (Sentence, error) {
+	s = strings.TrimRight(s, "\r\n")
+	if len(s) > maxLen {
+		return Sentence{}, errors.New("nmea: sentence too long")
+	}
+	if len(s) == 0 || (s[0] != '$' && s[0] != '!') {
+		return Sentence{}, fmt.Errorf("nmea: missing start of sentence in %q", s)
+	}
+	body := s[1:]
+	if i := strings.IndexByte(body, '*'); i >= 0 {
+		sum, err := strconv.ParseUint(body[i+1:], 16, 8)
+		if err != nil || len(body[i+1:]) != 2 {
+			return Sentence{}, fmt.Errorf("nmea: invalid checksum in %q", s)
+		}
+		body = body[:i]
+		if want := Checksum(body); byte(sum) != want {
+			return Sentence{}, fmt.Errorf("nmea: checksum mismatch in %q, expected %02X", s, want)
+		}
+	}
+
+	fields := strings.Split(body, ",")
+	st := Sentence{Fields: fields[1:], Encapsulated: s[0] == '!'}
+	switch addr := fields[0]; {
+	case len(addr) > 1 && addr[0] == 'P':
+		st.Talker, st.Type = "P", addr[1:]
+	case len(addr) == 5:
+		st.Talker, st.Type = addr[:2], addr[2:]
+	default:
+		return Sentence{}, fmt.Errorf("nmea: invalid address %q", addr)
+	}
+	return st, nil
+}
+
+// String returns the sentence with its checksum, without line ending.
+func (s Sentence) String() string {
+	body := s.Talker + s.Type
+	if len(s.Fields) > 0 {
+		body += "," + strings.Join(s.Fields, ",")
+	}
+	start := "$"
+	if s.Encapsulated {
+		start = "!"
+	}
+	return fmt.Sprintf("%s%s*%02X", start, body, Checksum(body))
+}
+
+// Checksum returns the checksum of the characters between the start of a
+// sentence and the '*'.
+func Checksum(body string) byte {
+	var sum byte
+	for i := 0; i < len(body); i++ {
+		sum ^= body[i]
+	}
+	return sum
+}
+
+// key is the name under which the decoder of the sentence is registered.
+func (s Sentence) key() string {
+	if s.Talker == "P" {
+		return "P" + s.Type
+	}
+	return s.Type
+}
+
+// DecodeFunc returns the typed value of a sentence.
+type DecodeFunc func(s Sentence) (interface{}, error)
+
+var (
+	decodersMu sync.RWMutex
+	decoders   = map[string]DecodeFunc{}
+)
+
+// Register registers the decoder of a sentence type, whatever its talker,
+// replacing the decoder of the package if any. Proprietary sentences are
+// registered with their full address, e.g. PGRME.
+func Register(typ string, f DecodeFunc) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	decoders[typ] = f
+}
+
+// Decode parses a sentence and returns its typed value, see
+// Sentence.Decode.
+func Decode(s string) (interface{}, error) {
+	st, err := Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	return st.Decode()
+}
+
+// Decode returns the typed value of the sentence, e.g. an RMC, or the
+// Sentence itself if no decoder is registered for its type.
+func (s Sentence) Decode() (interface{}, error) {
+	decodersMu.RLock()
+	f := decoders[s.key()]
+	decodersMu.RUnlock()
+	if f == nil {
+		return s, nil
+	}
+	return f(s)
+}
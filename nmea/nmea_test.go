@@ -0,0 +1,215 @@
+package nmea
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+
+func TestParse(t *testing.T) {
+	s, err := Parse("$GPGLL,4916.45,N,12311.12,W,225444,A,*1D\r\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Sentence{Talker: "GP", Type: "GLL", Fields: []string{"4916.45", "N", "12311.12", "W", "225444", "A", ""}}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("Parse = %+v, want %+v", s, want)
+	}
+	if got := s.String(); got != "$GPGLL,4916.45,N,12311.12,W,225444,A,*1D" {
+		t.Errorf("String = %q", got)
+	}
+
+	if s, err := Parse("$PGRME,15.0,M,45.0,M,25.0,M*1C"); err != nil || s.Talker != "P" || s.Type != "GRME" {
+		t.Errorf("Parse = %+v, %v; want a proprietary GRME sentence", s, err)
+	}
+	if s, err := Parse("!AIVDM,1,1,,B,177KQJ5000G?tO`K>RA1wUbN0TKH,0*5C"); err != nil || !s.Encapsulated {
+		t.Errorf("Parse = %+v, %v; want an encapsulated sentence", s, err)
+	}
+
+	for _, bad := range []string{
+		"$GPGLL,4916.45,N,12311.12,W,225444,A,*1E", // checksum
+		"$GPGLL,4916.45,N*1",
+		"GPGLL,4916.45,N",
+		"$GP,1,2",
+		"",
+		"$GPTXT," + strings.Repeat("A", maxLen),
+	} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	v, err := Decode("$GPRMC,123519,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W*6A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rmc := v.(RMC)
+	if want := time.Date(1994, 3, 23, 12, 35, 19, 0, time.UTC); !rmc.Time.Equal(want) {
+		t.Errorf("Time = %v, want %v", rmc.Time, want)
+	}
+	if !rmc.Valid || !near(rmc.Latitude, 48.1173) || !near(rmc.Longitude, 11.516666666) ||
+		rmc.Speed != 22.4 || rmc.Course != 84.4 || rmc.Variation != -3.1 {
+		t.Errorf("RMC = %+v", rmc)
+	}
+
+	v, err = Decode("$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gga := v.(GGA)
+	if gga.Time != 12*time.Hour+35*time.Minute+19*time.Second || gga.Quality != 1 || gga.Satellites != 8 ||
+		gga.HDOP != 0.9 || gga.Altitude != 545.4 || gga.GeoidSeparation != 46.9 {
+		t.Errorf("GGA = %+v", gga)
+	}
+
+	v, err = Decode("$GPGSA,A,3,04,05,,09,12,,,24,,,,,2.5,1.3,2.1*39")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gsa := v.(GSA); !gsa.Auto || gsa.Fix != 3 || !reflect.DeepEqual(gsa.Satellites, []int{4, 5, 9, 12, 24}) || gsa.VDOP != 2.1 {
+		t.Errorf("GSA = %+v", gsa)
+	}
+
+	v, err = Decode("$GPGLL,4916.45,S,12311.12,W,225444,A,*00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gll := v.(GLL); !near(gll.Latitude, -49.274166666) || !near(gll.Longitude, -123.185333333) || !gll.Valid {
+		t.Errorf("GLL = %+v", gll)
+	}
+
+	if _, err := Decode("$GPRMC,123519,A,4807.038,X,01131.000,E,022.4,084.4,230394,003.1,W"); err == nil {
+		t.Error("expected an error for an invalid hemisphere")
+	}
+	if _, err := Decode("$GPGGA,123519"); err == nil {
+		t.Error("expected an error for a truncated sentence")
+	}
+	if v, err := Decode("$GPZDA,201530.00,04,07,2002,00,00"); err != nil || v.(Sentence).Type != "ZDA" {
+		t.Errorf("Decode = %v, %v; want the undecoded sentence", v, err)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	for _, v := range []interface {
+		Encode(talker string) Sentence
+	}{
+		GGA{Time: 12*time.Hour + 35*time.Minute + 19*time.Second, Latitude: 48.1173, Longitude: -11.5166, Quality: 1, Satellites: 8, HDOP: 0.9, Altitude: 545.4, GeoidSeparation: 46.9},
+		RMC{Time: time.Date(2021, 6, 5, 8, 30, 15, 0, time.UTC), Valid: true, Latitude: -33.8568, Longitude: 151.2153, Speed: 5.5, Course: 270, Variation: -12.5},
+		GLL{Latitude: 49.274166, Longitude: -123.185333, Time: 22*time.Hour + 54*time.Minute + 44*time.Second, Valid: true},
+		VTG{Course: 54.7, MagneticCourse: 34.4, Speed: 5.5, SpeedKmh: 10.2},
+		GSA{Auto: true, Fix: 3, Satellites: []int{4, 5, 9}, PDOP: 2.5, HDOP: 1.3, VDOP: 2.1},
+		MWV{Angle: 45, Relative: true, Speed: 10.5, Unit: "N", Valid: true},
+		DBT{Depth: 12.5},
+		HDT{Heading: 274.1},
+	} {
+		s := v.Encode("II").String()
+		got, err := Decode(s)
+		if err != nil {
+			t.Errorf("Decode(%q) - %v", s, err)
+			continue
+		}
+		// clear the sentences embedded by Decode to compare the values
+		val := reflect.ValueOf(&got).Elem()
+		cp := reflect.New(val.Elem().Type()).Elem()
+		cp.Set(val.Elem())
+		cp.Field(0).Set(reflect.Zero(cp.Field(0).Type()))
+		if !equal(cp.Interface(), v) {
+			t.Errorf("%s decodes as %+v, want %+v", s, cp.Interface(), v)
+		}
+	}
+}
+
+// equal compares decoded values, numbers up to the encoded decimals.
+func equal(a, b interface{}) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	for i := 0; i < va.NumField(); i++ {
+		fa, fb := va.Field(i), vb.Field(i)
+		if fa.Kind() == reflect.Float64 {
+			if math.Abs(fa.Float()-fb.Float()) > 0.05 {
+				return false
+			}
+			continue
+		}
+		if fa.Type() == reflect.TypeOf(time.Time{}) {
+			if !fa.Interface().(time.Time).Equal(fb.Interface().(time.Time)) {
+				return false
+			}
+			continue
+		}
+		if !reflect.DeepEqual(fa.Interface(), fb.Interface()) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRegister(t *testing.T) {
+	type grme struct{ Horizontal float64 }
+	Register("PGRME", func(s Sentence) (interface{}, error) {
+		p := fields{s: s}
+		return grme{p.float(0)}, p.err
+	})
+	defer Register("PGRME", nil)
+	v, err := Decode("$PGRME,15.0,M,45.0,M,25.0,M*1C")
+	if err != nil || v != (grme{15}) {
+		t.Errorf("Decode = %v, %v; want %v", v, err, grme{15})
+	}
+}
+
+func TestReader(t *testing.T) {
+	in := "$GPGLL,4916.45,N,12311.12,W,225444,A,*1D\r\n\r\n" +
+		"$GPTXT," + strings.Repeat("A", 2*maxLen) + "\r\n" +
+		"garbage\n" +
+		"$GPVTG,054.7,T,034.4,M,005.5,N,010.2,K*48"
+	r := NewReader(strings.NewReader(in))
+	var types []string
+	var errs int
+	for {
+		s, err := r.Read()
+		if err != nil {
+			if strings.HasPrefix(err.Error(), "nmea:") {
+				errs++
+				continue
+			}
+			break
+		}
+		types = append(types, s.Type)
+	}
+	if !reflect.DeepEqual(types, []string{"GLL", "VTG"}) || errs != 2 {
+		t.Errorf("read %v with %d errors, want [GLL VTG] with 2 errors", types, errs)
+	}
+}
+
+func FuzzDecode(f *testing.F) {
+	f.Add("$GPRMC,123519,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W*6A")
+	f.Add("$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47")
+	f.Add("$GPGSA,A,3,04,05,,09,12,,,24,,,,,2.5,1.3,2.1*39")
+	f.Add("$IIMWV,045.0,R,10.5,N,A")
+	f.Fuzz(func(t *testing.T, s string) {
+		Decode(s)
+		r := NewReader(strings.NewReader(s))
+		for i := 0; i <= len(s); i++ {
+			if _, err := r.Read(); err != nil && !strings.HasPrefix(err.Error(), "nmea:") {
+				return
+			}
+		}
+		t.Error("Read doesn't reach the end of the stream")
+	})
+}
+
+func BenchmarkDecode(b *testing.B) {
+	const s = "$GPRMC,123519,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W*6A"
+	b.SetBytes(int64(len(s)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Decode(s); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package nmea
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// Reader reads the sentences sent on a serial port, one per line.
+type Reader struct {
+	r *bufio.Reader
+}
+
+// NewReader returns a Reader reading from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReaderSize(r, maxLen+2)}
+}
+
+// Read reads and parses the next sentence. An error is returned for
+// invalid lines, the next call reads the next line. Empty lines are
+// skipped.
+func (r *Reader) Read() (Sentence, error) {
+	for {
+		line, err := r.r.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			for err == bufio.ErrBufferFull {
+				_, err = r.r.ReadSlice('\n')
+			}
+			if err != nil && err != io.EOF {
+				return Sentence{}, err
+			}
+			return Sentence{}, errors.New("nmea: sentence too long")
+		}
+		if err != nil && (err != io.EOF || len(line) == 0) {
+			return Sentence{}, err
+		}
+		if s := string(line); s != "\n" && s != "\r\n" {
+			return Parse(s)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package nmea
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+func init() {
+	Register("GGA", decodeGGA)
+	Register("RMC", decodeRMC)
+	Register("GLL", decodeGLL)
+	Register("VTG", decodeVTG)
+	Register("GSA", decodeGSA)
+	Register("MWV", decodeMWV)
+	Register("DBT", decodeDBT)
+	Register("HDT", decodeHDT)
+}
+
+// GGA is a GPS fix.
+type GGA struct {
+	Sentence
+	Time                time.Duration // UTC time of day
+	Latitude, Longitude float64       // degrees, negative to the south and west
+	Quality             int           // 0 for no fix, 1 for GPS, 2 for DGPS...
+	Satellites          int
+	HDOP                float64
+	Altitude            float64 // meters above the mean sea level
+	GeoidSeparation     float64 // meters
+}
+
+func decodeGGA(s Sentence) (interface{}, error) {
+	p := fields{s: s}
+	v := GGA{
+		Sentence:        s,
+		Time:            p.clock(0),
+		Latitude:        p.coord(1),
+		Longitude:       p.coord(3),
+		Quality:         p.int(5),
+		Satellites:      p.int(6),
+		HDOP:            p.float(7),
+		Altitude:        p.float(8),
+		GeoidSeparation: p.float(10),
+	}
+	return v, p.err
+}
+
+// Encode returns the sentence of the fix.
+func (v GGA) Encode(talker string) Sentence {
+	lat, ns := formatCoord(v.Latitude, "N", "S", 2)
+	lon, ew := formatCoord(v.Longitude, "E", "W", 3)
+	return Sentence{Talker: talker, Type: "GGA", Fields: []string{
+		formatClock(v.Time), lat, ns, lon, ew,
+		strconv.Itoa(v.Quality), fmt.Sprintf("%02d", v.Satellites), format(v.HDOP, 1),
+		format(v.Altitude, 1), "M", format(v.GeoidSeparation, 1), "M", "", "",
+	}}
+}
+
+// RMC is the recommended minimum navigation information.
+type RMC struct {
+	Sentence
+	Time                time.Time // UTC
+	Valid               bool
+	Latitude, Longitude float64 // degrees, negative to the south and west
+	Speed               float64 // over ground, in knots
+	Course              float64 // over ground, in degrees from the true north
+	Variation           float64 // magnetic variation in degrees, negative to the west
+}
+
+func decodeRMC(s Sentence) (interface{}, error) {
+	p := fields{s: s}
+	v := RMC{
+		Sentence:  s,
+		Valid:     p.status(1),
+		Latitude:  p.coord(2),
+		Longitude: p.coord(4),
+		Speed:     p.float(6),
+		Course:    p.float(7),
+		Variation: p.float(9),
+	}
+	clock := p.clock(0)
+	if date := p.date(8); !date.IsZero() {
+		v.Time = date.Add(clock)
+	}
+	if p.str(10) == "W" {
+		v.Variation = -v.Variation
+	}
+	return v, p.err
+}
+
+// Encode returns the sentence of the navigation information.
+func (v RMC) Encode(talker string) Sentence {
+	lat, ns := formatCoord(v.Latitude, "N", "S", 2)
+	lon, ew := formatCoord(v.Longitude, "E", "W", 3)
+	status := "V"
+	if v.Valid {
+		status = "A"
+	}
+	variation, vew := format(math.Abs(v.Variation), 1), "E"
+	if v.Variation < 0 {
+		vew = "W"
+	}
+	t := v.Time.UTC()
+	return Sentence{Talker: talker, Type: "RMC", Fields: []string{
+		formatClock(t.Sub(t.Truncate(24 * time.Hour))),
+		status, lat, ns, lon, ew, format(v.Speed, 1), format(v.Course, 1),
+		t.Format("020106"), variation, vew,
+	}}
+}
+
+// GLL is a position.
+type GLL struct {
+	Sentence
+	Latitude, Longitude float64       // degrees, negative to the south and west
+	Time                time.Duration // UTC time of day
+	Valid               bool
+}
+
+func decodeGLL(s Sentence) (interface{}, error) {
+	p := fields{s: s}
+	v := GLL{
+		Sentence:  s,
+		Latitude:  p.coord(0),
+		Longitude: p.coord(2),
+		Time:      p.clock(4),
+		Valid:     p.status(5),
+	}
+	return v, p.err
+}
+
+// Encode returns the sentence of the position.
+func (v GLL) Encode(talker string) Sentence {
+	lat, ns := formatCoord(v.Latitude, "N", "S", 2)
+	lon, ew := formatCoord(v.Longitude, "E", "W", 3)
+	status := "V"
+	if v.Valid {
+		status = "A"
+	}
+	return Sentence{Talker: talker, Type: "GLL", Fields: []string{lat, ns, lon, ew, formatClock(v.Time), status}}
+}
+
+// VTG is the course and speed over ground.
+type VTG struct {
+	Sentence
+	Course         float64 // degrees from the true north
+	MagneticCourse float64 // degrees from the magnetic north
+	Speed          float64 // knots
+	SpeedKmh       float64
+}
+
+func decodeVTG(s Sentence) (interface{}, error) {
+	p := fields{s: s}
+	v := VTG{
+		Sentence:       s,
+		Course:         p.float(0),
+		MagneticCourse: p.float(2),
+		Speed:          p.float(4),
+		SpeedKmh:       p.float(6),
+	}
+	return v, p.err
+}
+
+// Encode returns the sentence of the course and speed.
+func (v VTG) Encode(talker string) Sentence {
+	return Sentence{Talker: talker, Type: "VTG", Fields: []string{
+		format(v.Course, 1), "T", format(v.MagneticCourse, 1), "M",
+		format(v.Speed, 1), "N", format(v.SpeedKmh, 1), "K",
+	}}
+}
+
+// GSA is the state of the fix and the satellites used.
+type GSA struct {
+	Sentence
+	Auto             bool  // whether the receiver selects 2D or 3D
+	Fix              int   // 1 for no fix, 2 for 2D, 3 for 3D
+	Satellites       []int // PRN numbers
+	PDOP, HDOP, VDOP float64
+}
+
+func decodeGSA(s Sentence) (interface{}, error) {
+	p := fields{s: s}
+	v := GSA{
+		Sentence: s,
+		Auto:     p.str(0) == "A",
+		Fix:      p.int(1),
+		PDOP:     p.float(14),
+		HDOP:     p.float(15),
+		VDOP:     p.float(16),
+	}
+	for i := 2; i < 14; i++ {
+		if prn := p.int(i); prn != 0 {
+			v.Satellites = append(v.Satellites, prn)
+		}
+	}
+	return v, p.err
+}
+
+// Encode returns the sentence of the fix state. Only the first 12
+// satellites are sent.
+func (v GSA) Encode(talker string) Sentence {
+	mode := "M"
+	if v.Auto {
+		mode = "A"
+	}
+	f := []string{mode, strconv.Itoa(v.Fix)}
+	for i := 0; i < 12; i++ {
+		prn := ""
+		if i < len(v.Satellites) {
+			prn = fmt.Sprintf("%02d", v.Satellites[i])
+		}
+		f = append(f, prn)
+	}
+	f = append(f, format(v.PDOP, 1), format(v.HDOP, 1), format(v.VDOP, 1))
+	return Sentence{Talker: talker, Type: "GSA", Fields: f}
+}
+
+// MWV is a wind measurement.
+type MWV struct {
+	Sentence
+	Angle    float64 // degrees, clockwise from the bow
+	Relative bool    // relative to the vessel, true wind otherwise
+	Speed    float64
+	Unit     string // K for km/h, M for m/s, N for knots
+	Valid    bool
+}
+
+func decodeMWV(s Sentence) (interface{}, error) {
+	p := fields{s: s}
+	v := MWV{
+		Sentence: s,
+		Angle:    p.float(0),
+		Relative: p.str(1) == "R",
+		Speed:    p.float(2),
+		Unit:     p.str(3),
+		Valid:    p.status(4),
+	}
+	return v, p.err
+}
+
+// Encode returns the sentence of the wind measurement.
+func (v MWV) Encode(talker string) Sentence {
+	ref, status := "T", "V"
+	if v.Relative {
+		ref = "R"
+	}
+	if v.Valid {
+		status = "A"
+	}
+	return Sentence{Talker: talker, Type: "MWV", Fields: []string{format(v.Angle, 1), ref, format(v.Speed, 1), v.Unit, status}}
+}
+
+// DBT is the depth below the transducer.
+type DBT struct {
+	Sentence
+	Depth float64 // meters
+}
+
+func decodeDBT(s Sentence) (interface{}, error) {
+	p := fields{s: s}
+	v := DBT{Sentence: s, Depth: p.float(2)}
+	return v, p.err
+}
+
+// Encode returns the sentence of the depth, in feet, meters and fathoms.
+func (v DBT) Encode(talker string) Sentence {
+	return Sentence{Talker: talker, Type: "DBT", Fields: []string{
+		format(v.Depth/0.3048, 1), "f", format(v.Depth, 1), "M", format(v.Depth/1.8288, 1), "F",
+	}}
+}
+
+// HDT is the true heading.
+type HDT struct {
+	Sentence
+	Heading float64 // degrees from the true north
+}
+
+func decodeHDT(s Sentence) (interface{}, error) {
+	p := fields{s: s}
+	v := HDT{Sentence: s, Heading: p.float(0)}
+	return v, p.err
+}
+
+// Encode returns the sentence of the heading.
+func (v HDT) Encode(talker string) Sentence {
+	return Sentence{Talker: talker, Type: "HDT", Fields: []string{format(v.Heading, 1), "T"}}
+}
+
+// formatCoord formats signed degrees as ddmm.mmmm, with digits digits
+// for the degrees, and the hemisphere.
+func formatCoord(v float64, pos, neg string, digits int) (string, string) {
+	h := pos
+	if v < 0 {
+		v, h = -v, neg
+	}
+	deg := math.Floor(v)
+	min := math.Round((v-deg)*60*1e4) / 1e4
+	if min >= 60 {
+		deg, min = deg+1, 0
+	}
+	return fmt.Sprintf("%0*d%07.4f", digits, int(deg), min), h
+}
+
+// formatClock formats a time of day as hhmmss.ss.
+func formatClock(d time.Duration) string {
+	h := d / time.Hour
+	m := d % time.Hour / time.Minute
+	s := float64(d%time.Minute) / float64(time.Second)
+	return fmt.Sprintf("%02d%02d%05.2f", h, m, s)
+}
@@ -3,14 +3,18 @@ package main
 import (
 	"time"
 
+	"github.com/goiot/devices/i2cbus"
 	"github.com/goiot/devices/oled96x96"
-	"golang.org/x/exp/io/i2c"
 )
 
 func main() {
-	display, err := oled96x96.Open(&i2c.Devfs{
-		Dev: "/dev/i2c-1",
-	})
+	// the bus of the GPIO header, see i2cbus.Buses to list the others
+	bus, err := i2cbus.Open("primary")
+	if err != nil {
+		panic(err)
+	}
+
+	display, err := oled96x96.Open(bus)
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,175 @@
+// Package onewire defines the interface implemented by the 1-Wire bus
+// masters, and the ROM commands addressing the devices on a bus, so that
+// drivers of 1-Wire devices such as the DS18B20 can use any master.
+package onewire
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Bus is a 1-Wire bus master.
+type Bus interface {
+	// Reset sends a reset pulse and reports whether a device answered
+	// with a presence pulse.
+	Reset() (bool, error)
+
+	// WriteBit writes a bit, a 1 if bit is true.
+	WriteBit(bit bool) error
+
+	// ReadBit reads a bit.
+	ReadBit() (bool, error)
+
+	// WriteByte writes a byte, least significant bit first.
+	WriteByte(b byte) error
+
+	// ReadByte reads a byte.
+	ReadByte() (byte, error)
+}
+
+// ROM commands.
+const (
+	searchROM = 0xF0
+	matchROM  = 0x55
+	skipROM   = 0xCC
+)
+
+// Address is the 64-bit ROM code of a device: its family code in the low
+// byte, its serial number, and their CRC in the high byte.
+type Address uint64
+
+// Family returns the family code, e.g. 0x28 for a DS18B20.
+func (a Address) Family() byte { return byte(a) }
+
+// String returns the address the way Linux names the devices, e.g.
+// 28-0000075d1e4b.
+func (a Address) String() string {
+	return fmt.Sprintf("%02x-%012x", a.Family(), uint64(a)>>8&(1<<48-1))
+}
+
+func (a Address) bytes() []byte {
+	b := make([]byte, 8)
+	for i := range b {
+		b[i] = byte(a >> (8 * uint(i)))
+	}
+	return b
+}
+
+// CRC8 returns the CRC of the ROM codes and of the scratchpads of the
+// devices, polynomial x^8+x^5+x^4+1. The CRC of data followed by its CRC
+// is 0.
+func CRC8(b []byte) byte {
+	var crc byte
+	for _, v := range b {
+		for i := 0; i < 8; i++ {
+			mix := (crc ^ v) & 0x01
+			crc >>= 1
+			if mix != 0 {
+				crc ^= 0x8C
+			}
+			v >>= 1
+		}
+	}
+	return crc
+}
+
+// ErrNoDevice is returned when no device answers a reset.
+var ErrNoDevice = errors.New("no device on the 1-Wire bus")
+
+func reset(b Bus) error {
+	present, err := b.Reset()
+	if err != nil {
+		return err
+	}
+	if !present {
+		return ErrNoDevice
+	}
+	return nil
+}
+
+// Select resets the bus and addresses the device a, the following
+// function command is for it only.
+func Select(b Bus, a Address) error {
+	if err := reset(b); err != nil {
+		return err
+	}
+	if err := b.WriteByte(matchROM); err != nil {
+		return err
+	}
+	for _, v := range a.bytes() {
+		if err := b.WriteByte(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Skip resets the bus and addresses all the devices at once, e.g. to
+// start the temperature conversions of all the DS18B20 of a chain, or
+// the single device of the bus.
+func Skip(b Bus) error {
+	if err := reset(b); err != nil {
+		return err
+	}
+	return b.WriteByte(skipROM)
+}
+
+// Search returns the addresses of the devices on the bus, with the search
+// algorithm of the application note 187 of Maxim.
+func Search(b Bus) ([]Address, error) {
+	var addrs []Address
+	var rom uint64
+	last := -1 // last bit position where the 0 branch was taken
+	for {
+		if err := reset(b); err != nil {
+			if err == ErrNoDevice && len(addrs) == 0 {
+				return nil, nil
+			}
+			return addrs, err
+		}
+		if err := b.WriteByte(searchROM); err != nil {
+			return addrs, err
+		}
+		zero := -1
+		for i := 0; i < 64; i++ {
+			id, err := b.ReadBit()
+			if err != nil {
+				return addrs, err
+			}
+			cmp, err := b.ReadBit()
+			if err != nil {
+				return addrs, err
+			}
+			var dir bool
+			switch {
+			case id && cmp:
+				return addrs, errors.New("the devices stopped answering the search")
+			case id != cmp:
+				dir = id // all the devices have the same bit
+			case i < last:
+				dir = rom>>uint(i)&1 == 1
+			default:
+				dir = i == last
+			}
+			if id == cmp && !dir {
+				zero = i
+			}
+			if err := b.WriteBit(dir); err != nil {
+				return addrs, err
+			}
+			if dir {
+				rom |= 1 << uint(i)
+			} else {
+				rom &^= 1 << uint(i)
+			}
+		}
+		a := Address(rom)
+		if CRC8(a.bytes()) != 0 {
+			return addrs, fmt.Errorf("invalid CRC of the address %v", a)
+		}
+		addrs = append(addrs, a)
+		if last = zero; last < 0 {
+			return addrs, nil
+		}
+	}
+}
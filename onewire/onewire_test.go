@@ -0,0 +1,122 @@
+package onewire
+
+import (
+	"testing"
+)
+
+// bus is a fake 1-Wire bus with the devices of the addresses, answering
+// the ROM commands.
+type bus struct {
+	devices []Address
+	written []byte
+
+	search  bool
+	active  []Address // devices taking part in the search
+	bit     int
+	readCmp bool
+}
+
+func (b *bus) Reset() (bool, error) {
+	b.search, b.written = false, nil
+	return len(b.devices) > 0, nil
+}
+
+func (b *bus) ReadBit() (bool, error) {
+	// Wired-and of the bits, then of their complement.
+	v := true
+	for _, a := range b.active {
+		bit := a>>uint(b.bit)&1 == 1
+		if b.readCmp {
+			bit = !bit
+		}
+		v = v && bit
+	}
+	b.readCmp = !b.readCmp
+	return v, nil
+}
+
+func (b *bus) WriteBit(bit bool) error {
+	var active []Address
+	for _, a := range b.active {
+		if a>>uint(b.bit)&1 == 1 == bit {
+			active = append(active, a)
+		}
+	}
+	b.active = active
+	b.bit++
+	return nil
+}
+
+func (b *bus) WriteByte(v byte) error {
+	b.written = append(b.written, v)
+	if v == searchROM && len(b.written) == 1 {
+		b.search, b.active, b.bit = true, b.devices, 0
+	}
+	return nil
+}
+
+func (b *bus) ReadByte() (byte, error) { return 0xFF, nil }
+
+// address returns the address of a device of the family with its CRC.
+func address(family byte, serial uint64) Address {
+	a := Address(serial<<8 | uint64(family))
+	return a | Address(CRC8(a.bytes()[:7]))<<56
+}
+
+func TestCRC8(t *testing.T) {
+	// The example of the application note 27 of Maxim.
+	a := Address(0xA200000001B81C02)
+	if crc := CRC8(a.bytes()[:7]); crc != 0xA2 {
+		t.Errorf("CRC8 = %#02x; want 0xa2", crc)
+	}
+	if crc := CRC8(a.bytes()); crc != 0 {
+		t.Errorf("CRC8 with the CRC = %#02x; want 0", crc)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	devices := []Address{
+		address(0x28, 0x00000A1B2C3D),
+		address(0x28, 0x00000A1B2C3C),
+		address(0x10, 0x00000A1B2C3D),
+		address(0x28, 0x80000A1B2C3D),
+	}
+	b := &bus{devices: devices}
+	addrs, err := Search(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) != len(devices) {
+		t.Fatalf("found %v; want %v", addrs, devices)
+	}
+	found := map[Address]bool{}
+	for _, a := range addrs {
+		found[a] = true
+	}
+	for _, a := range devices {
+		if !found[a] {
+			t.Errorf("%v not found in %v", a, addrs)
+		}
+	}
+
+	if addrs, err := Search(&bus{}); err != nil || addrs != nil {
+		t.Errorf("Search = %v, %v on an empty bus", addrs, err)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	a := address(0x28, 0x00000A1B2C3D)
+	if s := a.String(); s != "28-00000a1b2c3d" {
+		t.Errorf("String = %v", s)
+	}
+	b := &bus{devices: []Address{a}}
+	if err := Select(b, a); err != nil {
+		t.Fatal(err)
+	}
+	if want := append([]byte{matchROM}, a.bytes()...); string(b.written) != string(want) {
+		t.Errorf("wrote %x; want %x", b.written, want)
+	}
+	if err := Skip(&bus{}); err != ErrNoDevice {
+		t.Errorf("Skip = %v on an empty bus; want ErrNoDevice", err)
+	}
+}
@@ -0,0 +1,19 @@
+package opt3001
+
+import "github.com/goiot/devices/caps"
+
+// Caps are the capabilities of the sensor.
+var Caps = caps.Capabilities{
+	Name:      "OPT3001",
+	Bus:       "i2c",
+	Addresses: []int{Addr, Addr + 1, Addr + 2, Addr + 3},
+	Measurements: []caps.Measurement{
+		{Kind: caps.Illuminance, Unit: "lx", Min: 0.01, Max: 83865.6, Resolution: 0.01},
+	},
+	PowerModes: []string{"shutdown", "single-shot", "continuous"},
+}
+
+func init() { caps.Register(Caps) }
+
+// Capabilities implements caps.Describer.
+func (s *OPT3001) Capabilities() caps.Capabilities { return Caps }
@@ -0,0 +1,132 @@
+// Package opt3001 implements a driver for the Texas Instruments OPT3001
+// ambient light sensor.
+package opt3001
+
+import (
+	"fmt"
+
+	"golang.org/x/exp/io/i2c"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+const (
+	// Addr is the I2C address of the sensor with its ADDR pin connected to
+	// the ground. It answers at 0x45, 0x46 and 0x47 with the pin connected
+	// to VDD, SDA and SCL.
+	Addr = 0x44
+
+	regResult    = 0x00
+	regConfig    = 0x01
+	regLowLimit  = 0x02
+	regHighLimit = 0x03
+	regDeviceID  = 0x7F
+
+	deviceID = 0x3001
+
+	autoRange  = 0xC000
+	longTime   = 0x0800 // 800ms conversions instead of 100ms
+	continuous = 0x0600
+	flagHigh   = 0x0040
+	flagLow    = 0x0020
+	latch      = 0x0010
+)
+
+// OPT3001 represents an OPT3001 sensor.
+type OPT3001 struct {
+	Device *i2c.Device
+}
+
+// Open opens the sensor at the address addr and starts continuous
+// auto-ranged conversions of 800ms.
+func Open(o driver.Opener, addr int) (*OPT3001, error) {
+	dev, err := i2c.Open(o, addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &OPT3001{Device: dev}
+	id, err := s.read(regDeviceID)
+	if err != nil {
+		dev.Close()
+		return nil, err
+	}
+	if id != deviceID {
+		dev.Close()
+		return nil, fmt.Errorf("unexpected device ID %#x, expected %#x", id, deviceID)
+	}
+	if err := s.write(regConfig, autoRange|longTime|continuous|latch); err != nil {
+		dev.Close()
+		return nil, fmt.Errorf("configuring the sensor failed - %v", err)
+	}
+	return s, nil
+}
+
+func (s *OPT3001) read(reg byte) (uint16, error) {
+	b := make([]byte, 2)
+	if err := s.Device.ReadReg(reg, b); err != nil {
+		return 0, err
+	}
+	return uint16(b[0])<<8 | uint16(b[1]), nil
+}
+
+func (s *OPT3001) write(reg byte, v uint16) error {
+	return s.Device.Write([]byte{reg, byte(v >> 8), byte(v)})
+}
+
+// lux converts a result or a limit, a 4-bit exponent and a 12-bit
+// mantissa.
+func lux(v uint16) float64 {
+	return 0.01 * float64(uint32(v&0x0FFF)<<(v>>12))
+}
+
+// limit returns the register of a limit in lux.
+func limit(lux float64) uint16 {
+	m := lux / 0.01
+	e := uint16(0)
+	for ; m > 0x0FFF && e < 11; e++ {
+		m /= 2
+	}
+	if m > 0x0FFF {
+		m = 0x0FFF
+	}
+	return e<<12 | uint16(m)
+}
+
+// Lux returns the ambient light in lux, from 0.01 to 83865 lux.
+func (s *OPT3001) Lux() (float64, error) {
+	v, err := s.read(regResult)
+	if err != nil {
+		return 0, err
+	}
+	return lux(v), nil
+}
+
+// SetThresholds sets the limits of the interrupt, the INT pin is pulled
+// low once a conversion is out of the low to high lux window.
+func (s *OPT3001) SetThresholds(low, high float64) error {
+	if low < 0 || high < low {
+		return fmt.Errorf("invalid thresholds %v and %v lux", low, high)
+	}
+	if err := s.write(regLowLimit, limit(low)); err != nil {
+		return err
+	}
+	return s.write(regHighLimit, limit(high))
+}
+
+// Flags reports whether a conversion was below or above the thresholds
+// since the last call, and releases the INT pin.
+func (s *OPT3001) Flags() (low, high bool, err error) {
+	v, err := s.read(regConfig)
+	if err != nil {
+		return false, false, err
+	}
+	return v&flagLow != 0, v&flagHigh != 0, nil
+}
+
+// Close shuts the sensor down and closes it.
+func (s *OPT3001) Close() error {
+	if err := s.write(regConfig, autoRange|longTime|latch); err != nil {
+		s.Device.Close()
+		return err
+	}
+	return s.Device.Close()
+}
@@ -0,0 +1,87 @@
+package opt3001
+
+import (
+	"math"
+	"testing"
+
+	"github.com/goiot/devices/i2csim"
+)
+
+// sensor is a fake OPT3001, the flags of its configuration are cleared by
+// the reads.
+type sensor struct {
+	regs [256]uint16
+	reg  byte
+}
+
+func (s *sensor) Tx(w, r []byte) error {
+	if len(w) > 0 {
+		s.reg = w[0]
+	}
+	if len(w) == 3 {
+		s.regs[s.reg] = uint16(w[1])<<8 | uint16(w[2])
+	}
+	if len(r) == 2 {
+		v := s.regs[s.reg]
+		r[0], r[1] = byte(v>>8), byte(v)
+		if s.reg == regConfig {
+			s.regs[s.reg] &^= flagLow | flagHigh
+		}
+	}
+	return nil
+}
+
+func newSensor(t *testing.T) (*OPT3001, *sensor) {
+	s := &sensor{}
+	s.regs[regDeviceID] = deviceID
+	bus := i2csim.NewBus()
+	bus.Attach(Addr, s)
+	dev, err := Open(bus, Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dev, s
+}
+
+func TestLux(t *testing.T) {
+	dev, s := newSensor(t)
+	if conf := s.regs[regConfig]; conf != 0xCE10 {
+		t.Errorf("configuration %#04x; want continuous auto-ranged conversions 0xce10", conf)
+	}
+	for _, tt := range []struct {
+		v    uint16
+		want float64
+	}{
+		{0x0001, 0.01},
+		{0x3456, 0x456 * 8 * 0.01}, // 88.8
+		{0xBFFF, 0xFFF * 2048 * 0.01},
+	} {
+		s.regs[regResult] = tt.v
+		if lux, err := dev.Lux(); err != nil || math.Abs(lux-tt.want) > 1e-9 {
+			t.Errorf("Lux = %v, %v with %#04x; want %v", lux, err, tt.v, tt.want)
+		}
+	}
+	if err := dev.Close(); err != nil || s.regs[regConfig]&continuous != 0 {
+		t.Errorf("Close = %v, the sensor is not shut down", err)
+	}
+}
+
+func TestThresholds(t *testing.T) {
+	dev, s := newSensor(t)
+	if err := dev.SetThresholds(10, 1000); err != nil {
+		t.Fatal(err)
+	}
+	if s.regs[regLowLimit] != 0x03E8 || s.regs[regHighLimit] != 0x5C35 {
+		t.Errorf("limits %#04x and %#04x; want 0x03e8 and 0x5c35", s.regs[regLowLimit], s.regs[regHighLimit])
+	}
+	if err := dev.SetThresholds(10, 5); err == nil {
+		t.Error("expected an error with a high threshold below the low one")
+	}
+	s.regs[regConfig] |= flagHigh
+	if low, high, err := dev.Flags(); err != nil || low || !high {
+		t.Errorf("Flags = %v, %v, %v; want the high flag", low, high, err)
+	}
+	if _, high, _ := dev.Flags(); high {
+		t.Error("the flag is not cleared by the read")
+	}
+}
@@ -0,0 +1,161 @@
+// Package pca9685 implements a driver for the NXP PCA9685 16 channels 12
+// bits PWM controller, found on the servo and motor boards. Its channels
+// implement pwm.Output.
+package pca9685
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/pwm"
+	"golang.org/x/exp/io/i2c"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+// Addr is the I2C address of the controller with its address pins low.
+// The pins give addresses up to 0x7F, except the 0x70 all call address.
+const Addr = 0x40
+
+const (
+	regMode1    = 0x00
+	regMode2    = 0x01
+	regLED0     = 0x06 // ON_L, ON_H, OFF_L and OFF_H of each channel
+	regPrescale = 0xFE
+
+	mode1Restart = 0x80
+	mode1AI      = 0x20 // register auto increment
+	mode1Sleep   = 0x10
+	mode2OutDrv  = 0x04 // totem pole outputs
+
+	fullOn = 0x1000 // bit 12 of ON and OFF
+
+	oscillator = 25000000
+
+	// wakeup is the time the oscillator takes to start.
+	wakeup = 500 * time.Microsecond
+)
+
+// Channels is the number of channels of the controller.
+const Channels = 16
+
+// PCA9685 represents a PCA9685. It can be used by multiple goroutines.
+type PCA9685 struct {
+	Device *i2c.Device
+	// Clock times the start of the oscillator, clock.Real if nil.
+	Clock clock.Clock
+
+	mu sync.Mutex
+}
+
+// Open opens the controller at addr, with all its channels off and its
+// totem pole outputs enabled, at the 200Hz of its power up.
+func Open(o driver.Opener, addr int) (*PCA9685, error) {
+	dev, err := i2c.Open(o, addr)
+	if err != nil {
+		return nil, err
+	}
+	p := &PCA9685{Device: dev}
+	if err := p.init(); err != nil {
+		dev.Close()
+		return nil, fmt.Errorf("initializing the PCA9685 failed - %v", err)
+	}
+	return p, nil
+}
+
+func (p *PCA9685) init() error {
+	for ch := 0; ch < Channels; ch++ {
+		if err := p.set(ch, 0, fullOn); err != nil {
+			return err
+		}
+	}
+	if err := p.Device.WriteReg(regMode2, []byte{mode2OutDrv}); err != nil {
+		return err
+	}
+	if err := p.Device.WriteReg(regMode1, []byte{mode1AI}); err != nil {
+		return err
+	}
+	clock.Or(p.Clock).Sleep(wakeup)
+	return nil
+}
+
+// SetFrequency sets the frequency of the outputs, from 24 to 1526Hz,
+// rounded to what the prescaler of the 25MHz oscillator gives.
+func (p *PCA9685) SetFrequency(hz float64) error {
+	prescale := math.Floor(oscillator/(4096*hz)+0.5) - 1
+	if prescale < 3 || prescale > 255 {
+		return fmt.Errorf("invalid frequency %vHz", hz)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// the prescaler is written while the oscillator sleeps
+	for _, w := range [][]byte{
+		{regMode1, mode1AI | mode1Sleep},
+		{regPrescale, byte(prescale)},
+		{regMode1, mode1AI},
+	} {
+		if err := p.Device.Write(w); err != nil {
+			return err
+		}
+	}
+	clock.Or(p.Clock).Sleep(wakeup)
+	return p.Device.WriteReg(regMode1, []byte{mode1AI | mode1Restart})
+}
+
+// Set sets the raw counts, from 0 to 4095, at which the channel ch goes
+// high and low in each period. Bit 12 (0x1000) of on or off sets the
+// channel fully on or off.
+func (p *PCA9685) Set(ch int, on, off uint16) error {
+	if ch < 0 || ch >= Channels {
+		return fmt.Errorf("channel %d is out of range, the PCA9685 has %d channels", ch, Channels)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.set(ch, on, off)
+}
+
+func (p *PCA9685) set(ch int, on, off uint16) error {
+	return p.Device.WriteReg(byte(regLED0+4*ch), []byte{byte(on), byte(on >> 8), byte(off), byte(off >> 8)})
+}
+
+// SetDuty sets the channel ch high for the fraction duty of the period,
+// fully off at 0 and fully on at 1.
+func (p *PCA9685) SetDuty(ch int, duty float64) error {
+	switch {
+	case duty <= 0:
+		return p.Set(ch, 0, fullOn)
+	case duty >= 1:
+		return p.Set(ch, fullOn, 0)
+	}
+	return p.Set(ch, 0, uint16(math.Floor(duty*4096+0.5)))
+}
+
+// Output returns the channel ch as a pwm.Output.
+func (p *PCA9685) Output(ch int) (pwm.Output, error) {
+	if ch < 0 || ch >= Channels {
+		return nil, fmt.Errorf("channel %d is out of range, the PCA9685 has %d channels", ch, Channels)
+	}
+	return output{p, ch}, nil
+}
+
+type output struct {
+	p  *PCA9685
+	ch int
+}
+
+func (o output) SetDuty(duty float64) error { return o.p.SetDuty(o.ch, duty) }
+
+// Close turns all the channels off and closes the device.
+func (p *PCA9685) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for ch := 0; ch < Channels; ch++ {
+		if err := p.set(ch, 0, fullOn); err != nil {
+			p.Device.Close()
+			return err
+		}
+	}
+	return p.Device.Close()
+}
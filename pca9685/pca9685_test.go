@@ -0,0 +1,83 @@
+package pca9685
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/i2csim"
+	"github.com/goiot/devices/pwm"
+)
+
+var _ pwm.Output = output{}
+
+func newController(t *testing.T) (*PCA9685, *i2csim.Registers) {
+	regs := i2csim.NewRegisters()
+	bus := i2csim.NewBus()
+	bus.Attach(Addr, regs)
+	p, err := Open(bus, Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := clock.NewFake(time.Time{})
+	c.SetAutoSleep(true)
+	p.Clock = c
+	return p, regs
+}
+
+func TestOpen(t *testing.T) {
+	_, regs := newController(t)
+	if m1, m2 := regs.Get(regMode1, 1)[0], regs.Get(regMode2, 1)[0]; m1 != mode1AI || m2 != mode2OutDrv {
+		t.Errorf("MODE1 %#x, MODE2 %#x; want 0x20, 0x04", m1, m2)
+	}
+	if got := regs.Get(regLED0+4*15, 4); !bytes.Equal(got, []byte{0, 0, 0, 0x10}) {
+		t.Errorf("channel 15 set to %x; want fully off", got)
+	}
+}
+
+func TestFrequency(t *testing.T) {
+	p, regs := newController(t)
+	for hz, want := range map[float64]byte{50: 121, 1600: 3} {
+		if err := p.SetFrequency(hz); err != nil {
+			t.Fatal(err)
+		}
+		if got := regs.Get(regPrescale, 1)[0]; got != want {
+			t.Errorf("prescaler at %vHz = %d; want %d", hz, got, want)
+		}
+		if m1 := regs.Get(regMode1, 1)[0]; m1 != mode1AI|mode1Restart {
+			t.Errorf("MODE1 %#x; want awake and restarted", m1)
+		}
+	}
+	if err := p.SetFrequency(2000); err == nil {
+		t.Error("expected an error at 2kHz")
+	}
+}
+
+func TestDuty(t *testing.T) {
+	p, regs := newController(t)
+	out, err := p.Output(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		duty float64
+		want []byte
+	}{
+		{0.5, []byte{0, 0, 0x00, 0x08}},
+		{0.25, []byte{0, 0, 0x00, 0x04}},
+		{1, []byte{0, 0x10, 0, 0}},
+		{0, []byte{0, 0, 0, 0x10}},
+	}
+	for _, tt := range tests {
+		if err := out.SetDuty(tt.duty); err != nil {
+			t.Fatal(err)
+		}
+		if got := regs.Get(regLED0+12, 4); !bytes.Equal(got, tt.want) {
+			t.Errorf("duty %v: channel 3 set to %x; want %x", tt.duty, got, tt.want)
+		}
+	}
+	if _, err := p.Output(16); err == nil {
+		t.Error("expected an error with channel 16")
+	}
+}
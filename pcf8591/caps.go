@@ -0,0 +1,19 @@
+package pcf8591
+
+import "github.com/goiot/devices/caps"
+
+// Caps are the capabilities of the converter with the reference voltage of
+// 3.3V of the modules powered by a Raspberry Pi.
+var Caps = caps.Capabilities{
+	Name:      "PCF8591",
+	Bus:       "i2c",
+	Addresses: []int{Addr, Addr + 1, Addr + 2, Addr + 3, Addr + 4, Addr + 5, Addr + 6, Addr + 7},
+	Measurements: []caps.Measurement{
+		{Kind: caps.Voltage, Unit: "V", Min: 0, Max: 3.3, Resolution: 3.3 / 256, Channels: 4},
+	},
+}
+
+func init() { caps.Register(Caps) }
+
+// Capabilities implements caps.Describer.
+func (p *PCF8591) Capabilities() caps.Capabilities { return Caps }
@@ -0,0 +1,112 @@
+// Package pcf8591 implements a driver for the NXP PCF8591 8-bit ADC and
+// DAC, found on many "AD/DA" modules. The converter implements analog.ADC
+// with its four inputs used single-ended, and its output is an
+// analog.DAC.
+package pcf8591
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/goiot/devices/analog"
+	"golang.org/x/exp/io/i2c"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+// Addr is the I2C address of the converter with its address pins low, the
+// one of most modules. It answers up to 0x4F with the pins high.
+const Addr = 0x48
+
+const outputEnable = 0x40 // in the control byte, also keeps the DAC value
+
+// PCF8591 represents a PCF8591 converter.
+type PCF8591 struct {
+	Device *i2c.Device
+
+	mu  sync.Mutex
+	out bool // whether the analog output is enabled
+}
+
+// Open opens the converter at addr.
+func Open(o driver.Opener, addr int) (*PCF8591, error) {
+	dev, err := i2c.Open(o, addr)
+	if err != nil {
+		return nil, err
+	}
+	return &PCF8591{Device: dev}, nil
+}
+
+func (p *PCF8591) control(ch int) byte {
+	if p.out {
+		return outputEnable | byte(ch)
+	}
+	return byte(ch)
+}
+
+// Read runs a conversion of the channel ch, between 0 and 255 from the
+// ground to the reference voltage, the VREF pin of the module.
+func (p *PCF8591) Read(ch int) (int, error) {
+	if err := analog.CheckChannel(ch, 4); err != nil {
+		return 0, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// The conversion starts with the read, the first byte is the result
+	// of the previous one.
+	b := make([]byte, 2)
+	if err := p.Device.ReadReg(p.control(ch), b); err != nil {
+		return 0, err
+	}
+	return int(b[1]), nil
+}
+
+// Channels returns 4.
+func (p *PCF8591) Channels() int { return 4 }
+
+// Resolution returns 8.
+func (p *PCF8591) Resolution() int { return 8 }
+
+// DAC returns the analog output, whose value is kept between conversions.
+func (p *PCF8591) DAC() analog.DAC {
+	return dac{p}
+}
+
+type dac struct {
+	p *PCF8591
+}
+
+func (d dac) Write(ch int, v int) error {
+	if err := analog.CheckChannel(ch, 1); err != nil {
+		return err
+	}
+	if v < 0 || v > 255 {
+		return fmt.Errorf("invalid DAC value %d, should be between 0-255", v)
+	}
+	d.p.mu.Lock()
+	defer d.p.mu.Unlock()
+	if err := d.p.Device.Write([]byte{outputEnable, byte(v)}); err != nil {
+		return err
+	}
+	d.p.out = true
+	return nil
+}
+
+func (dac) Channels() int   { return 1 }
+func (dac) Resolution() int { return 8 }
+
+// DisableOutput turns the analog output off, it is enabled by the next
+// write to the DAC.
+func (p *PCF8591) DisableOutput() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.Device.Write([]byte{0x00}); err != nil {
+		return err
+	}
+	p.out = false
+	return nil
+}
+
+// Close closes the converter.
+func (p *PCF8591) Close() error {
+	return p.Device.Close()
+}
@@ -0,0 +1,70 @@
+package pcf8591
+
+import (
+	"testing"
+
+	"github.com/goiot/devices/analog"
+	"github.com/goiot/devices/i2csim"
+)
+
+// converter is a fake PCF8591, a read returns the previous conversion
+// and the one of the channel of the control byte.
+type converter struct {
+	inputs  [4]byte
+	control byte
+	dac     byte
+	last    byte
+}
+
+func (c *converter) Tx(w, r []byte) error {
+	if len(w) > 0 {
+		c.control = w[0]
+	}
+	if len(w) > 1 {
+		c.dac = w[1]
+	}
+	for i := range r {
+		r[i] = c.last
+		c.last = c.inputs[c.control&0x03]
+	}
+	return nil
+}
+
+func TestConverter(t *testing.T) {
+	c := &converter{inputs: [4]byte{10, 20, 30, 40}}
+	bus := i2csim.NewBus()
+	bus.Attach(Addr, c)
+	p, err := Open(bus, Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var _ analog.ADC = p
+
+	for _, ch := range []int{3, 0, 2} {
+		if v, err := p.Read(ch); err != nil || v != int(c.inputs[ch]) {
+			t.Errorf("channel %d = %d, %v; want %d", ch, v, err, c.inputs[ch])
+		}
+	}
+	if _, err := p.Read(4); err == nil {
+		t.Error("expected an error with channel 4")
+	}
+
+	dac := p.DAC()
+	if err := dac.Write(0, 128); err != nil {
+		t.Fatal(err)
+	}
+	if c.control != outputEnable || c.dac != 128 {
+		t.Errorf("control %#x and DAC %d; want %#x and 128", c.control, c.dac, outputEnable)
+	}
+	// The conversions keep the output enabled.
+	p.Read(1)
+	if c.control != outputEnable|1 {
+		t.Errorf("control %#x; want the output enabled", c.control)
+	}
+	if err := dac.Write(0, 256); err == nil {
+		t.Error("expected an error with 256")
+	}
+	if err := p.DisableOutput(); err != nil || c.control&outputEnable != 0 {
+		t.Errorf("DisableOutput = %v, control %#x", err, c.control)
+	}
+}
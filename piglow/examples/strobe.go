@@ -3,12 +3,18 @@ package main
 import (
 	"time"
 
+	"github.com/goiot/devices/i2cbus"
 	"github.com/goiot/devices/piglow"
-	"golang.org/x/exp/io/i2c"
 )
 
 func main() {
-	p, err := piglow.Open(&i2c.Devfs{Dev: "/dev/i2c-1"})
+	// the bus of the GPIO header, see i2cbus.Buses to list the others
+	bus, err := i2cbus.Open("primary")
+	if err != nil {
+		panic(err)
+	}
+
+	p, err := piglow.Open(bus)
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,21 @@
+package pms5003
+
+import "github.com/goiot/devices/caps"
+
+// Caps are the capabilities of the sensor, the concentrations are accurate
+// up to 500µg/m³.
+var Caps = caps.Capabilities{
+	Name: "PMS5003",
+	Bus:  "uart",
+	Measurements: []caps.Measurement{
+		{Kind: caps.PM1, Unit: "ug/m3", Min: 0, Max: 1000, Resolution: 1},
+		{Kind: caps.PM25, Unit: "ug/m3", Min: 0, Max: 1000, Resolution: 1},
+		{Kind: caps.PM10, Unit: "ug/m3", Min: 0, Max: 1000, Resolution: 1},
+	},
+	PowerModes: []string{"active", "passive", "sleep"},
+}
+
+func init() { caps.Register(Caps) }
+
+// Capabilities implements caps.Describer.
+func (p *PMS5003) Capabilities() caps.Capabilities { return Caps }
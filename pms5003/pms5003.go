@@ -0,0 +1,111 @@
+// Package pms5003 implements a driver for the Plantower PMS5003 particulate
+// matter sensor, which sends a measurement every second on its serial
+// output.
+//
+// The serial port must be configured by the caller at 9600 bauds, 8N1, e.g.
+// with
+//
+//	stty -F /dev/ttyAMA0 9600 cs8 -parenb -cstopb raw
+package pms5003
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	start1   = 0x42
+	start2   = 0x4D
+	frameLen = 28 // length field of the frames, checksum included
+
+	// maxSkip is the number of bytes skipped looking for a frame before
+	// giving up, a frame is sent every second.
+	maxSkip = 4 * (frameLen + 4)
+)
+
+// Reading is the content of a frame.
+type Reading struct {
+	// Concentrations in µg/m³ of the particles of less than 1, 2.5 and
+	// 10µm, for standard particles (CF=1).
+	PM1, PM25, PM10 int
+
+	// The same concentrations in atmospheric environment, these are the
+	// values to compare to the air quality standards.
+	PM1Atm, PM25Atm, PM10Atm int
+
+	// Particles is the number of particles in 0.1L of air above 0.3, 0.5,
+	// 1, 2.5, 5 and 10µm.
+	Particles [6]int
+}
+
+// PMS5003 reads the frames of a sensor.
+type PMS5003 struct {
+	r *bufio.Reader
+}
+
+// New returns a PMS5003 reading from the serial port r.
+func New(r io.Reader) *PMS5003 {
+	return &PMS5003{r: bufio.NewReader(r)}
+}
+
+// Read waits for the next frame. An error is returned for corrupted
+// frames, the next call looks for the next frame.
+func (p *PMS5003) Read() (Reading, error) {
+	if err := p.sync(); err != nil {
+		return Reading{}, err
+	}
+	frame := make([]byte, 4+frameLen)
+	frame[0], frame[1] = start1, start2
+	if _, err := io.ReadFull(p.r, frame[2:4]); err != nil {
+		return Reading{}, err
+	}
+	if n := int(frame[2])<<8 | int(frame[3]); n != frameLen {
+		return Reading{}, fmt.Errorf("invalid frame length %d", n)
+	}
+	if _, err := io.ReadFull(p.r, frame[4:]); err != nil {
+		return Reading{}, err
+	}
+	sum := 0
+	for _, b := range frame[:len(frame)-2] {
+		sum += int(b)
+	}
+	if want := word(frame[len(frame)-2:]); sum&0xFFFF != want {
+		return Reading{}, fmt.Errorf("checksum mismatch, got %#x, want %#x", sum&0xFFFF, want)
+	}
+
+	d := frame[4:]
+	r := Reading{
+		PM1:     word(d[0:]),
+		PM25:    word(d[2:]),
+		PM10:    word(d[4:]),
+		PM1Atm:  word(d[6:]),
+		PM25Atm: word(d[8:]),
+		PM10Atm: word(d[10:]),
+	}
+	for i := range r.Particles {
+		r.Particles[i] = word(d[12+2*i:])
+	}
+	return r, nil
+}
+
+// sync skips the bytes until the start of a frame.
+func (p *PMS5003) sync() error {
+	var prev byte
+	for i := 0; i < maxSkip; i++ {
+		b, err := p.r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if prev == start1 && b == start2 {
+			return nil
+		}
+		prev = b
+	}
+	return errors.New("no frame found, check the wiring and the baud rate")
+}
+
+func word(b []byte) int {
+	return int(b[0])<<8 | int(b[1])
+}
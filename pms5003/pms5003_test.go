@@ -0,0 +1,92 @@
+package pms5003
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func frame(words ...int) []byte {
+	b := []byte{start1, start2, 0, frameLen}
+	for i := 0; i < 13; i++ {
+		var w int
+		if i < len(words) {
+			w = words[i]
+		}
+		b = append(b, byte(w>>8), byte(w))
+	}
+	sum := 0
+	for _, v := range b {
+		sum += int(v)
+	}
+	return append(b, byte(sum>>8), byte(sum))
+}
+
+func TestRead(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write([]byte{0x00, 0x4D, 0x42}) // garbage, including a partial start
+	stream.Write(frame(5, 8, 9, 5, 8, 9, 1200, 350, 60, 4, 1, 0))
+	bad := frame(1, 2, 3)
+	bad[10] ^= 0xFF
+	stream.Write(bad)
+	stream.Write(frame(6, 10, 12, 6, 10, 12))
+
+	p := New(&stream)
+	r, err := p.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Reading{
+		PM1: 5, PM25: 8, PM10: 9,
+		PM1Atm: 5, PM25Atm: 8, PM10Atm: 9,
+		Particles: [6]int{1200, 350, 60, 4, 1, 0},
+	}
+	if r != want {
+		t.Errorf("got %+v, want %+v", r, want)
+	}
+	if _, err := p.Read(); err == nil {
+		t.Error("expected a checksum error")
+	}
+	if r, err := p.Read(); err != nil || r.PM25Atm != 10 {
+		t.Errorf("got %+v, %v after a corrupted frame", r, err)
+	}
+	if _, err := p.Read(); err != io.EOF {
+		t.Errorf("got %v at the end of the stream, want EOF", err)
+	}
+}
+
+func FuzzRead(f *testing.F) {
+	f.Add(frame(5, 8, 9, 5, 8, 9, 1200, 350, 60, 4, 1, 0))
+	f.Add(append([]byte{start1, start2, 0xFF, 0xFF}, frame(1)...))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		p := New(bytes.NewReader(data))
+		// every call consumes at least a byte
+		for i := 0; i <= len(data); i++ {
+			r, err := p.Read()
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return
+			}
+			if err == nil && (r.PM25 < 0 || r.PM25 > 0xFFFF) {
+				t.Errorf("invalid reading %+v", r)
+			}
+		}
+		t.Error("Read doesn't reach the end of the stream")
+	})
+}
+
+func BenchmarkRead(b *testing.B) {
+	f := frame(5, 8, 9, 5, 8, 9, 1200, 350, 60, 4, 1, 0)
+	stream := bytes.Repeat(f, 1000)
+	b.SetBytes(int64(len(f)))
+	b.ReportAllocs()
+	r := bytes.NewReader(stream)
+	p := New(r)
+	for i := 0; i < b.N; i++ {
+		if r.Len() == 0 {
+			r.Reset(stream)
+		}
+		if _, err := p.Read(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+// Package pn532 implements a driver for the NXP PN532 NFC controller, on
+// its UART (HSU). It reads the ISO14443-A cards: their UID, and the
+// ISO7816 APDUs of the ISO-DEP cards, such as the transit, payment and
+// access cards, exchanged with the iso7816 package.
+//
+// The interface of the module must be set to HSU with its switches or
+// jumpers, and the serial port configured by the caller at 115200 bauds,
+// 8N1, e.g. with
+//
+//	stty -F /dev/ttyAMA0 115200 cs8 -parenb -cstopb raw
+package pn532
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/goiot/devices/clock"
+)
+
+const (
+	tfiHost = 0xD4 // frames to the PN532
+	tfiPN   = 0xD5 // frames from the PN532
+
+	cmdGetFirmwareVersion  = 0x02
+	cmdSAMConfiguration    = 0x14
+	cmdRFConfiguration     = 0x32
+	cmdInDataExchange      = 0x40
+	cmdInListPassiveTarget = 0x4A
+	cmdInRelease           = 0x52
+
+	rfMaxRetries   = 0x05
+	br106TypeA     = 0x00
+	statusMoreInfo = 0x40
+
+	// timeout is the time waited for an answer, the PN532 gives up on the
+	// cards after about a second.
+	timeout = 2 * time.Second
+
+	// poll is the interval the field is polled for a card at.
+	poll = 100 * time.Millisecond
+)
+
+// wakeup wakes the PN532 up from its power down mode on the HSU, before
+// the first command.
+var wakeup = []byte{0x55, 0x55, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+
+var (
+	// ErrNoCard is returned when there is no card in the field.
+	ErrNoCard = errors.New("pn532: no card in the field")
+
+	errNACK = errors.New("pn532: the command was not acknowledged")
+)
+
+// Error is an error status of the PN532 exchanging with a card.
+type Error byte
+
+var errorMessages = map[Error]string{
+	0x01: "the card did not answer",
+	0x02: "CRC error",
+	0x03: "parity error",
+	0x05: "framing error",
+	0x06: "bit collision",
+	0x07: "buffer too small",
+	0x0B: "RF protocol error",
+	0x10: "invalid parameter",
+	0x13: "DEP format error",
+	0x14: "authentication error",
+	0x25: "invalid device state",
+	0x27: "command not acceptable",
+	0x29: "card released",
+	0x2B: "card removed",
+}
+
+func (e Error) Error() string {
+	if s, ok := errorMessages[e]; ok {
+		return "pn532: " + s
+	}
+	return fmt.Sprintf("pn532: error %#x", byte(e))
+}
+
+// deadliner is implemented by the serial ports supporting read timeouts,
+// such as *os.File.
+type deadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// PN532 represents a PN532 in reader mode. It can be used by multiple
+// goroutines.
+type PN532 struct {
+	// Clock times the polling for a card, clock.Real if nil.
+	Clock clock.Clock
+
+	port io.ReadWriter
+
+	mu sync.Mutex
+}
+
+// New returns the PN532 on the serial port, woken and ready to read the
+// cards.
+func New(port io.ReadWriter) (*PN532, error) {
+	p := &PN532{port: port}
+	if _, err := port.Write(wakeup); err != nil {
+		return nil, fmt.Errorf("waking the PN532 up failed - %v", err)
+	}
+	// normal mode, without the secure access module
+	if _, err := p.command(cmdSAMConfiguration, 0x01, 0x14, 0x01); err != nil {
+		return nil, fmt.Errorf("configuring the PN532 failed - %v", err)
+	}
+	// two activation retries instead of waiting for a card forever
+	if _, err := p.command(cmdRFConfiguration, rfMaxRetries, 0xFF, 0x01, 0x02); err != nil {
+		return nil, fmt.Errorf("configuring the PN532 failed - %v", err)
+	}
+	return p, nil
+}
+
+// frame returns the information frame of data, in the extended format
+// beyond 255 bytes.
+func frame(data ...byte) []byte {
+	b := []byte{0x00, 0x00, 0xFF}
+	if n := len(data); n > 0xFF {
+		b = append(b, 0xFF, 0xFF, byte(n>>8), byte(n), -byte(n>>8)-byte(n))
+	} else {
+		b = append(b, byte(n), -byte(n))
+	}
+	sum := byte(0)
+	for _, v := range data {
+		sum += v
+	}
+	b = append(b, data...)
+	return append(b, -sum, 0x00)
+}
+
+// readFrame reads the next frame, and returns its data; nil for an ACK.
+func (p *PN532) readFrame() ([]byte, error) {
+	// the preamble and the start code
+	b := make([]byte, 1)
+	for prev := byte(0xFF); ; {
+		if _, err := io.ReadFull(p.port, b); err != nil {
+			return nil, err
+		}
+		if prev == 0x00 && b[0] == 0xFF {
+			break
+		}
+		prev = b[0]
+	}
+	hdr := make([]byte, 2)
+	if _, err := io.ReadFull(p.port, hdr); err != nil {
+		return nil, err
+	}
+	n := int(hdr[0])
+	switch {
+	case hdr[0] == 0x00 && hdr[1] == 0xFF:
+		_, err := io.ReadFull(p.port, b) // postamble
+		return nil, err
+	case hdr[0] == 0xFF && hdr[1] == 0x00:
+		return nil, errNACK
+	case hdr[0] == 0xFF && hdr[1] == 0xFF:
+		ext := make([]byte, 3)
+		if _, err := io.ReadFull(p.port, ext); err != nil {
+			return nil, err
+		}
+		if ext[0]+ext[1]+ext[2] != 0 {
+			return nil, errors.New("invalid length checksum in the frame of the PN532")
+		}
+		n = int(ext[0])<<8 | int(ext[1])
+	case hdr[0]+hdr[1] != 0:
+		return nil, errors.New("invalid length checksum in the frame of the PN532")
+	}
+	data := make([]byte, n+2)
+	if _, err := io.ReadFull(p.port, data); err != nil {
+		return nil, err
+	}
+	sum := byte(0)
+	for _, v := range data[:n+1] {
+		sum += v
+	}
+	if sum != 0 {
+		return nil, errors.New("invalid checksum in the frame of the PN532")
+	}
+	if n == 1 && data[0] == 0x7F {
+		return nil, errors.New("pn532: syntax error in the command")
+	}
+	return data[:n], nil
+}
+
+// command sends the command cmd with its parameters and returns the
+// parameters of the response.
+func (p *PN532) command(cmd byte, params ...byte) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, err := p.port.Write(frame(append([]byte{tfiHost, cmd}, params...)...)); err != nil {
+		return nil, err
+	}
+	if d, ok := p.port.(deadliner); ok {
+		d.SetReadDeadline(time.Now().Add(timeout))
+		defer d.SetReadDeadline(time.Time{})
+	}
+	if ack, err := p.readFrame(); err != nil {
+		return nil, fmt.Errorf("reading the acknowledgement of the PN532 failed - %v", err)
+	} else if ack != nil {
+		return nil, errors.New("missing acknowledgement of the PN532")
+	}
+	b, err := p.readFrame()
+	if err != nil {
+		return nil, fmt.Errorf("reading the answer of the PN532 failed - %v", err)
+	}
+	if len(b) < 2 || b[0] != tfiPN || b[1] != cmd+1 {
+		return nil, fmt.Errorf("invalid answer % X of the PN532", b)
+	}
+	return b[2:], nil
+}
+
+// Firmware returns the version and the revision of the firmware.
+func (p *PN532) Firmware() (version, revision int, err error) {
+	b, err := p.command(cmdGetFirmwareVersion)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(b) < 4 || b[0] != 0x32 {
+		return 0, 0, fmt.Errorf("invalid firmware version % X, not a PN532", b)
+	}
+	return int(b[1]), int(b[2]), nil
+}
+
+// Card returns the card in the field, or ErrNoCard. The card is selected
+// until it is released or another card is returned.
+func (p *PN532) Card() (*Card, error) {
+	b, err := p.command(cmdInListPassiveTarget, 1, br106TypeA)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 || b[0] == 0 {
+		return nil, ErrNoCard
+	}
+	if len(b) < 6 || len(b) < 6+int(b[5]) {
+		return nil, fmt.Errorf("invalid card % X", b)
+	}
+	c := &Card{
+		ATQA: uint16(b[2])<<8 | uint16(b[3]),
+		SAK:  b[4],
+		UID:  append([]byte(nil), b[6:6+b[5]]...),
+		p:    p,
+		tg:   b[1],
+	}
+	// the length of the ATS counts itself
+	if ats := b[6+b[5]:]; c.ISODEP() && len(ats) > 0 && int(ats[0]) <= len(ats) && ats[0] > 0 {
+		c.ATS = append([]byte(nil), ats[1:ats[0]]...)
+	}
+	return c, nil
+}
+
+// WaitCard waits for a card in the field until ctx is done.
+func (p *PN532) WaitCard(ctx context.Context) (*Card, error) {
+	c := clock.Or(p.Clock)
+	for {
+		card, err := p.Card()
+		if err != ErrNoCard {
+			return card, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-c.After(poll):
+		}
+	}
+}
+
+// Close closes the port if it is an io.Closer.
+func (p *PN532) Close() error {
+	if c, ok := p.port.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Card is an ISO14443-A card selected by the PN532. It implements
+// iso7816.Card if it is an ISO-DEP card.
+type Card struct {
+	UID  []byte // 4, 7 or 10 bytes
+	ATQA uint16
+	SAK  byte
+	ATS  []byte // answer to select of the ISO-DEP cards, without its length
+
+	p  *PN532
+	tg byte
+}
+
+// ISODEP reports whether the card speaks ISO14443-4, and exchanges the
+// APDUs.
+func (c *Card) ISODEP() bool { return c.SAK&0x20 != 0 }
+
+// Transmit implements iso7816.Card.
+func (c *Card) Transmit(apdu []byte) ([]byte, error) {
+	if !c.ISODEP() {
+		return nil, fmt.Errorf("the card of SAK %#x does not exchange APDUs", c.SAK)
+	}
+	var r []byte
+	for data := apdu; ; data = nil {
+		b, err := c.p.command(cmdInDataExchange, append([]byte{c.tg}, data...)...)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) == 0 {
+			return nil, errors.New("invalid answer of the PN532")
+		}
+		if e := Error(b[0] & 0x3F); e != 0 {
+			return nil, e
+		}
+		r = append(r, b[1:]...)
+		if b[0]&statusMoreInfo == 0 {
+			return r, nil
+		}
+	}
+}
+
+// Release deselects the card.
+func (c *Card) Release() error {
+	b, err := c.p.command(cmdInRelease, c.tg)
+	if err != nil {
+		return err
+	}
+	if len(b) > 0 && b[0]&0x3F != 0 {
+		return Error(b[0] & 0x3F)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package pn532
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/iso7816"
+)
+
+var _ iso7816.Card = (*Card)(nil)
+
+// reader is a fake PN532 answering the frames written, with a card in its
+// field after polls empty polls.
+type reader struct {
+	in, out  []byte
+	awake    bool
+	polls    int
+	sak      byte
+	apdus    map[string]string // hex responses of the hex APDUs
+	released bool
+}
+
+func newReader() *reader {
+	return &reader{sak: 0x20, apdus: map[string]string{}}
+}
+
+func (r *reader) Write(b []byte) (int, error) {
+	r.in = append(r.in, b...)
+	for {
+		i := bytes.Index(r.in, []byte{0x00, 0xFF})
+		if i < 0 || len(r.in) < i+4 {
+			return len(b), nil
+		}
+		if bytes.IndexByte(r.in[:i], 0x55) >= 0 {
+			r.awake = true
+		}
+		n := int(r.in[i+2])
+		data := r.in[i+4:]
+		if len(data) < n+2 {
+			return len(b), nil
+		}
+		r.in = data[n+2:]
+		if !r.awake {
+			continue // asleep
+		}
+		r.out = append(r.out, 0x00, 0x00, 0xFF, 0x00, 0xFF, 0x00)
+		if ans := r.answer(data[1], data[2:n]); ans != nil {
+			r.out = append(r.out, frame(append([]byte{tfiPN, data[1] + 1}, ans...)...)...)
+		}
+	}
+}
+
+func (r *reader) answer(cmd byte, params []byte) []byte {
+	switch cmd {
+	case cmdGetFirmwareVersion:
+		return []byte{0x32, 1, 6, 7}
+	case cmdSAMConfiguration, cmdRFConfiguration:
+		return []byte{}
+	case cmdInListPassiveTarget:
+		if r.polls > 0 {
+			r.polls--
+			return []byte{0}
+		}
+		r.released = false
+		// a 7 bytes UID and the ATS 05 78 80 70 02
+		return []byte{1, 1, 0x03, 0x44, r.sak, 7, 0x04, 0x35, 0x6A, 0x12, 0x34, 0x56, 0x80, 0x05, 0x78, 0x80, 0x70, 0x02}
+	case cmdInDataExchange:
+		if r.released {
+			return []byte{0x29}
+		}
+		resp, ok := r.apdus[hex.EncodeToString(params[1:])]
+		if !ok {
+			return []byte{0x00, 0x6D, 0x00}
+		}
+		b, _ := hex.DecodeString(resp)
+		return append([]byte{0x00}, b...)
+	case cmdInRelease:
+		r.released = true
+		return []byte{0x00}
+	}
+	return nil
+}
+
+func (r *reader) Read(b []byte) (int, error) {
+	if len(r.out) == 0 {
+		return 0, io.EOF // timed out
+	}
+	n := copy(b, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+func TestFrame(t *testing.T) {
+	// GetFirmwareVersion in the user manual
+	want := []byte{0x00, 0x00, 0xFF, 0x02, 0xFE, 0xD4, 0x02, 0x2A, 0x00}
+	if got := frame(0xD4, 0x02); !bytes.Equal(got, want) {
+		t.Errorf("frame = % X; want % X", got, want)
+	}
+
+	p := &PN532{port: bytes.NewBuffer(frame(make([]byte, 300)...))}
+	if b, err := p.readFrame(); err != nil || len(b) != 300 {
+		t.Errorf("readFrame() of an extended frame = %d bytes, %v; want 300", len(b), err)
+	}
+	bad := frame(tfiPN, 0x03)
+	bad[len(bad)-2]++
+	p = &PN532{port: bytes.NewBuffer(bad)}
+	if _, err := p.readFrame(); err == nil {
+		t.Error("readFrame() succeeded with an invalid checksum")
+	}
+}
+
+func TestCard(t *testing.T) {
+	r := newReader()
+	p, err := New(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, rev, err := p.Firmware(); err != nil || v != 1 || rev != 6 {
+		t.Errorf("Firmware() = %d.%d, %v; want 1.6", v, rev, err)
+	}
+
+	r.polls = 3
+	if _, err := p.Card(); err != ErrNoCard {
+		t.Errorf("Card() = %v; want ErrNoCard", err)
+	}
+	fake := clock.NewFake(time.Time{})
+	p.Clock = fake
+	cards := make(chan *Card)
+	go func() {
+		c, err := p.WaitCard(context.Background())
+		if err != nil {
+			t.Error(err)
+		}
+		cards <- c
+	}()
+	for i := 0; i < 2; i++ {
+		fake.BlockUntil(1)
+		fake.Advance(poll)
+	}
+	c := <-cards
+	if !bytes.Equal(c.UID, []byte{0x04, 0x35, 0x6A, 0x12, 0x34, 0x56, 0x80}) || c.ATQA != 0x0344 || !c.ISODEP() {
+		t.Errorf("card = UID % X, ATQA %#x, SAK %#x", c.UID, c.ATQA, c.SAK)
+	}
+	if !bytes.Equal(c.ATS, []byte{0x78, 0x80, 0x70, 0x02}) {
+		t.Errorf("ATS = % X; want 78 80 70 02", c.ATS)
+	}
+
+	// a payment card, whose FCI comes with GET RESPONSE
+	r.apdus["00a404000e325041592e5359532e444446303100"] = "6f18840e325041592e5359532e4444463031" + "6108"
+	r.apdus["00c0000008"] = "a5065004564953419000"
+	fci, err := iso7816.Select(c, []byte("2PAY.SYS.DDF01"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if label, ok := iso7816.Find(fci, 0x50); !ok || string(label) != "VISA" {
+		t.Errorf("application label = %q, %v; want VISA", label, ok)
+	}
+	if _, err := iso7816.ReadRecord(c, 1, 1); err != iso7816.Status(0x6D00) {
+		t.Errorf("ReadRecord() = %v; want 6D00", err)
+	}
+
+	if err := c.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Transmit([]byte{0x00, 0xA4, 0x04, 0x00}); err != Error(0x29) {
+		t.Errorf("Transmit() after Release = %v; want %v", err, Error(0x29))
+	}
+
+	r.sak = 0x08 // MIFARE Classic
+	c, err = p.Card()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.ISODEP() || c.ATS != nil {
+		t.Errorf("MIFARE Classic card is ISO-DEP, ATS % X", c.ATS)
+	}
+	if _, err := c.Transmit([]byte{0x00, 0xA4, 0x04, 0x00}); err == nil {
+		t.Error("Transmit() succeeded on a MIFARE Classic card")
+	}
+}
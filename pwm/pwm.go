@@ -0,0 +1,10 @@
+// Package pwm defines the interface implemented by pulse width modulated
+// outputs, so that drivers of motors, LEDs and buzzers can use any of them.
+package pwm
+
+// Output is a pulse width modulated output.
+type Output interface {
+	// SetDuty sets the fraction of the period the output is high,
+	// between 0 and 1.
+	SetDuty(duty float64) error
+}
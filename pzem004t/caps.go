@@ -0,0 +1,23 @@
+package pzem004t
+
+import "github.com/goiot/devices/caps"
+
+// Caps are the capabilities of the 100A meter, the 10A one with a shunt
+// measures up to 10A and 2.3kW.
+var Caps = caps.Capabilities{
+	Name:      "PZEM-004T",
+	Bus:       "uart",
+	Addresses: []int{Addr},
+	Measurements: []caps.Measurement{
+		{Kind: caps.Voltage, Unit: "V", Min: 80, Max: 260, Resolution: 0.1},
+		{Kind: caps.Current, Unit: "A", Min: 0, Max: 100, Resolution: 0.001},
+		{Kind: caps.Power, Unit: "W", Min: 0, Max: 23000, Resolution: 0.1},
+		{Kind: caps.Energy, Unit: "Wh", Min: 0, Max: 9999999, Resolution: 1},
+		{Kind: caps.Frequency, Unit: "Hz", Min: 45, Max: 65, Resolution: 0.1},
+	},
+}
+
+func init() { caps.Register(Caps) }
+
+// Capabilities implements caps.Describer.
+func (p *PZEM) Capabilities() caps.Capabilities { return Caps }
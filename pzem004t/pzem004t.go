@@ -0,0 +1,198 @@
+// Package pzem004t implements a driver for the Peacefair PZEM-004T v3.0
+// energy meter, measuring the voltage, the current, the power and the
+// energy of a mains circuit through its current transformer and reporting
+// them through Modbus RTU on its isolated serial port. Several meters can
+// share a port at different addresses.
+//
+// The serial port must be configured by the caller at 9600 bauds, 8N1, e.g.
+// with
+//
+//	stty -F /dev/ttyAMA0 9600 cs8 -parenb -cstopb raw
+package pzem004t
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/goiot/devices/energy"
+)
+
+// Addr is the general address, answered by any meter: it can only be used
+// with one meter on the port, e.g. to set its address with SetAddress.
+const Addr = 0xF8
+
+const (
+	fnReadInput     = 0x04
+	fnWriteHolding  = 0x06
+	fnResetEnergy   = 0x42
+	regPowerAlarm   = 0x0001
+	regAddress      = 0x0002
+	inputRegisters  = 10
+	alarmOn         = 0xFFFF
+	exceptionFlag   = 0x80
+	maxMeterAddress = 0xF7
+
+	// timeout is the time waited for an answer, the meter answers within
+	// 100ms.
+	timeout = 500 * time.Millisecond
+)
+
+// deadliner is implemented by the serial ports supporting read timeouts,
+// such as *os.File.
+type deadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// PZEM represents a meter. It implements energy.Meter.
+type PZEM struct {
+	// Calibration corrects the readings.
+	Calibration energy.Calibration
+
+	port io.ReadWriter
+	addr byte
+}
+
+// New returns the meter at addr, 1 to 0xF7 or Addr, on the serial port.
+func New(port io.ReadWriter, addr int) (*PZEM, error) {
+	if addr < 1 || addr > Addr {
+		return nil, fmt.Errorf("invalid address %#x", addr)
+	}
+	return &PZEM{port: port, addr: byte(addr)}, nil
+}
+
+// crc returns the Modbus CRC of b.
+func crc(b []byte) uint16 {
+	c := uint16(0xFFFF)
+	for _, v := range b {
+		c ^= uint16(v)
+		for i := 0; i < 8; i++ {
+			if c&1 != 0 {
+				c = c>>1 ^ 0xA001
+			} else {
+				c >>= 1
+			}
+		}
+	}
+	return c
+}
+
+// frame appends the CRC to the frame b, low byte first.
+func frame(b ...byte) []byte {
+	c := crc(b)
+	return append(b, byte(c), byte(c>>8))
+}
+
+// query sends the request of the function fn with data and returns the
+// data of the answer, n bytes after the address and the function.
+func (p *PZEM) query(fn byte, data []byte, n int) ([]byte, error) {
+	if _, err := p.port.Write(frame(append([]byte{p.addr, fn}, data...)...)); err != nil {
+		return nil, err
+	}
+	if d, ok := p.port.(deadliner); ok {
+		d.SetReadDeadline(time.Now().Add(timeout))
+		defer d.SetReadDeadline(time.Time{})
+	}
+	b := make([]byte, 2)
+	if _, err := io.ReadFull(p.port, b); err != nil {
+		return nil, fmt.Errorf("reading the answer of the meter failed - %v", err)
+	}
+	if b[1] == fn|exceptionFlag {
+		n = 1 // the exception code
+	}
+	b = append(b, make([]byte, n+2)...)
+	if _, err := io.ReadFull(p.port, b[2:]); err != nil {
+		return nil, fmt.Errorf("reading the answer of the meter failed - %v", err)
+	}
+	if c := crc(b[:len(b)-2]); byte(c) != b[len(b)-2] || byte(c>>8) != b[len(b)-1] {
+		return nil, errors.New("invalid CRC in the answer of the meter")
+	}
+	if b[0] != p.addr && p.addr != Addr {
+		return nil, fmt.Errorf("answer from the meter %#x instead of %#x", b[0], p.addr)
+	}
+	if b[1] == fn|exceptionFlag {
+		return nil, fmt.Errorf("the meter failed the request with the exception %d", b[2])
+	}
+	return b[2 : 2+n], nil
+}
+
+// Read implements energy.Meter. The energy is counted since the last
+// ResetEnergy, it is kept by the meter when powered off.
+func (p *PZEM) Read() (energy.Measurement, error) {
+	m, _, err := p.read()
+	return m, err
+}
+
+// Alarm returns whether the power is above the threshold set by
+// SetPowerAlarm.
+func (p *PZEM) Alarm() (bool, error) {
+	_, alarm, err := p.read()
+	return alarm, err
+}
+
+func (p *PZEM) read() (energy.Measurement, bool, error) {
+	b, err := p.query(fnReadInput, []byte{0, 0, 0, inputRegisters}, 1+2*inputRegisters)
+	if err != nil {
+		return energy.Measurement{}, false, err
+	}
+	if b[0] != 2*inputRegisters {
+		return energy.Measurement{}, false, fmt.Errorf("answer of %d bytes, want %d", b[0], 2*inputRegisters)
+	}
+	r := make([]int, inputRegisters)
+	for i := range r {
+		r[i] = int(b[1+2*i])<<8 | int(b[2+2*i])
+	}
+	m := energy.Measurement{
+		Voltage:     float64(r[0]) / 10,
+		Current:     float64(r[1]|r[2]<<16) / 1000,
+		Power:       float64(r[3]|r[4]<<16) / 10,
+		Energy:      float64(r[5] | r[6]<<16),
+		Frequency:   float64(r[7]) / 10,
+		PowerFactor: float64(r[8]) / 100,
+	}
+	return p.Calibration.Apply(m), r[9] == alarmOn, nil
+}
+
+// write writes v in the holding register reg.
+func (p *PZEM) write(reg, v uint16) error {
+	data := []byte{byte(reg >> 8), byte(reg), byte(v >> 8), byte(v)}
+	_, err := p.query(fnWriteHolding, data, len(data))
+	return err
+}
+
+// SetPowerAlarm sets the power above which Alarm reports it, in watts.
+func (p *PZEM) SetPowerAlarm(watts int) error {
+	if watts < 0 || watts > 0xFFFF {
+		return fmt.Errorf("invalid power alarm threshold %dW", watts)
+	}
+	return p.write(regPowerAlarm, uint16(watts))
+}
+
+// SetAddress sets the address of the meter to addr, 1 to 0xF7, which it
+// keeps when powered off, and talks to it at addr from then on. Keep only
+// the meter on the port to set it from Addr.
+func (p *PZEM) SetAddress(addr int) error {
+	if addr < 1 || addr > maxMeterAddress {
+		return fmt.Errorf("invalid address %#x", addr)
+	}
+	if err := p.write(regAddress, uint16(addr)); err != nil {
+		return err
+	}
+	p.addr = byte(addr)
+	return nil
+}
+
+// ResetEnergy resets the energy counter of the meter.
+func (p *PZEM) ResetEnergy() error {
+	_, err := p.query(fnResetEnergy, nil, 0)
+	return err
+}
+
+// Close closes the port if it is an io.Closer.
+func (p *PZEM) Close() error {
+	if c, ok := p.port.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
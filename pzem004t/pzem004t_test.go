@@ -0,0 +1,160 @@
+package pzem004t
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/goiot/devices/energy"
+)
+
+var _ energy.Meter = (*PZEM)(nil)
+
+// meter is a fake PZEM-004T answering the Modbus requests written.
+type meter struct {
+	addr      byte
+	input     [inputRegisters]uint16
+	holding   map[uint16]uint16
+	exception byte // answered to the next request if not 0
+	corrupt   bool // corrupts the CRC of the next answer
+	out       []byte
+}
+
+func (m *meter) Write(b []byte) (int, error) {
+	if len(b) < 4 || crc(b[:len(b)-2]) != uint16(b[len(b)-2])|uint16(b[len(b)-1])<<8 {
+		return len(b), nil // ignored, as by the meter
+	}
+	if b[0] != m.addr && b[0] != Addr {
+		return len(b), nil
+	}
+	fn := b[1]
+	var ans []byte
+	switch {
+	case m.exception != 0:
+		ans = []byte{m.addr, fn | exceptionFlag, m.exception}
+		m.exception = 0
+	case fn == fnReadInput:
+		ans = []byte{m.addr, fn, 2 * inputRegisters}
+		for _, r := range m.input {
+			ans = append(ans, byte(r>>8), byte(r))
+		}
+	case fn == fnWriteHolding:
+		reg, v := uint16(b[2])<<8|uint16(b[3]), uint16(b[4])<<8|uint16(b[5])
+		m.holding[reg] = v
+		ans = []byte{m.addr, fn, b[2], b[3], b[4], b[5]}
+		if reg == regAddress {
+			m.addr = byte(v)
+		}
+	case fn == fnResetEnergy:
+		m.input[5], m.input[6] = 0, 0
+		ans = []byte{m.addr, fn}
+	}
+	ans = frame(ans...)
+	if m.corrupt {
+		ans[len(ans)-1] ^= 0xFF
+		m.corrupt = false
+	}
+	m.out = append(m.out, ans...)
+	return len(b), nil
+}
+
+func (m *meter) Read(b []byte) (int, error) {
+	if len(m.out) == 0 {
+		return 0, io.EOF // timed out
+	}
+	n := copy(b, m.out)
+	m.out = m.out[n:]
+	return n, nil
+}
+
+func newMeter() *meter {
+	return &meter{
+		addr: 1,
+		// 230.1V, 70.123A, 16134.5W, 123456Wh, 50Hz, 0.95, alarm
+		input:   [inputRegisters]uint16{2301, 0x11EB, 1, 0x7641, 2, 0xE240, 1, 500, 95, alarmOn},
+		holding: map[uint16]uint16{},
+	}
+}
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+
+func TestCRC(t *testing.T) {
+	// the request of the manual reading the 10 registers of the meter 1
+	want := []byte{0x01, 0x04, 0x00, 0x00, 0x00, 0x0A, 0x70, 0x0D}
+	if got := frame(0x01, 0x04, 0x00, 0x00, 0x00, 0x0A); !bytes.Equal(got, want) {
+		t.Errorf("frame = % X; want % X", got, want)
+	}
+}
+
+func TestRead(t *testing.T) {
+	m := newMeter()
+	p, err := New(m, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := p.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := energy.Measurement{Voltage: 230.1, Current: 70.123, Power: 16134.5, Energy: 123456, Frequency: 50, PowerFactor: 0.95}
+	for _, v := range [][2]float64{
+		{got.Voltage, want.Voltage}, {got.Current, want.Current}, {got.Power, want.Power},
+		{got.Energy, want.Energy}, {got.Frequency, want.Frequency}, {got.PowerFactor, want.PowerFactor},
+	} {
+		if !near(v[0], v[1]) {
+			t.Fatalf("Read() = %+v; want %+v", got, want)
+		}
+	}
+	if alarm, err := p.Alarm(); err != nil || !alarm {
+		t.Errorf("Alarm() = %v, %v; want true", alarm, err)
+	}
+
+	p.Calibration = energy.Calibration{Voltage: 1.01}
+	if got, _ := p.Read(); !near(got.Voltage, 232.401) {
+		t.Errorf("calibrated voltage = %v; want 232.401", got.Voltage)
+	}
+
+	m.exception = 2
+	if _, err := p.Read(); err == nil {
+		t.Error("Read() succeeded with an exception")
+	}
+	m.corrupt = true
+	if _, err := p.Read(); err == nil {
+		t.Error("Read() succeeded with an invalid CRC")
+	}
+	m.out = nil
+
+	// a silent meter
+	p, _ = New(m, 2)
+	if _, err := p.Read(); err == nil {
+		t.Error("Read() succeeded without answer")
+	}
+}
+
+func TestSettings(t *testing.T) {
+	m := newMeter()
+	p, err := New(m, Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetPowerAlarm(2000); err != nil || m.holding[regPowerAlarm] != 2000 {
+		t.Errorf("SetPowerAlarm(2000) = %v, register %d; want 2000", err, m.holding[regPowerAlarm])
+	}
+	if err := p.SetAddress(0x10); err != nil || m.addr != 0x10 || p.addr != 0x10 {
+		t.Errorf("SetAddress(0x10) = %v, meter at %#x; want 0x10", err, m.addr)
+	}
+	if err := p.ResetEnergy(); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := p.Read(); err != nil || got.Energy != 0 {
+		t.Errorf("energy = %v, %v after ResetEnergy; want 0", got.Energy, err)
+	}
+
+	if err := p.SetAddress(Addr); err == nil {
+		t.Error("SetAddress(Addr) succeeded")
+	}
+	if _, err := New(m, 0); err == nil {
+		t.Error("New() succeeded at address 0")
+	}
+}
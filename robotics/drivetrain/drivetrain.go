@@ -0,0 +1,204 @@
+// Package drivetrain drives the two wheels of a differential drive robot,
+// converting the linear and angular velocities of the robot into the
+// speeds of its wheel motors, and estimates the pose of the robot from the
+// encoders of the wheels by odometry.
+package drivetrain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/motor"
+)
+
+// Pose is the position and the heading of the robot, relative to where
+// it was when the odometry started: the robot starts at 0, 0 facing the X
+// axis, and Y is on its left.
+type Pose struct {
+	X, Y    float64 // meters
+	Heading float64 // radians, counterclockwise from the X axis, -π to π
+
+	// V and Omega are the linear and the angular velocities measured
+	// since the previous pose, in m/s and rad/s.
+	V, Omega float64
+
+	Time time.Time
+}
+
+// Wheels returns the speeds of the left and the right wheels, in m/s, of
+// a robot whose wheels are track meters apart moving at v m/s and turning
+// at omega rad/s, counterclockwise if positive.
+func Wheels(v, omega, track float64) (left, right float64) {
+	return v - omega*track/2, v + omega*track/2
+}
+
+// Velocity returns the linear and the angular velocities of a robot whose
+// wheels are track meters apart from the speeds of its wheels, the inverse
+// of Wheels.
+func Velocity(left, right, track float64) (v, omega float64) {
+	return (left + right) / 2, (right - left) / track
+}
+
+// Drivetrain is a differential drive. It can be used by multiple
+// goroutines.
+type Drivetrain struct {
+	Left, Right motor.Motor
+
+	// TrackWidth is the distance between the wheels in meters.
+	TrackWidth float64
+	// MaxSpeed is the speed of the wheels in m/s with their motors at
+	// full speed.
+	MaxSpeed float64
+
+	// LeftEncoder and RightEncoder count the turns of the wheels for the
+	// odometry, which is not available if they are nil.
+	LeftEncoder, RightEncoder motor.Encoder
+	// MetersPerCount is the distance traveled by a wheel per count of its
+	// encoder, π times the wheel diameter over the counts per turn.
+	MetersPerCount float64
+
+	// Poses receives the pose after each update if not nil. The poses are
+	// dropped while it is full.
+	Poses chan<- Pose
+
+	// ErrorLog logs the errors of Run, the standard logger is used if nil.
+	ErrorLog *log.Logger
+
+	// Clock dates the poses and times Run, clock.Real if nil.
+	Clock clock.Clock
+
+	mu          sync.Mutex
+	pose        Pose
+	left, right int // previous counts
+	started     bool
+}
+
+// Drive sets the motors to move the robot at v m/s turning at omega
+// rad/s. If a wheel would be faster than MaxSpeed both are slowed down in
+// proportion, so the robot still follows the same curve.
+func (d *Drivetrain) Drive(v, omega float64) error {
+	if d.TrackWidth <= 0 || d.MaxSpeed <= 0 {
+		return errors.New("the track width and the maximum speed must be positive")
+	}
+	l, r := Wheels(v, omega, d.TrackWidth)
+	if m := math.Max(math.Abs(l), math.Abs(r)); m > d.MaxSpeed {
+		l, r = l*d.MaxSpeed/m, r*d.MaxSpeed/m
+	}
+	if err := d.Left.SetSpeed(l / d.MaxSpeed); err != nil {
+		return fmt.Errorf("setting the speed of the left motor failed - %v", err)
+	}
+	if err := d.Right.SetSpeed(r / d.MaxSpeed); err != nil {
+		return fmt.Errorf("setting the speed of the right motor failed - %v", err)
+	}
+	return nil
+}
+
+// Stop lets both motors coast.
+func (d *Drivetrain) Stop() error {
+	return d.Drive(0, 0)
+}
+
+// Update reads the encoders and integrates the distances traveled by the
+// wheels since the previous update into the pose. Updating often keeps
+// the estimate accurate on curves; the error of the odometry grows with
+// the distance anyway, with the slip of the wheels.
+func (d *Drivetrain) Update() (Pose, error) {
+	if d.LeftEncoder == nil || d.RightEncoder == nil {
+		return Pose{}, errors.New("no encoders for the odometry")
+	}
+	l, err := d.LeftEncoder.Count()
+	if err != nil {
+		return Pose{}, fmt.Errorf("reading the left encoder failed - %v", err)
+	}
+	r, err := d.RightEncoder.Count()
+	if err != nil {
+		return Pose{}, fmt.Errorf("reading the right encoder failed - %v", err)
+	}
+	now := clock.Or(d.Clock).Now()
+
+	d.mu.Lock()
+	p := d.pose
+	if d.started {
+		dl := float64(l-d.left) * d.MetersPerCount
+		dr := float64(r-d.right) * d.MetersPerCount
+		dist, turn := Velocity(dl, dr, d.TrackWidth)
+		// the chord of the arc is along the mean heading
+		mid := p.Heading + turn/2
+		p.X += dist * math.Cos(mid)
+		p.Y += dist * math.Sin(mid)
+		p.Heading = normalize(p.Heading + turn)
+		p.V, p.Omega = 0, 0
+		if dt := now.Sub(p.Time).Seconds(); dt > 0 {
+			p.V, p.Omega = dist/dt, turn/dt
+		}
+	}
+	p.Time = now
+	d.pose, d.left, d.right, d.started = p, l, r, true
+	d.mu.Unlock()
+
+	if d.Poses != nil {
+		select {
+		case d.Poses <- p:
+		default:
+		}
+	}
+	return p, nil
+}
+
+// Pose returns the last pose estimated by Update.
+func (d *Drivetrain) Pose() Pose {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.pose
+}
+
+// SetPose resets the estimate to p, e.g. to the position of a landmark.
+func (d *Drivetrain) SetPose(p Pose) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	p.Heading = normalize(p.Heading)
+	d.pose = p
+}
+
+// Run updates the pose every interval until ctx is done, logging the
+// errors.
+func (d *Drivetrain) Run(ctx context.Context, interval time.Duration) error {
+	t := clock.Or(d.Clock).NewTicker(interval)
+	defer t.Stop()
+	for {
+		if _, err := d.Update(); err != nil {
+			d.logf("drivetrain: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C():
+		}
+	}
+}
+
+func (d *Drivetrain) logf(format string, args ...interface{}) {
+	if d.ErrorLog != nil {
+		d.ErrorLog.Printf(format, args...)
+		return
+	}
+	log.Printf(format, args...)
+}
+
+// normalize returns the angle a in -π to π.
+func normalize(a float64) float64 {
+	a = math.Mod(a, 2*math.Pi)
+	switch {
+	case a > math.Pi:
+		a -= 2 * math.Pi
+	case a <= -math.Pi:
+		a += 2 * math.Pi
+	}
+	return a
+}
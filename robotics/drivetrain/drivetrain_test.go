@@ -0,0 +1,170 @@
+package drivetrain
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/boards/motorhat"
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/motor"
+)
+
+var _ motor.Motor = (*motorhat.DC)(nil)
+
+type fakeMotor struct {
+	speed float64
+	err   error
+}
+
+func (m *fakeMotor) SetSpeed(speed float64) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.speed = speed
+	return nil
+}
+
+type fakeEncoder struct {
+	count int
+	err   error
+}
+
+func (e *fakeEncoder) Count() (int, error) { return e.count, e.err }
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestWheels(t *testing.T) {
+	for _, tt := range []struct {
+		v, omega, left, right float64
+	}{
+		{v: 1, omega: 0, left: 1, right: 1},
+		{v: 0, omega: 2, left: -0.2, right: 0.2},
+		{v: 0.5, omega: -1, left: 0.6, right: 0.4},
+	} {
+		l, r := Wheels(tt.v, tt.omega, 0.2)
+		if !near(l, tt.left) || !near(r, tt.right) {
+			t.Errorf("Wheels(%v, %v) = %v, %v; want %v, %v", tt.v, tt.omega, l, r, tt.left, tt.right)
+		}
+		if v, omega := Velocity(l, r, 0.2); !near(v, tt.v) || !near(omega, tt.omega) {
+			t.Errorf("Velocity(%v, %v) = %v, %v; want %v, %v", l, r, v, omega, tt.v, tt.omega)
+		}
+	}
+}
+
+func TestDrive(t *testing.T) {
+	left, right := &fakeMotor{}, &fakeMotor{}
+	d := &Drivetrain{Left: left, Right: right, TrackWidth: 0.2, MaxSpeed: 0.5}
+	if err := d.Drive(0.25, 1); err != nil {
+		t.Fatal(err)
+	}
+	if !near(left.speed, 0.3) || !near(right.speed, 0.7) {
+		t.Errorf("speeds = %v, %v; want 0.3, 0.7", left.speed, right.speed)
+	}
+
+	// too fast, slowed down on the same curve
+	if err := d.Drive(1, 5); err != nil {
+		t.Fatal(err)
+	}
+	if !near(right.speed, 1) || !near(left.speed, 1.0/3) {
+		t.Errorf("speeds = %v, %v; want 0.333, 1", left.speed, right.speed)
+	}
+
+	if err := d.Stop(); err != nil || left.speed != 0 || right.speed != 0 {
+		t.Errorf("Stop() = %v, speeds %v, %v; want stopped", err, left.speed, right.speed)
+	}
+
+	right.err = errors.New("i2c failure")
+	if err := d.Drive(0.1, 0); err == nil {
+		t.Error("Drive() succeeded with a failing motor")
+	}
+	if err := (&Drivetrain{Left: left, Right: right}).Drive(0.1, 0); err == nil {
+		t.Error("Drive() succeeded without track width and maximum speed")
+	}
+}
+
+func TestOdometry(t *testing.T) {
+	left, right := &fakeEncoder{count: 100}, &fakeEncoder{count: -50}
+	c := clock.NewFake(time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC))
+	poses := make(chan Pose, 1)
+	d := &Drivetrain{
+		LeftEncoder:    left,
+		RightEncoder:   right,
+		TrackWidth:     0.2,
+		MetersPerCount: 0.001,
+		Poses:          poses,
+		Clock:          c,
+	}
+	// the first update only takes the counts
+	if p, err := d.Update(); err != nil || p.X != 0 || p.Y != 0 || p.Heading != 0 {
+		t.Fatalf("Update() = %+v, %v; want the origin", p, err)
+	}
+	<-poses
+
+	// 1m forwards in 2s
+	left.count += 1000
+	right.count += 1000
+	c.Advance(2 * time.Second)
+	p, err := d.Update()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !near(p.X, 1) || !near(p.Y, 0) || !near(p.V, 0.5) || !near(p.Omega, 0) || !p.Time.Equal(c.Now()) {
+		t.Errorf("pose = %+v; want 1m forwards at 0.5m/s", p)
+	}
+	if got := <-poses; got != p {
+		t.Errorf("streamed pose = %+v; want %+v", got, p)
+	}
+
+	// a quarter turn left in place: each wheel travels π/2·0.1m
+	n := int(math.Round(math.Pi / 2 * 0.1 / 0.001))
+	left.count -= n
+	right.count += n
+	c.Advance(time.Second)
+	p, _ = d.Update()
+	if math.Abs(p.Heading-math.Pi/2) > 0.01 || math.Abs(p.X-1) > 1e-9 || math.Abs(p.Omega-math.Pi/2) > 0.01 {
+		t.Errorf("pose = %+v; want facing Y at 1, 0", p)
+	}
+
+	// then 0.5m forwards, along Y
+	left.count += 500
+	right.count += 500
+	p, _ = d.Update()
+	if math.Abs(p.X-1) > 0.01 || math.Abs(p.Y-0.5) > 0.01 {
+		t.Errorf("pose = %+v; want 1, 0.5", p)
+	}
+	if got := d.Pose(); got != p {
+		t.Errorf("Pose() = %+v; want %+v", got, p)
+	}
+
+	// the channel is full, the update does not block
+	d.Update()
+
+	d.SetPose(Pose{Heading: 3 * math.Pi})
+	if h := d.Pose().Heading; !near(h, math.Pi) {
+		t.Errorf("heading = %v; want π", h)
+	}
+
+	right.err = errors.New("i2c failure")
+	if _, err := d.Update(); err == nil {
+		t.Error("Update() succeeded with a failing encoder")
+	}
+	if _, err := (&Drivetrain{}).Update(); err == nil {
+		t.Error("Update() succeeded without encoders")
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	for _, tt := range []struct{ a, want float64 }{
+		{0, 0},
+		{math.Pi, math.Pi},
+		{-math.Pi, math.Pi},
+		{3 * math.Pi / 2, -math.Pi / 2},
+		{-5 * math.Pi / 2, -math.Pi / 2},
+	} {
+		if got := normalize(tt.a); !near(got, tt.want) {
+			t.Errorf("normalize(%v) = %v; want %v", tt.a, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package rules
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// ServeHTTP implements http.Handler to change the rules at runtime: GET
+// lists the rules in JSON, PUT /<name> adds or replaces the rule given in
+// the body and DELETE /<name> removes it.
+func (e *Engine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.Trim(r.URL.Path, "/")
+	switch {
+	case r.Method == "GET" && name == "":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(e.Rules())
+	case r.Method == "PUT" && name != "":
+		b, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, 4096))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := e.Add(name, string(b)); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == "DELETE" && name != "":
+		e.Remove(name)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
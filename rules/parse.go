@@ -0,0 +1,261 @@
+package rules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// expr is a condition of a rule.
+type expr interface {
+	eval(s *state) bool
+}
+
+// state is what the conditions are evaluated against.
+type state struct {
+	values map[string]float64
+	preds  map[string]func() bool
+}
+
+type and struct{ l, r expr }
+
+func (e and) eval(s *state) bool { return e.l.eval(s) && e.r.eval(s) }
+
+type or struct{ l, r expr }
+
+func (e or) eval(s *state) bool { return e.l.eval(s) || e.r.eval(s) }
+
+type not struct{ e expr }
+
+func (e not) eval(s *state) bool { return !e.e.eval(s) }
+
+// name is a predicate if one is defined with the name, otherwise it is
+// true if the source is not zero.
+type name string
+
+func (n name) eval(s *state) bool {
+	if p, ok := s.preds[string(n)]; ok {
+		return p()
+	}
+	v, ok := s.values[string(n)]
+	return ok && v != 0
+}
+
+// compare is false until the source has a value.
+type compare struct {
+	source string
+	op     string
+	v      float64
+}
+
+func (c compare) eval(s *state) bool {
+	v, ok := s.values[c.source]
+	if !ok {
+		return false
+	}
+	switch c.op {
+	case ">":
+		return v > c.v
+	case ">=":
+		return v >= c.v
+	case "<":
+		return v < c.v
+	case "<=":
+		return v <= c.v
+	case "==":
+		return v == c.v
+	default: // !=
+		return v != c.v
+	}
+}
+
+// action is an action of a rule and its argument.
+type action struct {
+	name, arg string
+}
+
+// parsed is a rule:
+//
+//	[if] <condition> [for <duration>] then <action> [<arg>] [else <action> [<arg>]]
+type parsed struct {
+	cond            expr
+	hold            time.Duration
+	then, otherwise action
+	hasElse         bool
+}
+
+// tokenize splits the text in words, operators and parentheses.
+func tokenize(text string) []string {
+	var toks []string
+	isOp := func(r rune) bool { return strings.ContainsRune("<>=!", r) }
+	rs := []rune(text)
+	for i := 0; i < len(rs); {
+		switch r := rs[i]; {
+		case r == ' ' || r == '\t' || r == '\n':
+			i++
+		case r == '(' || r == ')':
+			toks = append(toks, string(r))
+			i++
+		case isOp(r):
+			j := i
+			for j < len(rs) && isOp(rs[j]) {
+				j++
+			}
+			toks = append(toks, string(rs[i:j]))
+			i = j
+		default:
+			j := i
+			for j < len(rs) && !isOp(rs[j]) && !strings.ContainsRune(" \t\n()", rs[j]) {
+				j++
+			}
+			toks = append(toks, string(rs[i:j]))
+			i = j
+		}
+	}
+	return toks
+}
+
+type parser struct {
+	toks []string
+	pos  int
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos]
+	}
+	return ""
+}
+
+func (p *parser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+// keyword reports whether t is a keyword, which cannot name a source.
+func keyword(t string) bool {
+	switch t {
+	case "if", "and", "or", "not", "for", "then", "else":
+		return true
+	}
+	return false
+}
+
+func parse(text string) (*parsed, error) {
+	p := &parser{toks: tokenize(text)}
+	if p.peek() == "if" {
+		p.next()
+	}
+	r := &parsed{}
+	var err error
+	if r.cond, err = p.or(); err != nil {
+		return nil, err
+	}
+	if p.peek() == "for" {
+		p.next()
+		d := p.next()
+		if r.hold, err = time.ParseDuration(d); err != nil || r.hold < 0 {
+			return nil, fmt.Errorf("invalid duration %q", d)
+		}
+	}
+	if t := p.next(); t != "then" {
+		return nil, fmt.Errorf("expected then, found %q", t)
+	}
+	if r.then, err = p.action(); err != nil {
+		return nil, err
+	}
+	if p.peek() == "else" {
+		p.next()
+		if r.otherwise, err = p.action(); err != nil {
+			return nil, err
+		}
+	}
+	if t := p.peek(); t != "" {
+		return nil, fmt.Errorf("unexpected %q at the end of the rule", t)
+	}
+	return r, nil
+}
+
+func (p *parser) action() (action, error) {
+	a := action{name: p.next()}
+	if a.name == "" || keyword(a.name) {
+		return a, fmt.Errorf("expected an action, found %q", a.name)
+	}
+	if t := p.peek(); t != "" && t != "else" {
+		a.arg = p.next()
+	}
+	return a, nil
+}
+
+func (p *parser) or() (expr, error) {
+	l, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "or" {
+		p.next()
+		r, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l = or{l, r}
+	}
+	return l, nil
+}
+
+func (p *parser) and() (expr, error) {
+	l, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "and" {
+		p.next()
+		r, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l = and{l, r}
+	}
+	return l, nil
+}
+
+func (p *parser) unary() (expr, error) {
+	switch t := p.next(); {
+	case t == "not":
+		e, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return not{e}, nil
+	case t == "(":
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t != ")" {
+			return nil, fmt.Errorf("expected ), found %q", t)
+		}
+		return e, nil
+	case t == "" || t == ")" || keyword(t) || strings.ContainsAny(t[:1], "<>=!"):
+		return nil, fmt.Errorf("expected a condition, found %q", t)
+	default:
+		switch op := p.peek(); op {
+		case ">", ">=", "<", "<=", "==", "!=":
+			p.next()
+			s := p.next()
+			v, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q", s)
+			}
+			return compare{source: t, op: op, v: v}, nil
+		case "":
+		default:
+			if strings.ContainsAny(op[:1], "<>=!") {
+				return nil, fmt.Errorf("invalid operator %q", op)
+			}
+		}
+		return name(t), nil
+	}
+}
@@ -0,0 +1,270 @@
+// Package rules automates actuators and displays with declarative rules
+// evaluated against the samples and input events of an event bus:
+//
+//	if temperature > 28 for 2m then fan on else fan off
+//	if motion and night then display on
+//
+// The conditions compare the latest values of the sources, combined with
+// and, or, not and parentheses. A source alone is true if it is not zero,
+// the inputs are 1 after a rising edge and 0 after a falling edge, unless
+// a predicate with its name is defined. The action runs when the condition
+// becomes true, after it held for the duration if any, and the else action
+// when it becomes false.
+package rules
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/events"
+	"github.com/goiot/devices/gpio"
+	"github.com/goiot/devices/pwm"
+)
+
+// Action is run by the rules with the argument given after its name.
+type Action func(arg string) error
+
+// Rule is a named rule.
+type Rule struct {
+	Name string `json:"name"`
+	Text string `json:"text"`
+
+	p      *parsed
+	since  time.Time // when the condition became true, zero if false
+	active bool      // the action ran and the else action did not
+}
+
+// Engine evaluates the rules. It can be used by multiple goroutines.
+type Engine struct {
+	// ErrorLog logs the errors of the actions, the standard logger is
+	// used if nil.
+	ErrorLog *log.Logger
+
+	// Clock times the durations of the rules and Run, clock.Real if nil.
+	Clock clock.Clock
+
+	mu      sync.Mutex
+	st      state
+	actions map[string]Action
+	rules   map[string]*Rule
+}
+
+// NewEngine returns an engine without rules.
+func NewEngine() *Engine {
+	return &Engine{
+		st:      state{values: make(map[string]float64), preds: make(map[string]func() bool)},
+		actions: make(map[string]Action),
+		rules:   make(map[string]*Rule),
+	}
+}
+
+// Define defines a predicate usable in the conditions, e.g. night.
+func (e *Engine) Define(name string, f func() bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.st.preds[name] = f
+}
+
+// Action registers an action usable by the rules, e.g. fan.
+func (e *Engine) Action(name string, a Action) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.actions[name] = a
+}
+
+// Add adds a rule, or replaces the rule with the same name. The actions
+// of the rule must be registered.
+func (e *Engine) Add(name, text string) error {
+	p, err := parse(text)
+	if err != nil {
+		return fmt.Errorf("invalid rule %q - %v", name, err)
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, a := range []action{p.then, p.otherwise} {
+		if _, ok := e.actions[a.name]; a.name != "" && !ok {
+			return fmt.Errorf("invalid rule %q - unknown action %q", name, a.name)
+		}
+	}
+	e.rules[name] = &Rule{Name: name, Text: text, p: p}
+	return nil
+}
+
+// Remove removes the rule with the name.
+func (e *Engine) Remove(name string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.rules, name)
+}
+
+// Rules returns the rules, sorted by name.
+func (e *Engine) Rules() []Rule {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	rules := make([]Rule, 0, len(e.rules))
+	for _, r := range e.rules {
+		rules = append(rules, Rule{Name: r.Name, Text: r.Text})
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name < rules[j].Name })
+	return rules
+}
+
+// Set sets the value of a source.
+func (e *Engine) Set(source string, v float64) {
+	e.mu.Lock()
+	e.st.values[source] = v
+	e.mu.Unlock()
+	e.Evaluate()
+}
+
+// Handle updates the sources with a sample or an input event.
+func (e *Engine) Handle(ev events.Event) {
+	switch ev := ev.(type) {
+	case events.Sample:
+		e.Set(ev.Source, ev.Value)
+	case events.Input:
+		switch ev.Edge {
+		case gpio.RisingEdge:
+			e.Set(ev.Source, 1)
+		case gpio.FallingEdge:
+			e.Set(ev.Source, 0)
+		}
+	}
+}
+
+// Evaluate evaluates the rules and runs the actions of the rules whose
+// condition changed.
+func (e *Engine) Evaluate() {
+	type run struct {
+		rule string
+		a    Action
+		arg  string
+	}
+	var runs []run
+	e.mu.Lock()
+	now := clock.Or(e.Clock).Now()
+	names := make([]string, 0, len(e.rules))
+	for n := range e.rules {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		r := e.rules[n]
+		if !r.p.cond.eval(&e.st) {
+			r.since = time.Time{}
+			if r.active {
+				r.active = false
+				if r.p.otherwise.name != "" {
+					runs = append(runs, run{n, e.actions[r.p.otherwise.name], r.p.otherwise.arg})
+				}
+			}
+			continue
+		}
+		if r.since.IsZero() {
+			r.since = now
+		}
+		if !r.active && now.Sub(r.since) >= r.p.hold {
+			r.active = true
+			runs = append(runs, run{n, e.actions[r.p.then.name], r.p.then.arg})
+		}
+	}
+	e.mu.Unlock()
+	for _, r := range runs {
+		if err := r.a(r.arg); err != nil {
+			e.logf("rules: %v: %v", r.rule, err)
+		}
+	}
+}
+
+func (e *Engine) logf(format string, args ...interface{}) {
+	if e.ErrorLog != nil {
+		e.ErrorLog.Printf(format, args...)
+		return
+	}
+	log.Printf(format, args...)
+}
+
+// Run handles the samples and input events of the bus, and evaluates the
+// rules every second for their durations and predicates, until ctx is
+// done.
+func (e *Engine) Run(ctx context.Context, bus *events.Bus) error {
+	sub := bus.Subscribe("#", 64)
+	defer sub.Close()
+	t := clock.Or(e.Clock).NewTicker(time.Second)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ev := <-sub.C:
+			e.Handle(ev)
+		case <-t.C():
+			e.Evaluate()
+		}
+	}
+}
+
+// Pin returns an action writing on or 1, off or 0 on an output.
+func Pin(p gpio.Pin) Action {
+	return func(arg string) error {
+		switch arg {
+		case "on", "1":
+			return p.Write(1)
+		case "off", "0":
+			return p.Write(0)
+		}
+		return fmt.Errorf("invalid level %q, expected on or off", arg)
+	}
+}
+
+// PWM returns an action setting the duty of an output, given as a
+// fraction or a percentage: 0.5 or 50%. On and off are full duty and 0.
+func PWM(o pwm.Output) Action {
+	return func(arg string) error {
+		switch arg {
+		case "on":
+			return o.SetDuty(1)
+		case "off":
+			return o.SetDuty(0)
+		}
+		scale := 1.0
+		if strings.HasSuffix(arg, "%") {
+			arg, scale = strings.TrimSuffix(arg, "%"), 100
+		}
+		d, err := strconv.ParseFloat(arg, 64)
+		if err != nil || d < 0 || d > scale {
+			return fmt.Errorf("invalid duty %q", arg)
+		}
+		return o.SetDuty(d / scale)
+	}
+}
+
+// Between returns a predicate true between two times of the day given as
+// 15:04, e.g. Between("22:00", "06:30") for the night, on the clock of the
+// engine.
+func (e *Engine) Between(from, to string) (func() bool, error) {
+	f, err := time.Parse("15:04", from)
+	if err != nil {
+		return nil, err
+	}
+	t, err := time.Parse("15:04", to)
+	if err != nil {
+		return nil, err
+	}
+	start, end := f.Hour()*60+f.Minute(), t.Hour()*60+t.Minute()
+	return func() bool {
+		now := clock.Or(e.Clock).Now()
+		m := now.Hour()*60 + now.Minute()
+		if start <= end {
+			return m >= start && m < end
+		}
+		return m >= start || m < end
+	}, nil
+}
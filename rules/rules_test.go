@@ -0,0 +1,238 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/events"
+	"github.com/goiot/devices/gpio"
+	"github.com/goiot/devices/gpiosim"
+)
+
+func TestParse(t *testing.T) {
+	st := &state{
+		values: map[string]float64{"temperature": 30, "motion": 1, "door": 0},
+		preds:  map[string]func() bool{"night": func() bool { return true }},
+	}
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"if temperature > 28 then fan on", true},
+		{"temperature>28 then fan", true},
+		{"temperature <= 28 then fan on", false},
+		{"temperature == 30 and motion then fan on", true},
+		{"motion and night then display on", true},
+		{"door or not night then display on", false},
+		{"not (door or humidity > 50) then display on", true},
+		{"humidity < 50 then fan on", false},
+		{"bme280/temperature != 1 then fan on", false},
+	}
+	for _, tt := range tests {
+		p, err := parse(tt.text)
+		if err != nil {
+			t.Errorf("parse(%q): %v", tt.text, err)
+			continue
+		}
+		if got := p.cond.eval(st); got != tt.want {
+			t.Errorf("%q evaluated to %v; want %v", tt.text, got, tt.want)
+		}
+	}
+
+	p, err := parse("if temperature > 28 for 2m then fan 50% else fan off")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.hold != 2*time.Minute || p.then != (action{"fan", "50%"}) || p.otherwise != (action{"fan", "off"}) {
+		t.Errorf("parsed %+v", p)
+	}
+
+	for _, text := range []string{
+		"",
+		"temperature > 28",
+		"temperature > hot then fan on",
+		"temperature => 28 then fan on",
+		"(temperature > 28 then fan on",
+		"temperature > 28 for ever then fan on",
+		"temperature > 28 then",
+		"temperature > 28 then fan on off",
+		"and then fan on",
+	} {
+		if _, err := parse(text); err == nil {
+			t.Errorf("parse(%q) succeeded", text)
+		}
+	}
+}
+
+func TestEngine(t *testing.T) {
+	e := NewEngine()
+	c := clock.NewFake(time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC))
+	e.Clock = c
+	fan := gpiosim.NewPin(0)
+	e.Action("fan", Pin(fan))
+	var shown []string
+	e.Action("display", func(arg string) error { shown = append(shown, arg); return nil })
+	night := false
+	e.Define("night", func() bool { return night })
+
+	if err := e.Add("cooling", "if temperature > 28 for 2m then fan on else fan off"); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Add("lights", "if motion and night then display on else display off"); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Add("bad", "if motion then buzzer on"); err == nil {
+		t.Error("rule with an unknown action added")
+	}
+
+	e.Handle(events.Sample{Source: "temperature", Value: 29})
+	if fan.Level() != 0 {
+		t.Error("fan on before the condition held for 2m")
+	}
+	c.Advance(time.Minute)
+	e.Handle(events.Sample{Source: "temperature", Value: 27})
+	c.Advance(2 * time.Minute)
+	e.Handle(events.Sample{Source: "temperature", Value: 29})
+	e.Evaluate()
+	if fan.Level() != 0 {
+		t.Error("the duration did not restart when the condition became false")
+	}
+	c.Advance(2 * time.Minute)
+	e.Evaluate()
+	if fan.Level() != 1 {
+		t.Error("fan not on after 2m")
+	}
+	e.Handle(events.Sample{Source: "temperature", Value: 25})
+	if fan.Level() != 0 {
+		t.Error("fan not off")
+	}
+
+	e.Handle(events.Input{Source: "motion", Edge: gpio.RisingEdge})
+	night = true
+	e.Evaluate()
+	e.Evaluate()
+	e.Handle(events.Input{Source: "motion", Edge: gpio.FallingEdge})
+	if strings.Join(shown, ",") != "on,off" {
+		t.Errorf("display actions %v; want on and off once", shown)
+	}
+
+	e.Remove("lights")
+	if rules := e.Rules(); len(rules) != 1 || rules[0].Name != "cooling" {
+		t.Errorf("Rules = %v", rules)
+	}
+}
+
+type output struct{ duty float64 }
+
+func (o *output) SetDuty(d float64) error { o.duty = d; return nil }
+
+func TestActions(t *testing.T) {
+	o := &output{}
+	a := PWM(o)
+	for arg, want := range map[string]float64{"50%": 0.5, "0.25": 0.25, "on": 1, "off": 0} {
+		if err := a(arg); err != nil || o.duty != want {
+			t.Errorf("PWM(%q): duty %v, %v; want %v", arg, o.duty, err, want)
+		}
+	}
+	for _, arg := range []string{"", "150%", "2", "-1", "half"} {
+		if err := a(arg); err == nil {
+			t.Errorf("PWM(%q) succeeded", arg)
+		}
+	}
+	if err := Pin(gpiosim.NewPin(0))("maybe"); err == nil {
+		t.Error("Pin(maybe) succeeded")
+	}
+	e := NewEngine()
+	c := clock.NewFake(time.Date(2024, 5, 17, 23, 0, 0, 0, time.UTC))
+	e.Clock = c
+	if _, err := e.Between("22:00", "25:00"); err == nil {
+		t.Error("Between with an invalid time succeeded")
+	}
+	always, _ := e.Between("00:00", "00:00")
+	if always() {
+		t.Error("empty range is true")
+	}
+	night, _ := e.Between("22:00", "06:30")
+	if !night() {
+		t.Error("night false at 23:00")
+	}
+	c.Advance(8 * time.Hour)
+	if night() {
+		t.Error("night true at 07:00")
+	}
+}
+
+func TestRun(t *testing.T) {
+	e := NewEngine()
+	ran := make(chan string, 1)
+	e.Action("alarm", func(arg string) error { ran <- arg; return nil })
+	e.Add("co2", "co2 > 1500 then alarm loud")
+	bus := events.New()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- e.Run(ctx, bus) }()
+
+	for i := 0; ; i++ {
+		bus.Publish(events.Sample{Source: "co2", Value: 1600})
+		select {
+		case arg := <-ran:
+			if arg != "loud" {
+				t.Errorf("alarm %q", arg)
+			}
+			cancel()
+			if err := <-done; err != context.Canceled {
+				t.Errorf("Run = %v", err)
+			}
+			return
+		case <-time.After(10 * time.Millisecond):
+			if i == 500 {
+				t.Fatal("the rule did not run")
+			}
+		}
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	e := NewEngine()
+	e.ErrorLog = log.New(ioutil.Discard, "", 0)
+	e.Action("fan", func(string) error { return nil })
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+
+	do := func(method, path, body string) *http.Response {
+		req, _ := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	if resp := do("PUT", "/cooling", "temperature > 28 then fan on"); resp.StatusCode != http.StatusNoContent {
+		t.Errorf("PUT: %v", resp.Status)
+	}
+	if resp := do("PUT", "/bad", "temperature >"); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("PUT of an invalid rule: %v", resp.Status)
+	}
+	resp := do("GET", "/", "")
+	var rules []Rule
+	json.NewDecoder(resp.Body).Decode(&rules)
+	resp.Body.Close()
+	if len(rules) != 1 || rules[0].Text != "temperature > 28 then fan on" {
+		t.Errorf("GET = %v", rules)
+	}
+	do("DELETE", "/cooling", "")
+	if len(e.Rules()) != 0 {
+		t.Error("rule not deleted")
+	}
+	if resp := do("POST", "/", ""); resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST: %v", resp.Status)
+	}
+}
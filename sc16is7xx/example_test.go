@@ -0,0 +1,43 @@
+package sc16is7xx_test
+
+import (
+	"fmt"
+
+	"github.com/goiot/devices/i2cbus"
+	"github.com/goiot/devices/pms5003"
+	"github.com/goiot/devices/sc16is7xx"
+)
+
+// Example reads a PMS5003 connected to the UART of the bridge, its SET
+// pin driven by GPIO0 of the bridge to wake it up.
+func Example() {
+	bus, err := i2cbus.Open("primary")
+	if err != nil {
+		panic(err)
+	}
+	bridge, err := sc16is7xx.Open(bus, sc16is7xx.Addr, sc16is7xx.Crystal)
+	if err != nil {
+		panic(err)
+	}
+	defer bridge.Close()
+
+	set, err := bridge.Output(0, 1)
+	if err != nil {
+		panic(err)
+	}
+	defer set.Close()
+
+	port, err := bridge.UART(0, 9600)
+	if err != nil {
+		panic(err)
+	}
+	sensor := pms5003.New(port)
+	for i := 0; i < 10; i++ {
+		r, err := sensor.Read()
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		fmt.Printf("PM2.5: %dµg/m³\n", r.PM25Atm)
+	}
+}
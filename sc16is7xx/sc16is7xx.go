@@ -0,0 +1,410 @@
+// Package sc16is7xx implements a driver for the NXP SC16IS740, SC16IS750
+// and SC16IS760 I2C/SPI to UART bridges, and the dual channel SC16IS752
+// and SC16IS762. The channels of the bridge are serial ports implementing
+// io.ReadWriter, the ports the pms5003, nmea and flashloader packages read,
+// so GPS, CO2 or particulate matter sensors can be connected to hosts with
+// no free serial port. The 8 extra pins of the bridge are exposed as
+// gpio.Pin.
+package sc16is7xx
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/gpio"
+	"golang.org/x/exp/io/i2c"
+	i2cdriver "golang.org/x/exp/io/i2c/driver"
+	"golang.org/x/exp/io/spi"
+	spidriver "golang.org/x/exp/io/spi/driver"
+)
+
+// Addr is the I2C address of the bridge with its A1 and A0 pins tied to
+// VDD, the breakout boards default. The other settings of the pins give
+// addresses from 0x48 to 0x57.
+const Addr = 0x48
+
+// SPISpeed is the SPI clock frequency used by OpenSPI, the maximum of the
+// SC16IS750. The SC16IS760 runs up to 15MHz.
+const SPISpeed = 4000000
+
+// Crystal is the frequency of the crystal of most breakout boards, in Hz.
+const Crystal = 14745600
+
+// Registers, shifted in the address byte along with the channel.
+const (
+	regRHR       = 0x00 // THR on writes
+	regFCR       = 0x02
+	regLCR       = 0x03
+	regLSR       = 0x05
+	regSPR       = 0x07
+	regTXLVL     = 0x08
+	regRXLVL     = 0x09
+	regIODir     = 0x0A
+	regIOState   = 0x0B
+	regIOControl = 0x0E
+
+	// with the divisor latch enabled
+	regDLL = 0x00
+	regDLH = 0x01
+)
+
+const (
+	lcrDivisorLatch = 0x80
+	lcr8N1          = 0x03
+	fcrEnable       = 0x07 // enables and resets the FIFOs
+	lsrOverrun      = 0x02
+	fifoSize        = 64
+
+	// pollInterval is how often the FIFOs are polled, about a byte at
+	// 9600 bauds.
+	pollInterval = time.Millisecond
+)
+
+// Bridge represents a SC16IS7xx. It can be used by multiple goroutines and
+// must be closed if no longer in use.
+type Bridge struct {
+	// Clock times the polling of the FIFOs, clock.Real if nil.
+	Clock clock.Clock
+
+	crystal int
+
+	mu    sync.Mutex
+	bus   bus
+	ioDir byte // shadows of the pin registers
+	ioOut byte
+}
+
+// bus accesses the registers of a channel on I2C or SPI.
+type bus interface {
+	read(reg byte, ch int, b []byte) error
+	write(reg byte, ch int, b ...byte) error
+	Close() error
+}
+
+// Open opens the bridge at addr, clocked by a crystal of crystal Hz.
+func Open(o i2cdriver.Opener, addr, crystal int) (*Bridge, error) {
+	dev, err := i2c.Open(o, addr)
+	if err != nil {
+		return nil, err
+	}
+	b := &Bridge{crystal: crystal, bus: i2cBus{dev}}
+	if err := b.init(); err != nil {
+		dev.Close()
+		return nil, fmt.Errorf("no SC16IS7xx at %#x - %v", addr, err)
+	}
+	return b, nil
+}
+
+// OpenSPI opens the bridge on an SPI port, clocked by a crystal of crystal
+// Hz.
+func OpenSPI(o spidriver.Opener, crystal int) (*Bridge, error) {
+	dev, err := spi.Open(o)
+	if err != nil {
+		return nil, err
+	}
+	b := &Bridge{crystal: crystal, bus: spiBus{dev}}
+	if err := b.initSPI(dev); err != nil {
+		dev.Close()
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *Bridge) initSPI(dev *spi.Device) error {
+	if err := dev.SetMode(spi.Mode0); err != nil {
+		return err
+	}
+	if err := dev.SetBitsPerWord(8); err != nil {
+		return err
+	}
+	if err := dev.SetMaxSpeed(SPISpeed); err != nil {
+		return err
+	}
+	if err := b.init(); err != nil {
+		return fmt.Errorf("no SC16IS7xx on the SPI port - %v", err)
+	}
+	return nil
+}
+
+// init checks the bridge answers through its scratch pad register and
+// configures the pins as GPIO inputs.
+func (b *Bridge) init() error {
+	if err := b.bus.write(regSPR, 0, 0x55); err != nil {
+		return err
+	}
+	spr := make([]byte, 1)
+	if err := b.bus.read(regSPR, 0, spr); err != nil {
+		return err
+	}
+	if spr[0] != 0x55 {
+		return fmt.Errorf("scratch pad read back as %#x", spr[0])
+	}
+	if err := b.bus.write(regIOControl, 0, 0x00); err != nil {
+		return err
+	}
+	return b.bus.write(regIODir, 0, 0x00)
+}
+
+// UART configures the channel ch, 0 or 1 on the SC16IS752 and SC16IS762
+// and 0 on the others, at baud bauds, 8N1. Its FIFOs are emptied.
+func (b *Bridge) UART(ch, baud int) (*UART, error) {
+	if ch < 0 || ch > 1 {
+		return nil, fmt.Errorf("channel %d is not a UART channel", ch)
+	}
+	if baud <= 0 {
+		return nil, fmt.Errorf("invalid baud rate %d", baud)
+	}
+	div := (b.crystal + 8*baud) / (16 * baud)
+	if div < 1 || div > 0xFFFF {
+		return nil, fmt.Errorf("%d bauds cannot be derived from a %dHz crystal", baud, b.crystal)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, w := range []struct {
+		reg, v byte
+	}{
+		{regLCR, lcrDivisorLatch},
+		{regDLL, byte(div)},
+		{regDLH, byte(div >> 8)},
+		{regLCR, lcr8N1},
+		{regFCR, fcrEnable},
+	} {
+		if err := b.bus.write(w.reg, ch, w.v); err != nil {
+			return nil, fmt.Errorf("configuring channel %d failed - %v", ch, err)
+		}
+	}
+	return &UART{b: b, ch: ch}, nil
+}
+
+// Input configures the pin GPIOp (0-7) as an input.
+func (b *Bridge) Input(p int) (gpio.Pin, error) {
+	return b.pin(p, false, 0)
+}
+
+// Output configures the pin GPIOp (0-7) as an output initially driven to
+// v.
+func (b *Bridge) Output(p int, v int) (gpio.Pin, error) {
+	return b.pin(p, true, v)
+}
+
+func (b *Bridge) pin(p int, out bool, v int) (gpio.Pin, error) {
+	if p < 0 || p > 7 {
+		return nil, fmt.Errorf("GPIO%d is not a pin of the bridge", p)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	mask := byte(1) << uint(p)
+	if out {
+		if err := b.setOutput(mask, v); err != nil {
+			return nil, err
+		}
+	}
+	dir := b.ioDir &^ mask
+	if out {
+		dir |= mask
+	}
+	if err := b.bus.write(regIODir, 0, dir); err != nil {
+		return nil, err
+	}
+	b.ioDir = dir
+	return &pin{b: b, p: p, out: out}, nil
+}
+
+// setOutput drives the output latch of the pins of mask to v.
+func (b *Bridge) setOutput(mask byte, v int) error {
+	state := b.ioOut &^ mask
+	if v != 0 {
+		state |= mask
+	}
+	if err := b.bus.write(regIOState, 0, state); err != nil {
+		return err
+	}
+	b.ioOut = state
+	return nil
+}
+
+// Close closes the bridge, the UARTs and pins are unusable afterwards.
+func (b *Bridge) Close() error {
+	return b.bus.Close()
+}
+
+type pin struct {
+	b   *Bridge
+	p   int
+	out bool
+}
+
+func (p *pin) Read() (int, error) {
+	p.b.mu.Lock()
+	defer p.b.mu.Unlock()
+	state := make([]byte, 1)
+	if err := p.b.bus.read(regIOState, 0, state); err != nil {
+		return 0, err
+	}
+	return int(state[0]>>uint(p.p)) & 1, nil
+}
+
+func (p *pin) Write(v int) error {
+	if !p.out {
+		return fmt.Errorf("GPIO%d is not configured as an output", p.p)
+	}
+	p.b.mu.Lock()
+	defer p.b.mu.Unlock()
+	return p.b.setOutput(1<<uint(p.p), v)
+}
+
+func (p *pin) Close() error { return nil }
+
+// UART is a channel of the bridge. Read blocks until data is received or
+// the read deadline passes, Write until all the data is queued in the
+// transmit FIFO.
+type UART struct {
+	b  *Bridge
+	ch int
+
+	mu       sync.Mutex
+	deadline time.Time
+}
+
+var _ io.ReadWriter = (*UART)(nil)
+
+// SetReadDeadline sets the deadline of the reads, as *os.File does. Reads
+// past the deadline fail with os.ErrDeadlineExceeded. A zero t disables
+// the deadline.
+func (u *UART) SetReadDeadline(t time.Time) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.deadline = t
+	return nil
+}
+
+// Read reads the bytes received, up to len(p) and at least one.
+func (u *UART) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	u.mu.Lock()
+	deadline := u.deadline
+	u.mu.Unlock()
+	c := clock.Or(u.b.Clock)
+	for {
+		n, err := u.level(regRXLVL)
+		if err != nil {
+			return 0, err
+		}
+		if n > 0 {
+			if n > len(p) {
+				n = len(p)
+			}
+			return n, u.b.locked(func() error {
+				return u.b.bus.read(regRHR, u.ch, p[:n])
+			})
+		}
+		if !deadline.IsZero() && !c.Now().Before(deadline) {
+			return 0, os.ErrDeadlineExceeded
+		}
+		c.Sleep(pollInterval)
+	}
+}
+
+// Write queues p in the transmit FIFO, waiting for space as needed.
+func (u *UART) Write(p []byte) (int, error) {
+	c := clock.Or(u.b.Clock)
+	written := 0
+	for written < len(p) {
+		n, err := u.level(regTXLVL)
+		if err != nil {
+			return written, err
+		}
+		if n == 0 {
+			c.Sleep(pollInterval)
+			continue
+		}
+		if n > len(p)-written {
+			n = len(p) - written
+		}
+		err = u.b.locked(func() error {
+			return u.b.bus.write(regRHR, u.ch, p[written:written+n]...)
+		})
+		if err != nil {
+			return written, err
+		}
+		written += n
+	}
+	return written, nil
+}
+
+// Overrun reports whether received bytes were lost since the last call
+// because the receive FIFO was full.
+func (u *UART) Overrun() (bool, error) {
+	lsr := make([]byte, 1)
+	err := u.b.locked(func() error {
+		return u.b.bus.read(regLSR, u.ch, lsr)
+	})
+	return lsr[0]&lsrOverrun != 0, err
+}
+
+// level reads the number of bytes in the receive FIFO or of spaces in the
+// transmit FIFO.
+func (u *UART) level(reg byte) (int, error) {
+	lvl := make([]byte, 1)
+	err := u.b.locked(func() error {
+		return u.b.bus.read(reg, u.ch, lvl)
+	})
+	if err != nil {
+		return 0, err
+	}
+	if lvl[0] > fifoSize {
+		return 0, fmt.Errorf("invalid FIFO level %d", lvl[0])
+	}
+	return int(lvl[0]), nil
+}
+
+// Close releases the channel, the bridge stays open.
+func (u *UART) Close() error { return nil }
+
+func (b *Bridge) locked(f func() error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return f()
+}
+
+// addr is the register address byte of reg on channel ch.
+func addr(reg byte, ch int) byte {
+	return reg<<3 | byte(ch)<<1
+}
+
+type i2cBus struct{ dev *i2c.Device }
+
+func (i i2cBus) read(reg byte, ch int, b []byte) error {
+	return i.dev.ReadReg(addr(reg, ch), b)
+}
+
+func (i i2cBus) write(reg byte, ch int, b ...byte) error {
+	return i.dev.WriteReg(addr(reg, ch), b)
+}
+
+func (i i2cBus) Close() error { return i.dev.Close() }
+
+type spiBus struct{ dev *spi.Device }
+
+func (s spiBus) read(reg byte, ch int, b []byte) error {
+	w := make([]byte, 1+len(b))
+	w[0] = 0x80 | addr(reg, ch)
+	r := make([]byte, len(w))
+	if err := s.dev.Tx(w, r); err != nil {
+		return err
+	}
+	copy(b, r[1:])
+	return nil
+}
+
+func (s spiBus) write(reg byte, ch int, b ...byte) error {
+	return s.dev.Tx(append([]byte{addr(reg, ch)}, b...), nil)
+}
+
+func (s spiBus) Close() error { return s.dev.Close() }
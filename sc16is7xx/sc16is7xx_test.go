@@ -0,0 +1,185 @@
+package sc16is7xx
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/i2csim"
+	"github.com/goiot/devices/spisim"
+)
+
+// bridge is a fake SC16IS752, whose transmit FIFO has room for space bytes
+// per poll.
+type bridge struct {
+	regs  [2][16]byte
+	dll   [2]byte
+	rx    [2][]byte
+	tx    [2][]byte
+	space int
+}
+
+func (b *bridge) reg(a byte) (byte, int) { return a >> 3 & 0x0F, int(a>>1) & 3 }
+
+func (b *bridge) write(a byte, data []byte) {
+	reg, ch := b.reg(a)
+	if reg == regRHR && b.regs[ch][regLCR]&lcrDivisorLatch == 0 {
+		b.tx[ch] = append(b.tx[ch], data...)
+		return
+	}
+	if reg == regDLL && b.regs[ch][regLCR]&lcrDivisorLatch != 0 {
+		b.dll[ch] = data[0]
+		return
+	}
+	b.regs[ch][reg] = data[0]
+}
+
+func (b *bridge) read(a byte, r []byte) {
+	reg, ch := b.reg(a)
+	switch reg {
+	case regRHR:
+		n := copy(r, b.rx[ch])
+		b.rx[ch] = b.rx[ch][n:]
+	case regRXLVL:
+		r[0] = byte(len(b.rx[ch]))
+	case regTXLVL:
+		r[0] = byte(b.space)
+	case regIOState:
+		r[0] = b.regs[0][regIOState]
+	default:
+		r[0] = b.regs[ch][reg]
+	}
+}
+
+func (b *bridge) Tx(w, r []byte) error {
+	if len(r) == 0 {
+		b.write(w[0], w[1:])
+		return nil
+	}
+	b.read(w[0], r)
+	return nil
+}
+
+func newBridge(t *testing.T, b *bridge) *Bridge {
+	bus := i2csim.NewBus()
+	bus.Attach(Addr, b)
+	d, err := Open(bus, Addr, Crystal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := clock.NewFake(time.Time{})
+	c.SetAutoSleep(true)
+	d.Clock = c
+	return d
+}
+
+func TestUART(t *testing.T) {
+	b := &bridge{space: 3}
+	d := newBridge(t, b)
+	u, err := d.UART(1, 9600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.dll[1] != 96 || b.regs[1][regLCR] != lcr8N1 || b.regs[1][regFCR] != fcrEnable {
+		t.Errorf("divisor %d, LCR %#x, FCR %#x; want 96, 0x03, 0x07", b.dll[1], b.regs[1][regLCR], b.regs[1][regFCR])
+	}
+
+	if n, err := u.Write([]byte("$GPGGA")); n != 6 || err != nil {
+		t.Errorf("Write = %d, %v; want 6, nil", n, err)
+	}
+	if string(b.tx[1]) != "$GPGGA" || len(b.tx[0]) != 0 {
+		t.Errorf("transmitted %q on channel 1 and %q on channel 0", b.tx[1], b.tx[0])
+	}
+
+	b.rx[1] = []byte("OK\r\n")
+	buf := make([]byte, 3)
+	if n, err := u.Read(buf); n != 3 || err != nil || string(buf) != "OK\r" {
+		t.Errorf("Read = %d, %v, %q; want 3, nil, \"OK\\r\"", n, err, buf[:n])
+	}
+	if n, err := u.Read(buf); n != 1 || err != nil || buf[0] != '\n' {
+		t.Errorf("Read = %d, %v, %q; want 1, nil, \"\\n\"", n, err, buf[:n])
+	}
+
+	u.SetReadDeadline(clock.Or(d.Clock).Now().Add(10 * time.Millisecond))
+	if _, err := u.Read(buf); err != os.ErrDeadlineExceeded {
+		t.Errorf("Read with no data = %v; want %v", err, os.ErrDeadlineExceeded)
+	}
+
+	if _, err := d.UART(0, 1); err == nil {
+		t.Error("expected an error with a divisor above 0xffff")
+	}
+	if _, err := d.UART(2, 9600); err == nil {
+		t.Error("expected an error with channel 2")
+	}
+}
+
+func TestPins(t *testing.T) {
+	b := &bridge{}
+	d := newBridge(t, b)
+	out, err := d.Output(5, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in, err := d.Input(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir, state := b.regs[0][regIODir], b.regs[0][regIOState]; dir != 0x20 || state != 0x20 {
+		t.Errorf("IODir %#x, IOState %#x; want 0x20, 0x20", dir, state)
+	}
+	if err := out.Write(0); err != nil || b.regs[0][regIOState] != 0 {
+		t.Errorf("Write(0) = %v, IOState %#x", err, b.regs[0][regIOState])
+	}
+	if err := in.Write(1); err == nil {
+		t.Error("expected an error writing an input")
+	}
+	b.regs[0][regIOState] = 0x04
+	if v, err := in.Read(); v != 1 || err != nil {
+		t.Errorf("Read = %d, %v; want 1, nil", v, err)
+	}
+	if _, err := d.Input(8); err == nil {
+		t.Error("expected an error with GPIO8")
+	}
+}
+
+func TestSPI(t *testing.T) {
+	b := &bridge{space: fifoSize}
+	port := spisim.New(spisim.DeviceFunc(func(w, r []byte) error {
+		if w[0]&0x80 == 0 {
+			b.write(w[0], w[1:])
+			return nil
+		}
+		b.read(w[0]&0x7F, r[1:])
+		return nil
+	}))
+	d, err := OpenSPI(port, Crystal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := d.UART(0, 115200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.dll[0] != 8 {
+		t.Errorf("divisor %d; want 8", b.dll[0])
+	}
+	b.rx[0] = []byte{0x42, 0x4D}
+	buf := make([]byte, 4)
+	if n, err := u.Read(buf); n != 2 || err != nil || buf[0] != 0x42 || buf[1] != 0x4D {
+		t.Errorf("Read = %d, %v, %x; want 2, nil, 424d", n, err, buf[:n])
+	}
+}
+
+func TestNoBridge(t *testing.T) {
+	bus := i2csim.NewBus()
+	bus.Attach(Addr+1, i2csim.DeviceFunc(func(w, r []byte) error {
+		for i := range r {
+			r[i] = 0xFF
+		}
+		return nil
+	}))
+	if _, err := Open(bus, Addr+1, Crystal); err == nil {
+		t.Error("expected an error with no bridge at the address")
+	}
+}
@@ -0,0 +1,121 @@
+// Package scheduler runs the periodic tasks of an application, such as
+// taking readings, refreshing a display or rotating between pages, at
+// intervals or following cron expressions.
+//
+// A run is skipped if the previous run of the same task is not finished,
+// so a slow bus never piles up work, and Stop waits for the running tasks
+// to return.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/goiot/devices/clock"
+)
+
+// Task is a unit of work run by the scheduler.
+type Task struct {
+	// Name identifies the task in errors.
+	Name string
+
+	// Run does the work, ctx is canceled when the scheduler stops.
+	Run func(ctx context.Context) error
+
+	// Jitter delays each run by a random duration up to Jitter, which
+	// spreads the load when many devices poll the same service.
+	Jitter time.Duration
+}
+
+// Scheduler runs tasks. It can be used by multiple goroutines.
+type Scheduler struct {
+	// OnError is called with the errors returned by the tasks.
+	// Errors are ignored if nil.
+	OnError func(name string, err error)
+
+	// Clock times the runs, clock.Real if nil. It must be set before the
+	// tasks are added.
+	Clock clock.Clock
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup // task loops and their runs
+}
+
+// New returns a scheduler, tasks start as soon as they are added.
+func New() *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{ctx: ctx, cancel: cancel}
+}
+
+// Every runs t every interval, the first run happens after one interval.
+func (s *Scheduler) Every(interval time.Duration, t Task) error {
+	if interval <= 0 {
+		return errors.New("interval should be positive")
+	}
+	s.start(t, func(now time.Time) time.Time { return now.Add(interval) })
+	return nil
+}
+
+// Cron runs t at the times matching the cron expression spec, in the
+// local time zone, e.g. "*/15 * * * *" every 15 minutes and "0 8 * * 1-5"
+// at 8am on weekdays. An expression which never matches, such as
+// "0 0 30 2 *", is an error.
+func (s *Scheduler) Cron(spec string, t Task) error {
+	sched, err := parseCron(spec)
+	if err != nil {
+		return err
+	}
+	s.start(t, sched.next)
+	return nil
+}
+
+func (s *Scheduler) start(t Task, next func(time.Time) time.Time) {
+	c := clock.Or(s.Clock)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		var running sync.Mutex
+		for {
+			now := c.Now()
+			at := next(now)
+			if at.IsZero() {
+				if s.OnError != nil {
+					s.OnError(t.Name, errors.New("the schedule has no time left to run"))
+				}
+				return
+			}
+			if t.Jitter > 0 {
+				at = at.Add(time.Duration(rand.Int63n(int64(t.Jitter))))
+			}
+			timer := c.NewTimer(at.Sub(now))
+			select {
+			case <-timer.C():
+			case <-s.ctx.Done():
+				timer.Stop()
+				return
+			}
+			if !running.TryLock() {
+				continue // the previous run is not over
+			}
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				defer running.Unlock()
+				if err := t.Run(s.ctx); err != nil && s.OnError != nil {
+					s.OnError(t.Name, err)
+				}
+			}()
+		}
+	}()
+}
+
+// Stop stops scheduling runs, cancels the context of the running tasks
+// and waits for them to return.
+func (s *Scheduler) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
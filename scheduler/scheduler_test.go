@@ -0,0 +1,168 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/clock"
+)
+
+func TestCronNext(t *testing.T) {
+	from := time.Date(2016, 3, 4, 10, 7, 30, 0, time.UTC) // a Friday
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2016, 3, 4, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2016, 3, 4, 10, 15, 0, 0, time.UTC)},
+		{"0 8 * * 1-5", time.Date(2016, 3, 7, 8, 0, 0, 0, time.UTC)},
+		{"30 9,18 * * *", time.Date(2016, 3, 4, 18, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2016, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 29 2 *", time.Date(2016, 2, 29, 12, 0, 0, 0, time.UTC).AddDate(4, 0, 0)},
+		{"0 0 * * 7", time.Date(2016, 3, 6, 0, 0, 0, 0, time.UTC)},
+		// either the day of month or the day of week
+		{"0 0 10 * 6", time.Date(2016, 3, 5, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		s, err := parseCron(tt.spec)
+		if err != nil {
+			t.Errorf("%q: %v", tt.spec, err)
+			continue
+		}
+		if got := s.next(from); !got.Equal(tt.want) {
+			t.Errorf("%q: next = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestCronNextZone(t *testing.T) {
+	for _, name := range []string{"Asia/Kolkata", "Asia/Kathmandu", "Australia/Adelaide"} {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			t.Skip(err)
+		}
+		s, _ := parseCron("0 9 * * *")
+		from := time.Date(2016, 3, 4, 10, 12, 0, 0, loc)
+		want := time.Date(2016, 3, 5, 9, 0, 0, 0, loc)
+		if got := s.next(from); !got.Equal(want) {
+			t.Errorf("%s: next = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestCronInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"0 0 30 2 *",
+		"0 0 31 4,6,9,11 *",
+	} {
+		if _, err := parseCron(spec); err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+}
+
+func TestEvery(t *testing.T) {
+	s := New()
+	var runs int32
+	s.Every(5*time.Millisecond, Task{
+		Name: "reading",
+		Run: func(ctx context.Context) error {
+			atomic.AddInt32(&runs, 1)
+			return nil
+		},
+	})
+	time.Sleep(60 * time.Millisecond)
+	s.Stop()
+	if n := atomic.LoadInt32(&runs); n < 3 {
+		t.Errorf("got %d runs, want at least 3", n)
+	}
+}
+
+func TestCronClock(t *testing.T) {
+	c := clock.NewFake(time.Date(2016, 3, 4, 7, 59, 30, 0, time.Local))
+	s := New()
+	s.Clock = c
+	defer s.Stop()
+	runs := make(chan time.Time, 1)
+	s.Cron("0 8 * * *", Task{
+		Name: "report",
+		Run: func(ctx context.Context) error {
+			runs <- c.Now()
+			return nil
+		},
+	})
+	c.BlockUntil(1)
+	c.Advance(29 * time.Second)
+	select {
+	case <-runs:
+		t.Fatal("run before 8am")
+	default:
+	}
+	c.Advance(time.Second)
+	if at := <-runs; at.Hour() != 8 || at.Minute() != 0 {
+		t.Errorf("run at %v; want 8am", at)
+	}
+	c.BlockUntil(1)
+	c.Advance(24 * time.Hour)
+	if at := <-runs; at.Day() != 5 {
+		t.Errorf("second run at %v; want the next day", at)
+	}
+}
+
+func TestNoOverlap(t *testing.T) {
+	s := New()
+	var running, overlaps int32
+	s.Every(time.Millisecond, Task{
+		Name: "slow",
+		Run: func(ctx context.Context) error {
+			if atomic.AddInt32(&running, 1) > 1 {
+				atomic.AddInt32(&overlaps, 1)
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return nil
+		},
+	})
+	time.Sleep(50 * time.Millisecond)
+	s.Stop()
+	if n := atomic.LoadInt32(&overlaps); n != 0 {
+		t.Errorf("got %d overlapping runs", n)
+	}
+}
+
+func TestStopWaits(t *testing.T) {
+	s := New()
+	errs := make(chan error, 10)
+	s.OnError = func(name string, err error) { errs <- err }
+	started := make(chan struct{}, 1)
+	var finished int32
+	s.Every(time.Millisecond, Task{
+		Name: "refresh",
+		Run: func(ctx context.Context) error {
+			select {
+			case started <- struct{}{}:
+			default:
+			}
+			<-ctx.Done()
+			atomic.StoreInt32(&finished, 1)
+			return errors.New("canceled")
+		},
+	})
+	<-started
+	s.Stop()
+	if atomic.LoadInt32(&finished) != 1 {
+		t.Error("Stop returned before the running task")
+	}
+	if len(errs) == 0 {
+		t.Error("OnError was not called")
+	}
+}
@@ -0,0 +1,57 @@
+#!/bin/sh
+# benchcmp.sh compares the benchmarks of HEAD with those of a base
+# revision, master by default, with benchstat. It fails if a benchmark
+# got significantly slower, or allocates more, beyond THRESHOLD percent.
+#
+# usage: scripts/benchcmp.sh [base] [packages]
+#
+# benchstat is installed with: go install golang.org/x/perf/cmd/benchstat@latest
+# The working tree must be clean, the base revision is checked out to run
+# its benchmarks.
+set -e
+
+base=${1:-master}
+pkgs=${2:-./...}
+threshold=${THRESHOLD:-10}
+count=${COUNT:-10}
+
+cd "$(git rev-parse --show-toplevel)"
+if [ -n "$(git status --porcelain --untracked-files=no)" ]; then
+	echo "benchcmp: the working tree has changes, commit or stash them" >&2
+	exit 2
+fi
+head=$(git symbolic-ref -q --short HEAD || git rev-parse HEAD)
+tmp=$(mktemp -d)
+trap 'git checkout -q "$head"; rm -rf "$tmp"' EXIT
+
+bench() {
+	go test -run='^$' -bench=. -benchmem -count="$count" $pkgs | grep -v -e '^ok' -e '^PASS' > "$1"
+}
+
+git checkout -q "$base"
+bench "$tmp/old.txt"
+git checkout -q "$head"
+bench "$tmp/new.txt"
+
+benchstat "$tmp/old.txt" "$tmp/new.txt" | tee "$tmp/cmp.txt"
+
+# The unit of each table is the word before "vs base" in its header. Less
+# is better for the units per operation, more for the rates (MB/s...).
+awk -v t="$threshold" '
+	/vs base/ {
+		for (i = 2; i <= NF; i++)
+			if ($i == "vs")
+				unit = $(i - 1)
+		next
+	}
+	/% \(p=/ {
+		match($0, /[+-][0-9.]+% \(p=/)
+		d = substr($0, RSTART, RLENGTH - 5) + 0
+		worse = unit ~ /\/op$/ ? d : -d
+		if (worse > t) {
+			printf "benchcmp: %s regressed by %.2f%% in %s\n", $1, worse, unit
+			bad = 1
+		}
+	}
+	END { exit bad }
+' "$tmp/cmp.txt"
@@ -0,0 +1,19 @@
+package sensehat
+
+import "github.com/goiot/devices/compensate"
+
+// Conditions implements compensate.Source with the humidity and the
+// temperature of the HTS221 and the pressure of the LPS25H.
+func (h *SenseHAT) Conditions() (compensate.Conditions, error) {
+	c := compensate.Unknown()
+	rh, celsius, err := h.Humidity.Read()
+	if err != nil {
+		return c, err
+	}
+	hPa, _, err := h.Pressure.Read()
+	if err != nil {
+		return c, err
+	}
+	c.Temperature, c.Humidity, c.Pressure = celsius, rh, hPa
+	return c, nil
+}
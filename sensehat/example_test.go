@@ -0,0 +1,62 @@
+package sensehat_test
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"time"
+
+	"github.com/goiot/devices/i2cbus"
+	"github.com/goiot/devices/sensehat"
+)
+
+func Example() {
+	bus, err := i2cbus.Open("primary")
+	if err != nil {
+		panic(err)
+	}
+	hat, err := sensehat.Open(bus)
+	if err != nil {
+		panic(err)
+	}
+	defer hat.Close()
+
+	hPa, _, err := hat.Pressure.Read()
+	if err != nil {
+		panic(err)
+	}
+	rh, celsius, err := hat.Humidity.Read()
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("%.1fhPa %.1f%% %.1fC\n", hPa, rh, celsius)
+
+	// light the LED under the joystick direction until it is pushed in
+	for {
+		k, err := hat.Joystick.Read()
+		if err != nil {
+			panic(err)
+		}
+		if k&sensehat.Enter != 0 {
+			return
+		}
+		x, y := 3, 3
+		switch {
+		case k&sensehat.Up != 0:
+			y = 0
+		case k&sensehat.Down != 0:
+			y = 7
+		case k&sensehat.Left != 0:
+			x = 0
+		case k&sensehat.Right != 0:
+			x = 7
+		}
+		draw.Draw(hat.LEDs, hat.LEDs.Bounds(), image.Black, image.Point{}, draw.Src)
+		hat.LEDs.SetPixel(x, y, color.RGBA{0, 0x80, 0xFF, 0xFF})
+		if err := hat.LEDs.Draw(); err != nil {
+			panic(err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
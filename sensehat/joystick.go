@@ -0,0 +1,47 @@
+package sensehat
+
+import (
+	"strings"
+
+	"golang.org/x/exp/io/i2c"
+)
+
+// Key is a set of joystick directions.
+type Key byte
+
+const (
+	Down Key = 1 << iota
+	Right
+	Up
+	Enter // the joystick is pushed in
+	Left
+)
+
+func (k Key) String() string {
+	var names []string
+	for i, name := range []string{"Down", "Right", "Up", "Enter", "Left"} {
+		if k&(1<<uint(i)) != 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "None"
+	}
+	return strings.Join(names, "|")
+}
+
+// Joystick is the 5-button joystick.
+type Joystick struct {
+	dev *i2c.Device
+}
+
+// Read returns the keys currently pressed. The joystick is polled: the
+// interrupt line of GPIO23 falls when a key changes, and can be waited
+// for with a gpio.Watcher before calling Read.
+func (j *Joystick) Read() (Key, error) {
+	b := make([]byte, 1)
+	if err := j.dev.ReadReg(regJoystick, b); err != nil {
+		return 0, err
+	}
+	return Key(b[0] & 0x1F), nil
+}
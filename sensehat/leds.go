@@ -0,0 +1,81 @@
+package sensehat
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"golang.org/x/exp/io/i2c"
+)
+
+// LEDs is the 8x8 RGB LED matrix. It implements draw.Image, drawing on an
+// intermediate buffer sent to the matrix by Draw. Pixel (0, 0) is next to
+// the HDMI connector of the Pi.
+type LEDs struct {
+	dev *i2c.Device
+	buf [1 + 8*24]byte // register followed by the 5-bit channels
+}
+
+// ColorModel implements image.Image.
+func (l *LEDs) ColorModel() color.Model { return color.RGBAModel }
+
+// Bounds implements image.Image.
+func (l *LEDs) Bounds() image.Rectangle { return image.Rect(0, 0, 8, 8) }
+
+// At implements image.Image.
+func (l *LEDs) At(x, y int) color.Color {
+	if !(image.Point{x, y}.In(l.Bounds())) {
+		return color.RGBA{}
+	}
+	i := 1 + y*24 + x
+	c5to8 := func(v byte) uint8 { return v<<3 | v>>2 }
+	return color.RGBA{c5to8(l.buf[i]), c5to8(l.buf[i+8]), c5to8(l.buf[i+16]), 0xFF}
+}
+
+// Set implements draw.Image, pixels out of the matrix are ignored.
+func (l *LEDs) Set(x, y int, c color.Color) {
+	if !(image.Point{x, y}.In(l.Bounds())) {
+		return
+	}
+	r, g, b, _ := c.RGBA()
+	i := 1 + y*24 + x
+	l.buf[i] = byte(r >> 11)
+	l.buf[i+8] = byte(g >> 11)
+	l.buf[i+16] = byte(b >> 11)
+}
+
+// SetPixel sets the color of the LED at x, y. A call to Draw is required
+// to display it.
+func (l *LEDs) SetPixel(x, y int, c color.Color) error {
+	if !(image.Point{x, y}.In(l.Bounds())) {
+		return fmt.Errorf("(x=%v, y=%v) is out of bounds on the 8x8 matrix", x, y)
+	}
+	l.Set(x, y, c)
+	return nil
+}
+
+// SetImage draws an image on the buffer starting from x, y.
+// A call to Draw is required to display it.
+func (l *LEDs) SetImage(x, y int, img image.Image) {
+	b := img.Bounds()
+	r := b.Sub(b.Min).Add(image.Pt(x, y)).Intersect(l.Bounds())
+	for j := r.Min.Y; j < r.Max.Y; j++ {
+		for i := r.Min.X; i < r.Max.X; i++ {
+			l.Set(i, j, img.At(b.Min.X+i-x, b.Min.Y+j-y))
+		}
+	}
+}
+
+// Draw sends the buffer to the matrix.
+func (l *LEDs) Draw() error {
+	l.buf[0] = regLEDs
+	return l.dev.Write(l.buf[:])
+}
+
+// Clear turns off all the LEDs.
+func (l *LEDs) Clear() error {
+	for i := range l.buf {
+		l.buf[i] = 0
+	}
+	return l.Draw()
+}
@@ -0,0 +1,68 @@
+package sensehat
+
+// I2C addresses of the components.
+const (
+	addrATtiny = 0x46 // LED matrix and joystick controller
+	addrLPS25H = 0x5C
+	addrHTS221 = 0x5F
+	addrAG     = 0x6A // LSM9DS1 accelerometer and gyroscope
+	addrMag    = 0x1C // LSM9DS1 magnetometer
+)
+
+// ATtiny88 registers.
+const (
+	regLEDs     = 0x00 // 8 rows of 8 red, 8 green and 8 blue 5-bit values
+	regJoystick = 0xF2
+	regID       = 0xF0
+	idATtiny    = 's'
+)
+
+// Registers shared by the ST sensors.
+const (
+	regWhoAmI = 0x0F
+	autoInc   = 0x80 // MSB of the register address, reads several registers
+)
+
+// LPS25H registers.
+const (
+	lpsID       = 0xBD
+	lpsCtrl1    = 0x20
+	lpsCtrl1On  = 0xB4 // power on, 12.5Hz, block data update
+	lpsPressure = 0x28
+)
+
+// HTS221 registers.
+const (
+	htsID       = 0xBC
+	htsCtrl1    = 0x20
+	htsCtrl1On  = 0x87 // power on, block data update, 12.5Hz
+	htsHumidity = 0x28
+	htsCalib    = 0x30 // 16 bytes of factory calibration
+)
+
+// LSM9DS1 registers.
+const (
+	agID        = 0x68
+	agCtrl1G    = 0x10
+	agCtrl1GOn  = 0x60 // 119Hz, 245dps
+	agGyro      = 0x18
+	agCtrl6XL   = 0x20
+	agCtrl6XLOn = 0x60 // 119Hz, 2g
+	agAccel     = 0x28
+
+	magID      = 0x3D
+	magCtrl1   = 0x20
+	magCtrl1On = 0x70 // ultra high performance X and Y, 10Hz
+	magCtrl3   = 0x22
+	magCtrl3On = 0x00 // continuous conversion
+	magCtrl4   = 0x23
+	magCtrl4On = 0x0C // ultra high performance Z
+	magOut     = 0x28
+)
+
+// Sensitivities of the LSM9DS1 at the configured full scales.
+const (
+	accelScale = 0.061e-3 // g/LSB at 2g
+	gyroScale  = 8.75e-3  // dps/LSB at 245dps
+	magScale   = 0.14e-3  // gauss/LSB at 4 gauss
+)
@@ -0,0 +1,99 @@
+// Package sensehat implements a driver for the Raspberry Pi Sense HAT: its
+// 8x8 RGB LED matrix, 5-button joystick, LPS25H pressure sensor, HTS221
+// humidity sensor and LSM9DS1 inertial measurement unit, all on the
+// same I2C bus.
+package sensehat
+
+import (
+	"fmt"
+
+	"golang.org/x/exp/io/i2c"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+// SenseHAT represents the components of a Sense HAT. It must be closed if
+// no longer in use.
+type SenseHAT struct {
+	LEDs     *LEDs
+	Joystick *Joystick
+	Pressure *Pressure
+	Humidity *Humidity
+	IMU      *IMU
+}
+
+// Open opens all the components of the board. The sensors are powered on
+// and sample continuously.
+func Open(o driver.Opener) (*SenseHAT, error) {
+	h := &SenseHAT{}
+	fail := func(err error) (*SenseHAT, error) {
+		h.Close()
+		return nil, err
+	}
+
+	tiny, err := openChecked(o, addrATtiny, regID, idATtiny)
+	if err != nil {
+		return fail(err)
+	}
+	h.LEDs = &LEDs{dev: tiny}
+	h.Joystick = &Joystick{dev: tiny}
+
+	if h.Pressure, err = openPressure(o); err != nil {
+		return fail(err)
+	}
+	if h.Humidity, err = openHumidity(o); err != nil {
+		return fail(err)
+	}
+	if h.IMU, err = openIMU(o); err != nil {
+		return fail(err)
+	}
+	return h, nil
+}
+
+// openChecked opens the device at addr and checks its identification
+// register.
+func openChecked(o driver.Opener, addr int, reg, id byte) (*i2c.Device, error) {
+	dev, err := i2c.Open(o, addr)
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, 1)
+	if err := dev.ReadReg(reg, b); err != nil {
+		dev.Close()
+		return nil, fmt.Errorf("no device responding at address %#x - %v", addr, err)
+	}
+	if b[0] != id {
+		dev.Close()
+		return nil, fmt.Errorf("unexpected device %#x at address %#x, expected %#x", b[0], addr, id)
+	}
+	return dev, nil
+}
+
+// Close turns off the LEDs and closes all the components.
+func (h *SenseHAT) Close() error {
+	var first error
+	keep := func(err error) {
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+	if h.LEDs != nil {
+		keep(h.LEDs.Clear())
+		keep(h.LEDs.dev.Close())
+	}
+	if h.Pressure != nil {
+		keep(h.Pressure.dev.Close())
+	}
+	if h.Humidity != nil {
+		keep(h.Humidity.dev.Close())
+	}
+	if h.IMU != nil {
+		keep(h.IMU.ag.Close())
+		keep(h.IMU.mag.Close())
+	}
+	return first
+}
+
+// int16le decodes the little endian 16-bit value of the sensors.
+func int16le(b []byte) int16 {
+	return int16(uint16(b[0]) | uint16(b[1])<<8)
+}
@@ -0,0 +1,151 @@
+package sensehat
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+// bus is a fake Sense HAT, every device is a map of 256 registers.
+type bus map[int]*[256]byte
+
+func (b bus) Open(addr int, tenbit bool) (driver.Conn, error) {
+	if b[addr] == nil {
+		b[addr] = &[256]byte{}
+	}
+	return &conn{addr: addr, regs: b[addr]}, nil
+}
+
+type conn struct {
+	addr int
+	regs *[256]byte
+	reg  byte
+}
+
+func (c *conn) Tx(w, r []byte) error {
+	if len(w) > 0 {
+		c.reg = w[0]
+		if c.addr != addrATtiny {
+			c.reg &^= autoInc
+		}
+		for i, v := range w[1:] {
+			c.regs[int(c.reg)+i] = v
+		}
+	}
+	for i := range r {
+		r[i] = c.regs[int(c.reg)+i]
+	}
+	return nil
+}
+
+func (c *conn) Close() error { return nil }
+
+func newBus() bus {
+	b := bus{}
+	for addr, id := range map[int][2]byte{
+		addrATtiny: {regID, idATtiny},
+		addrLPS25H: {regWhoAmI, lpsID},
+		addrHTS221: {regWhoAmI, htsID},
+		addrAG:     {regWhoAmI, agID},
+		addrMag:    {regWhoAmI, magID},
+	} {
+		b[addr] = &[256]byte{}
+		b[addr][id[0]] = id[1]
+	}
+	// HTS221 calibration: 20% at 0, 80% at 6000; 10C at 0, 30C at 1000
+	copy(b[addrHTS221][htsCalib:], []byte{40, 160, 80, 240, 0, 0, 0, 0, 0, 0, 0x70, 0x17, 0, 0, 0xE8, 0x03})
+	return b
+}
+
+func TestOpen(t *testing.T) {
+	b := newBus()
+	h, err := Open(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b[addrLPS25H][lpsCtrl1] != lpsCtrl1On || b[addrMag][magCtrl4] != magCtrl4On {
+		t.Error("the sensors are not powered on")
+	}
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	delete(b, addrHTS221)
+	if _, err := Open(b); err == nil {
+		t.Error("expected an error without HTS221")
+	}
+}
+
+func TestSensors(t *testing.T) {
+	b := newBus()
+	h, err := Open(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 1013.25hPa, 42.5C
+	copy(b[addrLPS25H][lpsPressure:], []byte{0x00, 0x54, 0x3F, 0, 0})
+	if p, c, err := h.Pressure.Read(); err != nil || p != 1013.25 || c != 42.5 {
+		t.Errorf("Pressure = %v, %v, %v; want 1013.25, 42.5", p, c, err)
+	}
+	// 3000 and 500 are half way on the calibration lines
+	copy(b[addrHTS221][htsHumidity:], []byte{0xB8, 0x0B, 0xF4, 0x01})
+	if rh, c, err := h.Humidity.Read(); err != nil || rh != 50 || c != 20 {
+		t.Errorf("Humidity = %v, %v, %v; want 50, 20", rh, c, err)
+	}
+	// 1g on Z
+	copy(b[addrAG][agAccel:], []byte{0, 0, 0, 0, 0x05, 0x40})
+	if v, err := h.IMU.Acceleration(); err != nil || v.Z < 0.99 || v.Z > 1.01 {
+		t.Errorf("Acceleration = %+v, %v; want Z=1g", v, err)
+	}
+}
+
+func TestLEDs(t *testing.T) {
+	b := newBus()
+	h, err := Open(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.LEDs.SetPixel(2, 1, color.RGBA{0xFF, 0x80, 0, 0xFF}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.LEDs.SetPixel(8, 0, color.White); err == nil {
+		t.Error("expected an error out of the matrix")
+	}
+	if err := h.LEDs.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	regs := b[addrATtiny]
+	if r, g, b := regs[24+2], regs[24+8+2], regs[24+16+2]; r != 31 || g != 16 || b != 0 {
+		t.Errorf("LED (2, 1) = %d, %d, %d; want 31, 16, 0", r, g, b)
+	}
+
+	h.LEDs.SetImage(6, 6, image.NewUniform(color.White)) // clipped
+	if c := h.LEDs.At(7, 7); c != (color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}) {
+		t.Errorf("LED (7, 7) = %v, want white", c)
+	}
+	if c := h.LEDs.At(5, 7); c != (color.RGBA{0, 0, 0, 0xFF}) {
+		t.Errorf("LED (5, 7) = %v, want off", c)
+	}
+
+	regs[regJoystick] = byte(Up | Enter)
+	if k, err := h.Joystick.Read(); err != nil || k != Up|Enter {
+		t.Errorf("Joystick = %v, %v; want Up|Enter", k, err)
+	}
+}
+
+func BenchmarkAcceleration(b *testing.B) {
+	h, err := Open(newBus())
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := h.IMU.Acceleration(); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "samples/s")
+}
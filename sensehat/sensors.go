@@ -0,0 +1,161 @@
+package sensehat
+
+import (
+	"errors"
+
+	"golang.org/x/exp/io/i2c"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+// Pressure is the LPS25H barometer.
+type Pressure struct {
+	dev *i2c.Device
+}
+
+func openPressure(o driver.Opener) (*Pressure, error) {
+	dev, err := openChecked(o, addrLPS25H, regWhoAmI, lpsID)
+	if err != nil {
+		return nil, err
+	}
+	if err := dev.WriteReg(lpsCtrl1, []byte{lpsCtrl1On}); err != nil {
+		dev.Close()
+		return nil, err
+	}
+	return &Pressure{dev: dev}, nil
+}
+
+// Read returns the pressure in hPa and the temperature of the sensor in
+// degrees Celsius.
+func (p *Pressure) Read() (hPa, celsius float64, err error) {
+	b := make([]byte, 5)
+	if err := p.dev.ReadReg(autoInc|lpsPressure, b); err != nil {
+		return 0, 0, err
+	}
+	press := int32(uint32(b[0])|uint32(b[1])<<8|uint32(b[2])<<16) << 8 >> 8 // 24-bit two's complement
+	return float64(press) / 4096, 42.5 + float64(int16le(b[3:]))/480, nil
+}
+
+// Humidity is the HTS221 humidity sensor.
+type Humidity struct {
+	dev *i2c.Device
+
+	// calibration lines
+	h0, h1, t0, t1             float64
+	h0Out, h1Out, t0Out, t1Out float64
+}
+
+func openHumidity(o driver.Opener) (*Humidity, error) {
+	dev, err := openChecked(o, addrHTS221, regWhoAmI, htsID)
+	if err != nil {
+		return nil, err
+	}
+	if err := dev.WriteReg(htsCtrl1, []byte{htsCtrl1On}); err != nil {
+		dev.Close()
+		return nil, err
+	}
+	c := make([]byte, 16)
+	if err := dev.ReadReg(autoInc|htsCalib, c); err != nil {
+		dev.Close()
+		return nil, err
+	}
+	h := &Humidity{
+		dev:   dev,
+		h0:    float64(c[0]) / 2,
+		h1:    float64(c[1]) / 2,
+		t0:    float64(int(c[5]&0x03)<<8|int(c[2])) / 8,
+		t1:    float64(int(c[5]&0x0C)<<6|int(c[3])) / 8,
+		h0Out: float64(int16le(c[6:])),
+		h1Out: float64(int16le(c[10:])),
+		t0Out: float64(int16le(c[12:])),
+		t1Out: float64(int16le(c[14:])),
+	}
+	if h.h1Out == h.h0Out || h.t1Out == h.t0Out {
+		dev.Close()
+		return nil, errors.New("invalid HTS221 calibration")
+	}
+	return h, nil
+}
+
+// Read returns the relative humidity in percent and the temperature of the
+// sensor in degrees Celsius.
+func (h *Humidity) Read() (rh, celsius float64, err error) {
+	b := make([]byte, 4)
+	if err := h.dev.ReadReg(autoInc|htsHumidity, b); err != nil {
+		return 0, 0, err
+	}
+	rh = h.h0 + (h.h1-h.h0)*(float64(int16le(b))-h.h0Out)/(h.h1Out-h.h0Out)
+	if rh < 0 {
+		rh = 0
+	} else if rh > 100 {
+		rh = 100
+	}
+	celsius = h.t0 + (h.t1-h.t0)*(float64(int16le(b[2:]))-h.t0Out)/(h.t1Out-h.t0Out)
+	return rh, celsius, nil
+}
+
+// Vector is a measurement along the axes of the LSM9DS1.
+type Vector struct {
+	X, Y, Z float64
+}
+
+// IMU is the LSM9DS1 accelerometer, gyroscope and magnetometer.
+type IMU struct {
+	ag, mag *i2c.Device
+}
+
+func openIMU(o driver.Opener) (*IMU, error) {
+	ag, err := openChecked(o, addrAG, regWhoAmI, agID)
+	if err != nil {
+		return nil, err
+	}
+	mag, err := openChecked(o, addrMag, regWhoAmI, magID)
+	if err != nil {
+		ag.Close()
+		return nil, err
+	}
+	imu := &IMU{ag: ag, mag: mag}
+	for _, w := range []struct {
+		dev    *i2c.Device
+		reg, v byte
+	}{
+		{ag, agCtrl1G, agCtrl1GOn},
+		{ag, agCtrl6XL, agCtrl6XLOn},
+		{mag, magCtrl1, magCtrl1On},
+		{mag, magCtrl3, magCtrl3On},
+		{mag, magCtrl4, magCtrl4On},
+	} {
+		if err := w.dev.WriteReg(w.reg, []byte{w.v}); err != nil {
+			ag.Close()
+			mag.Close()
+			return nil, err
+		}
+	}
+	return imu, nil
+}
+
+func readVector(dev *i2c.Device, reg byte, scale float64) (Vector, error) {
+	b := make([]byte, 6)
+	if err := dev.ReadReg(reg, b); err != nil {
+		return Vector{}, err
+	}
+	return Vector{
+		X: float64(int16le(b[0:])) * scale,
+		Y: float64(int16le(b[2:])) * scale,
+		Z: float64(int16le(b[4:])) * scale,
+	}, nil
+}
+
+// Acceleration returns the acceleration in g.
+func (imu *IMU) Acceleration() (Vector, error) {
+	return readVector(imu.ag, agAccel, accelScale)
+}
+
+// Rotation returns the angular rate in degrees per second.
+func (imu *IMU) Rotation() (Vector, error) {
+	return readVector(imu.ag, agGyro, gyroScale)
+}
+
+// MagneticField returns the magnetic field in gauss.
+func (imu *IMU) MagneticField() (Vector, error) {
+	return readVector(imu.mag, autoInc|magOut, magScale)
+}
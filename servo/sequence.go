@@ -0,0 +1,246 @@
+package servo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/goiot/devices/clock"
+)
+
+// Easing maps the fraction of the duration of a move elapsed, from 0 to
+// 1, to the fraction of the move done.
+type Easing func(t float64) float64
+
+// Linear moves at a constant speed.
+func Linear(t float64) float64 { return t }
+
+// EaseIn starts slowly and accelerates, as a cubic.
+func EaseIn(t float64) float64 { return t * t * t }
+
+// EaseOut decelerates to a stop, as a cubic.
+func EaseOut(t float64) float64 {
+	u := 1 - t
+	return 1 - u*u*u
+}
+
+// EaseInOut accelerates and decelerates, the natural motion of a limb.
+func EaseInOut(t float64) float64 {
+	if t < 0.5 {
+		return 4 * t * t * t
+	}
+	u := 2 - 2*t
+	return 1 - u*u*u/2
+}
+
+// Keyframe is a pose of the servos of a sequence.
+type Keyframe struct {
+	// Angles are the angles of the servos, in the order of the servos of
+	// the sequence.
+	Angles []float64
+
+	// Duration is the time taken to move from the previous keyframe to
+	// this one, or from the last one to the first when the sequence
+	// loops. The servos jump to the first keyframe when the sequence
+	// starts.
+	Duration time.Duration
+
+	// Easing eases the move, Linear if nil.
+	Easing Easing
+}
+
+// Sequence moves servos through keyframes, all the servos being updated
+// together at each step. Pause and Resume can be called by other
+// goroutines while it plays.
+type Sequence struct {
+	Servos    []Servo
+	Keyframes []Keyframe
+
+	// Loop plays the sequence until the context of Play is done.
+	Loop bool
+
+	// Rate is the number of updates of the servos per second, 50 if 0:
+	// the pulses of most servos are 50Hz, faster updates are not followed.
+	Rate float64
+
+	// Clock times the steps, clock.Real if nil.
+	Clock clock.Clock
+
+	mu        sync.Mutex
+	paused    bool
+	pausedAt  time.Time
+	pausedFor time.Duration // since the start of Play
+	resume    chan struct{}
+}
+
+// move is the move to a keyframe.
+type move struct {
+	from, to []float64
+	d        time.Duration
+	ease     Easing
+}
+
+func (s *Sequence) moves() []move {
+	k := s.Keyframes
+	var m []move
+	for i := 1; i < len(k); i++ {
+		m = append(m, move{k[i-1].Angles, k[i].Angles, k[i].Duration, k[i].Easing})
+	}
+	if s.Loop && len(k) > 1 {
+		m = append(m, move{k[len(k)-1].Angles, k[0].Angles, k[0].Duration, k[0].Easing})
+	}
+	return m
+}
+
+// Length returns the duration of the sequence, of one loop if it loops.
+func (s *Sequence) Length() time.Duration {
+	var d time.Duration
+	for _, m := range s.moves() {
+		d += m.d
+	}
+	return d
+}
+
+// Pose returns the angles of the servos at t from the start of the
+// sequence.
+func (s *Sequence) Pose(t time.Duration) []float64 {
+	if len(s.Keyframes) == 0 {
+		return nil
+	}
+	moves := s.moves()
+	if t < 0 {
+		t = 0
+	}
+	if l := s.Length(); s.Loop && l > 0 {
+		t %= l
+	}
+	angles := make([]float64, len(s.Keyframes[0].Angles))
+	copy(angles, s.Keyframes[0].Angles)
+	for _, m := range moves {
+		if t >= m.d {
+			copy(angles, m.to)
+			t -= m.d
+			continue
+		}
+		ease := m.ease
+		if ease == nil {
+			ease = Linear
+		}
+		f := ease(float64(t) / float64(m.d))
+		for i := range angles {
+			angles[i] = m.from[i] + f*(m.to[i]-m.from[i])
+		}
+		break
+	}
+	return angles
+}
+
+func (s *Sequence) check() error {
+	if len(s.Keyframes) == 0 {
+		return errors.New("the sequence has no keyframes")
+	}
+	for i, k := range s.Keyframes {
+		if len(k.Angles) != len(s.Servos) {
+			return fmt.Errorf("keyframe %d has %d angles for %d servos", i, len(k.Angles), len(s.Servos))
+		}
+		if k.Duration < 0 {
+			return fmt.Errorf("keyframe %d has a negative duration", i)
+		}
+	}
+	if s.Loop && s.Length() == 0 {
+		return errors.New("the looping sequence has no duration")
+	}
+	return nil
+}
+
+// Play plays the sequence until its end, or until ctx is done if it
+// loops.
+func (s *Sequence) Play(ctx context.Context) error {
+	if err := s.check(); err != nil {
+		return err
+	}
+	rate := s.Rate
+	if rate <= 0 {
+		rate = 50
+	}
+	step := time.Duration(float64(time.Second) / rate)
+	c := clock.Or(s.Clock)
+	length := s.Length()
+	start := c.Now()
+	s.mu.Lock()
+	s.pausedFor = 0
+	s.mu.Unlock()
+	for {
+		if err := s.wait(ctx); err != nil {
+			return err
+		}
+		s.mu.Lock()
+		elapsed := c.Now().Sub(start) - s.pausedFor
+		s.mu.Unlock()
+		if !s.Loop && elapsed > length {
+			elapsed = length
+		}
+		if err := s.set(s.Pose(elapsed)); err != nil {
+			return err
+		}
+		if !s.Loop && elapsed == length {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.After(step):
+		}
+	}
+}
+
+func (s *Sequence) set(angles []float64) error {
+	for i, a := range angles {
+		if err := s.Servos[i].SetAngle(a); err != nil {
+			return fmt.Errorf("moving servo %d failed - %v", i, err)
+		}
+	}
+	return nil
+}
+
+// wait blocks while the sequence is paused.
+func (s *Sequence) wait(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.paused {
+		s.mu.Unlock()
+		return nil
+	}
+	resume := s.resume
+	s.mu.Unlock()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-resume:
+		return nil
+	}
+}
+
+// Pause holds the servos in their current pose, the sequence stops
+// advancing until Resume.
+func (s *Sequence) Pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.paused {
+		s.paused = true
+		s.pausedAt = clock.Or(s.Clock).Now()
+		s.resume = make(chan struct{})
+	}
+}
+
+// Resume resumes the sequence from the pose it was paused in.
+func (s *Sequence) Resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.paused {
+		s.paused = false
+		s.pausedFor += clock.Or(s.Clock).Now().Sub(s.pausedAt)
+		close(s.resume)
+	}
+}
@@ -0,0 +1,57 @@
+// Package servo drives hobby servos and plays sequences of keyframed poses
+// on several of them, with easing curves between the poses, for the
+// motions of animatronics and robot arms.
+package servo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/goiot/devices/pwm"
+)
+
+// Servo is a hobby servo, it is implemented by PWM and by the servos of
+// the firmata package.
+type Servo interface {
+	// SetAngle moves the servo to the angle, between 0 and 180 degrees.
+	SetAngle(deg float64) error
+}
+
+// Defaults of PWM.
+const (
+	Period = 20 * time.Millisecond // 50Hz
+	Min    = 544 * time.Microsecond
+	Max    = 2400 * time.Microsecond
+)
+
+// PWM is a servo driven by a pulse width modulated output, e.g. a channel
+// of a PCA9685 set to the frequency of the servo.
+type PWM struct {
+	Output pwm.Output
+
+	// Period is the period of the output, Period if 0.
+	Period time.Duration
+	// Min and Max are the widths of the pulses at 0 and 180 degrees, Min
+	// and Max if 0. They vary between servos, find them by moving the
+	// servo to its stops.
+	Min, Max time.Duration
+}
+
+// SetAngle implements Servo.
+func (s *PWM) SetAngle(deg float64) error {
+	if deg < 0 || deg > 180 {
+		return fmt.Errorf("angle %v is out of range, must be between 0 and 180", deg)
+	}
+	period, min, max := s.Period, s.Min, s.Max
+	if period == 0 {
+		period = Period
+	}
+	if min == 0 {
+		min = Min
+	}
+	if max == 0 {
+		max = Max
+	}
+	width := float64(min) + deg/180*float64(max-min)
+	return s.Output.SetDuty(width / float64(period))
+}
@@ -0,0 +1,230 @@
+package servo
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/firmata"
+)
+
+var (
+	_ Servo = (*PWM)(nil)
+	_ Servo = (*firmata.Servo)(nil)
+)
+
+type output struct{ duty float64 }
+
+func (o *output) SetDuty(duty float64) error {
+	o.duty = duty
+	return nil
+}
+
+type fakeServo struct {
+	mu     sync.Mutex
+	angles []float64
+	err    error
+}
+
+func (s *fakeServo) SetAngle(deg float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.angles = append(s.angles, deg)
+	return nil
+}
+
+func (s *fakeServo) moves() []float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]float64(nil), s.angles...)
+}
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+
+func TestPWM(t *testing.T) {
+	o := &output{}
+	s := &PWM{Output: o}
+	for _, tt := range []struct{ deg, duty float64 }{
+		{0, 0.0272},
+		{90, 0.0736},
+		{180, 0.12},
+	} {
+		if err := s.SetAngle(tt.deg); err != nil {
+			t.Fatal(err)
+		}
+		if !near(o.duty, tt.duty) {
+			t.Errorf("duty at %v° = %v; want %v", tt.deg, o.duty, tt.duty)
+		}
+	}
+	s = &PWM{Output: o, Period: 10 * time.Millisecond, Min: time.Millisecond, Max: 2 * time.Millisecond}
+	s.SetAngle(90)
+	if !near(o.duty, 0.15) {
+		t.Errorf("duty = %v; want 0.15", o.duty)
+	}
+	if err := s.SetAngle(181); err == nil {
+		t.Error("SetAngle(181) succeeded")
+	}
+}
+
+func TestEasing(t *testing.T) {
+	for name, e := range map[string]Easing{"Linear": Linear, "EaseIn": EaseIn, "EaseOut": EaseOut, "EaseInOut": EaseInOut} {
+		if !near(e(0), 0) || !near(e(1), 1) {
+			t.Errorf("%s(0), %s(1) = %v, %v; want 0, 1", name, name, e(0), e(1))
+		}
+		for x := 0.0; x < 1; x += 0.05 {
+			if e(x+0.05) < e(x) {
+				t.Errorf("%s decreases at %v", name, x)
+			}
+		}
+	}
+	if !near(EaseInOut(0.5), 0.5) || EaseIn(0.5) >= 0.5 || EaseOut(0.5) <= 0.5 {
+		t.Errorf("EaseIn(0.5), EaseOut(0.5), EaseInOut(0.5) = %v, %v, %v", EaseIn(0.5), EaseOut(0.5), EaseInOut(0.5))
+	}
+}
+
+func TestPose(t *testing.T) {
+	s := &Sequence{Keyframes: []Keyframe{
+		{Angles: []float64{0, 180}, Duration: 2 * time.Second},
+		{Angles: []float64{90, 90}, Duration: time.Second},
+		{Angles: []float64{90, 0}, Duration: time.Second, Easing: EaseIn},
+	}}
+	if l := s.Length(); l != 2*time.Second {
+		t.Errorf("Length() = %v; want 2s", l)
+	}
+	for _, tt := range []struct {
+		t    time.Duration
+		want []float64
+	}{
+		{0, []float64{0, 180}},
+		{500 * time.Millisecond, []float64{45, 135}},
+		{time.Second, []float64{90, 90}},
+		{1500 * time.Millisecond, []float64{90, 90 - 90*0.125}},
+		{time.Hour, []float64{90, 0}},
+	} {
+		got := s.Pose(tt.t)
+		if !near(got[0], tt.want[0]) || !near(got[1], tt.want[1]) {
+			t.Errorf("Pose(%v) = %v; want %v", tt.t, got, tt.want)
+		}
+	}
+
+	// looping, back to the first keyframe in 2s
+	s.Loop = true
+	if l := s.Length(); l != 4*time.Second {
+		t.Errorf("Length() = %v; want 4s", l)
+	}
+	if got := s.Pose(3 * time.Second); !near(got[0], 45) || !near(got[1], 90) {
+		t.Errorf("Pose(3s) = %v; want [45 90]", got)
+	}
+	if got := s.Pose(4*time.Second + 500*time.Millisecond); !near(got[0], 45) || !near(got[1], 135) {
+		t.Errorf("Pose(4.5s) = %v; want [45 135]", got)
+	}
+}
+
+func TestPlay(t *testing.T) {
+	a, b := &fakeServo{}, &fakeServo{}
+	c := clock.NewFake(time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC))
+	s := &Sequence{
+		Servos: []Servo{a, b},
+		Keyframes: []Keyframe{
+			{Angles: []float64{0, 90}},
+			{Angles: []float64{90, 0}, Duration: time.Second},
+		},
+		Rate:  10,
+		Clock: c,
+	}
+	done := make(chan error)
+	go func() { done <- s.Play(context.Background()) }()
+	for i := 0; i < 3; i++ {
+		c.BlockUntil(1)
+		c.Advance(100 * time.Millisecond)
+	}
+	// paused after 300ms, the time spent paused does not count
+	c.BlockUntil(1)
+	s.Pause()
+	c.Advance(100 * time.Millisecond)
+	c.Advance(time.Hour)
+	s.Resume()
+	for i := 0; i < 7; i++ {
+		c.BlockUntil(1)
+		c.Advance(100 * time.Millisecond)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	want := []float64{0, 9, 18, 27, 27, 36, 45, 54, 63, 72, 81, 90}
+	got := a.moves()
+	if len(got) != len(want) {
+		t.Fatalf("servo moved to %v; want %v", got, want)
+	}
+	for i := range want {
+		if !near(got[i], want[i]) {
+			t.Fatalf("servo moved to %v; want %v", got, want)
+		}
+	}
+	if got := b.moves(); len(got) != len(want) || !near(got[len(got)-1], 0) {
+		t.Errorf("second servo moved to %v; want 12 moves to 0", got)
+	}
+}
+
+func TestPlayLoop(t *testing.T) {
+	a := &fakeServo{}
+	c := clock.NewFake(time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC))
+	s := &Sequence{
+		Servos: []Servo{a},
+		Keyframes: []Keyframe{
+			{Angles: []float64{0}, Duration: time.Second},
+			{Angles: []float64{100}, Duration: time.Second},
+		},
+		Loop:  true,
+		Rate:  2,
+		Clock: c,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Play(ctx) }()
+	for i := 0; i < 4; i++ {
+		c.BlockUntil(1)
+		c.Advance(500 * time.Millisecond)
+	}
+	c.BlockUntil(1)
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Play() = %v; want %v", err, context.Canceled)
+	}
+	want := []float64{0, 50, 100, 50, 0}
+	got := a.moves()
+	if len(got) != len(want) {
+		t.Fatalf("servo moved to %v; want %v", got, want)
+	}
+	for i := range want {
+		if !near(got[i], want[i]) {
+			t.Fatalf("servo moved to %v; want %v", got, want)
+		}
+	}
+}
+
+func TestPlayErrors(t *testing.T) {
+	a := &fakeServo{}
+	for _, s := range []*Sequence{
+		{Servos: []Servo{a}},
+		{Servos: []Servo{a}, Keyframes: []Keyframe{{Angles: []float64{0, 1}}}},
+		{Servos: []Servo{a}, Keyframes: []Keyframe{{Angles: []float64{0}, Duration: -time.Second}}},
+		{Servos: []Servo{a}, Keyframes: []Keyframe{{Angles: []float64{0}}}, Loop: true},
+	} {
+		if err := s.Play(context.Background()); err == nil {
+			t.Errorf("Play() of %+v succeeded", s)
+		}
+	}
+	a.err = errors.New("firmata failure")
+	s := &Sequence{Servos: []Servo{a}, Keyframes: []Keyframe{{Angles: []float64{0}}}}
+	if err := s.Play(context.Background()); err == nil {
+		t.Error("Play() succeeded with a failing servo")
+	}
+}
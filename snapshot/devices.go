@@ -0,0 +1,128 @@
+package snapshot
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/draw"
+	"image/png"
+	"strconv"
+	"sync"
+
+	"github.com/goiot/devices/display"
+	"github.com/goiot/devices/gpio"
+	"github.com/goiot/devices/pwm"
+	"golang.org/x/exp/io/i2c"
+)
+
+// Display returns the restorer of the contents of a display, saved as a
+// PNG image. Restore draws the image on the display.
+func Display(d display.Display) Restorer { return displayState{d} }
+
+type displayState struct{ d display.Display }
+
+func (s displayState) Snapshot() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, s.d); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s displayState) Restore(state []byte) error {
+	img, err := png.Decode(bytes.NewReader(state))
+	if err != nil {
+		return err
+	}
+	draw.Draw(s.d, s.d.Bounds(), img, img.Bounds().Min, draw.Src)
+	return s.d.Draw()
+}
+
+// Pin returns the restorer of the level of an output, such as a relay.
+func Pin(p gpio.Pin) Restorer { return pinState{p} }
+
+type pinState struct{ p gpio.Pin }
+
+func (s pinState) Snapshot() ([]byte, error) {
+	v, err := s.p.Read()
+	if err != nil {
+		return nil, err
+	}
+	return []byte{byte(v & 1)}, nil
+}
+
+func (s pinState) Restore(state []byte) error {
+	if len(state) != 1 {
+		return errors.New("invalid pin state")
+	}
+	return s.p.Write(int(state[0]))
+}
+
+// PWM is a PWM output remembering its duty so that it can be restored.
+// It implements pwm.Output.
+type PWM struct {
+	pwm.Output
+
+	mu   sync.Mutex
+	duty float64
+}
+
+// NewPWM returns the output o remembering its duty, initially duty.
+func NewPWM(o pwm.Output, duty float64) *PWM {
+	return &PWM{Output: o, duty: duty}
+}
+
+// SetDuty sets the duty of the output.
+func (p *PWM) SetDuty(duty float64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.Output.SetDuty(duty); err != nil {
+		return err
+	}
+	p.duty = duty
+	return nil
+}
+
+// Snapshot implements Restorer.
+func (p *PWM) Snapshot() ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return strconv.AppendFloat(nil, p.duty, 'g', -1, 64), nil
+}
+
+// Restore implements Restorer.
+func (p *PWM) Restore(state []byte) error {
+	duty, err := strconv.ParseFloat(string(state), 64)
+	if err != nil {
+		return fmt.Errorf("invalid duty %q", state)
+	}
+	return p.SetDuty(duty)
+}
+
+// Registers returns the restorer of n consecutive registers of a device
+// from reg, read and written in one transfer: the device must increment
+// its register address, as most sensors and controllers do.
+func Registers(dev *i2c.Device, reg byte, n int) Restorer {
+	return registers{dev, reg, n}
+}
+
+type registers struct {
+	dev *i2c.Device
+	reg byte
+	n   int
+}
+
+func (r registers) Snapshot() ([]byte, error) {
+	buf := make([]byte, r.n)
+	if err := r.dev.ReadReg(r.reg, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+func (r registers) Restore(state []byte) error {
+	if len(state) != r.n {
+		return fmt.Errorf("%d registers saved, expected %d", len(state), r.n)
+	}
+	return r.dev.WriteReg(r.reg, state)
+}
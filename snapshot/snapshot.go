@@ -0,0 +1,179 @@
+// Package snapshot saves the state of devices to disk and restores it when
+// the process restarts, e.g. after a power loss, so kiosks and controllers
+// resume where they left off: display contents, relay states, PWM duties
+// or configuration registers.
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/goiot/devices/clock"
+)
+
+// Restorer is a device whose state can be saved and restored.
+type Restorer interface {
+	// Snapshot returns the state of the device.
+	Snapshot() ([]byte, error)
+
+	// Restore sets the device to a state returned by Snapshot.
+	Restore(state []byte) error
+}
+
+// file is the content of a snapshot file.
+type file struct {
+	Version int               `json:"version"`
+	Time    time.Time         `json:"time"`
+	Devices map[string][]byte `json:"devices"`
+}
+
+const version = 1
+
+type entry struct {
+	name string
+	r    Restorer
+}
+
+// Store saves the state of the devices added to it in a file. It can be
+// used by multiple goroutines.
+type Store struct {
+	// Clock dates the snapshots and times Run, clock.Real if nil.
+	Clock clock.Clock
+
+	path string
+
+	mu      sync.Mutex
+	devices []entry
+}
+
+// New returns a store saving the snapshots to path.
+func New(path string) *Store {
+	return &Store{path: path}
+}
+
+// Add adds a device to the store. The devices are restored in the order
+// they are added, e.g. the configuration registers of a controller before
+// the outputs it drives.
+func (s *Store) Add(name string, r Restorer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, e := range s.devices {
+		if e.name == name {
+			s.devices[i].r = r
+			return
+		}
+	}
+	s.devices = append(s.devices, entry{name, r})
+}
+
+// Save saves the state of the devices. The file is replaced atomically, a
+// power loss while saving leaves the previous snapshot.
+func (s *Store) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f := file{Version: version, Time: clock.Or(s.Clock).Now(), Devices: make(map[string][]byte)}
+	for _, e := range s.devices {
+		state, err := e.r.Snapshot()
+		if err != nil {
+			return fmt.Errorf("snapshot of %v failed - %v", e.name, err)
+		}
+		f.Devices[e.name] = state
+	}
+	b, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	return writeFile(s.path, b)
+}
+
+// writeFile writes a temporary file synced to disk, renames it to path and
+// syncs the directory.
+func writeFile(path string, b []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := ioutil.TempFile(dir, filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// Restore restores the state of the devices saved in the snapshot, the
+// devices without a saved state are left unchanged. It does nothing if no
+// snapshot was saved. The remaining devices are restored when one fails,
+// the first error is returned.
+func (s *Store) Restore() error {
+	b, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var f file
+	if err := json.Unmarshal(b, &f); err != nil {
+		return fmt.Errorf("invalid snapshot %v - %v", s.path, err)
+	}
+	if f.Version != version {
+		return fmt.Errorf("unsupported snapshot version %d", f.Version)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var first error
+	for _, e := range s.devices {
+		state, ok := f.Devices[e.name]
+		if !ok {
+			continue
+		}
+		if err := e.r.Restore(state); err != nil && first == nil {
+			first = fmt.Errorf("restoring %v failed - %v", e.name, err)
+		}
+	}
+	return first
+}
+
+// Run saves the state of the devices every interval, and a last time when
+// ctx is done. It returns the error of the last save, or of the first
+// failing one.
+func (s *Store) Run(ctx context.Context, interval time.Duration) error {
+	t := clock.Or(s.Clock).NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return s.Save()
+		case <-t.C():
+			if err := s.Save(); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/gpiosim"
+	"github.com/goiot/devices/i2csim"
+	"golang.org/x/exp/io/i2c"
+)
+
+type screen struct {
+	*image.Gray
+	draws int
+}
+
+func (s *screen) Draw() error { s.draws++; return nil }
+
+type output struct{ duty float64 }
+
+func (o *output) SetDuty(d float64) error { o.duty = d; return nil }
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	// The state before the restart.
+	scr := &screen{Gray: image.NewGray(image.Rect(0, 0, 8, 4))}
+	scr.SetGray(3, 2, color.Gray{Y: 0xFF})
+	relay := gpiosim.NewPin(1)
+	fan := NewPWM(&output{}, 0)
+	fan.SetDuty(0.75)
+	bus := i2csim.NewBus()
+	regs := i2csim.NewRegisters()
+	regs.Set(0x20, 0x12, 0x34, 0x56)
+	bus.Attach(0x40, regs)
+	dev, err := i2c.Open(bus, 0x40)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(path)
+	s.Add("screen", Display(scr))
+	s.Add("relay", Pin(relay))
+	s.Add("fan", fan)
+	s.Add("config", Registers(dev, 0x20, 3))
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The devices are reset by the power loss.
+	scr2 := &screen{Gray: image.NewGray(image.Rect(0, 0, 8, 4))}
+	relay2 := gpiosim.NewPin(0)
+	out := &output{}
+	regs2 := i2csim.NewRegisters()
+	bus.Attach(0x40, regs2)
+
+	s = New(path)
+	s.Add("config", Registers(dev, 0x20, 3))
+	s.Add("screen", Display(scr2))
+	s.Add("relay", Pin(relay2))
+	s.Add("fan", NewPWM(out, 0))
+	s.Add("new", Pin(gpiosim.NewPin(0)))
+	if err := s.Restore(); err != nil {
+		t.Fatal(err)
+	}
+	if scr2.GrayAt(3, 2).Y != 0xFF || scr2.draws != 1 {
+		t.Errorf("screen not restored: pixel %v, %d draws", scr2.GrayAt(3, 2), scr2.draws)
+	}
+	if relay2.Level() != 1 {
+		t.Error("relay not restored")
+	}
+	if out.duty != 0.75 {
+		t.Errorf("duty = %v; want 0.75", out.duty)
+	}
+	if got := regs2.Get(0x20, 3); string(got) != "\x12\x34\x56" {
+		t.Errorf("registers = %x; want 123456", got)
+	}
+
+	// A failing device does not prevent the others from being restored.
+	relay3 := gpiosim.NewPin(0)
+	bus.Fail(0x40, 1, errors.New("nak"))
+	s = New(path)
+	s.Add("config", Registers(dev, 0x20, 3))
+	s.Add("relay", Pin(relay3))
+	if err := s.Restore(); err == nil {
+		t.Error("Restore succeeded with a failing device")
+	}
+	if relay3.Level() != 1 {
+		t.Error("relay not restored after a failure")
+	}
+}
+
+func TestRestoreWithoutSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	s := New(filepath.Join(dir, "missing.json"))
+	s.Add("relay", Pin(gpiosim.NewPin(0)))
+	if err := s.Restore(); err != nil {
+		t.Errorf("Restore without snapshot = %v", err)
+	}
+
+	bad := filepath.Join(dir, "bad.json")
+	ioutil.WriteFile(bad, []byte(`{"version":2}`), 0644)
+	if err := New(bad).Restore(); err == nil {
+		t.Error("Restore of an unsupported version succeeded")
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	relay := gpiosim.NewPin(0)
+	s := New(path)
+	s.Add("relay", Pin(relay))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx, time.Hour) }()
+	relay.Write(1)
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	relay2 := gpiosim.NewPin(0)
+	s = New(path)
+	s.Add("relay", Pin(relay2))
+	if err := s.Restore(); err != nil || relay2.Level() != 1 {
+		t.Errorf("Restore = %v, relay %d; want the state saved on cancellation", err, relay2.Level())
+	}
+
+	// No temporary file is left behind.
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 1 {
+		t.Errorf("%d files in the directory; want the snapshot only", len(files))
+	}
+}
@@ -0,0 +1,88 @@
+// Package spisim provides a virtual SPI port to which tests connect an
+// emulated device. The port implements driver.Opener, so drivers talk to
+// the emulated device exactly as they would to a real one and can be
+// tested end to end without hardware.
+package spisim
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/fault"
+	"golang.org/x/exp/io/spi/driver"
+)
+
+// Device is an emulated SPI device. Tx is called for each transfer, with
+// the bytes clocked out by the master in w and r to be filled with the
+// bytes clocked in. Either can be nil, otherwise they have the same
+// length.
+type Device interface {
+	Tx(w, r []byte) error
+}
+
+// DeviceFunc adapts a function to a Device.
+type DeviceFunc func(w, r []byte) error
+
+// Tx implements Device.
+func (f DeviceFunc) Tx(w, r []byte) error { return f(w, r) }
+
+// Port is a virtual SPI port, with its chip select connected to a single
+// device. It can be used by multiple goroutines.
+type Port struct {
+	// Clock times the delays of the faults injected, clock.Real if nil.
+	Clock clock.Clock
+
+	dev    Device
+	faults fault.Queue
+
+	mu     sync.Mutex
+	config map[int]int
+}
+
+// New returns a port connected to d.
+func New(d Device) *Port {
+	return &Port{dev: d, config: make(map[int]int)}
+}
+
+// Config returns the last value the driver configured for the key k, a
+// driver constant such as driver.Mode or driver.MaxSpeed.
+func (p *Port) Config(k int) (int, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	v, ok := p.config[k]
+	return v, ok
+}
+
+// Inject queues faults for the next transfers, one per transfer. NAK
+// faults behave as Error faults after writing their bytes.
+func (p *Port) Inject(faults ...fault.Fault) {
+	p.faults.Inject(faults...)
+}
+
+// Open implements driver.Opener.
+func (p *Port) Open() (driver.Conn, error) {
+	return conn{p}, nil
+}
+
+type conn struct{ p *Port }
+
+func (c conn) Configure(k, v int) error {
+	c.p.mu.Lock()
+	defer c.p.mu.Unlock()
+	c.p.config[k] = v
+	return nil
+}
+
+func (c conn) Tx(w, r []byte) error {
+	if w != nil && r != nil && len(w) != len(r) {
+		return errors.New("spisim: w and r have different lengths")
+	}
+	f := c.p.faults.Next()
+	if f.Clock == nil {
+		f.Clock = c.p.Clock
+	}
+	return f.Tx(w, r, c.p.dev.Tx)
+}
+
+func (c conn) Close() error { return nil }
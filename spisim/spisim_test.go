@@ -0,0 +1,47 @@
+package spisim
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/goiot/devices/fault"
+	"golang.org/x/exp/io/spi"
+	"golang.org/x/exp/io/spi/driver"
+)
+
+func TestPort(t *testing.T) {
+	var written [][]byte
+	p := New(DeviceFunc(func(w, r []byte) error {
+		written = append(written, append([]byte(nil), w...))
+		copy(r, []byte{0xA5, 0x5A})
+		return nil
+	}))
+	dev, err := spi.Open(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dev.SetMode(spi.Mode3); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := p.Config(driver.Mode); !ok || v != int(spi.Mode3) {
+		t.Errorf("mode = %v, %v; want 3", v, ok)
+	}
+
+	p.Inject(fault.Fault{}, fault.Fault{Kind: fault.BitFlip, Bytes: 1, Mask: 0xFF}, fault.Fault{Kind: fault.Error})
+	r := make([]byte, 2)
+	if err := dev.Tx([]byte{1, 2}, r); err != nil || !bytes.Equal(r, []byte{0xA5, 0x5A}) {
+		t.Errorf("Tx = %x, %v; want a55a", r, err)
+	}
+	if err := dev.Tx([]byte{1, 2}, r); err != nil || !bytes.Equal(r, []byte{0xA5, 0xA5}) {
+		t.Errorf("Tx = %x, %v; want a5a5", r, err)
+	}
+	if err := dev.Tx([]byte{3, 4}, r); err != fault.ErrInjected {
+		t.Errorf("Tx error = %v, want %v", err, fault.ErrInjected)
+	}
+	if len(written) != 2 {
+		t.Errorf("the device got %d transfers, want 2", len(written))
+	}
+	if err := dev.Tx([]byte{1, 2}, r[:1]); err == nil {
+		t.Error("expected an error for buffers of different lengths")
+	}
+}
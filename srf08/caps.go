@@ -0,0 +1,27 @@
+package srf08
+
+import "github.com/goiot/devices/caps"
+
+// Caps are the capabilities of the SRF08.
+var Caps = caps.Capabilities{
+	Name:      "SRF08",
+	Bus:       "i2c",
+	Addresses: []int{Addr},
+	Measurements: []caps.Measurement{
+		{Kind: caps.Distance, Unit: "m", Min: 0.03, Max: 6, Resolution: 0.01},
+	},
+}
+
+func init() { caps.Register(Caps) }
+
+// Capabilities implements caps.Describer.
+func (s *SRF08) Capabilities() caps.Capabilities {
+	c := Caps
+	if s.srf02 {
+		c.Name = "SRF02"
+		c.Measurements = []caps.Measurement{
+			{Kind: caps.Distance, Unit: "m", Min: 0.15, Max: 6, Resolution: 0.01},
+		}
+	}
+	return c
+}
@@ -0,0 +1,135 @@
+// Package srf08 implements a driver for the Devantech SRF08 and SRF02
+// ultrasonic rangefinders on I2C. The SRF08 also measures the ambient
+// light.
+package srf08
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/distance"
+	"golang.org/x/exp/io/i2c"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+// Addr is the I2C address of the sensors as shipped, 0xE0 in the 8-bit
+// notation of their documentation. ChangeAddress moves a sensor up to
+// 0x7F, so that 16 can share a bus.
+const Addr = 0x70
+
+const (
+	regCommand = 0x00 // the software revision on reads
+	regLight   = 0x01
+	regEcho    = 0x02
+
+	cmdRangeCM = 0x51
+
+	// timeout is the time waited for a measurement, which takes 65ms and
+	// during which the sensors do not answer on the bus.
+	timeout = 100 * time.Millisecond
+	poll    = 5 * time.Millisecond
+)
+
+// SRF08 represents a SRF08 or SRF02 rangefinder.
+type SRF08 struct {
+	Device *i2c.Device
+	// Clock times the waits of the measurements, clock.Real if nil.
+	Clock clock.Clock
+
+	srf02 bool
+}
+
+// Open opens the SRF08 at addr.
+func Open(o driver.Opener, addr int) (*SRF08, error) {
+	return open(o, addr, false)
+}
+
+// OpenSRF02 opens the SRF02 at addr.
+func OpenSRF02(o driver.Opener, addr int) (*SRF08, error) {
+	return open(o, addr, true)
+}
+
+func open(o driver.Opener, addr int, srf02 bool) (*SRF08, error) {
+	dev, err := i2c.Open(o, addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &SRF08{Device: dev, srf02: srf02}
+	rev := make([]byte, 1)
+	if err := dev.ReadReg(regCommand, rev); err != nil {
+		dev.Close()
+		return nil, fmt.Errorf("reading the software revision failed - %v", err)
+	}
+	return s, nil
+}
+
+// measure starts a measurement and waits for its end, when the sensor
+// answers with its software revision again.
+func (s *SRF08) measure() error {
+	if err := s.Device.WriteReg(regCommand, []byte{cmdRangeCM}); err != nil {
+		return err
+	}
+	c := clock.Or(s.Clock)
+	rev := make([]byte, 1)
+	for waited := time.Duration(0); waited < timeout; waited += poll {
+		c.Sleep(poll)
+		if err := s.Device.ReadReg(regCommand, rev); err == nil && rev[0] != 0xFF {
+			return nil
+		}
+	}
+	return fmt.Errorf("no measurement after %v", timeout)
+}
+
+// Distance implements distance.Sensor, with a resolution of 1cm. It
+// returns distance.ErrOutOfRange if the sensor got no echo.
+func (s *SRF08) Distance() (float64, error) {
+	if err := s.measure(); err != nil {
+		return 0, err
+	}
+	b := make([]byte, 2)
+	if err := s.Device.ReadReg(regEcho, b); err != nil {
+		return 0, err
+	}
+	cm := int(b[0])<<8 | int(b[1])
+	if cm == 0 {
+		return 0, distance.ErrOutOfRange
+	}
+	return float64(cm) / 100, nil
+}
+
+// Light measures the ambient light on the SRF08, from 0 in the dark to 255
+// in daylight, along with a distance. The SRF02 has no light sensor.
+func (s *SRF08) Light() (int, error) {
+	if s.srf02 {
+		return 0, fmt.Errorf("the SRF02 has no light sensor")
+	}
+	if err := s.measure(); err != nil {
+		return 0, err
+	}
+	b := make([]byte, 1)
+	if err := s.Device.ReadReg(regLight, b); err != nil {
+		return 0, err
+	}
+	return int(b[0]), nil
+}
+
+// ChangeAddress moves the sensor to the address addr, from 0x70 to 0x7F.
+// It must be the only sensor on the bus. The sensor is unusable
+// afterwards, open it again at its new address.
+func (s *SRF08) ChangeAddress(addr int) error {
+	if addr < 0x70 || addr > 0x7F {
+		return fmt.Errorf("invalid address %#x, must be 0x70 to 0x7f", addr)
+	}
+	for _, b := range []byte{0xA0, 0xAA, 0xA5, byte(addr << 1)} {
+		if err := s.Device.WriteReg(regCommand, []byte{b}); err != nil {
+			return fmt.Errorf("changing the address failed - %v", err)
+		}
+	}
+	return nil
+}
+
+// Close closes the device.
+func (s *SRF08) Close() error {
+	return s.Device.Close()
+}
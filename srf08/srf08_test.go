@@ -0,0 +1,94 @@
+package srf08
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/distance"
+	"github.com/goiot/devices/i2csim"
+)
+
+var _ distance.Sensor = (*SRF08)(nil)
+
+// sensor is a fake SRF08, not answering for the first polls of a
+// measurement.
+type sensor struct {
+	cm      int
+	light   byte
+	busy    int
+	written []byte
+}
+
+func (s *sensor) Tx(w, r []byte) error {
+	if len(r) == 0 {
+		s.written = append(s.written, w[1])
+		if w[1] == cmdRangeCM {
+			s.busy = 3
+		}
+		return nil
+	}
+	switch w[0] {
+	case regCommand:
+		if s.busy > 0 {
+			s.busy--
+			return errors.New("NAK")
+		}
+		r[0] = 6
+	case regLight:
+		r[0] = s.light
+	case regEcho:
+		r[0], r[1] = byte(s.cm>>8), byte(s.cm)
+	}
+	return nil
+}
+
+func newSensor(t *testing.T, s *sensor, srf02 bool) *SRF08 {
+	bus := i2csim.NewBus()
+	bus.Attach(Addr, s)
+	d, err := open(bus, Addr, srf02)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := clock.NewFake(time.Time{})
+	c.SetAutoSleep(true)
+	d.Clock = c
+	return d
+}
+
+func TestDistance(t *testing.T) {
+	s := &sensor{cm: 257, light: 0x80}
+	d := newSensor(t, s, false)
+	if m, err := d.Distance(); err != nil || m != 2.57 {
+		t.Errorf("Distance = %v, %v; want 2.57", m, err)
+	}
+	if l, err := d.Light(); err != nil || l != 0x80 {
+		t.Errorf("Light = %v, %v; want 128", l, err)
+	}
+	s.cm = 0
+	if _, err := d.Distance(); err != distance.ErrOutOfRange {
+		t.Errorf("Distance with no echo = %v; want ErrOutOfRange", err)
+	}
+
+	s.written = nil
+	if err := d.ChangeAddress(0x72); err != nil || string(s.written) != "\xA0\xAA\xA5\xE4" {
+		t.Errorf("ChangeAddress = %v, wrote %x; want a0aaa5e4", err, s.written)
+	}
+	if err := d.ChangeAddress(0x80); err == nil {
+		t.Error("expected an error with address 0x80")
+	}
+}
+
+func TestSRF02(t *testing.T) {
+	d := newSensor(t, &sensor{cm: 40}, true)
+	if m, err := d.Distance(); err != nil || m != 0.4 {
+		t.Errorf("Distance = %v, %v; want 0.4", m, err)
+	}
+	if _, err := d.Light(); err == nil {
+		t.Error("expected an error reading the light of a SRF02")
+	}
+	if name := d.Capabilities().Name; name != "SRF02" {
+		t.Errorf("Capabilities().Name = %q; want SRF02", name)
+	}
+}
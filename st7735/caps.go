@@ -0,0 +1,22 @@
+package st7735
+
+import "github.com/goiot/devices/caps"
+
+// Caps are the capabilities of the controller, the size of the panels
+// varies.
+var Caps = caps.Capabilities{
+	Name:       "ST7735",
+	Bus:        "spi",
+	Outputs:    []caps.Output{{Kind: caps.Pixels, Color: "rgb565"}},
+	PowerModes: []string{"on", "off", "sleep"},
+}
+
+func init() { caps.Register(Caps) }
+
+// Capabilities implements caps.Describer.
+func (d *Display) Capabilities() caps.Capabilities {
+	c := Caps
+	b := d.Bounds()
+	c.Outputs = []caps.Output{{Kind: caps.Pixels, Width: b.Dx(), Height: b.Dy(), Color: "rgb565"}}
+	return c
+}
@@ -0,0 +1,264 @@
+// Package st7735 implements a driver for the color TFT displays driven by a
+// Sitronix ST7735 controller, such as the 0.96" 160x80 IPS display of the
+// Pimoroni Enviro boards or the Adafruit 1.8" 160x128 display.
+package st7735
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"time"
+
+	"github.com/goiot/devices/gpio"
+	"golang.org/x/exp/io/spi"
+	"golang.org/x/exp/io/spi/driver"
+)
+
+// Speed is the SPI clock used to talk to the controller.
+const Speed = 16000000
+
+// Commands of the controller.
+const (
+	cmdSWRESET = 0x01
+	cmdSLPOUT  = 0x11
+	cmdNORON   = 0x13
+	cmdINVOFF  = 0x20
+	cmdINVON   = 0x21
+	cmdDISPOFF = 0x28
+	cmdDISPON  = 0x29
+	cmdCASET   = 0x2A
+	cmdRASET   = 0x2B
+	cmdRAMWR   = 0x2C
+	cmdMADCTL  = 0x36
+	cmdCOLMOD  = 0x3A
+	cmdFRMCTR1 = 0xB1
+	cmdFRMCTR2 = 0xB2
+	cmdFRMCTR3 = 0xB3
+	cmdINVCTR  = 0xB4
+	cmdPWCTR1  = 0xC0
+	cmdPWCTR2  = 0xC1
+	cmdPWCTR3  = 0xC2
+	cmdPWCTR4  = 0xC3
+	cmdPWCTR5  = 0xC4
+	cmdVMCTR1  = 0xC5
+	cmdGMCTRP1 = 0xE0
+	cmdGMCTRN1 = 0xE1
+
+	madctlMXMY = 0xC0
+	madctlBGR  = 0x08
+	colmod16   = 0x05 // 16-bit RGB565 pixels
+)
+
+// maxTx is the largest SPI transfer, the default buffer size of spidev.
+const maxTx = 4096
+
+// Config describes a panel.
+type Config struct {
+	// Width and Height of the panel in its native portrait orientation.
+	Width, Height int
+
+	// OffsetX and OffsetY position the panel in the 132x162 memory of the
+	// controller.
+	OffsetX, OffsetY int
+
+	// Rotation is the angle of the image on the panel in degrees,
+	// counter-clockwise: 0, 90, 180 or 270.
+	Rotation int
+
+	// Invert and BGR are set for the panels with inverted colors or with
+	// the blue and red channels swapped.
+	Invert, BGR bool
+}
+
+// Enviro is the 0.96" 160x80 display of the Pimoroni Enviro boards, in
+// landscape.
+var Enviro = Config{Width: 80, Height: 160, OffsetX: 26, OffsetY: 1, Rotation: 270, Invert: true, BGR: true}
+
+// Display represents a display. It implements draw.Image, drawing on an
+// intermediate buffer sent to the display by Draw.
+type Display struct {
+	// Device is the underlying SPI bus. Most users don't have to access
+	// this field.
+	Device *spi.Device
+
+	dc  gpio.Pin
+	cfg Config
+	img *image.RGBA
+	buf []byte
+}
+
+// Open opens a display; dc is the output pin connected to the data/command
+// pin of the controller. The display is on and black after Open.
+func Open(o driver.Opener, dc gpio.Pin, c Config) (*Display, error) {
+	w, h := c.Width, c.Height
+	switch c.Rotation {
+	case 0, 180:
+	case 90, 270:
+		w, h = h, w
+	default:
+		return nil, fmt.Errorf("invalid rotation %d, must be 0, 90, 180 or 270", c.Rotation)
+	}
+	dev, err := spi.Open(o)
+	if err != nil {
+		return nil, err
+	}
+	d := &Display{
+		Device: dev,
+		dc:     dc,
+		cfg:    c,
+		img:    image.NewRGBA(image.Rect(0, 0, w, h)),
+		buf:    make([]byte, 2*c.Width*c.Height),
+	}
+	if err := d.init(); err != nil {
+		dev.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *Display) init() error {
+	if err := d.Device.SetMode(spi.Mode0); err != nil {
+		return err
+	}
+	if err := d.Device.SetBitsPerWord(8); err != nil {
+		return err
+	}
+	if err := d.Device.SetMaxSpeed(Speed); err != nil {
+		return err
+	}
+
+	inv := byte(cmdINVOFF)
+	if d.cfg.Invert {
+		inv = cmdINVON
+	}
+	madctl := byte(madctlMXMY)
+	if d.cfg.BGR {
+		madctl |= madctlBGR
+	}
+	for _, c := range []struct {
+		cmd   byte
+		data  []byte
+		sleep time.Duration
+	}{
+		{cmdSWRESET, nil, 150 * time.Millisecond},
+		{cmdSLPOUT, nil, 500 * time.Millisecond},
+		{cmdFRMCTR1, []byte{0x01, 0x2C, 0x2D}, 0},
+		{cmdFRMCTR2, []byte{0x01, 0x2C, 0x2D}, 0},
+		{cmdFRMCTR3, []byte{0x01, 0x2C, 0x2D, 0x01, 0x2C, 0x2D}, 0},
+		{cmdINVCTR, []byte{0x07}, 0},
+		{cmdPWCTR1, []byte{0xA2, 0x02, 0x84}, 0},
+		{cmdPWCTR2, []byte{0xC5}, 0},
+		{cmdPWCTR3, []byte{0x0A, 0x00}, 0},
+		{cmdPWCTR4, []byte{0x8A, 0x2A}, 0},
+		{cmdPWCTR5, []byte{0x8A, 0xEE}, 0},
+		{cmdVMCTR1, []byte{0x0E}, 0},
+		{inv, nil, 0},
+		{cmdMADCTL, []byte{madctl}, 0},
+		{cmdCOLMOD, []byte{colmod16}, 0},
+		{cmdGMCTRP1, []byte{0x02, 0x1C, 0x07, 0x12, 0x37, 0x32, 0x29, 0x2D, 0x29, 0x25, 0x2B, 0x39, 0x00, 0x01, 0x03, 0x10}, 0},
+		{cmdGMCTRN1, []byte{0x03, 0x1D, 0x07, 0x06, 0x2E, 0x2C, 0x29, 0x2D, 0x2E, 0x2E, 0x37, 0x3F, 0x00, 0x00, 0x02, 0x10}, 0},
+		{cmdNORON, nil, 10 * time.Millisecond},
+	} {
+		if err := d.command(c.cmd, c.data...); err != nil {
+			return err
+		}
+		time.Sleep(c.sleep)
+	}
+	if err := d.Draw(); err != nil {
+		return err
+	}
+	return d.On()
+}
+
+// command sends a command followed by its parameters.
+func (d *Display) command(cmd byte, data ...byte) error {
+	if err := d.dc.Write(0); err != nil {
+		return err
+	}
+	if err := d.Device.Tx([]byte{cmd}, nil); err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	if err := d.dc.Write(1); err != nil {
+		return err
+	}
+	for len(data) > 0 {
+		n := len(data)
+		if n > maxTx {
+			n = maxTx
+		}
+		if err := d.Device.Tx(data[:n], nil); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+// ColorModel implements image.Image.
+func (d *Display) ColorModel() color.Model { return color.RGBAModel }
+
+// Bounds implements image.Image, the size of the display after rotation.
+func (d *Display) Bounds() image.Rectangle { return d.img.Bounds() }
+
+// At implements image.Image.
+func (d *Display) At(x, y int) color.Color { return d.img.At(x, y) }
+
+// Set implements draw.Image, pixels out of the display are ignored.
+func (d *Display) Set(x, y int, c color.Color) { d.img.Set(x, y, c) }
+
+// native returns the position on the panel of the pixel x, y of the
+// image.
+func (d *Display) native(x, y int) (int, int) {
+	w, h := d.cfg.Width, d.cfg.Height
+	switch d.cfg.Rotation {
+	case 90:
+		return y, h - 1 - x
+	case 180:
+		return w - 1 - x, h - 1 - y
+	case 270:
+		return w - 1 - y, x
+	}
+	return x, y
+}
+
+// Draw sends the buffer to the display.
+func (d *Display) Draw() error {
+	b := d.img.Bounds()
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			i := d.img.PixOffset(x, y)
+			r, g, bl := d.img.Pix[i], d.img.Pix[i+1], d.img.Pix[i+2]
+			nx, ny := d.native(x, y)
+			j := 2 * (ny*d.cfg.Width + nx)
+			d.buf[j] = r&0xF8 | g>>5
+			d.buf[j+1] = g&0x1C<<3 | bl>>3
+		}
+	}
+	x0, y0 := d.cfg.OffsetX, d.cfg.OffsetY
+	x1, y1 := x0+d.cfg.Width-1, y0+d.cfg.Height-1
+	if err := d.command(cmdCASET, 0, byte(x0), 0, byte(x1)); err != nil {
+		return err
+	}
+	if err := d.command(cmdRASET, 0, byte(y0), 0, byte(y1)); err != nil {
+		return err
+	}
+	return d.command(cmdRAMWR, d.buf...)
+}
+
+// On turns on the display.
+func (d *Display) On() error {
+	return d.command(cmdDISPON)
+}
+
+// Off turns off the display, the content is kept.
+func (d *Display) Off() error {
+	return d.command(cmdDISPOFF)
+}
+
+// Close closes the display.
+func (d *Display) Close() error {
+	return d.Device.Close()
+}
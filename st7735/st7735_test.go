@@ -0,0 +1,106 @@
+package st7735
+
+import (
+	"bytes"
+	"image/color"
+	"testing"
+
+	"github.com/goiot/devices/spisim"
+	"golang.org/x/exp/io/spi/driver"
+)
+
+// panel is a fake controller splitting the transfers into commands and
+// parameters with the level of the DC pin.
+type panel struct {
+	dc   pin
+	cmds []byte
+	data map[byte][]byte // parameters of the last occurrence of each command
+}
+
+func (p *panel) Open() (driver.Conn, error) { return p, nil }
+func (p *panel) Configure(k, v int) error   { return nil }
+func (p *panel) Close() error               { return nil }
+
+func (p *panel) Tx(w, r []byte) error {
+	if p.dc.v == 0 {
+		p.cmds = append(p.cmds, w...)
+		p.data[w[0]] = nil
+		return nil
+	}
+	last := p.cmds[len(p.cmds)-1]
+	p.data[last] = append(p.data[last], w...)
+	return nil
+}
+
+type pin struct{ v int }
+
+func (p *pin) Read() (int, error) { return p.v, nil }
+func (p *pin) Write(v int) error  { p.v = v; return nil }
+func (p *pin) Close() error       { return nil }
+
+func TestOpen(t *testing.T) {
+	p := &panel{data: make(map[byte][]byte)}
+	d, err := Open(p, &p.dc, Enviro)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := d.Bounds(); b.Dx() != 160 || b.Dy() != 80 {
+		t.Errorf("Bounds = %v, want 160x80", b)
+	}
+	if !bytes.Contains(p.cmds, []byte{cmdINVON}) {
+		t.Error("the IPS panel must be inverted")
+	}
+	if got := p.data[cmdCOLMOD]; !bytes.Equal(got, []byte{colmod16}) {
+		t.Errorf("COLMOD = %x, want %x", got, colmod16)
+	}
+	if last := p.cmds[len(p.cmds)-1]; last != cmdDISPON {
+		t.Errorf("last command = %#x, want DISPON", last)
+	}
+
+	if _, err := Open(p, &p.dc, Config{Width: 80, Height: 160, Rotation: 45}); err == nil {
+		t.Error("expected an error on an invalid rotation")
+	}
+}
+
+func TestDraw(t *testing.T) {
+	p := &panel{data: make(map[byte][]byte)}
+	d, err := Open(p, &p.dc, Enviro)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Set(0, 0, color.RGBA{0xFF, 0, 0, 0xFF})
+	d.Set(159, 79, color.RGBA{0, 0, 0xFF, 0xFF})
+	if err := d.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := p.data[cmdCASET], []byte{0, 26, 0, 105}; !bytes.Equal(got, want) {
+		t.Errorf("CASET = %v, want %v", got, want)
+	}
+	fb := p.data[cmdRAMWR]
+	if len(fb) != 2*80*160 {
+		t.Fatalf("got %d bytes of pixels, want %d", len(fb), 2*80*160)
+	}
+	// the top left corner of the landscape image is the top right corner
+	// of the portrait panel
+	if i := 2 * 79; fb[i] != 0xF8 || fb[i+1] != 0x00 {
+		t.Errorf("pixel = %x, want red", fb[i:i+2])
+	}
+	if i := 2 * (159 * 80); fb[i] != 0x00 || fb[i+1] != 0x1F {
+		t.Errorf("pixel = %x, want blue", fb[i:i+2])
+	}
+}
+
+func BenchmarkDraw(b *testing.B) {
+	d, err := Open(spisim.New(spisim.DeviceFunc(func(w, r []byte) error { return nil })), &pin{}, Enviro)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(2 * d.Bounds().Dx() * d.Bounds().Dy()))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := d.Draw(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package stream
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"image"
+	"image/png"
+)
+
+const (
+	// tileSize is the size of the tiles of the delta frames, in pixels.
+	tileSize = 8
+
+	// deltaMagic starts the delta frames, the keyframes are PNG images.
+	deltaMagic = 'D'
+)
+
+// keyframe returns the PNG encoding of f.
+func keyframe(f *image.RGBA) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, f); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// delta returns the delta frame updating prev to f: the byte 'D' followed
+// by the raw deflate of the width and the height of the frame and the
+// size of the tiles, big endian uint16, uint16 and uint8, then of each
+// tile changed: its column and row, uint16, and its RGBA pixels, row by
+// row, the tiles of the right and bottom edges cut to the frame. It
+// returns nil if f did not change, and false if a keyframe is smaller.
+func delta(prev, f *image.RGBA) ([]byte, bool) {
+	b := f.Bounds()
+	if prev == nil || prev.Bounds() != b {
+		return nil, false
+	}
+	cols, rows := (b.Dx()+tileSize-1)/tileSize, (b.Dy()+tileSize-1)/tileSize
+	var changed []image.Rectangle
+	for ty := 0; ty < rows; ty++ {
+		for tx := 0; tx < cols; tx++ {
+			r := image.Rect(tx*tileSize, ty*tileSize, (tx+1)*tileSize, (ty+1)*tileSize).Add(b.Min).Intersect(b)
+			if !sameTile(prev, f, r) {
+				changed = append(changed, r)
+			}
+		}
+	}
+	if len(changed) == 0 {
+		return nil, true
+	}
+	if len(changed) > cols*rows/2 {
+		return nil, false
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(deltaMagic)
+	w, _ := flate.NewWriter(&buf, flate.BestSpeed)
+	hdr := make([]byte, 5)
+	binary.BigEndian.PutUint16(hdr, uint16(b.Dx()))
+	binary.BigEndian.PutUint16(hdr[2:], uint16(b.Dy()))
+	hdr[4] = tileSize
+	w.Write(hdr)
+	for _, r := range changed {
+		at := make([]byte, 4)
+		binary.BigEndian.PutUint16(at, uint16((r.Min.X-b.Min.X)/tileSize))
+		binary.BigEndian.PutUint16(at[2:], uint16((r.Min.Y-b.Min.Y)/tileSize))
+		w.Write(at)
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			i := f.PixOffset(r.Min.X, y)
+			w.Write(f.Pix[i : i+4*r.Dx()])
+		}
+	}
+	w.Close()
+	return buf.Bytes(), true
+}
+
+// sameTile reports whether the tile r of a and b are the same.
+func sameTile(a, b *image.RGBA, r image.Rectangle) bool {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		i, j := a.PixOffset(r.Min.X, y), b.PixOffset(r.Min.X, y)
+		if !bytes.Equal(a.Pix[i:i+4*r.Dx()], b.Pix[j:j+4*r.Dx()]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+// The dashboard example streams the status screen of a PiOLED and the
+// measurements of a BME280; open http://<device>:8080 in a browser.
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/goiot/devices/apps"
+	"github.com/goiot/devices/bme280"
+	"github.com/goiot/devices/boards/pioled"
+	"github.com/goiot/devices/i2cbus"
+	"github.com/goiot/devices/stream"
+)
+
+func main() {
+	oled, err := pioled.OpenPiOLED()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer oled.Close()
+	bus, err := i2cbus.Open("primary")
+	if err != nil {
+		log.Fatal(err)
+	}
+	sensor, err := bme280.Open(bus, bme280.Addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer sensor.Close()
+
+	s := stream.NewServer()
+	go func() {
+		log.Fatal(http.ListenAndServe(":8080", s))
+	}()
+
+	go func() {
+		for range time.Tick(time.Second) {
+			m, err := sensor.Read()
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+			s.Sample(stream.Sample{Sensor: "temperature", Value: m.Temperature, Unit: "C"})
+			s.Sample(stream.Sample{Sensor: "humidity", Value: m.Humidity, Unit: "%"})
+			s.Sample(stream.Sample{Sensor: "pressure", Value: m.Pressure, Unit: "hPa"})
+		}
+	}()
+
+	d := stream.Display{Display: oled, Server: s}
+	log.Fatal(apps.Run(context.Background(), d, 5*time.Second, &apps.Stats{}, &apps.Clock{}))
+}
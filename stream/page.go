@@ -0,0 +1,73 @@
+package stream
+
+// page shows the frames, scaled up, and the latest sample of each sensor.
+// The delta frames are drawn over the keyframe on a canvas, in order.
+const page = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Device stream</title>
+<style>
+body { font-family: sans-serif; background: #222; color: #eee; }
+#frame { image-rendering: pixelated; width: 512px; border: 1px solid #555; }
+td { padding: 0 1em 0 0; }
+</style>
+</head>
+<body>
+<canvas id="frame"></canvas>
+<table id="samples"></table>
+<p id="status">connecting</p>
+<script>
+var frame = document.getElementById("frame");
+var ctx = frame.getContext("2d");
+var frames = Promise.resolve();
+var samples = document.getElementById("samples");
+var status = document.getElementById("status");
+var rows = {};
+var proto = location.protocol === "https:" ? "wss://" : "ws://";
+var ws = new WebSocket(proto + location.host + location.pathname);
+ws.binaryType = "arraybuffer";
+ws.onopen = function() { status.textContent = "connected"; };
+ws.onclose = function() { status.textContent = "disconnected"; };
+ws.onmessage = function(e) {
+	if (e.data instanceof ArrayBuffer) {
+		var data = e.data;
+		frames = frames.then(function() { return show(data); });
+		return;
+	}
+	var s = JSON.parse(e.data);
+	var row = rows[s.sensor];
+	if (!row) {
+		row = rows[s.sensor] = samples.insertRow();
+		row.insertCell().textContent = s.sensor;
+		row.insertCell();
+		row.insertCell();
+	}
+	row.cells[1].textContent = s.value + (s.unit ? " " + s.unit : "");
+	row.cells[2].textContent = new Date(s.time).toLocaleTimeString();
+};
+function show(data) {
+	var b = new Uint8Array(data);
+	if (b[0] !== 0x44) { // a PNG keyframe
+		return createImageBitmap(new Blob([data])).then(function(img) {
+			frame.width = img.width;
+			frame.height = img.height;
+			ctx.drawImage(img, 0, 0);
+		});
+	}
+	var tiles = new Blob([b.subarray(1)]).stream().pipeThrough(new DecompressionStream("deflate-raw"));
+	return new Response(tiles).arrayBuffer().then(function(buf) {
+		var v = new DataView(buf), w = v.getUint16(0), h = v.getUint16(2), size = v.getUint8(4);
+		for (var i = 5; i < buf.byteLength;) {
+			var x = v.getUint16(i) * size, y = v.getUint16(i + 2) * size;
+			var tw = Math.min(size, w - x), th = Math.min(size, h - y);
+			i += 4;
+			ctx.putImageData(new ImageData(new Uint8ClampedArray(buf, i, tw * th * 4), tw, th), x, y);
+			i += tw * th * 4;
+		}
+	});
+}
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,283 @@
+// Package stream streams the frames shown on a display and the samples of
+// sensors to browsers over WebSocket, so a dashboard can mirror what a
+// headless device shows and senses.
+package stream
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/draw"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/goiot/devices/display"
+)
+
+// writeTimeout is the time given to a client to receive a message before
+// it is disconnected.
+const writeTimeout = 10 * time.Second
+
+// Sample is a reading of a sensor, sent as a JSON text message.
+type Sample struct {
+	Sensor string    `json:"sensor"`
+	Value  float64   `json:"value"`
+	Unit   string    `json:"unit,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// Server is an http.Handler streaming the frames and the samples, in text
+// messages, to the WebSocket clients. The frames are sent in binary
+// messages: a keyframe is a PNG image, and the frames in between are
+// delta frames of the tiles changed since the frame sent before, see
+// Keyframes. The requests which are not WebSocket handshakes get a page
+// showing the stream. A client slower than the frames only receives the
+// latest frame.
+type Server struct {
+	// ErrorLog logs the failed handshakes, the standard logger is used if
+	// nil.
+	ErrorLog *log.Logger
+
+	// Keyframes is the number of frames sent to a client from a keyframe
+	// to the next, 60 if 0; 1 sends only keyframes. A keyframe is also
+	// sent to the new clients, and when most of the tiles changed.
+	Keyframes int
+
+	mu      sync.Mutex
+	clients map[*client]bool
+}
+
+// NewServer returns a server without clients.
+func NewServer() *Server {
+	return &Server{clients: make(map[*client]bool)}
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !isUpgrade(r) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+		return
+	}
+	conn, br, err := upgrade(w, r)
+	if err != nil {
+		s.logf("stream: %v: %v", r.RemoteAddr, err)
+		return
+	}
+	c := &client{conn: conn, wake: make(chan struct{}, 1), keyframes: s.Keyframes}
+	if c.keyframes <= 0 {
+		c.keyframes = defaultKeyframes
+	}
+	s.mu.Lock()
+	s.clients[c] = true
+	s.mu.Unlock()
+	go c.write()
+	c.read(br)
+	s.mu.Lock()
+	delete(s.clients, c)
+	s.mu.Unlock()
+	c.close()
+}
+
+func (s *Server) logf(format string, args ...interface{}) {
+	if s.ErrorLog != nil {
+		s.ErrorLog.Printf(format, args...)
+		return
+	}
+	log.Printf(format, args...)
+}
+
+// Clients returns the number of connected clients.
+func (s *Server) Clients() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.clients)
+}
+
+// defaultKeyframes is the number of frames from a keyframe to the next.
+const defaultKeyframes = 60
+
+// Frame sends img to the clients. It is encoded only if there are clients,
+// by each client as a keyframe or a delta frame.
+func (s *Server) Frame(img image.Image) error {
+	if s.Clients() == 0 {
+		return nil
+	}
+	b := img.Bounds()
+	f := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(f, f.Bounds(), img, b.Min, draw.Src)
+	s.each(func(c *client) { c.sendFrame(f) })
+	return nil
+}
+
+// Sample sends a sample to the clients.
+func (s *Server) Sample(sample Sample) error {
+	if sample.Time.IsZero() {
+		sample.Time = time.Now()
+	}
+	b, err := json.Marshal(sample)
+	if err != nil {
+		return err
+	}
+	s.each(func(c *client) { c.send(b) })
+	return nil
+}
+
+func (s *Server) each(f func(*client)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.clients {
+		f(c)
+	}
+}
+
+// Display is a display whose frames are also sent to the clients of the
+// server. It implements display.Display.
+type Display struct {
+	display.Display
+	Server *Server
+}
+
+// Draw draws the display and sends the frame to the clients.
+func (d Display) Draw() error {
+	if err := d.Display.Draw(); err != nil {
+		return err
+	}
+	return d.Server.Frame(d.Display)
+}
+
+// maxSamples is the number of samples queued for a client before they are
+// dropped.
+const maxSamples = 64
+
+type client struct {
+	conn net.Conn
+	wake chan struct{}
+
+	mu      sync.Mutex
+	frame   *image.RGBA // latest frame not sent yet
+	samples [][]byte    // samples not sent yet
+
+	last      *image.RGBA // frame sent last
+	keyframes int         // frames from a keyframe to the next
+	deltas    int         // frames sent since the last keyframe
+
+	wmu    sync.Mutex // serializes the writes of the writer and the reader
+	closed bool       // the close frame was sent
+}
+
+// send queues a sample.
+func (c *client) send(b []byte) {
+	c.mu.Lock()
+	if len(c.samples) < maxSamples {
+		c.samples = append(c.samples, b)
+	}
+	c.mu.Unlock()
+	c.notify()
+}
+
+// sendFrame queues a frame, replacing the frame not sent yet.
+func (c *client) sendFrame(f *image.RGBA) {
+	c.mu.Lock()
+	c.frame = f
+	c.mu.Unlock()
+	c.notify()
+}
+
+func (c *client) notify() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// write sends the queued messages until the client is removed.
+func (c *client) write() {
+	for range c.wake {
+		c.mu.Lock()
+		frame, samples := c.frame, c.samples
+		c.frame, c.samples = nil, nil
+		c.mu.Unlock()
+		for _, b := range samples {
+			if err := c.writeFrame(opText, b); err != nil {
+				c.conn.Close()
+				return
+			}
+		}
+		if frame == nil {
+			continue
+		}
+		b, err := c.encode(frame)
+		if err == nil && b != nil {
+			err = c.writeFrame(opBinary, b)
+		}
+		if err != nil {
+			c.conn.Close()
+			return
+		}
+	}
+}
+
+// encode returns the message of the frame f, a delta frame from the frame
+// sent last or a keyframe; nil if f did not change.
+func (c *client) encode(f *image.RGBA) ([]byte, error) {
+	if c.deltas+1 < c.keyframes {
+		if b, ok := delta(c.last, f); ok {
+			if b != nil {
+				c.last = f
+				c.deltas++
+			}
+			return b, nil
+		}
+	}
+	b, err := keyframe(f)
+	if err != nil {
+		return nil, err
+	}
+	c.last, c.deltas = f, 0
+	return b, nil
+}
+
+var errClosed = errors.New("websocket closed")
+
+func (c *client) writeFrame(op byte, b []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closed {
+		return errClosed
+	}
+	if op == opClose {
+		c.closed = true
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return writeFrame(c.conn, op, b)
+}
+
+// read answers the control frames of the client until it closes the
+// connection.
+func (c *client) read(r *bufio.Reader) {
+	for {
+		op, payload, err := readFrame(r)
+		if err != nil {
+			return
+		}
+		switch op {
+		case opClose:
+			c.writeFrame(opClose, payload)
+			return
+		case opPing:
+			c.writeFrame(opPong, payload)
+		}
+	}
+}
+
+// close stops the writer and closes the connection, the client must have
+// been removed from the server.
+func (c *client) close() {
+	close(c.wake)
+	c.conn.Close()
+}
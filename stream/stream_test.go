@@ -0,0 +1,276 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAcceptKey(t *testing.T) {
+	// The example of RFC 6455 section 1.3.
+	if got, want := acceptKey("dGhlIHNhbXBsZSBub25jZQ=="), "s3pPLMBiTxaQ9kYGzzhZRbK+xOo="; got != want {
+		t.Errorf("acceptKey = %q; want %q", got, want)
+	}
+}
+
+type wsClient struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dial(t *testing.T, url string) *wsClient {
+	conn, err := net.Dial("tcp", strings.TrimPrefix(url, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	io.WriteString(conn, "GET / HTTP/1.1\r\nHost: device\r\nUpgrade: websocket\r\nConnection: keep-alive, Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n")
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("handshake response %v %v", resp.Status, resp.Header)
+	}
+	return &wsClient{conn: conn, r: r}
+}
+
+// read reads an unmasked frame of the server.
+func (c *wsClient) read(t *testing.T) (byte, []byte) {
+	var hdr [2]byte
+	if _, err := io.ReadFull(c.r, hdr[:]); err != nil {
+		t.Fatal(err)
+	}
+	n := int(hdr[1] & 0x7F)
+	switch n {
+	case 126:
+		var b [2]byte
+		io.ReadFull(c.r, b[:])
+		n = int(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		io.ReadFull(c.r, b[:])
+		n = int(binary.BigEndian.Uint64(b[:]))
+	}
+	p := make([]byte, n)
+	if _, err := io.ReadFull(c.r, p); err != nil {
+		t.Fatal(err)
+	}
+	if hdr[0]&0x80 == 0 {
+		t.Fatal("fragmented frame")
+	}
+	return hdr[0] & 0x0F, p
+}
+
+// write writes a masked frame, as the browsers do.
+func (c *wsClient) write(op byte, p []byte) {
+	mask := []byte{1, 2, 3, 4}
+	b := []byte{0x80 | op, 0x80 | byte(len(p))}
+	b = append(b, mask...)
+	for i, v := range p {
+		b = append(b, v^mask[i%4])
+	}
+	c.conn.Write(b)
+}
+
+func waitClients(t *testing.T, s *Server, n int) {
+	for i := 0; s.Clients() != n; i++ {
+		if i == 500 {
+			t.Fatalf("%d clients; want %d", s.Clients(), n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+type screen struct {
+	*image.Gray
+	draws int
+}
+
+func (s *screen) Draw() error { s.draws++; return nil }
+
+func TestServer(t *testing.T) {
+	s := NewServer()
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	c := dial(t, ts.URL)
+	defer c.conn.Close()
+	waitClients(t, s, 1)
+
+	at := time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC)
+	if err := s.Sample(Sample{Sensor: "bme280/temperature", Value: 21.5, Unit: "C", Time: at}); err != nil {
+		t.Fatal(err)
+	}
+	op, p := c.read(t)
+	var got Sample
+	if err := json.Unmarshal(p, &got); op != opText || err != nil || got.Sensor != "bme280/temperature" || got.Value != 21.5 || !got.Time.Equal(at) {
+		t.Errorf("sample message %d %s", op, p)
+	}
+
+	d := Display{Display: &screen{Gray: image.NewGray(image.Rect(0, 0, 200, 100))}, Server: s}
+	d.Set(10, 20, color.White)
+	if err := d.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	op, p = c.read(t)
+	if op != opBinary {
+		t.Fatalf("frame message opcode %d", op)
+	}
+	img, err := png.Decode(bytes.NewReader(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, _, _, _ := img.At(10, 20).RGBA(); img.Bounds().Dx() != 200 || r != 0xFFFF {
+		t.Errorf("frame %v, pixel %v", img.Bounds(), img.At(10, 20))
+	}
+	if n := d.Display.(*screen).draws; n != 1 {
+		t.Errorf("%d draws of the display; want 1", n)
+	}
+	d.Set(11, 20, color.White)
+	if err := d.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	if op, p = c.read(t); op != opBinary || p[0] != deltaMagic {
+		t.Errorf("second frame message %d % X; want a delta frame", op, p[:1])
+	}
+
+	c.write(opPing, []byte("hi"))
+	if op, p := c.read(t); op != opPong || string(p) != "hi" {
+		t.Errorf("ping answered by %d %q", op, p)
+	}
+	c.write(opClose, []byte{0x03, 0xE8})
+	if op, p := c.read(t); op != opClose || !bytes.Equal(p, []byte{0x03, 0xE8}) {
+		t.Errorf("close answered by %d %v", op, p)
+	}
+	waitClients(t, s, 0)
+}
+
+func TestPage(t *testing.T) {
+	s := NewServer()
+	s.ErrorLog = log.New(ioutil.Discard, "", 0)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	resp, err := http.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(b), "new WebSocket(") {
+		t.Errorf("page %s", b)
+	}
+
+	req, _ := http.NewRequest("GET", ts.URL, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "8")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("handshake with version 8: %v", resp.Status)
+	}
+}
+
+func TestLatestFrame(t *testing.T) {
+	c := &client{wake: make(chan struct{}, 1)}
+	f1, f2 := image.NewRGBA(image.Rect(0, 0, 1, 1)), image.NewRGBA(image.Rect(0, 0, 2, 2))
+	c.sendFrame(f1)
+	c.sendFrame(f2)
+	for i := 0; i < maxSamples+10; i++ {
+		c.send([]byte("sample"))
+	}
+	if c.frame != f2 || len(c.samples) != maxSamples {
+		t.Errorf("queued frame %v and %d samples; want the frame 2 and %d samples", c.frame.Bounds(), len(c.samples), maxSamples)
+	}
+}
+
+// apply decodes the keyframe or the delta frame b over img.
+func apply(t *testing.T, img *image.RGBA, b []byte) *image.RGBA {
+	if b[0] != deltaMagic {
+		k, err := png.Decode(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		img = image.NewRGBA(k.Bounds())
+		draw.Draw(img, img.Bounds(), k, image.Point{}, draw.Src)
+		return img
+	}
+	p, err := ioutil.ReadAll(flate.NewReader(bytes.NewReader(b[1:])))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, h, size := int(binary.BigEndian.Uint16(p)), int(binary.BigEndian.Uint16(p[2:])), int(p[4])
+	if img == nil || img.Bounds() != image.Rect(0, 0, w, h) {
+		t.Fatalf("delta frame of %dx%d over %v", w, h, img)
+	}
+	for p = p[5:]; len(p) > 0; {
+		x, y := int(binary.BigEndian.Uint16(p))*size, int(binary.BigEndian.Uint16(p[2:]))*size
+		r := image.Rect(x, y, x+size, y+size).Intersect(img.Bounds())
+		p = p[4:]
+		for row := r.Min.Y; row < r.Max.Y; row++ {
+			i := img.PixOffset(r.Min.X, row)
+			p = p[copy(img.Pix[i:i+4*r.Dx()], p):]
+		}
+	}
+	return img
+}
+
+func TestDelta(t *testing.T) {
+	c := &client{keyframes: 4}
+	f := image.NewRGBA(image.Rect(0, 0, 100, 30)) // the edge tiles are cut
+	var shown *image.RGBA
+	kinds := ""
+	for i := 0; i < 6; i++ {
+		next := image.NewRGBA(f.Bounds())
+		copy(next.Pix, f.Pix)
+		next.Set(3*i, 2*i, color.White)
+		next.Set(99, 29, color.RGBA{R: byte(i), A: 0xFF})
+		b, err := c.encode(next)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b[0] == deltaMagic {
+			kinds += "D"
+		} else {
+			kinds += "K"
+		}
+		shown = apply(t, shown, b)
+		if !bytes.Equal(shown.Pix, next.Pix) {
+			t.Fatalf("frame %d differs once decoded", i)
+		}
+		f = next
+	}
+	if kinds != "KDDDKD" {
+		t.Errorf("frames %s; want a keyframe every 4 frames", kinds)
+	}
+	if b, err := c.encode(f); err != nil || b != nil {
+		t.Errorf("unchanged frame encoded to %d bytes, %v", len(b), err)
+	}
+
+	// mostly changed
+	full := image.NewRGBA(f.Bounds())
+	draw.Draw(full, full.Bounds(), image.White, image.Point{}, draw.Src)
+	if b, _ := c.encode(full); b[0] == deltaMagic {
+		t.Error("delta frame of a frame whose tiles all changed")
+	}
+}
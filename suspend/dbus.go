@@ -0,0 +1,339 @@
+package suspend
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// The D-Bus wire format, of the few messages exchanged with logind: the
+// bodies are made of basic types only.
+
+// Types of messages.
+const (
+	methodCall   = 1
+	methodReturn = 2
+	errorReply   = 3
+	signal       = 4
+)
+
+// Header fields.
+const (
+	fieldPath        = 1
+	fieldInterface   = 2
+	fieldMember      = 3
+	fieldErrorName   = 4
+	fieldReplySerial = 5
+	fieldDestination = 6
+	fieldSender      = 7
+	fieldSignature   = 8
+	fieldUnixFDs     = 9
+)
+
+// objectPath is a value of type o, string one of type s.
+type objectPath string
+
+// unixFD is a value of type h, the index of a descriptor passed with the
+// message.
+type unixFD uint32
+
+// message is a D-Bus message.
+type message struct {
+	typ         byte
+	flags       byte
+	serial      uint32
+	path        objectPath
+	iface       string
+	member      string
+	errName     string
+	replySerial uint32
+	dest        string
+	sender      string
+	sig         string
+	nfds        uint32
+
+	// body are the values of sig: byte, bool, uint32, string, objectPath
+	// or unixFD. It is nil if sig has other types.
+	body []interface{}
+
+	fds []int // descriptors received with the message
+}
+
+// marshal returns m in little endian.
+func (m *message) marshal() ([]byte, error) {
+	body := &encoder{order: binary.LittleEndian}
+	for _, v := range m.body {
+		if err := body.value(v); err != nil {
+			return nil, err
+		}
+	}
+	e := &encoder{order: binary.LittleEndian}
+	e.b = append(e.b, 'l', m.typ, m.flags, 1)
+	e.uint32(uint32(len(body.b)))
+	e.uint32(m.serial)
+
+	// the array of the header fields, its length set at last
+	e.uint32(0)
+	start := len(e.b)
+	field := func(code byte, sig string, v interface{}) {
+		e.align(8)
+		e.b = append(e.b, code)
+		e.signature(sig)
+		e.value(v)
+	}
+	if m.path != "" {
+		field(fieldPath, "o", m.path)
+	}
+	if m.iface != "" {
+		field(fieldInterface, "s", m.iface)
+	}
+	if m.member != "" {
+		field(fieldMember, "s", m.member)
+	}
+	if m.errName != "" {
+		field(fieldErrorName, "s", m.errName)
+	}
+	if m.replySerial != 0 {
+		field(fieldReplySerial, "u", m.replySerial)
+	}
+	if m.dest != "" {
+		field(fieldDestination, "s", m.dest)
+	}
+	if m.sender != "" {
+		field(fieldSender, "s", m.sender)
+	}
+	if m.sig != "" {
+		field(fieldSignature, "g", signature(m.sig))
+	}
+	if m.nfds != 0 {
+		field(fieldUnixFDs, "u", m.nfds)
+	}
+	e.order.PutUint32(e.b[start-4:], uint32(len(e.b)-start))
+	e.align(8)
+	return append(e.b, body.b...), nil
+}
+
+// signature is a value of type g.
+type signature string
+
+type encoder struct {
+	b     []byte
+	order binary.ByteOrder
+}
+
+func (e *encoder) align(n int) {
+	for len(e.b)%n != 0 {
+		e.b = append(e.b, 0)
+	}
+}
+
+func (e *encoder) uint32(v uint32) {
+	e.align(4)
+	e.b = append(e.b, 0, 0, 0, 0)
+	e.order.PutUint32(e.b[len(e.b)-4:], v)
+}
+
+func (e *encoder) string(s string) {
+	e.uint32(uint32(len(s)))
+	e.b = append(append(e.b, s...), 0)
+}
+
+func (e *encoder) signature(s string) {
+	e.b = append(append(append(e.b, byte(len(s))), s...), 0)
+}
+
+func (e *encoder) value(v interface{}) error {
+	switch v := v.(type) {
+	case byte:
+		e.b = append(e.b, v)
+	case bool:
+		var b uint32
+		if v {
+			b = 1
+		}
+		e.uint32(b)
+	case uint32:
+		e.uint32(v)
+	case unixFD:
+		e.uint32(uint32(v))
+	case string:
+		e.string(v)
+	case objectPath:
+		e.string(string(v))
+	case signature:
+		e.signature(string(v))
+	default:
+		return fmt.Errorf("unsupported D-Bus value %T", v)
+	}
+	return nil
+}
+
+// sigOf returns the signature of the values.
+func sigOf(values ...interface{}) string {
+	var s []byte
+	for _, v := range values {
+		switch v.(type) {
+		case byte:
+			s = append(s, 'y')
+		case bool:
+			s = append(s, 'b')
+		case uint32:
+			s = append(s, 'u')
+		case unixFD:
+			s = append(s, 'h')
+		case string:
+			s = append(s, 's')
+		case objectPath:
+			s = append(s, 'o')
+		case signature:
+			s = append(s, 'g')
+		}
+	}
+	return string(s)
+}
+
+var errShort = errors.New("truncated D-Bus message")
+
+// messageLen returns the length of the message starting b, or 0 if the
+// fixed part of its header is not there yet.
+func messageLen(b []byte) (int, error) {
+	if len(b) < 16 {
+		return 0, nil
+	}
+	order, err := byteOrder(b[0])
+	if err != nil {
+		return 0, err
+	}
+	header := align(16+int(order.Uint32(b[12:])), 8)
+	return header + int(order.Uint32(b[4:])), nil
+}
+
+func byteOrder(endian byte) (binary.ByteOrder, error) {
+	switch endian {
+	case 'l':
+		return binary.LittleEndian, nil
+	case 'B':
+		return binary.BigEndian, nil
+	}
+	return nil, fmt.Errorf("invalid D-Bus endianness %q", endian)
+}
+
+func align(n, a int) int { return (n + a - 1) / a * a }
+
+// unmarshal parses the message b, of the length returned by messageLen.
+func unmarshal(b []byte) (*message, error) {
+	order, err := byteOrder(b[0])
+	if err != nil {
+		return nil, err
+	}
+	m := &message{typ: b[1], flags: b[2]}
+	m.serial = order.Uint32(b[8:])
+	d := &decoder{b: b, i: 16, order: order}
+	end := 16 + int(order.Uint32(b[12:]))
+	if end > len(b) {
+		return nil, errShort
+	}
+	for d.i < end && d.err == nil {
+		d.align(8)
+		code := d.byte()
+		v := d.value(d.signature())
+		switch code {
+		case fieldPath:
+			p, _ := v.(objectPath)
+			m.path = p
+		case fieldInterface:
+			m.iface, _ = v.(string)
+		case fieldMember:
+			m.member, _ = v.(string)
+		case fieldErrorName:
+			m.errName, _ = v.(string)
+		case fieldReplySerial:
+			m.replySerial, _ = v.(uint32)
+		case fieldDestination:
+			m.dest, _ = v.(string)
+		case fieldSender:
+			m.sender, _ = v.(string)
+		case fieldSignature:
+			s, _ := v.(signature)
+			m.sig = string(s)
+		case fieldUnixFDs:
+			m.nfds, _ = v.(uint32)
+		}
+	}
+	d.b, d.i = b[align(end, 8):], 0
+	var body []interface{}
+	for _, c := range m.sig {
+		v := d.value(string(c))
+		if v == nil {
+			// arrays, structs... are not used by the messages of logind
+			body = nil
+			break
+		}
+		body = append(body, v)
+	}
+	m.body = body
+	return m, d.err
+}
+
+type decoder struct {
+	b     []byte
+	i     int
+	order binary.ByteOrder
+	err   error
+}
+
+func (d *decoder) align(n int) { d.i = align(d.i, n) }
+
+func (d *decoder) byte() byte {
+	if d.err != nil || d.i >= len(d.b) {
+		d.err = errShort
+		return 0
+	}
+	d.i++
+	return d.b[d.i-1]
+}
+
+func (d *decoder) uint32() uint32 {
+	d.align(4)
+	if d.err != nil || d.i+4 > len(d.b) {
+		d.err = errShort
+		return 0
+	}
+	d.i += 4
+	return d.order.Uint32(d.b[d.i-4:])
+}
+
+func (d *decoder) bytes(n int) string {
+	if d.err != nil || n < 0 || d.i+n+1 > len(d.b) {
+		d.err = errShort
+		return ""
+	}
+	s := string(d.b[d.i : d.i+n])
+	d.i += n + 1 // and the nul byte
+	return s
+}
+
+func (d *decoder) signature() string { return d.bytes(int(d.byte())) }
+
+// value returns the value of the basic type sig, or nil if it is not one.
+func (d *decoder) value(sig string) interface{} {
+	switch sig {
+	case "y":
+		return d.byte()
+	case "b":
+		return d.uint32() != 0
+	case "u":
+		return d.uint32()
+	case "h":
+		return unixFD(d.uint32())
+	case "s":
+		return d.bytes(int(d.uint32()))
+	case "o":
+		return objectPath(d.bytes(int(d.uint32())))
+	case "g":
+		return signature(d.signature())
+	case "v":
+		return d.value(d.signature())
+	}
+	return nil
+}
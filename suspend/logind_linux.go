@@ -0,0 +1,317 @@
+//go:build linux && !tinygo
+// +build linux,!tinygo
+
+package suspend
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const (
+	systemBus = "/var/run/dbus/system_bus_socket"
+
+	logind        = "org.freedesktop.login1"
+	logindPath    = objectPath("/org/freedesktop/login1")
+	logindManager = "org.freedesktop.login1.Manager"
+)
+
+// Listen runs the hooks at the sleeps of the system until ctx is done,
+// following the PrepareForSleep signals of systemd-logind. A delay lock
+// holds the sleeps until the Suspend hooks are done, for the time set by
+// InhibitDelayMaxSec of logind.conf, 5 seconds by default. The system bus
+// is the one of DBUS_SYSTEM_BUS_ADDRESS, if set.
+func (h *Hooks) Listen(ctx context.Context) error {
+	path, err := systemBusPath()
+	if err != nil {
+		return err
+	}
+	c, err := dial(path)
+	if err != nil {
+		return fmt.Errorf("cannot connect to the system bus - %v", err)
+	}
+	defer c.Close()
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.Close()
+		case <-stop:
+		}
+	}()
+	err = h.listen(c)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+func (h *Hooks) listen(c *conn) error {
+	if _, err := c.call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "Hello"); err != nil {
+		return err
+	}
+	match := "type='signal',interface='" + logindManager + "',member='PrepareForSleep'"
+	if _, err := c.call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "AddMatch", match); err != nil {
+		return err
+	}
+	lock, err := c.inhibit()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if lock >= 0 {
+			syscall.Close(lock)
+		}
+	}()
+	for {
+		m, err := c.next()
+		if err != nil {
+			return err
+		}
+		if m.typ != signal || m.iface != logindManager || m.member != "PrepareForSleep" || len(m.body) != 1 {
+			continue
+		}
+		if sleeping, _ := m.body[0].(bool); sleeping {
+			h.Suspend()
+			if lock >= 0 {
+				// lets the system sleep
+				syscall.Close(lock)
+				lock = -1
+			}
+			continue
+		}
+		h.Resume()
+		if lock < 0 {
+			if lock, err = c.inhibit(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func systemBusPath() (string, error) {
+	addr := os.Getenv("DBUS_SYSTEM_BUS_ADDRESS")
+	if addr == "" {
+		return systemBus, nil
+	}
+	// the first unix path of the addresses
+	for _, a := range strings.Split(addr, ";") {
+		if !strings.HasPrefix(a, "unix:") {
+			continue
+		}
+		for _, kv := range strings.Split(a[len("unix:"):], ",") {
+			if strings.HasPrefix(kv, "path=") {
+				return kv[len("path="):], nil
+			}
+		}
+	}
+	return "", fmt.Errorf("unsupported system bus address %q", addr)
+}
+
+// conn is a connection to a D-Bus bus.
+type conn struct {
+	c      *net.UnixConn
+	r      *bufio.Reader // of the authentication only
+	serial uint32
+
+	buf     []byte // received, not parsed yet
+	fds     []int  // received, not taken by a message yet
+	pending []*message
+}
+
+// dial connects to the bus at path and authenticates as the user of the
+// process, with the passing of descriptors.
+func dial(path string) (*conn, error) {
+	uc, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	c := &conn{c: uc, r: bufio.NewReader(uc)}
+	if err := c.auth(); err != nil {
+		uc.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *conn) auth() error {
+	uid := fmt.Sprintf("%x", strconv.Itoa(os.Getuid()))
+	if err := c.command("\x00AUTH EXTERNAL "+uid, "OK "); err != nil {
+		return err
+	}
+	if err := c.command("NEGOTIATE_UNIX_FD", "AGREE_UNIX_FD"); err != nil {
+		return err
+	}
+	if _, err := c.c.Write([]byte("BEGIN\r\n")); err != nil {
+		return err
+	}
+	// the messages may follow the replies in the buffer
+	if n := c.r.Buffered(); n > 0 {
+		b, _ := c.r.Peek(n)
+		c.buf = append(c.buf, b...)
+	}
+	c.r = nil
+	return nil
+}
+
+// command sends a command of the authentication, and checks the reply.
+func (c *conn) command(cmd, reply string) error {
+	if _, err := c.c.Write([]byte(cmd + "\r\n")); err != nil {
+		return err
+	}
+	l, err := c.r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if l = strings.TrimRight(l, "\r\n"); !strings.HasPrefix(l, reply) {
+		return fmt.Errorf("authentication to the bus failed - %s", l)
+	}
+	return nil
+}
+
+func (c *conn) Close() error {
+	for _, fd := range c.fds {
+		syscall.Close(fd)
+	}
+	c.fds = nil
+	return c.c.Close()
+}
+
+// call calls the method of the object at path of the destination dest
+// with the arguments, and returns the reply.
+func (c *conn) call(dest string, path objectPath, iface, member string, args ...interface{}) (*message, error) {
+	c.serial++
+	m := &message{
+		typ:    methodCall,
+		serial: c.serial,
+		path:   path,
+		iface:  iface,
+		member: member,
+		dest:   dest,
+		sig:    sigOf(args...),
+		body:   args,
+	}
+	b, err := m.marshal()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.c.Write(b); err != nil {
+		return nil, err
+	}
+	for {
+		r, err := c.read()
+		if err != nil {
+			return nil, err
+		}
+		if r.replySerial != m.serial || r.typ != methodReturn && r.typ != errorReply {
+			// a signal received in the meantime
+			c.pending = append(c.pending, r)
+			continue
+		}
+		if r.typ == errorReply {
+			closeAll(r.fds)
+			msg := r.errName
+			if len(r.body) > 0 {
+				if s, ok := r.body[0].(string); ok {
+					msg += ": " + s
+				}
+			}
+			return nil, fmt.Errorf("%s.%s failed - %s", iface, member, msg)
+		}
+		return r, nil
+	}
+}
+
+// inhibit takes a delay lock on the sleeps of the system, held until the
+// descriptor returned is closed.
+func (c *conn) inhibit() (int, error) {
+	r, err := c.call(logind, logindPath, logindManager, "Inhibit", "sleep", os.Args[0], "Quiescing the devices", "delay")
+	if err != nil {
+		return -1, err
+	}
+	if len(r.body) != 1 {
+		closeAll(r.fds)
+		return -1, fmt.Errorf("unexpected reply %q to Inhibit", r.sig)
+	}
+	i, ok := r.body[0].(unixFD)
+	if !ok || int(i) >= len(r.fds) {
+		closeAll(r.fds)
+		return -1, fmt.Errorf("no descriptor in the reply to Inhibit")
+	}
+	fd := r.fds[i]
+	r.fds[i] = -1
+	closeAll(r.fds)
+	return fd, nil
+}
+
+// next returns the next message which is not a reply.
+func (c *conn) next() (*message, error) {
+	if len(c.pending) > 0 {
+		m := c.pending[0]
+		c.pending = c.pending[1:]
+		return m, nil
+	}
+	return c.read()
+}
+
+// read reads the next message, and the descriptors passed with it.
+func (c *conn) read() (*message, error) {
+	b := make([]byte, 4096)
+	oob := make([]byte, syscall.CmsgSpace(16*4))
+	for {
+		n, err := messageLen(c.buf)
+		if err != nil {
+			return nil, err
+		}
+		if n > 0 && len(c.buf) >= n {
+			m, err := unmarshal(c.buf[:n])
+			c.buf = c.buf[n:]
+			if err != nil {
+				return nil, err
+			}
+			k := int(m.nfds)
+			if k > len(c.fds) {
+				k = len(c.fds)
+			}
+			m.fds, c.fds = c.fds[:k:k], c.fds[k:]
+			if m.typ == signal {
+				// no signal of logind passes descriptors
+				closeAll(m.fds)
+				m.fds = nil
+			}
+			return m, nil
+		}
+		nb, noob, _, _, err := c.c.ReadMsgUnix(b, oob)
+		if err != nil {
+			return nil, err
+		}
+		c.buf = append(c.buf, b[:nb]...)
+		if noob > 0 {
+			msgs, err := syscall.ParseSocketControlMessage(oob[:noob])
+			if err != nil {
+				return nil, err
+			}
+			for _, msg := range msgs {
+				fds, err := syscall.ParseUnixRights(&msg)
+				if err == nil {
+					c.fds = append(c.fds, fds...)
+				}
+			}
+		}
+	}
+}
+
+func closeAll(fds []int) {
+	for _, fd := range fds {
+		if fd >= 0 {
+			syscall.Close(fd)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+//go:build linux && !tinygo
+// +build linux,!tinygo
+
+package suspend
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fakeBus is the system bus and logind, as seen by one client.
+type fakeBus struct {
+	t *testing.T
+	c *conn
+}
+
+func (b *fakeBus) auth(uc *net.UnixConn) {
+	r := bufio.NewReader(uc)
+	for _, reply := range []string{"OK 0123456789abcdef", "AGREE_UNIX_FD", ""} {
+		l, err := r.ReadString('\n')
+		if err != nil {
+			b.t.Fatal(err)
+		}
+		if reply != "" {
+			uc.Write([]byte(reply + "\r\n"))
+		} else if l != "BEGIN\r\n" {
+			b.t.Fatalf("got %q; want BEGIN", l)
+		}
+	}
+	b.c = &conn{c: uc}
+	if n := r.Buffered(); n > 0 {
+		p, _ := r.Peek(n)
+		b.c.buf = append(b.c.buf, p...)
+	}
+}
+
+func (b *fakeBus) send(m *message, fds ...int) {
+	m.sig = sigOf(m.body...)
+	p, err := m.marshal()
+	if err != nil {
+		b.t.Fatal(err)
+	}
+	if _, _, err := b.c.c.WriteMsgUnix(p, syscall.UnixRights(fds...), nil); err != nil {
+		b.t.Fatal(err)
+	}
+}
+
+// serve answers the call of member, and returns it.
+func (b *fakeBus) serve(member string, reply ...interface{}) *message {
+	m, err := b.c.read()
+	if err != nil {
+		b.t.Fatal(err)
+	}
+	if m.typ != methodCall || m.member != member {
+		b.t.Fatalf("got %q of type %d; want a call of %s", m.member, m.typ, member)
+	}
+	b.send(&message{typ: methodReturn, serial: 100 + m.serial, replySerial: m.serial, body: reply})
+	return m
+}
+
+// inhibit answers a call of Inhibit, and returns the read end of the lock.
+func (b *fakeBus) inhibit() *os.File {
+	m, err := b.c.read()
+	if err != nil {
+		b.t.Fatal(err)
+	}
+	if m.member != "Inhibit" || len(m.body) != 4 || m.body[0] != "sleep" || m.body[3] != "delay" {
+		b.t.Fatalf("got %s%v; want an Inhibit of a delay lock on the sleeps", m.member, m.body)
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		b.t.Fatal(err)
+	}
+	b.send(&message{typ: methodReturn, serial: 100 + m.serial, replySerial: m.serial, body: []interface{}{unixFD(0)}, nfds: 1}, int(w.Fd()))
+	w.Close()
+	return r
+}
+
+func (b *fakeBus) prepareForSleep(sleeping bool) {
+	b.send(&message{
+		typ:    signal,
+		serial: 1,
+		path:   logindPath,
+		iface:  logindManager,
+		member: "PrepareForSleep",
+		body:   []interface{}{sleeping},
+	})
+}
+
+func TestListen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bus")
+	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	t.Setenv("DBUS_SYSTEM_BUS_ADDRESS", "unix:path="+path+",guid=0123")
+
+	events := make(chan string, 10)
+	var hooks Hooks
+	hooks.Add(Hook{
+		Name:    "oled",
+		Suspend: func() error { events <- "suspend"; return nil },
+		Resume:  func() error { events <- "resume"; return nil },
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- hooks.Listen(ctx) }()
+
+	uc, err := l.AcceptUnix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer uc.Close()
+	bus := &fakeBus{t: t}
+	bus.auth(uc)
+	bus.serve("Hello", ":1.42")
+	if m := bus.serve("AddMatch"); len(m.body) != 1 || !strings.Contains(m.body[0].(string), "PrepareForSleep") {
+		t.Errorf("AddMatch%v; want a match of PrepareForSleep", m.body)
+	}
+	lock := bus.inhibit()
+
+	// the lock is released once the devices are suspended
+	bus.prepareForSleep(true)
+	lock.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if n, err := lock.Read(make([]byte, 1)); n != 0 || err == nil || os.IsTimeout(err) {
+		t.Fatalf("lock still held: %v", err)
+	}
+	lock.Close()
+	select {
+	case e := <-events:
+		if e != "suspend" {
+			t.Errorf("got %s before the sleep; want suspend", e)
+		}
+	default:
+		t.Error("lock released before the devices were suspended")
+	}
+
+	// and taken again after they are resumed
+	bus.prepareForSleep(false)
+	lock = bus.inhibit()
+	defer lock.Close()
+	if e := <-events; e != "resume" {
+		t.Errorf("got %s after the sleep; want resume", e)
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Listen() = %v; want context.Canceled", err)
+	}
+}
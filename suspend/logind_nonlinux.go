@@ -0,0 +1,15 @@
+//go:build !linux || tinygo
+// +build !linux tinygo
+
+package suspend
+
+import (
+	"context"
+	"errors"
+)
+
+// Listen runs the hooks at the sleeps of the system until ctx is done. It
+// relies on systemd-logind, and is only implemented on Linux.
+func (h *Hooks) Listen(ctx context.Context) error {
+	return errors.New("not implemented on this platform")
+}
@@ -0,0 +1,82 @@
+// Package suspend quiesces the devices before the system sleeps, and
+// initializes them again after it wakes up, so that the laptops and the
+// boards which suspend do not come back with dead peripherals: a module
+// whose power was cut has lost its configuration, such as the
+// initialization of an OLED, the application started on a sensor or the
+// interrupts armed on a chip.
+//
+// On Linux, Listen follows the sleeps of systemd-logind over D-Bus, and
+// delays them until the devices are quiesced:
+//
+//	var hooks suspend.Hooks
+//	hooks.Add(suspend.Hook{Name: "oled", Suspend: oled.Off, Resume: oled.Reinit})
+//	go hooks.Listen(ctx)
+package suspend
+
+import "sync"
+
+// Hook quiesces and initializes again a device, either function may be
+// nil.
+type Hook struct {
+	Name string
+
+	// Suspend is called before the system sleeps, e.g. to turn off a
+	// display or to stop a conversion.
+	Suspend func() error
+
+	// Resume is called after the system woke up, e.g. to initialize again
+	// the controller of a display.
+	Resume func() error
+}
+
+// Hooks are the hooks of the devices. It can be used by multiple
+// goroutines.
+type Hooks struct {
+	// OnError is called with the errors returned by the hooks. Errors are
+	// ignored if nil.
+	OnError func(name string, err error)
+
+	mu    sync.Mutex
+	hooks []Hook
+}
+
+// Add adds the hook h. The devices are suspended in the reverse order of
+// their hooks, and resumed in order, e.g. a bus before the devices on it.
+func (h *Hooks) Add(hook Hook) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hooks = append(h.hooks, hook)
+}
+
+// Suspend runs the Suspend functions of the hooks, the last one added
+// first. The hooks failing are reported to OnError, and do not stop the
+// others.
+func (h *Hooks) Suspend() {
+	hooks := h.list()
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h.run(hooks[i].Name, hooks[i].Suspend)
+	}
+}
+
+// Resume runs the Resume functions of the hooks, in the order they were
+// added.
+func (h *Hooks) Resume() {
+	for _, hook := range h.list() {
+		h.run(hook.Name, hook.Resume)
+	}
+}
+
+func (h *Hooks) list() []Hook {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]Hook(nil), h.hooks...)
+}
+
+func (h *Hooks) run(name string, f func() error) {
+	if f == nil {
+		return
+	}
+	if err := f(); err != nil && h.OnError != nil {
+		h.OnError(name, err)
+	}
+}
@@ -0,0 +1,89 @@
+package suspend
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	var calls, failed []string
+	hooks := Hooks{OnError: func(name string, err error) { failed = append(failed, name) }}
+	hook := func(name string, err error) func() error {
+		return func() error {
+			calls = append(calls, name)
+			return err
+		}
+	}
+	hooks.Add(Hook{Name: "bus", Suspend: hook("suspend bus", nil), Resume: hook("resume bus", nil)})
+	hooks.Add(Hook{Name: "oled", Suspend: hook("suspend oled", errors.New("nak")), Resume: hook("resume oled", nil)})
+	hooks.Add(Hook{Name: "sensor", Resume: hook("resume sensor", nil)})
+
+	hooks.Suspend()
+	hooks.Resume()
+	want := []string{"suspend oled", "suspend bus", "resume bus", "resume oled", "resume sensor"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %q; want %q", calls, want)
+	}
+	if !reflect.DeepEqual(failed, []string{"oled"}) {
+		t.Errorf("failed = %q; want the oled", failed)
+	}
+}
+
+func TestMessage(t *testing.T) {
+	m := &message{
+		typ:    methodCall,
+		serial: 7,
+		path:   "/org/freedesktop/login1",
+		iface:  "org.freedesktop.login1.Manager",
+		member: "Inhibit",
+		dest:   "org.freedesktop.login1",
+		body:   []interface{}{"sleep", "test", "why", "delay", true, byte(3), unixFD(0)},
+		nfds:   1,
+	}
+	m.sig = sigOf(m.body...)
+	if m.sig != "ssssbyh" {
+		t.Fatalf("signature %q; want ssssbyh", m.sig)
+	}
+	b, err := m.marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b)%4 != 0 {
+		t.Errorf("message of %d bytes, not padded", len(b))
+	}
+	if n, err := messageLen(b); err != nil || n != len(b) {
+		t.Fatalf("messageLen() = %d, %v; want %d", n, err, len(b))
+	}
+	if n, _ := messageLen(b[:10]); n != 0 {
+		t.Errorf("messageLen() of a partial header = %d; want 0", n)
+	}
+	got, err := unmarshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("unmarshal() = %+v; want %+v", got, m)
+	}
+	if _, err := unmarshal(b[:len(b)-3]); err == nil {
+		t.Error("unmarshal() of a truncated message succeeded")
+	}
+
+	// a reply of the bus in big endian
+	big := []byte{
+		'B', methodReturn, 0, 1,
+		0, 0, 0, 6, // body
+		0, 0, 0, 2, // serial
+		0, 0, 0, 15, // fields
+		fieldReplySerial, 1, 'u', 0, 0, 0, 0, 9,
+		fieldSignature, 1, 'g', 0, 1, 's', 0, 0,
+		0, 0, 0, 1, 'x', 0,
+	}
+	if got, err := unmarshal(big); err != nil || got.replySerial != 9 || !reflect.DeepEqual(got.body, []interface{}{"x"}) {
+		t.Errorf("unmarshal() = %+v, %v; want the reply to 9 of x", got, err)
+	}
+	if _, err := unmarshal([]byte(strings.Repeat("x", 16))); err == nil {
+		t.Error("unmarshal() succeeded with an invalid endianness")
+	}
+}
@@ -0,0 +1,201 @@
+package text
+
+import "unicode"
+
+// class is the direction of a rune.
+type class int
+
+const (
+	neutral class = iota
+	ltr
+	rtl
+	number
+)
+
+var rtlScripts = []*unicode.RangeTable{unicode.Hebrew, unicode.Arabic, unicode.Syriac, unicode.Thaana, unicode.Nko}
+
+func classify(r rune) class {
+	switch {
+	case unicode.IsDigit(r):
+		return number
+	case unicode.In(r, rtlScripts...):
+		return rtl
+	case unicode.IsLetter(r):
+		return ltr
+	}
+	return neutral
+}
+
+// strong returns the direction of a resolved class, the numbers count as
+// right-to-left.
+func strong(t class) class {
+	if t == number {
+		return rtl
+	}
+	return t
+}
+
+var mirrors = map[rune]rune{
+	'(': ')', ')': '(', '[': ']', ']': '[', '{': '}', '}': '{',
+	'<': '>', '>': '<', '«': '»', '»': '«', '‹': '›', '›': '‹',
+}
+
+// Visual returns s, in logical order, reordered in the display order of
+// the runes from left to right, with a simplified version of the Unicode
+// bidirectional algorithm: the direction of the text is the one of its
+// first letter, the runs of right-to-left letters are reversed, the
+// numbers are kept left-to-right and the brackets are mirrored. s is
+// returned as is without right-to-left letters. The Arabic letters are
+// not shaped, they are drawn in their isolated form.
+func Visual(s string) string {
+	// The combining marks stay after their rune.
+	var clusters [][]rune
+	var types []class
+	hasRTL := false
+	for _, r := range s {
+		if isMark(r) && len(clusters) > 0 {
+			clusters[len(clusters)-1] = append(clusters[len(clusters)-1], r)
+			continue
+		}
+		t := classify(r)
+		hasRTL = hasRTL || t == rtl
+		clusters = append(clusters, []rune{r})
+		types = append(types, t)
+	}
+	if !hasRTL {
+		return s
+	}
+
+	base := ltr
+	for _, t := range types {
+		if t == ltr || t == rtl {
+			base = t
+			break
+		}
+	}
+	// The numbers following left-to-right letters are left-to-right.
+	prev := base
+	for i, t := range types {
+		switch t {
+		case ltr, rtl:
+			prev = t
+		case number:
+			if prev == ltr {
+				types[i] = ltr
+			}
+		}
+	}
+	resolveBrackets(clusters, types, base)
+	// The neutrals take the direction of the text around them if it is
+	// the same on both sides, the base direction otherwise.
+	dir := func(i int) class {
+		if i < 0 || i >= len(types) {
+			return base
+		}
+		return strong(types[i])
+	}
+	for i := 0; i < len(types); {
+		if types[i] != neutral {
+			i++
+			continue
+		}
+		j := i
+		for j < len(types) && types[j] == neutral {
+			j++
+		}
+		d := base
+		if before, after := dir(i-1), dir(j); before == after {
+			d = before
+		}
+		for k := i; k < j; k++ {
+			types[k] = d
+		}
+		i = j
+	}
+
+	levels := make([]int, len(types))
+	for i, t := range types {
+		switch {
+		case t == number:
+			levels[i] = 2
+		case t == rtl:
+			levels[i] = 1
+		case base == rtl:
+			levels[i] = 2
+		}
+	}
+	for lvl := 2; lvl >= 1; lvl-- {
+		for i := 0; i < len(levels); {
+			if levels[i] < lvl {
+				i++
+				continue
+			}
+			j := i
+			for j < len(levels) && levels[j] >= lvl {
+				j++
+			}
+			for a, b := i, j-1; a < b; a, b = a+1, b-1 {
+				clusters[a], clusters[b] = clusters[b], clusters[a]
+				levels[a], levels[b] = levels[b], levels[a]
+			}
+			i = j
+		}
+	}
+
+	out := make([]rune, 0, len(s))
+	for i, c := range clusters {
+		if m, ok := mirrors[c[0]]; ok && levels[i]%2 == 1 {
+			c[0] = m
+		}
+		out = append(out, c...)
+	}
+	return string(out)
+}
+
+// resolveBrackets gives the pairs of brackets the direction of the text
+// they enclose, when it is the base direction or the direction of the
+// text before them, so that they stay around it.
+func resolveBrackets(clusters [][]rune, types []class, base class) {
+	var open []int
+	for i, c := range clusters {
+		switch c[0] {
+		case '(', '[', '{':
+			open = append(open, i)
+		case ')', ']', '}':
+			if len(open) == 0 {
+				continue
+			}
+			o := open[len(open)-1]
+			open = open[:len(open)-1]
+			if m := mirrors[c[0]]; clusters[o][0] != m {
+				continue
+			}
+			inside := neutral
+			for _, t := range types[o+1 : i] {
+				if t == neutral {
+					continue
+				}
+				if inside = strong(t); inside == base {
+					break
+				}
+			}
+			if inside == neutral {
+				continue
+			}
+			d := base
+			if inside != base {
+				before := base
+				for k := o - 1; k >= 0; k-- {
+					if types[k] != neutral {
+						before = strong(types[k])
+						break
+					}
+				}
+				if before == inside {
+					d = inside
+				}
+			}
+			types[o], types[i] = d, d
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package text
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+	"unicode"
+)
+
+// Chain is a fallback chain of fonts, each rune is drawn with the first
+// font having a glyph for it. The glyphs are aligned on the top of the
+// line, which is as high as the highest font.
+type Chain []*Font
+
+// glyph returns the font and the glyph of r, the first font and a nil
+// glyph if r is missing from all the fonts.
+func (c Chain) glyph(r rune) (*Font, []byte) {
+	for _, f := range c {
+		if g := f.Glyph(r); g != nil {
+			return f, g
+		}
+	}
+	return c[0], nil
+}
+
+// Height returns the height of the lines.
+func (c Chain) Height() int {
+	h := 0
+	for _, f := range c {
+		if f.Height > h {
+			h = f.Height
+		}
+	}
+	return h
+}
+
+// width returns the width of s, in logical order, at scale 1.
+func (c Chain) width(s string) int {
+	w := 0
+	for _, r := range s {
+		if isMark(r) {
+			continue
+		}
+		f, g := c.glyph(r)
+		w += f.advance(g)
+	}
+	return w
+}
+
+// Draw draws s on dst with its top left corner at x, y, every pixel of the
+// fonts being scale pixels wide. Runes missing from the fonts are drawn as
+// blanks. It returns the x coordinate following the text.
+func (c Chain) Draw(dst draw.Image, x, y int, s string, col color.Color, scale int) int {
+	if scale < 1 {
+		scale = 1
+	}
+	last := x // position of the previous rune, for the combining marks
+	for _, r := range Visual(s) {
+		f, g := c.glyph(r)
+		if isMark(r) {
+			if g != nil {
+				f.draw(dst, last, y, g, col, scale)
+			}
+			continue
+		}
+		if g != nil {
+			f.draw(dst, x, y, g, col, scale)
+		}
+		last = x
+		x += f.advance(g) * scale
+	}
+	return x
+}
+
+// Size returns the size of s drawn at the given scale.
+func (c Chain) Size(s string, scale int) image.Point {
+	if scale < 1 {
+		scale = 1
+	}
+	return image.Pt(c.width(s)*scale, c.Height()*scale)
+}
+
+// Fit returns the largest scale at which s fits in a w x h box, or 0 if it
+// does not fit even at scale 1.
+func (c Chain) Fit(s string, w, h int) int {
+	p := c.Size(s, 1)
+	if p.X == 0 {
+		return h / p.Y
+	}
+	x, y := w/p.X, h/p.Y
+	if x < y {
+		return x
+	}
+	return y
+}
+
+// Wrap breaks s in lines at most w pixels wide at the given scale. The
+// lines are broken at the spaces and the newlines, and between any two
+// runes in the words too long for a line, like the CJK text.
+func (c Chain) Wrap(s string, w, scale int) []string {
+	if scale < 1 {
+		scale = 1
+	}
+	w /= scale
+	var lines []string
+	for _, para := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			if line != "" && c.width(line+" "+word) <= w {
+				line += " " + word
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			for c.width(word) > w {
+				i := c.split(word, w)
+				lines = append(lines, word[:i])
+				word = word[i:]
+			}
+			line = word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// split returns the length of the longest prefix of s fitting in w, at
+// least a rune and its combining marks.
+func (c Chain) split(s string, w int) int {
+	x := 0
+	for i, r := range s {
+		if isMark(r) {
+			continue
+		}
+		f, g := c.glyph(r)
+		x += f.advance(g)
+		if x > w && i > 0 {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// isMark reports whether r is a combining mark drawn over the previous
+// rune.
+func isMark(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me)
+}
@@ -0,0 +1,69 @@
+// Package face draws text with the fonts of golang.org/x/image/font, so
+// TrueType and OpenType fonts at any size can be drawn on the displays
+// like the bitmap fonts of the text package. It is kept apart from the
+// text package so that only the programs using it depend on x/image.
+package face
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// Font draws text with a font.Face, e.g. a TrueType font parsed and sized
+// with golang.org/x/image/font/opentype.
+type Font struct {
+	Face font.Face
+
+	// Mono draws the pixels covered by at least half of a glyph in the
+	// color of the text and leaves the others, instead of blending the
+	// antialiased edges of the glyphs. The 1-bit displays, which light any
+	// pixel that is not black, need it.
+	Mono bool
+}
+
+// Height returns the height of the lines.
+func (f Font) Height() int {
+	return f.Face.Metrics().Height.Ceil()
+}
+
+// Draw draws s on dst with the top left corner of its line at x, y, the
+// baseline being Ascent pixels below y, and the pairs of runes kerned.
+// It returns the x coordinate following the text.
+func (f Font) Draw(dst draw.Image, x, y int, s string, c color.Color) int {
+	dot := fixed.P(x, y+f.Face.Metrics().Ascent.Ceil())
+	src := image.NewUniform(c)
+	prev := rune(-1)
+	for _, r := range s {
+		if prev >= 0 {
+			dot.X += f.Face.Kern(prev, r)
+		}
+		prev = r
+		dr, mask, maskp, advance, _ := f.Face.Glyph(dot, r)
+		dot.X += advance
+		if dr.Empty() {
+			continue
+		}
+		if !f.Mono {
+			draw.DrawMask(dst, dr, src, image.Point{}, mask, maskp, draw.Over)
+			continue
+		}
+		for py := dr.Min.Y; py < dr.Max.Y; py++ {
+			for px := dr.Min.X; px < dr.Max.X; px++ {
+				_, _, _, a := mask.At(maskp.X+px-dr.Min.X, maskp.Y+py-dr.Min.Y).RGBA()
+				if a >= 0x8000 {
+					dst.Set(px, py, c)
+				}
+			}
+		}
+	}
+	return dot.X.Ceil()
+}
+
+// Size returns the size of the line s.
+func (f Font) Size(s string) image.Point {
+	return image.Pt(font.MeasureString(f.Face, s).Ceil(), f.Height())
+}
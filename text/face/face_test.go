@@ -0,0 +1,87 @@
+package face
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// kerned moves the V of AV 3 pixels to the left.
+type kerned struct{ font.Face }
+
+func (kerned) Kern(r0, r1 rune) fixed.Int26_6 {
+	if r0 == 'A' && r1 == 'V' {
+		return fixed.I(-3)
+	}
+	return 0
+}
+
+// blurry draws every glyph as a pixel covered at a quarter and one covered
+// at three quarters, at the baseline.
+type blurry struct{ font.Face }
+
+func (blurry) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	mask := &image.Alpha{Pix: []uint8{0x40, 0xC0}, Stride: 2, Rect: image.Rect(0, 0, 2, 1)}
+	p := image.Pt(dot.X.Floor(), dot.Y.Floor())
+	return image.Rectangle{p, p.Add(image.Pt(2, 1))}, mask, image.Point{}, fixed.I(2), true
+}
+
+func lit(img *image.Gray) image.Rectangle {
+	var r image.Rectangle
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if img.GrayAt(x, y).Y != 0 {
+				r = r.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return r
+}
+
+func TestDraw(t *testing.T) {
+	f := Font{Face: basicfont.Face7x13}
+	if h := f.Height(); h != 13 {
+		t.Errorf("Height = %d; want 13", h)
+	}
+	img := image.NewGray(image.Rect(0, 0, 64, 32))
+	if x := f.Draw(img, 2, 10, "Hi", color.White); x != 16 {
+		t.Errorf("Draw returned x = %d; want 16", x)
+	}
+	r := lit(img)
+	if r.Empty() || r.Min.Y < 10 || r.Max.Y > 23 || r.Min.X < 2 {
+		t.Errorf("text drawn in %v; want within the line (2,10)-(16,23)", r)
+	}
+	if got := f.Size("Hi"); got != image.Pt(14, 13) {
+		t.Errorf("Size = %v; want (14,13)", got)
+	}
+}
+
+func TestKern(t *testing.T) {
+	f := Font{Face: kerned{basicfont.Face7x13}}
+	img := image.NewGray(image.Rect(0, 0, 64, 16))
+	if x := f.Draw(img, 0, 0, "AVA", color.White); x != 18 {
+		t.Errorf("Draw returned x = %d; want 18", x)
+	}
+	if got := f.Size("AVA").X; got != 18 {
+		t.Errorf("Size = %d; want 18", got)
+	}
+}
+
+func TestMono(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 8, 16))
+	Font{Face: blurry{basicfont.Face7x13}}.Draw(img, 0, 0, "a", color.White)
+	if a, b := img.GrayAt(0, 11).Y, img.GrayAt(1, 11).Y; a != 0x40 || b != 0xC0 {
+		t.Errorf("blended pixels %#x and %#x; want 0x40 and 0xc0", a, b)
+	}
+
+	img = image.NewGray(image.Rect(0, 0, 8, 16))
+	Font{Face: blurry{basicfont.Face7x13}, Mono: true}.Draw(img, 0, 0, "a", color.White)
+	if a, b := img.GrayAt(0, 11).Y, img.GrayAt(1, 11).Y; a != 0 || b != 0xFF {
+		t.Errorf("mono pixels %#x and %#x; want 0 and 0xff", a, b)
+	}
+}
@@ -0,0 +1,62 @@
+package text
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ParseHex parses a font in the .hex format of GNU Unifont, which covers
+// the Basic Multilingual Plane with 8x16 glyphs and 16x16 glyphs for the
+// wide scripts, such as CJK. Each line is the code point of a glyph and
+// its rows from top to bottom, in hexadecimal, e.g.
+//
+//	0041:0000000018242442427E424242420000
+//
+// The whole font is large, a subset of the runes needed is usually good
+// enough for a device.
+func ParseHex(r io.Reader) (*Font, error) {
+	f := &Font{Width: 8, Height: 16, Sparse: make(map[rune][]byte)}
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+		i := strings.IndexByte(line, ':')
+		if i < 0 {
+			return nil, fmt.Errorf("line %d: missing code point", n)
+		}
+		cp, err := strconv.ParseUint(line[:i], 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid code point - %v", n, err)
+		}
+		rows, err := hex.DecodeString(line[i+1:])
+		if err != nil || (len(rows) != 16 && len(rows) != 32) {
+			return nil, fmt.Errorf("line %d: invalid glyph of %d bytes", n, len(rows))
+		}
+		f.Sparse[rune(cp)] = columns(rows, len(rows)/16*8)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// columns converts the 16 rows of a w pixels wide glyph, the leftmost
+// pixel in the most significant bit, to columns of 2 bytes.
+func columns(rows []byte, w int) []byte {
+	bpr := w / 8 // bytes per row
+	g := make([]byte, 2*w)
+	for x := 0; x < w; x++ {
+		for y := 0; y < 16; y++ {
+			if rows[y*bpr+x/8]&(0x80>>uint(x%8)) != 0 {
+				g[2*x+y/8] |= 1 << uint(y%8)
+			}
+		}
+	}
+	return g
+}
@@ -0,0 +1,95 @@
+// Package text draws text with bitmap fonts on images, such as the frame
+// buffers of the displays.
+//
+// The text is laid out as UTF-8: the combining marks are drawn over the
+// rune they follow, the wide glyphs of the CJK fonts take their width and
+// the right-to-left scripts, such as Hebrew and Arabic, are reordered for
+// display. A Chain draws each rune with the first font having a glyph
+// for it, e.g. the default font and a Unifont subset for the rest.
+package text
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/goiot/devices/oled96x96"
+)
+
+// Font is a bitmap font. Each glyph is a slice of columns, the least
+// significant bit of a column being its top pixel. The columns of the
+// fonts higher than 8 pixels take (Height+7)/8 bytes, the top 8 pixels
+// first. The glyphs wider than Width are drawn with their own width.
+type Font struct {
+	Width, Height int      // size of a glyph cell, the spacing included
+	Glyphs        [][]byte // glyphs indexed by rune
+	Sparse        map[rune][]byte
+}
+
+// Default is the 8x8 ASCII font of the OLED 96x96 driver.
+var Default = fromOLED(oled96x96.DefaultFont())
+
+func fromOLED(f oled96x96.Font) *Font {
+	font := &Font{Width: 8, Height: 8, Glyphs: make([][]byte, len(f))}
+	for i := range f {
+		font.Glyphs[i] = f[i][:]
+	}
+	return font
+}
+
+// Glyph returns the glyph of r, nil if it is missing from the font.
+func (f *Font) Glyph(r rune) []byte {
+	if r >= 0 && int(r) < len(f.Glyphs) && f.Glyphs[r] != nil {
+		return f.Glyphs[r]
+	}
+	return f.Sparse[r]
+}
+
+// stride is the number of bytes of a column.
+func (f *Font) stride() int { return (f.Height + 7) / 8 }
+
+// advance returns the width of the glyph g.
+func (f *Font) advance(g []byte) int {
+	if n := len(g) / f.stride(); n > f.Width {
+		return n
+	}
+	return f.Width
+}
+
+func (f *Font) draw(dst draw.Image, x, y int, g []byte, c color.Color, scale int) {
+	stride := f.stride()
+	src := image.NewUniform(c)
+	for i := 0; i+stride <= len(g); i += stride {
+		for j := 0; j < f.Height; j++ {
+			if g[i+j/8]>>uint(j%8)&1 == 0 {
+				continue
+			}
+			col := i / stride
+			px := image.Rect(x+col*scale, y+j*scale, x+(col+1)*scale, y+(j+1)*scale)
+			draw.Draw(dst, px, src, image.Point{}, draw.Src)
+		}
+	}
+}
+
+// Draw draws s on dst with its top left corner at x, y, every pixel of the
+// font being scale pixels wide. Runes missing from the font are drawn as
+// blanks. It returns the x coordinate following the text.
+func (f *Font) Draw(dst draw.Image, x, y int, s string, c color.Color, scale int) int {
+	return Chain{f}.Draw(dst, x, y, s, c, scale)
+}
+
+// Size returns the size of s drawn at the given scale.
+func (f *Font) Size(s string, scale int) image.Point {
+	return Chain{f}.Size(s, scale)
+}
+
+// Fit returns the largest scale at which s fits in a w x h box, or 0 if it
+// does not fit even at scale 1.
+func (f *Font) Fit(s string, w, h int) int {
+	return Chain{f}.Fit(s, w, h)
+}
+
+// Wrap breaks s in lines at most w pixels wide at the given scale.
+func (f *Font) Wrap(s string, w, scale int) []string {
+	return Chain{f}.Wrap(s, w, scale)
+}
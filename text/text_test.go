@@ -0,0 +1,182 @@
+package text
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func lit(img *image.Gray) int {
+	n := 0
+	for _, v := range img.Pix {
+		if v != 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func TestDraw(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 64, 16))
+	if x := Default.Draw(img, 0, 0, "Hi", color.White, 1); x != 16 {
+		t.Errorf("Draw returned x = %d; want 16", x)
+	}
+	n := lit(img)
+	if n == 0 {
+		t.Fatal("no pixel drawn")
+	}
+	for x := 0; x < 64; x++ {
+		for y := 8; y < 16; y++ {
+			if img.GrayAt(x, y).Y != 0 {
+				t.Fatalf("pixel %d,%d drawn below the text", x, y)
+			}
+		}
+	}
+
+	img = image.NewGray(image.Rect(0, 0, 64, 16))
+	Default.Draw(img, 0, 0, "Hi", color.White, 2)
+	if got := lit(img); got != 4*n {
+		t.Errorf("scale 2 lit %d pixels; want %d", got, 4*n)
+	}
+
+	img = image.NewGray(image.Rect(0, 0, 64, 16))
+	Default.Draw(img, 0, 0, "é☃", color.White, 1)
+	if got := lit(img); got != 0 {
+		t.Errorf("runes missing from the font lit %d pixels", got)
+	}
+}
+
+func TestFit(t *testing.T) {
+	tests := []struct {
+		s    string
+		w, h int
+		want int
+	}{
+		{"12:34", 128, 64, 3},
+		{"12:34", 128, 32, 3},
+		{"12:34", 128, 16, 2},
+		{"12:34", 30, 64, 0},
+	}
+	for _, tt := range tests {
+		if got := Default.Fit(tt.s, tt.w, tt.h); got != tt.want {
+			t.Errorf("Fit(%q, %d, %d) = %d; want %d", tt.s, tt.w, tt.h, got, tt.want)
+		}
+	}
+	if got := Default.Size("abc", 2); got != image.Pt(48, 16) {
+		t.Errorf("Size = %v; want (48,16)", got)
+	}
+	if got := Small.Size("abc", 1); got != image.Pt(18, 8) {
+		t.Errorf("Small.Size = %v; want (18,8)", got)
+	}
+}
+
+func TestSmall(t *testing.T) {
+	for r := ' '; r <= '~'; r++ {
+		g := Small.Glyph(r)
+		if len(g) != 5 {
+			t.Fatalf("glyph of %q has %d columns; want 5", r, len(g))
+		}
+		for _, c := range g {
+			if c&0x80 != 0 {
+				t.Errorf("glyph of %q lights the spacing row", r)
+			}
+		}
+	}
+	img := image.NewGray(image.Rect(0, 0, 6, 8))
+	Small.Draw(img, 0, 0, "|", color.White, 1)
+	if got := lit(img); got != 7 {
+		t.Errorf("| lit %d pixels; want 7", got)
+	}
+}
+
+const unifont = `0041:0000000018242442427E424242420000
+4E00:0000000000000000000000000000FFFE00000000000000000000000000000000
+`
+
+func TestParseHex(t *testing.T) {
+	f, err := ParseHex(strings.NewReader(unifont))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Glyph('A') == nil || len(f.Glyph('一')) != 32 {
+		t.Fatalf("glyphs of A and 一 = %v, %v", f.Glyph('A'), f.Glyph('一'))
+	}
+	img := image.NewGray(image.Rect(0, 0, 32, 16))
+	if x := f.Draw(img, 0, 0, "一A", color.White, 1); x != 24 {
+		t.Errorf("Draw returned x = %d; want 24", x)
+	}
+	for x := 0; x < 16; x++ {
+		if want := x < 15; (img.GrayAt(x, 7).Y != 0) != want {
+			t.Errorf("pixel %d,7 of 一 lit = %v; want %v", x, !want, want)
+		}
+	}
+	if img.GrayAt(16+3, 4).Y == 0 || img.GrayAt(16+3, 3).Y != 0 {
+		t.Error("top of A not drawn at row 4")
+	}
+
+	for _, hex := range []string{"0041", "zz:00", "0041:00", "0041:0g000000000000000000000000000000"} {
+		if _, err := ParseHex(strings.NewReader(hex)); err == nil {
+			t.Errorf("ParseHex(%q) succeeded", hex)
+		}
+	}
+}
+
+func TestChain(t *testing.T) {
+	cjk, err := ParseHex(strings.NewReader(unifont))
+	if err != nil {
+		t.Fatal(err)
+	}
+	accent := &Font{Width: 8, Height: 8, Sparse: map[rune][]byte{0x301: {0, 0, 0, 1, 0, 0, 0, 0}}}
+	c := Chain{Default, accent, cjk}
+	if got := c.Size("a一b", 1); got != image.Pt(32, 16) {
+		t.Errorf("Size = %v; want (32,16)", got)
+	}
+	if got := c.Size("e\u0301", 1); got.X != 8 {
+		t.Errorf("combining mark is %d pixels wide", got.X-8)
+	}
+	img := image.NewGray(image.Rect(0, 0, 16, 8))
+	c.Draw(img, 0, 0, "\u0301", color.White, 1)
+	if lit(img) != 1 || img.GrayAt(3, 0).Y == 0 {
+		t.Errorf("mark not drawn at 3,0")
+	}
+}
+
+func TestVisual(t *testing.T) {
+	tests := []struct{ logical, visual string }{
+		{"hello", "hello"},
+		{"abc אבג def", "abc גבא def"},
+		{"שלום 123!", "!123 םולש"},
+		{"temp (חם) 28", "temp (םח) 28"},
+		{"מצב (ok)", "(ok) בצמ"},
+		{"ש\u05b8ל", "לש\u05b8"},
+		{"سلام", "مالس"},
+	}
+	for _, tt := range tests {
+		if got := Visual(tt.logical); got != tt.visual {
+			t.Errorf("Visual(%q) = %q; want %q", tt.logical, got, tt.visual)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		s    string
+		w    int
+		want []string
+	}{
+		{"the quick brown fox", 80, []string{"the quick", "brown fox"}},
+		{"a\nb c", 80, []string{"a", "b c"}},
+		{"abcdefghij", 32, []string{"abcd", "efgh", "ij"}},
+		{"", 32, []string{""}},
+	}
+	for _, tt := range tests {
+		if got := Default.Wrap(tt.s, tt.w, 1); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Wrap(%q, %d) = %q; want %q", tt.s, tt.w, got, tt.want)
+		}
+	}
+	if got := Default.Wrap("ab cd", 32, 2); !reflect.DeepEqual(got, []string{"ab", "cd"}) {
+		t.Errorf("Wrap at scale 2 = %q", got)
+	}
+}
@@ -0,0 +1,19 @@
+package tfluna
+
+import "github.com/goiot/devices/caps"
+
+// Caps are the capabilities of the lidar, the range is 8m indoors and 3m
+// in sunlight.
+var Caps = caps.Capabilities{
+	Name:      "TF-Luna",
+	Bus:       "i2c",
+	Addresses: []int{Addr},
+	Measurements: []caps.Measurement{
+		{Kind: caps.Distance, Unit: "m", Min: 0.2, Max: 8, Resolution: 0.01},
+	},
+}
+
+func init() { caps.Register(Caps) }
+
+// Capabilities implements caps.Describer.
+func (l *TFLuna) Capabilities() caps.Capabilities { return Caps }
@@ -0,0 +1,83 @@
+// Package tfluna implements a driver for the Benewake TF-Luna time of
+// flight lidar in its I2C mode, selected by tying its pin 5 to ground
+// before power up.
+package tfluna
+
+import (
+	"fmt"
+
+	"github.com/goiot/devices/distance"
+	"golang.org/x/exp/io/i2c"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+// Addr is the default I2C address of the lidar.
+const Addr = 0x10
+
+const (
+	regDistance = 0x00 // distance, amplitude and temperature, little endian
+
+	// minAmplitude is the signal amplitude below which the distance is
+	// unreliable, overExposed the one of a saturated receiver.
+	minAmplitude = 100
+	overExposed  = 0xFFFF
+)
+
+// Reading is a measurement of the lidar.
+type Reading struct {
+	Distance    float64 // meters
+	Amplitude   int     // strength of the reflected signal
+	Temperature float64 // of the chip, degrees Celsius
+}
+
+// TFLuna represents a TF-Luna lidar, measuring continuously at 100Hz.
+type TFLuna struct {
+	Device *i2c.Device
+}
+
+// Open opens the lidar at addr.
+func Open(o driver.Opener, addr int) (*TFLuna, error) {
+	dev, err := i2c.Open(o, addr)
+	if err != nil {
+		return nil, err
+	}
+	l := &TFLuna{Device: dev}
+	if _, err := l.Read(); err != nil {
+		dev.Close()
+		return nil, fmt.Errorf("reading the lidar failed - %v", err)
+	}
+	return l, nil
+}
+
+// Read returns the last measurement of the lidar, whose distance is
+// unreliable if its amplitude is below 100 or saturated at 65535.
+func (l *TFLuna) Read() (Reading, error) {
+	b := make([]byte, 6)
+	if err := l.Device.ReadReg(regDistance, b); err != nil {
+		return Reading{}, err
+	}
+	return Reading{
+		Distance:    float64(int(b[1])<<8|int(b[0])) / 100,
+		Amplitude:   int(b[3])<<8 | int(b[2]),
+		Temperature: float64(int16(uint16(b[5])<<8|uint16(b[4]))) / 100,
+	}, nil
+}
+
+// Distance implements distance.Sensor, with a resolution of 1cm. It
+// returns distance.ErrOutOfRange if the reflected signal is too weak or
+// saturated for the distance to be reliable.
+func (l *TFLuna) Distance() (float64, error) {
+	r, err := l.Read()
+	if err != nil {
+		return 0, err
+	}
+	if r.Amplitude < minAmplitude || r.Amplitude == overExposed {
+		return 0, distance.ErrOutOfRange
+	}
+	return r.Distance, nil
+}
+
+// Close closes the device.
+func (l *TFLuna) Close() error {
+	return l.Device.Close()
+}
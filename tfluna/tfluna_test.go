@@ -0,0 +1,35 @@
+package tfluna
+
+import (
+	"testing"
+
+	"github.com/goiot/devices/distance"
+	"github.com/goiot/devices/i2csim"
+)
+
+var _ distance.Sensor = (*TFLuna)(nil)
+
+func TestDistance(t *testing.T) {
+	regs := i2csim.NewRegisters()
+	// 1.23m, amplitude 1000, 41.5°C
+	regs.Set(regDistance, 0x7B, 0x00, 0xE8, 0x03, 0x36, 0x10)
+	bus := i2csim.NewBus()
+	bus.Attach(Addr, regs)
+	l, err := Open(bus, Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := l.Read()
+	if err != nil || r != (Reading{Distance: 1.23, Amplitude: 1000, Temperature: 41.5}) {
+		t.Errorf("Read = %+v, %v", r, err)
+	}
+	if d, err := l.Distance(); err != nil || d != 1.23 {
+		t.Errorf("Distance = %v, %v; want 1.23", d, err)
+	}
+	for _, amp := range [][]byte{{0x20, 0x00}, {0xFF, 0xFF}} {
+		regs.Set(regDistance+2, amp...)
+		if _, err := l.Distance(); err != distance.ErrOutOfRange {
+			t.Errorf("Distance with amplitude %x = %v; want ErrOutOfRange", amp, err)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package thermalprinter
+
+import "github.com/goiot/devices/caps"
+
+// Caps are the capabilities of the printers, the width of the print head
+// varies and the length of the paper is not limited.
+var Caps = caps.Capabilities{
+	Name: "ESC/POS",
+	Bus:  "uart",
+	Outputs: []caps.Output{
+		{Kind: caps.Pixels, Color: "monochrome"},
+		{Kind: caps.Text},
+	},
+}
+
+func init() { caps.Register(Caps) }
+
+// Capabilities implements caps.Describer.
+func (p *Printer) Capabilities() caps.Capabilities {
+	c := Caps
+	c.Outputs = []caps.Output{
+		{Kind: caps.Pixels, Width: p.width, Color: "monochrome"},
+		{Kind: caps.Text, Width: p.width / 12}, // 12x24 dots font
+	}
+	return c
+}
@@ -0,0 +1,50 @@
+package thermalprinter_test
+
+import (
+	"fmt"
+	"image"
+	_ "image/png"
+	"os"
+
+	"github.com/goiot/devices/thermalprinter"
+)
+
+// Example prints a receipt with a logo, on a printer configured with stty.
+func Example() {
+	port, err := os.OpenFile("/dev/serial0", os.O_RDWR, 0)
+	if err != nil {
+		panic(err)
+	}
+	p, err := thermalprinter.Open(port, thermalprinter.Width58mm)
+	if err != nil {
+		panic(err)
+	}
+	defer p.Close()
+
+	if paper, err := p.Paper(); err != nil || paper != thermalprinter.PaperOK {
+		fmt.Println("paper:", paper, err)
+	}
+
+	f, err := os.Open("logo.png")
+	if err != nil {
+		panic(err)
+	}
+	logo, _, err := image.Decode(f)
+	f.Close()
+	if err != nil {
+		panic(err)
+	}
+	p.SetAlign(thermalprinter.Center)
+	p.Image(logo)
+	p.SetStyle(thermalprinter.Bold | thermalprinter.DoubleHeight)
+	p.Print("Coffee corner\n")
+	p.SetStyle(0)
+	p.SetAlign(thermalprinter.Left)
+	p.Print("1 espresso      1.80\n1 croissant     1.20\n")
+	p.SetStyle(thermalprinter.Bold)
+	p.Print("TOTAL           3.00\n")
+	p.SetStyle(0)
+	p.SetAlign(thermalprinter.Center)
+	p.QRCode("https://example.com/receipts/1234", 6)
+	p.Feed(3)
+}
@@ -0,0 +1,286 @@
+// Package thermalprinter implements a driver for the receipt thermal
+// printers speaking ESC/POS on their serial input, such as the Adafruit
+// mini thermal printers and most 58mm and 80mm receipt printers. It prints
+// text with styles, barcodes, QR codes and images.
+//
+// The serial port must be configured by the caller at the baud rate of
+// the printer, printed on its self test page (hold the feed button on
+// power up), e.g. with
+//
+//	stty -F /dev/ttyAMA0 19200 cs8 -parenb -cstopb raw
+package thermalprinter
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"time"
+)
+
+// Widths in dots of the print heads, at 8 dots per mm.
+const (
+	Width58mm = 384
+	Width80mm = 576
+)
+
+const (
+	esc = 0x1B
+	gs  = 0x1D
+	dle = 0x10
+
+	// bandHeight is the height in dots of the bands images are sent in,
+	// the printers with small receive buffers drop the data of larger
+	// ones.
+	bandHeight = 24
+
+	statusTimeout = time.Second
+)
+
+// Style is a combination of text styles.
+type Style byte
+
+// Styles, as the bits of the ESC ! command.
+const (
+	Bold         Style = 0x08
+	DoubleHeight Style = 0x10
+	DoubleWidth  Style = 0x20
+	Underline    Style = 0x80
+)
+
+// Align is the alignment of the lines.
+type Align byte
+
+const (
+	Left Align = iota
+	Center
+	Right
+)
+
+// Barcode is a barcode symbology.
+type Barcode byte
+
+// Symbologies, as the m codes of the GS k command taking a length.
+const (
+	UPCA    Barcode = 65
+	UPCE    Barcode = 66
+	EAN13   Barcode = 67
+	EAN8    Barcode = 68
+	Code39  Barcode = 69
+	ITF     Barcode = 70
+	Codabar Barcode = 71
+	Code93  Barcode = 72
+	Code128 Barcode = 73
+)
+
+// Paper is the state of the paper roll.
+type Paper int
+
+const (
+	PaperOK Paper = iota
+	PaperLow
+	PaperOut
+)
+
+func (p Paper) String() string {
+	switch p {
+	case PaperLow:
+		return "low"
+	case PaperOut:
+		return "out"
+	default:
+		return "ok"
+	}
+}
+
+// deadliner is implemented by the serial ports supporting read timeouts,
+// such as *os.File.
+type deadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// Printer represents a printer.
+type Printer struct {
+	port  io.ReadWriter
+	width int
+}
+
+// Open initializes the printer on port, whose print head is width dots
+// wide, e.g. Width58mm.
+func Open(port io.ReadWriter, width int) (*Printer, error) {
+	if width <= 0 {
+		return nil, fmt.Errorf("invalid width %d", width)
+	}
+	p := &Printer{port: port, width: width}
+	if err := p.write(esc, '@'); err != nil {
+		return nil, fmt.Errorf("initializing the printer failed - %v", err)
+	}
+	return p, nil
+}
+
+// Width returns the width of the print head in dots.
+func (p *Printer) Width() int { return p.width }
+
+func (p *Printer) write(b ...byte) error {
+	_, err := p.port.Write(b)
+	return err
+}
+
+// Print prints s with the current style and alignment. The lines are
+// printed once ended by '\n', or once wider than the paper.
+func (p *Printer) Print(s string) error {
+	_, err := io.WriteString(p.port, s)
+	return err
+}
+
+// SetStyle sets the style of the text printed next, 0 for plain text.
+func (p *Printer) SetStyle(s Style) error {
+	return p.write(esc, '!', byte(s))
+}
+
+// SetInverse prints the text printed next white on black.
+func (p *Printer) SetInverse(on bool) error {
+	var v byte
+	if on {
+		v = 1
+	}
+	return p.write(gs, 'B', v)
+}
+
+// SetAlign sets the alignment of the lines printed next.
+func (p *Printer) SetAlign(a Align) error {
+	if a > Right {
+		return fmt.Errorf("invalid alignment %d", a)
+	}
+	return p.write(esc, 'a', byte(a))
+}
+
+// Feed feeds n lines of paper.
+func (p *Printer) Feed(n int) error {
+	if n < 0 || n > 255 {
+		return fmt.Errorf("cannot feed %d lines", n)
+	}
+	return p.write(esc, 'd', byte(n))
+}
+
+// Cut feeds the paper to the cutter and cuts it, leaving a point
+// uncut. The printers without cutter only feed the paper.
+func (p *Printer) Cut() error {
+	return p.write(gs, 'V', 66, 0)
+}
+
+// Barcode prints data as a barcode of the symbology b, height dots high
+// with its text below it. The printers check data, the digits and the
+// characters allowed depend on the symbology.
+func (p *Printer) Barcode(b Barcode, data string, height int) error {
+	if b < UPCA || b > Code128 {
+		return fmt.Errorf("invalid symbology %d", b)
+	}
+	if len(data) == 0 || len(data) > 255 {
+		return fmt.Errorf("barcode data of %d bytes, must be 1 to 255", len(data))
+	}
+	if height < 1 || height > 255 {
+		return fmt.Errorf("invalid barcode height %d", height)
+	}
+	cmd := []byte{
+		gs, 'h', byte(height),
+		gs, 'H', 2, // text below
+		gs, 'k', byte(b), byte(len(data)),
+	}
+	return p.write(append(cmd, data...)...)
+}
+
+// QRCode prints data as a QR code of modules size x size dots (1-16),
+// with the error correction level M.
+func (p *Printer) QRCode(data string, size int) error {
+	if size < 1 || size > 16 {
+		return fmt.Errorf("invalid module size %d", size)
+	}
+	if len(data) == 0 || len(data) > 7089 {
+		return fmt.Errorf("QR code data of %d bytes, must be 1 to 7089", len(data))
+	}
+	n := len(data) + 3
+	cmd := []byte{
+		gs, '(', 'k', 4, 0, '1', 'A', '2', 0, // model 2
+		gs, '(', 'k', 3, 0, '1', 'C', byte(size),
+		gs, '(', 'k', 3, 0, '1', 'E', '1', // level M
+		gs, '(', 'k', byte(n), byte(n >> 8), '1', 'P', '0',
+	}
+	cmd = append(cmd, data...)
+	cmd = append(cmd, gs, '(', 'k', 3, 0, '1', 'Q', '0')
+	return p.write(cmd...)
+}
+
+// Image prints img, dithered to black and white with Floyd-Steinberg
+// error diffusion. The images wider than the print head are cropped.
+func (p *Printer) Image(img image.Image) error {
+	b := img.Bounds()
+	if b.Dx() > p.width {
+		b.Max.X = b.Min.X + p.width
+	}
+	if b.Empty() {
+		return nil
+	}
+	bw := image.NewPaletted(image.Rect(0, 0, b.Dx(), b.Dy()), color.Palette{color.White, color.Black})
+	draw.FloydSteinberg.Draw(bw, bw.Bounds(), img, b.Min)
+
+	stride := (b.Dx() + 7) / 8
+	for y := 0; y < b.Dy(); y += bandHeight {
+		h := bandHeight
+		if y+h > b.Dy() {
+			h = b.Dy() - y
+		}
+		cmd := make([]byte, 8, 8+stride*h)
+		copy(cmd, []byte{gs, 'v', '0', 0, byte(stride), byte(stride >> 8), byte(h), byte(h >> 8)})
+		for j := y; j < y+h; j++ {
+			row := make([]byte, stride)
+			for x := 0; x < b.Dx(); x++ {
+				if bw.ColorIndexAt(x, j) == 1 {
+					row[x/8] |= 0x80 >> uint(x%8)
+				}
+			}
+			cmd = append(cmd, row...)
+		}
+		if err := p.write(cmd...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Paper queries the paper roll sensors. The ports supporting read
+// deadlines, such as *os.File, time out after a second if the printer
+// does not answer.
+func (p *Printer) Paper() (Paper, error) {
+	if err := p.write(dle, 4, 4); err != nil {
+		return PaperOK, err
+	}
+	if d, ok := p.port.(deadliner); ok {
+		d.SetReadDeadline(time.Now().Add(statusTimeout))
+		defer d.SetReadDeadline(time.Time{})
+	}
+	b := make([]byte, 1)
+	if _, err := io.ReadFull(p.port, b); err != nil {
+		return PaperOK, fmt.Errorf("reading the paper status failed - %v", err)
+	}
+	if b[0]&0x93 != 0x12 {
+		return PaperOK, errors.New("invalid paper status")
+	}
+	switch {
+	case b[0]&0x60 != 0:
+		return PaperOut, nil
+	case b[0]&0x0C != 0:
+		return PaperLow, nil
+	}
+	return PaperOK, nil
+}
+
+// Close closes the port if it is an io.Closer.
+func (p *Printer) Close() error {
+	if c, ok := p.port.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
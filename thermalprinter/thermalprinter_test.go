@@ -0,0 +1,143 @@
+package thermalprinter
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/goiot/devices/caps"
+)
+
+// port is a fake serial port, answering the status queries with status.
+type port struct {
+	bytes.Buffer
+	status []byte
+}
+
+func (p *port) Read(b []byte) (int, error) {
+	n := copy(b, p.status)
+	p.status = p.status[n:]
+	return n, nil
+}
+
+func open(t *testing.T, p *port) *Printer {
+	pr, err := Open(p, Width58mm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Next(2); !bytes.Equal(got, []byte{esc, '@'}) {
+		t.Fatalf("initialized with %x; want 1b40", got)
+	}
+	return pr
+}
+
+func TestText(t *testing.T) {
+	p := &port{}
+	pr := open(t, p)
+	pr.SetAlign(Center)
+	pr.SetStyle(Bold | DoubleHeight)
+	pr.Print("TOTAL\n")
+	pr.SetInverse(true)
+	pr.Feed(3)
+	pr.Cut()
+	want := "\x1ba\x01\x1b!\x18TOTAL\n\x1dB\x01\x1bd\x03\x1dVB\x00"
+	if got := p.String(); got != want {
+		t.Errorf("sent %q; want %q", got, want)
+	}
+	if err := pr.SetAlign(3); err == nil {
+		t.Error("expected an error with alignment 3")
+	}
+}
+
+func TestCodes(t *testing.T) {
+	p := &port{}
+	pr := open(t, p)
+	if err := pr.Barcode(EAN13, "4006381333931", 80); err != nil {
+		t.Fatal(err)
+	}
+	want := "\x1dhP\x1dH\x02\x1dkC\x0d4006381333931"
+	if got := p.Next(p.Len()); string(got) != want {
+		t.Errorf("Barcode sent %q; want %q", got, want)
+	}
+	if err := pr.Barcode(Code128, "", 80); err == nil {
+		t.Error("expected an error with no data")
+	}
+
+	if err := pr.QRCode("https://golang.org", 4); err != nil {
+		t.Fatal(err)
+	}
+	want = "\x1d(k\x04\x001A2\x00\x1d(k\x03\x001C\x04\x1d(k\x03\x001E1" +
+		"\x1d(k\x15\x001P0https://golang.org\x1d(k\x03\x001Q0"
+	if got := p.String(); got != want {
+		t.Errorf("QRCode sent %q; want %q", got, want)
+	}
+	if err := pr.QRCode("x", 17); err == nil {
+		t.Error("expected an error with modules of 17 dots")
+	}
+}
+
+func TestImage(t *testing.T) {
+	p := &port{}
+	pr := open(t, p)
+	img := image.NewGray(image.Rect(0, 0, 400, 30))
+	for x := 0; x < 400; x++ {
+		img.SetGray(x, 0, color.Gray{Y: 0xFF})
+	}
+	img.SetGray(9, 25, color.Gray{Y: 0xFF})
+	if err := pr.Image(img); err != nil {
+		t.Fatal(err)
+	}
+	// cropped to 384 dots, 48 bytes per row, in bands of 24 and 6 rows
+	b := p.Bytes()
+	if !bytes.Equal(b[:8], []byte{gs, 'v', '0', 0, 48, 0, 24, 0}) {
+		t.Fatalf("first band header %x", b[:8])
+	}
+	if b[8] != 0 || b[8+48] != 0xFF {
+		t.Errorf("first rows %#x and %#x; want white then black", b[8], b[8+48])
+	}
+	b = b[8+48*24:]
+	if !bytes.Equal(b[:8], []byte{gs, 'v', '0', 0, 48, 0, 6, 0}) {
+		t.Fatalf("second band header %x", b[:8])
+	}
+	if len(b) != 8+48*6 {
+		t.Errorf("second band of %d bytes; want %d", len(b), 8+48*6)
+	}
+	if row := b[8+48:]; row[1] != 0xBF {
+		t.Errorf("row 25 starts %#x; want a white dot at x=9", row[:2])
+	}
+}
+
+func TestPaper(t *testing.T) {
+	tests := []struct {
+		status byte
+		want   Paper
+	}{
+		{0x12, PaperOK},
+		{0x1E, PaperLow},
+		{0x72, PaperOut},
+		{0x7E, PaperOut},
+	}
+	for _, tt := range tests {
+		p := &port{}
+		pr := open(t, p)
+		p.status = []byte{tt.status}
+		if got, err := pr.Paper(); err != nil || got != tt.want {
+			t.Errorf("status %#x, Paper = %v, %v; want %v", tt.status, got, err, tt.want)
+		}
+		if q := p.String(); q != "\x10\x04\x04" {
+			t.Errorf("queried with %q", q)
+		}
+	}
+	p := &port{status: []byte{0xFF}}
+	if _, err := open(t, p).Paper(); err == nil {
+		t.Error("expected an error with an invalid status")
+	}
+}
+
+func TestCaps(t *testing.T) {
+	out := open(t, &port{}).Capabilities().Outputs
+	if len(out) != 2 || out[0].Width != 384 || out[1].Kind != caps.Text || out[1].Width != 32 {
+		t.Errorf("outputs %+v; want 384 dots and 32 characters wide", out)
+	}
+}
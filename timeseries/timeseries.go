@@ -0,0 +1,232 @@
+// Package timeseries keeps the recent history of sensor readings in memory,
+// e.g. the last 24 hours at a one minute resolution, for charts and history
+// queries without an external database.
+//
+// Readings are aggregated into fixed size buckets stored in a ring buffer:
+// memory use only depends on the retention and the resolution.
+package timeseries
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Stats aggregates readings.
+type Stats struct {
+	Count    int
+	Min, Max float64
+	Sum      float64
+}
+
+// Avg returns the average of the readings, or 0 if there are none.
+func (s Stats) Avg() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Sum / float64(s.Count)
+}
+
+func (s *Stats) add(v float64) {
+	if s.Count == 0 || v < s.Min {
+		s.Min = v
+	}
+	if s.Count == 0 || v > s.Max {
+		s.Max = v
+	}
+	s.Count++
+	s.Sum += v
+}
+
+func (s *Stats) merge(o Stats) {
+	if o.Count == 0 {
+		return
+	}
+	if s.Count == 0 || o.Min < s.Min {
+		s.Min = o.Min
+	}
+	if s.Count == 0 || o.Max > s.Max {
+		s.Max = o.Max
+	}
+	s.Count += o.Count
+	s.Sum += o.Sum
+}
+
+// Point is the aggregate of the readings of a period starting at Time.
+type Point struct {
+	Time time.Time
+	Stats
+}
+
+type bucket struct {
+	start int64 // start of the bucket in units of the resolution
+	Stats
+}
+
+// Series holds the readings of a sensor. It can be used by multiple
+// goroutines.
+type Series struct {
+	res time.Duration
+
+	mu      sync.Mutex
+	buckets []bucket
+	latest  int64
+}
+
+// New returns a series keeping readings for the retention period at the
+// given resolution.
+func New(retention, resolution time.Duration) *Series {
+	n := int(retention / resolution)
+	if n < 1 {
+		n = 1
+	}
+	return &Series{res: resolution, buckets: make([]bucket, n)}
+}
+
+func (s *Series) slot(t time.Time) int64 {
+	return t.UnixNano() / int64(s.res)
+}
+
+// Add adds a reading taken at t. Readings older than the retention period
+// are dropped.
+func (s *Series) Add(v float64, t time.Time) {
+	slot := s.slot(t)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := int64(len(s.buckets))
+	if slot <= s.latest-n {
+		return
+	}
+	if slot > s.latest {
+		s.latest = slot
+	}
+	b := &s.buckets[slot%n]
+	if b.start != slot || b.Count == 0 {
+		*b = bucket{start: slot}
+	}
+	b.add(v)
+}
+
+// get returns the bucket of slot if it is held; s.mu must be held.
+func (s *Series) get(slot int64) (Stats, bool) {
+	n := int64(len(s.buckets))
+	if slot <= s.latest-n || slot > s.latest || slot < 0 {
+		return Stats{}, false
+	}
+	b := s.buckets[slot%n]
+	if b.start != slot || b.Count == 0 {
+		return Stats{}, false
+	}
+	return b.Stats, true
+}
+
+// Last returns the aggregate of the most recent period with readings, and
+// false if there are none.
+func (s *Series) Last() (Point, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.get(s.latest)
+	if !ok {
+		return Point{}, false
+	}
+	return Point{Time: time.Unix(0, s.latest*int64(s.res)), Stats: st}, true
+}
+
+// Range returns the aggregate of the readings between from and to.
+// The bounds are rounded down to the resolution.
+func (s *Series) Range(from, to time.Time) Stats {
+	var st Stats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.each(from, to, func(_ int64, b Stats) { st.merge(b) })
+	return st
+}
+
+// Downsample returns the aggregates of the readings between from and to
+// over periods of step, skipping the periods without readings. Step is
+// rounded up to a multiple of the resolution.
+func (s *Series) Downsample(from, to time.Time, step time.Duration) []Point {
+	k := int64((step + s.res - 1) / s.res)
+	if k < 1 {
+		k = 1
+	}
+	var pts []Point
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	first := s.slot(from)
+	s.each(from, to, func(slot int64, b Stats) {
+		start := first + (slot-first)/k*k
+		if len(pts) == 0 || pts[len(pts)-1].Time.UnixNano() != start*int64(s.res) {
+			pts = append(pts, Point{Time: time.Unix(0, start*int64(s.res))})
+		}
+		pts[len(pts)-1].merge(b)
+	})
+	return pts
+}
+
+// each calls fn with the buckets between from and to in order; s.mu must be
+// held.
+func (s *Series) each(from, to time.Time, fn func(slot int64, b Stats)) {
+	first, last := s.slot(from), s.slot(to)
+	if min := s.latest - int64(len(s.buckets)) + 1; first < min {
+		first = min
+	}
+	if last > s.latest {
+		last = s.latest
+	}
+	for slot := first; slot <= last; slot++ {
+		if b, ok := s.get(slot); ok {
+			fn(slot, b)
+		}
+	}
+}
+
+// Store holds a series per sensor, all with the same retention and
+// resolution. It can be used by multiple goroutines.
+type Store struct {
+	retention, resolution time.Duration
+
+	mu     sync.Mutex
+	series map[string]*Series
+}
+
+// NewStore returns an empty store.
+func NewStore(retention, resolution time.Duration) *Store {
+	return &Store{
+		retention:  retention,
+		resolution: resolution,
+		series:     make(map[string]*Series),
+	}
+}
+
+// Add adds a reading of the named sensor, creating its series if needed.
+func (st *Store) Add(name string, v float64, t time.Time) {
+	st.mu.Lock()
+	s, ok := st.series[name]
+	if !ok {
+		s = New(st.retention, st.resolution)
+		st.series[name] = s
+	}
+	st.mu.Unlock()
+	s.Add(v, t)
+}
+
+// Series returns the series of the named sensor, or nil if it has no
+// readings.
+func (st *Store) Series(name string) *Series {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.series[name]
+}
+
+// Names returns the sorted names of the sensors.
+func (st *Store) Names() []string {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	names := make([]string, 0, len(st.series))
+	for name := range st.series {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
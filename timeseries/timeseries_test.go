@@ -0,0 +1,66 @@
+package timeseries
+
+import (
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2016, 3, 4, 10, 0, 0, 0, time.UTC)
+
+func at(min int) time.Time { return t0.Add(time.Duration(min) * time.Minute) }
+
+func TestRange(t *testing.T) {
+	s := New(time.Hour, time.Minute)
+	for i, v := range []float64{20, 22, 21, 25, 19} {
+		s.Add(v, at(i))
+	}
+	st := s.Range(at(1), at(3))
+	if st.Count != 3 || st.Min != 21 || st.Max != 25 || st.Avg() != 68.0/3 {
+		t.Errorf("Range = %+v, want 3 readings between 21 and 25", st)
+	}
+	if p, ok := s.Last(); !ok || !p.Time.Equal(at(4)) || p.Max != 19 {
+		t.Errorf("Last = %+v, %v; want 19 at %v", p, ok, at(4))
+	}
+}
+
+func TestRetention(t *testing.T) {
+	s := New(10*time.Minute, time.Minute)
+	s.Add(1, at(0))
+	s.Add(2, at(15)) // overwrites the slot of at(5)
+	s.Add(3, at(2))  // too old
+	if st := s.Range(at(0), at(20)); st.Count != 1 || st.Sum != 2 {
+		t.Errorf("Range = %+v, want only the last reading", st)
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	s := New(time.Hour, time.Minute)
+	for i := 0; i < 30; i++ {
+		if i >= 10 && i < 20 {
+			continue // gap
+		}
+		s.Add(float64(i), at(i))
+	}
+	pts := s.Downsample(at(0), at(29), 10*time.Minute)
+	if len(pts) != 2 {
+		t.Fatalf("got %d points, want 2: %+v", len(pts), pts)
+	}
+	if !pts[0].Time.Equal(at(0)) || pts[0].Count != 10 || pts[0].Avg() != 4.5 {
+		t.Errorf("pts[0] = %+v", pts[0])
+	}
+	if !pts[1].Time.Equal(at(20)) || pts[1].Min != 20 || pts[1].Max != 29 {
+		t.Errorf("pts[1] = %+v", pts[1])
+	}
+}
+
+func TestStore(t *testing.T) {
+	st := NewStore(time.Hour, time.Minute)
+	st.Add("temperature", 21, at(0))
+	st.Add("humidity", 40, at(0))
+	if names := st.Names(); len(names) != 2 || names[0] != "humidity" {
+		t.Errorf("Names = %v", names)
+	}
+	if st.Series("pressure") != nil {
+		t.Error("Series of an unknown sensor should be nil")
+	}
+}
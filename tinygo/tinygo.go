@@ -0,0 +1,154 @@
+// Package tinygo adapts the buses and pins of TinyGo's machine package to
+// the openers used by the drivers of this repo, so the same drivers run on
+// Linux boards and on microcontrollers.
+//
+// The interfaces below are implemented by machine.I2C, machine.SPI and
+// machine.Pin; this package doesn't import machine and compiles with the
+// standard toolchain too.
+package tinygo
+
+import (
+	"errors"
+
+	"github.com/goiot/devices/gpio"
+	i2cdriver "golang.org/x/exp/io/i2c/driver"
+	spidriver "golang.org/x/exp/io/spi/driver"
+)
+
+// I2CBus is implemented by *machine.I2C.
+type I2CBus interface {
+	Tx(addr uint16, w, r []byte) error
+}
+
+// SPIBus is implemented by machine.SPI.
+type SPIBus interface {
+	Tx(w, r []byte) error
+}
+
+// Pin is implemented by machine.Pin.
+type Pin interface {
+	High()
+	Low()
+	Get() bool
+}
+
+// I2C is an I2C driver using a configured TinyGo I2C bus.
+type I2C struct {
+	Bus I2CBus
+}
+
+// Open opens a connection to the device at addr.
+func (o *I2C) Open(addr int, tenbit bool) (i2cdriver.Conn, error) {
+	if tenbit {
+		return nil, errors.New("10-bit addresses are not supported")
+	}
+	return &i2cConn{bus: o.Bus, addr: uint16(addr)}, nil
+}
+
+type i2cConn struct {
+	bus  I2CBus
+	addr uint16
+}
+
+func (c *i2cConn) Tx(w, r []byte) error { return c.bus.Tx(c.addr, w, r) }
+func (c *i2cConn) Close() error         { return nil }
+
+// SPI is a SPI driver using a configured TinyGo SPI bus.
+type SPI struct {
+	Bus SPIBus
+
+	// CS is the optional active low chip select pin, driven around
+	// each transaction. It needs to be configured as an output.
+	CS Pin
+
+	// Configure applies the configuration requests of the drivers
+	// (see golang.org/x/exp/io/spi/driver). If nil, the requests are
+	// ignored and the bus keeps the configuration given to
+	// machine.SPI.Configure.
+	Configure func(k, v int) error
+}
+
+// Open opens a connection to the device selected by CS.
+func (o *SPI) Open() (spidriver.Conn, error) {
+	if o.CS != nil {
+		o.CS.High()
+	}
+	return &spiConn{o: o}, nil
+}
+
+type spiConn struct {
+	o        *SPI
+	csChange bool
+}
+
+func (c *spiConn) Configure(k, v int) error {
+	if k == spidriver.CSChange {
+		c.csChange = v != 0
+		return nil
+	}
+	if c.o.Configure == nil {
+		return nil
+	}
+	return c.o.Configure(k, v)
+}
+
+func (c *spiConn) Tx(w, r []byte) error {
+	cs := c.o.CS
+	if cs != nil {
+		cs.Low()
+	}
+	err := c.o.Bus.Tx(w, r)
+	if cs != nil && !c.csChange {
+		cs.High()
+	}
+	return err
+}
+
+// WriteVec implements writev.Writer, the segments are sent with the chip
+// select held low between them.
+func (c *spiConn) WriteVec(segs [][]byte) error {
+	cs := c.o.CS
+	if cs != nil {
+		cs.Low()
+	}
+	var err error
+	for _, s := range segs {
+		if err = c.o.Bus.Tx(s, nil); err != nil {
+			break
+		}
+	}
+	if cs != nil && !c.csChange {
+		cs.High()
+	}
+	return err
+}
+
+func (c *spiConn) Close() error { return nil }
+
+// GPIO returns p as a gpio.Pin. The pin needs to be configured as an
+// input or as an output with machine.Pin.Configure.
+func GPIO(p Pin) gpio.Pin {
+	return &pin{p: p}
+}
+
+type pin struct {
+	p Pin
+}
+
+func (p *pin) Read() (int, error) {
+	if p.p.Get() {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (p *pin) Write(v int) error {
+	if v == 0 {
+		p.p.Low()
+	} else {
+		p.p.High()
+	}
+	return nil
+}
+
+func (p *pin) Close() error { return nil }
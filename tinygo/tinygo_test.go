@@ -0,0 +1,97 @@
+package tinygo
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/goiot/devices/writev"
+	"golang.org/x/exp/io/i2c"
+	"golang.org/x/exp/io/spi"
+)
+
+type i2cBus struct {
+	addr uint16
+	w    []byte
+}
+
+func (b *i2cBus) Tx(addr uint16, w, r []byte) error {
+	b.addr = addr
+	b.w = append(b.w, w...)
+	return nil
+}
+
+type spiBus struct {
+	log *[]string
+}
+
+func (b spiBus) Tx(w, r []byte) error {
+	*b.log = append(*b.log, "tx")
+	return nil
+}
+
+type csPin struct {
+	log *[]string
+}
+
+func (p csPin) High()     { *p.log = append(*p.log, "high") }
+func (p csPin) Low()      { *p.log = append(*p.log, "low") }
+func (p csPin) Get() bool { return false }
+
+func TestI2C(t *testing.T) {
+	bus := &i2cBus{}
+	dev, err := i2c.Open(&I2C{Bus: bus}, 0x3C)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dev.Write([]byte{0xAE}); err != nil {
+		t.Fatal(err)
+	}
+	if bus.addr != 0x3C || !bytes.Equal(bus.w, []byte{0xAE}) {
+		t.Errorf("got addr %#x and %x, want 0x3c and ae", bus.addr, bus.w)
+	}
+}
+
+func TestSPIChipSelect(t *testing.T) {
+	var log []string
+	dev, err := spi.Open(&SPI{Bus: spiBus{&log}, CS: csPin{&log}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// drivers configure the bus when they open it
+	if err := dev.SetMode(spi.Mode3); err != nil {
+		t.Fatal(err)
+	}
+	if err := dev.Tx([]byte{0x00}, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"high", "low", "tx", "high"}
+	if len(log) != len(want) {
+		t.Fatalf("got %v, want %v", log, want)
+	}
+	for i := range want {
+		if log[i] != want[i] {
+			t.Fatalf("got %v, want %v", log, want)
+		}
+	}
+}
+
+func TestSPIWriteVec(t *testing.T) {
+	var log []string
+	_, conn, err := writev.OpenSPI(&SPI{Bus: spiBus{&log}, CS: csPin{&log}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writev.Write(conn, []byte{0x40}, []byte{1, 2}, []byte{3}); err != nil {
+		t.Fatal(err)
+	}
+	// the chip select is held low between the segments
+	want := []string{"high", "low", "tx", "tx", "tx", "high"}
+	if len(log) != len(want) {
+		t.Fatalf("got %v, want %v", log, want)
+	}
+	for i := range want {
+		if log[i] != want[i] {
+			t.Fatalf("got %v, want %v", log, want)
+		}
+	}
+}
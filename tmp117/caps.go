@@ -0,0 +1,19 @@
+package tmp117
+
+import "github.com/goiot/devices/caps"
+
+// Caps are the capabilities of the sensor.
+var Caps = caps.Capabilities{
+	Name:      "TMP117",
+	Bus:       "i2c",
+	Addresses: []int{Addr, Addr + 1, Addr + 2, Addr + 3},
+	Measurements: []caps.Measurement{
+		{Kind: caps.Temperature, Unit: "C", Min: -55, Max: 150, Resolution: 0.0078125},
+	},
+	PowerModes: []string{"shutdown", "one-shot", "continuous"},
+}
+
+func init() { caps.Register(Caps) }
+
+// Capabilities implements caps.Describer.
+func (d *TMP117) Capabilities() caps.Capabilities { return Caps }
@@ -0,0 +1,448 @@
+// Code generated by chipgen from the TMP117 descriptor. DO NOT EDIT.
+
+package tmp117
+
+import (
+	"fmt"
+	"math"
+
+	"golang.org/x/exp/io/i2c"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+// Addr is the default I2C address of the TMP117.
+const Addr = 0x48
+
+// Registers of the TMP117.
+const (
+	RegTemp         = 0x00 // the temperature of the last conversion
+	RegConfig       = 0x01
+	RegHighLimit    = 0x02
+	RegLowLimit     = 0x03
+	RegEEPROMUnlock = 0x04
+	RegOffset       = 0x07 // the offset added to the temperatures
+	RegDeviceID     = 0x0f
+)
+
+// Fields of the Config register, and their values.
+const (
+	ConfigHighAlertMask  = 0x8000
+	ConfigHighAlertShift = 15
+
+	ConfigLowAlertMask  = 0x4000
+	ConfigLowAlertShift = 14
+
+	ConfigDataReadyMask  = 0x2000
+	ConfigDataReadyShift = 13
+
+	ConfigEEPROMBusyMask  = 0x1000
+	ConfigEEPROMBusyShift = 12
+
+	ConfigModeMask       = 0x0c00
+	ConfigModeShift      = 10
+	ConfigModeContinuous = 0
+	ConfigModeShutdown   = 1
+	ConfigModeOneShot    = 3
+
+	ConfigCycleMask  = 0x0380 // the conversion cycle, with Averaging
+	ConfigCycleShift = 7
+
+	ConfigAveragingMask  = 0x0060
+	ConfigAveragingShift = 5
+	ConfigAveragingNone  = 0
+	ConfigAveragingAvg8  = 1
+	ConfigAveragingAvg32 = 2
+	ConfigAveragingAvg64 = 3
+
+	ConfigThermModeMask  = 0x0010
+	ConfigThermModeShift = 4
+
+	ConfigPolarityMask  = 0x0008
+	ConfigPolarityShift = 3
+
+	ConfigDRAlertMask  = 0x0004 // the ALERT pin reports the data ready flag
+	ConfigDRAlertShift = 2
+
+	ConfigSoftResetMask  = 0x0002
+	ConfigSoftResetShift = 1
+)
+
+// Fields of the DeviceID register, and their values.
+const (
+	DeviceIDRevisionMask  = 0xf000
+	DeviceIDRevisionShift = 12
+)
+
+// TMP117 represents a Texas Instruments TMP117 temperature sensor.
+type TMP117 struct {
+	Device *i2c.Device
+}
+
+// Open opens the TMP117 at the address addr, checks its DeviceID register
+// and initializes its registers.
+func Open(o driver.Opener, addr int) (*TMP117, error) {
+	dev, err := i2c.Open(o, addr)
+	if err != nil {
+		return nil, err
+	}
+	d := &TMP117{Device: dev}
+	id, err := d.ReadDeviceID()
+	if err != nil {
+		dev.Close()
+		return nil, err
+	}
+	if id&0x0fff != 0x0117 {
+		dev.Close()
+		return nil, fmt.Errorf("unexpected DeviceID %#x, expected %#x", id&0x0fff, 0x0117)
+	}
+	if err := d.WriteConfig(0x0220); err != nil {
+		dev.Close()
+		return nil, fmt.Errorf("initializing the Config register failed - %v", err)
+	}
+	return d, nil
+}
+
+// Close closes the TMP117.
+func (d *TMP117) Close() error {
+	return d.Device.Close()
+}
+
+// ReadTemp reads the Temp register, the temperature of the last conversion.
+func (d *TMP117) ReadTemp() (int16, error) {
+	v, err := d.readReg(RegTemp, 2)
+	return int16(v), err
+}
+
+// TempCelsius reads the Temp register in °C.
+func (d *TMP117) TempCelsius() (float64, error) {
+	raw, err := d.ReadTemp()
+	if err != nil {
+		return 0, err
+	}
+	return float64(raw) * 0.0078125, nil
+}
+
+// ReadConfig reads the Config register.
+func (d *TMP117) ReadConfig() (uint16, error) {
+	v, err := d.readReg(RegConfig, 2)
+	return uint16(v), err
+}
+
+// WriteConfig writes the Config register.
+func (d *TMP117) WriteConfig(v uint16) error {
+	return d.writeReg(RegConfig, uint32(v), 2)
+}
+
+// ReadConfigHighAlert reads the HighAlert field of the Config register.
+func (d *TMP117) ReadConfigHighAlert() (uint16, error) {
+	v, err := d.ReadConfig()
+	return v & ConfigHighAlertMask >> ConfigHighAlertShift, err
+}
+
+// WriteConfigHighAlert writes the HighAlert field of the Config register,
+// its other fields are left as they are.
+func (d *TMP117) WriteConfigHighAlert(v uint16) error {
+	r, err := d.ReadConfig()
+	if err != nil {
+		return err
+	}
+	return d.WriteConfig(r&^ConfigHighAlertMask | v<<ConfigHighAlertShift&ConfigHighAlertMask)
+}
+
+// ReadConfigLowAlert reads the LowAlert field of the Config register.
+func (d *TMP117) ReadConfigLowAlert() (uint16, error) {
+	v, err := d.ReadConfig()
+	return v & ConfigLowAlertMask >> ConfigLowAlertShift, err
+}
+
+// WriteConfigLowAlert writes the LowAlert field of the Config register, its
+// other fields are left as they are.
+func (d *TMP117) WriteConfigLowAlert(v uint16) error {
+	r, err := d.ReadConfig()
+	if err != nil {
+		return err
+	}
+	return d.WriteConfig(r&^ConfigLowAlertMask | v<<ConfigLowAlertShift&ConfigLowAlertMask)
+}
+
+// ReadConfigDataReady reads the DataReady field of the Config register.
+func (d *TMP117) ReadConfigDataReady() (uint16, error) {
+	v, err := d.ReadConfig()
+	return v & ConfigDataReadyMask >> ConfigDataReadyShift, err
+}
+
+// WriteConfigDataReady writes the DataReady field of the Config register,
+// its other fields are left as they are.
+func (d *TMP117) WriteConfigDataReady(v uint16) error {
+	r, err := d.ReadConfig()
+	if err != nil {
+		return err
+	}
+	return d.WriteConfig(r&^ConfigDataReadyMask | v<<ConfigDataReadyShift&ConfigDataReadyMask)
+}
+
+// ReadConfigEEPROMBusy reads the EEPROMBusy field of the Config register.
+func (d *TMP117) ReadConfigEEPROMBusy() (uint16, error) {
+	v, err := d.ReadConfig()
+	return v & ConfigEEPROMBusyMask >> ConfigEEPROMBusyShift, err
+}
+
+// WriteConfigEEPROMBusy writes the EEPROMBusy field of the Config register,
+// its other fields are left as they are.
+func (d *TMP117) WriteConfigEEPROMBusy(v uint16) error {
+	r, err := d.ReadConfig()
+	if err != nil {
+		return err
+	}
+	return d.WriteConfig(r&^ConfigEEPROMBusyMask | v<<ConfigEEPROMBusyShift&ConfigEEPROMBusyMask)
+}
+
+// ReadConfigMode reads the Mode field of the Config register.
+func (d *TMP117) ReadConfigMode() (uint16, error) {
+	v, err := d.ReadConfig()
+	return v & ConfigModeMask >> ConfigModeShift, err
+}
+
+// WriteConfigMode writes the Mode field of the Config register, its other
+// fields are left as they are.
+func (d *TMP117) WriteConfigMode(v uint16) error {
+	r, err := d.ReadConfig()
+	if err != nil {
+		return err
+	}
+	return d.WriteConfig(r&^ConfigModeMask | v<<ConfigModeShift&ConfigModeMask)
+}
+
+// ReadConfigCycle reads the Cycle field of the Config register, the
+// conversion cycle, with Averaging.
+func (d *TMP117) ReadConfigCycle() (uint16, error) {
+	v, err := d.ReadConfig()
+	return v & ConfigCycleMask >> ConfigCycleShift, err
+}
+
+// WriteConfigCycle writes the Cycle field of the Config register, its other
+// fields are left as they are.
+func (d *TMP117) WriteConfigCycle(v uint16) error {
+	r, err := d.ReadConfig()
+	if err != nil {
+		return err
+	}
+	return d.WriteConfig(r&^ConfigCycleMask | v<<ConfigCycleShift&ConfigCycleMask)
+}
+
+// ReadConfigAveraging reads the Averaging field of the Config register.
+func (d *TMP117) ReadConfigAveraging() (uint16, error) {
+	v, err := d.ReadConfig()
+	return v & ConfigAveragingMask >> ConfigAveragingShift, err
+}
+
+// WriteConfigAveraging writes the Averaging field of the Config register,
+// its other fields are left as they are.
+func (d *TMP117) WriteConfigAveraging(v uint16) error {
+	r, err := d.ReadConfig()
+	if err != nil {
+		return err
+	}
+	return d.WriteConfig(r&^ConfigAveragingMask | v<<ConfigAveragingShift&ConfigAveragingMask)
+}
+
+// ReadConfigThermMode reads the ThermMode field of the Config register.
+func (d *TMP117) ReadConfigThermMode() (uint16, error) {
+	v, err := d.ReadConfig()
+	return v & ConfigThermModeMask >> ConfigThermModeShift, err
+}
+
+// WriteConfigThermMode writes the ThermMode field of the Config register,
+// its other fields are left as they are.
+func (d *TMP117) WriteConfigThermMode(v uint16) error {
+	r, err := d.ReadConfig()
+	if err != nil {
+		return err
+	}
+	return d.WriteConfig(r&^ConfigThermModeMask | v<<ConfigThermModeShift&ConfigThermModeMask)
+}
+
+// ReadConfigPolarity reads the Polarity field of the Config register.
+func (d *TMP117) ReadConfigPolarity() (uint16, error) {
+	v, err := d.ReadConfig()
+	return v & ConfigPolarityMask >> ConfigPolarityShift, err
+}
+
+// WriteConfigPolarity writes the Polarity field of the Config register, its
+// other fields are left as they are.
+func (d *TMP117) WriteConfigPolarity(v uint16) error {
+	r, err := d.ReadConfig()
+	if err != nil {
+		return err
+	}
+	return d.WriteConfig(r&^ConfigPolarityMask | v<<ConfigPolarityShift&ConfigPolarityMask)
+}
+
+// ReadConfigDRAlert reads the DRAlert field of the Config register, the
+// ALERT pin reports the data ready flag.
+func (d *TMP117) ReadConfigDRAlert() (uint16, error) {
+	v, err := d.ReadConfig()
+	return v & ConfigDRAlertMask >> ConfigDRAlertShift, err
+}
+
+// WriteConfigDRAlert writes the DRAlert field of the Config register, its
+// other fields are left as they are.
+func (d *TMP117) WriteConfigDRAlert(v uint16) error {
+	r, err := d.ReadConfig()
+	if err != nil {
+		return err
+	}
+	return d.WriteConfig(r&^ConfigDRAlertMask | v<<ConfigDRAlertShift&ConfigDRAlertMask)
+}
+
+// ReadConfigSoftReset reads the SoftReset field of the Config register.
+func (d *TMP117) ReadConfigSoftReset() (uint16, error) {
+	v, err := d.ReadConfig()
+	return v & ConfigSoftResetMask >> ConfigSoftResetShift, err
+}
+
+// WriteConfigSoftReset writes the SoftReset field of the Config register,
+// its other fields are left as they are.
+func (d *TMP117) WriteConfigSoftReset(v uint16) error {
+	r, err := d.ReadConfig()
+	if err != nil {
+		return err
+	}
+	return d.WriteConfig(r&^ConfigSoftResetMask | v<<ConfigSoftResetShift&ConfigSoftResetMask)
+}
+
+// ReadHighLimit reads the HighLimit register.
+func (d *TMP117) ReadHighLimit() (int16, error) {
+	v, err := d.readReg(RegHighLimit, 2)
+	return int16(v), err
+}
+
+// WriteHighLimit writes the HighLimit register.
+func (d *TMP117) WriteHighLimit(v int16) error {
+	return d.writeReg(RegHighLimit, uint32(v), 2)
+}
+
+// HighLimitCelsius reads the HighLimit register in °C.
+func (d *TMP117) HighLimitCelsius() (float64, error) {
+	raw, err := d.ReadHighLimit()
+	if err != nil {
+		return 0, err
+	}
+	return float64(raw) * 0.0078125, nil
+}
+
+// SetHighLimitCelsius writes the HighLimit register in °C.
+func (d *TMP117) SetHighLimitCelsius(v float64) error {
+	x := math.Round(v / 0.0078125)
+	if x < -1<<15 || x > 1<<15-1 {
+		return fmt.Errorf("%v °C out of the range of the HighLimit register", v)
+	}
+	return d.WriteHighLimit(int16(x))
+}
+
+// ReadLowLimit reads the LowLimit register.
+func (d *TMP117) ReadLowLimit() (int16, error) {
+	v, err := d.readReg(RegLowLimit, 2)
+	return int16(v), err
+}
+
+// WriteLowLimit writes the LowLimit register.
+func (d *TMP117) WriteLowLimit(v int16) error {
+	return d.writeReg(RegLowLimit, uint32(v), 2)
+}
+
+// LowLimitCelsius reads the LowLimit register in °C.
+func (d *TMP117) LowLimitCelsius() (float64, error) {
+	raw, err := d.ReadLowLimit()
+	if err != nil {
+		return 0, err
+	}
+	return float64(raw) * 0.0078125, nil
+}
+
+// SetLowLimitCelsius writes the LowLimit register in °C.
+func (d *TMP117) SetLowLimitCelsius(v float64) error {
+	x := math.Round(v / 0.0078125)
+	if x < -1<<15 || x > 1<<15-1 {
+		return fmt.Errorf("%v °C out of the range of the LowLimit register", v)
+	}
+	return d.WriteLowLimit(int16(x))
+}
+
+// ReadEEPROMUnlock reads the EEPROMUnlock register.
+func (d *TMP117) ReadEEPROMUnlock() (uint16, error) {
+	v, err := d.readReg(RegEEPROMUnlock, 2)
+	return uint16(v), err
+}
+
+// WriteEEPROMUnlock writes the EEPROMUnlock register.
+func (d *TMP117) WriteEEPROMUnlock(v uint16) error {
+	return d.writeReg(RegEEPROMUnlock, uint32(v), 2)
+}
+
+// ReadOffset reads the Offset register, the offset added to the
+// temperatures.
+func (d *TMP117) ReadOffset() (int16, error) {
+	v, err := d.readReg(RegOffset, 2)
+	return int16(v), err
+}
+
+// WriteOffset writes the Offset register, the offset added to the
+// temperatures.
+func (d *TMP117) WriteOffset(v int16) error {
+	return d.writeReg(RegOffset, uint32(v), 2)
+}
+
+// OffsetCelsius reads the Offset register in °C.
+func (d *TMP117) OffsetCelsius() (float64, error) {
+	raw, err := d.ReadOffset()
+	if err != nil {
+		return 0, err
+	}
+	return float64(raw) * 0.0078125, nil
+}
+
+// SetOffsetCelsius writes the Offset register in °C.
+func (d *TMP117) SetOffsetCelsius(v float64) error {
+	x := math.Round(v / 0.0078125)
+	if x < -1<<15 || x > 1<<15-1 {
+		return fmt.Errorf("%v °C out of the range of the Offset register", v)
+	}
+	return d.WriteOffset(int16(x))
+}
+
+// ReadDeviceID reads the DeviceID register.
+func (d *TMP117) ReadDeviceID() (uint16, error) {
+	v, err := d.readReg(RegDeviceID, 2)
+	return uint16(v), err
+}
+
+// ReadDeviceIDRevision reads the Revision field of the DeviceID register.
+func (d *TMP117) ReadDeviceIDRevision() (uint16, error) {
+	v, err := d.ReadDeviceID()
+	return v & DeviceIDRevisionMask >> DeviceIDRevisionShift, err
+}
+
+// readReg reads the register reg of n bytes.
+func (d *TMP117) readReg(reg byte, n int) (uint32, error) {
+	b := make([]byte, n)
+	if err := d.Device.ReadReg(reg, b); err != nil {
+		return 0, err
+	}
+	var v uint32
+	for _, c := range b {
+		v = v<<8 | uint32(c)
+	}
+	return v, nil
+}
+
+// writeReg writes v to the register reg of n bytes.
+func (d *TMP117) writeReg(reg byte, v uint32, n int) error {
+	b := make([]byte, 1+n)
+	b[0] = reg
+	for i := n; i > 0; i-- {
+		b[i] = byte(v)
+		v >>= 8
+	}
+	return d.Device.Write(b)
+}
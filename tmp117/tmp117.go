@@ -0,0 +1,6 @@
+// Package tmp117 implements a driver for the Texas Instruments TMP117
+// high-accuracy temperature sensor, ±0.1°C from -20 to 50°C. Its registers
+// are generated by chipgen from tmp117.yaml.
+package tmp117
+
+//go:generate go run github.com/goiot/devices/chipgen/cmd/chipgen -o regs.go tmp117.yaml
@@ -0,0 +1,87 @@
+package tmp117
+
+import (
+	"testing"
+
+	"github.com/goiot/devices/i2csim"
+)
+
+// sensor is a fake TMP117.
+type sensor struct {
+	regs [16]uint16
+	reg  byte
+}
+
+func (s *sensor) Tx(w, r []byte) error {
+	if len(w) > 0 {
+		s.reg = w[0]
+	}
+	if len(w) == 3 {
+		s.regs[s.reg] = uint16(w[1])<<8 | uint16(w[2])
+	}
+	if len(r) == 2 {
+		v := s.regs[s.reg]
+		r[0], r[1] = byte(v>>8), byte(v)
+	}
+	return nil
+}
+
+func TestTMP117(t *testing.T) {
+	s := &sensor{}
+	s.regs[RegDeviceID] = 0x2117 // revision 2
+	bus := i2csim.NewBus()
+	bus.Attach(Addr, s)
+	d, err := Open(bus, Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.regs[RegConfig] != 0x0220 {
+		t.Errorf("configuration %#04x; want 0x0220", s.regs[RegConfig])
+	}
+
+	for _, tt := range []struct {
+		v    uint16
+		want float64
+	}{
+		{0x0C80, 25},
+		{0xFF80, -1},
+		{0x0001, 0.0078125},
+	} {
+		s.regs[RegTemp] = tt.v
+		if c, err := d.TempCelsius(); err != nil || c != tt.want {
+			t.Errorf("TempCelsius() of %#04x = %v, %v; want %v", tt.v, c, err, tt.want)
+		}
+	}
+
+	if err := d.SetHighLimitCelsius(-10.5); err != nil {
+		t.Fatal(err)
+	}
+	if v := s.regs[RegHighLimit]; v != 0xFAC0 {
+		t.Errorf("high limit %#04x; want 0xfac0", v)
+	}
+	if c, err := d.HighLimitCelsius(); err != nil || c != -10.5 {
+		t.Errorf("HighLimitCelsius() = %v, %v; want -10.5", c, err)
+	}
+	if err := d.SetLowLimitCelsius(300); err == nil {
+		t.Error("SetLowLimitCelsius(300) succeeded out of the range")
+	}
+
+	if err := d.WriteConfigMode(ConfigModeOneShot); err != nil {
+		t.Fatal(err)
+	}
+	if s.regs[RegConfig] != 0x0E20 {
+		t.Errorf("configuration %#04x in one-shot mode; want 0x0e20", s.regs[RegConfig])
+	}
+	if m, err := d.ReadConfigMode(); err != nil || m != ConfigModeOneShot {
+		t.Errorf("ReadConfigMode() = %d, %v; want %d", m, err, ConfigModeOneShot)
+	}
+	if rev, err := d.ReadDeviceIDRevision(); err != nil || rev != 2 {
+		t.Errorf("ReadDeviceIDRevision() = %d, %v; want 2", rev, err)
+	}
+	d.Close()
+
+	s.regs[RegDeviceID] = 0x0116
+	if _, err := Open(bus, Addr); err == nil {
+		t.Error("Open() succeeded with the ID of another chip")
+	}
+}
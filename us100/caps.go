@@ -0,0 +1,18 @@
+package us100
+
+import "github.com/goiot/devices/caps"
+
+// Caps are the capabilities of the sensor.
+var Caps = caps.Capabilities{
+	Name: "US-100",
+	Bus:  "uart",
+	Measurements: []caps.Measurement{
+		{Kind: caps.Distance, Unit: "m", Min: 0.02, Max: 4.5, Resolution: 0.001},
+		{Kind: caps.Temperature, Unit: "C", Min: -20, Max: 70, Resolution: 1},
+	},
+}
+
+func init() { caps.Register(Caps) }
+
+// Capabilities implements caps.Describer.
+func (u *US100) Capabilities() caps.Capabilities { return Caps }
@@ -0,0 +1,87 @@
+// Package us100 implements a driver for the US-100 ultrasonic rangefinder
+// in its serial mode, selected by the jumper on its back. Unlike the
+// HC-SR04 it measures the echo itself and compensates the speed of sound
+// with its temperature sensor.
+//
+// The serial port must be configured by the caller at 9600 bauds, 8N1, e.g.
+// with
+//
+//	stty -F /dev/ttyAMA0 9600 cs8 -parenb -cstopb raw
+package us100
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/goiot/devices/distance"
+)
+
+const (
+	cmdDistance    = 0x55
+	cmdTemperature = 0x50
+
+	// timeout is the time waited for an answer, a measurement up to the
+	// 4.5m of the range takes 30ms.
+	timeout = 100 * time.Millisecond
+)
+
+// deadliner is implemented by the serial ports supporting read timeouts,
+// such as *os.File.
+type deadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// US100 represents a rangefinder.
+type US100 struct {
+	port io.ReadWriter
+}
+
+// New returns a US100 on the serial port.
+func New(port io.ReadWriter) *US100 {
+	return &US100{port: port}
+}
+
+// query sends cmd and reads the n bytes of the answer. The ports supporting
+// read deadlines, such as *os.File, time out if the sensor does not answer.
+func (u *US100) query(cmd byte, n int) ([]byte, error) {
+	if _, err := u.port.Write([]byte{cmd}); err != nil {
+		return nil, err
+	}
+	if d, ok := u.port.(deadliner); ok {
+		d.SetReadDeadline(time.Now().Add(timeout))
+		defer d.SetReadDeadline(time.Time{})
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(u.port, b); err != nil {
+		return nil, fmt.Errorf("reading the answer of the sensor failed - %v", err)
+	}
+	return b, nil
+}
+
+// Distance implements distance.Sensor. It returns distance.ErrOutOfRange
+// if the sensor got no echo.
+func (u *US100) Distance() (float64, error) {
+	b, err := u.query(cmdDistance, 2)
+	if err != nil {
+		return 0, err
+	}
+	mm := int(b[0])<<8 | int(b[1])
+	if mm == 0 || mm > 4500 {
+		return 0, distance.ErrOutOfRange
+	}
+	return float64(mm) / 1000, nil
+}
+
+// Temperature returns the temperature of the sensor in degrees Celsius,
+// with a resolution of 1°C.
+func (u *US100) Temperature() (float64, error) {
+	b, err := u.query(cmdTemperature, 1)
+	if err != nil {
+		return 0, err
+	}
+	if b[0] == 0 || b[0] > 130 {
+		return 0, fmt.Errorf("invalid temperature reading %d", b[0])
+	}
+	return float64(int(b[0]) - 45), nil
+}
@@ -0,0 +1,48 @@
+package us100
+
+import (
+	"testing"
+
+	"github.com/goiot/devices/distance"
+)
+
+var _ distance.Sensor = (*US100)(nil)
+
+// sensor is a fake US-100 with answers queued by the commands written.
+type sensor struct {
+	mm, temp int
+	out      []byte
+}
+
+func (s *sensor) Write(b []byte) (int, error) {
+	for _, c := range b {
+		switch c {
+		case cmdDistance:
+			s.out = append(s.out, byte(s.mm>>8), byte(s.mm))
+		case cmdTemperature:
+			s.out = append(s.out, byte(s.temp+45))
+		}
+	}
+	return len(b), nil
+}
+
+func (s *sensor) Read(b []byte) (int, error) {
+	n := copy(b, s.out)
+	s.out = s.out[n:]
+	return n, nil
+}
+
+func TestDistance(t *testing.T) {
+	s := &sensor{mm: 1234, temp: 22}
+	u := New(s)
+	if d, err := u.Distance(); err != nil || d != 1.234 {
+		t.Errorf("Distance = %v, %v; want 1.234", d, err)
+	}
+	if c, err := u.Temperature(); err != nil || c != 22 {
+		t.Errorf("Temperature = %v, %v; want 22", c, err)
+	}
+	s.mm = 0
+	if _, err := u.Distance(); err != distance.ErrOutOfRange {
+		t.Errorf("Distance with no echo = %v; want ErrOutOfRange", err)
+	}
+}
@@ -0,0 +1,19 @@
+package veml7700
+
+import "github.com/goiot/devices/caps"
+
+// Caps are the capabilities of the sensor.
+var Caps = caps.Capabilities{
+	Name:      "VEML7700",
+	Bus:       "i2c",
+	Addresses: []int{addr},
+	Measurements: []caps.Measurement{
+		{Kind: caps.Illuminance, Unit: "lx", Min: 0, Max: 120000, Resolution: resolution},
+	},
+	PowerModes: []string{"shutdown", "active"},
+}
+
+func init() { caps.Register(Caps) }
+
+// Capabilities implements caps.Describer.
+func (s *VEML7700) Capabilities() caps.Capabilities { return Caps }
@@ -0,0 +1,36 @@
+// The autodim example dims an SSD1306 OLED display in the dark, with a
+// VEML7700 on the same bus.
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/goiot/devices/i2cbus"
+	"github.com/goiot/devices/lightsensor"
+	"github.com/goiot/devices/monochromeoled"
+	"github.com/goiot/devices/veml7700"
+)
+
+func main() {
+	// the bus of the GPIO header, see i2cbus.Buses to list the others
+	bus, err := i2cbus.Open("primary")
+	if err != nil {
+		panic(err)
+	}
+
+	sensor, err := veml7700.Open(bus)
+	if err != nil {
+		panic(err)
+	}
+	defer sensor.Close()
+
+	d, err := monochromeoled.Open(bus)
+	if err != nil {
+		panic(err)
+	}
+	defer d.Close()
+
+	dim := &lightsensor.Dimmer{Sensor: lightsensor.Func(sensor.AutoLux), Display: d, Min: 1}
+	dim.Run(context.Background(), 5*time.Second)
+}
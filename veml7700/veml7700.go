@@ -0,0 +1,232 @@
+// Package veml7700 implements a driver for the Vishay VEML7700 high
+// accuracy ambient light sensor.
+package veml7700
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"golang.org/x/exp/io/i2c"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+const (
+	addr = 0x10
+
+	regConf  = 0x00
+	regALS   = 0x04
+	regWhite = 0x05
+	regID    = 0x07
+
+	deviceID = 0x81
+
+	shutdown = 0x0001
+
+	// resolution of the ALS output with a gain of 2 and an integration
+	// time of 800ms, in lux per count.
+	resolution = 0.0036
+)
+
+// Gain is the gain of the sensor.
+type Gain byte
+
+// The gains, with their values in the configuration register.
+const (
+	Gain1   Gain = 0
+	Gain2   Gain = 1
+	Gain1_8 Gain = 2
+	Gain1_4 Gain = 3
+)
+
+func (g Gain) factor() float64 {
+	switch g {
+	case Gain2:
+		return 2
+	case Gain1_8:
+		return 0.125
+	case Gain1_4:
+		return 0.25
+	}
+	return 1
+}
+
+// gains and times are the settings of the auto-ranging, from the least to
+// the most sensitive.
+var (
+	gains = []Gain{Gain1_8, Gain1_4, Gain1, Gain2}
+	times = []time.Duration{
+		25 * time.Millisecond,
+		50 * time.Millisecond,
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+	}
+	// timeBits are the values of times in the configuration register.
+	timeBits = []uint16{0x0C, 0x08, 0x00, 0x01, 0x02, 0x03}
+)
+
+// VEML7700 represents a VEML7700 sensor.
+type VEML7700 struct {
+	Device *i2c.Device
+	// Clock times the waits for the measurements, clock.Real if nil.
+	Clock clock.Clock
+
+	gain Gain
+	it   int // index in times
+}
+
+// Open opens the sensor and starts the measurements with a gain of 1 and
+// an integration time of 100ms.
+func Open(o driver.Opener) (*VEML7700, error) {
+	dev, err := i2c.Open(o, addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &VEML7700{Device: dev}
+	id, err := s.read(regID)
+	if err != nil {
+		dev.Close()
+		return nil, err
+	}
+	if byte(id) != deviceID {
+		dev.Close()
+		return nil, fmt.Errorf("unexpected device ID %#x, expected %#x", byte(id), deviceID)
+	}
+	if err := s.configure(Gain1, 2); err != nil {
+		dev.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *VEML7700) read(reg byte) (uint16, error) {
+	b := make([]byte, 2)
+	if err := s.Device.ReadReg(reg, b); err != nil {
+		return 0, err
+	}
+	return uint16(b[0]) | uint16(b[1])<<8, nil
+}
+
+func (s *VEML7700) write(reg byte, v uint16) error {
+	return s.Device.Write([]byte{reg, byte(v), byte(v >> 8)})
+}
+
+func (s *VEML7700) configure(g Gain, it int) error {
+	if err := s.write(regConf, uint16(g)<<11|timeBits[it]<<6); err != nil {
+		return fmt.Errorf("configuring the sensor failed - %v", err)
+	}
+	s.gain, s.it = g, it
+	return nil
+}
+
+// SetGain sets the gain and the integration time of the measurements, one
+// of 25, 50, 100, 200, 400 or 800ms. A longer integration time and a
+// higher gain measure lower light levels.
+func (s *VEML7700) SetGain(g Gain, it time.Duration) error {
+	if g > Gain1_4 {
+		return fmt.Errorf("invalid gain %d", g)
+	}
+	for i, d := range times {
+		if d == it {
+			return s.configure(g, i)
+		}
+	}
+	return fmt.Errorf("invalid integration time %v", it)
+}
+
+// Resolution returns the lux per count of the current settings.
+func (s *VEML7700) Resolution() float64 {
+	return resolution * float64(800*time.Millisecond/times[s.it]) * 2 / s.gain.factor()
+}
+
+// Raw returns the counts of the ambient light channel.
+func (s *VEML7700) Raw() (uint16, error) {
+	return s.read(regALS)
+}
+
+// White returns the counts of the white channel.
+func (s *VEML7700) White() (uint16, error) {
+	return s.read(regWhite)
+}
+
+// Lux returns the ambient light with the current settings, in lux.
+func (s *VEML7700) Lux() (float64, error) {
+	counts, err := s.Raw()
+	if err != nil {
+		return 0, err
+	}
+	return s.lux(counts), nil
+}
+
+// lux converts counts to lux, the sensor is not linear above 1000 lux
+// which requires the lowest gains.
+func (s *VEML7700) lux(counts uint16) float64 {
+	lux := float64(counts) * s.Resolution()
+	if s.gain == Gain1_8 || s.gain == Gain1_4 {
+		lux = correct(lux)
+	}
+	return lux
+}
+
+// correct corrects the nonlinearity of the sensor, from the application
+// note "Designing the VEML7700 Into an Application".
+func correct(lux float64) float64 {
+	return ((6.0135e-13*lux-9.3924e-9)*lux+8.1488e-5)*lux*lux + 1.0023*lux
+}
+
+// AutoLux returns the ambient light in lux after picking the gain and the
+// integration time for the light level, as described in the application
+// note: the gain is raised and the integration time is lengthened in low
+// light until there are more than 100 counts, and the integration time
+// is shortened in bright light until there are less than 10000 counts.
+// The sensor keeps the settings, for the next calls to Lux.
+func (s *VEML7700) AutoLux() (float64, error) {
+	g, it := 0, 2 // a gain of 1/8 and 100ms
+	counts, err := s.measure(g, it)
+	if err != nil {
+		return 0, err
+	}
+	if counts <= 100 {
+		for counts <= 100 && (g < len(gains)-1 || it < len(times)-1) {
+			if g < len(gains)-1 {
+				g++
+			} else {
+				it++
+			}
+			if counts, err = s.measure(g, it); err != nil {
+				return 0, err
+			}
+		}
+	} else {
+		for counts > 10000 && it > 0 {
+			it--
+			if counts, err = s.measure(g, it); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return s.lux(counts), nil
+}
+
+// measure configures the sensor and waits for a measurement with the new
+// settings: the one in progress completes first.
+func (s *VEML7700) measure(g, it int) (uint16, error) {
+	if gains[g] != s.gain || it != s.it {
+		if err := s.configure(gains[g], it); err != nil {
+			return 0, err
+		}
+		clock.Or(s.Clock).Sleep(2 * times[it])
+	}
+	return s.Raw()
+}
+
+// Close shuts the sensor down and closes it.
+func (s *VEML7700) Close() error {
+	if err := s.write(regConf, uint16(s.gain)<<11|timeBits[s.it]<<6|shutdown); err != nil {
+		s.Device.Close()
+		return err
+	}
+	return s.Device.Close()
+}
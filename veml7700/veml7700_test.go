@@ -0,0 +1,121 @@
+package veml7700
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/i2csim"
+)
+
+// sensor is a fake VEML7700 measuring a linear light level.
+type sensor struct {
+	conf uint16
+	lux  float64
+}
+
+func (s *sensor) counts() uint16 {
+	g := Gain(s.conf >> 11 & 0x3)
+	bits := s.conf >> 6 & 0xF
+	it := 0
+	for i, b := range timeBits {
+		if b == bits {
+			it = i
+		}
+	}
+	res := (&VEML7700{gain: g, it: it}).Resolution()
+	return uint16(math.Min(s.lux/res, 0xFFFF))
+}
+
+func (s *sensor) Tx(w, r []byte) error {
+	var v uint16
+	switch w[0] {
+	case regConf:
+		if len(w) == 3 {
+			s.conf = uint16(w[1]) | uint16(w[2])<<8
+		}
+		v = s.conf
+	case regALS:
+		v = s.counts()
+	case regID:
+		v = 0xC400 | deviceID
+	}
+	if len(r) == 2 {
+		r[0], r[1] = byte(v), byte(v>>8)
+	}
+	return nil
+}
+
+func newSensor(t *testing.T, s *sensor) *VEML7700 {
+	bus := i2csim.NewBus()
+	bus.Attach(addr, s)
+	dev, err := Open(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := clock.NewFake(time.Time{})
+	c.SetAutoSleep(true)
+	dev.Clock = c
+	return dev
+}
+
+func TestLux(t *testing.T) {
+	s := &sensor{lux: 100}
+	dev := newSensor(t, s)
+	if s.conf != 0 {
+		t.Errorf("configuration %#04x; want a gain of 1 and 100ms", s.conf)
+	}
+	if lux, err := dev.Lux(); err != nil || math.Abs(lux-100) > 0.1 {
+		t.Errorf("Lux = %v, %v; want 100", lux, err)
+	}
+	if err := dev.SetGain(Gain2, 800*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if s.conf != 0x0800|0x03<<6 {
+		t.Errorf("configuration %#04x; want a gain of 2 and 800ms", s.conf)
+	}
+	if err := dev.SetGain(Gain1, 300*time.Millisecond); err == nil {
+		t.Error("expected an error with an integration time of 300ms")
+	}
+	if err := dev.Close(); err != nil || s.conf&shutdown == 0 {
+		t.Errorf("Close = %v, the sensor is not shut down", err)
+	}
+}
+
+func TestAutoLux(t *testing.T) {
+	for _, tt := range []struct {
+		lux  float64
+		gain Gain
+		it   time.Duration
+		want float64
+	}{
+		{lux: 5, gain: Gain2, it: 100 * time.Millisecond, want: 5},
+		{lux: 0.5, gain: Gain2, it: 800 * time.Millisecond, want: 0.5},
+		{lux: 300, gain: Gain1_8, it: 100 * time.Millisecond, want: correct(300)},
+		{lux: 20000, gain: Gain1_8, it: 25 * time.Millisecond, want: correct(20000)},
+	} {
+		s := &sensor{lux: tt.lux}
+		dev := newSensor(t, s)
+		lux, err := dev.AutoLux()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(lux-tt.want) > tt.want/50 {
+			t.Errorf("AutoLux = %v with %v lux; want %v", lux, tt.lux, tt.want)
+		}
+		if dev.gain != tt.gain || times[dev.it] != tt.it {
+			t.Errorf("gain %v and %v with %v lux; want %v and %v", dev.gain, times[dev.it], tt.lux, tt.gain, tt.it)
+		}
+	}
+}
+
+func TestCorrect(t *testing.T) {
+	// The correction is small in low light, and grows in bright light.
+	if c := correct(10); math.Abs(c-10.03) > 0.01 {
+		t.Errorf("correct(10) = %v", c)
+	}
+	if c := correct(10000); math.Abs(c-14792.9) > 0.1 {
+		t.Errorf("correct(10000) = %v", c)
+	}
+}
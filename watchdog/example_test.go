@@ -0,0 +1,45 @@
+package watchdog_test
+
+import (
+	"log"
+	"time"
+
+	"github.com/goiot/devices/accel3xdigital"
+	"github.com/goiot/devices/watchdog"
+	"golang.org/x/exp/io/i2c"
+)
+
+func Example() {
+	w := watchdog.New()
+	defer w.Close()
+	w.OnError = func(name string, err error) {
+		log.Printf("recovering %v failed: %v", name, err)
+	}
+
+	accel, err := accel3xdigital.Open(&i2c.Devfs{Dev: "/dev/i2c-1"})
+	if err != nil {
+		panic(err)
+	}
+	defer accel.Close()
+
+	// put the accelerometer back in active mode if the loop stalls
+	l := w.Watch("accelerometer", 5*time.Second, func(string) error {
+		return accel.ChangeMode(accel3xdigital.Active)
+	})
+	defer l.Stop()
+
+	// reboot if the loop cannot recover
+	hw, err := watchdog.OpenHardware("/dev/watchdog")
+	if err != nil {
+		panic(err)
+	}
+	defer hw.Close()
+	go w.Keep(hw, time.Second)
+
+	for {
+		if err := accel.Update(); err == nil {
+			l.Kick()
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
@@ -0,0 +1,194 @@
+// Package watchdog monitors long running loops, such as samplers and
+// control loops, and runs recovery actions when one of them stops
+// checking in. It can also keep the Linux hardware watchdog alive only
+// while all the loops are healthy, so an unattended device reboots if it
+// cannot recover.
+package watchdog
+
+import (
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/gpio"
+)
+
+// Action is a recovery action run when the loop name misses its deadline.
+type Action func(name string) error
+
+// Watchdog monitors a set of loops. It can be used by multiple goroutines.
+type Watchdog struct {
+	// OnError is called with the errors returned by the actions.
+	// Errors are ignored if nil.
+	OnError func(name string, err error)
+
+	// Clock times the deadlines, clock.Real if nil. It must be set before
+	// the loops are watched.
+	Clock clock.Clock
+
+	mu     sync.Mutex
+	loops  map[*Loop]struct{}
+	closed bool
+	done   chan struct{}
+}
+
+// New returns a watchdog monitoring no loop.
+func New() *Watchdog {
+	return &Watchdog{
+		loops: make(map[*Loop]struct{}),
+		done:  make(chan struct{}),
+	}
+}
+
+// Loop is a monitored loop, it needs to call Kick at least once per timeout.
+type Loop struct {
+	w       *Watchdog
+	name    string
+	timeout time.Duration
+	actions []Action
+
+	mu      sync.Mutex
+	timer   clock.Timer
+	overdue bool
+}
+
+// Watch starts monitoring the loop name. If it doesn't call Kick within
+// timeout, the actions are run in order and the deadline is re-armed,
+// so actions keep being run until the loop recovers.
+func (w *Watchdog) Watch(name string, timeout time.Duration, actions ...Action) *Loop {
+	l := &Loop{w: w, name: name, timeout: timeout, actions: actions}
+	l.mu.Lock()
+	l.timer = clock.Or(w.Clock).AfterFunc(timeout, l.expire)
+	l.mu.Unlock()
+	w.mu.Lock()
+	w.loops[l] = struct{}{}
+	w.mu.Unlock()
+	return l
+}
+
+// Kick checks in, the loop is healthy until timeout elapses again.
+func (l *Loop) Kick() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.overdue = false
+	l.timer.Reset(l.timeout)
+}
+
+// Stop stops monitoring the loop.
+func (l *Loop) Stop() {
+	l.timer.Stop()
+	l.w.mu.Lock()
+	delete(l.w.loops, l)
+	l.w.mu.Unlock()
+}
+
+func (l *Loop) expire() {
+	l.mu.Lock()
+	l.overdue = true
+	l.mu.Unlock()
+	for _, a := range l.actions {
+		if err := a(l.name); err != nil && l.w.OnError != nil {
+			l.w.OnError(l.name, err)
+		}
+	}
+	l.mu.Lock()
+	if l.overdue {
+		l.timer.Reset(l.timeout)
+	}
+	l.mu.Unlock()
+}
+
+// Healthy reports whether all the loops checked in before their deadline.
+func (w *Watchdog) Healthy() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for l := range w.loops {
+		l.mu.Lock()
+		overdue := l.overdue
+		l.mu.Unlock()
+		if overdue {
+			return false
+		}
+	}
+	return true
+}
+
+// Keep pets hw, typically a hardware watchdog, every interval as long as
+// all the loops are healthy. It returns once the watchdog is closed.
+func (w *Watchdog) Keep(hw io.Writer, interval time.Duration) error {
+	t := clock.Or(w.Clock).NewTicker(interval)
+	defer t.Stop()
+	for {
+		if w.Healthy() {
+			if _, err := hw.Write([]byte{0}); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-t.C():
+		case <-w.done:
+			return nil
+		}
+	}
+}
+
+// Close stops monitoring all the loops.
+func (w *Watchdog) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	loops := w.loops
+	w.loops = make(map[*Loop]struct{})
+	w.mu.Unlock()
+	for l := range loops {
+		l.timer.Stop()
+	}
+	close(w.done)
+	return nil
+}
+
+// Pulse returns an action driving p to v for d, timed by the clock of the
+// watchdog, and then back to the opposite level, e.g. to pulse the reset
+// line of a device.
+func (w *Watchdog) Pulse(p gpio.Pin, v int, d time.Duration) Action {
+	return func(string) error {
+		if err := p.Write(v); err != nil {
+			return err
+		}
+		clock.Or(w.Clock).Sleep(d)
+		return p.Write(v ^ 1)
+	}
+}
+
+// Hardware is a Linux hardware watchdog. The system reboots if it isn't
+// written to before its timeout, usually between 10 and 60 seconds.
+type Hardware struct {
+	f *os.File
+}
+
+// OpenHardware opens the hardware watchdog dev, usually /dev/watchdog.
+// The watchdog starts as soon as it is opened.
+func OpenHardware(dev string) (*Hardware, error) {
+	f, err := os.OpenFile(dev, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &Hardware{f: f}, nil
+}
+
+// Write pets the watchdog.
+func (h *Hardware) Write(b []byte) (int, error) {
+	return h.f.Write(b)
+}
+
+// Close disarms the watchdog with the magic close character, if the
+// driver supports it, and closes it.
+func (h *Hardware) Close() error {
+	h.f.Write([]byte("V"))
+	return h.f.Close()
+}
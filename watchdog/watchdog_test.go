@@ -0,0 +1,143 @@
+package watchdog
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/gpiosim"
+)
+
+func TestKick(t *testing.T) {
+	w := New()
+	defer w.Close()
+	fired := make(chan string, 1)
+	l := w.Watch("sampler", 50*time.Millisecond, func(name string) error {
+		fired <- name
+		return nil
+	})
+	for i := 0; i < 5; i++ {
+		time.Sleep(10 * time.Millisecond)
+		l.Kick()
+	}
+	select {
+	case <-fired:
+		t.Fatal("action run while the loop was checking in")
+	default:
+	}
+	if !w.Healthy() {
+		t.Error("watchdog should be healthy")
+	}
+}
+
+func TestMissedDeadline(t *testing.T) {
+	w := New()
+	defer w.Close()
+	var mu sync.Mutex
+	var errs []error
+	w.OnError = func(name string, err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}
+	fired := make(chan string, 10)
+	l := w.Watch("sampler", 10*time.Millisecond, func(name string) error {
+		fired <- name
+		return errors.New("reset failed")
+	})
+	if name := <-fired; name != "sampler" {
+		t.Errorf("got %q, want sampler", name)
+	}
+	if w.Healthy() {
+		t.Error("watchdog should not be healthy")
+	}
+	// actions are run again until the loop recovers
+	<-fired
+	l.Kick()
+	if !w.Healthy() {
+		t.Error("watchdog should be healthy after a kick")
+	}
+	mu.Lock()
+	if len(errs) == 0 {
+		t.Error("OnError was not called")
+	}
+	mu.Unlock()
+}
+
+func TestDeadlineClock(t *testing.T) {
+	c := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	w := New()
+	w.Clock = c
+	defer w.Close()
+	var fired int
+	l := w.Watch("sampler", time.Minute, func(string) error {
+		fired++
+		return nil
+	})
+	c.Advance(59 * time.Second)
+	l.Kick()
+	c.Advance(59 * time.Second)
+	if fired != 0 || !w.Healthy() {
+		t.Fatalf("%d actions run before the deadline", fired)
+	}
+	c.Advance(time.Second)
+	if fired != 1 || w.Healthy() {
+		t.Errorf("%d actions run after the deadline; want 1", fired)
+	}
+	c.Advance(time.Minute)
+	if fired != 2 {
+		t.Errorf("%d actions run a minute later; want 2", fired)
+	}
+}
+
+func TestPulse(t *testing.T) {
+	c := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	w := New()
+	w.Clock = c
+	defer w.Close()
+	reset := gpiosim.NewPin(1)
+	done := make(chan error)
+	go func() { done <- w.Pulse(reset, 0, 10*time.Millisecond)("sensor") }()
+	c.BlockUntil(1)
+	if reset.Level() != 0 {
+		t.Error("the reset line not driven low")
+	}
+	c.Advance(10 * time.Millisecond)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if reset.Level() != 1 {
+		t.Error("the reset line not driven back high")
+	}
+}
+
+type hw struct {
+	mu   sync.Mutex
+	pets int
+}
+
+func (h *hw) Write(b []byte) (int, error) {
+	h.mu.Lock()
+	h.pets++
+	h.mu.Unlock()
+	return len(b), nil
+}
+
+func TestKeep(t *testing.T) {
+	w := New()
+	w.Watch("stalled", 5*time.Millisecond)
+	h := &hw{}
+	done := make(chan error)
+	go func() { done <- w.Keep(h, time.Millisecond) }()
+	time.Sleep(50 * time.Millisecond)
+	w.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	// at most the first few pets happened before the deadline was missed
+	if h.pets > 10 {
+		t.Errorf("hardware watchdog petted %d times while a loop was stalled", h.pets)
+	}
+}
@@ -0,0 +1,326 @@
+// Package weatherstation combines the sensors of the common weather meter
+// kits, such as the SparkFun and Argent Data ones, into a weather station:
+// a cup anemometer and a tipping bucket rain gauge closing switches to
+// ground, read on GPIO inputs with pull-ups, a wind vane read through an
+// ADC, and a BME280 for the temperature, the humidity and the pressure.
+package weatherstation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/goiot/devices/analog"
+	"github.com/goiot/devices/bme280"
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/events"
+	"github.com/goiot/devices/gpio"
+)
+
+// Calibration of the SparkFun and Argent Data weather meters.
+const (
+	WindFactor = 0.667  // m/s per Hz of the anemometer, 2.4km/h
+	RainPerTip = 0.2794 // mm
+	VanePullup = 10000  // ohms, the resistor of the divider of the vane
+)
+
+const (
+	// WindAverage is the period of the mean wind speed, the 2 minutes of
+	// the WMO for the reports of the weather stations.
+	WindAverage = 2 * time.Minute
+	// GustPeriod is the period over which the gust is the fastest wind,
+	// averaged over GustAverage.
+	GustPeriod  = 10 * time.Minute
+	GustAverage = 3 * time.Second
+
+	// debounce is the shortest time between two closings of a switch,
+	// the shorter ones are bounces.
+	debounce = 5 * time.Millisecond
+)
+
+// vane is the resistance of the vane in ohms in the 16 directions, from
+// the north by 22.5°.
+var vane = [16]float64{
+	33000, 6570, 8200, 891, 1000, 688, 2200, 1410,
+	3900, 3140, 16000, 14120, 120000, 42120, 64900, 21880,
+}
+
+// Env measures the temperature, the humidity and the pressure, it is
+// implemented by *bme280.BME280.
+type Env interface {
+	Read() (bme280.Measurement, error)
+}
+
+// Reading is a reading of the station.
+type Reading struct {
+	WindSpeed     float64 // m/s, averaged over WindAverage
+	Gust          float64 // m/s
+	WindDirection float64 // degrees clockwise from the north, where the wind comes from
+	Rain          float64 // mm since midnight
+
+	bme280.Measurement
+
+	Time time.Time
+}
+
+// Station is a weather station. The sensors left nil are not read. It can
+// be used by multiple goroutines.
+type Station struct {
+	Anemometer gpio.Watcher
+	RainGauge  gpio.Watcher
+	Vane       analog.ADC
+	Env        Env
+
+	// VaneChannel is the channel of Vane the divider of the vane is
+	// connected to, with VanePullup ohms to the reference voltage.
+	VaneChannel int
+
+	// WindFactor, RainPerTip and VanePullup calibrate the sensors, the
+	// constants of the same names are used if zero.
+	WindFactor float64
+	RainPerTip float64
+	VanePullup float64
+
+	// VaneOffset is the direction in degrees clockwise from the north of
+	// the north mark of the vane, if it is not mounted facing the north.
+	VaneOffset float64
+
+	// Location is where the days of the daily rain start, time.Local if
+	// nil.
+	Location *time.Location
+
+	// Bus receives the readings of Run as the samples <Source>/wind_speed,
+	// gust, wind_direction, rain, temperature, humidity and pressure if not
+	// nil, Source is weather if empty.
+	Bus    *events.Bus
+	Source string
+
+	// ErrorLog logs the errors of Run, the standard logger is used if nil.
+	ErrorLog *log.Logger
+
+	// Clock dates the readings and times Run, clock.Real if nil.
+	Clock clock.Clock
+
+	mu      sync.Mutex
+	wind    []time.Time // pulses of the last GustPeriod
+	rain    int         // tips since rainDay
+	rainDay time.Time
+}
+
+// Wind records a pulse of the anemometer at t, Run records the falling
+// edges of Anemometer.
+func (s *Station) Wind(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.wind = append(s.wind, t)
+	s.prune(t)
+}
+
+// Tip records a tip of the rain gauge at t, Run records the falling edges
+// of RainGauge.
+func (s *Station) Tip(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rollover(t)
+	s.rain++
+}
+
+// prune drops the pulses older than GustPeriod.
+func (s *Station) prune(now time.Time) {
+	i := 0
+	for i < len(s.wind) && now.Sub(s.wind[i]) > GustPeriod {
+		i++
+	}
+	s.wind = s.wind[i:]
+}
+
+// rollover resets the rain at midnight.
+func (s *Station) rollover(now time.Time) {
+	loc := s.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	y, m, d := now.In(loc).Date()
+	if day := time.Date(y, m, d, 0, 0, 0, 0, loc); !day.Equal(s.rainDay) {
+		s.rainDay, s.rain = day, 0
+	}
+}
+
+// Read reads the sensors.
+func (s *Station) Read() (Reading, error) {
+	now := clock.Or(s.Clock).Now()
+	r := Reading{Time: now}
+	s.mu.Lock()
+	s.prune(now)
+	s.rollover(now)
+	factor := or(s.WindFactor, WindFactor)
+	r.WindSpeed = float64(pulses(s.wind, now, WindAverage)) / WindAverage.Seconds() * factor
+	for _, t := range s.wind {
+		if g := float64(pulses(s.wind, t, GustAverage)) / GustAverage.Seconds() * factor; g > r.Gust {
+			r.Gust = g
+		}
+	}
+	r.Rain = float64(s.rain) * or(s.RainPerTip, RainPerTip)
+	s.mu.Unlock()
+
+	if s.Vane != nil {
+		d, err := s.direction()
+		if err != nil {
+			return Reading{}, fmt.Errorf("reading the wind vane failed - %v", err)
+		}
+		r.WindDirection = d
+	}
+	if s.Env != nil {
+		m, err := s.Env.Read()
+		if err != nil {
+			return Reading{}, fmt.Errorf("reading the environment sensor failed - %v", err)
+		}
+		r.Measurement = m
+	}
+	return r, nil
+}
+
+// pulses returns the number of pulses of the period ending at end.
+func pulses(p []time.Time, end time.Time, period time.Duration) int {
+	n := 0
+	for _, t := range p {
+		if !t.After(end) && end.Sub(t) < period {
+			n++
+		}
+	}
+	return n
+}
+
+// direction reads the vane and returns the nearest of its directions.
+func (s *Station) direction() (float64, error) {
+	v, err := s.Vane.Read(s.VaneChannel)
+	if err != nil {
+		return 0, err
+	}
+	ratio := float64(v) / float64(int(1)<<uint(s.Vane.Resolution())-1)
+	if ratio >= 0.99 {
+		return 0, errors.New("the vane is not connected")
+	}
+	ohms := or(s.VanePullup, VanePullup) * ratio / (1 - ratio)
+	best, dir := math.Inf(1), 0
+	for i, r := range vane {
+		// the resistances spread over decades, compare their logarithms
+		if d := math.Abs(math.Log(ohms / r)); d < best {
+			best, dir = d, i
+		}
+	}
+	return math.Mod(float64(dir)*22.5+s.VaneOffset+360, 360), nil
+}
+
+func or(v, def float64) float64 {
+	if v == 0 {
+		return def
+	}
+	return v
+}
+
+func (s *Station) source() string {
+	if s.Source == "" {
+		return "weather"
+	}
+	return s.Source
+}
+
+// Publish publishes r on Bus.
+func (s *Station) Publish(r Reading) {
+	if s.Bus == nil {
+		return
+	}
+	src := s.source()
+	for _, v := range []struct {
+		name, unit string
+		value      float64
+	}{
+		{"wind_speed", "m/s", r.WindSpeed},
+		{"gust", "m/s", r.Gust},
+		{"wind_direction", "°", r.WindDirection},
+		{"rain", "mm", r.Rain},
+		{"temperature", "C", r.Temperature},
+		{"humidity", "%", r.Humidity},
+		{"pressure", "hPa", r.Pressure},
+	} {
+		s.Bus.Publish(events.Sample{Source: src + "/" + v.name, Value: v.value, Unit: v.unit, Time: r.Time})
+	}
+}
+
+// Run records the pulses of the anemometer and of the rain gauge, and
+// reads and publishes the readings every interval until ctx is done,
+// logging the errors.
+func (s *Station) Run(ctx context.Context, interval time.Duration) error {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer cancel()
+	for _, w := range []struct {
+		name string
+		pin  gpio.Watcher
+		f    func(time.Time)
+	}{
+		{"anemometer", s.Anemometer, s.Wind},
+		{"rain gauge", s.RainGauge, s.Tip},
+	} {
+		if w.pin == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(name string, pin gpio.Watcher, f func(time.Time)) {
+			defer wg.Done()
+			if err := watch(ctx, pin, f); err != nil && err != ctx.Err() {
+				s.logf("weatherstation: watching the %s failed - %v", name, err)
+			}
+		}(w.name, w.pin, w.f)
+	}
+
+	t := clock.Or(s.Clock).NewTicker(interval)
+	defer t.Stop()
+	for {
+		if r, err := s.Read(); err != nil {
+			s.logf("weatherstation: %v", err)
+		} else {
+			s.Publish(r)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C():
+		}
+	}
+}
+
+// watch calls f with the times of the falling edges of w, closings of a
+// switch pulling it to ground, until ctx is done or w fails.
+func watch(ctx context.Context, w gpio.Watcher, f func(time.Time)) error {
+	var last time.Time
+	for ctx.Err() == nil {
+		e, err := w.Wait(time.Second)
+		if err == gpio.ErrTimeout {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if e.Edge != gpio.FallingEdge || (!last.IsZero() && e.Time.Sub(last) < debounce) {
+			continue
+		}
+		last = e.Time
+		f(e.Time)
+	}
+	return ctx.Err()
+}
+
+func (s *Station) logf(format string, args ...interface{}) {
+	if s.ErrorLog != nil {
+		s.ErrorLog.Printf(format, args...)
+		return
+	}
+	log.Printf(format, args...)
+}
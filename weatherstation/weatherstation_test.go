@@ -0,0 +1,216 @@
+package weatherstation
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/goiot/devices/bme280"
+	"github.com/goiot/devices/clock"
+	"github.com/goiot/devices/events"
+	"github.com/goiot/devices/fault"
+	"github.com/goiot/devices/gpiosim"
+)
+
+var _ Env = (*bme280.BME280)(nil)
+
+type adc struct {
+	v   int
+	err error
+}
+
+func (a *adc) Read(ch int) (int, error) { return a.v, a.err }
+func (a *adc) Channels() int            { return 4 }
+func (a *adc) Resolution() int          { return 10 }
+
+// at returns the reading of the vane of resistance ohms.
+func at(ohms float64) int {
+	return int(math.Round(ohms / (ohms + VanePullup) * 1023))
+}
+
+type env struct {
+	m   bme280.Measurement
+	err error
+}
+
+func (e *env) Read() (bme280.Measurement, error) { return e.m, e.err }
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+
+func TestWind(t *testing.T) {
+	c := clock.NewFake(time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC))
+	s := &Station{Clock: c}
+	// 2Hz for 2 minutes, with a gust of 10Hz for 3s a minute ago
+	start := c.Now()
+	for t := time.Duration(0); t < WindAverage; t += 500 * time.Millisecond {
+		s.Wind(start.Add(t))
+	}
+	for t := time.Duration(0); t < 3*time.Second; t += 125 * time.Millisecond {
+		s.Wind(start.Add(time.Minute + t + 50*time.Millisecond))
+	}
+	c.Advance(WindAverage)
+	r, err := s.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the first pulse is WindAverage old, out of the average
+	if want := (239 + 24.0) / 120 * WindFactor; !near(r.WindSpeed, want) {
+		t.Errorf("wind speed = %v m/s; want %v", r.WindSpeed, want)
+	}
+	if want := 10 * WindFactor; !near(r.Gust, want) {
+		t.Errorf("gust = %v m/s; want %v", r.Gust, want)
+	}
+
+	// calm for 7 minutes, the gust is still reported until 10 minutes
+	c.Advance(7 * time.Minute)
+	if r, _ := s.Read(); r.WindSpeed != 0 || !near(r.Gust, 10*WindFactor) {
+		t.Errorf("reading = %+v; want calm with a gust of %v", r, 10*WindFactor)
+	}
+	c.Advance(3 * time.Minute)
+	if r, _ := s.Read(); r.Gust != 0 {
+		t.Errorf("gust = %v after 10 minutes; want 0", r.Gust)
+	}
+}
+
+func TestRain(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip(err)
+	}
+	c := clock.NewFake(time.Date(2024, 5, 17, 23, 0, 0, 0, paris))
+	s := &Station{Clock: c, Location: paris, RainPerTip: 0.5}
+	for i := 0; i < 3; i++ {
+		s.Tip(c.Now())
+	}
+	if r, _ := s.Read(); !near(r.Rain, 1.5) {
+		t.Errorf("rain = %v mm; want 1.5", r.Rain)
+	}
+	c.Advance(30 * time.Minute) // 23:30, still the 17th
+	if r, _ := s.Read(); !near(r.Rain, 1.5) {
+		t.Errorf("rain = %v mm; want 1.5", r.Rain)
+	}
+	c.Advance(time.Hour) // 00:30 in Paris
+	s.Tip(c.Now())
+	if r, _ := s.Read(); !near(r.Rain, 0.5) {
+		t.Errorf("rain = %v mm after midnight; want 0.5", r.Rain)
+	}
+	c.Advance(24 * time.Hour)
+	if r, _ := s.Read(); r.Rain != 0 {
+		t.Errorf("rain = %v mm the next day; want 0", r.Rain)
+	}
+}
+
+func TestDirection(t *testing.T) {
+	a := &adc{}
+	s := &Station{Vane: a}
+	for _, tt := range []struct {
+		ohms, offset, want float64
+	}{
+		{33000, 0, 0},
+		{891, 0, 67.5},
+		{688, 0, 112.5},
+		{3900, 0, 180},
+		{120000, 0, 270},
+		{21880, 0, 337.5},
+		{3900, 90, 270},
+		{33000, -22.5, 337.5},
+	} {
+		a.v = at(tt.ohms)
+		s.VaneOffset = tt.offset
+		r, err := s.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !near(r.WindDirection, tt.want) {
+			t.Errorf("direction at %vΩ offset by %v° = %v; want %v", tt.ohms, tt.offset, r.WindDirection, tt.want)
+		}
+	}
+
+	a.v = 1023
+	if _, err := s.Read(); err == nil {
+		t.Error("Read() succeeded with the vane disconnected")
+	}
+	a.err = errors.New("i2c failure")
+	if _, err := s.Read(); err == nil {
+		t.Error("Read() succeeded with a failing ADC")
+	}
+}
+
+func TestEnv(t *testing.T) {
+	e := &env{m: bme280.Measurement{Temperature: 21.5, Pressure: 1013, Humidity: 40}}
+	s := &Station{Env: e}
+	r, err := s.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Measurement != e.m {
+		t.Errorf("measurement = %+v; want %+v", r.Measurement, e.m)
+	}
+	e.err = errors.New("i2c failure")
+	if _, err := s.Read(); err == nil {
+		t.Error("Read() succeeded with a failing BME280")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	c := clock.NewFake(time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC))
+	p := gpiosim.NewPin(1)
+	p.Clock = c
+	// two closings, the first one bouncing
+	p.Set(0)
+	p.Set(1)
+	c.Advance(time.Millisecond)
+	p.Set(0)
+	c.Advance(time.Millisecond)
+	p.Set(1)
+	c.Advance(20 * time.Millisecond)
+	p.Set(0)
+	p.Set(1)
+	// the 6 edges, then the pin fails
+	p.Inject(fault.Fault{}, fault.Fault{}, fault.Fault{}, fault.Fault{}, fault.Fault{}, fault.Fault{}, fault.Fault{Kind: fault.Error})
+	var got []time.Time
+	if err := watch(context.Background(), p, func(t time.Time) { got = append(got, t) }); err != fault.ErrInjected {
+		t.Errorf("watch() = %v; want %v", err, fault.ErrInjected)
+	}
+	start := time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC)
+	if len(got) != 2 || !got[0].Equal(start) || !got[1].Equal(start.Add(22*time.Millisecond)) {
+		t.Errorf("pulses at %v; want at 0 and 22ms", got)
+	}
+}
+
+func TestRun(t *testing.T) {
+	c := clock.NewFake(time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC))
+	bus := events.New()
+	sub := bus.Subscribe("sample/#", 16)
+	s := &Station{
+		Vane:  &adc{v: at(3900)},
+		Env:   &env{m: bme280.Measurement{Temperature: 21.5, Pressure: 1013, Humidity: 40}},
+		Bus:   bus,
+		Clock: c,
+	}
+	s.Tip(c.Now())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx, time.Minute) }()
+	want := map[string]float64{
+		"weather/wind_speed":     0,
+		"weather/gust":           0,
+		"weather/wind_direction": 180,
+		"weather/rain":           RainPerTip,
+		"weather/temperature":    21.5,
+		"weather/humidity":       40,
+		"weather/pressure":       1013,
+	}
+	for range want {
+		e := (<-sub.C).(events.Sample)
+		if v, ok := want[e.Source]; !ok || !near(e.Value, v) {
+			t.Errorf("sample %+v; want %v", e, v)
+		}
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Run() = %v; want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,101 @@
+// Package writev writes a message made of several segments, such as a
+// command header and the pixel data of a display, in one transfer. The
+// connections implementing Writer send the segments as they are, e.g.
+// with the chip select of a SPI bus held between them; the segments are
+// joined into one buffer, reused by the next transfers, for the others.
+//
+// The drivers open their device with OpenI2C or OpenSPI to get its
+// connection:
+//
+//	dev, conn, err := writev.OpenI2C(o, addr)
+//	...
+//	err = writev.Write(conn, header, page)
+package writev
+
+import (
+	"sync"
+
+	"golang.org/x/exp/io/i2c"
+	i2cdriver "golang.org/x/exp/io/i2c/driver"
+	"golang.org/x/exp/io/spi"
+	spidriver "golang.org/x/exp/io/spi/driver"
+)
+
+// Conn is a connection to a device, implemented by the connections of the
+// I2C and SPI drivers.
+type Conn interface {
+	Tx(w, r []byte) error
+}
+
+// Writer is implemented by the connections writing the segments of a
+// message in one transfer without joining them.
+type Writer interface {
+	WriteVec(segs [][]byte) error
+}
+
+// bufs are the buffers joining the segments for the connections which are
+// not Writers.
+var bufs = sync.Pool{New: func() interface{} { return new([]byte) }}
+
+// Write writes the segments in one transfer on c.
+func Write(c Conn, segs ...[]byte) error {
+	if w, ok := c.(Writer); ok {
+		return w.WriteVec(segs)
+	}
+	if len(segs) == 1 {
+		return c.Tx(segs[0], nil)
+	}
+	b := bufs.Get().(*[]byte)
+	defer bufs.Put(b)
+	*b = (*b)[:0]
+	for _, s := range segs {
+		*b = append(*b, s...)
+	}
+	return c.Tx(*b, nil)
+}
+
+// i2cOpener keeps the connection it opens.
+type i2cOpener struct {
+	i2cdriver.Opener
+	conn i2cdriver.Conn
+}
+
+func (o *i2cOpener) Open(addr int, tenbit bool) (i2cdriver.Conn, error) {
+	c, err := o.Opener.Open(addr, tenbit)
+	o.conn = c
+	return c, err
+}
+
+// OpenI2C opens the device at addr as i2c.Open, and returns its connection
+// for Write.
+func OpenI2C(o i2cdriver.Opener, addr int) (*i2c.Device, i2cdriver.Conn, error) {
+	k := &i2cOpener{Opener: o}
+	dev, err := i2c.Open(k, addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dev, k.conn, nil
+}
+
+// spiOpener keeps the connection it opens.
+type spiOpener struct {
+	spidriver.Opener
+	conn spidriver.Conn
+}
+
+func (o *spiOpener) Open() (spidriver.Conn, error) {
+	c, err := o.Opener.Open()
+	o.conn = c
+	return c, err
+}
+
+// OpenSPI opens the device as spi.Open, and returns its connection for
+// Write.
+func OpenSPI(o spidriver.Opener) (*spi.Device, spidriver.Conn, error) {
+	k := &spiOpener{Opener: o}
+	dev, err := spi.Open(k)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dev, k.conn, nil
+}
@@ -0,0 +1,70 @@
+package writev
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/goiot/devices/i2csim"
+)
+
+// conn records the transfers.
+type conn struct {
+	tx [][]byte
+}
+
+func (c *conn) Tx(w, r []byte) error {
+	c.tx = append(c.tx, append([]byte(nil), w...))
+	return nil
+}
+
+// vecConn records the segments written.
+type vecConn struct {
+	conn
+	segs [][]byte
+}
+
+func (c *vecConn) WriteVec(segs [][]byte) error {
+	c.segs = segs
+	return nil
+}
+
+func TestWrite(t *testing.T) {
+	c := &conn{}
+	for i := 0; i < 2; i++ {
+		if err := Write(c, []byte{0x40}, []byte{1, 2}, nil, []byte{3}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(c.tx) != 2 || !bytes.Equal(c.tx[0], []byte{0x40, 1, 2, 3}) || !bytes.Equal(c.tx[1], c.tx[0]) {
+		t.Errorf("transfers = %x; want 2 of 40010203", c.tx)
+	}
+
+	v := &vecConn{}
+	page := []byte{1, 2}
+	if err := Write(v, []byte{0x40}, page); err != nil {
+		t.Fatal(err)
+	}
+	if len(v.tx) != 0 || len(v.segs) != 2 || &v.segs[1][0] != &page[0] {
+		t.Errorf("transfers = %x, segments = %x; want the segments as they are", v.tx, v.segs)
+	}
+}
+
+func TestOpenI2C(t *testing.T) {
+	var got []byte
+	bus := i2csim.NewBus()
+	bus.Attach(0x3C, i2csim.DeviceFunc(func(w, r []byte) error {
+		got = append(got, w...)
+		return nil
+	}))
+	dev, conn, err := OpenI2C(bus, 0x3C)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Close()
+	if err := Write(conn, []byte{0x40}, []byte{0xAA, 0x55}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, []byte{0x40, 0xAA, 0x55}) {
+		t.Errorf("the device got %x; want 40aa55", got)
+	}
+}